@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package agent provides Go bindings for the public org.eclipse.bluechi.Agent
+// interface of the BlueChi agent running on the local managed node.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// StatusOnline is the Status of an agent connected to the controller.
+const StatusOnline = "online"
+
+// Agent is a proxy for the BlueChi agent on the local node.
+type Agent struct {
+	conn common.Connection
+	obj  dbus.BusObject
+}
+
+// Connect opens a connection to the system bus and returns a proxy for the
+// local agent.
+func Connect() (*Agent, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
+	}
+	return New(conn), nil
+}
+
+// New returns a proxy for the agent reachable on conn.
+func New(conn common.Connection) *Agent {
+	return &Agent{
+		conn: conn,
+		obj:  bus.Object(conn, common.BC_AGENT_DBUS_NAME, common.BC_OBJECT_PATH),
+	}
+}
+
+// Status returns the connection status of the agent with the controller,
+// either online or offline.
+func (a *Agent) Status(ctx context.Context) (string, error) {
+	return a.getStringProperty(ctx, "Status")
+}
+
+// IsConnected reports whether the agent is connected to the controller.
+func (a *Agent) IsConnected(ctx context.Context) (bool, error) {
+	status, err := a.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	return status == StatusOnline, nil
+}
+
+// LogLevel returns the log level currently used by the agent. The agent
+// interface does not allow changing it, use node.Node.SetLogLevel on the
+// controller instead.
+func (a *Agent) LogLevel(ctx context.Context) (string, error) {
+	return a.getStringProperty(ctx, "LogLevel")
+}
+
+// LogTarget returns the log target currently used by the agent.
+func (a *Agent) LogTarget(ctx context.Context) (string, error) {
+	return a.getStringProperty(ctx, "LogTarget")
+}
+
+// DisconnectTimestamp returns when the agent lost the connection to the
+// controller. The zero time is returned while the agent is connected. The
+// agent does not export the address of the controller it connects to.
+func (a *Agent) DisconnectTimestamp(ctx context.Context) (time.Time, error) {
+	v, err := a.getProperty(ctx, "DisconnectTimestamp")
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := variant.Uint64(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode agent property DisconnectTimestamp: %w", err)
+	}
+	if seconds == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+// HeartbeatInterval returns the interval in which the agent sends its
+// heartbeat to the controller, not above zero if it sends none. Agents not
+// exporting the HeartbeatInterval property yet, which includes all released
+// versions at the time of writing, fail with common.ErrUnknownProperty.
+func (a *Agent) HeartbeatInterval(ctx context.Context) (time.Duration, error) {
+	return a.getMillisecondsProperty(ctx, "HeartbeatInterval")
+}
+
+// ControllerHeartbeatThreshold returns how long the agent waits for the
+// heartbeat of the controller before it considers the connection lost,
+// like HeartbeatInterval.
+func (a *Agent) ControllerHeartbeatThreshold(ctx context.Context) (time.Duration, error) {
+	return a.getMillisecondsProperty(ctx, "ControllerHeartbeatThreshold")
+}
+
+// SetHeartbeatInterval changes the interval in which the agent sends its
+// heartbeat at runtime, in whole milliseconds, e.g. to keep it consistent
+// with the stale threshold of a manager.HealthChecker. It fails with
+// common.ErrUnknownProperty or common.ErrPropertyReadOnly for agents which
+// do not support changing it.
+func (a *Agent) SetHeartbeatInterval(ctx context.Context, interval time.Duration) error {
+	return a.setMillisecondsProperty(ctx, "HeartbeatInterval", interval)
+}
+
+// SetControllerHeartbeatThreshold changes how long the agent waits for the
+// heartbeat of the controller at runtime, like SetHeartbeatInterval.
+func (a *Agent) SetControllerHeartbeatThreshold(ctx context.Context, threshold time.Duration) error {
+	return a.setMillisecondsProperty(ctx, "ControllerHeartbeatThreshold", threshold)
+}
+
+func (a *Agent) getMillisecondsProperty(ctx context.Context, name string) (time.Duration, error) {
+	v, err := a.getProperty(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	d, err := variant.Milliseconds(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode agent property %s: %w", name, err)
+	}
+	return d, nil
+}
+
+func (a *Agent) setMillisecondsProperty(ctx context.Context, name string, d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("failed to set agent property %s: negative duration %s: %w", name, d, common.ErrInvalidArgs)
+	}
+	if err := bus.SetProperty(ctx, a.obj, common.AGENT_INTERFACE, name, uint64(d.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to set agent property %s: %w", name, err)
+	}
+	return nil
+}
+
+func (a *Agent) getProperty(ctx context.Context, name string) (dbus.Variant, error) {
+	v, err := bus.GetProperty(ctx, a.obj, common.AGENT_INTERFACE, name)
+	if err != nil {
+		return dbus.Variant{}, fmt.Errorf("failed to get agent property %s: %w", name, err)
+	}
+	return v, nil
+}
+
+func (a *Agent) getStringProperty(ctx context.Context, name string) (string, error) {
+	v, err := a.getProperty(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	s, err := variant.String(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode agent property %s: %w", name, err)
+	}
+	return s, nil
+}
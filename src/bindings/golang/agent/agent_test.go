@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package agent_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/agent"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+)
+
+// fakeAgent records the proxies created on the agent.
+type fakeAgent struct {
+	mu      sync.Mutex
+	proxies map[string]bool
+}
+
+func (a *fakeAgent) CreateProxy(localService string, node string, unit string) *dbus.Error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.proxies[localService+" "+node+" "+unit] = true
+	return nil
+}
+
+func (a *fakeAgent) RemoveProxy(localService string, node string, unit string) *dbus.Error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := localService + " " + node + " " + unit
+	if !a.proxies[key] {
+		return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"no such proxy"})
+	}
+	delete(a.proxies, key)
+	return nil
+}
+
+// startAgent serves a fake agent on a private bus and returns the bus
+// address, the agent and its properties.
+func startAgent(t *testing.T) (string, *fakeAgent, *prop.Properties) {
+	address := testbus.Start(t)
+	conn := testbus.Connect(t, address)
+	fake := &fakeAgent{proxies: make(map[string]bool)}
+	if err := conn.Export(fake, common.BC_OBJECT_PATH, common.AGENT_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+	props, err := prop.Export(conn, common.BC_OBJECT_PATH, prop.Map{
+		common.AGENT_INTERFACE: {
+			"Status":              {Value: agent.StatusOnline},
+			"LogLevel":            {Value: "INFO"},
+			"LogTarget":           {Value: "journald"},
+			"DisconnectTimestamp": {Value: uint64(0)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testbus.RequestName(t, conn, common.BC_AGENT_DBUS_NAME)
+	return address, fake, props
+}
+
+func TestAgent(t *testing.T) {
+	ctx := context.Background()
+	address, _, props := startAgent(t)
+	a := agent.New(testbus.Connect(t, address))
+
+	if connected, err := a.IsConnected(ctx); err != nil || !connected {
+		t.Fatalf("expected the agent to be connected, got %v, %v", connected, err)
+	}
+	if level, err := a.LogLevel(ctx); err != nil || level != "INFO" {
+		t.Fatalf("expected log level INFO, got %q, %v", level, err)
+	}
+	if target, err := a.LogTarget(ctx); err != nil || target != "journald" {
+		t.Fatalf("expected log target journald, got %q, %v", target, err)
+	}
+	if ts, err := a.DisconnectTimestamp(ctx); err != nil || !ts.IsZero() {
+		t.Fatalf("expected no disconnect timestamp, got %v, %v", ts, err)
+	}
+
+	disconnected := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	props.SetMust(common.AGENT_INTERFACE, "Status", "offline")
+	props.SetMust(common.AGENT_INTERFACE, "DisconnectTimestamp", uint64(disconnected.Unix()))
+	if connected, err := a.IsConnected(ctx); err != nil || connected {
+		t.Fatalf("expected the agent to be disconnected, got %v, %v", connected, err)
+	}
+	if ts, err := a.DisconnectTimestamp(ctx); err != nil || !ts.Equal(disconnected) {
+		t.Fatalf("expected disconnect timestamp %v, got %v, %v", disconnected, ts, err)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	ctx := context.Background()
+	address, fake, _ := startAgent(t)
+	a := agent.New(testbus.Connect(t, address))
+
+	if err := a.CreateProxy(ctx, "web.service", "node_b", "db.service"); err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	created := fake.proxies["web.service node_b db.service"]
+	fake.mu.Unlock()
+	if !created {
+		t.Fatal("expected the proxy to be created")
+	}
+	if err := a.RemoveProxy(ctx, "web.service", "node_b", "db.service"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RemoveProxy(ctx, "web.service", "node_b", "db.service"); err == nil {
+		t.Fatal("expected an error removing the proxy twice")
+	}
+}
+
+func TestAgentNotRunning(t *testing.T) {
+	a := agent.New(testbus.Connect(t, testbus.Start(t)))
+	if _, err := a.Status(context.Background()); err == nil {
+		t.Fatal("expected an error without agent")
+	}
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// SupportsProxies reports whether the agent implements CreateProxy and
+// RemoveProxy.
+func (a *Agent) SupportsProxies(ctx context.Context) (bool, error) {
+	i, err := common.Introspect(ctx, a.obj)
+	if err != nil {
+		return false, err
+	}
+	return i.HasMethod(common.AGENT_INTERFACE, "CreateProxy") && i.HasMethod(common.AGENT_INTERFACE, "RemoveProxy"), nil
+}
+
+// CreateProxy declares that localService on this node depends on unit
+// running on node. The agent creates a proxy service which starts unit on
+// the other node and mirrors its state, the same as a dependency on
+// bluechi-proxy@node_unit.service in the unit file of localService.
+func (a *Agent) CreateProxy(ctx context.Context, localService string, node string, unit string) error {
+	err := bus.Exec(ctx, a.obj, common.METHOD_AGENT_CREATE_PROXY, localService, node, unit)
+	if err != nil {
+		return fmt.Errorf("failed to create proxy for unit %s on node %s: %w", unit, node, err)
+	}
+	return nil
+}
+
+// RemoveProxy removes a proxy service previously created by CreateProxy for
+// the same arguments.
+func (a *Agent) RemoveProxy(ctx context.Context, localService string, node string, unit string) error {
+	err := bus.Exec(ctx, a.obj, common.METHOD_AGENT_REMOVE_PROXY, localService, node, unit)
+	if err != nil {
+		return fmt.Errorf("failed to remove proxy for unit %s on node %s: %w", unit, node, err)
+	}
+	return nil
+}
+
+// proxyServicePrefix is the prefix of the instances of the
+// bluechi-proxy@.service template created by CreateProxy.
+const proxyServicePrefix = "bluechi-proxy@"
+
+// ProxyServiceName returns the name of the proxy service mirroring unit on
+// node, e.g. bluechi-proxy@node1_db.service.service, which local services
+// depend on to depend on the remote unit.
+func ProxyServiceName(node string, unit string) string {
+	return proxyServicePrefix + node + "_" + unit + ".service"
+}
+
+// parseProxyServiceName returns the node and unit of the proxy service
+// name. The node name ends at the first underscore, the unit name may
+// contain further ones.
+func parseProxyServiceName(name string) (string, string, bool) {
+	instance, ok := strings.CutPrefix(name, proxyServicePrefix)
+	if !ok {
+		return "", "", false
+	}
+	instance, ok = strings.CutSuffix(instance, ".service")
+	if !ok {
+		return "", "", false
+	}
+	node, unit, ok := strings.Cut(instance, "_")
+	return node, unit, ok && node != "" && unit != ""
+}
+
+// ProxyState is the state of a proxy service on the local node, which
+// mirrors the state of the unit on the other node once the controller
+// resolved the dependency.
+type ProxyState struct {
+	// Node is the node the unit runs on.
+	Node string
+	// Unit is the name of the unit on Node.
+	Unit string
+	// Service is the name of the local proxy service.
+	Service string
+	// ActiveState is the active state of the proxy service. It is active
+	// while the unit is active on Node.
+	ActiveState node.ActiveState
+	// SubState is the sub state of the proxy service.
+	SubState node.SubState
+}
+
+// IsResolved reports whether the unit is active on the other node, i.e. the
+// local services depending on it can run.
+func (s ProxyState) IsResolved() bool {
+	return s.ActiveState.IsActive()
+}
+
+// ProxyState returns the state of the proxy service for unit on node from
+// systemd on the local node. An error matching common.ErrNoSuchUnit is
+// returned if no local service depends on the unit.
+func (a *Agent) ProxyState(ctx context.Context, node string, unit string) (ProxyState, error) {
+	s := ProxyState{Node: node, Unit: unit, Service: ProxyServiceName(node, unit)}
+	path, err := bus.Call[dbus.ObjectPath](ctx, a.systemd(), common.METHOD_SYSTEMD_GET_UNIT, s.Service)
+	if err != nil {
+		return ProxyState{}, fmt.Errorf("failed to get proxy for unit %s on node %s: %w", unit, node, err)
+	}
+
+	obj := bus.Object(a.conn, common.SYSTEMD_BUS_NAME, path)
+	for name, field := range map[string]*string{"ActiveState": (*string)(&s.ActiveState), "SubState": (*string)(&s.SubState)} {
+		v, err := bus.GetProperty(ctx, obj, common.SYSTEMD_UNIT_INTERFACE, name)
+		if err == nil {
+			*field, err = variant.String(v)
+		}
+		if err != nil {
+			return ProxyState{}, fmt.Errorf("failed to get proxy for unit %s on node %s: %w", unit, node, err)
+		}
+	}
+	return s, nil
+}
+
+// systemdUnit is an entry of ListUnitsByPatterns of systemd.
+type systemdUnit struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	Path        dbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     dbus.ObjectPath
+}
+
+// Proxies returns the states of all proxy services loaded on the local
+// node, i.e. the remote units local services depend on, sorted by service
+// name.
+func (a *Agent) Proxies(ctx context.Context) ([]ProxyState, error) {
+	units, err := bus.Call[[]systemdUnit](ctx, a.systemd(), common.METHOD_SYSTEMD_LIST_UNITS_BY_PATTERNS,
+		[]string{}, []string{proxyServicePrefix + "*"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxies: %w", err)
+	}
+	proxies := make([]ProxyState, 0, len(units))
+	for _, u := range units {
+		n, unit, ok := parseProxyServiceName(u.Name)
+		if !ok {
+			continue
+		}
+		proxies = append(proxies, ProxyState{
+			Node:        n,
+			Unit:        unit,
+			Service:     u.Name,
+			ActiveState: node.ActiveState(u.ActiveState),
+			SubState:    node.SubState(u.SubState),
+		})
+	}
+	sort.Slice(proxies, func(i, j int) bool { return proxies[i].Service < proxies[j].Service })
+	return proxies, nil
+}
+
+func (a *Agent) systemd() dbus.BusObject {
+	return bus.Object(a.conn, common.SYSTEMD_BUS_NAME, common.SYSTEMD_OBJECT_PATH)
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/agent"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// fakeSystemd serves the proxy services of the local node.
+type fakeSystemd struct {
+	units map[string][2]string
+}
+
+type fakeSystemdUnit struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	Path        dbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     dbus.ObjectPath
+}
+
+// unitPath returns the object path of the unit, escaping the characters
+// not allowed in object paths roughly as systemd does.
+func unitPath(name string) dbus.ObjectPath {
+	escaped := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	return dbus.ObjectPath("/org/freedesktop/systemd1/unit/" + escaped)
+}
+
+func (s *fakeSystemd) GetUnit(name string) (dbus.ObjectPath, *dbus.Error) {
+	if _, ok := s.units[name]; !ok {
+		return "", dbus.NewError(common.ERROR_SYSTEMD_NO_SUCH_UNIT, []interface{}{"Unit " + name + " not loaded."})
+	}
+	return unitPath(name), nil
+}
+
+func (s *fakeSystemd) ListUnitsByPatterns(states []string, patterns []string) ([]fakeSystemdUnit, *dbus.Error) {
+	var units []fakeSystemdUnit
+	for name, state := range s.units {
+		units = append(units, fakeSystemdUnit{Name: name, LoadState: "loaded", ActiveState: state[0], SubState: state[1], Path: unitPath(name), JobPath: "/"})
+	}
+	return units, nil
+}
+
+func startSystemd(t *testing.T, units map[string][2]string) string {
+	address := testbus.Start(t)
+	conn := testbus.Connect(t, address)
+	if err := conn.Export(&fakeSystemd{units: units}, common.SYSTEMD_OBJECT_PATH, common.SYSTEMD_MANAGER_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+	for name, state := range units {
+		_, err := prop.Export(conn, unitPath(name), prop.Map{
+			common.SYSTEMD_UNIT_INTERFACE: {
+				"ActiveState": {Value: state[0]},
+				"SubState":    {Value: state[1]},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	testbus.RequestName(t, conn, common.SYSTEMD_BUS_NAME)
+	return address
+}
+
+func TestProxyState(t *testing.T) {
+	ctx := context.Background()
+	address := startSystemd(t, map[string][2]string{
+		agent.ProxyServiceName("node1", "db.service"):      {"active", "running"},
+		agent.ProxyServiceName("node2", "cache_a.service"): {"activating", "start"},
+		"bluechi-proxy@invalid.service":                    {"failed", "failed"},
+	})
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	a := agent.New(conn)
+
+	if name := agent.ProxyServiceName("node1", "db.service"); name != "bluechi-proxy@node1_db.service.service" {
+		t.Fatalf("unexpected proxy service name %s", name)
+	}
+
+	s, err := a.ProxyState(ctx, "node1", "db.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsResolved() || s.SubState != node.SubStateRunning {
+		t.Fatalf("expected a resolved proxy, got %+v", s)
+	}
+	if _, err := a.ProxyState(ctx, "node3", "db.service"); !errors.Is(err, common.ErrNoSuchUnit) {
+		t.Fatalf("expected common.ErrNoSuchUnit, got %v", err)
+	}
+
+	proxies, err := a.Proxies(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proxies) != 2 || proxies[0].Node != "node1" || proxies[1].Unit != "cache_a.service" || proxies[1].IsResolved() {
+		t.Fatalf("unexpected proxies %+v", proxies)
+	}
+}
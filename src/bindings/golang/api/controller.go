@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Code generated by internal/gen from org.eclipse.bluechi.Controller.xml. DO NOT EDIT.
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// ControllerInterface is the name of the org.eclipse.bluechi.Controller interface.
+const ControllerInterface = "org.eclipse.bluechi.Controller"
+
+// Controller is a proxy for the org.eclipse.bluechi.Controller interface.
+//
+// This interface can be used to get information about all nodes and their
+// units, create monitors and listen for job signals.
+type Controller struct {
+	obj dbus.BusObject
+}
+
+// NewController returns a proxy for the org.eclipse.bluechi.Controller
+// interface of the object at path.
+func NewController(conn common.Connection, path dbus.ObjectPath) *Controller {
+	return &Controller{obj: bus.Object(conn, common.BC_DBUS_NAME, path)}
+}
+
+// ObjectPath returns the path of the object.
+func (p *Controller) ObjectPath() dbus.ObjectPath {
+	return p.obj.Path()
+}
+
+// ListUnits calls the ListUnits method.
+//
+// List all loaded systemd units on all nodes which are online.
+//
+//   - units: A list of all units on each node
+func (p *Controller) ListUnits(ctx context.Context) ([]ControllerListUnitsUnit, error) {
+	units, err := bus.Call[[]ControllerListUnitsUnit](ctx, p.obj, ControllerInterface+".ListUnits")
+	if err != nil {
+		return units, fmt.Errorf("failed to call org.eclipse.bluechi.Controller.ListUnits: %w", err)
+	}
+	return units, nil
+}
+
+// ListNodes calls the ListNodes method.
+//
+// List all nodes managed by BlueChi regardless if they are offline or online.
+//
+//   - nodes: A list of all nodes
+func (p *Controller) ListNodes(ctx context.Context) ([]ControllerListNodesNode, error) {
+	nodes, err := bus.Call[[]ControllerListNodesNode](ctx, p.obj, ControllerInterface+".ListNodes")
+	if err != nil {
+		return nodes, fmt.Errorf("failed to call org.eclipse.bluechi.Controller.ListNodes: %w", err)
+	}
+	return nodes, nil
+}
+
+// GetNode calls the GetNode method.
+//
+// Get the object path of the named node.
+//
+//   - name: Name of the node
+//   - path: The path of the requested node
+func (p *Controller) GetNode(ctx context.Context, name string) (dbus.ObjectPath, error) {
+	path, err := bus.Call[dbus.ObjectPath](ctx, p.obj, ControllerInterface+".GetNode", name)
+	if err != nil {
+		return path, fmt.Errorf("failed to call org.eclipse.bluechi.Controller.GetNode: %w", err)
+	}
+	return path, nil
+}
+
+// CreateMonitor calls the CreateMonitor method.
+//
+// Create a new monitor on which subscriptions can be added. It will
+// automatically be closed as soon as the connection is closed.
+//
+//   - monitor: The path of the created monitor.
+func (p *Controller) CreateMonitor(ctx context.Context) (dbus.ObjectPath, error) {
+	monitor, err := bus.Call[dbus.ObjectPath](ctx, p.obj, ControllerInterface+".CreateMonitor")
+	if err != nil {
+		return monitor, fmt.Errorf("failed to call org.eclipse.bluechi.Controller.CreateMonitor: %w", err)
+	}
+	return monitor, nil
+}
+
+// EnableMetrics calls the EnableMetrics method.
+//
+// Enable collecting performance metrics.
+func (p *Controller) EnableMetrics(ctx context.Context) error {
+	err := bus.Exec(ctx, p.obj, ControllerInterface+".EnableMetrics")
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Controller.EnableMetrics: %w", err)
+	}
+	return nil
+}
+
+// DisableMetrics calls the DisableMetrics method.
+//
+// Disable collecting performance metrics.
+func (p *Controller) DisableMetrics(ctx context.Context) error {
+	err := bus.Exec(ctx, p.obj, ControllerInterface+".DisableMetrics")
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Controller.DisableMetrics: %w", err)
+	}
+	return nil
+}
+
+// SetLogLevel calls the SetLogLevel method.
+//
+// Change the loglevel of the controller.
+//
+//   - loglevel: The new loglevel to use.
+func (p *Controller) SetLogLevel(ctx context.Context, loglevel string) error {
+	err := bus.Exec(ctx, p.obj, ControllerInterface+".SetLogLevel", loglevel)
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Controller.SetLogLevel: %w", err)
+	}
+	return nil
+}
+
+// Status returns the Status property.
+//
+// The status of the overall system. Its value is one of: down: no node is
+// connected degraded: at least one node is not connected up: all nodes listed
+// in the AllowedNodeNames config are connected A signal is emitted on the
+// org.freedesktop.DBus.Properties interface each time the system state changes.
+// Therefore, a (dis-)connecting node doesn't necessarily result in a signal to
+// be emitted. For this puprose, the Status property on the
+// org.eclipse.bluechi.Node interface is a better choice.
+func (p *Controller) Status(ctx context.Context) (string, error) {
+	var value string
+	v, err := bus.GetProperty(ctx, p.obj, ControllerInterface, "Status")
+	if err == nil {
+		err = v.Store(&value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to get property Status of org.eclipse.bluechi.Controller: %w", err)
+	}
+	return value, nil
+}
+
+// LogLevel returns the LogLevel property.
+//
+// The LogLevel of the controller node that is currently used. Its value is one
+// of: INFO, DEBUG, ERROR and WARN
+func (p *Controller) LogLevel(ctx context.Context) (string, error) {
+	var value string
+	v, err := bus.GetProperty(ctx, p.obj, ControllerInterface, "LogLevel")
+	if err == nil {
+		err = v.Store(&value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to get property LogLevel of org.eclipse.bluechi.Controller: %w", err)
+	}
+	return value, nil
+}
+
+// LogTarget returns the LogTarget property.
+//
+// The LogTarget of the controller node that is currently used. Its value is one
+// of: stderr, stderr-full, journald
+func (p *Controller) LogTarget(ctx context.Context) (string, error) {
+	var value string
+	v, err := bus.GetProperty(ctx, p.obj, ControllerInterface, "LogTarget")
+	if err == nil {
+		err = v.Store(&value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to get property LogTarget of org.eclipse.bluechi.Controller: %w", err)
+	}
+	return value, nil
+}
+
+// ControllerJobNewSignal is the name of the JobNew signal of
+// org.eclipse.bluechi.Controller.
+const ControllerJobNewSignal = ControllerInterface + ".JobNew"
+
+// ControllerJobNew is the JobNew signal of org.eclipse.bluechi.Controller.
+//
+// Emitted each time a new BlueChi job is queued.
+type ControllerJobNew struct {
+	// The id of the new job
+	ID uint32
+
+	// The path of the job
+	Job dbus.ObjectPath
+}
+
+// DecodeControllerJobNew decodes a JobNew signal.
+func DecodeControllerJobNew(sig *dbus.Signal) (ControllerJobNew, error) {
+	var s ControllerJobNew
+	if sig.Name != ControllerJobNewSignal {
+		return s, fmt.Errorf("unexpected signal %s, expected %s", sig.Name, ControllerJobNewSignal)
+	}
+	if err := dbus.Store(sig.Body, &s.ID, &s.Job); err != nil {
+		return s, fmt.Errorf("failed to decode signal %s: %w", sig.Name, err)
+	}
+	return s, nil
+}
+
+// ControllerJobRemovedSignal is the name of the JobRemoved signal of
+// org.eclipse.bluechi.Controller.
+const ControllerJobRemovedSignal = ControllerInterface + ".JobRemoved"
+
+// ControllerJobRemoved is the JobRemoved signal of
+// org.eclipse.bluechi.Controller.
+//
+// Emitted each time a new job is dequeued or the underlying systemd job
+// finished. result is one of: done, failed, cancelled, timeout, dependency,
+// skipped. This is either the result from systemd on the node, or cancelled if
+// the job was cancelled in BlueChi before any systemd job was started for it.
+type ControllerJobRemoved struct {
+	// The id of the new job
+	ID uint32
+
+	// The path of the job
+	Job dbus.ObjectPath
+
+	// The name of the node the job has been completed on
+	Node string
+
+	// The name of the unit the job has been completed on
+	Unit string
+
+	// The result of the job
+	Result string
+}
+
+// DecodeControllerJobRemoved decodes a JobRemoved signal.
+func DecodeControllerJobRemoved(sig *dbus.Signal) (ControllerJobRemoved, error) {
+	var s ControllerJobRemoved
+	if sig.Name != ControllerJobRemovedSignal {
+		return s, fmt.Errorf("unexpected signal %s, expected %s", sig.Name, ControllerJobRemovedSignal)
+	}
+	if err := dbus.Store(sig.Body, &s.ID, &s.Job, &s.Node, &s.Unit, &s.Result); err != nil {
+		return s, fmt.Errorf("failed to decode signal %s: %w", sig.Name, err)
+	}
+	return s, nil
+}
+
+// ControllerListUnitsUnit is the D-Bus struct (sssssssouso). A list of all
+// units on each node.
+type ControllerListUnitsUnit struct {
+	// The node name
+	Field1 string
+	// The primary unit name as string
+	Field2 string
+	// The human readable description string
+	Field3 string
+	// The load state (i.e. whether the unit file has been loaded successfully)
+	Field4 string
+	// The active state (i.e. whether the unit is currently started or not)
+	Field5 string
+	// The sub state (a more fine-grained version of the active state that is
+	// specific to the unit type, which the active state is not)
+	Field6 string
+	// A unit that is being followed in its state by this unit, if there is any,
+	// otherwise the empty string.
+	Field7 string
+	// The unit object path
+	Field8 dbus.ObjectPath
+	// If there is a job queued for the job unit the numeric job id, 0 otherwise
+	Field9 uint32
+	// The job type as string
+	Field10 string
+	// The job object path
+	Field11 dbus.ObjectPath
+}
+
+// ControllerListNodesNode is the D-Bus struct (sos). A list of all nodes.
+type ControllerListNodesNode struct {
+	// The node name
+	Field1 string
+	// The object path of the node
+	Field2 dbus.ObjectPath
+	// the current state of that node, either online or offline
+	Field3 string
+}
@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package api provides typed wrappers for the public BlueChi D-Bus
+// interfaces, generated from the introspection XML files in the data
+// directory of the BlueChi repository.
+//
+// The wrappers are thin: every method calls the D-Bus method of the same
+// name and converts its arguments, signals are decoded with the Decode
+// functions. The hand-written packages such as manager and node build on
+// top of the same interfaces and add subscriptions, reconnects and helpers.
+package api
+
+//go:generate go run ../internal/gen -in ../../../../data -out .
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Code generated by internal/gen from org.eclipse.bluechi.Metrics.xml. DO NOT EDIT.
+
+package api
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// MetricsInterface is the name of the org.eclipse.bluechi.Metrics interface.
+const MetricsInterface = "org.eclipse.bluechi.Metrics"
+
+// Metrics is a proxy for the org.eclipse.bluechi.Metrics interface.
+//
+// This interface is only available if the metrics have been enabled before via
+// the Controller interface.
+type Metrics struct {
+	obj dbus.BusObject
+}
+
+// NewMetrics returns a proxy for the org.eclipse.bluechi.Metrics interface of
+// the object at path.
+func NewMetrics(conn common.Connection, path dbus.ObjectPath) *Metrics {
+	return &Metrics{obj: bus.Object(conn, common.BC_DBUS_NAME, path)}
+}
+
+// ObjectPath returns the path of the object.
+func (p *Metrics) ObjectPath() dbus.ObjectPath {
+	return p.obj.Path()
+}
+
+// MetricsStartUnitJobMetricsSignal is the name of the StartUnitJobMetrics
+// signal of org.eclipse.bluechi.Metrics.
+const MetricsStartUnitJobMetricsSignal = MetricsInterface + ".StartUnitJobMetrics"
+
+// MetricsStartUnitJobMetrics is the StartUnitJobMetrics signal of
+// org.eclipse.bluechi.Metrics.
+//
+// Emitted when a start operation processed by BlueChi finishes and the
+// collection of metrics has been enabled previously.
+type MetricsStartUnitJobMetrics struct {
+	// The node name this metrics has been collected for
+	NodeName string
+
+	// The id of the job linked to the collected metrics
+	JobID string
+
+	// The unit name this metrics has been collected for
+	Unit string
+
+	// The measured time it took starting the unit on the node in microseconds
+	JobMeasuredTimeMicros uint64
+
+	// The systemd time it took starting the unit on the node in microseconds
+	UnitStartPropTimeMicros uint64
+}
+
+// DecodeMetricsStartUnitJobMetrics decodes a StartUnitJobMetrics signal.
+func DecodeMetricsStartUnitJobMetrics(sig *dbus.Signal) (MetricsStartUnitJobMetrics, error) {
+	var s MetricsStartUnitJobMetrics
+	if sig.Name != MetricsStartUnitJobMetricsSignal {
+		return s, fmt.Errorf("unexpected signal %s, expected %s", sig.Name, MetricsStartUnitJobMetricsSignal)
+	}
+	if err := dbus.Store(sig.Body, &s.NodeName, &s.JobID, &s.Unit, &s.JobMeasuredTimeMicros, &s.UnitStartPropTimeMicros); err != nil {
+		return s, fmt.Errorf("failed to decode signal %s: %w", sig.Name, err)
+	}
+	return s, nil
+}
+
+// MetricsAgentJobMetricsSignal is the name of the AgentJobMetrics signal of
+// org.eclipse.bluechi.Metrics.
+const MetricsAgentJobMetricsSignal = MetricsInterface + ".AgentJobMetrics"
+
+// MetricsAgentJobMetrics is the AgentJobMetrics signal of
+// org.eclipse.bluechi.Metrics.
+//
+// Emitted for all unit lifecycle operations (e.g. Start, Stop, Reload, etc.)
+// processed by BlueChi when these finish and the collection of metrics has been
+// enabled previously.
+type MetricsAgentJobMetrics struct {
+	// The node name this metrics has been collected for
+	NodeName string
+
+	// The unit name this metrics has been collected for
+	Unit string
+
+	// The lifecycle operation
+	Method string
+
+	// The systemd time it took starting the unit on the node in microseconds
+	SystemdJobTimeMicros uint64
+}
+
+// DecodeMetricsAgentJobMetrics decodes a AgentJobMetrics signal.
+func DecodeMetricsAgentJobMetrics(sig *dbus.Signal) (MetricsAgentJobMetrics, error) {
+	var s MetricsAgentJobMetrics
+	if sig.Name != MetricsAgentJobMetricsSignal {
+		return s, fmt.Errorf("unexpected signal %s, expected %s", sig.Name, MetricsAgentJobMetricsSignal)
+	}
+	if err := dbus.Store(sig.Body, &s.NodeName, &s.Unit, &s.Method, &s.SystemdJobTimeMicros); err != nil {
+		return s, fmt.Errorf("failed to decode signal %s: %w", sig.Name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,360 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Code generated by internal/gen from org.eclipse.bluechi.Node.xml. DO NOT EDIT.
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// NodeInterface is the name of the org.eclipse.bluechi.Node interface.
+const NodeInterface = "org.eclipse.bluechi.Node"
+
+// Node is a proxy for the org.eclipse.bluechi.Node interface.
+//
+// This interface can be used to get information about a specific node and its
+// units as well as control them, e.g. by starting or stopping them.
+type Node struct {
+	obj dbus.BusObject
+}
+
+// NewNode returns a proxy for the org.eclipse.bluechi.Node interface of the
+// object at path.
+func NewNode(conn common.Connection, path dbus.ObjectPath) *Node {
+	return &Node{obj: bus.Object(conn, common.BC_DBUS_NAME, path)}
+}
+
+// ObjectPath returns the path of the object.
+func (p *Node) ObjectPath() dbus.ObjectPath {
+	return p.obj.Path()
+}
+
+// StartUnit calls the StartUnit method.
+//
+// Queues a unit activate job for the named unit on this node. The queue is
+// per-unit name, which means there is only ever one active job per unit. Mode
+// can be one of replace or fail. If there is an outstanding queued (but not
+// running) job, that is replaced if mode is replace, or the job fails if mode
+// is fail.
+//
+// The job returned is an object path for an object implementing
+// org.eclipse.bluechi.Job, and which be monitored for the progress of the job,
+// or used to cancel the job. To track the result of the job, follow the
+// JobRemoved signal on the Controller.
+//
+//   - name: The name of the unit to start
+//   - mode: The mode used to start the unit
+//   - job: The path for the job associated with the start operation
+func (p *Node) StartUnit(ctx context.Context, name string, mode string) (dbus.ObjectPath, error) {
+	job, err := bus.Call[dbus.ObjectPath](ctx, p.obj, NodeInterface+".StartUnit", name, mode)
+	if err != nil {
+		return job, fmt.Errorf("failed to call org.eclipse.bluechi.Node.StartUnit: %w", err)
+	}
+	return job, nil
+}
+
+// StopUnit calls the StopUnit method.
+//
+// StopUnit() is similar to StartUnit() but stops the specified unit rather than
+// starting it.
+//
+//   - name: The name of the unit to stop
+//   - mode: The mode used to stop the unit
+//   - job: The path for the job associated with the stop operation
+func (p *Node) StopUnit(ctx context.Context, name string, mode string) (dbus.ObjectPath, error) {
+	job, err := bus.Call[dbus.ObjectPath](ctx, p.obj, NodeInterface+".StopUnit", name, mode)
+	if err != nil {
+		return job, fmt.Errorf("failed to call org.eclipse.bluechi.Node.StopUnit: %w", err)
+	}
+	return job, nil
+}
+
+// FreezeUnit calls the FreezeUnit method.
+//
+// Freezing the unit will cause all processes contained within the cgroup
+// corresponding to the unit to be suspended. Being suspended means that unit's
+// processes won't be scheduled to run on CPU until thawed.
+//
+//   - name: The name of the unit to freeze
+func (p *Node) FreezeUnit(ctx context.Context, name string) error {
+	err := bus.Exec(ctx, p.obj, NodeInterface+".FreezeUnit", name)
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Node.FreezeUnit: %w", err)
+	}
+	return nil
+}
+
+// ThawUnit calls the ThawUnit method.
+//
+// This is the inverse operation to the freeze command and resumes the execution
+// of processes in the unit's cgroup.
+//
+//   - name: The name of the unit to thaw
+func (p *Node) ThawUnit(ctx context.Context, name string) error {
+	err := bus.Exec(ctx, p.obj, NodeInterface+".ThawUnit", name)
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Node.ThawUnit: %w", err)
+	}
+	return nil
+}
+
+// ReloadUnit calls the ReloadUnit method.
+//
+// ReloadUnit() is similar to StartUnit() but can be used to reload a unit
+// instead. See equivalent systemd methods for details.
+//
+//   - name: The name of the unit to reload
+//   - mode: The mode used to reload the unit
+//   - job: The path for the job associated with the reload operation
+func (p *Node) ReloadUnit(ctx context.Context, name string, mode string) (dbus.ObjectPath, error) {
+	job, err := bus.Call[dbus.ObjectPath](ctx, p.obj, NodeInterface+".ReloadUnit", name, mode)
+	if err != nil {
+		return job, fmt.Errorf("failed to call org.eclipse.bluechi.Node.ReloadUnit: %w", err)
+	}
+	return job, nil
+}
+
+// RestartUnit calls the RestartUnit method.
+//
+// RestartUnit() is similar to StartUnit() but can be used to restart a unit
+// instead. See equivalent systemd methods for details.
+//
+//   - name: The name of the unit to restart
+//   - mode: The mode used to restart the unit
+//   - job: The path for the job associated with the restart operation
+func (p *Node) RestartUnit(ctx context.Context, name string, mode string) (dbus.ObjectPath, error) {
+	job, err := bus.Call[dbus.ObjectPath](ctx, p.obj, NodeInterface+".RestartUnit", name, mode)
+	if err != nil {
+		return job, fmt.Errorf("failed to call org.eclipse.bluechi.Node.RestartUnit: %w", err)
+	}
+	return job, nil
+}
+
+// GetUnitProperties calls the GetUnitProperties method.
+//
+// Returns the current for a named unit on the node. The returned are the same
+// as you would get in the systemd apis.
+//
+//   - name: The name of unit
+//   - iface: The interface name
+//   - props: The as key-value pair with the name of the property as key
+func (p *Node) GetUnitProperties(ctx context.Context, name string, iface string) (map[string]dbus.Variant, error) {
+	props, err := bus.Call[map[string]dbus.Variant](ctx, p.obj, NodeInterface+".GetUnitProperties", name, iface)
+	if err != nil {
+		return props, fmt.Errorf("failed to call org.eclipse.bluechi.Node.GetUnitProperties: %w", err)
+	}
+	return props, nil
+}
+
+// GetUnitProperty calls the GetUnitProperty method.
+//
+// Get one named property, otherwise similar to GetUnit.
+//
+//   - name: The name of unit
+//   - iface: The interface name
+//   - property: The property name
+//   - value: The value of the property
+func (p *Node) GetUnitProperty(ctx context.Context, name string, iface string, property string) (dbus.Variant, error) {
+	value, err := bus.Call[dbus.Variant](ctx, p.obj, NodeInterface+".GetUnitProperty", name, iface, property)
+	if err != nil {
+		return value, fmt.Errorf("failed to call org.eclipse.bluechi.Node.GetUnitProperty: %w", err)
+	}
+	return value, nil
+}
+
+// SetUnitProperties calls the SetUnitProperties method.
+//
+// Set named . If runtime is true the property changes do not persist across
+// reboots.
+//
+//   - name: The name of the unit
+//   - runtime: Specify if the changes should persist after reboot or not
+//   - keyvalues: A list of the new values as key-value pair with the key being
+//     the name of the property
+func (p *Node) SetUnitProperties(ctx context.Context, name string, runtime bool, keyvalues []NodeSetUnitPropertiesKeyvalue) error {
+	err := bus.Exec(ctx, p.obj, NodeInterface+".SetUnitProperties", name, runtime, keyvalues)
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Node.SetUnitProperties: %w", err)
+	}
+	return nil
+}
+
+// EnableUnitFiles calls the EnableUnitFiles method.
+//
+// EnableUnitFiles() may be used to enable one or more units in the system (by
+// creating symlinks to them in /etc/ or /run/).
+//
+//   - files: A list of units to enable
+//   - runtime: Specify if the changes should persist after reboot or not
+//   - force: Specify if replacing the symlinks pointing to other units should
+//     be enforced
+//   - carriesInstallInfo: True if the units contained enablement information
+//   - changes: The changes made
+func (p *Node) EnableUnitFiles(ctx context.Context, files []string, runtime bool, force bool) (bool, []NodeEnableUnitFilesChange, error) {
+	var carriesInstallInfo bool
+	var changes []NodeEnableUnitFilesChange
+	err := p.obj.CallWithContext(ctx, NodeInterface+".EnableUnitFiles", 0, files, runtime, force).Store(&carriesInstallInfo, &changes)
+	if err != nil {
+		return carriesInstallInfo, changes, fmt.Errorf("failed to call org.eclipse.bluechi.Node.EnableUnitFiles: %w", err)
+	}
+	return carriesInstallInfo, changes, nil
+}
+
+// DisableUnitFiles calls the DisableUnitFiles method.
+//
+// DisableUnitFiles() is similar to EnableUnitFiles() but disables the specified
+// units by removing all symlinks to them in /etc/ and /run/
+//
+//   - files: A list of units to enable
+//   - runtime: Specify if the changes should persist after reboot or not
+//   - changes: The changes made
+func (p *Node) DisableUnitFiles(ctx context.Context, files []string, runtime bool) ([]NodeDisableUnitFilesChange, error) {
+	changes, err := bus.Call[[]NodeDisableUnitFilesChange](ctx, p.obj, NodeInterface+".DisableUnitFiles", files, runtime)
+	if err != nil {
+		return changes, fmt.Errorf("failed to call org.eclipse.bluechi.Node.DisableUnitFiles: %w", err)
+	}
+	return changes, nil
+}
+
+// ListUnits calls the ListUnits method.
+//
+// List all loaded systemd units.
+//
+//   - units: A list of all units on the node
+func (p *Node) ListUnits(ctx context.Context) ([]NodeListUnitsUnit, error) {
+	units, err := bus.Call[[]NodeListUnitsUnit](ctx, p.obj, NodeInterface+".ListUnits")
+	if err != nil {
+		return units, fmt.Errorf("failed to call org.eclipse.bluechi.Node.ListUnits: %w", err)
+	}
+	return units, nil
+}
+
+// Reload calls the Reload method.
+//
+// Reload() may be invoked to reload all unit files.
+func (p *Node) Reload(ctx context.Context) error {
+	err := bus.Exec(ctx, p.obj, NodeInterface+".Reload")
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Node.Reload: %w", err)
+	}
+	return nil
+}
+
+// SetLogLevel calls the SetLogLevel method.
+//
+// Change the loglevel of the controller.
+func (p *Node) SetLogLevel(ctx context.Context, level string) error {
+	err := bus.Exec(ctx, p.obj, NodeInterface+".SetLogLevel", level)
+	if err != nil {
+		return fmt.Errorf("failed to call org.eclipse.bluechi.Node.SetLogLevel: %w", err)
+	}
+	return nil
+}
+
+// Name returns the Name property.
+//
+// The name of the node.
+func (p *Node) Name(ctx context.Context) (string, error) {
+	var value string
+	v, err := bus.GetProperty(ctx, p.obj, NodeInterface, "Name")
+	if err == nil {
+		err = v.Store(&value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to get property Name of org.eclipse.bluechi.Node: %w", err)
+	}
+	return value, nil
+}
+
+// Status returns the Status property.
+//
+// The connection status of the node with the BlueChi controller. On any change,
+// a signal is emitted on the org.freedesktop.DBus.Properties interface.
+func (p *Node) Status(ctx context.Context) (string, error) {
+	var value string
+	v, err := bus.GetProperty(ctx, p.obj, NodeInterface, "Status")
+	if err == nil {
+		err = v.Store(&value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to get property Status of org.eclipse.bluechi.Node: %w", err)
+	}
+	return value, nil
+}
+
+// LastSeenTimestamp returns the LastSeenTimestamp property.
+//
+// A timestamp indicating when the last connection test (e.g. via heartbeat) was
+// successful.
+func (p *Node) LastSeenTimestamp(ctx context.Context) (uint64, error) {
+	var value uint64
+	v, err := bus.GetProperty(ctx, p.obj, NodeInterface, "LastSeenTimestamp")
+	if err == nil {
+		err = v.Store(&value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to get property LastSeenTimestamp of org.eclipse.bluechi.Node: %w", err)
+	}
+	return value, nil
+}
+
+// NodeSetUnitPropertiesKeyvalue is the D-Bus struct (sv). A list of the new
+// values as key-value pair with the key being the name of the property.
+type NodeSetUnitPropertiesKeyvalue struct {
+	Field1 string
+	Field2 dbus.Variant
+}
+
+// NodeEnableUnitFilesChange is the D-Bus struct (sss). The changes made.
+type NodeEnableUnitFilesChange struct {
+	// type of change (one of: symlink, unlink)
+	Field1 string
+	// file name of the symlink
+	Field2 string
+	// destination of the symlink
+	Field3 string
+}
+
+// NodeDisableUnitFilesChange is the D-Bus struct (sss). The changes made.
+type NodeDisableUnitFilesChange struct {
+	// type of change (one of: symlink, unlink)
+	Field1 string
+	// file name of the symlink
+	Field2 string
+	// destination of the symlink
+	Field3 string
+}
+
+// NodeListUnitsUnit is the D-Bus struct (ssssssouso). A list of all units on
+// the node.
+type NodeListUnitsUnit struct {
+	// The primary unit name as string
+	Field1 string
+	// The human readable description string
+	Field2 string
+	// The load state (i.e. whether the unit file has been loaded successfully)
+	Field3 string
+	// The active state (i.e. whether the unit is currently started or not)
+	Field4 string
+	// The sub state (a more fine-grained version of the active state that is
+	// specific to the unit type, which the active state is not)
+	Field5 string
+	// A unit that is being followed in its state by this unit, if there is any,
+	// otherwise the empty string.
+	Field6 string
+	// The unit object path
+	Field7 dbus.ObjectPath
+	// If there is a job queued for the job unit the numeric job id, 0 otherwise
+	Field8 uint32
+	// The job type as string
+	Field9 string
+	// The job object path
+	Field10 dbus.ObjectPath
+}
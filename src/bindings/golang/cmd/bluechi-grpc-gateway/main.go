@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Command bluechi-grpc-gateway serves the Gateway service of package
+// gatewaypb, exposing the nodes, unit operations and unit changes of a
+// BlueChi cluster to clients without access to its D-Bus API. It connects
+// to the controller on the system bus like any other user of the bindings.
+//
+// Usage:
+//
+//	bluechi-grpc-gateway [--listen=address] [--tls-cert=file --tls-key=file]
+//
+// The gateway does not authenticate its clients. Unless it listens on a
+// trusted network only, it should be run behind a proxy doing so.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/gatewaypb"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+// config holds the options of the gateway.
+type config struct {
+	listen  string
+	tlsCert string
+	tlsKey  string
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(mainWithExitCode(ctx, os.Args[1:], os.Stderr))
+}
+
+func mainWithExitCode(ctx context.Context, args []string, stderr io.Writer) int {
+	cfg, err := parseFlags(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+	m, err := manager.NewManager(manager.WithLogger(logger))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer m.Close()
+
+	if err := serve(ctx, cfg, m.API(), logger); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func parseFlags(args []string, stderr io.Writer) (config, error) {
+	var cfg config
+	fs := flag.NewFlagSet("bluechi-grpc-gateway", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.listen, "listen", "localhost:50051", "address to listen on")
+	fs.StringVar(&cfg.tlsCert, "tls-cert", "", "certificate file to serve TLS with")
+	fs.StringVar(&cfg.tlsKey, "tls-key", "", "key file of the TLS certificate")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments %v\n", fs.Args())
+		fs.Usage()
+		return cfg, errors.New("unexpected arguments")
+	}
+	if (cfg.tlsCert == "") != (cfg.tlsKey == "") {
+		fmt.Fprintln(stderr, "--tls-cert and --tls-key must be given together")
+		return cfg, errors.New("incomplete TLS options")
+	}
+	return cfg, nil
+}
+
+// serve serves the gateway for api on the address of cfg until ctx is done,
+// then stops gracefully.
+func serve(ctx context.Context, cfg config, api manager.ManagerAPI, logger *slog.Logger) error {
+	var opts []grpc.ServerOption
+	if cfg.tlsCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.tlsCert, cfg.tlsKey)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+	}
+
+	lis, err := net.Listen("tcp", cfg.listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.listen, err)
+	}
+	srv := newServer(api, opts...)
+
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	logger.Info("serving gateway", "address", lis.Addr().String())
+	if err := srv.Serve(lis); err != nil {
+		return fmt.Errorf("failed to serve gateway: %w", err)
+	}
+	return nil
+}
+
+// newServer returns a gRPC server with the Gateway service for api
+// registered.
+func newServer(api manager.ManagerAPI, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	gatewaypb.RegisterGatewayServer(srv, &server{api: api})
+	return srv
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/gatewaypb"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+)
+
+func newFake() *managertest.Manager {
+	f := managertest.New()
+	n1 := f.AddNode("node1")
+	n1.AddUnit("nginx.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	n1.AddUnit("sshd.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.AddNode("node2").AddUnit("nginx.service", managertest.ActiveStateFailed, managertest.SubStateFailed)
+	return f
+}
+
+// newClient serves the gateway for f on an in-memory listener and returns
+// a client connected to it.
+func newClient(t *testing.T, f *managertest.Manager) gatewaypb.GatewayClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := newServer(f)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gatewaypb.NewGatewayClient(conn)
+}
+
+func TestListNodes(t *testing.T) {
+	f := newFake()
+	f.SetNodeStatus("node2", managertest.NodeOffline)
+	c := newClient(t, f)
+
+	resp, err := c.ListNodes(context.Background(), &gatewaypb.ListNodesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, n := range resp.GetNodes() {
+		got[n.GetName()] = n.GetStatus()
+	}
+	if len(got) != 2 || got["node1"] != "online" || got["node2"] != "offline" {
+		t.Errorf("unexpected nodes %v", got)
+	}
+}
+
+func TestListUnits(t *testing.T) {
+	c := newClient(t, newFake())
+	ctx := context.Background()
+
+	tests := []struct {
+		req  *gatewaypb.ListUnitsRequest
+		want []string
+	}{
+		{&gatewaypb.ListUnitsRequest{}, []string{"node1/nginx.service", "node1/sshd.service", "node2/nginx.service"}},
+		{&gatewaypb.ListUnitsRequest{Nodes: []string{"node2"}}, []string{"node2/nginx.service"}},
+		{&gatewaypb.ListUnitsRequest{Pattern: "ssh*"}, []string{"node1/sshd.service"}},
+	}
+	for _, tt := range tests {
+		resp, err := c.ListUnits(ctx, tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, u := range resp.GetUnits() {
+			got = append(got, u.GetNode()+"/"+u.GetName())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%v listed %v, want %v", tt.req, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%v listed %v, want %v", tt.req, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestUnitOperations(t *testing.T) {
+	f := newFake()
+	c := newClient(t, f)
+	ctx := context.Background()
+
+	resp, err := c.StartUnit(ctx, &gatewaypb.UnitRequest{Node: "node1", Unit: "sshd.service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetResult() != job.ResultDone || resp.GetJobPath() == "" {
+		t.Errorf("unexpected response %v", resp)
+	}
+	if u, _ := f.Node("node1").Unit("sshd.service"); u.ActiveState != managertest.ActiveStateActive {
+		t.Errorf("sshd.service is %s after start", u.ActiveState)
+	}
+
+	f.Node("node1").SetJobResult("nginx.service", job.ResultFailed)
+	resp, err = c.RestartUnit(ctx, &gatewaypb.UnitRequest{Node: "node1", Unit: "nginx.service"})
+	if err != nil || resp.GetResult() != job.ResultFailed {
+		t.Errorf("expected a failed job result, got %v, %v", resp, err)
+	}
+
+	_, err = c.StopUnit(ctx, &gatewaypb.UnitRequest{Node: "node3", Unit: "nginx.service"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown node, got %v", err)
+	}
+	_, err = c.StopUnit(ctx, &gatewaypb.UnitRequest{Node: "node1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without a unit, got %v", err)
+	}
+}
+
+func TestDisconnected(t *testing.T) {
+	f := newFake()
+	f.FailCall("ListNodes", fmt.Errorf("failed to list nodes: %w", manager.ErrDisconnected))
+	c := newClient(t, f)
+
+	_, err := c.ListNodes(context.Background(), &gatewaypb.ListNodesRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable while disconnected, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	f := newFake()
+	c := newClient(t, f)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := c.Watch(ctx, &gatewaypb.WatchRequest{Node: "node1", Units: []string{"sshd.service"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the monitor subscribes after the call returned, change the unit until
+	// its event arrives
+	events := make(chan *gatewaypb.UnitEvent)
+	go func() {
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				close(events)
+				return
+			}
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.Node("node1").SetUnitState("sshd.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("stream ended without events")
+			}
+			sc := e.GetStateChanged()
+			if e.GetNode() != "node1" || e.GetUnit() != "sshd.service" || sc == nil || sc.GetActiveState() != "active" {
+				t.Fatalf("unexpected event %v", e)
+			}
+			return
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	cfg, err := parseFlags([]string{"--listen", ":9000"}, io.Discard)
+	if err != nil || cfg.listen != ":9000" {
+		t.Errorf("unexpected config %+v, %v", cfg, err)
+	}
+	if _, err := parseFlags([]string{"--tls-cert", "cert.pem"}, io.Discard); err == nil {
+		t.Error("expected --tls-cert without --tls-key to fail")
+	}
+	if _, err := parseFlags([]string{"extra"}, io.Discard); err == nil {
+		t.Error("expected operands to fail")
+	}
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/gatewaypb"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// server implements the Gateway service on top of a manager.ManagerAPI.
+type server struct {
+	gatewaypb.UnimplementedGatewayServer
+	api manager.ManagerAPI
+}
+
+func (s *server) ListNodes(ctx context.Context, req *gatewaypb.ListNodesRequest) (*gatewaypb.ListNodesResponse, error) {
+	nodes, err := s.api.ListNodes(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &gatewaypb.ListNodesResponse{Nodes: make([]*gatewaypb.Node, 0, len(nodes))}
+	for _, n := range nodes {
+		resp.Nodes = append(resp.Nodes, &gatewaypb.Node{Name: n.Name, Status: string(n.Status), PeerIp: n.PeerIP})
+	}
+	return resp, nil
+}
+
+func (s *server) ListUnits(ctx context.Context, req *gatewaypb.ListUnitsRequest) (*gatewaypb.ListUnitsResponse, error) {
+	var opts []manager.ListUnitsOption
+	if len(req.GetNodes()) > 0 {
+		opts = append(opts, manager.WithNodes(req.GetNodes()...))
+	}
+	if req.GetPattern() != "" {
+		opts = append(opts, manager.WithPattern(req.GetPattern()))
+	}
+	units, err := s.api.ListUnits(ctx, opts...)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resp := &gatewaypb.ListUnitsResponse{}
+	for _, name := range names {
+		for _, u := range units[name] {
+			resp.Units = append(resp.Units, &gatewaypb.Unit{
+				Node:        name,
+				Name:        u.Name,
+				Description: u.Description,
+				LoadState:   string(u.LoadState),
+				ActiveState: string(u.ActiveState),
+				SubState:    string(u.SubState),
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (s *server) StartUnit(ctx context.Context, req *gatewaypb.UnitRequest) (*gatewaypb.UnitResponse, error) {
+	return s.unitJob(ctx, req, manager.NodeAPI.StartUnitAsync)
+}
+
+func (s *server) StopUnit(ctx context.Context, req *gatewaypb.UnitRequest) (*gatewaypb.UnitResponse, error) {
+	return s.unitJob(ctx, req, manager.NodeAPI.StopUnitAsync)
+}
+
+func (s *server) RestartUnit(ctx context.Context, req *gatewaypb.UnitRequest) (*gatewaypb.UnitResponse, error) {
+	return s.unitJob(ctx, req, manager.NodeAPI.RestartUnitAsync)
+}
+
+func (s *server) ReloadUnit(ctx context.Context, req *gatewaypb.UnitRequest) (*gatewaypb.UnitResponse, error) {
+	return s.unitJob(ctx, req, manager.NodeAPI.ReloadUnitAsync)
+}
+
+// unitJob queues the job of op for the unit of req and waits for it. A job
+// which finished with a result other than done is reported by the result of
+// the response rather than by an error.
+func (s *server) unitJob(ctx context.Context, req *gatewaypb.UnitRequest,
+	op func(manager.NodeAPI, context.Context, string, string) <-chan node.JobResult) (*gatewaypb.UnitResponse, error) {
+	if req.GetNode() == "" || req.GetUnit() == "" {
+		return nil, status.Error(codes.InvalidArgument, "node and unit are required")
+	}
+	mode := req.GetMode()
+	if mode == "" {
+		mode = node.ModeReplace
+	}
+
+	n, err := s.api.GetNode(ctx, req.GetNode())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	res := <-op(n, ctx, req.GetUnit(), mode)
+	if res.Err != nil && res.Result == "" {
+		return nil, toStatus(res.Err)
+	}
+	return &gatewaypb.UnitResponse{JobPath: string(res.Path), Result: res.Result}, nil
+}
+
+func (s *server) Watch(req *gatewaypb.WatchRequest, stream gatewaypb.Gateway_WatchServer) error {
+	ctx := stream.Context()
+	nodeName, units := req.GetNode(), req.GetUnits()
+	if nodeName == "" {
+		nodeName = common.SYMBOL_WILDCARD
+	}
+	if len(units) == 0 {
+		units = []string{common.SYMBOL_WILDCARD}
+	}
+
+	mon, err := s.api.CreateMonitor(ctx)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer mon.Close(context.Background())
+
+	if len(units) == 1 {
+		_, err = mon.Subscribe(ctx, nodeName, units[0])
+	} else {
+		_, err = mon.SubscribeList(ctx, nodeName, units)
+	}
+	if err != nil {
+		return toStatus(err)
+	}
+
+	events := mon.Events()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "monitor %s was closed", mon.ObjectPath())
+			}
+			pe := unitEvent(e)
+			if pe == nil {
+				continue
+			}
+			if err := stream.Send(pe); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// unitEvent converts a monitor event into its message, nil for events not
+// concerning a unit.
+func unitEvent(event monitor.Event) *gatewaypb.UnitEvent {
+	pe := &gatewaypb.UnitEvent{Node: event.NodeName(), Unit: event.UnitName()}
+	switch e := event.(type) {
+	case monitor.UnitNew:
+		pe.Event = &gatewaypb.UnitEvent_New{New: &gatewaypb.UnitNew{Reason: e.Reason}}
+	case monitor.UnitRemoved:
+		pe.Event = &gatewaypb.UnitEvent_Removed{Removed: &gatewaypb.UnitRemoved{Reason: e.Reason}}
+	case monitor.UnitStateChanged:
+		pe.Event = &gatewaypb.UnitEvent_StateChanged{StateChanged: &gatewaypb.UnitStateChanged{
+			ActiveState: string(e.ActiveState),
+			SubState:    string(e.SubState),
+			Reason:      e.Reason,
+		}}
+	case monitor.UnitPropertiesChanged:
+		props := make(map[string]string, len(e.Properties))
+		for name, v := range e.Properties {
+			props[name] = v.String()
+		}
+		pe.Event = &gatewaypb.UnitEvent_PropertiesChanged{PropertiesChanged: &gatewaypb.UnitPropertiesChanged{
+			Interface:  e.Interface,
+			Properties: props,
+		}}
+	default:
+		return nil
+	}
+	return pe
+}
+
+// toStatus maps an error of the bindings to a gRPC status by its sentinel
+// error, e.g. common.ErrNoSuchNode to codes.NotFound.
+func toStatus(err error) error {
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, common.ErrNoSuchNode), errors.Is(err, common.ErrNoSuchUnit):
+		code = codes.NotFound
+	case errors.Is(err, common.ErrNodeOffline), errors.Is(err, common.ErrNodeCircuitOpen),
+		errors.Is(err, common.ErrServiceUnknown), errors.Is(err, manager.ErrNotConnected),
+		errors.Is(err, manager.ErrDisconnected):
+		code = codes.Unavailable
+	case errors.Is(err, common.ErrPermissionDenied):
+		code = codes.PermissionDenied
+	case errors.Is(err, common.ErrInvalidArgs):
+		code = codes.InvalidArgument
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Command gobluechictl is a subset of bluechictl implemented purely on top
+// of the Go bindings. It can be used where only Go binaries are available
+// and exercises the bindings against a real controller.
+//
+// Usage:
+//
+//	gobluechictl list-units [nodename] [--filter=glob] [--output=json|yaml|table]
+//	gobluechictl start|stop|restart|reload nodename unitname
+//	gobluechictl status [nodename [unitname]] [--output=json|yaml|table]
+//	gobluechictl monitor [nodename] [unit1,unit2,...]
+//	gobluechictl metrics enable|disable|listen
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/format"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+// command is a subcommand of gobluechictl.
+type command struct {
+	name    string
+	usage   string
+	help    string
+	minArgs int
+	maxArgs int
+	// flags registers the options of the command on fs, may be nil
+	flags func(fs *flag.FlagSet, c *cli)
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+// cli holds the state shared by all subcommands.
+type cli struct {
+	api manager.ManagerAPI
+	out io.Writer
+
+	filter string
+	output format.Output
+}
+
+var commands = []command{
+	{
+		name: "list-units", usage: "list-units [nodename] [--filter=glob] [--output=json|yaml|table]", maxArgs: 1,
+		help: "returns the list of systemd services running on a specific or on all nodes",
+		flags: func(fs *flag.FlagSet, c *cli) {
+			fs.StringVar(&c.filter, "filter", "", "show only units matching the glob")
+			outputFlag(fs, c)
+		},
+		run: listUnits,
+	},
+	unitAction("start", "starts"),
+	unitAction("stop", "stops"),
+	unitAction("restart", "restarts"),
+	unitAction("reload", "reloads"),
+	{
+		name: "status", usage: "status [nodename [unitname]] [--output=json|yaml|table]", maxArgs: 2,
+		help:  "shows the status of a node, or statuses of all nodes, or status of a unit on node",
+		flags: outputFlag,
+		run:   status,
+	},
+	{
+		name: "monitor", usage: "monitor [nodename] [unit1,unit2,...]", maxArgs: 2,
+		help: "creates a monitor on the given node to observe changes in the specified units",
+		run:  monitorUnits,
+	},
+	{
+		name: "metrics", usage: "metrics enable|disable|listen", minArgs: 1, maxArgs: 1,
+		help: "enables/disables metrics reporting, or listens and prints incoming metrics reports",
+		run:  metricsCommand,
+	},
+}
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(mainWithExitCode(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func mainWithExitCode(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+
+	m, err := manager.NewManager()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer m.Close()
+
+	err = run(ctx, m.API(), stdout, args)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(stderr, err)
+		usage(stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// run executes the subcommand named by args[0] against api.
+func run(ctx context.Context, api manager.ManagerAPI, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		c := &cli{api: api, out: out}
+		operands, err := parseArgs(cmd, c, args[1:])
+		if err != nil {
+			return err
+		}
+		return cmd.run(ctx, c, operands)
+	}
+	return fmt.Errorf("%w: unknown command %s", errUsage, args[0])
+}
+
+// parseArgs parses the options of cmd into c and returns the remaining
+// operands. Unlike flag.Parse, options may follow the operands as they do
+// for bluechictl.
+func parseArgs(cmd command, c *cli, args []string) ([]string, error) {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if cmd.flags != nil {
+		cmd.flags(fs, c)
+	}
+
+	var operands []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", errUsage, cmd.name, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		operands = append(operands, args[0])
+		args = args[1:]
+	}
+
+	if len(operands) < cmd.minArgs || len(operands) > cmd.maxArgs {
+		return nil, fmt.Errorf("%w: usage: %s", errUsage, cmd.usage)
+	}
+	return operands, nil
+}
+
+// outputFlag registers the --output option selecting a format of package
+// format instead of the bluechictl output.
+func outputFlag(fs *flag.FlagSet, c *cli) {
+	fs.Func("output", "output format, one of json, yaml or table", func(s string) error {
+		out, err := format.ParseOutput(s)
+		c.output = out
+		return err
+	})
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "gobluechictl is a convenience CLI tool to interact with bluechi")
+	fmt.Fprintln(w, "Usage: gobluechictl COMMAND")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Available command:")
+	fmt.Fprintln(w, "  - help: shows this help message")
+	fmt.Fprintln(w, "    usage: help")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  - %s: %s\n", cmd.name, cmd.help)
+		fmt.Fprintf(w, "    usage: %s\n", cmd.usage)
+	}
+}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+)
+
+func newFake() *managertest.Manager {
+	f := managertest.New()
+	n1 := f.AddNode("node1")
+	n1.AddUnit("nginx.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	n1.AddUnit("sshd.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.AddNode("node2").AddUnit("nginx.service", managertest.ActiveStateFailed, managertest.SubStateFailed)
+	return f
+}
+
+func TestListUnits(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"list-units"}, []string{"node1|nginx", "node1|sshd", "node2|nginx"}},
+		{[]string{"list-units", "node2"}, []string{"node2|nginx"}},
+		{[]string{"list-units", "node1", "--filter=ssh*"}, []string{"node1|sshd"}},
+		{[]string{"list-units", "--filter", "nginx*"}, []string{"node1|nginx", "node2|nginx"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := run(context.Background(), newFake(), &out, tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		var got []string
+		for _, line := range strings.Split(out.String(), "\n")[2:] {
+			fields := strings.Split(line, "|")
+			if len(fields) == 4 {
+				got = append(got, strings.TrimSpace(fields[0])+"|"+strings.TrimSuffix(strings.TrimSpace(fields[1]), ".service"))
+			}
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%v listed %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), newFake(), &out, []string{"status", "--output=json"}); err != nil {
+		t.Fatal(err)
+	}
+	var nodes []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &nodes); err != nil || len(nodes) != 2 || nodes[0]["name"] != "node1" {
+		t.Errorf("unexpected output %q: %v", out.String(), err)
+	}
+
+	out.Reset()
+	if err := run(context.Background(), newFake(), &out, []string{"list-units", "node2", "--output", "table"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(strings.Split(out.String(), "\n")[1]); len(got) < 5 || got[0] != "node2" || got[1] != "nginx.service" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestUnitActions(t *testing.T) {
+	ctx := context.Background()
+	f := newFake()
+
+	var out bytes.Buffer
+	if err := run(ctx, f, &out, []string{"start", "node1", "sshd.service"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "Unit sshd.service start operation result: done\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+	if u, _ := f.Node("node1").Unit("sshd.service"); u.ActiveState != managertest.ActiveStateActive {
+		t.Errorf("sshd.service is %s after start", u.ActiveState)
+	}
+
+	if err := run(ctx, f, &out, []string{"stop", "node1", "nginx.service"}); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := f.Node("node1").Unit("nginx.service"); u.ActiveState != managertest.ActiveStateInactive {
+		t.Errorf("nginx.service is %s after stop", u.ActiveState)
+	}
+
+	if err := run(ctx, f, &out, []string{"restart", "nosuchnode", "nginx.service"}); err == nil {
+		t.Error("expected restarting a unit on an unknown node to fail")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	f := newFake()
+	f.SetNodeStatus("node2", managertest.NodeOffline)
+
+	var out bytes.Buffer
+	if err := run(ctx, f, &out, []string{"status"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "node1") || !strings.Contains(got, "offline") {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	if err := run(ctx, f, &out, []string{"status", "node3"}); err == nil {
+		t.Error("expected the status of an unknown node to fail")
+	}
+
+	out.Reset()
+	if err := run(ctx, f, &out, []string{"status", "node1", "nginx.service"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "nginx.service") || !strings.Contains(got, "running") {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMonitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := newFake()
+
+	var out syncBuffer
+	done := make(chan error)
+	go func() { done <- run(ctx, f, &out, []string{"monitor", "node1", "sshd.service,nginx.service"}) }()
+
+	// the monitor may not be subscribed yet, emit until an event is printed
+	want := "[node1] sshd.service\n\tUnit state changed (reason: real)\n\tActive: failed (failed)\n"
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the event, got %q", out.String())
+		}
+		f.Node("node1").SetUnitState("sshd.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+		f.Node("node1").SetUnitState("sshd.service", managertest.ActiveStateFailed, managertest.SubStateFailed)
+		f.Node("node2").SetUnitState("nginx.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Contains(out.String(), "node2") {
+		t.Errorf("unexpected event of another node in %q", out.String())
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	f := newFake()
+
+	var out bytes.Buffer
+	if err := run(ctx, f, &out, []string{"metrics", "enable"}); err != nil {
+		t.Fatal(err)
+	}
+	if !f.MetricsEnabled() || out.String() != "Done\n" {
+		t.Errorf("metrics enable: enabled %v, output %q", f.MetricsEnabled(), out.String())
+	}
+	if err := run(ctx, f, &out, []string{"metrics", "disable"}); err != nil {
+		t.Fatal(err)
+	}
+	if f.MetricsEnabled() {
+		t.Error("expected metrics to be disabled")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"frobnicate"},
+		{"start", "node1"},
+		{"list-units", "node1", "node2"},
+		{"list-units", "--nosuchflag"},
+		{"metrics"},
+		{"metrics", "frobnicate"},
+		{"status", "--output=xml"},
+	} {
+		var out bytes.Buffer
+		if err := run(ctx, newFake(), &out, args); !errors.Is(err, errUsage) {
+			t.Errorf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+)
+
+// monitorUnits prints the unit events of the given node and units, all
+// nodes and units by default, until ctx is done.
+func monitorUnits(ctx context.Context, c *cli, args []string) error {
+	nodeName, units := common.SYMBOL_WILDCARD, []string{common.SYMBOL_WILDCARD}
+	if len(args) > 0 {
+		nodeName = args[0]
+	}
+	if len(args) > 1 {
+		units = strings.Split(args[1], ",")
+	}
+
+	mon, err := c.api.CreateMonitor(ctx)
+	if err != nil {
+		return err
+	}
+	defer mon.Close(context.Background())
+
+	if len(units) == 1 {
+		_, err = mon.Subscribe(ctx, nodeName, units[0])
+	} else {
+		_, err = mon.SubscribeList(ctx, nodeName, units)
+	}
+	if err != nil {
+		return err
+	}
+
+	events := mon.Events()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return fmt.Errorf("monitor %s was closed", mon.ObjectPath())
+			}
+			printUnitEvent(c, e)
+		}
+	}
+}
+
+func printUnitEvent(c *cli, event monitor.Event) {
+	switch e := event.(type) {
+	case monitor.UnitNew:
+		fmt.Fprintf(c.out, "[%s] %s\n\tUnit created (reason: %s)\n", e.Node, e.Unit, e.Reason)
+	case monitor.UnitRemoved:
+		fmt.Fprintf(c.out, "[%s] %s\n\tUnit removed (reason: %s)\n", e.Node, e.Unit, e.Reason)
+	case monitor.UnitStateChanged:
+		fmt.Fprintf(c.out, "[%s] %s\n\tUnit state changed (reason: %s)\n\tActive: %s (%s)\n",
+			e.Node, e.Unit, e.Reason, e.ActiveState, e.SubState)
+	case monitor.UnitPropertiesChanged:
+		fmt.Fprintf(c.out, "[%s] %s\n\tUnit properties changed (Interface: %s)\n", e.Node, e.Unit, e.Interface)
+		names := make([]string, 0, len(e.Properties))
+		for name := range e.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if s, ok := e.Properties[name].Value().(string); ok {
+				fmt.Fprintf(c.out, "\t%s: %s\n", name, s)
+			}
+		}
+	}
+}
+
+func metricsCommand(ctx context.Context, c *cli, args []string) error {
+	switch args[0] {
+	case "enable":
+		if err := c.api.EnableMetrics(ctx); err != nil {
+			return err
+		}
+	case "disable":
+		if err := c.api.DisableMetrics(ctx); err != nil {
+			return err
+		}
+	case "listen":
+		return listenMetrics(ctx, c)
+	default:
+		return fmt.Errorf("%w: unknown metrics command %s", errUsage, args[0])
+	}
+	fmt.Fprintln(c.out, "Done")
+	return nil
+}
+
+// listenMetrics prints the metrics signals until ctx is done.
+func listenMetrics(ctx context.Context, c *cli) error {
+	events, err := c.api.SubscribeMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, "Waiting for metrics signals...")
+	for e := range events {
+		switch e := e.(type) {
+		case metrics.StartUnitJobMetrics:
+			fmt.Fprintf(c.out, "[%s] Job %s to start unit %s:\n\tBlueChi job gross measured time: %.1fms\n\tUnit net start time (from properties): %.1fms\n",
+				e.Node, e.JobID, e.Unit, millis(e.JobMeasuredTime), millis(e.UnitStartPropTime))
+		case metrics.AgentJobMetrics:
+			fmt.Fprintf(c.out, "[%s] Agent systemd %s job on %s net measured time: %.1fms\n",
+				e.Node, e.Method, e.Unit, millis(e.SystemdJobTime))
+		}
+	}
+	return nil
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/format"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+func status(ctx context.Context, c *cli, args []string) error {
+	switch len(args) {
+	case 0:
+		return nodesStatus(ctx, c, "")
+	case 1:
+		return nodesStatus(ctx, c, args[0])
+	default:
+		return unitStatus(ctx, c, args[0], args[1])
+	}
+}
+
+// nodesStatus prints the state of the named node, or of all nodes if name
+// is empty.
+func nodesStatus(ctx context.Context, c *cli, name string) error {
+	nodes, err := c.api.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	var shown []manager.NodeInfo
+	for _, n := range nodes {
+		if name == "" || n.Name == name {
+			shown = append(shown, n)
+		}
+	}
+	if name != "" && len(shown) == 0 {
+		return fmt.Errorf("node %s not found", name)
+	}
+	if c.output != "" {
+		return format.Nodes(c.out, c.output, shown)
+	}
+
+	fmt.Fprintf(c.out, "%-30.30s| %-10.10s| %-28.28s\n", "NODE", "STATE", "LAST SEEN")
+	fmt.Fprintln(c.out, "=========================================================================")
+	for _, info := range shown {
+		lastSeen := "never"
+		if info.Status.IsOnline() {
+			lastSeen = "now"
+		} else if n, err := c.api.GetNode(ctx, info.Name); err == nil {
+			if t, err := n.LastSeenTimestamp(ctx); err == nil && !t.IsZero() {
+				lastSeen = t.Format(time.RFC3339)
+			}
+		}
+		fmt.Fprintf(c.out, "%-30.30s| %-10.10s| %-28.28s\n", info.Name, info.Status, lastSeen)
+	}
+	return nil
+}
+
+func unitStatus(ctx context.Context, c *cli, nodeName string, unit string) error {
+	n, err := c.api.GetNode(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	props, err := n.GetUnitProperties(ctx, unit, common.SYSTEMD_UNIT_INTERFACE)
+	if err != nil {
+		return err
+	}
+	enabled, err := n.GetUnitFileState(ctx, unit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "%-30.30s| %-10.10s| %-10.10s| %-10.10s| %-12.12s| %-10.10s\n",
+		"UNIT", "LOADED", "ACTIVE", "SUBSTATE", "FREEZERSTATE", "ENABLED")
+	fmt.Fprintln(c.out, "=================================================================================================")
+	fmt.Fprintf(c.out, "%-30.30s| %-10.10s| %-10.10s| %-10.10s| %-12.12s| %-10.10s\n",
+		unit, property(props, "LoadState"), property(props, "ActiveState"), property(props, "SubState"),
+		property(props, "FreezerState"), enabled)
+	return nil
+}
+
+// property returns the named string property, "-" if it is missing.
+func property(props map[string]interface{}, name string) string {
+	if s, ok := props[name].(string); ok && s != "" {
+		return s
+	}
+	return "-"
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/format"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+func listUnits(ctx context.Context, c *cli, args []string) error {
+	var opts []manager.ListUnitsOption
+	if len(args) == 1 {
+		opts = append(opts, manager.WithNodes(args[0]))
+	}
+	if c.filter != "" {
+		opts = append(opts, manager.WithPattern(c.filter))
+	}
+	units, err := c.api.ListUnits(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	if c.output != "" {
+		return format.NodeUnits(c.out, c.output, units)
+	}
+
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(c.out, "%-20.20s|%-59.59s|%9s|%9s\n", "NODE", "ID", "ACTIVE", "SUB")
+	fmt.Fprintln(c.out, "====================================================================================================")
+	for _, name := range names {
+		for _, u := range units[name] {
+			fmt.Fprintf(c.out, "%-20.20s|%-59.59s|%9s|%9s\n", name, u.Name, u.ActiveState, u.SubState)
+		}
+	}
+	return nil
+}
+
+// unitAction returns the command queueing a job of the given operation,
+// e.g. start, and waiting for its result.
+func unitAction(op string, verb string) command {
+	return command{
+		name:    op,
+		usage:   op + " nodename unitname",
+		help:    verb + " a specific systemd service (or timer, or slice) on a specific node",
+		minArgs: 2,
+		maxArgs: 2,
+		run: func(ctx context.Context, c *cli, args []string) error {
+			n, err := c.api.GetNode(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			unit := args[1]
+			switch op {
+			case "start":
+				err = n.StartUnitAndWait(ctx, unit, node.ModeReplace)
+			case "stop":
+				err = n.StopUnitAndWait(ctx, unit, node.ModeReplace)
+			case "restart":
+				err = n.RestartUnitAndWait(ctx, unit, node.ModeReplace)
+			case "reload":
+				err = n.ReloadUnitAndWait(ctx, unit, node.ModeReplace)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "Unit %s %s operation result: done\n", unit, op)
+			return nil
+		},
+	}
+}
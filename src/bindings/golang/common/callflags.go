@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// perCallFlags are the flags WithCallFlags accepts. The interactive
+// authorization of polkit is allowed per connection, see
+// manager.WithInteractiveAuthorization.
+const perCallFlags = dbus.FlagNoReplyExpected | dbus.FlagNoAutoStart
+
+type callFlagsKey struct{}
+
+// WithCallFlags returns a copy of ctx setting flags on the D-Bus calls
+// issued with it, in addition to the flags already carried by ctx. Only
+// dbus.FlagNoReplyExpected and dbus.FlagNoAutoStart are accepted, other
+// flags are ignored.
+//
+// dbus.FlagNoAutoStart keeps the bus from activating the controller if it
+// is not running, the calls fail with ErrServiceUnknown instead.
+//
+// dbus.FlagNoReplyExpected turns calls whose return values are discarded,
+// e.g. node.Node.SetLogLevel, into fire-and-forget calls: they return once
+// the message is sent, so fanning them out to hundreds of nodes does not
+// wait for each reply, but errors of the controller are not reported. Calls
+// decoding their reply ignore the flag.
+func WithCallFlags(ctx context.Context, flags dbus.Flags) context.Context {
+	flags = (flags & perCallFlags) | CallFlagsFromContext(ctx)
+	return context.WithValue(ctx, callFlagsKey{}, flags)
+}
+
+// CallFlagsFromContext returns the flags carried by ctx, zero if there are
+// none.
+func CallFlagsFromContext(ctx context.Context) dbus.Flags {
+	flags, _ := ctx.Value(callFlagsKey{}).(dbus.Flags)
+	return flags
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package common contains the D-Bus service names, object paths,
+// interfaces, methods and signals of BlueChi, mirroring protocol.h of the C
+// implementation. They are shared by the Go bindings and code talking to
+// BlueChi directly, e.g. via godbus.
+package common
+
+/* BlueChi DBus service names */
+const (
+	BC_DBUS_NAME       = "org.eclipse.bluechi"
+	BC_AGENT_DBUS_NAME = "org.eclipse.bluechi.Agent"
+)
+
+// BC_DBUS_INTERFACE is the service name of the controller.
+//
+// Deprecated: Use BC_DBUS_NAME for the service name and
+// BC_INTERFACE_BASE_NAME for the common prefix of the interfaces.
+const BC_DBUS_INTERFACE = BC_DBUS_NAME
+
+/* Root object path */
+const BC_OBJECT_PATH = "/org/eclipse/bluechi"
+
+/* Public objects */
+const (
+	BC_CONTROLLER_OBJECT_PATH = BC_OBJECT_PATH
+	BC_AGENT_OBJECT_PATH      = BC_OBJECT_PATH
+)
+
+/* Object path prefixes of public objects */
+const (
+	NODE_OBJECT_PATH_PREFIX    = BC_OBJECT_PATH + "/node"
+	JOB_OBJECT_PATH_PREFIX     = BC_OBJECT_PATH + "/job"
+	MONITOR_OBJECT_PATH_PREFIX = BC_OBJECT_PATH + "/monitor"
+	METRICS_OBJECT_PATH        = BC_OBJECT_PATH + "/metrics"
+)
+
+/* Public interfaces */
+const (
+	BC_INTERFACE_BASE_NAME = "org.eclipse.bluechi"
+
+	CONTROLLER_INTERFACE = BC_INTERFACE_BASE_NAME + ".Controller"
+	NODE_INTERFACE       = BC_INTERFACE_BASE_NAME + ".Node"
+	MONITOR_INTERFACE    = BC_INTERFACE_BASE_NAME + ".Monitor"
+	JOB_INTERFACE        = BC_INTERFACE_BASE_NAME + ".Job"
+	AGENT_INTERFACE      = BC_INTERFACE_BASE_NAME + ".Agent"
+	METRICS_INTERFACE    = BC_INTERFACE_BASE_NAME + ".Metrics"
+)
+
+/* Standard D-Bus interfaces */
+const (
+	PROPERTIES_INTERFACE     = "org.freedesktop.DBus.Properties"
+	INTROSPECTABLE_INTERFACE = "org.freedesktop.DBus.Introspectable"
+	PEER_INTERFACE           = "org.freedesktop.DBus.Peer"
+
+	METHOD_PROPERTIES_GET    = PROPERTIES_INTERFACE + ".Get"
+	METHOD_PROPERTIES_GETALL = PROPERTIES_INTERFACE + ".GetAll"
+	METHOD_PROPERTIES_SET    = PROPERTIES_INTERFACE + ".Set"
+	METHOD_INTROSPECT        = INTROSPECTABLE_INTERFACE + ".Introspect"
+	METHOD_PEER_PING         = PEER_INTERFACE + ".Ping"
+
+	SIGNAL_PROPERTIES_CHANGED = PROPERTIES_INTERFACE + ".PropertiesChanged"
+)
+
+/* Controller methods */
+const (
+	METHOD_LISTNODES      = CONTROLLER_INTERFACE + ".ListNodes"
+	METHOD_GETNODE        = CONTROLLER_INTERFACE + ".GetNode"
+	METHOD_LISTUNITS      = CONTROLLER_INTERFACE + ".ListUnits"
+	METHOD_CREATE_MONITOR = CONTROLLER_INTERFACE + ".CreateMonitor"
+	METHOD_SET_LOG_LEVEL  = CONTROLLER_INTERFACE + ".SetLogLevel"
+
+	METHOD_ENABLE_METRICS  = CONTROLLER_INTERFACE + ".EnableMetrics"
+	METHOD_DISABLE_METRICS = CONTROLLER_INTERFACE + ".DisableMetrics"
+)
+
+/* Controller signals */
+const (
+	SIGNAL_JOB_NEW     = CONTROLLER_INTERFACE + ".JobNew"
+	SIGNAL_JOB_REMOVED = CONTROLLER_INTERFACE + ".JobRemoved"
+)
+
+/* Node methods */
+const (
+	METHOD_START_UNIT         = NODE_INTERFACE + ".StartUnit"
+	METHOD_STOP_UNIT          = NODE_INTERFACE + ".StopUnit"
+	METHOD_RESTART_UNIT       = NODE_INTERFACE + ".RestartUnit"
+	METHOD_RELOAD_UNIT        = NODE_INTERFACE + ".ReloadUnit"
+	METHOD_FREEZE_UNIT        = NODE_INTERFACE + ".FreezeUnit"
+	METHOD_THAW_UNIT          = NODE_INTERFACE + ".ThawUnit"
+	METHOD_NODE_LISTUNITS     = NODE_INTERFACE + ".ListUnits"
+	METHOD_RELOAD             = NODE_INTERFACE + ".Reload"
+	METHOD_NODE_SET_LOG_LEVEL = NODE_INTERFACE + ".SetLogLevel"
+
+	/* Not exported by all controller versions */
+	METHOD_GET_UNIT_PROPERTIES = NODE_INTERFACE + ".GetUnitProperties"
+	METHOD_GET_UNIT_PROPERTY   = NODE_INTERFACE + ".GetUnitProperty"
+	METHOD_SET_UNIT_PROPERTIES = NODE_INTERFACE + ".SetUnitProperties"
+
+	METHOD_ENABLE_UNIT_FILES  = NODE_INTERFACE + ".EnableUnitFiles"
+	METHOD_DISABLE_UNIT_FILES = NODE_INTERFACE + ".DisableUnitFiles"
+)
+
+/* Metrics signals */
+const (
+	SIGNAL_START_UNIT_JOB_METRICS = METRICS_INTERFACE + ".StartUnitJobMetrics"
+	SIGNAL_AGENT_JOB_METRICS      = METRICS_INTERFACE + ".AgentJobMetrics"
+)
+
+/* Systemd service, manager object and interfaces of units proxied by BlueChi */
+const (
+	SYSTEMD_BUS_NAME          = "org.freedesktop.systemd1"
+	SYSTEMD_OBJECT_PATH       = "/org/freedesktop/systemd1"
+	SYSTEMD_MANAGER_INTERFACE = "org.freedesktop.systemd1.Manager"
+
+	SYSTEMD_UNIT_INTERFACE    = "org.freedesktop.systemd1.Unit"
+	SYSTEMD_SERVICE_INTERFACE = "org.freedesktop.systemd1.Service"
+	SYSTEMD_SOCKET_INTERFACE  = "org.freedesktop.systemd1.Socket"
+	SYSTEMD_MOUNT_INTERFACE   = "org.freedesktop.systemd1.Mount"
+	SYSTEMD_SWAP_INTERFACE    = "org.freedesktop.systemd1.Swap"
+	SYSTEMD_SLICE_INTERFACE   = "org.freedesktop.systemd1.Slice"
+	SYSTEMD_SCOPE_INTERFACE   = "org.freedesktop.systemd1.Scope"
+)
+
+/* Systemd manager methods used to read the proxy services of the local agent */
+const (
+	METHOD_SYSTEMD_GET_UNIT               = SYSTEMD_MANAGER_INTERFACE + ".GetUnit"
+	METHOD_SYSTEMD_LIST_UNITS_BY_PATTERNS = SYSTEMD_MANAGER_INTERFACE + ".ListUnitsByPatterns"
+)
+
+/* Agent methods */
+const (
+	METHOD_AGENT_CREATE_PROXY = AGENT_INTERFACE + ".CreateProxy"
+	METHOD_AGENT_REMOVE_PROXY = AGENT_INTERFACE + ".RemoveProxy"
+)
+
+/* Job methods */
+const (
+	METHOD_JOB_CANCEL = JOB_INTERFACE + ".Cancel"
+)
+
+/* Monitor methods */
+const (
+	METHOD_MONITOR_SUBSCRIBE      = MONITOR_INTERFACE + ".Subscribe"
+	METHOD_MONITOR_SUBSCRIBE_LIST = MONITOR_INTERFACE + ".SubscribeList"
+	METHOD_MONITOR_UNSUBSCRIBE    = MONITOR_INTERFACE + ".Unsubscribe"
+	METHOD_MONITOR_CLOSE          = MONITOR_INTERFACE + ".Close"
+	METHOD_MONITOR_ADD_PEER       = MONITOR_INTERFACE + ".AddPeer"
+	METHOD_MONITOR_REMOVE_PEER    = MONITOR_INTERFACE + ".RemovePeer"
+)
+
+/* Monitor signals */
+const (
+	SIGNAL_UNIT_NEW                = MONITOR_INTERFACE + ".UnitNew"
+	SIGNAL_UNIT_REMOVED            = MONITOR_INTERFACE + ".UnitRemoved"
+	SIGNAL_UNIT_STATE_CHANGED      = MONITOR_INTERFACE + ".UnitStateChanged"
+	SIGNAL_UNIT_PROPERTIES_CHANGED = MONITOR_INTERFACE + ".UnitPropertiesChanged"
+	SIGNAL_PEER_REMOVED            = MONITOR_INTERFACE + ".PeerRemoved"
+)
+
+/* Log levels of the controller and agents */
+const (
+	LOG_LEVEL_DEBUG = "DEBUG"
+	LOG_LEVEL_INFO  = "INFO"
+	LOG_LEVEL_WARN  = "WARN"
+	LOG_LEVEL_ERROR = "ERROR"
+)
+
+/* Values of the Status property of the controller */
+const (
+	SYSTEM_STATUS_UP       = "up"
+	SYSTEM_STATUS_DEGRADED = "degraded"
+	SYSTEM_STATUS_DOWN     = "down"
+)
+
+/* Wildcard matching all nodes or units in monitor subscriptions */
+const SYMBOL_WILDCARD = "*"
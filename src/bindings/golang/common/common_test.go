@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common_test
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/godbus/dbus/v5/introspect"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// members are the methods and signals with a constant in package common.
+var members = []string{
+	common.METHOD_LISTNODES,
+	common.METHOD_GETNODE,
+	common.METHOD_LISTUNITS,
+	common.METHOD_CREATE_MONITOR,
+	common.METHOD_SET_LOG_LEVEL,
+	common.METHOD_ENABLE_METRICS,
+	common.METHOD_DISABLE_METRICS,
+	common.SIGNAL_JOB_NEW,
+	common.SIGNAL_JOB_REMOVED,
+
+	common.METHOD_START_UNIT,
+	common.METHOD_STOP_UNIT,
+	common.METHOD_RESTART_UNIT,
+	common.METHOD_RELOAD_UNIT,
+	common.METHOD_FREEZE_UNIT,
+	common.METHOD_THAW_UNIT,
+	common.METHOD_NODE_LISTUNITS,
+	common.METHOD_RELOAD,
+	common.METHOD_NODE_SET_LOG_LEVEL,
+	common.METHOD_GET_UNIT_PROPERTIES,
+	common.METHOD_GET_UNIT_PROPERTY,
+	common.METHOD_SET_UNIT_PROPERTIES,
+	common.METHOD_ENABLE_UNIT_FILES,
+	common.METHOD_DISABLE_UNIT_FILES,
+
+	common.SIGNAL_START_UNIT_JOB_METRICS,
+	common.SIGNAL_AGENT_JOB_METRICS,
+
+	common.METHOD_AGENT_CREATE_PROXY,
+	common.METHOD_AGENT_REMOVE_PROXY,
+
+	common.METHOD_JOB_CANCEL,
+
+	common.METHOD_MONITOR_SUBSCRIBE,
+	common.METHOD_MONITOR_SUBSCRIBE_LIST,
+	common.METHOD_MONITOR_UNSUBSCRIBE,
+	common.METHOD_MONITOR_CLOSE,
+	common.METHOD_MONITOR_ADD_PEER,
+	common.METHOD_MONITOR_REMOVE_PEER,
+	common.SIGNAL_UNIT_NEW,
+	common.SIGNAL_UNIT_REMOVED,
+	common.SIGNAL_UNIT_STATE_CHANGED,
+	common.SIGNAL_UNIT_PROPERTIES_CHANGED,
+	common.SIGNAL_PEER_REMOVED,
+
+	common.METHOD_INTERNAL_CONTROLLER_REGISTER,
+
+	common.METHOD_INTERNAL_AGENT_START_UNIT,
+	common.METHOD_INTERNAL_AGENT_STOP_UNIT,
+	common.METHOD_INTERNAL_AGENT_RESTART_UNIT,
+	common.METHOD_INTERNAL_AGENT_RELOAD_UNIT,
+	common.METHOD_INTERNAL_AGENT_FREEZE_UNIT,
+	common.METHOD_INTERNAL_AGENT_THAW_UNIT,
+	common.METHOD_INTERNAL_AGENT_GET_UNIT_PROPERTIES,
+	common.METHOD_INTERNAL_AGENT_GET_UNIT_PROPERTY,
+	common.METHOD_INTERNAL_AGENT_SET_UNIT_PROPERTIES,
+	common.METHOD_INTERNAL_AGENT_ENABLE_UNIT_FILES,
+	common.METHOD_INTERNAL_AGENT_DISABLE_UNIT_FILES,
+	common.METHOD_INTERNAL_AGENT_LISTUNITS,
+	common.METHOD_INTERNAL_AGENT_SUBSCRIBE,
+	common.METHOD_INTERNAL_AGENT_UNSUBSCRIBE,
+	common.METHOD_INTERNAL_AGENT_ENABLE_METRICS,
+	common.METHOD_INTERNAL_AGENT_DISABLE_METRICS,
+	common.METHOD_INTERNAL_AGENT_START_DEP,
+	common.METHOD_INTERNAL_AGENT_STOP_DEP,
+	common.METHOD_INTERNAL_AGENT_RELOAD,
+	common.METHOD_INTERNAL_AGENT_SET_LOG_LEVEL,
+	common.SIGNAL_INTERNAL_AGENT_JOB_DONE,
+	common.SIGNAL_INTERNAL_AGENT_JOB_STATE_CHANGED,
+	common.SIGNAL_INTERNAL_AGENT_UNIT_NEW,
+	common.SIGNAL_INTERNAL_AGENT_UNIT_REMOVED,
+	common.SIGNAL_INTERNAL_AGENT_UNIT_STATE_CHANGED,
+	common.SIGNAL_INTERNAL_AGENT_UNIT_PROPERTIES_CHANGED,
+	common.SIGNAL_INTERNAL_AGENT_PROXY_NEW,
+	common.SIGNAL_INTERNAL_AGENT_PROXY_REMOVED,
+	common.SIGNAL_INTERNAL_AGENT_HEARTBEAT,
+	common.SIGNAL_INTERNAL_AGENT_METRICS_AGENT_JOB_METRICS,
+
+	common.METHOD_INTERNAL_PROXY_ERROR,
+	common.METHOD_INTERNAL_PROXY_TARGET_NEW,
+	common.METHOD_INTERNAL_PROXY_TARGET_STATE_CHANGED,
+	common.METHOD_INTERNAL_PROXY_TARGET_REMOVED,
+}
+
+// TestConstantsComplete checks that each method and signal of the
+// introspection XML files has a constant.
+func TestConstantsComplete(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("..", "..", "..", "..", "data", "org.eclipse.bluechi*.xml"))
+	if len(files) == 0 {
+		t.Skip("introspection XML files not available")
+	}
+	known := make(map[string]bool, len(members))
+	for _, m := range members {
+		known[m] = true
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var node introspect.Node
+		if err := xml.Unmarshal(data, &node); err != nil {
+			t.Fatalf("failed to parse %s: %v", file, err)
+		}
+		for _, iface := range node.Interfaces {
+			for _, m := range iface.Methods {
+				if !known[iface.Name+"."+m.Name] {
+					t.Errorf("no constant for method %s.%s", iface.Name, m.Name)
+				}
+			}
+			for _, s := range iface.Signals {
+				if !known[iface.Name+"."+s.Name] {
+					t.Errorf("no constant for signal %s.%s", iface.Name, s.Name)
+				}
+			}
+		}
+	}
+}
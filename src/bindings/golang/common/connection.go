@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Connection is the D-Bus connection the proxies of the bindings use. It is
+// implemented by *dbus.Conn as well as by the connection of a
+// manager.Manager, which keeps the proxies working across reconnects.
+type Connection interface {
+	// Object returns the object at path of the service dest.
+	Object(dest string, path dbus.ObjectPath) dbus.BusObject
+	// Signal registers ch to receive all signals of the connection.
+	Signal(ch chan<- *dbus.Signal)
+	// RemoveSignal unregisters a channel registered by Signal.
+	RemoveSignal(ch chan<- *dbus.Signal)
+	// AddMatchSignal adds a match rule for the signals to receive.
+	AddMatchSignal(options ...dbus.MatchOption) error
+	// RemoveMatchSignal removes a match rule added by AddMatchSignal.
+	RemoveMatchSignal(options ...dbus.MatchOption) error
+	// Context returns a context which is done once the connection is closed.
+	Context() context.Context
+}
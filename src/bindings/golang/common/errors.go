@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+/* D-Bus error names returned by BlueChi, systemd and the bus */
+const (
+	ERROR_OFFLINE                   = BC_INTERFACE_BASE_NAME + ".Offline"
+	ERROR_NO_SUCH_SUBSCRIPTION      = BC_INTERFACE_BASE_NAME + ".NoSuchSubscription"
+	ERROR_ACTIVATION_FAILED         = BC_INTERFACE_BASE_NAME + ".ActivationFailed"
+	ERROR_SYSTEMD_NO_SUCH_UNIT      = "org.freedesktop.systemd1.NoSuchUnit"
+	ERROR_ACCESS_DENIED             = "org.freedesktop.DBus.Error.AccessDenied"
+	ERROR_AUTH_FAILED               = "org.freedesktop.DBus.Error.AuthFailed"
+	ERROR_INTERACTIVE_AUTH_REQUIRED = "org.freedesktop.DBus.Error.InteractiveAuthorizationRequired"
+	ERROR_POLKIT_NOT_AUTHORIZED     = "org.freedesktop.PolicyKit1.Error.NotAuthorized"
+	ERROR_FAILED                    = "org.freedesktop.DBus.Error.Failed"
+	ERROR_INVALID_ARGS              = "org.freedesktop.DBus.Error.InvalidArgs"
+	ERROR_SERVICE_UNKNOWN           = "org.freedesktop.DBus.Error.ServiceUnknown"
+	ERROR_UNKNOWN_METHOD            = "org.freedesktop.DBus.Error.UnknownMethod"
+	ERROR_UNKNOWN_OBJECT            = "org.freedesktop.DBus.Error.UnknownObject"
+	ERROR_UNKNOWN_PROPERTY          = "org.freedesktop.DBus.Error.UnknownProperty"
+	ERROR_PROPERTY_READ_ONLY        = "org.freedesktop.DBus.Error.PropertyReadOnly"
+	ERROR_NO_REPLY                  = "org.freedesktop.DBus.Error.NoReply"
+	ERROR_TIMEOUT                   = "org.freedesktop.DBus.Error.Timeout"
+	ERROR_DISCONNECTED              = "org.freedesktop.DBus.Error.Disconnected"
+	ERROR_MESSAGE_NODE_NOT_FOUND    = "Node not found"
+	ERROR_MESSAGE_UNEXPECTED_NODE   = "Unexpected node name"
+	ERROR_MESSAGE_PEER_NOT_FOUND    = "Peer with ID '%d' not found."
+)
+
+// Sentinel errors for the failure causes callers commonly branch on. Errors
+// returned by the bindings for a failed D-Bus call wrap an *Error, which
+// matches the sentinel of its D-Bus error name with errors.Is.
+var (
+	// ErrNodeOffline is returned for calls on a node whose agent is not
+	// connected to the controller.
+	ErrNodeOffline = errors.New("node is offline")
+	// ErrNodeCircuitOpen is returned without issuing the call for calls on
+	// a node whose circuit breaker tripped after repeated failures, see
+	// manager.WithCircuitBreaker.
+	ErrNodeCircuitOpen = errors.New("circuit breaker of node is open")
+	// ErrNoSuchNode is returned for a node name unknown to the controller.
+	ErrNoSuchNode = errors.New("no such node")
+	// ErrNoSuchUnit is returned by systemd for an unknown unit.
+	ErrNoSuchUnit = errors.New("no such unit")
+	// ErrNoSuchSubscription is returned for an unknown monitor subscription.
+	ErrNoSuchSubscription = errors.New("no such subscription")
+	// ErrNoSuchPeer is returned for an unknown peer of a monitor.
+	ErrNoSuchPeer = errors.New("no such peer")
+	// ErrPermissionDenied is returned if the policy of the bus, the
+	// controller or systemd denies the call.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrNotAuthorized is returned if polkit did not authorize the call,
+	// e.g. because authorizing it needs an interactive authentication which
+	// the call did not allow, see manager.WithInteractiveAuthorization. It
+	// also matches ErrPermissionDenied.
+	ErrNotAuthorized = fmt.Errorf("not authorized: %w", ErrPermissionDenied)
+	// ErrInvalidArgs is returned for invalid arguments, e.g. an unknown job
+	// mode.
+	ErrInvalidArgs = errors.New("invalid arguments")
+	// ErrActivationFailed is returned if a proxy service could not be
+	// started on the node providing the service.
+	ErrActivationFailed = errors.New("activation failed")
+	// ErrServiceUnknown is returned if the service, e.g. the controller, is
+	// not on the bus.
+	ErrServiceUnknown = errors.New("service unknown")
+	// ErrUnknownMethod is returned for methods the service does not
+	// implement, e.g. methods missing in older controller versions.
+	ErrUnknownMethod = errors.New("unknown method")
+	// ErrUnknownProperty is returned for properties the service does not
+	// export, e.g. properties missing in older controller versions.
+	ErrUnknownProperty = errors.New("unknown property")
+	// ErrPropertyReadOnly is returned for setting a property which can only
+	// be read.
+	ErrPropertyReadOnly = errors.New("property is read-only")
+)
+
+var errorsByName = map[string]error{
+	ERROR_OFFLINE:                   ErrNodeOffline,
+	ERROR_NO_SUCH_SUBSCRIPTION:      ErrNoSuchSubscription,
+	ERROR_ACTIVATION_FAILED:         ErrActivationFailed,
+	ERROR_SYSTEMD_NO_SUCH_UNIT:      ErrNoSuchUnit,
+	ERROR_ACCESS_DENIED:             ErrPermissionDenied,
+	ERROR_AUTH_FAILED:               ErrPermissionDenied,
+	ERROR_INTERACTIVE_AUTH_REQUIRED: ErrNotAuthorized,
+	ERROR_POLKIT_NOT_AUTHORIZED:     ErrNotAuthorized,
+	ERROR_INVALID_ARGS:              ErrInvalidArgs,
+	ERROR_SERVICE_UNKNOWN:           ErrServiceUnknown,
+	ERROR_UNKNOWN_METHOD:            ErrUnknownMethod,
+	ERROR_UNKNOWN_PROPERTY:          ErrUnknownProperty,
+	ERROR_PROPERTY_READ_ONLY:        ErrPropertyReadOnly,
+}
+
+// Error is a D-Bus error reply, e.g. of the controller or of systemd on a
+// node.
+type Error struct {
+	// Name is the D-Bus error name, e.g. org.eclipse.bluechi.Offline.
+	Name string
+	// Message is the human readable description sent with the error.
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Message + " (" + e.Name + ")"
+}
+
+// Unwrap returns the sentinel error matching the error name, if any.
+func (e *Error) Unwrap() error {
+	// the controller reports unknown nodes the same way the bus reports
+	// unknown services
+	if e.Name == ERROR_SERVICE_UNKNOWN && (e.Message == ERROR_MESSAGE_NODE_NOT_FOUND || e.Message == ERROR_MESSAGE_UNEXPECTED_NODE) {
+		return ErrNoSuchNode
+	}
+	// monitors report unknown peer ids as generic failure
+	if e.Name == ERROR_FAILED {
+		var id uint32
+		if _, err := fmt.Sscanf(e.Message, ERROR_MESSAGE_PEER_NOT_FOUND, &id); err == nil {
+			return ErrNoSuchPeer
+		}
+	}
+	return errorsByName[e.Name]
+}
+
+// FromDBus converts a dbus.Error into an *Error. Other errors, including
+// nil, are returned unchanged.
+func FromDBus(err error) error {
+	var dbusErr dbus.Error
+	if !errors.As(err, &dbusErr) {
+		var dbusErrPtr *dbus.Error
+		if !errors.As(err, &dbusErrPtr) || dbusErrPtr == nil {
+			return err
+		}
+		dbusErr = *dbusErrPtr
+	}
+
+	e := &Error{Name: dbusErr.Name}
+	if len(dbusErr.Body) > 0 {
+		e.Message, _ = dbusErr.Body[0].(string)
+	}
+	return e
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+func TestFromDBus(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    error
+	}{
+		{common.ERROR_OFFLINE, "Node is offline", common.ErrNodeOffline},
+		{common.ERROR_SERVICE_UNKNOWN, "Node not found", common.ErrNoSuchNode},
+		{common.ERROR_SERVICE_UNKNOWN, "The name org.eclipse.bluechi was not provided by any .service files", common.ErrServiceUnknown},
+		{common.ERROR_SYSTEMD_NO_SUCH_UNIT, "Unit foo.service not loaded.", common.ErrNoSuchUnit},
+		{common.ERROR_ACCESS_DENIED, "Rejected send message", common.ErrPermissionDenied},
+		{common.ERROR_INTERACTIVE_AUTH_REQUIRED, "Interactive authentication required.", common.ErrPermissionDenied},
+		{common.ERROR_INTERACTIVE_AUTH_REQUIRED, "Interactive authentication required.", common.ErrNotAuthorized},
+		{common.ERROR_POLKIT_NOT_AUTHORIZED, "Not authorized", common.ErrNotAuthorized},
+		{common.ERROR_NO_SUCH_SUBSCRIPTION, "", common.ErrNoSuchSubscription},
+		{common.ERROR_UNKNOWN_METHOD, "Unknown method KillUnit", common.ErrUnknownMethod},
+		{common.ERROR_FAILED, "Peer with ID '3' not found.", common.ErrNoSuchPeer},
+	}
+	for _, tt := range tests {
+		dbusErr := dbus.NewError(tt.name, []interface{}{tt.message})
+		err := fmt.Errorf("failed to call: %w", common.FromDBus(*dbusErr))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s %q: expected %v, got %v", tt.name, tt.message, tt.want, err)
+		}
+
+		var bcErr *common.Error
+		if !errors.As(err, &bcErr) {
+			t.Fatalf("%s: expected a *common.Error, got %T", tt.name, err)
+		}
+		if bcErr.Name != tt.name || bcErr.Message != tt.message {
+			t.Errorf("unexpected error fields %+v", bcErr)
+		}
+	}
+}
+
+func TestNotAuthorizedIsNotAccessDenied(t *testing.T) {
+	err := common.FromDBus(dbus.NewError(common.ERROR_ACCESS_DENIED, []interface{}{"Rejected send message"}))
+	if errors.Is(err, common.ErrNotAuthorized) {
+		t.Fatal("expected a denial by the bus policy not to match common.ErrNotAuthorized")
+	}
+}
+
+func TestFromDBusUnknownName(t *testing.T) {
+	err := common.FromDBus(dbus.NewError("org.freedesktop.DBus.Error.Failed", []interface{}{"List units not found"}))
+	if errors.Unwrap(err) != nil {
+		t.Fatalf("expected no sentinel for a generic failure, got %v", errors.Unwrap(err))
+	}
+	if err.Error() != "List units not found (org.freedesktop.DBus.Error.Failed)" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestFromDBusOtherErrors(t *testing.T) {
+	if common.FromDBus(nil) != nil {
+		t.Fatal("expected nil for nil")
+	}
+	other := errors.New("other")
+	if common.FromDBus(other) != other {
+		t.Fatal("expected other errors to be returned unchanged")
+	}
+}
+
+func TestMultiError(t *testing.T) {
+	offline := &common.Error{Name: common.ERROR_OFFLINE, Message: "Node is offline"}
+	multi := &common.MultiError{Op: "start unit app.service"}
+	if multi.ErrorOrNil() != nil {
+		t.Fatal("expected an empty MultiError to be nil")
+	}
+	multi.Add("n2", fmt.Errorf("failed to start unit app.service on node n2: %w", offline))
+	multi.Add("n1", context.DeadlineExceeded)
+
+	var err error = multi
+	if !errors.Is(err, common.ErrNodeOffline) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v to match the errors of both nodes", err)
+	}
+	var nodeErr *common.NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Node != "n1" || nodeErr.Op != "start unit app.service" {
+		t.Fatalf("expected the error of n1 first, got %+v", nodeErr)
+	}
+	if got := multi.NodeErrors()[1].DBusError(); got != offline {
+		t.Fatalf("expected the D-Bus error of n2, got %v", got)
+	}
+
+	data, err := json.Marshal(multi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"operation":"start unit app.service","errors":[` +
+		`{"node":"n1","operation":"start unit app.service","error":"context deadline exceeded"},` +
+		`{"node":"n2","operation":"start unit app.service","error":"failed to start unit app.service on node n2: Node is offline (org.eclipse.bluechi.Offline)","dbusError":"org.eclipse.bluechi.Offline"}]}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+// The internal objects and interfaces are used between the controller, the
+// agents and bluechi-proxy. Clients should not call them, they are listed
+// for tools inspecting the traffic on a BlueChi connection.
+
+/* Internal objects */
+const (
+	INTERNAL_CONTROLLER_OBJECT_PATH    = BC_OBJECT_PATH + "/internal"
+	INTERNAL_AGENT_OBJECT_PATH         = BC_OBJECT_PATH + "/internal/agent"
+	INTERNAL_PROXY_OBJECT_PATH_PREFIX  = BC_OBJECT_PATH + "/internal/proxy"
+	INTERNAL_AGENT_METRICS_OBJECT_PATH = INTERNAL_AGENT_OBJECT_PATH + "/metrics"
+)
+
+/* Internal interfaces */
+const (
+	INTERNAL_CONTROLLER_INTERFACE    = BC_INTERFACE_BASE_NAME + ".internal.Controller"
+	INTERNAL_AGENT_INTERFACE         = BC_INTERFACE_BASE_NAME + ".internal.Agent"
+	INTERNAL_PROXY_INTERFACE         = BC_INTERFACE_BASE_NAME + ".internal.Proxy"
+	INTERNAL_AGENT_METRICS_INTERFACE = INTERNAL_AGENT_INTERFACE + ".Metrics"
+)
+
+/* Internal controller methods */
+const (
+	METHOD_INTERNAL_CONTROLLER_REGISTER = INTERNAL_CONTROLLER_INTERFACE + ".Register"
+)
+
+/* Internal agent methods */
+const (
+	METHOD_INTERNAL_AGENT_START_UNIT          = INTERNAL_AGENT_INTERFACE + ".StartUnit"
+	METHOD_INTERNAL_AGENT_STOP_UNIT           = INTERNAL_AGENT_INTERFACE + ".StopUnit"
+	METHOD_INTERNAL_AGENT_RESTART_UNIT        = INTERNAL_AGENT_INTERFACE + ".RestartUnit"
+	METHOD_INTERNAL_AGENT_RELOAD_UNIT         = INTERNAL_AGENT_INTERFACE + ".ReloadUnit"
+	METHOD_INTERNAL_AGENT_FREEZE_UNIT         = INTERNAL_AGENT_INTERFACE + ".FreezeUnit"
+	METHOD_INTERNAL_AGENT_THAW_UNIT           = INTERNAL_AGENT_INTERFACE + ".ThawUnit"
+	METHOD_INTERNAL_AGENT_GET_UNIT_PROPERTIES = INTERNAL_AGENT_INTERFACE + ".GetUnitProperties"
+	METHOD_INTERNAL_AGENT_GET_UNIT_PROPERTY   = INTERNAL_AGENT_INTERFACE + ".GetUnitProperty"
+	METHOD_INTERNAL_AGENT_SET_UNIT_PROPERTIES = INTERNAL_AGENT_INTERFACE + ".SetUnitProperties"
+	METHOD_INTERNAL_AGENT_ENABLE_UNIT_FILES   = INTERNAL_AGENT_INTERFACE + ".EnableUnitFiles"
+	METHOD_INTERNAL_AGENT_DISABLE_UNIT_FILES  = INTERNAL_AGENT_INTERFACE + ".DisableUnitFiles"
+	METHOD_INTERNAL_AGENT_LISTUNITS           = INTERNAL_AGENT_INTERFACE + ".ListUnits"
+	METHOD_INTERNAL_AGENT_SUBSCRIBE           = INTERNAL_AGENT_INTERFACE + ".Subscribe"
+	METHOD_INTERNAL_AGENT_UNSUBSCRIBE         = INTERNAL_AGENT_INTERFACE + ".Unsubscribe"
+	METHOD_INTERNAL_AGENT_ENABLE_METRICS      = INTERNAL_AGENT_INTERFACE + ".EnableMetrics"
+	METHOD_INTERNAL_AGENT_DISABLE_METRICS     = INTERNAL_AGENT_INTERFACE + ".DisableMetrics"
+	METHOD_INTERNAL_AGENT_START_DEP           = INTERNAL_AGENT_INTERFACE + ".StartDep"
+	METHOD_INTERNAL_AGENT_STOP_DEP            = INTERNAL_AGENT_INTERFACE + ".StopDep"
+	METHOD_INTERNAL_AGENT_RELOAD              = INTERNAL_AGENT_INTERFACE + ".Reload"
+	METHOD_INTERNAL_AGENT_SET_LOG_LEVEL       = INTERNAL_AGENT_INTERFACE + ".SetLogLevel"
+)
+
+/* Internal agent signals */
+const (
+	SIGNAL_INTERNAL_AGENT_JOB_DONE                = INTERNAL_AGENT_INTERFACE + ".JobDone"
+	SIGNAL_INTERNAL_AGENT_JOB_STATE_CHANGED       = INTERNAL_AGENT_INTERFACE + ".JobStateChanged"
+	SIGNAL_INTERNAL_AGENT_UNIT_NEW                = INTERNAL_AGENT_INTERFACE + ".UnitNew"
+	SIGNAL_INTERNAL_AGENT_UNIT_REMOVED            = INTERNAL_AGENT_INTERFACE + ".UnitRemoved"
+	SIGNAL_INTERNAL_AGENT_UNIT_STATE_CHANGED      = INTERNAL_AGENT_INTERFACE + ".UnitStateChanged"
+	SIGNAL_INTERNAL_AGENT_UNIT_PROPERTIES_CHANGED = INTERNAL_AGENT_INTERFACE + ".UnitPropertiesChanged"
+	SIGNAL_INTERNAL_AGENT_PROXY_NEW               = INTERNAL_AGENT_INTERFACE + ".ProxyNew"
+	SIGNAL_INTERNAL_AGENT_PROXY_REMOVED           = INTERNAL_AGENT_INTERFACE + ".ProxyRemoved"
+	SIGNAL_INTERNAL_AGENT_HEARTBEAT               = INTERNAL_AGENT_INTERFACE + ".Heartbeat"
+
+	SIGNAL_INTERNAL_AGENT_METRICS_AGENT_JOB_METRICS = INTERNAL_AGENT_METRICS_INTERFACE + ".AgentJobMetrics"
+)
+
+/* Internal proxy methods */
+const (
+	METHOD_INTERNAL_PROXY_ERROR                = INTERNAL_PROXY_INTERFACE + ".Error"
+	METHOD_INTERNAL_PROXY_TARGET_NEW           = INTERNAL_PROXY_INTERFACE + ".TargetNew"
+	METHOD_INTERNAL_PROXY_TARGET_STATE_CHANGED = INTERNAL_PROXY_INTERFACE + ".TargetStateChanged"
+	METHOD_INTERNAL_PROXY_TARGET_REMOVED       = INTERNAL_PROXY_INTERFACE + ".TargetRemoved"
+)
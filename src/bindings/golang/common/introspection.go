@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// Introspection is the introspection data of an object, i.e. its
+// interfaces with their methods, signals and properties, and the names of
+// its child objects. It allows detecting at runtime which features the
+// BlueChi version serving the object supports.
+type Introspection struct {
+	introspect.Node
+}
+
+// Introspect calls the Introspect method on obj and parses the returned
+// XML.
+func Introspect(ctx context.Context, obj dbus.BusObject) (Introspection, error) {
+	var data string
+	if err := obj.CallWithContext(ctx, METHOD_INTROSPECT, 0).Store(&data); err != nil {
+		return Introspection{}, fmt.Errorf("failed to introspect %s: %w", obj.Path(), err)
+	}
+	var i Introspection
+	if err := xml.Unmarshal([]byte(data), &i.Node); err != nil {
+		return Introspection{}, fmt.Errorf("failed to parse introspection data of %s: %w", obj.Path(), err)
+	}
+	return i, nil
+}
+
+// Interface returns the named interface.
+func (i Introspection) Interface(name string) (introspect.Interface, bool) {
+	for _, iface := range i.Interfaces {
+		if iface.Name == name {
+			return iface, true
+		}
+	}
+	return introspect.Interface{}, false
+}
+
+// HasInterface reports whether the object implements the named interface.
+func (i Introspection) HasInterface(name string) bool {
+	_, ok := i.Interface(name)
+	return ok
+}
+
+// HasMethod reports whether the named interface has the method, e.g.
+// HasMethod(NODE_INTERFACE, "EnableUnitFiles").
+func (i Introspection) HasMethod(iface string, method string) bool {
+	ifc, _ := i.Interface(iface)
+	for _, m := range ifc.Methods {
+		if m.Name == method {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSignal reports whether the named interface has the signal.
+func (i Introspection) HasSignal(iface string, signal string) bool {
+	ifc, _ := i.Interface(iface)
+	for _, s := range ifc.Signals {
+		if s.Name == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// HasProperty reports whether the named interface has the property.
+func (i Introspection) HasProperty(iface string, property string) bool {
+	ifc, _ := i.Interface(iface)
+	for _, p := range ifc.Properties {
+		if p.Name == property {
+			return true
+		}
+	}
+	return false
+}
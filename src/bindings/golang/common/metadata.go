@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+import "context"
+
+// Metadata describes who issued an operation and why. Attached to the
+// context of a call with WithMetadata, it is passed to the hooks of a
+// manager.Manager, added to the spans of the D-Bus calls and to the log
+// records written for them, so that audit trails can be joined with change
+// tickets.
+type Metadata struct {
+	// Initiator is the user or system the operation is issued for, e.g.
+	// "alice" or "deploy-pipeline".
+	Initiator string
+	// Reason is a free-form description of why the operation is issued.
+	Reason string
+	// TicketID is the identifier of the change or incident ticket the
+	// operation belongs to.
+	TicketID string
+}
+
+// IsZero reports whether no field of md is set.
+func (md Metadata) IsZero() bool {
+	return md == Metadata{}
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx carrying md. The fields of md which are
+// empty keep the value of the metadata already carried by ctx, so that e.g.
+// a workflow sets the initiator once and its steps add their reason.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	if parent, ok := MetadataFromContext(ctx); ok {
+		md = parent.merge(md)
+	}
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFromContext returns the metadata carried by ctx, false if there
+// is none.
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(metadataKey{}).(Metadata)
+	return md, ok
+}
+
+// merge returns md with the fields set in other replaced.
+func (md Metadata) merge(other Metadata) Metadata {
+	if other.Initiator != "" {
+		md.Initiator = other.Initiator
+	}
+	if other.Reason != "" {
+		md.Reason = other.Reason
+	}
+	if other.TicketID != "" {
+		md.TicketID = other.TicketID
+	}
+	return md
+}
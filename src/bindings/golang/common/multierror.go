@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NodeError is the error of an operation on a single node of a fan-out
+// operation, e.g. of starting a unit on many nodes.
+type NodeError struct {
+	// Node is the name of the node.
+	Node string
+	// Op describes the operation, e.g. "start unit app.service".
+	Op string
+	// Err is the error of the operation, which wraps an *Error if a D-Bus
+	// call failed.
+	Err error
+}
+
+func (e *NodeError) Error() string {
+	return e.Node + ": " + e.Err.Error()
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// DBusError returns the D-Bus error reply the operation failed with, nil if
+// it failed otherwise, e.g. because its context was done.
+func (e *NodeError) DBusError() *Error {
+	var dbusErr *Error
+	if errors.As(e.Err, &dbusErr) {
+		return dbusErr
+	}
+	return nil
+}
+
+// MarshalJSON encodes the node error with the message of the error and,
+// if the operation failed with a D-Bus error reply, its name.
+func (e *NodeError) MarshalJSON() ([]byte, error) {
+	var name string
+	if dbusErr := e.DBusError(); dbusErr != nil {
+		name = dbusErr.Name
+	}
+	return json.Marshal(struct {
+		Node      string `json:"node"`
+		Op        string `json:"operation"`
+		Error     string `json:"error"`
+		DBusError string `json:"dbusError,omitempty"`
+	}{e.Node, e.Op, e.Err.Error(), name})
+}
+
+// MultiError collects the errors of a fan-out operation keyed by node name.
+// errors.Is and errors.As match the error of any node, including the
+// *NodeError itself.
+type MultiError struct {
+	// Op describes the operation, e.g. "start unit app.service".
+	Op string
+	// Errors are the errors of the failed nodes, each a *NodeError when
+	// added with Add.
+	Errors map[string]error
+}
+
+// Add records err of the named node, wrapped in a *NodeError with the
+// operation of the MultiError unless it is one already.
+func (e *MultiError) Add(node string, err error) {
+	if e.Errors == nil {
+		e.Errors = make(map[string]error)
+	}
+	nodeErr, ok := err.(*NodeError)
+	if !ok || nodeErr.Node != node {
+		nodeErr = &NodeError{Node: node, Op: e.Op, Err: err}
+	}
+	e.Errors[node] = nodeErr
+}
+
+// ErrorOrNil returns e if any node failed and nil otherwise, so that an
+// empty MultiError is not returned as a non-nil error.
+func (e *MultiError) ErrorOrNil() error {
+	if e == nil || len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// NodeErrors returns the errors sorted by node name, errors not added with
+// Add wrapped like by Add.
+func (e *MultiError) NodeErrors() []*NodeError {
+	names := e.nodes()
+	errs := make([]*NodeError, 0, len(names))
+	for _, name := range names {
+		nodeErr, ok := e.Errors[name].(*NodeError)
+		if !ok {
+			nodeErr = &NodeError{Node: name, Op: e.Op, Err: e.Errors[name]}
+		}
+		errs = append(errs, nodeErr)
+	}
+	return errs
+}
+
+func (e *MultiError) Error() string {
+	errs := e.NodeErrors()
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	prefix := "failed"
+	if e.Op != "" {
+		prefix = "failed to " + e.Op
+	}
+	return fmt.Sprintf("%s on %d node(s): %s", prefix, len(errs), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the node errors sorted by node name, so that errors.Is and
+// errors.As match the error of any node.
+func (e *MultiError) Unwrap() []error {
+	nodeErrs := e.NodeErrors()
+	errs := make([]error, 0, len(nodeErrs))
+	for _, err := range nodeErrs {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// MarshalJSON encodes the operation and the node errors sorted by node
+// name, e.g. for a report of a batch operation.
+func (e *MultiError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Op     string       `json:"operation,omitempty"`
+		Errors []*NodeError `json:"errors"`
+	}{e.Op, e.NodeErrors()})
+}
+
+func (e *MultiError) nodes() []string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
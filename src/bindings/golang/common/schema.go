@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// replySignatures are the signatures of the replies of the public BlueChi
+// methods as of the introspection XML files, and of the standard methods
+// called by the bindings.
+var replySignatures = map[string]string{
+	METHOD_LISTNODES:              "a(sos)",
+	METHOD_GETNODE:                "o",
+	METHOD_LISTUNITS:              "a(sssssssouso)",
+	METHOD_CREATE_MONITOR:         "o",
+	METHOD_SET_LOG_LEVEL:          "",
+	METHOD_ENABLE_METRICS:         "",
+	METHOD_DISABLE_METRICS:        "",
+	METHOD_START_UNIT:             "o",
+	METHOD_STOP_UNIT:              "o",
+	METHOD_RESTART_UNIT:           "o",
+	METHOD_RELOAD_UNIT:            "o",
+	METHOD_FREEZE_UNIT:            "",
+	METHOD_THAW_UNIT:              "",
+	METHOD_NODE_LISTUNITS:         "a(ssssssouso)",
+	METHOD_RELOAD:                 "",
+	METHOD_NODE_SET_LOG_LEVEL:     "",
+	METHOD_GET_UNIT_PROPERTIES:    "a{sv}",
+	METHOD_GET_UNIT_PROPERTY:      "v",
+	METHOD_SET_UNIT_PROPERTIES:    "",
+	METHOD_ENABLE_UNIT_FILES:      "ba(sss)",
+	METHOD_DISABLE_UNIT_FILES:     "a(sss)",
+	METHOD_AGENT_CREATE_PROXY:     "",
+	METHOD_AGENT_REMOVE_PROXY:     "",
+	METHOD_JOB_CANCEL:             "",
+	METHOD_MONITOR_SUBSCRIBE:      "u",
+	METHOD_MONITOR_SUBSCRIBE_LIST: "u",
+	METHOD_MONITOR_UNSUBSCRIBE:    "",
+	METHOD_MONITOR_CLOSE:          "",
+	METHOD_MONITOR_ADD_PEER:       "u",
+	METHOD_MONITOR_REMOVE_PEER:    "",
+	METHOD_PROPERTIES_GET:         "v",
+	METHOD_PROPERTIES_GETALL:      "a{sv}",
+	METHOD_PROPERTIES_SET:         "",
+	METHOD_INTROSPECT:             "s",
+	METHOD_PEER_PING:              "",
+}
+
+// ReplySignature returns the signature of the reply of method, e.g.
+// "a(sos)" for METHOD_LISTNODES, and false for methods without a known
+// schema, e.g. the ones added after the introspection XML files the
+// bindings were built from.
+func ReplySignature(method string) (dbus.Signature, bool) {
+	sig, ok := replySignatures[method]
+	if !ok {
+		return dbus.Signature{}, false
+	}
+	return dbus.ParseSignatureMust(sig), true
+}
+
+// SchemaError is returned in strict decode mode, see
+// manager.WithStrictDecode, for a reply not matching the signature of its
+// method, e.g. of a controller of another version. It locates the first
+// mismatch instead of the generic error of decoding the reply.
+type SchemaError struct {
+	// Method is the method replied to.
+	Method string
+	// Index is the index of the mismatching return value.
+	Index int
+	// Field locates the mismatch in the return value, empty for the value
+	// itself, e.g. "[0].7" for the field 7 of the first struct of an
+	// array or "[Id]" for the value of the key Id of a dict.
+	Field string
+	// Expected is the signature expected at the mismatch, empty if the
+	// reply has more values than expected.
+	Expected string
+	// Actual is the signature of the reply at the mismatch, empty if the
+	// reply has fewer values than expected.
+	Actual string
+}
+
+func (e *SchemaError) Error() string {
+	expected, actual := e.Expected, e.Actual
+	if expected == "" {
+		expected = "nothing"
+	}
+	if actual == "" {
+		actual = "nothing"
+	}
+	at := fmt.Sprintf("value %d", e.Index)
+	if e.Field != "" {
+		at += " at " + e.Field
+	}
+	return fmt.Sprintf("reply of %s does not match its schema: %s: expected %s, got %s", e.Method, at, expected, actual)
+}
+
+// CheckReply checks the values body of the reply of method as decoded by
+// godbus against the signature of the method, see ReplySignature, and
+// returns a *SchemaError for the first mismatch. Replies of methods without
+// a known schema pass, godbus decodes structs as []interface{}.
+func CheckReply(method string, body []interface{}) error {
+	sig, ok := replySignatures[method]
+	if !ok {
+		return nil
+	}
+	types := splitSignature(sig)
+	for i := 0; i < len(types) || i < len(body); i++ {
+		e := &SchemaError{Method: method, Index: i}
+		switch {
+		case i >= len(body):
+			e.Expected = types[i]
+		case i >= len(types):
+			e.Actual = signatureOf(reflect.ValueOf(body[i]))
+		default:
+			if !checkValue(reflect.ValueOf(body[i]), types[i], "", e) {
+				return e
+			}
+			continue
+		}
+		return e
+	}
+	return nil
+}
+
+// checkValue checks v against the single complete type sig, filling in e
+// with the mismatch at the location field if it does not match.
+func checkValue(v reflect.Value, sig string, field string, e *SchemaError) bool {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	fail := func() bool {
+		e.Field, e.Expected, e.Actual = field, sig, signatureOf(v)
+		return false
+	}
+	if !v.IsValid() {
+		return fail()
+	}
+
+	switch {
+	case sig[0] == '(':
+		fields, ok := v.Interface().([]interface{})
+		types := splitSignature(sig[1 : len(sig)-1])
+		if !ok || len(fields) != len(types) {
+			return fail()
+		}
+		for i, f := range fields {
+			if !checkValue(reflect.ValueOf(f), types[i], fmt.Sprintf("%s.%d", field, i), e) {
+				return false
+			}
+		}
+		return true
+	case strings.HasPrefix(sig, "a{"):
+		if v.Kind() != reflect.Map {
+			return fail()
+		}
+		entry := splitSignature(sig[2 : len(sig)-1])
+		if signatureOfType(v.Type().Key()) != entry[0] {
+			return fail()
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if !checkValue(iter.Value(), entry[1], fmt.Sprintf("%s[%v]", field, iter.Key()), e) {
+				return false
+			}
+		}
+		return true
+	case sig[0] == 'a' && strings.ContainsAny(sig[1:], "(v"):
+		// arrays of structs and variants are checked element by element,
+		// the others by their type
+		if v.Kind() != reflect.Slice {
+			return fail()
+		}
+		for i := 0; i < v.Len(); i++ {
+			if !checkValue(v.Index(i), sig[1:], fmt.Sprintf("%s[%d]", field, i), e) {
+				return false
+			}
+		}
+		return true
+	}
+	if signatureOf(v) != sig {
+		return fail()
+	}
+	return true
+}
+
+// signatureOf returns the signature of the value v decoded by godbus.
+func signatureOf(v reflect.Value) string {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	if fields, ok := v.Interface().([]interface{}); ok {
+		var b strings.Builder
+		b.WriteByte('(')
+		for _, f := range fields {
+			b.WriteString(signatureOf(reflect.ValueOf(f)))
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf([]interface{}{}) {
+		// the fields of an empty array of structs are unknown
+		if v.Len() == 0 {
+			return "a()"
+		}
+		return "a" + signatureOf(v.Index(0))
+	}
+	return signatureOfType(v.Type())
+}
+
+func signatureOfType(t reflect.Type) (sig string) {
+	defer func() {
+		// godbus panics for types it cannot encode
+		if recover() != nil {
+			sig = t.String()
+		}
+	}()
+	return dbus.SignatureOfType(t).String()
+}
+
+// splitSignature splits the signature sig into its complete types.
+func splitSignature(sig string) []string {
+	var types []string
+	for sig != "" {
+		n := completeTypeLen(sig)
+		types = append(types, sig[:n])
+		sig = sig[n:]
+	}
+	return types
+}
+
+// completeTypeLen returns the length of the complete type sig starts with.
+func completeTypeLen(sig string) int {
+	switch sig[0] {
+	case 'a':
+		return 1 + completeTypeLen(sig[1:])
+	case '(', '{':
+		depth := 0
+		for i := 0; i < len(sig); i++ {
+			switch sig[i] {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(sig)
+	}
+	return 1
+}
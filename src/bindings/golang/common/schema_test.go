@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package common_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// TestReplySignatures checks that each method of the public introspection
+// XML files has the signature of its reply.
+func TestReplySignatures(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("..", "..", "..", "..", "data", "org.eclipse.bluechi*.xml"))
+	if len(files) == 0 {
+		t.Skip("introspection XML files not available")
+	}
+	for _, file := range files {
+		if strings.Contains(file, ".internal.") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var node introspect.Node
+		if err := xml.Unmarshal(data, &node); err != nil {
+			t.Fatalf("failed to parse %s: %v", file, err)
+		}
+		for _, iface := range node.Interfaces {
+			for _, m := range iface.Methods {
+				var want string
+				for _, arg := range m.Args {
+					if arg.Direction == "out" {
+						want += arg.Type
+					}
+				}
+				method := iface.Name + "." + m.Name
+				sig, ok := common.ReplySignature(method)
+				if !ok {
+					t.Errorf("no reply signature for method %s", method)
+				} else if sig.String() != want {
+					t.Errorf("expected reply signature %q for method %s, got %q", want, method, sig.String())
+				}
+			}
+		}
+	}
+}
+
+func TestCheckReply(t *testing.T) {
+	unit := func(fields ...interface{}) []interface{} {
+		return fields
+	}
+	units := func(n int) [][]interface{} {
+		u := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			u = append(u, "x")
+		}
+		return [][]interface{}{u}
+	}
+	listed := units(11)
+	listed[0][7] = dbus.ObjectPath("/org/freedesktop/systemd1/unit/x")
+	listed[0][8] = uint32(0)
+	listed[0][9] = "x"
+	listed[0][10] = dbus.ObjectPath("/")
+
+	tests := []struct {
+		method string
+		body   []interface{}
+		want   *common.SchemaError
+	}{
+		{common.METHOD_GETNODE, []interface{}{dbus.ObjectPath("/org/eclipse/bluechi/node/n")}, nil},
+		{common.METHOD_GETNODE, []interface{}{"n"}, &common.SchemaError{Expected: "o", Actual: "s"}},
+		{common.METHOD_GETNODE, nil, &common.SchemaError{Expected: "o"}},
+		{common.METHOD_JOB_CANCEL, []interface{}{uint32(1)}, &common.SchemaError{Actual: "u"}},
+		{common.METHOD_LISTNODES, []interface{}{[][]interface{}{}}, nil},
+		{common.METHOD_LISTNODES, []interface{}{[][]interface{}{unit("n", dbus.ObjectPath("/n"), "online")}}, nil},
+		{common.METHOD_LISTNODES, []interface{}{[][]interface{}{unit("n", dbus.ObjectPath("/n"), uint32(1))}},
+			&common.SchemaError{Field: "[0].2", Expected: "s", Actual: "u"}},
+		{common.METHOD_LISTNODES, []interface{}{[][]interface{}{unit("n", dbus.ObjectPath("/n"))}},
+			&common.SchemaError{Field: "[0]", Expected: "(sos)", Actual: "(so)"}},
+		{common.METHOD_LISTUNITS, []interface{}{units(11)},
+			&common.SchemaError{Field: "[0].7", Expected: "o", Actual: "s"}},
+		{common.METHOD_LISTUNITS, []interface{}{listed}, nil},
+		{common.METHOD_ENABLE_UNIT_FILES, []interface{}{true, [][]interface{}{unit("symlink", "a", "b")}}, nil},
+		{common.METHOD_ENABLE_UNIT_FILES, []interface{}{[][]interface{}{unit("symlink", "a", "b")}},
+			&common.SchemaError{Expected: "b", Actual: "a(sss)"}},
+		{common.METHOD_GET_UNIT_PROPERTIES, []interface{}{map[string]dbus.Variant{"Id": dbus.MakeVariant("x")}}, nil},
+		{common.METHOD_GET_UNIT_PROPERTIES, []interface{}{map[string]string{"Id": "x"}},
+			&common.SchemaError{Field: "[Id]", Expected: "v", Actual: "s"}},
+		{"org.example.Unknown", []interface{}{"anything"}, nil},
+	}
+	for _, tt := range tests {
+		err := common.CheckReply(tt.method, tt.body)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("CheckReply(%s, %v) failed: %v", tt.method, tt.body, err)
+			}
+			continue
+		}
+		var e *common.SchemaError
+		if !errors.As(err, &e) {
+			t.Errorf("CheckReply(%s, %v): expected a SchemaError, got %v", tt.method, tt.body, err)
+			continue
+		}
+		tt.want.Method = tt.method
+		if *e != *tt.want {
+			t.Errorf("CheckReply(%s, %v): expected %+v, got %+v", tt.method, tt.body, tt.want, e)
+		}
+	}
+
+	err := common.CheckReply(common.METHOD_LISTNODES, []interface{}{[][]interface{}{unit("n", dbus.ObjectPath("/n"), uint32(1))}})
+	want := "reply of org.eclipse.bluechi.Controller.ListNodes does not match its schema: value 0 at [0].2: expected s, got u"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
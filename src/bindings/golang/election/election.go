@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package election elects a single leader among the replicas of a program
+// built on the bindings, e.g. an orchestrator deployed highly available, so
+// that only one of them operates on the cluster at a time. The replicas
+// compete for a lease kept by a Backend shared by all of them; the holder
+// renews it periodically and loses leadership once it could not renew it
+// in time.
+//
+// An Elector is a manager.Hook denying the mutating calls of a replica
+// while it is not the leader. Reading calls and monitors stay available, so
+// the other replicas keep warm caches, e.g. with package unitcache, and can
+// take over without reloading the state of the cluster:
+//
+//	e := election.New(backend, hostname)
+//	m, err := manager.NewManager(manager.WithHook(e))
+//	...
+//	err = e.Run(ctx, func(ctx context.Context) error {
+//		// operate on the cluster until ctx is done
+//	})
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+// Defaults of an Elector.
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRetryInterval = 5 * time.Second
+)
+
+// ErrNotLeader is returned by the mutating calls of a Manager using an
+// Elector as hook while the replica is not the leader.
+var ErrNotLeader = errors.New("not the leader")
+
+// Lease is the leadership granted to a holder until it expires.
+type Lease struct {
+	// Holder is the identity of the leader, empty if the lease is free.
+	Holder string
+	// Expires is the time the lease expires unless renewed, by the clock of
+	// the backend.
+	Expires time.Time
+}
+
+// Backend stores the lease the replicas compete for, e.g. in a database, a
+// key-value store or a Kubernetes Lease object. The methods must be safe
+// for concurrent use and update the lease atomically.
+type Backend interface {
+	// Acquire grants the lease to holder for ttl if it is free, expired or
+	// already held by holder, and returns the lease as stored afterwards.
+	// A lease held by another replica is returned without an error.
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (Lease, error)
+	// Release frees the lease if it is held by holder.
+	Release(ctx context.Context, holder string) error
+}
+
+// Option configures an Elector.
+type Option func(*options)
+
+type options struct {
+	leaseDuration time.Duration
+	retryInterval time.Duration
+}
+
+// WithLeaseDuration sets the duration a lease is granted for,
+// DefaultLeaseDuration by default. A leader failing to renew its lease
+// within the duration steps down, so it bounds the time the cluster has no
+// leader after the leader died.
+func WithLeaseDuration(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.leaseDuration = d
+		}
+	}
+}
+
+// WithRetryInterval sets the interval the leader renews its lease in and
+// the other replicas try to acquire it, DefaultRetryInterval by default. It
+// is reduced to a third of the lease duration if longer, so the leader can
+// miss a renewal without stepping down.
+func WithRetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.retryInterval = d
+		}
+	}
+}
+
+// Elector competes for the lease of a Backend on behalf of a replica.
+type Elector struct {
+	backend  Backend
+	identity string
+	o        options
+
+	mu     sync.Mutex
+	leader string
+	// until is the time the own lease expires by the local clock, zero
+	// while not leading
+	until time.Time
+}
+
+var _ manager.Hook = (*Elector)(nil)
+
+// New returns an Elector competing for the lease of backend as identity,
+// which must be unique among the replicas, e.g. the host name.
+func New(backend Backend, identity string, opts ...Option) *Elector {
+	o := options{leaseDuration: DefaultLeaseDuration, retryInterval: DefaultRetryInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.retryInterval = min(o.retryInterval, o.leaseDuration/3)
+	return &Elector{backend: backend, identity: identity, o: o}
+}
+
+// Identity returns the identity the Elector competes as.
+func (e *Elector) Identity() string {
+	return e.identity
+}
+
+// IsLeader reports whether the replica holds the lease.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leadingLocked()
+}
+
+func (e *Elector) leadingLocked() bool {
+	return !e.until.IsZero() && time.Now().Before(e.until)
+}
+
+// Leader returns the identity of the leader last seen, empty if the lease
+// was free or not read yet.
+func (e *Elector) Leader() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Run competes for the lease until ctx is done. Each time the replica
+// becomes the leader, lead is called with a context which is cancelled when
+// the lease is lost, and Run waits for it to return before competing
+// again. Run releases the lease and returns when ctx is done, nil then, or
+// when lead returns while still leading, with the error of lead.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context) error) error {
+	var (
+		cancel context.CancelFunc
+		done   chan error
+	)
+	stop := func() {
+		if cancel != nil {
+			cancel()
+			<-done
+			cancel, done = nil, nil
+		}
+	}
+	defer func() {
+		stop()
+		e.release()
+	}()
+
+	ticker := time.NewTicker(e.o.retryInterval)
+	defer ticker.Stop()
+	for {
+		if cancel != nil && !e.IsLeader() {
+			// the lease expired before it was renewed, another replica
+			// may have acquired it meanwhile
+			stop()
+		}
+		if e.tryAcquire(ctx) {
+			if cancel == nil {
+				var term context.Context
+				term, cancel = context.WithCancel(ctx)
+				done = make(chan error, 1)
+				go func() { done <- lead(term) }()
+			}
+		} else {
+			stop()
+		}
+		// step down once the lease expired for lack of renewals
+		var expired <-chan time.Time
+		if cancel != nil {
+			expired = time.After(time.Until(e.deadline()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-expired:
+			stop()
+		case err := <-done:
+			cancel()
+			cancel, done = nil, nil
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// deadline returns the time the own lease expires by the local clock.
+func (e *Elector) deadline() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.until
+}
+
+// tryAcquire acquires or renews the lease and reports whether the replica
+// is the leader. A failed renewal keeps the leadership until the lease
+// would have expired.
+func (e *Elector) tryAcquire(ctx context.Context) bool {
+	start := time.Now()
+	lease, err := e.backend.Acquire(ctx, e.identity, e.o.leaseDuration)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		return e.leadingLocked()
+	}
+	e.leader = lease.Holder
+	if lease.Holder != e.identity {
+		e.until = time.Time{}
+		return false
+	}
+	// the lease may expire by the clock of the backend before it does by
+	// the local one, the start of the call is a safe lower bound
+	e.until = start.Add(e.o.leaseDuration)
+	return true
+}
+
+func (e *Elector) release() {
+	e.mu.Lock()
+	leading := !e.until.IsZero()
+	e.until = time.Time{}
+	if leading {
+		e.leader = ""
+	}
+	e.mu.Unlock()
+
+	if leading {
+		ctx, cancel := context.WithTimeout(context.Background(), e.o.retryInterval)
+		defer cancel()
+		_ = e.backend.Release(ctx, e.identity)
+	}
+}
+
+// Before denies op with ErrNotLeader unless the replica is the leader.
+func (e *Elector) Before(ctx context.Context, op manager.Operation) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.leadingLocked() {
+		return nil
+	}
+	if e.leader != "" {
+		return fmt.Errorf("%w, %s is", ErrNotLeader, e.leader)
+	}
+	return ErrNotLeader
+}
+
+// After does nothing, it completes the manager.Hook interface.
+func (e *Elector) After(ctx context.Context, op manager.Operation, err error) {}
+
+// MemoryBackend keeps a lease in memory, e.g. for replicas running as
+// goroutines of a single process or for tests.
+type MemoryBackend struct {
+	mu    sync.Mutex
+	lease Lease
+}
+
+// NewMemoryBackend returns a MemoryBackend with a free lease.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+// Acquire implements Backend.
+func (b *MemoryBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (Lease, error) {
+	if err := ctx.Err(); err != nil {
+		return Lease{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if b.lease.Holder == "" || b.lease.Holder == holder || !now.Before(b.lease.Expires) {
+		b.lease = Lease{Holder: holder, Expires: now.Add(ttl)}
+	}
+	return b.lease, nil
+}
+
+// Release implements Backend.
+func (b *MemoryBackend) Release(ctx context.Context, holder string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.lease.Holder == holder {
+		b.lease = Lease{}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package election_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/election"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+var opts = []election.Option{
+	election.WithLeaseDuration(300 * time.Millisecond),
+	election.WithRetryInterval(20 * time.Millisecond),
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// replica runs an Elector whose lead function blocks until its context is
+// done, counting the terms.
+type replica struct {
+	*election.Elector
+	terms  atomic.Int32
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func start(backend election.Backend, identity string) *replica {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &replica{Elector: election.New(backend, identity, opts...), cancel: cancel, done: make(chan error, 1)}
+	go func() {
+		r.done <- r.Run(ctx, func(ctx context.Context) error {
+			r.terms.Add(1)
+			<-ctx.Done()
+			return nil
+		})
+	}()
+	return r
+}
+
+func (r *replica) stop(t *testing.T) {
+	t.Helper()
+	r.cancel()
+	if err := <-r.done; err != nil {
+		t.Errorf("%s: Run returned %v", r.Identity(), err)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	backend := election.NewMemoryBackend()
+	a := start(backend, "a")
+	waitFor(t, "a to lead", a.IsLeader)
+	b := start(backend, "b")
+	defer b.stop(t)
+
+	waitFor(t, "b to see a", func() bool { return b.Leader() == "a" })
+	if b.IsLeader() || b.terms.Load() != 0 {
+		t.Fatal("b leads while a holds the lease")
+	}
+	err := b.Before(context.Background(), manager.Operation{})
+	if !errors.Is(err, election.ErrNotLeader) {
+		t.Errorf("expected ErrNotLeader for b, got %v", err)
+	}
+	if err := a.Before(context.Background(), manager.Operation{}); err != nil {
+		t.Errorf("expected the leader to be allowed, got %v", err)
+	}
+
+	// a releases the lease when stopping, b takes over right away
+	released := time.Now()
+	a.stop(t)
+	waitFor(t, "b to lead", func() bool { return b.IsLeader() && b.terms.Load() == 1 })
+	if d := time.Since(released); d > 200*time.Millisecond {
+		t.Errorf("b took over after %v, expected it before the lease expired", d)
+	}
+	if a.IsLeader() || a.terms.Load() != 1 {
+		t.Errorf("a leads after stopping, terms %d", a.terms.Load())
+	}
+}
+
+// flakyBackend fails all calls while down is set.
+type flakyBackend struct {
+	election.Backend
+	down atomic.Bool
+}
+
+func (b *flakyBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (election.Lease, error) {
+	if b.down.Load() {
+		return election.Lease{}, errors.New("backend unavailable")
+	}
+	return b.Backend.Acquire(ctx, holder, ttl)
+}
+
+func TestLostLease(t *testing.T) {
+	backend := &flakyBackend{Backend: election.NewMemoryBackend()}
+	a := start(backend, "a")
+	defer a.stop(t)
+	waitFor(t, "a to lead", a.IsLeader)
+
+	// failed renewals keep the leadership until the lease expires
+	backend.down.Store(true)
+	lost := time.Now()
+	time.Sleep(100 * time.Millisecond)
+	if !a.IsLeader() {
+		t.Fatal("a stepped down before the lease expired")
+	}
+	waitFor(t, "a to step down", func() bool { return !a.IsLeader() })
+	if d := time.Since(lost); d > 400*time.Millisecond {
+		t.Errorf("a stepped down after %v", d)
+	}
+
+	backend.down.Store(false)
+	waitFor(t, "a to lead again", func() bool { return a.IsLeader() && a.terms.Load() == 2 })
+}
+
+func TestLeadError(t *testing.T) {
+	backend := election.NewMemoryBackend()
+	e := election.New(backend, "a", opts...)
+	errLead := errors.New("lead failed")
+	err := e.Run(context.Background(), func(ctx context.Context) error { return errLead })
+	if !errors.Is(err, errLead) {
+		t.Fatalf("expected the error of lead, got %v", err)
+	}
+	if e.IsLeader() {
+		t.Error("still leading after Run returned")
+	}
+	if lease, _ := backend.Acquire(context.Background(), "b", time.Second); lease.Holder != "b" {
+		t.Errorf("lease not released, held by %q", lease.Holder)
+	}
+}
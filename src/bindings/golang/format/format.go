@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package format renders the results of the bindings, e.g. the nodes
+// returned by ListNodes, as JSON, YAML or aligned tables, so that tools can
+// offer an --output option without implementing it themselves. JSON and
+// YAML use the keys of the MarshalJSON methods of the result types.
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// Output is an output format.
+type Output string
+
+// Supported output formats.
+const (
+	JSON  Output = "json"
+	YAML  Output = "yaml"
+	Table Output = "table"
+)
+
+// Outputs lists all supported output formats, e.g. for the help text of an
+// --output option.
+var Outputs = []Output{JSON, YAML, Table}
+
+// ParseOutput returns the output format named by s, case-insensitively.
+func ParseOutput(s string) (Output, error) {
+	for _, o := range Outputs {
+		if strings.EqualFold(s, string(o)) {
+			return o, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format %q, expected one of %v", s, Outputs)
+}
+
+// Nodes writes the nodes to w. Tables have the columns NAME, STATUS and
+// PEER IP.
+func Nodes(w io.Writer, out Output, nodes []manager.NodeInfo) error {
+	t := table{header: []string{"NAME", "STATUS", "PEER IP"}}
+	for _, n := range nodes {
+		t.rows = append(t.rows, []string{n.Name, n.Status.String(), n.PeerIP})
+	}
+	if nodes == nil {
+		nodes = []manager.NodeInfo{}
+	}
+	return write(w, out, nodes, t)
+}
+
+// Units writes the units of a single node to w. Tables have the columns
+// NAME, LOAD, ACTIVE, SUB and DESCRIPTION.
+func Units(w io.Writer, out Output, units []node.UnitInfo) error {
+	t := table{header: []string{"NAME", "LOAD", "ACTIVE", "SUB", "DESCRIPTION"}}
+	for _, u := range units {
+		t.rows = append(t.rows, []string{u.Name, u.LoadState.String(), u.ActiveState.String(), u.SubState.String(), u.Description})
+	}
+	if units == nil {
+		units = []node.UnitInfo{}
+	}
+	return write(w, out, units, t)
+}
+
+// NodeUnits writes units keyed by node name as returned by
+// Manager.ListUnits to w. JSON and YAML keep them keyed by node name,
+// tables list them sorted by node with an additional NODE column.
+func NodeUnits(w io.Writer, out Output, units map[string][]node.UnitInfo) error {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t := table{header: []string{"NODE", "NAME", "LOAD", "ACTIVE", "SUB", "DESCRIPTION"}}
+	for _, name := range names {
+		for _, u := range units[name] {
+			t.rows = append(t.rows, []string{name, u.Name, u.LoadState.String(), u.ActiveState.String(), u.SubState.String(), u.Description})
+		}
+	}
+	if units == nil {
+		units = map[string][]node.UnitInfo{}
+	}
+	return write(w, out, units, t)
+}
+
+// Jobs writes the jobs to w. Tables have the columns ID, NODE, UNIT, TYPE
+// and STATE.
+func Jobs(w io.Writer, out Output, jobs []job.Info) error {
+	t := table{header: []string{"ID", "NODE", "UNIT", "TYPE", "STATE"}}
+	for _, j := range jobs {
+		t.rows = append(t.rows, []string{strconv.FormatUint(uint64(j.ID), 10), j.Node, j.Unit, j.JobType, j.State})
+	}
+	if jobs == nil {
+		jobs = []job.Info{}
+	}
+	return write(w, out, jobs, t)
+}
+
+// table is the rendering of a result as table, built by the callers of
+// write as the result types differ.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// write writes v to w in the given output format, nil slices and maps
+// have to be replaced by empty ones to be written as such.
+func write(w io.Writer, out Output, v interface{}, t table) error {
+	switch out {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		return nil
+	case YAML:
+		return writeYAML(w, v)
+	case Table:
+		return writeTable(w, t)
+	default:
+		return fmt.Errorf("unsupported output format %q", out)
+	}
+}
+
+// writeYAML writes v as YAML. It goes through JSON so that both formats
+// share the keys chosen by the MarshalJSON methods.
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(generic); err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+	return nil
+}
+
+func writeTable(w io.Writer, t table) error {
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	_ = tw.Flush()
+
+	// drop the padding of empty cells at the end of rows
+	var trimmed strings.Builder
+	for _, line := range strings.SplitAfter(b.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed.WriteString(strings.TrimRight(line, " \n") + "\n")
+	}
+	if _, err := io.WriteString(w, trimmed.String()); err != nil {
+		return fmt.Errorf("failed to write table: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package format_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/format"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+var nodes = []manager.NodeInfo{
+	{Name: "node1", ObjectPath: "/org/eclipse/bluechi/node/node1", Status: "online", PeerIP: "10.0.0.1"},
+	{Name: "laptop", ObjectPath: "/org/eclipse/bluechi/node/laptop", Status: "offline"},
+}
+
+func TestNodes(t *testing.T) {
+	tests := []struct {
+		out  format.Output
+		want string
+	}{
+		{format.JSON, `[
+  {
+    "name": "node1",
+    "objectPath": "/org/eclipse/bluechi/node/node1",
+    "status": "online",
+    "peerIP": "10.0.0.1"
+  },
+  {
+    "name": "laptop",
+    "objectPath": "/org/eclipse/bluechi/node/laptop",
+    "status": "offline"
+  }
+]
+`},
+		{format.YAML, `- name: node1
+  objectPath: /org/eclipse/bluechi/node/node1
+  peerIP: 10.0.0.1
+  status: online
+- name: laptop
+  objectPath: /org/eclipse/bluechi/node/laptop
+  status: offline
+`},
+		{format.Table, `NAME    STATUS   PEER IP
+node1   online   10.0.0.1
+laptop  offline
+`},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := format.Nodes(&b, tt.out, nodes); err != nil {
+			t.Fatalf("%s: %v", tt.out, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.out, b.String(), tt.want)
+		}
+	}
+}
+
+func TestNodeUnits(t *testing.T) {
+	units := map[string][]node.UnitInfo{
+		"node2": {{Name: "sshd.service", LoadState: "loaded", ActiveState: "active", SubState: "running", ObjectPath: "/u/sshd"}},
+		"node1": {{Name: "nginx.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed", ObjectPath: "/u/nginx",
+			JobID: 7, JobType: "start", JobPath: "/j/7"}},
+	}
+
+	var b bytes.Buffer
+	if err := format.NodeUnits(&b, format.Table, units); err != nil {
+		t.Fatal(err)
+	}
+	want := `NODE   NAME           LOAD    ACTIVE  SUB      DESCRIPTION
+node1  nginx.service  loaded  failed  failed
+node2  sshd.service   loaded  active  running
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := format.Units(&b, format.YAML, units["node1"]); err != nil {
+		t.Fatal(err)
+	}
+	want = `- activeState: failed
+  description: ""
+  jobID: 7
+  jobPath: /j/7
+  jobType: start
+  loadState: loaded
+  name: nginx.service
+  objectPath: /u/nginx
+  subState: failed
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	for out, want := range map[format.Output]string{
+		format.JSON:  "[]\n",
+		format.YAML:  "[]\n",
+		format.Table: "ID  NODE  UNIT  TYPE  STATE\n",
+	} {
+		var b bytes.Buffer
+		if err := format.Jobs(&b, out, nil); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != want {
+			t.Errorf("%s: got %q, want %q", out, b.String(), want)
+		}
+	}
+}
+
+func TestJobs(t *testing.T) {
+	jobs := []job.Info{{ID: 3, ObjectPath: "/org/eclipse/bluechi/job/3", Node: "node1", Unit: "a.service", JobType: "start", State: "running"}}
+
+	var b bytes.Buffer
+	if err := format.Jobs(&b, format.JSON, jobs); err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "id": 3,
+    "objectPath": "/org/eclipse/bluechi/job/3",
+    "node": "node1",
+    "unit": "a.service",
+    "jobType": "start",
+    "state": "running"
+  }
+]
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	if out, err := format.ParseOutput("JSON"); err != nil || out != format.JSON {
+		t.Errorf("ParseOutput(JSON) = %q, %v", out, err)
+	}
+	if _, err := format.ParseOutput("xml"); err == nil {
+		t.Error("expected ParseOutput(xml) to fail")
+	}
+	var b bytes.Buffer
+	if err := format.Nodes(&b, "xml", nodes); err == nil {
+		t.Error("expected writing an unsupported output format to fail")
+	}
+}
@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package gatewaypb holds the messages and the client and server stubs of
+// the Gateway service served by cmd/bluechi-grpc-gateway, generated from
+// gateway.proto. Clients in other languages generate theirs from the same
+// file.
+package gatewaypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gateway.proto
@@ -0,0 +1,1014 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// The gateway service of bluechi-grpc-gateway exposes a BlueChi cluster to
+// clients without access to its D-Bus API, e.g. remote tooling or programs
+// in languages without D-Bus bindings. Errors of the controller are mapped
+// to gRPC status codes, e.g. NOT_FOUND for unknown nodes and units and
+// UNAVAILABLE for offline nodes.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: gateway.proto
+
+package gatewaypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListNodesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodesRequest) Reset() {
+	*x = ListNodesRequest{}
+	mi := &file_gateway_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodesRequest) ProtoMessage() {}
+
+func (x *ListNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodesRequest.ProtoReflect.Descriptor instead.
+func (*ListNodesRequest) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{0}
+}
+
+type ListNodesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodesResponse) Reset() {
+	*x = ListNodesResponse{}
+	mi := &file_gateway_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodesResponse) ProtoMessage() {}
+
+func (x *ListNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodesResponse.ProtoReflect.Descriptor instead.
+func (*ListNodesResponse) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListNodesResponse) GetNodes() []*Node {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type Node struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// status is either online or offline.
+	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	// peer_ip is the IP address of the connected agent, if reported by the
+	// controller.
+	PeerIp        string `protobuf:"bytes,3,opt,name=peer_ip,json=peerIp,proto3" json:"peer_ip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Node) Reset() {
+	*x = Node{}
+	mi := &file_gateway_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Node) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Node) ProtoMessage() {}
+
+func (x *Node) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Node.ProtoReflect.Descriptor instead.
+func (*Node) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Node) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Node) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Node) GetPeerIp() string {
+	if x != nil {
+		return x.PeerIp
+	}
+	return ""
+}
+
+type ListUnitsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// nodes restricts the units to the named nodes, all nodes if empty.
+	Nodes []string `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// pattern restricts the units to those whose name matches the glob.
+	Pattern       string `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUnitsRequest) Reset() {
+	*x = ListUnitsRequest{}
+	mi := &file_gateway_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUnitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUnitsRequest) ProtoMessage() {}
+
+func (x *ListUnitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUnitsRequest.ProtoReflect.Descriptor instead.
+func (*ListUnitsRequest) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListUnitsRequest) GetNodes() []string {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *ListUnitsRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+type ListUnitsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Units         []*Unit                `protobuf:"bytes,1,rep,name=units,proto3" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUnitsResponse) Reset() {
+	*x = ListUnitsResponse{}
+	mi := &file_gateway_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUnitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUnitsResponse) ProtoMessage() {}
+
+func (x *ListUnitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUnitsResponse.ProtoReflect.Descriptor instead.
+func (*ListUnitsResponse) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListUnitsResponse) GetUnits() []*Unit {
+	if x != nil {
+		return x.Units
+	}
+	return nil
+}
+
+type Unit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          string                 `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	LoadState     string                 `protobuf:"bytes,4,opt,name=load_state,json=loadState,proto3" json:"load_state,omitempty"`
+	ActiveState   string                 `protobuf:"bytes,5,opt,name=active_state,json=activeState,proto3" json:"active_state,omitempty"`
+	SubState      string                 `protobuf:"bytes,6,opt,name=sub_state,json=subState,proto3" json:"sub_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Unit) Reset() {
+	*x = Unit{}
+	mi := &file_gateway_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Unit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Unit) ProtoMessage() {}
+
+func (x *Unit) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Unit.ProtoReflect.Descriptor instead.
+func (*Unit) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Unit) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *Unit) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Unit) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Unit) GetLoadState() string {
+	if x != nil {
+		return x.LoadState
+	}
+	return ""
+}
+
+func (x *Unit) GetActiveState() string {
+	if x != nil {
+		return x.ActiveState
+	}
+	return ""
+}
+
+func (x *Unit) GetSubState() string {
+	if x != nil {
+		return x.SubState
+	}
+	return ""
+}
+
+type UnitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Node  string                 `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Unit  string                 `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+	// mode is the systemd job mode, replace if empty.
+	Mode          string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitRequest) Reset() {
+	*x = UnitRequest{}
+	mi := &file_gateway_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitRequest) ProtoMessage() {}
+
+func (x *UnitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitRequest.ProtoReflect.Descriptor instead.
+func (*UnitRequest) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UnitRequest) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *UnitRequest) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *UnitRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type UnitResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// job_path is the object path of the job on the controller.
+	JobPath string `protobuf:"bytes,1,opt,name=job_path,json=jobPath,proto3" json:"job_path,omitempty"`
+	// result is the result of the job, e.g. done or failed.
+	Result        string `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitResponse) Reset() {
+	*x = UnitResponse{}
+	mi := &file_gateway_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitResponse) ProtoMessage() {}
+
+func (x *UnitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitResponse.ProtoReflect.Descriptor instead.
+func (*UnitResponse) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UnitResponse) GetJobPath() string {
+	if x != nil {
+		return x.JobPath
+	}
+	return ""
+}
+
+func (x *UnitResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// node is the node whose units are watched, all nodes if empty.
+	Node string `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// units are the watched units, all units if empty.
+	Units         []string `protobuf:"bytes,2,rep,name=units,proto3" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_gateway_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchRequest) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetUnits() []string {
+	if x != nil {
+		return x.Units
+	}
+	return nil
+}
+
+type UnitEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Node  string                 `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Unit  string                 `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*UnitEvent_New
+	//	*UnitEvent_Removed
+	//	*UnitEvent_StateChanged
+	//	*UnitEvent_PropertiesChanged
+	Event         isUnitEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitEvent) Reset() {
+	*x = UnitEvent{}
+	mi := &file_gateway_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitEvent) ProtoMessage() {}
+
+func (x *UnitEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitEvent.ProtoReflect.Descriptor instead.
+func (*UnitEvent) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UnitEvent) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *UnitEvent) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *UnitEvent) GetEvent() isUnitEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *UnitEvent) GetNew() *UnitNew {
+	if x != nil {
+		if x, ok := x.Event.(*UnitEvent_New); ok {
+			return x.New
+		}
+	}
+	return nil
+}
+
+func (x *UnitEvent) GetRemoved() *UnitRemoved {
+	if x != nil {
+		if x, ok := x.Event.(*UnitEvent_Removed); ok {
+			return x.Removed
+		}
+	}
+	return nil
+}
+
+func (x *UnitEvent) GetStateChanged() *UnitStateChanged {
+	if x != nil {
+		if x, ok := x.Event.(*UnitEvent_StateChanged); ok {
+			return x.StateChanged
+		}
+	}
+	return nil
+}
+
+func (x *UnitEvent) GetPropertiesChanged() *UnitPropertiesChanged {
+	if x != nil {
+		if x, ok := x.Event.(*UnitEvent_PropertiesChanged); ok {
+			return x.PropertiesChanged
+		}
+	}
+	return nil
+}
+
+type isUnitEvent_Event interface {
+	isUnitEvent_Event()
+}
+
+type UnitEvent_New struct {
+	New *UnitNew `protobuf:"bytes,3,opt,name=new,proto3,oneof"`
+}
+
+type UnitEvent_Removed struct {
+	Removed *UnitRemoved `protobuf:"bytes,4,opt,name=removed,proto3,oneof"`
+}
+
+type UnitEvent_StateChanged struct {
+	StateChanged *UnitStateChanged `protobuf:"bytes,5,opt,name=state_changed,json=stateChanged,proto3,oneof"`
+}
+
+type UnitEvent_PropertiesChanged struct {
+	PropertiesChanged *UnitPropertiesChanged `protobuf:"bytes,6,opt,name=properties_changed,json=propertiesChanged,proto3,oneof"`
+}
+
+func (*UnitEvent_New) isUnitEvent_Event() {}
+
+func (*UnitEvent_Removed) isUnitEvent_Event() {}
+
+func (*UnitEvent_StateChanged) isUnitEvent_Event() {}
+
+func (*UnitEvent_PropertiesChanged) isUnitEvent_Event() {}
+
+type UnitNew struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitNew) Reset() {
+	*x = UnitNew{}
+	mi := &file_gateway_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitNew) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitNew) ProtoMessage() {}
+
+func (x *UnitNew) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitNew.ProtoReflect.Descriptor instead.
+func (*UnitNew) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UnitNew) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type UnitRemoved struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitRemoved) Reset() {
+	*x = UnitRemoved{}
+	mi := &file_gateway_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitRemoved) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitRemoved) ProtoMessage() {}
+
+func (x *UnitRemoved) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitRemoved.ProtoReflect.Descriptor instead.
+func (*UnitRemoved) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UnitRemoved) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type UnitStateChanged struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ActiveState   string                 `protobuf:"bytes,1,opt,name=active_state,json=activeState,proto3" json:"active_state,omitempty"`
+	SubState      string                 `protobuf:"bytes,2,opt,name=sub_state,json=subState,proto3" json:"sub_state,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitStateChanged) Reset() {
+	*x = UnitStateChanged{}
+	mi := &file_gateway_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitStateChanged) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitStateChanged) ProtoMessage() {}
+
+func (x *UnitStateChanged) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitStateChanged.ProtoReflect.Descriptor instead.
+func (*UnitStateChanged) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UnitStateChanged) GetActiveState() string {
+	if x != nil {
+		return x.ActiveState
+	}
+	return ""
+}
+
+func (x *UnitStateChanged) GetSubState() string {
+	if x != nil {
+		return x.SubState
+	}
+	return ""
+}
+
+func (x *UnitStateChanged) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type UnitPropertiesChanged struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Interface string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	// properties are the changed values in the GVariant text format, e.g.
+	// "active" or @t 1700000000.
+	Properties    map[string]string `protobuf:"bytes,2,rep,name=properties,proto3" json:"properties,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnitPropertiesChanged) Reset() {
+	*x = UnitPropertiesChanged{}
+	mi := &file_gateway_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnitPropertiesChanged) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnitPropertiesChanged) ProtoMessage() {}
+
+func (x *UnitPropertiesChanged) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnitPropertiesChanged.ProtoReflect.Descriptor instead.
+func (*UnitPropertiesChanged) Descriptor() ([]byte, []int) {
+	return file_gateway_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UnitPropertiesChanged) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *UnitPropertiesChanged) GetProperties() map[string]string {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+var File_gateway_proto protoreflect.FileDescriptor
+
+const file_gateway_proto_rawDesc = "" +
+	"\n" +
+	"\rgateway.proto\x12\x12bluechi.gateway.v1\"\x12\n" +
+	"\x10ListNodesRequest\"C\n" +
+	"\x11ListNodesResponse\x12.\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x18.bluechi.gateway.v1.NodeR\x05nodes\"K\n" +
+	"\x04Node\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x17\n" +
+	"\apeer_ip\x18\x03 \x01(\tR\x06peerIp\"B\n" +
+	"\x10ListUnitsRequest\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\tR\x05nodes\x12\x18\n" +
+	"\apattern\x18\x02 \x01(\tR\apattern\"C\n" +
+	"\x11ListUnitsResponse\x12.\n" +
+	"\x05units\x18\x01 \x03(\v2\x18.bluechi.gateway.v1.UnitR\x05units\"\xaf\x01\n" +
+	"\x04Unit\x12\x12\n" +
+	"\x04node\x18\x01 \x01(\tR\x04node\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1d\n" +
+	"\n" +
+	"load_state\x18\x04 \x01(\tR\tloadState\x12!\n" +
+	"\factive_state\x18\x05 \x01(\tR\vactiveState\x12\x1b\n" +
+	"\tsub_state\x18\x06 \x01(\tR\bsubState\"I\n" +
+	"\vUnitRequest\x12\x12\n" +
+	"\x04node\x18\x01 \x01(\tR\x04node\x12\x12\n" +
+	"\x04unit\x18\x02 \x01(\tR\x04unit\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\"A\n" +
+	"\fUnitResponse\x12\x19\n" +
+	"\bjob_path\x18\x01 \x01(\tR\ajobPath\x12\x16\n" +
+	"\x06result\x18\x02 \x01(\tR\x06result\"8\n" +
+	"\fWatchRequest\x12\x12\n" +
+	"\x04node\x18\x01 \x01(\tR\x04node\x12\x14\n" +
+	"\x05units\x18\x02 \x03(\tR\x05units\"\xd3\x02\n" +
+	"\tUnitEvent\x12\x12\n" +
+	"\x04node\x18\x01 \x01(\tR\x04node\x12\x12\n" +
+	"\x04unit\x18\x02 \x01(\tR\x04unit\x12/\n" +
+	"\x03new\x18\x03 \x01(\v2\x1b.bluechi.gateway.v1.UnitNewH\x00R\x03new\x12;\n" +
+	"\aremoved\x18\x04 \x01(\v2\x1f.bluechi.gateway.v1.UnitRemovedH\x00R\aremoved\x12K\n" +
+	"\rstate_changed\x18\x05 \x01(\v2$.bluechi.gateway.v1.UnitStateChangedH\x00R\fstateChanged\x12Z\n" +
+	"\x12properties_changed\x18\x06 \x01(\v2).bluechi.gateway.v1.UnitPropertiesChangedH\x00R\x11propertiesChangedB\a\n" +
+	"\x05event\"!\n" +
+	"\aUnitNew\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\"%\n" +
+	"\vUnitRemoved\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\"j\n" +
+	"\x10UnitStateChanged\x12!\n" +
+	"\factive_state\x18\x01 \x01(\tR\vactiveState\x12\x1b\n" +
+	"\tsub_state\x18\x02 \x01(\tR\bsubState\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xcf\x01\n" +
+	"\x15UnitPropertiesChanged\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\x12Y\n" +
+	"\n" +
+	"properties\x18\x02 \x03(\v29.bluechi.gateway.v1.UnitPropertiesChanged.PropertiesEntryR\n" +
+	"properties\x1a=\n" +
+	"\x0fPropertiesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xcb\x04\n" +
+	"\aGateway\x12X\n" +
+	"\tListNodes\x12$.bluechi.gateway.v1.ListNodesRequest\x1a%.bluechi.gateway.v1.ListNodesResponse\x12X\n" +
+	"\tListUnits\x12$.bluechi.gateway.v1.ListUnitsRequest\x1a%.bluechi.gateway.v1.ListUnitsResponse\x12N\n" +
+	"\tStartUnit\x12\x1f.bluechi.gateway.v1.UnitRequest\x1a .bluechi.gateway.v1.UnitResponse\x12M\n" +
+	"\bStopUnit\x12\x1f.bluechi.gateway.v1.UnitRequest\x1a .bluechi.gateway.v1.UnitResponse\x12P\n" +
+	"\vRestartUnit\x12\x1f.bluechi.gateway.v1.UnitRequest\x1a .bluechi.gateway.v1.UnitResponse\x12O\n" +
+	"\n" +
+	"ReloadUnit\x12\x1f.bluechi.gateway.v1.UnitRequest\x1a .bluechi.gateway.v1.UnitResponse\x12J\n" +
+	"\x05Watch\x12 .bluechi.gateway.v1.WatchRequest\x1a\x1d.bluechi.gateway.v1.UnitEvent0\x01BBZ@github.com/eclipse-bluechi/bluechi/src/bindings/golang/gatewaypbb\x06proto3"
+
+var (
+	file_gateway_proto_rawDescOnce sync.Once
+	file_gateway_proto_rawDescData []byte
+)
+
+func file_gateway_proto_rawDescGZIP() []byte {
+	file_gateway_proto_rawDescOnce.Do(func() {
+		file_gateway_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gateway_proto_rawDesc), len(file_gateway_proto_rawDesc)))
+	})
+	return file_gateway_proto_rawDescData
+}
+
+var file_gateway_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_gateway_proto_goTypes = []any{
+	(*ListNodesRequest)(nil),      // 0: bluechi.gateway.v1.ListNodesRequest
+	(*ListNodesResponse)(nil),     // 1: bluechi.gateway.v1.ListNodesResponse
+	(*Node)(nil),                  // 2: bluechi.gateway.v1.Node
+	(*ListUnitsRequest)(nil),      // 3: bluechi.gateway.v1.ListUnitsRequest
+	(*ListUnitsResponse)(nil),     // 4: bluechi.gateway.v1.ListUnitsResponse
+	(*Unit)(nil),                  // 5: bluechi.gateway.v1.Unit
+	(*UnitRequest)(nil),           // 6: bluechi.gateway.v1.UnitRequest
+	(*UnitResponse)(nil),          // 7: bluechi.gateway.v1.UnitResponse
+	(*WatchRequest)(nil),          // 8: bluechi.gateway.v1.WatchRequest
+	(*UnitEvent)(nil),             // 9: bluechi.gateway.v1.UnitEvent
+	(*UnitNew)(nil),               // 10: bluechi.gateway.v1.UnitNew
+	(*UnitRemoved)(nil),           // 11: bluechi.gateway.v1.UnitRemoved
+	(*UnitStateChanged)(nil),      // 12: bluechi.gateway.v1.UnitStateChanged
+	(*UnitPropertiesChanged)(nil), // 13: bluechi.gateway.v1.UnitPropertiesChanged
+	nil,                           // 14: bluechi.gateway.v1.UnitPropertiesChanged.PropertiesEntry
+}
+var file_gateway_proto_depIdxs = []int32{
+	2,  // 0: bluechi.gateway.v1.ListNodesResponse.nodes:type_name -> bluechi.gateway.v1.Node
+	5,  // 1: bluechi.gateway.v1.ListUnitsResponse.units:type_name -> bluechi.gateway.v1.Unit
+	10, // 2: bluechi.gateway.v1.UnitEvent.new:type_name -> bluechi.gateway.v1.UnitNew
+	11, // 3: bluechi.gateway.v1.UnitEvent.removed:type_name -> bluechi.gateway.v1.UnitRemoved
+	12, // 4: bluechi.gateway.v1.UnitEvent.state_changed:type_name -> bluechi.gateway.v1.UnitStateChanged
+	13, // 5: bluechi.gateway.v1.UnitEvent.properties_changed:type_name -> bluechi.gateway.v1.UnitPropertiesChanged
+	14, // 6: bluechi.gateway.v1.UnitPropertiesChanged.properties:type_name -> bluechi.gateway.v1.UnitPropertiesChanged.PropertiesEntry
+	0,  // 7: bluechi.gateway.v1.Gateway.ListNodes:input_type -> bluechi.gateway.v1.ListNodesRequest
+	3,  // 8: bluechi.gateway.v1.Gateway.ListUnits:input_type -> bluechi.gateway.v1.ListUnitsRequest
+	6,  // 9: bluechi.gateway.v1.Gateway.StartUnit:input_type -> bluechi.gateway.v1.UnitRequest
+	6,  // 10: bluechi.gateway.v1.Gateway.StopUnit:input_type -> bluechi.gateway.v1.UnitRequest
+	6,  // 11: bluechi.gateway.v1.Gateway.RestartUnit:input_type -> bluechi.gateway.v1.UnitRequest
+	6,  // 12: bluechi.gateway.v1.Gateway.ReloadUnit:input_type -> bluechi.gateway.v1.UnitRequest
+	8,  // 13: bluechi.gateway.v1.Gateway.Watch:input_type -> bluechi.gateway.v1.WatchRequest
+	1,  // 14: bluechi.gateway.v1.Gateway.ListNodes:output_type -> bluechi.gateway.v1.ListNodesResponse
+	4,  // 15: bluechi.gateway.v1.Gateway.ListUnits:output_type -> bluechi.gateway.v1.ListUnitsResponse
+	7,  // 16: bluechi.gateway.v1.Gateway.StartUnit:output_type -> bluechi.gateway.v1.UnitResponse
+	7,  // 17: bluechi.gateway.v1.Gateway.StopUnit:output_type -> bluechi.gateway.v1.UnitResponse
+	7,  // 18: bluechi.gateway.v1.Gateway.RestartUnit:output_type -> bluechi.gateway.v1.UnitResponse
+	7,  // 19: bluechi.gateway.v1.Gateway.ReloadUnit:output_type -> bluechi.gateway.v1.UnitResponse
+	9,  // 20: bluechi.gateway.v1.Gateway.Watch:output_type -> bluechi.gateway.v1.UnitEvent
+	14, // [14:21] is the sub-list for method output_type
+	7,  // [7:14] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_gateway_proto_init() }
+func file_gateway_proto_init() {
+	if File_gateway_proto != nil {
+		return
+	}
+	file_gateway_proto_msgTypes[9].OneofWrappers = []any{
+		(*UnitEvent_New)(nil),
+		(*UnitEvent_Removed)(nil),
+		(*UnitEvent_StateChanged)(nil),
+		(*UnitEvent_PropertiesChanged)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gateway_proto_rawDesc), len(file_gateway_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gateway_proto_goTypes,
+		DependencyIndexes: file_gateway_proto_depIdxs,
+		MessageInfos:      file_gateway_proto_msgTypes,
+	}.Build()
+	File_gateway_proto = out.File
+	file_gateway_proto_goTypes = nil
+	file_gateway_proto_depIdxs = nil
+}
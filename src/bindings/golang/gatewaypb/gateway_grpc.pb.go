@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// The gateway service of bluechi-grpc-gateway exposes a BlueChi cluster to
+// clients without access to its D-Bus API, e.g. remote tooling or programs
+// in languages without D-Bus bindings. Errors of the controller are mapped
+// to gRPC status codes, e.g. NOT_FOUND for unknown nodes and units and
+// UNAVAILABLE for offline nodes.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: gateway.proto
+
+package gatewaypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Gateway_ListNodes_FullMethodName   = "/bluechi.gateway.v1.Gateway/ListNodes"
+	Gateway_ListUnits_FullMethodName   = "/bluechi.gateway.v1.Gateway/ListUnits"
+	Gateway_StartUnit_FullMethodName   = "/bluechi.gateway.v1.Gateway/StartUnit"
+	Gateway_StopUnit_FullMethodName    = "/bluechi.gateway.v1.Gateway/StopUnit"
+	Gateway_RestartUnit_FullMethodName = "/bluechi.gateway.v1.Gateway/RestartUnit"
+	Gateway_ReloadUnit_FullMethodName  = "/bluechi.gateway.v1.Gateway/ReloadUnit"
+	Gateway_Watch_FullMethodName       = "/bluechi.gateway.v1.Gateway/Watch"
+)
+
+// GatewayClient is the client API for Gateway service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GatewayClient interface {
+	// ListNodes returns all nodes managed by BlueChi.
+	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
+	// ListUnits returns the units of the online nodes.
+	ListUnits(ctx context.Context, in *ListUnitsRequest, opts ...grpc.CallOption) (*ListUnitsResponse, error)
+	// StartUnit starts a unit on a node and waits for the job.
+	StartUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error)
+	// StopUnit stops a unit on a node and waits for the job.
+	StopUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error)
+	// RestartUnit restarts a unit on a node and waits for the job.
+	RestartUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error)
+	// ReloadUnit reloads a unit on a node and waits for the job.
+	ReloadUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error)
+	// Watch streams the changes of units until the call is cancelled.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UnitEvent], error)
+}
+
+type gatewayClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGatewayClient(cc grpc.ClientConnInterface) GatewayClient {
+	return &gatewayClient{cc}
+}
+
+func (c *gatewayClient) ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNodesResponse)
+	err := c.cc.Invoke(ctx, Gateway_ListNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gatewayClient) ListUnits(ctx context.Context, in *ListUnitsRequest, opts ...grpc.CallOption) (*ListUnitsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListUnitsResponse)
+	err := c.cc.Invoke(ctx, Gateway_ListUnits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gatewayClient) StartUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnitResponse)
+	err := c.cc.Invoke(ctx, Gateway_StartUnit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gatewayClient) StopUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnitResponse)
+	err := c.cc.Invoke(ctx, Gateway_StopUnit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gatewayClient) RestartUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnitResponse)
+	err := c.cc.Invoke(ctx, Gateway_RestartUnit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gatewayClient) ReloadUnit(ctx context.Context, in *UnitRequest, opts ...grpc.CallOption) (*UnitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnitResponse)
+	err := c.cc.Invoke(ctx, Gateway_ReloadUnit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gatewayClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UnitEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Gateway_ServiceDesc.Streams[0], Gateway_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, UnitEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Gateway_WatchClient = grpc.ServerStreamingClient[UnitEvent]
+
+// GatewayServer is the server API for Gateway service.
+// All implementations must embed UnimplementedGatewayServer
+// for forward compatibility.
+type GatewayServer interface {
+	// ListNodes returns all nodes managed by BlueChi.
+	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
+	// ListUnits returns the units of the online nodes.
+	ListUnits(context.Context, *ListUnitsRequest) (*ListUnitsResponse, error)
+	// StartUnit starts a unit on a node and waits for the job.
+	StartUnit(context.Context, *UnitRequest) (*UnitResponse, error)
+	// StopUnit stops a unit on a node and waits for the job.
+	StopUnit(context.Context, *UnitRequest) (*UnitResponse, error)
+	// RestartUnit restarts a unit on a node and waits for the job.
+	RestartUnit(context.Context, *UnitRequest) (*UnitResponse, error)
+	// ReloadUnit reloads a unit on a node and waits for the job.
+	ReloadUnit(context.Context, *UnitRequest) (*UnitResponse, error)
+	// Watch streams the changes of units until the call is cancelled.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[UnitEvent]) error
+	mustEmbedUnimplementedGatewayServer()
+}
+
+// UnimplementedGatewayServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGatewayServer struct{}
+
+func (UnimplementedGatewayServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListNodes not implemented")
+}
+func (UnimplementedGatewayServer) ListUnits(context.Context, *ListUnitsRequest) (*ListUnitsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListUnits not implemented")
+}
+func (UnimplementedGatewayServer) StartUnit(context.Context, *UnitRequest) (*UnitResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StartUnit not implemented")
+}
+func (UnimplementedGatewayServer) StopUnit(context.Context, *UnitRequest) (*UnitResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StopUnit not implemented")
+}
+func (UnimplementedGatewayServer) RestartUnit(context.Context, *UnitRequest) (*UnitResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RestartUnit not implemented")
+}
+func (UnimplementedGatewayServer) ReloadUnit(context.Context, *UnitRequest) (*UnitResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReloadUnit not implemented")
+}
+func (UnimplementedGatewayServer) Watch(*WatchRequest, grpc.ServerStreamingServer[UnitEvent]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedGatewayServer) mustEmbedUnimplementedGatewayServer() {}
+func (UnimplementedGatewayServer) testEmbeddedByValue()                 {}
+
+// UnsafeGatewayServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GatewayServer will
+// result in compilation errors.
+type UnsafeGatewayServer interface {
+	mustEmbedUnimplementedGatewayServer()
+}
+
+func RegisterGatewayServer(s grpc.ServiceRegistrar, srv GatewayServer) {
+	// If the following call panics, it indicates UnimplementedGatewayServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Gateway_ServiceDesc, srv)
+}
+
+func _Gateway_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatewayServer).ListNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gateway_ListNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatewayServer).ListNodes(ctx, req.(*ListNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gateway_ListUnits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUnitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatewayServer).ListUnits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gateway_ListUnits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatewayServer).ListUnits(ctx, req.(*ListUnitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gateway_StartUnit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatewayServer).StartUnit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gateway_StartUnit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatewayServer).StartUnit(ctx, req.(*UnitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gateway_StopUnit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatewayServer).StopUnit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gateway_StopUnit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatewayServer).StopUnit(ctx, req.(*UnitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gateway_RestartUnit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatewayServer).RestartUnit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gateway_RestartUnit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatewayServer).RestartUnit(ctx, req.(*UnitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gateway_ReloadUnit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatewayServer).ReloadUnit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gateway_ReloadUnit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatewayServer).ReloadUnit(ctx, req.(*UnitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gateway_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GatewayServer).Watch(m, &grpc.GenericServerStream[WatchRequest, UnitEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Gateway_WatchServer = grpc.ServerStreamingServer[UnitEvent]
+
+// Gateway_ServiceDesc is the grpc.ServiceDesc for Gateway service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Gateway_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bluechi.gateway.v1.Gateway",
+	HandlerType: (*GatewayServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNodes",
+			Handler:    _Gateway_ListNodes_Handler,
+		},
+		{
+			MethodName: "ListUnits",
+			Handler:    _Gateway_ListUnits_Handler,
+		},
+		{
+			MethodName: "StartUnit",
+			Handler:    _Gateway_StartUnit_Handler,
+		},
+		{
+			MethodName: "StopUnit",
+			Handler:    _Gateway_StopUnit_Handler,
+		},
+		{
+			MethodName: "RestartUnit",
+			Handler:    _Gateway_RestartUnit_Handler,
+		},
+		{
+			MethodName: "ReloadUnit",
+			Handler:    _Gateway_ReloadUnit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Gateway_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gateway.proto",
+}
@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+//go:build integration
+
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+const (
+	// testUnit is installed on all nodes for the lifecycle tests.
+	testUnit = "bluechi-go-test.service"
+	// readyTimeout bounds waiting for the cluster to come up.
+	readyTimeout = 60 * time.Second
+)
+
+// nodeNames are the nodes of the cluster, each running in its own container.
+var nodeNames = []string{"node-foo", "node-bar"}
+
+const testUnitFile = `[Unit]
+Description=BlueChi Go bindings integration test unit
+
+[Service]
+ExecStart=/bin/sleep infinity
+`
+
+// cluster is a controller container plus one container per node, attached
+// to a private podman network. The system bus of the controller container
+// is bind mounted to busDir, which is how the bindings running on the host
+// reach the controller.
+type cluster struct {
+	prefix     string
+	network    string
+	busDir     string
+	containers []string
+}
+
+var testCluster *cluster
+
+func TestMain(m *testing.M) {
+	c, err := startCluster()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start BlueChi cluster: %v\n", err)
+		if c != nil {
+			c.stop()
+		}
+		os.Exit(1)
+	}
+	testCluster = c
+	code := m.Run()
+	c.stop()
+	os.Exit(code)
+}
+
+// imageName returns the image of the containers, the same image the python
+// integration tests use.
+func imageName() string {
+	if name := os.Getenv("BLUECHI_IMAGE_NAME"); name != "" {
+		return name
+	}
+	return "bluechi-image"
+}
+
+func podman(stdin string, args ...string) (string, error) {
+	cmd := exec.Command("podman", args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("podman %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(out.String()))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func startCluster() (*cluster, error) {
+	if _, err := exec.LookPath("podman"); err != nil {
+		return nil, fmt.Errorf("podman is required: %w", err)
+	}
+
+	busDir, err := os.MkdirTemp("", "bluechi-go-bus-")
+	if err != nil {
+		return nil, err
+	}
+	c := &cluster{
+		prefix:  fmt.Sprintf("bluechi-go-%d", os.Getpid()),
+		busDir:  busDir,
+		network: fmt.Sprintf("bluechi-go-%d", os.Getpid()),
+	}
+	if _, err := podman("", "network", "create", c.network); err != nil {
+		c.network = ""
+		return c, err
+	}
+
+	controller, err := c.run("controller", "-v", busDir+":/run/dbus:z")
+	if err != nil {
+		return c, err
+	}
+	controllerConf := fmt.Sprintf("[bluechi-controller]\nControllerPort=8420\nAllowedNodeNames=%s\nLogLevel=DEBUG\n",
+		strings.Join(nodeNames, ","))
+	if err := writeFile(controller, "/etc/bluechi/controller.conf.d/go-integration.conf", controllerConf); err != nil {
+		return c, err
+	}
+	if _, err := podman("", "exec", controller, "systemctl", "start", "bluechi-controller"); err != nil {
+		return c, err
+	}
+	ip, err := podman("", "container", "inspect", "--format", "{{range .NetworkSettings.Networks}}{{.IPAddress}}{{end}}", controller)
+	if err != nil {
+		return c, err
+	}
+
+	for _, name := range nodeNames {
+		container, err := c.run(name)
+		if err != nil {
+			return c, err
+		}
+		agentConf := fmt.Sprintf("[bluechi-agent]\nNodeName=%s\nControllerHost=%s\nControllerPort=8420\nLogLevel=DEBUG\n", name, ip)
+		if err := writeFile(container, "/etc/bluechi/agent.conf.d/go-integration.conf", agentConf); err != nil {
+			return c, err
+		}
+		if err := writeFile(container, "/etc/systemd/system/"+testUnit, testUnitFile); err != nil {
+			return c, err
+		}
+		if _, err := podman("", "exec", container, "systemctl", "daemon-reload"); err != nil {
+			return c, err
+		}
+		if _, err := podman("", "exec", container, "systemctl", "start", "bluechi-agent"); err != nil {
+			return c, err
+		}
+	}
+	return c, c.waitOnline()
+}
+
+// run starts a container for role and waits for its systemd to boot.
+func (c *cluster) run(role string, args ...string) (string, error) {
+	name := c.prefix + "-" + role
+	args = append([]string{"run", "-d", "--name", name, "--hostname", role, "--network", c.network}, args...)
+	if _, err := podman("", append(args, imageName())...); err != nil {
+		return "", err
+	}
+	c.containers = append(c.containers, name)
+	// is-system-running fails for a degraded system, which is fine here
+	_, _ = podman("", "exec", name, "systemctl", "is-system-running", "--wait")
+	return name, nil
+}
+
+func writeFile(container string, path string, content string) error {
+	_, err := podman(content, "exec", "-i", container, "sh", "-c", fmt.Sprintf("mkdir -p %s && cat > %s", filepath.Dir(path), path))
+	return err
+}
+
+// container returns the name of the container of the named node.
+func (c *cluster) container(node string) string {
+	return c.prefix + "-" + node
+}
+
+// address returns the D-Bus address of the system bus of the controller.
+func (c *cluster) address() string {
+	return "unix:path=" + filepath.Join(c.busDir, "system_bus_socket")
+}
+
+// waitOnline waits until the controller is reachable and reports all nodes
+// online.
+func (c *cluster) waitOnline() error {
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		lastErr = c.allOnline(ctx)
+		if lastErr == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cluster not ready after %s: %w", readyTimeout, lastErr)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func (c *cluster) allOnline(ctx context.Context) error {
+	m, err := manager.NewManager(manager.WithBusAddress(c.address()))
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	online := 0
+	for _, n := range nodes {
+		if n.Status == "online" {
+			online++
+		}
+	}
+	if online != len(nodeNames) {
+		return fmt.Errorf("%d of %d nodes online", online, len(nodeNames))
+	}
+	return nil
+}
+
+func (c *cluster) stop() {
+	for _, name := range c.containers {
+		_, _ = podman("", "rm", "-f", "-t", "0", name)
+	}
+	if c.network != "" {
+		_, _ = podman("", "network", "rm", "-f", c.network)
+	}
+	os.RemoveAll(c.busDir)
+}
+
+// newManager returns a Manager connected to the controller of the cluster,
+// which is closed at the end of the test.
+func newManager(t *testing.T, opts ...manager.Option) *manager.Manager {
+	t.Helper()
+	m, err := manager.NewManager(append([]manager.Option{manager.WithBusAddress(testCluster.address())}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
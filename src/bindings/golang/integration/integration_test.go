@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// eventTimeout bounds waiting for a single signal of the controller.
+const eventTimeout = 10 * time.Second
+
+func TestListNodes(t *testing.T) {
+	m := newManager(t)
+
+	nodes, err := m.ListNodes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]bool)
+	for _, n := range nodes {
+		if n.Status != "online" {
+			t.Errorf("expected node %s to be online, got %s", n.Name, n.Status)
+		}
+		if !strings.HasPrefix(string(n.ObjectPath), common.NODE_OBJECT_PATH_PREFIX+"/") {
+			t.Errorf("unexpected object path %s of node %s", n.ObjectPath, n.Name)
+		}
+		found[n.Name] = true
+	}
+	for _, name := range nodeNames {
+		if !found[name] {
+			t.Errorf("node %s missing in %+v", name, nodes)
+		}
+	}
+}
+
+func TestListUnits(t *testing.T) {
+	m := newManager(t)
+
+	units, err := m.ListUnits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range nodeNames {
+		var found bool
+		for _, u := range units[name] {
+			if u.Name == testUnit {
+				found = true
+				if u.LoadState != "loaded" || !strings.HasPrefix(string(u.ObjectPath), "/org/freedesktop/systemd1/unit/") {
+					t.Errorf("unexpected unit on node %s: %+v", name, u)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("unit %s missing on node %s", testUnit, name)
+		}
+	}
+}
+
+func TestUnitLifecycle(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(t)
+
+	for _, name := range nodeNames {
+		t.Run(name, func(t *testing.T) {
+			n, err := m.GetNode(ctx, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mon, err := m.CreateMonitor(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mon.Close(ctx)
+			if _, err := mon.Subscribe(ctx, name, testUnit); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := n.StartUnitAndWait(ctx, testUnit, node.ModeReplace); err != nil {
+				t.Fatal(err)
+			}
+			awaitUnitState(t, mon, name, "active")
+			if state, err := n.GetUnitActiveState(ctx, testUnit); err != nil || state != "active" {
+				t.Fatalf("expected unit to be active, got %q (%v)", state, err)
+			}
+			props, err := n.GetUnitProperties(ctx, testUnit, common.SYSTEMD_SERVICE_INTERFACE)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pid, ok := props["MainPID"].(uint32); !ok || pid == 0 {
+				t.Errorf("expected a MainPID of type uint32, got %#v", props["MainPID"])
+			}
+			if cgroup, err := n.GetUnitCGroupPath(ctx, testUnit); err != nil || !strings.HasSuffix(cgroup, "/"+testUnit) {
+				t.Errorf("unexpected control group %q (%v)", cgroup, err)
+			}
+
+			if err := n.StopUnitAndWait(ctx, testUnit, node.ModeReplace); err != nil {
+				t.Fatal(err)
+			}
+			awaitUnitState(t, mon, name, "inactive")
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(t)
+
+	if _, err := m.GetNode(ctx, "node-unknown"); !errors.Is(err, common.ErrNoSuchNode) {
+		t.Errorf("expected ErrNoSuchNode, got %v", err)
+	}
+	n, err := m.GetNode(ctx, nodeNames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.StartUnit(ctx, "bluechi-go-missing.service", node.ModeReplace); !errors.Is(err, common.ErrNoSuchUnit) {
+		t.Errorf("expected ErrNoSuchUnit, got %v", err)
+	}
+}
+
+func TestNodeOffline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newManager(t)
+	name := nodeNames[len(nodeNames)-1]
+
+	events, err := m.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := podman("", "exec", testCluster.container(name), "systemctl", "stop", "bluechi-agent"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if _, err := podman("", "exec", testCluster.container(name), "systemctl", "start", "bluechi-agent"); err != nil {
+			t.Error(err)
+		}
+		if err := testCluster.waitOnline(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	awaitNodeStatus(t, events, name, "offline")
+	n, err := m.GetNode(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.ListUnits(ctx); !errors.Is(err, common.ErrNodeOffline) {
+		t.Fatalf("expected ErrNodeOffline, got %v", err)
+	}
+}
+
+func awaitUnitState(t *testing.T, mon *monitor.Monitor, nodeName string, activeState node.ActiveState) {
+	t.Helper()
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case event, ok := <-mon.Events():
+			if !ok {
+				t.Fatal("monitor closed")
+			}
+			if e, ok := event.(monitor.UnitStateChanged); ok && e.Node == nodeName && e.Unit == testUnit && e.ActiveState == activeState {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for unit %s on node %s to become %s", testUnit, nodeName, activeState)
+		}
+	}
+}
+
+func awaitNodeStatus(t *testing.T, events <-chan manager.NodeConnectionStateChanged, nodeName string, status node.NodeStatus) {
+	t.Helper()
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("subscription ended")
+			}
+			if event.Node == nodeName && event.NewState == status {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for node %s to become %s", nodeName, status)
+		}
+	}
+}
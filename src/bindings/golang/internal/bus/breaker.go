@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// Breaker describes when the circuit breaker of a node trips. While it is
+// open, the calls on the node object fail with common.ErrNodeCircuitOpen
+// without being issued. Once OpenTimeout passed, a single probe call is let
+// through, which closes the breaker if the node replies and opens it again
+// otherwise.
+type Breaker struct {
+	// Threshold is the number of consecutive failed calls on a node which
+	// opens its breaker.
+	Threshold int
+	// OpenTimeout is the time the breaker stays open before probing.
+	OpenTimeout time.Duration
+}
+
+// breakers holds the state of the breakers of all nodes of a Conn.
+type breakers struct {
+	cfg    Breaker
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	nodes map[string]*breakerState
+}
+
+type breakerState struct {
+	failures int
+	// openUntil is when the open breaker lets a probe through, zero while
+	// the breaker is closed.
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreakers(cfg *Breaker, logger *slog.Logger) *breakers {
+	if cfg == nil {
+		return nil
+	}
+	return &breakers{cfg: *cfg, logger: logger, nodes: make(map[string]*breakerState)}
+}
+
+// allow reports whether a call on node may be issued and whether it is the
+// probe of an open breaker.
+func (b *breakers) allow(node string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.nodes[node]
+	if st == nil || st.openUntil.IsZero() {
+		return false, nil
+	}
+	if st.probing || time.Now().Before(st.openUntil) {
+		return false, common.ErrNodeCircuitOpen
+	}
+	st.probing = true
+	return true, nil
+}
+
+// done records the outcome of a call on node allowed by allow.
+func (b *breakers) done(node string, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.nodes[node]
+	if probe && st != nil {
+		st.probing = false
+	}
+	switch {
+	case nodeFailure(err):
+		if st == nil {
+			st = &breakerState{}
+			b.nodes[node] = st
+		}
+		st.failures++
+		if probe || st.failures >= b.cfg.Threshold {
+			if st.openUntil.IsZero() {
+				b.logger.Warn("circuit breaker opened", "node", node, "failures", st.failures)
+			}
+			st.openUntil = time.Now().Add(b.cfg.OpenTimeout)
+		}
+	case err == nil || errors.As(err, new(*common.Error)) && !transient(err):
+		// any other reply shows that the node is reachable
+		if st != nil && !st.openUntil.IsZero() {
+			b.logger.Info("circuit breaker closed", "node", node)
+		}
+		delete(b.nodes, node)
+	}
+	// other errors, e.g. the caller canceling the call or the connection
+	// to the controller being down, tell nothing about the node
+}
+
+// nodeFailure reports whether a call failing with err counts against the
+// breaker of the node, i.e. if the node is offline or did not reply in
+// time. Calls refused by the rate limit never reached the node.
+func nodeFailure(err error) bool {
+	if errors.Is(err, ErrRateLimited) {
+		return false
+	}
+	if errors.Is(err, common.ErrNodeOffline) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var e *common.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Name == common.ERROR_NO_REPLY || e.Name == common.ERROR_TIMEOUT
+}
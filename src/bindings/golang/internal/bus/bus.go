@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package bus contains helpers for the D-Bus connections used by the
+// bindings, which are either bus connections or direct peer connections.
+package bus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// DialPeer opens a direct peer-to-peer connection to the D-Bus server at
+// address, authenticating anonymously. No bus daemon is involved, hence no
+// Hello is sent and no unique name is assigned.
+func DialPeer(address string) (*dbus.Conn, error) {
+	conn, err := dbus.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth([]dbus.Auth{dbus.AuthAnonymous()}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	return conn, nil
+}
+
+// IsPeer reports whether conn is a peer connection rather than a connection
+// to a bus daemon, which assigns a unique name to each connection.
+func IsPeer(conn *dbus.Conn) bool {
+	names := conn.Names()
+	return len(names) == 0 || names[0] == ""
+}
+
+// AddMatchSignal adds a match rule for signals on bus connections. Peer
+// connections deliver all signals without match rules.
+func AddMatchSignal(conn common.Connection, options ...dbus.MatchOption) error {
+	if raw, ok := conn.(*dbus.Conn); ok && IsPeer(raw) {
+		return nil
+	}
+	return conn.AddMatchSignal(options...)
+}
+
+// RemoveMatchSignal removes a match rule added by AddMatchSignal.
+func RemoveMatchSignal(conn common.Connection, options ...dbus.MatchOption) error {
+	if raw, ok := conn.(*dbus.Conn); ok && IsPeer(raw) {
+		return nil
+	}
+	return conn.RemoveMatchSignal(options...)
+}
+
+// Lost returns a channel which is closed once the controller reachable on
+// conn is lost. For a Conn this happens each time the connection drops or
+// the controller leaves the bus, for other connections once they are closed.
+func Lost(conn common.Connection) <-chan struct{} {
+	if c, ok := conn.(*Conn); ok {
+		return c.lostChan()
+	}
+	return conn.Context().Done()
+}
+
+// OnRestore registers fn to be called each time a Conn has re-established
+// the connection to the controller, so that state kept on the controller,
+// e.g. monitors, can be recreated. It is a no-op for other connections. The
+// returned function unregisters fn.
+func OnRestore(conn common.Connection, fn func(ctx context.Context)) func() {
+	if c, ok := conn.(*Conn); ok {
+		return c.onRestore(fn)
+	}
+	return func() {}
+}
+
+// Logger returns the logger configured for a Conn, which discards all
+// records for other connections.
+func Logger(conn common.Connection) *slog.Logger {
+	if c, ok := conn.(*Conn); ok {
+		return c.cfg.Logger
+	}
+	return slog.New(slog.DiscardHandler)
+}
+
+// Object returns the object at path of the service dest on conn. Errors
+// replied to calls on the object are converted to *common.Error.
+func Object(conn common.Connection, dest string, path dbus.ObjectPath) dbus.BusObject {
+	obj := conn.Object(dest, path)
+	if _, ok := obj.(*object); ok {
+		return obj
+	}
+	return errorObject{obj}
+}
+
+// RawObject is Object for calls of methods the bindings do not know, e.g.
+// methods added to the controller after them. Calls on it are treated as
+// mutating and not idempotent, as nothing is known about them: they pass
+// the interceptor and the dry run and are not repeated by Retry.
+func RawObject(conn common.Connection, dest string, path dbus.ObjectPath) dbus.BusObject {
+	obj := Object(conn, dest, path)
+	if o, ok := obj.(*object); ok {
+		o.raw = true
+	}
+	return obj
+}
+
+// errorObject converts the errors of calls on a dbus.BusObject.
+type errorObject struct {
+	dbus.BusObject
+}
+
+func mapCall(call *dbus.Call) *dbus.Call {
+	if call.Err != nil {
+		call.Err = common.FromDBus(call.Err)
+	}
+	return call
+}
+
+func (o errorObject) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return mapCall(o.BusObject.Call(method, flags, args...))
+}
+
+func (o errorObject) CallWithContext(ctx context.Context, method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return mapCall(o.BusObject.CallWithContext(ctx, method, flags, args...))
+}
+
+func (o errorObject) GetProperty(p string) (dbus.Variant, error) {
+	v, err := o.BusObject.GetProperty(p)
+	return v, common.FromDBus(err)
+}
+
+func (o errorObject) StoreProperty(p string, value interface{}) error {
+	return common.FromDBus(o.BusObject.StoreProperty(p, value))
+}
+
+func (o errorObject) SetProperty(p string, v interface{}) error {
+	return common.FromDBus(o.BusObject.SetProperty(p, v))
+}
+
+// flagObject is a dbus.BusObject sending its calls with all flags given,
+// unlike the objects of package dbus, which drop
+// dbus.FlagAllowInteractiveAuthorization.
+type flagObject struct {
+	dbus.BusObject
+	conn *dbus.Conn
+}
+
+func (o flagObject) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return o.CallWithContext(context.Background(), method, flags, args...)
+}
+
+func (o flagObject) CallWithContext(ctx context.Context, method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return <-o.GoWithContext(ctx, method, flags, make(chan *dbus.Call, 1), args...).Done
+}
+
+func (o flagObject) Go(method string, flags dbus.Flags, ch chan *dbus.Call, args ...interface{}) *dbus.Call {
+	return o.GoWithContext(context.Background(), method, flags, ch, args...)
+}
+
+func (o flagObject) GoWithContext(ctx context.Context, method string, flags dbus.Flags, ch chan *dbus.Call, args ...interface{}) *dbus.Call {
+	iface, member := "", method
+	if i := strings.LastIndexByte(method, '.'); i >= 0 {
+		iface, member = method[:i], method[i+1:]
+	}
+	msg := &dbus.Message{
+		Type:  dbus.TypeMethodCall,
+		Flags: flags,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldPath:        dbus.MakeVariant(o.Path()),
+			dbus.FieldDestination: dbus.MakeVariant(o.Destination()),
+			dbus.FieldMember:      dbus.MakeVariant(member),
+		},
+		Body: args,
+	}
+	if iface != "" {
+		msg.Headers[dbus.FieldInterface] = dbus.MakeVariant(iface)
+	}
+	if len(args) > 0 {
+		msg.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(args...))
+	}
+	return o.conn.SendWithContext(ctx, msg, ch)
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// Call calls method on obj and decodes its single return value into a T.
+// The call is bound by ctx and, for objects of a Conn, by its call timeout
+// and retry policy. Errors replied by the peer are returned as
+// *common.Error, callers wrap them with the context of the operation. The
+// flags carried by ctx are set on the call, see common.WithCallFlags, except
+// dbus.FlagNoReplyExpected as the reply is needed.
+func Call[T any](ctx context.Context, obj dbus.BusObject, method string, args ...interface{}) (T, error) {
+	value, _, err := CallSequence[T](ctx, obj, method, args...)
+	return value, err
+}
+
+// CallSequence is Call also returning the sequence of the reply on the
+// underlying connection, dbus.NoSequence if unknown. The signals received
+// before the reply have a lower sequence, see dbus.Signal.Sequence, e.g. to
+// drop the signals whose changes a listing already contains.
+func CallSequence[T any](ctx context.Context, obj dbus.BusObject, method string, args ...interface{}) (T, dbus.Sequence, error) {
+	var value T
+	call := obj.CallWithContext(ctx, method, replyFlags(ctx), args...)
+	if call.Err != nil {
+		return value, dbus.NoSequence, common.FromDBus(call.Err)
+	}
+	// most replies are decoded by godbus into T already, e.g. object paths
+	// and property maps, which need no reflection
+	if len(call.Body) == 1 {
+		if v, ok := call.Body[0].(T); ok {
+			return v, call.ResponseSequence, nil
+		}
+	}
+	if err := call.Store(&value); err != nil {
+		return value, dbus.NoSequence, fmt.Errorf("failed to decode reply of %s: %w", method, err)
+	}
+	return value, call.ResponseSequence, nil
+}
+
+// Array calls method on obj, whose single return value is an array of
+// structs, and returns the fields of the structs as decoded by godbus.
+// Decoding them with type assertions instead of Call avoids the reflection
+// of dbus.Store and a second copy of the reply, e.g. for ListUnits.
+func Array(ctx context.Context, obj dbus.BusObject, method string, args ...interface{}) ([][]interface{}, error) {
+	call := obj.CallWithContext(ctx, method, replyFlags(ctx), args...)
+	if call.Err != nil {
+		return nil, common.FromDBus(call.Err)
+	}
+	if len(call.Body) != 1 {
+		return nil, fmt.Errorf("failed to decode reply of %s: expected 1 value, got %d", method, len(call.Body))
+	}
+	elems, ok := call.Body[0].([][]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to decode reply of %s: expected an array of structs, got %T", method, call.Body[0])
+	}
+	return elems, nil
+}
+
+// Each is Array passing the fields of the structs one by one to fn until fn
+// returns false or an error, which is returned. Each struct is released
+// once passed to fn, so callers need not hold a second copy of the reply.
+func Each(ctx context.Context, obj dbus.BusObject, method string, fn func(fields []interface{}) (bool, error), args ...interface{}) error {
+	elems, err := Array(ctx, obj, method, args...)
+	if err != nil {
+		return err
+	}
+	for i := range elems {
+		fields := elems[i]
+		elems[i] = nil
+		if cont, err := fn(fields); err != nil || !cont {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exec calls method on obj, discarding its return values if any. It
+// behaves like Call otherwise, but honours dbus.FlagNoReplyExpected carried
+// by ctx: the call returns once it is sent then, without waiting for the
+// reply and its error.
+func Exec(ctx context.Context, obj dbus.BusObject, method string, args ...interface{}) error {
+	return common.FromDBus(obj.CallWithContext(ctx, method, common.CallFlagsFromContext(ctx), args...).Err)
+}
+
+// replyFlags returns the flags carried by ctx for calls decoding their
+// reply.
+func replyFlags(ctx context.Context) dbus.Flags {
+	return common.CallFlagsFromContext(ctx) &^ dbus.FlagNoReplyExpected
+}
+
+// GetProperty returns the property name of the interface iface of obj.
+func GetProperty(ctx context.Context, obj dbus.BusObject, iface string, name string) (dbus.Variant, error) {
+	return Call[dbus.Variant](ctx, obj, common.METHOD_PROPERTIES_GET, iface, name)
+}
+
+// SetProperty sets the property name of the interface iface of obj to
+// value.
+func SetProperty(ctx context.Context, obj dbus.BusObject, iface string, name string, value interface{}) error {
+	return Exec(ctx, obj, common.METHOD_PROPERTIES_SET, iface, name, dbus.MakeVariant(value))
+}
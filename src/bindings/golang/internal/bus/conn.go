@@ -0,0 +1,799 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// ErrDisconnected is returned by calls on a Conn while the connection is
+// down.
+var ErrDisconnected = errors.New("disconnected from the BlueChi controller")
+
+// State is the connection state of a Conn.
+type State int
+
+const (
+	// StateConnected means the controller is reachable.
+	StateConnected State = iota
+	// StateDisconnected means the connection dropped or the controller left
+	// the bus.
+	StateDisconnected
+	// StateReconnecting means the connection is being re-established.
+	StateReconnecting
+)
+
+// Backoff describes the exponentially growing delay between reconnection
+// attempts.
+type Backoff struct {
+	// Initial is the delay before the first attempt.
+	Initial time.Duration
+	// Max caps the delay between two attempts.
+	Max time.Duration
+	// Multiplier is applied to the delay after each failed attempt.
+	Multiplier float64
+}
+
+func (b Backoff) next(delay time.Duration) time.Duration {
+	delay = time.Duration(float64(delay) * b.Multiplier)
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+// Config configures a Conn.
+type Config struct {
+	// Dial opens the underlying D-Bus connection.
+	Dial func() (*dbus.Conn, error)
+	// Service is the bus name of the controller. It is watched to detect
+	// restarts of the controller while the bus connection stays up.
+	Service string
+	// Reconnect enables re-establishing lost connections if set.
+	Reconnect *Backoff
+	// Failover closes the underlying connection when the controller
+	// leaves the bus instead of waiting for it to come back, so that
+	// Dial can pick another controller. It requires Reconnect.
+	Failover bool
+	// OnState is called on each change of the connection state. It must not
+	// block. The last state reported is StateDisconnected, once the
+	// connection is closed or lost without reconnecting.
+	OnState func(State)
+	// Logger receives the diagnostics of the connection, e.g. failed
+	// reconnection attempts. Nothing is logged if it is nil.
+	Logger *slog.Logger
+	// CallTimeout bounds each attempt of a call on the objects of the Conn.
+	// Calls are only bound by their context if it is zero.
+	CallTimeout time.Duration
+	// Retry repeats calls failing with a transient error if set.
+	Retry *Retry
+	// Breaker fails the calls on the objects of nodes failing repeatedly
+	// fast if set.
+	Breaker *Breaker
+	// RateLimit limits the calls issued on the objects of the Conn if set,
+	// each attempt of a retried call counts. It can be shared with other
+	// Conns, e.g. the ones replacing a closed Conn.
+	RateLimit *RateLimiter
+	// Overflow is the overflow policy of the event channels fed from the
+	// signals of the Conn.
+	Overflow Overflow
+	// Overflows counts the events lost on all event channels of the Conn
+	// if set.
+	Overflows *atomic.Uint64
+	// Names are the names of the controller if they differ from
+	// StandardNames. Service should be set to Names.Service.
+	Names *Names
+	// Tracer creates a span for each call on the objects of the Conn if
+	// set.
+	Tracer trace.Tracer
+	// Intercept is called for the mutating calls on the objects of the
+	// Conn if set.
+	Intercept Interceptor
+	// DryRun replaces the mutating calls on the objects of the Conn if set.
+	DryRun DryRun
+	// Flags are set on all calls on the objects of the Conn, e.g.
+	// dbus.FlagAllowInteractiveAuthorization.
+	Flags dbus.Flags
+	// Strict checks the replies to the calls on the objects of the Conn
+	// against the signatures of their methods, see common.CheckReply, and
+	// fails the calls whose reply does not match with a
+	// *common.SchemaError.
+	Strict bool
+}
+
+// forwardBufferSize is the capacity of the channel receiving the signals of
+// the underlying connection.
+const forwardBufferSize = 128
+
+// Conn is a connection to the controller whose underlying D-Bus connection
+// can be replaced. Objects, signal channels and match rules of a Conn stay
+// valid when the connection is re-established, so proxies created on it
+// keep working.
+type Conn struct {
+	cfg      Config
+	names    *translator
+	breakers *breakers
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.RWMutex
+	conn    *dbus.Conn
+	up      bool
+	lost    chan struct{}
+	signals map[chan<- *dbus.Signal]struct{}
+	matches [][]dbus.MatchOption
+	hooks   []hook
+	nextID  uint64
+
+	// inflight counts the calls and job waits Shutdown waits for, workers
+	// the goroutines started with Go.
+	inflight gauge
+	workers  gauge
+
+	// restoreMu serializes running the restore hooks.
+	restoreMu sync.Mutex
+	// stateMu serializes the state notifications.
+	stateMu sync.Mutex
+}
+
+type hook struct {
+	id uint64
+	fn func(ctx context.Context)
+}
+
+// Open dials the connection described by cfg.
+func Open(cfg Config) (*Conn, error) {
+	raw, err := cfg.Dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.DiscardHandler)
+	}
+	cfg.Logger = slog.New(metadataHandler{cfg.Logger.Handler()})
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Conn{
+		cfg:      cfg,
+		names:    newTranslator(cfg.Names),
+		breakers: newBreakers(cfg.Breaker, cfg.Logger),
+		ctx:      ctx,
+		cancel:   cancel,
+		up:       true,
+		lost:     make(chan struct{}),
+		signals:  make(map[chan<- *dbus.Signal]struct{}),
+	}
+
+	c.mu.Lock()
+	err = c.attachLocked(raw)
+	if err == nil && c.watchOwner(raw) {
+		// reachable once the controller is known to own its name
+		c.markLostLocked()
+	}
+	c.mu.Unlock()
+	if err != nil {
+		cancel()
+		raw.Close()
+		return nil, err
+	}
+	if c.watchOwner(raw) {
+		c.awaitService(raw)
+	}
+	return c, nil
+}
+
+// Close closes the connection and stops reconnecting. The context of the
+// connection is done afterwards.
+func (c *Conn) Close() error {
+	c.stateMu.Lock()
+	c.notifyLocked(StateDisconnected)
+	c.cancel()
+	c.stateMu.Unlock()
+
+	c.mu.Lock()
+	raw := c.conn
+	c.conn = nil
+	c.markLostLocked()
+	c.mu.Unlock()
+
+	if raw == nil {
+		return nil
+	}
+	return raw.Close()
+}
+
+// Current returns the underlying D-Bus connection, nil while disconnected.
+func (c *Conn) Current() *dbus.Conn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
+
+// Object returns the object at path of the service dest. Calls on the
+// object are sent on the current underlying connection and fail with
+// ErrDisconnected while there is none. Error replies are converted to
+// *common.Error.
+func (c *Conn) Object(dest string, path dbus.ObjectPath) dbus.BusObject {
+	return &object{c: c, dest: dest, path: path}
+}
+
+// Signal registers ch to receive the signals of all underlying connections.
+func (c *Conn) Signal(ch chan<- *dbus.Signal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.signals[ch] = struct{}{}
+}
+
+// RemoveSignal unregisters a channel registered by Signal.
+func (c *Conn) RemoveSignal(ch chan<- *dbus.Signal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.signals, ch)
+}
+
+// AddMatchSignal adds a match rule, which is added again to each new
+// underlying connection.
+func (c *Conn) AddMatchSignal(options ...dbus.MatchOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		if err := AddMatchSignal(c.conn, c.match(options)...); err != nil {
+			return err
+		}
+	}
+	c.matches = append(c.matches, append([]dbus.MatchOption(nil), options...))
+	return nil
+}
+
+// RemoveMatchSignal removes a match rule added by AddMatchSignal.
+func (c *Conn) RemoveMatchSignal(options ...dbus.MatchOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for idx, m := range c.matches {
+		if equalMatch(m, options) {
+			c.matches = append(c.matches[:idx], c.matches[idx+1:]...)
+			break
+		}
+	}
+	if c.conn == nil {
+		return nil
+	}
+	return RemoveMatchSignal(c.conn, c.match(options)...)
+}
+
+// match translates the options of a match rule to the names of the
+// controller.
+func (c *Conn) match(options []dbus.MatchOption) []dbus.MatchOption {
+	if c.names == nil {
+		return options
+	}
+	return c.names.match(options)
+}
+
+// Context returns a context which is done once the connection is closed, or
+// once it is lost if reconnecting is disabled.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *Conn) lostChan() <-chan struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lost
+}
+
+func (c *Conn) onRestore(fn func(ctx context.Context)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	id := c.nextID
+	c.hooks = append(c.hooks, hook{id: id, fn: fn})
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for idx, h := range c.hooks {
+			if h.id == id {
+				c.hooks = append(c.hooks[:idx], c.hooks[idx+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) notify(state State) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.notifyLocked(state)
+}
+
+// notifyLocked reports state unless the connection has been closed, after
+// which no further states are reported.
+func (c *Conn) notifyLocked(state State) {
+	if c.cfg.OnState != nil && c.ctx.Err() == nil {
+		c.cfg.OnState(state)
+	}
+}
+
+// markLostLocked closes the lost channel if the controller was reachable.
+func (c *Conn) markLostLocked() bool {
+	if !c.up {
+		return false
+	}
+	c.up = false
+	close(c.lost)
+	return true
+}
+
+// attachLocked makes raw the underlying connection after adding all match
+// rules to it.
+func (c *Conn) attachLocked(raw *dbus.Conn) error {
+	if c.watchOwner(raw) {
+		if err := raw.AddMatchSignal(ownerMatchOptions(c.cfg.Service)...); err != nil {
+			return err
+		}
+	}
+	for _, m := range c.matches {
+		if err := AddMatchSignal(raw, c.match(m)...); err != nil {
+			return err
+		}
+	}
+
+	c.conn = raw
+	in := make(chan *dbus.Signal, forwardBufferSize)
+	raw.Signal(in)
+	go c.forward(raw, in)
+	go c.watch(raw)
+	return nil
+}
+
+// watchOwner reports whether the owner of the controller name is tracked on
+// raw, which requires a bus connection.
+func (c *Conn) watchOwner(raw *dbus.Conn) bool {
+	return c.cfg.Reconnect != nil && c.cfg.Service != "" && !IsPeer(raw)
+}
+
+// forward delivers the signals received on raw to all registered channels.
+// Like godbus, a channel that is full gets the signal delivered
+// asynchronously instead of blocking the others.
+func (c *Conn) forward(raw *dbus.Conn, in chan *dbus.Signal) {
+	for sig := range in {
+		if c.watchOwner(raw) && sig.Name == ownerChangedSignal {
+			c.ownerChanged(raw, sig)
+		}
+		if c.names != nil {
+			sig = c.names.inverse().signal(sig)
+		}
+
+		c.mu.RLock()
+		for ch := range c.signals {
+			select {
+			case ch <- sig:
+			default:
+				go func(ch chan<- *dbus.Signal) {
+					select {
+					case ch <- sig:
+					case <-c.ctx.Done():
+					}
+				}(ch)
+			}
+		}
+		c.mu.RUnlock()
+	}
+}
+
+// watch waits for raw to be closed and re-establishes the connection if
+// enabled.
+func (c *Conn) watch(raw *dbus.Conn) {
+	select {
+	case <-raw.Context().Done():
+	case <-c.ctx.Done():
+		return
+	}
+
+	c.mu.Lock()
+	if c.conn != raw {
+		c.mu.Unlock()
+		return
+	}
+	c.conn = nil
+	c.markLostLocked()
+	c.mu.Unlock()
+
+	if c.ctx.Err() != nil {
+		return
+	}
+	c.cfg.Logger.Warn("connection to the bus lost", "error", context.Cause(raw.Context()))
+	c.notify(StateDisconnected)
+	if c.cfg.Reconnect == nil {
+		c.cancel()
+		return
+	}
+	c.reconnect()
+}
+
+func (c *Conn) reconnect() {
+	delay := c.cfg.Reconnect.Initial
+	for {
+		c.notify(StateReconnecting)
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		raw, err := c.cfg.Dial()
+		if err == nil {
+			c.mu.Lock()
+			if c.ctx.Err() != nil {
+				c.mu.Unlock()
+				raw.Close()
+				return
+			}
+			err = c.attachLocked(raw)
+			c.mu.Unlock()
+			if err == nil {
+				c.cfg.Logger.Info("connection to the bus re-established")
+				c.awaitService(raw)
+				return
+			}
+			raw.Close()
+		}
+		delay = c.cfg.Reconnect.next(delay)
+		c.cfg.Logger.Debug("failed to reconnect", "error", err, "retry_in", delay)
+	}
+}
+
+// restore marks the controller reachable again and runs the restore hooks.
+func (c *Conn) restore() {
+	c.restoreMu.Lock()
+	defer c.restoreMu.Unlock()
+
+	c.mu.Lock()
+	if c.ctx.Err() != nil || c.conn == nil || c.up {
+		c.mu.Unlock()
+		return
+	}
+	c.up = true
+	c.lost = make(chan struct{})
+	hooks := append([]hook(nil), c.hooks...)
+	c.mu.Unlock()
+
+	c.cfg.Logger.Debug("controller reachable, restoring subscriptions", "service", c.cfg.Service)
+	for _, h := range hooks {
+		h.fn(c.ctx)
+	}
+	c.notify(StateConnected)
+}
+
+// awaitService restores the connection if the controller is on the bus.
+// Otherwise the restore is triggered by its NameOwnerChanged signal.
+func (c *Conn) awaitService(raw *dbus.Conn) {
+	if c.serviceOwned(raw) {
+		c.restore()
+		return
+	}
+	if c.cfg.Failover {
+		// the controller left since it was dialed
+		raw.Close()
+		return
+	}
+
+	c.restoreMu.Lock()
+	defer c.restoreMu.Unlock()
+	c.mu.RLock()
+	up := c.up
+	c.mu.RUnlock()
+	if !up {
+		c.notify(StateReconnecting)
+	}
+}
+
+const (
+	ownerChangedMember = "NameOwnerChanged"
+	ownerChangedSignal = "org.freedesktop.DBus." + ownerChangedMember
+)
+
+func ownerMatchOptions(service string) []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember(ownerChangedMember),
+		dbus.WithMatchArg(0, service),
+	}
+}
+
+// ownerChanged handles the NameOwnerChanged signal of the controller name,
+// which is emitted with an empty new owner when the controller exits and
+// with the new owner once it is back.
+func (c *Conn) ownerChanged(raw *dbus.Conn, sig *dbus.Signal) {
+	var name, oldOwner, newOwner string
+	if dbus.Store(sig.Body, &name, &oldOwner, &newOwner) != nil || name != c.cfg.Service {
+		return
+	}
+
+	if oldOwner != "" {
+		c.mu.Lock()
+		wasUp := c.conn == raw && c.markLostLocked()
+		c.mu.Unlock()
+		if wasUp {
+			c.cfg.Logger.Warn("controller left the bus", "service", c.cfg.Service)
+			c.notify(StateDisconnected)
+			c.notify(StateReconnecting)
+		}
+		if c.cfg.Failover && newOwner == "" {
+			// watch dials again once raw is closed
+			raw.Close()
+			return
+		}
+	}
+	if newOwner != "" {
+		go c.restore()
+	}
+}
+
+func (c *Conn) serviceOwned(raw *dbus.Conn) bool {
+	if !c.watchOwner(raw) {
+		return true
+	}
+	var owned bool
+	err := raw.BusObject().CallWithContext(c.ctx, "org.freedesktop.DBus.NameHasOwner", 0, c.cfg.Service).Store(&owned)
+	return err == nil && owned
+}
+
+func equalMatch(a, b []dbus.MatchOption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// object is a dbus.BusObject sending its calls on the current underlying
+// connection of a Conn.
+type object struct {
+	c    *Conn
+	dest string
+	path dbus.ObjectPath
+	// node is the name of the node of a node object, see NodeObject.
+	node string
+	// raw is set for the objects returned by RawObject.
+	raw bool
+}
+
+func (o *object) target() (dbus.BusObject, error) {
+	raw := o.c.Current()
+	if raw == nil {
+		return nil, ErrDisconnected
+	}
+	dest, path := o.dest, o.path
+	if t := o.c.names; t != nil {
+		dest, path = t.service(dest), t.path(path)
+	}
+	if o.c.cfg.Flags&dbus.FlagAllowInteractiveAuthorization != 0 {
+		return errorObject{flagObject{raw.Object(dest, path), raw}}, nil
+	}
+	return errorObject{raw.Object(dest, path)}, nil
+}
+
+func failedCall(err error, ch chan *dbus.Call) *dbus.Call {
+	if ch == nil {
+		ch = make(chan *dbus.Call, 1)
+	}
+	call := &dbus.Call{Err: err, Done: ch}
+	select {
+	case ch <- call:
+	default:
+	}
+	return call
+}
+
+func (o *object) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return o.CallWithContext(context.Background(), method, flags, args...)
+}
+
+func (o *object) CallWithContext(ctx context.Context, method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	if !o.c.inflight.acquire() {
+		return failedCall(ErrShuttingDown, nil)
+	}
+	defer o.c.inflight.release()
+
+	ctx, span := o.startSpan(ctx, method, args)
+	after, call := o.intercept(ctx, method, args)
+	if call == nil {
+		call = o.guarded(ctx, span, method, flags, args)
+		if o.c.cfg.Strict && call.Err == nil {
+			call.Err = common.CheckReply(method, call.Body)
+		}
+	}
+	if after != nil {
+		after(call.Err)
+	}
+	endSpan(span, call.Err)
+	return call
+}
+
+// guarded issues the call through the circuit breaker of the node of o if
+// the Conn has breakers and o is a node object.
+func (o *object) guarded(ctx context.Context, span trace.Span, method string, flags dbus.Flags, args []interface{}) *dbus.Call {
+	b := o.c.breakers
+	if b == nil || o.node == "" {
+		return o.call(ctx, span, method, flags, args)
+	}
+	probe, err := b.allow(o.node)
+	if err != nil {
+		return failedCall(err, nil)
+	}
+	call := o.call(ctx, span, method, flags, args)
+	b.done(o.node, probe, call.Err)
+	return call
+}
+
+// call issues the call and repeats it as allowed by the retry policy of
+// the Conn. The retries are recorded as events of span if it is set.
+func (o *object) call(ctx context.Context, span trace.Span, method string, flags dbus.Flags, args []interface{}) *dbus.Call {
+	retry := o.c.cfg.Retry
+	if retry == nil || !retry.allows(method) || o.raw && !retry.NonIdempotent {
+		return o.attempt(ctx, method, flags, args)
+	}
+
+	delay := retry.Backoff.Initial
+	for attempt := 1; ; attempt++ {
+		call := o.attempt(ctx, method, flags, args)
+		if call.Err == nil || attempt >= retry.MaxAttempts || ctx.Err() != nil || !transient(call.Err) {
+			return call
+		}
+		o.c.cfg.Logger.DebugContext(ctx, "retrying call", "method", method, "path", o.path, "attempt", attempt, "error", call.Err)
+		if span != nil {
+			span.AddEvent("retry", trace.WithAttributes(attrAttempt.Int(attempt), attrError.String(call.Err.Error())))
+		}
+		select {
+		case <-ctx.Done():
+			return call
+		case <-o.c.ctx.Done():
+			return call
+		case <-time.After(delay):
+		}
+		delay = retry.Backoff.next(delay)
+	}
+}
+
+// attempt issues a single call bound by the call timeout of the Conn.
+func (o *object) attempt(ctx context.Context, method string, flags dbus.Flags, args []interface{}) *dbus.Call {
+	if l := o.c.cfg.RateLimit; l != nil {
+		if err := l.wait(ctx, o.c.ctx.Done()); err != nil {
+			return failedCall(err, nil)
+		}
+	}
+	obj, err := o.target()
+	if err != nil {
+		return failedCall(err, nil)
+	}
+	if t := o.c.names; t != nil {
+		method, args = t.call(method, args)
+	}
+	flags |= o.c.cfg.Flags | replyFlags(ctx)
+	if o.c.cfg.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.c.cfg.CallTimeout)
+		defer cancel()
+	}
+	call := obj.CallWithContext(ctx, method, flags, args...)
+	if t := o.c.names; t != nil && call.Err == nil {
+		call.Body = t.inverse().values(call.Body)
+	}
+	return call
+}
+
+func (o *object) Go(method string, flags dbus.Flags, ch chan *dbus.Call, args ...interface{}) *dbus.Call {
+	return o.GoWithContext(context.Background(), method, flags, ch, args...)
+}
+
+// GoWithContext issues the call like CallWithContext, i.e. through the
+// interceptors, dry-run mode, tracing and the checks of the Conn, without
+// waiting for it. The completed call is sent on ch, or on the Done channel
+// of the returned call if ch is nil, which only carries the method and
+// arguments otherwise.
+func (o *object) GoWithContext(ctx context.Context, method string, flags dbus.Flags, ch chan *dbus.Call, args ...interface{}) *dbus.Call {
+	if ch == nil {
+		ch = make(chan *dbus.Call, 1)
+	} else if cap(ch) == 0 {
+		panic("dbus: unbuffered channel passed to (*Object).Go")
+	}
+	pending := &dbus.Call{Destination: o.dest, Path: o.path, Method: method, Args: args, Done: ch}
+	go func() {
+		call := o.CallWithContext(ctx, method, flags, args...)
+		call.Done = ch
+		select {
+		case ch <- call:
+		default:
+		}
+	}()
+	return pending
+}
+
+func (o *object) AddMatchSignal(iface, member string, options ...dbus.MatchOption) *dbus.Call {
+	obj, err := o.target()
+	if err != nil {
+		return failedCall(err, nil)
+	}
+	if t := o.c.names; t != nil {
+		iface, options = t.name(iface), t.match(options)
+	}
+	return obj.AddMatchSignal(iface, member, options...)
+}
+
+func (o *object) RemoveMatchSignal(iface, member string, options ...dbus.MatchOption) *dbus.Call {
+	obj, err := o.target()
+	if err != nil {
+		return failedCall(err, nil)
+	}
+	if t := o.c.names; t != nil {
+		iface, options = t.name(iface), t.match(options)
+	}
+	return obj.RemoveMatchSignal(iface, member, options...)
+}
+
+// GetProperty, StoreProperty and SetProperty call the methods of
+// org.freedesktop.DBus.Properties with CallWithContext, so that setting
+// properties is intercepted and validated in dry-run mode like the other
+// mutating calls.
+func (o *object) GetProperty(p string) (dbus.Variant, error) {
+	var v dbus.Variant
+	err := o.StoreProperty(p, &v)
+	return v, err
+}
+
+func (o *object) StoreProperty(p string, value interface{}) error {
+	iface, name, err := splitProperty(p)
+	if err != nil {
+		return err
+	}
+	return o.CallWithContext(context.Background(), common.METHOD_PROPERTIES_GET, 0, iface, name).Store(value)
+}
+
+func (o *object) SetProperty(p string, v interface{}) error {
+	iface, name, err := splitProperty(p)
+	if err != nil {
+		return err
+	}
+	variant, ok := v.(dbus.Variant)
+	if !ok {
+		variant = dbus.MakeVariant(v)
+	}
+	return o.CallWithContext(context.Background(), common.METHOD_PROPERTIES_SET, 0, iface, name, variant).Err
+}
+
+// splitProperty splits a property in interface.member notation.
+func splitProperty(p string) (string, string, error) {
+	idx := strings.LastIndex(p, ".")
+	if idx == -1 || idx+1 == len(p) {
+		return "", "", errors.New("dbus: invalid property " + p)
+	}
+	return p[:idx], p[idx+1:], nil
+}
+
+func (o *object) Destination() string {
+	return o.dest
+}
+
+func (o *object) Path() dbus.ObjectPath {
+	return o.path
+}
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// dispatchBufferSize is the capacity of the channel on which a dispatcher
+// receives the signals of its connection.
+const dispatchBufferSize = 128
+
+// errClosed is returned by Subscribe for connections which are closed.
+var errClosed = errors.New("connection closed")
+
+// Policy decides how a signal is delivered to a subscription whose channel
+// is full.
+type Policy int
+
+const (
+	// Block waits until the subscriber makes room or closes the
+	// subscription. The signals of all subscriptions of the connection are
+	// held up meanwhile.
+	Block Policy = iota
+	// DropNewest drops the signal.
+	DropNewest
+	// DropOldest drops the oldest signal in the channel to make room.
+	DropOldest
+)
+
+// Match selects the signals delivered to a subscription.
+type Match struct {
+	// Rules are the match rules added on bus connections for the signals.
+	// Subscriptions with equal rules share them, so rules should be as
+	// broad as the signal type allows and Filter narrow them down.
+	Rules [][]dbus.MatchOption
+	// Filter reports whether a signal received on the connection is
+	// delivered to the subscription. All signals are delivered if it is
+	// nil.
+	Filter func(sig *dbus.Signal) bool
+}
+
+// Subscription receives the signals selected by a Match. It is created by
+// Subscribe.
+type Subscription struct {
+	d       *dispatcher
+	rules   []string
+	filter  func(*dbus.Signal) bool
+	policy  Policy
+	ch      chan *dbus.Signal
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// Subscribe returns a subscription for the signals selected by match on
+// conn, buffering up to size signals for the subscriber. All subscriptions
+// of a connection are served by a single channel registered with
+// conn.Signal, and each distinct match rule is added on the bus once for
+// all of them.
+func Subscribe(conn common.Connection, match Match, size int, policy Policy) (*Subscription, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("invalid signal buffer size %d", size)
+	}
+	d, err := dispatcherFor(conn)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscription{
+		d:      d,
+		filter: match.Filter,
+		policy: policy,
+		ch:     make(chan *dbus.Signal, size),
+		done:   make(chan struct{}),
+	}
+	if err := d.add(s, match.Rules); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Signals returns the channel on which the signals are delivered. It is
+// closed when the connection is closed, not by Close.
+func (s *Subscription) Signals() <-chan *dbus.Signal {
+	return s.ch
+}
+
+// Dropped returns the number of signals dropped because the channel was
+// full, which is always zero with Block.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close ends the subscription and removes its match rules from the bus
+// unless other subscriptions share them.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.d.remove(s)
+	})
+}
+
+func (s *Subscription) deliver(sig *dbus.Signal, stop <-chan struct{}) {
+	select {
+	case s.ch <- sig:
+		return
+	default:
+	}
+
+	switch s.policy {
+	case DropNewest:
+		s.dropped.Add(1)
+	case DropOldest:
+		// the dispatcher is the only sender, so there is room after
+		// taking one signal out
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.ch <- sig:
+		default:
+			s.dropped.Add(1)
+		}
+	default:
+		select {
+		case s.ch <- sig:
+		case <-s.done:
+		case <-stop:
+		}
+	}
+}
+
+// rule is a match rule added on the bus, counting the subscriptions using
+// it.
+type rule struct {
+	options []dbus.MatchOption
+	refs    int
+}
+
+// dispatcher fans the signals of a connection out to its subscriptions.
+type dispatcher struct {
+	conn    common.Connection
+	signals chan *dbus.Signal
+
+	mu      sync.Mutex
+	stopped bool
+	subs    map[*Subscription]struct{}
+	rules   map[string]*rule
+}
+
+var (
+	dispatchersMu sync.Mutex
+	dispatchers   = make(map[common.Connection]*dispatcher)
+)
+
+// dispatcherFor returns the dispatcher of conn, starting it on first use.
+func dispatcherFor(conn common.Connection) (*dispatcher, error) {
+	dispatchersMu.Lock()
+	defer dispatchersMu.Unlock()
+
+	if d, ok := dispatchers[conn]; ok {
+		return d, nil
+	}
+	if conn.Context().Err() != nil {
+		return nil, errClosed
+	}
+	d := &dispatcher{
+		conn:    conn,
+		signals: make(chan *dbus.Signal, dispatchBufferSize),
+		subs:    make(map[*Subscription]struct{}),
+		rules:   make(map[string]*rule),
+	}
+	dispatchers[conn] = d
+	conn.Signal(d.signals)
+	go d.run()
+	return d, nil
+}
+
+func ruleKey(options []dbus.MatchOption) string {
+	return fmt.Sprint(options)
+}
+
+func (d *dispatcher) add(s *Subscription, rules [][]dbus.MatchOption) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return errClosed
+	}
+	for _, options := range rules {
+		key := ruleKey(options)
+		r, ok := d.rules[key]
+		if !ok {
+			if err := AddMatchSignal(d.conn, options...); err != nil {
+				d.releaseLocked(s.rules)
+				return err
+			}
+			r = &rule{options: options}
+			d.rules[key] = r
+		}
+		r.refs++
+		s.rules = append(s.rules, key)
+	}
+	d.subs[s] = struct{}{}
+	return nil
+}
+
+func (d *dispatcher) remove(s *Subscription) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.subs[s]; !ok {
+		return
+	}
+	delete(d.subs, s)
+	d.releaseLocked(s.rules)
+}
+
+func (d *dispatcher) releaseLocked(keys []string) {
+	for _, key := range keys {
+		r := d.rules[key]
+		r.refs--
+		if r.refs > 0 {
+			continue
+		}
+		delete(d.rules, key)
+		if !d.stopped {
+			_ = RemoveMatchSignal(d.conn, r.options...)
+		}
+	}
+}
+
+func (d *dispatcher) run() {
+	defer d.stop()
+
+	stop := d.conn.Context().Done()
+	for {
+		select {
+		case <-stop:
+			return
+		case sig, ok := <-d.signals:
+			if !ok {
+				return
+			}
+			for _, s := range d.targets(sig) {
+				s.deliver(sig, stop)
+			}
+		}
+	}
+}
+
+// targets returns the subscriptions sig is delivered to. The filters are
+// called without holding the lock, they may take locks of their own.
+func (d *dispatcher) targets(sig *dbus.Signal) []*Subscription {
+	d.mu.Lock()
+	subs := make([]*Subscription, 0, len(d.subs))
+	for s := range d.subs {
+		subs = append(subs, s)
+	}
+	d.mu.Unlock()
+
+	targets := subs[:0]
+	for _, s := range subs {
+		if s.filter == nil || s.filter(sig) {
+			targets = append(targets, s)
+		}
+	}
+	return targets
+}
+
+// stop closes the channels of all subscriptions once the connection is
+// closed. Subscribing on the connection fails from then on.
+func (d *dispatcher) stop() {
+	dispatchersMu.Lock()
+	if dispatchers[d.conn] == d {
+		delete(dispatchers, d.conn)
+	}
+	dispatchersMu.Unlock()
+
+	d.conn.RemoveSignal(d.signals)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	for s := range d.subs {
+		close(s.ch)
+	}
+}
+
+// PropertiesChangedRule returns the match rule for the PropertiesChanged
+// signals of iface on all objects below namespace.
+func PropertiesChangedRule(namespace dbus.ObjectPath, iface string) []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchPathNamespace(namespace),
+		dbus.WithMatchInterface(common.PROPERTIES_INTERFACE),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, iface),
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// fakeConn is a connection which counts the match rules added on it and
+// delivers the signals passed to emit.
+type fakeConn struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	signals []chan<- *dbus.Signal
+	added   int
+	removed int
+}
+
+func newFakeConn() *fakeConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fakeConn{ctx: ctx, cancel: cancel}
+}
+
+func (c *fakeConn) Object(dest string, path dbus.ObjectPath) dbus.BusObject { return nil }
+func (c *fakeConn) Context() context.Context                                { return c.ctx }
+
+func (c *fakeConn) Signal(ch chan<- *dbus.Signal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.signals = append(c.signals, ch)
+}
+
+func (c *fakeConn) RemoveSignal(ch chan<- *dbus.Signal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for idx, s := range c.signals {
+		if s == ch {
+			c.signals = append(c.signals[:idx], c.signals[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (c *fakeConn) AddMatchSignal(options ...dbus.MatchOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.added++
+	return nil
+}
+
+func (c *fakeConn) RemoveMatchSignal(options ...dbus.MatchOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removed++
+	return nil
+}
+
+func (c *fakeConn) rules() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.added, c.removed
+}
+
+func (c *fakeConn) emit(path dbus.ObjectPath) {
+	c.send(&dbus.Signal{Path: path, Name: "org.example.Changed"})
+}
+
+func (c *fakeConn) send(sig *dbus.Signal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.signals {
+		ch <- sig
+	}
+}
+
+var rule = []dbus.MatchOption{dbus.WithMatchInterface("org.example")}
+
+func pathMatch(path dbus.ObjectPath) bus.Match {
+	return bus.Match{
+		Rules:  [][]dbus.MatchOption{rule},
+		Filter: func(sig *dbus.Signal) bool { return sig.Path == path },
+	}
+}
+
+func TestSubscribeSharesRules(t *testing.T) {
+	conn := newFakeConn()
+	defer conn.cancel()
+
+	a, err := bus.Subscribe(conn, pathMatch("/a"), 4, bus.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bus.Subscribe(conn, pathMatch("/b"), 4, bus.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, _ := conn.rules(); added != 1 {
+		t.Fatalf("expected 1 match rule, got %d", added)
+	}
+
+	conn.emit("/b")
+	conn.emit("/a")
+	if sig := <-a.Signals(); sig.Path != "/a" {
+		t.Fatalf("unexpected signal of %s", sig.Path)
+	}
+	if sig := <-b.Signals(); sig.Path != "/b" {
+		t.Fatalf("unexpected signal of %s", sig.Path)
+	}
+
+	a.Close()
+	if _, removed := conn.rules(); removed != 0 {
+		t.Fatal("expected shared match rule to be kept")
+	}
+	b.Close()
+	if _, removed := conn.rules(); removed != 1 {
+		t.Fatal("expected match rule to be removed with the last subscription")
+	}
+}
+
+func TestSubscribePolicies(t *testing.T) {
+	conn := newFakeConn()
+	defer conn.cancel()
+
+	newest, err := bus.Subscribe(conn, pathMatch("/x"), 1, bus.DropNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldest, err := bus.Subscribe(conn, pathMatch("/x"), 1, bus.DropOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	barrier, err := bus.Subscribe(conn, pathMatch("/sync"), 1, bus.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []dbus.ObjectPath{"/x", "/x", "/x", "/sync"} {
+		conn.emit(path)
+	}
+	// the barrier receives its signal once the others have been delivered
+	<-barrier.Signals()
+
+	if n := newest.Dropped(); n != 2 {
+		t.Fatalf("expected 2 signals dropped with DropNewest, got %d", n)
+	}
+	if n := oldest.Dropped(); n != 2 {
+		t.Fatalf("expected 2 signals dropped with DropOldest, got %d", n)
+	}
+	if n := barrier.Dropped(); n != 0 {
+		t.Fatalf("expected no signals dropped with Block, got %d", n)
+	}
+}
+
+func TestSubscribeClosed(t *testing.T) {
+	conn := newFakeConn()
+	sub, err := bus.Subscribe(conn, pathMatch("/a"), 1, bus.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.cancel()
+	if _, ok := <-sub.Signals(); ok {
+		t.Fatal("expected channel to be closed with the connection")
+	}
+	if _, err := bus.Subscribe(conn, pathMatch("/a"), 1, bus.Block); err == nil {
+		t.Fatal("expected error on closed connection")
+	}
+	if _, err := bus.Subscribe(newFakeConn(), pathMatch("/a"), 0, bus.Block); err == nil {
+		t.Fatal("expected error for empty buffer")
+	}
+}
+
+// dispatchAllocs is the allocation budget of dispatching a signal: the
+// targets of the signal.
+const dispatchAllocs = 1
+
+// subscribeNodes subscribes to the signals of n nodes on conn like the
+// proxies of n nodes do.
+func subscribeNodes(tb testing.TB, conn *fakeConn, n int) []*bus.Subscription {
+	subs := make([]*bus.Subscription, n)
+	for i := range subs {
+		sub, err := bus.Subscribe(conn, pathMatch(dbus.ObjectPath(fmt.Sprintf("/node/%d", i))), 16, bus.Block)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		tb.Cleanup(sub.Close)
+		subs[i] = sub
+	}
+	return subs
+}
+
+// BenchmarkDispatch measures delivering a signal to one of the
+// subscriptions of 100 nodes.
+func BenchmarkDispatch(b *testing.B) {
+	conn := newFakeConn()
+	defer conn.cancel()
+	subs := subscribeNodes(b, conn, 100)
+	sig := &dbus.Signal{Path: "/node/42", Name: "org.example.Changed"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.send(sig)
+		<-subs[42].Signals()
+	}
+}
+
+// TestDispatchAllocs guards the allocations of dispatching the signals of
+// a connection, which are dispatched for each signal received.
+func TestDispatchAllocs(t *testing.T) {
+	conn := newFakeConn()
+	defer conn.cancel()
+	subs := subscribeNodes(t, conn, 100)
+	sig := &dbus.Signal{Path: "/node/42", Name: "org.example.Changed"}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		conn.send(sig)
+		<-subs[42].Signals()
+	})
+	if allocs > dispatchAllocs {
+		t.Errorf("dispatching a signal allocates %v times, the budget is %d", allocs, dispatchAllocs)
+	}
+}
@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+)
+
+// FuzzTranslateReply feeds the translation of the object paths in replies
+// and signals under other names with arbitrary bodies, which must not
+// panic and must keep the shape of the body.
+func FuzzTranslateReply(f *testing.F) {
+	testbus.AddReply(f, dbus.ObjectPath("/org/example/node/node_a"))
+	testbus.AddReply(f, []struct {
+		Name string
+		Path dbus.ObjectPath
+	}{{"node_a", "/org/example/node/node_a"}})
+	testbus.AddReply(f, map[string]dbus.Variant{"Node": dbus.MakeVariant(dbus.MakeVariant(dbus.ObjectPath("/org/example")))})
+	testbus.AddReply(f, map[dbus.ObjectPath][]dbus.ObjectPath{"/org/example/a": {"/org/example/b"}})
+	testbus.AddReply(f, "org.example.Node", []byte("raw"))
+
+	t := newTranslator(&Names{Service: "org.example", ObjectPath: "/org/example", Interface: "org.example"})
+	f.Fuzz(func(tt *testing.T, sig string, data []byte) {
+		body, ok := testbus.DecodeReply(sig, data)
+		if !ok {
+			return
+		}
+		mapped := t.inverse().values(body)
+		if len(mapped) != len(body) {
+			tt.Fatalf("expected %d values, got %d", len(body), len(mapped))
+		}
+		for i := range body {
+			if reflect.TypeOf(mapped[i]) != reflect.TypeOf(body[i]) {
+				tt.Fatalf("expected a %T, got a %T", body[i], mapped[i])
+			}
+		}
+		_ = t.signal(&dbus.Signal{Name: "org.freedesktop.DBus.Properties.PropertiesChanged", Body: body})
+	})
+}
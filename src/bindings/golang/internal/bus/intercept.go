@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// CallInfo describes a call passed to Config.Intercept.
+type CallInfo struct {
+	// Method is the interface qualified name of the method.
+	Method string
+	// Path is the object path called.
+	Path dbus.ObjectPath
+	// Node is the name of the node the call is about, empty if not known.
+	Node string
+	// Unit is the name of the unit the call is about, empty if none.
+	Unit string
+	// Args are the arguments of the call.
+	Args []interface{}
+	// Metadata is the metadata attached to the context of the call.
+	Metadata common.Metadata
+}
+
+// Interceptor is called before each mutating call on the objects of a Conn.
+// A non-nil error fails the call without issuing it. Otherwise the returned
+// function, if not nil, is called with the error of the call once it is
+// done.
+type Interceptor func(ctx context.Context, info CallInfo) (func(err error), error)
+
+// mutating are the methods changing the state of the cluster, which are
+// passed to Config.Intercept and Config.DryRun.
+var mutating = map[string]bool{
+	common.METHOD_SET_LOG_LEVEL:       true,
+	common.METHOD_ENABLE_METRICS:      true,
+	common.METHOD_DISABLE_METRICS:     true,
+	common.METHOD_START_UNIT:          true,
+	common.METHOD_STOP_UNIT:           true,
+	common.METHOD_RESTART_UNIT:        true,
+	common.METHOD_RELOAD_UNIT:         true,
+	common.METHOD_FREEZE_UNIT:         true,
+	common.METHOD_THAW_UNIT:           true,
+	common.METHOD_RELOAD:              true,
+	common.METHOD_NODE_SET_LOG_LEVEL:  true,
+	common.METHOD_SET_UNIT_PROPERTIES: true,
+	common.METHOD_ENABLE_UNIT_FILES:   true,
+	common.METHOD_DISABLE_UNIT_FILES:  true,
+	common.METHOD_JOB_CANCEL:          true,
+	common.METHOD_PROPERTIES_SET:      true,
+}
+
+// DryRunJob is the object path of the jobs replied to the calls queueing
+// jobs in dry-run mode. Waiting for it yields the result "done" right away.
+const DryRunJob = dbus.ObjectPath(common.JOB_OBJECT_PATH_PREFIX + "/dryrun")
+
+// DryRun validates a mutating call in dry-run mode instead of issuing it.
+// The call fails with the returned error, otherwise it succeeds with a
+// reply of zero values, e.g. DryRunJob for calls queueing jobs.
+type DryRun func(ctx context.Context, info CallInfo) error
+
+// intercept runs a mutating call through the interceptor and the dry run of
+// the Conn. It returns the function to call with the outcome of the call
+// and, in dry-run mode, the call standing in for it.
+func (o *object) intercept(ctx context.Context, method string, args []interface{}) (func(error), *dbus.Call) {
+	cfg := &o.c.cfg
+	if !mutating[method] && !o.raw || cfg.Intercept == nil && cfg.DryRun == nil {
+		return nil, nil
+	}
+	node, unit := o.subject(method, args)
+	info := CallInfo{Method: method, Path: o.path, Node: node, Unit: unit, Args: args}
+	info.Metadata, _ = common.MetadataFromContext(ctx)
+
+	var after func(error)
+	if cfg.Intercept != nil {
+		var err error
+		if after, err = cfg.Intercept(ctx, info); err != nil {
+			return nil, failedCall(err, nil)
+		}
+	}
+	if cfg.DryRun == nil {
+		return after, nil
+	}
+	if err := cfg.DryRun(ctx, info); err != nil {
+		return after, failedCall(err, nil)
+	}
+	return after, &dbus.Call{Destination: o.dest, Path: o.path, Method: method, Args: args, Body: dryRunReply(method), Done: make(chan *dbus.Call, 1)}
+}
+
+// dryRunReply returns the reply to method in dry-run mode.
+func dryRunReply(method string) []interface{} {
+	switch method {
+	case common.METHOD_START_UNIT, common.METHOD_STOP_UNIT, common.METHOD_RESTART_UNIT, common.METHOD_RELOAD_UNIT:
+		return []interface{}{DryRunJob}
+	case common.METHOD_ENABLE_UNIT_FILES:
+		return []interface{}{false, [][]interface{}{}}
+	case common.METHOD_DISABLE_UNIT_FILES:
+		return []interface{}{[][]interface{}{}}
+	}
+	return nil
+}
+
+// subject returns the node and the unit a call is about, as far as known.
+func (o *object) subject(method string, args []interface{}) (string, string) {
+	node, unit := o.node, ""
+	switch {
+	case o.raw:
+		// nothing is known about the arguments of raw calls
+	case method == common.METHOD_MONITOR_SUBSCRIBE:
+		// Subscribe(node, unit), the node may be a wildcard
+		node, _ = stringArg(args, 0)
+		unit, _ = stringArg(args, 1)
+	case method == common.METHOD_GETNODE:
+		node, _ = stringArg(args, 0)
+	case method == common.METHOD_NODE_SET_LOG_LEVEL:
+	case strings.HasPrefix(method, common.NODE_INTERFACE+"."):
+		// the unit operations of nodes take the unit first
+		unit, _ = stringArg(args, 0)
+	}
+	return node, unit
+}
+
+func stringArg(args []interface{}, idx int) (string, bool) {
+	if idx >= len(args) {
+		return "", false
+	}
+	s, ok := args[idx].(string)
+	return s, ok
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// Attributes of the spans and log records of calls with common.Metadata.
+const (
+	attrInitiator = attribute.Key("bluechi.initiator")
+	attrReason    = attribute.Key("bluechi.reason")
+	attrTicketID  = attribute.Key("bluechi.ticket_id")
+)
+
+// metadataAttributes returns the span attributes of the metadata in ctx.
+func metadataAttributes(ctx context.Context) []attribute.KeyValue {
+	md, _ := common.MetadataFromContext(ctx)
+	var attrs []attribute.KeyValue
+	if md.Initiator != "" {
+		attrs = append(attrs, attrInitiator.String(md.Initiator))
+	}
+	if md.Reason != "" {
+		attrs = append(attrs, attrReason.String(md.Reason))
+	}
+	if md.TicketID != "" {
+		attrs = append(attrs, attrTicketID.String(md.TicketID))
+	}
+	return attrs
+}
+
+// metadataHandler adds the metadata in the context of a log record, e.g.
+// of a retried call, to the record.
+type metadataHandler struct {
+	slog.Handler
+}
+
+func (h metadataHandler) Handle(ctx context.Context, r slog.Record) error {
+	if md, ok := common.MetadataFromContext(ctx); ok && !md.IsZero() {
+		r = r.Clone()
+		if md.Initiator != "" {
+			r.AddAttrs(slog.String("initiator", md.Initiator))
+		}
+		if md.Reason != "" {
+			r.AddAttrs(slog.String("reason", md.Reason))
+		}
+		if md.TicketID != "" {
+			r.AddAttrs(slog.String("ticket_id", md.TicketID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h metadataHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return metadataHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h metadataHandler) WithGroup(name string) slog.Handler {
+	return metadataHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// Names are the bus name of the controller, the root of its object paths
+// and the prefix of its interfaces. The bindings use the standard names of
+// package common throughout, a Conn with other names translates them on
+// the wire.
+type Names struct {
+	Service    string
+	ObjectPath dbus.ObjectPath
+	Interface  string
+}
+
+// StandardNames are the names of BlueChi, used by the controller both in
+// system and in user mode.
+var StandardNames = Names{
+	Service:    common.BC_DBUS_NAME,
+	ObjectPath: common.BC_OBJECT_PATH,
+	Interface:  common.BC_INTERFACE_BASE_NAME,
+}
+
+// translator maps the names of a message between the standard names and
+// the names of a deployment.
+type translator struct {
+	from Names
+	to   Names
+}
+
+// newTranslator returns the translator to names, nil if no translation is
+// needed.
+func newTranslator(names *Names) *translator {
+	if names == nil || *names == StandardNames {
+		return nil
+	}
+	return &translator{from: StandardNames, to: *names}
+}
+
+// inverse returns the translator from the names of the deployment to the
+// standard ones.
+func (t *translator) inverse() *translator {
+	return &translator{from: t.to, to: t.from}
+}
+
+func (t *translator) service(name string) string {
+	if name == t.from.Service {
+		return t.to.Service
+	}
+	return name
+}
+
+func (t *translator) path(path dbus.ObjectPath) dbus.ObjectPath {
+	if path == t.from.ObjectPath {
+		return t.to.ObjectPath
+	}
+	if rest, ok := strings.CutPrefix(string(path), string(t.from.ObjectPath)+"/"); ok {
+		return t.to.ObjectPath + "/" + dbus.ObjectPath(rest)
+	}
+	return path
+}
+
+// name translates an interface name or a member qualified by it.
+func (t *translator) name(name string) string {
+	if name == t.from.Interface {
+		return t.to.Interface
+	}
+	if rest, ok := strings.CutPrefix(name, t.from.Interface+"."); ok {
+		return t.to.Interface + "." + rest
+	}
+	return name
+}
+
+// call translates the method and the arguments of a call. The interface
+// argument of the calls of org.freedesktop.DBus.Properties is translated
+// in addition to the object paths.
+func (t *translator) call(method string, args []interface{}) (string, []interface{}) {
+	mapped := t.values(args)
+	if strings.HasPrefix(method, common.PROPERTIES_INTERFACE+".") && len(mapped) > 0 {
+		if iface, ok := mapped[0].(string); ok {
+			mapped[0] = t.name(iface)
+		}
+	}
+	return t.name(method), mapped
+}
+
+// signal returns a copy of sig with translated names.
+func (t *translator) signal(sig *dbus.Signal) *dbus.Signal {
+	mapped := *sig
+	mapped.Path = t.path(sig.Path)
+	mapped.Name = t.name(sig.Name)
+	mapped.Body = t.values(sig.Body)
+	if sig.Name == common.SIGNAL_PROPERTIES_CHANGED && len(mapped.Body) > 0 {
+		if iface, ok := mapped.Body[0].(string); ok {
+			mapped.Body[0] = t.name(iface)
+		}
+	}
+	return &mapped
+}
+
+// match translates the options of a match rule.
+func (t *translator) match(options []dbus.MatchOption) []dbus.MatchOption {
+	mapped := make([]dbus.MatchOption, len(options))
+	for idx, option := range options {
+		// the fields of a MatchOption are only readable via reflection
+		v := reflect.ValueOf(option)
+		key, value := v.Field(0).String(), v.Field(1).String()
+		switch key {
+		case "sender", "destination":
+			value = t.service(value)
+		case "path", "path_namespace":
+			value = string(t.path(dbus.ObjectPath(value)))
+		case "interface", "arg0":
+			value = t.name(value)
+		}
+		mapped[idx] = dbus.WithMatchOption(key, value)
+	}
+	return mapped
+}
+
+// values translates the object paths in the body of a message.
+func (t *translator) values(body []interface{}) []interface{} {
+	mapped := make([]interface{}, len(body))
+	for idx, v := range body {
+		if v != nil {
+			mapped[idx] = t.value(reflect.ValueOf(v)).Interface()
+		}
+	}
+	return mapped
+}
+
+var (
+	objectPathType = reflect.TypeOf(dbus.ObjectPath(""))
+	variantType    = reflect.TypeOf(dbus.Variant{})
+)
+
+// value returns v with all object paths in it translated, copying the
+// slices, maps and structs containing them.
+func (t *translator) value(v reflect.Value) reflect.Value {
+	if !v.IsValid() {
+		return v
+	}
+	switch {
+	case v.Type() == objectPathType:
+		return reflect.ValueOf(t.path(v.Interface().(dbus.ObjectPath)))
+	case v.Type() == variantType:
+		variant := v.Interface().(dbus.Variant)
+		if variant.Value() == nil {
+			return v
+		}
+		mapped := t.value(reflect.ValueOf(variant.Value()))
+		return reflect.ValueOf(dbus.MakeVariantWithSignature(mapped.Interface(), variant.Signature()))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		mapped := reflect.New(v.Type()).Elem()
+		mapped.Set(t.value(v.Elem()))
+		return mapped
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		mapped := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for idx := range v.Len() {
+			mapped.Index(idx).Set(t.value(v.Index(idx)))
+		}
+		return mapped
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		mapped := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			mapped.SetMapIndex(t.value(iter.Key()), t.value(iter.Value()))
+		}
+		return mapped
+	case reflect.Struct:
+		mapped := reflect.New(v.Type()).Elem()
+		mapped.Set(v)
+		for idx := range v.NumField() {
+			if mapped.Field(idx).CanSet() {
+				mapped.Field(idx).Set(t.value(v.Field(idx)))
+			}
+		}
+		return mapped
+	}
+	return v
+}
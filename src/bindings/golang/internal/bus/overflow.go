@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"sync/atomic"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// Overflow decides what happens with an event for a consumer whose channel
+// is full.
+type Overflow int
+
+const (
+	// OverflowBlock waits for the consumer to make room.
+	OverflowBlock Overflow = iota
+	// OverflowDropOldest drops the oldest event in the channel.
+	OverflowDropOldest
+	// OverflowCoalesce merges the event with an earlier event in the
+	// channel it supersedes, e.g. a state change of the same unit, and
+	// drops the oldest event if there is none.
+	OverflowCoalesce
+)
+
+// Outbox is the channel on which events are delivered to a consumer
+// according to the overflow policy of a connection. It has a single
+// sender.
+type Outbox[T any] struct {
+	// C is the channel of the consumer.
+	C chan T
+
+	policy   Overflow
+	merge    func(old, new T) (T, bool)
+	counters []*atomic.Uint64
+}
+
+// NewOutbox returns an outbox buffering size events, which must be
+// positive, with the overflow policy of conn. The policy is OverflowBlock
+// for connections other than a Conn. merge returns the event replacing old
+// and new if new supersedes old, it is only used by OverflowCoalesce and
+// may be nil. The events lost are counted by the counters and by the
+// counter of the Conn.
+func NewOutbox[T any](conn common.Connection, size int, merge func(old, new T) (T, bool), counters ...*atomic.Uint64) *Outbox[T] {
+	o := &Outbox[T]{C: make(chan T, size), merge: merge, counters: counters}
+	if c, ok := conn.(*Conn); ok {
+		o.policy = c.cfg.Overflow
+		if c.cfg.Overflows != nil {
+			o.counters = append(o.counters, c.cfg.Overflows)
+		}
+	}
+	return o
+}
+
+// Offer delivers v unless the channel is full and the policy is
+// OverflowBlock, in which case it returns false and the caller waits for
+// the consumer itself.
+func (o *Outbox[T]) Offer(v T) bool {
+	select {
+	case o.C <- v:
+		return true
+	default:
+	}
+
+	switch o.policy {
+	case OverflowDropOldest:
+		o.dropOldest(v)
+	case OverflowCoalesce:
+		o.coalesce(v)
+	default:
+		return false
+	}
+	return true
+}
+
+// overflow counts an event dropped or merged into another because the
+// channel was full.
+func (o *Outbox[T]) overflow() {
+	for _, c := range o.counters {
+		c.Add(1)
+	}
+}
+
+func (o *Outbox[T]) dropOldest(v T) {
+	// the consumer may have made room meanwhile, then nothing is dropped
+	select {
+	case <-o.C:
+		o.overflow()
+	default:
+	}
+	select {
+	case o.C <- v:
+	default:
+		o.overflow()
+	}
+}
+
+func (o *Outbox[T]) coalesce(v T) {
+	if o.merge == nil {
+		o.dropOldest(v)
+		return
+	}
+
+	// take the pending events out, the consumer receives the ones it is
+	// reading meanwhile in order
+	pending := make([]T, 0, cap(o.C))
+drain:
+	for len(pending) < cap(o.C) {
+		select {
+		case e := <-o.C:
+			pending = append(pending, e)
+		default:
+			break drain
+		}
+	}
+
+	lost := false
+	for idx := len(pending) - 1; idx >= 0; idx-- {
+		if e, ok := o.merge(pending[idx], v); ok {
+			pending = append(pending[:idx], pending[idx+1:]...)
+			v, lost = e, true
+			break
+		}
+	}
+	if !lost && len(pending) == cap(o.C) {
+		pending, lost = pending[1:], true
+	}
+	if lost {
+		o.overflow()
+	}
+	for _, e := range append(pending, v) {
+		o.C <- e
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+type change struct {
+	key   string
+	value int
+}
+
+func mergeChanges(prev, next change) (change, bool) {
+	return next, prev.key == next.key
+}
+
+func drain[T any](ch chan T) []T {
+	var values []T
+	for len(ch) > 0 {
+		values = append(values, <-ch)
+	}
+	return values
+}
+
+func TestOutbox(t *testing.T) {
+	tests := []struct {
+		policy    Overflow
+		offered   bool
+		want      []change
+		overflows uint64
+	}{
+		{OverflowBlock, false, []change{{"a", 1}, {"b", 1}}, 0},
+		{OverflowDropOldest, true, []change{{"b", 1}, {"a", 2}}, 1},
+		{OverflowCoalesce, true, []change{{"b", 1}, {"a", 2}}, 1},
+	}
+	for _, tt := range tests {
+		var counter atomic.Uint64
+		o := &Outbox[change]{C: make(chan change, 2), policy: tt.policy, merge: mergeChanges, counters: []*atomic.Uint64{&counter}}
+		o.Offer(change{"a", 1})
+		o.Offer(change{"b", 1})
+		if offered := o.Offer(change{"a", 2}); offered != tt.offered {
+			t.Errorf("policy %d: expected offer %t, got %t", tt.policy, tt.offered, offered)
+		}
+		if got := drain(o.C); !slices.Equal(got, tt.want) {
+			t.Errorf("policy %d: expected %v, got %v", tt.policy, tt.want, got)
+		}
+		if n := counter.Load(); n != tt.overflows {
+			t.Errorf("policy %d: expected %d overflows, got %d", tt.policy, tt.overflows, n)
+		}
+	}
+}
+
+func TestOutboxCoalesceWithoutMatch(t *testing.T) {
+	var counter atomic.Uint64
+	o := &Outbox[change]{C: make(chan change, 2), policy: OverflowCoalesce, merge: mergeChanges, counters: []*atomic.Uint64{&counter}}
+	for _, c := range []change{{"a", 1}, {"b", 1}, {"c", 1}} {
+		o.Offer(c)
+	}
+	want := []change{{"b", 1}, {"c", 1}}
+	if got := drain(o.C); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if n := counter.Load(); n != 1 {
+		t.Fatalf("expected 1 overflow, got %d", n)
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is wrapped together with the context error by the errors of
+// the calls whose context expires before the rate limit lets them through.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimiter is a token bucket limiting the calls issued on the objects of
+// the Conns sharing it. It holds up to Burst tokens and gains Rate tokens
+// per second, each call takes one and waits for it if there is none left.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu sync.Mutex
+	// tokens drops below zero by the calls waiting for a token
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a full RateLimiter.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve takes a token and returns the time until it is available.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a token reserved by a call which was not issued.
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// wait waits for a token until ctx or done is done. A call whose context
+// expires before its token is available fails right away.
+func (l *RateLimiter) wait(ctx context.Context, done <-chan struct{}) error {
+	delay := l.reserve()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		l.cancel()
+		return fmt.Errorf("failed to wait for rate limit: %w: %w", ErrRateLimited, context.DeadlineExceeded)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return fmt.Errorf("failed to wait for rate limit: %w: %w", ErrRateLimited, ctx.Err())
+	case <-done:
+		l.cancel()
+		return ErrDisconnected
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// Retry describes how calls failing with a transient error are repeated.
+type Retry struct {
+	// MaxAttempts is the number of attempts including the first one.
+	MaxAttempts int
+	// Backoff is the delay between two attempts.
+	Backoff Backoff
+	// NonIdempotent also repeats the calls which queue jobs or create
+	// objects on the controller, which might then be done twice if only
+	// the reply was lost.
+	NonIdempotent bool
+}
+
+// nonIdempotent are the methods whose effect is not the same when called
+// twice.
+var nonIdempotent = map[string]bool{
+	common.METHOD_START_UNIT:             true,
+	common.METHOD_STOP_UNIT:              true,
+	common.METHOD_RESTART_UNIT:           true,
+	common.METHOD_RELOAD_UNIT:            true,
+	common.METHOD_CREATE_MONITOR:         true,
+	common.METHOD_MONITOR_SUBSCRIBE:      true,
+	common.METHOD_MONITOR_SUBSCRIBE_LIST: true,
+	common.METHOD_MONITOR_ADD_PEER:       true,
+}
+
+func (r *Retry) allows(method string) bool {
+	return r.MaxAttempts > 1 && (r.NonIdempotent || !nonIdempotent[method])
+}
+
+// transient reports whether a call failing with err might succeed when
+// issued again, i.e. if the connection was down or the call timed out.
+// Calls refused by the rate limit are not, their context is spent.
+func transient(err error) bool {
+	if errors.Is(err, ErrRateLimited) {
+		return false
+	}
+	if errors.Is(err, ErrDisconnected) || errors.Is(err, dbus.ErrClosed) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var e *common.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Name {
+	case common.ERROR_NO_REPLY, common.ERROR_TIMEOUT, common.ERROR_DISCONNECTED:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// ErrShuttingDown is returned by calls on a Conn and waits for jobs started
+// after Shutdown was called.
+var ErrShuttingDown = errors.New("shutting down the connection to the BlueChi controller")
+
+// gauge counts the operations or goroutines of a Conn which Shutdown waits
+// for.
+type gauge struct {
+	mu     sync.Mutex
+	n      int
+	closed bool
+	// zero is closed once n drops to zero, created by wait
+	zero chan struct{}
+}
+
+// acquire counts an operation unless the gauge is closed.
+func (g *gauge) acquire() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.n++
+	return true
+}
+
+func (g *gauge) add() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.n++
+}
+
+func (g *gauge) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.n--
+	if g.n == 0 && g.zero != nil {
+		close(g.zero)
+		g.zero = nil
+	}
+}
+
+// wait closes the gauge if close is set and waits until nothing is counted
+// anymore or ctx is done.
+func (g *gauge) wait(ctx context.Context, close bool) error {
+	g.mu.Lock()
+	g.closed = g.closed || close
+	if g.n == 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	if g.zero == nil {
+		g.zero = make(chan struct{})
+	}
+	zero := g.zero
+	g.mu.Unlock()
+
+	select {
+	case <-zero:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Begin counts an operation on conn in flight, e.g. a wait for a job, until
+// the returned function is called. Shutdown waits for the operations in
+// flight before closing the connection, once it was called Begin fails with
+// ErrShuttingDown. The calls on the objects of a Conn are counted by
+// themselves. On other connections Begin has no effect.
+func Begin(conn common.Connection) (func(), error) {
+	c, ok := conn.(*Conn)
+	if !ok {
+		return func() {}, nil
+	}
+	if !c.inflight.acquire() {
+		return nil, ErrShuttingDown
+	}
+	var once sync.Once
+	return func() { once.Do(c.inflight.release) }, nil
+}
+
+// Go runs fn on a goroutine, which Shutdown waits for after closing conn if
+// conn is a Conn. It is used for the goroutines feeding event channels from
+// the signals of conn, which must return once the context of conn is done,
+// so that their channels are closed when Shutdown returns.
+func Go(conn common.Connection, fn func()) {
+	c, ok := conn.(*Conn)
+	if !ok {
+		go fn()
+		return
+	}
+	c.workers.add()
+	go func() {
+		defer c.workers.release()
+		fn()
+	}()
+}
+
+// Shutdown closes the connection gracefully: calls and job waits started
+// afterwards fail with ErrShuttingDown, the ones in flight are waited for,
+// then the connection is closed like by Close and the goroutines started
+// with Go are waited for. If ctx is done first, the connection is closed
+// right away and the error of ctx is returned.
+func (c *Conn) Shutdown(ctx context.Context) error {
+	drained := c.inflight.wait(ctx, true)
+	closeErr := c.Close()
+	if drained != nil {
+		return fmt.Errorf("failed to wait for calls in flight: %w", drained)
+	}
+	if err := c.workers.wait(ctx, false); err != nil {
+		return fmt.Errorf("failed to wait for event delivery to end: %w", err)
+	}
+	return closeErr
+}
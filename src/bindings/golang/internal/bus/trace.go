@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package bus
+
+import (
+	"context"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// TracerName is the name of the tracer the bindings create their spans
+// with.
+const TracerName = "github.com/eclipse-bluechi/bluechi/src/bindings/golang"
+
+// Attributes of the spans of the calls.
+const (
+	attrRPCSystem  = attribute.Key("rpc.system")
+	attrRPCService = attribute.Key("rpc.service")
+	attrRPCMethod  = attribute.Key("rpc.method")
+	attrObjectPath = attribute.Key("dbus.object_path")
+	attrNode       = attribute.Key("bluechi.node")
+	attrUnit       = attribute.Key("bluechi.unit")
+	attrAttempt    = attribute.Key("bluechi.attempt")
+	attrError      = attribute.Key("error.message")
+)
+
+// NodeObject is Object for the object of the named node. The spans of its
+// calls carry the name of the node.
+func NodeObject(conn common.Connection, node string, path dbus.ObjectPath) dbus.BusObject {
+	obj := Object(conn, common.BC_DBUS_NAME, path)
+	if o, ok := obj.(*object); ok {
+		o.node = node
+	}
+	return obj
+}
+
+// startSpan starts the span of a call of method on o if the Conn has a
+// tracer. It is a child of the span in ctx, so calls issued in a traced
+// operation show up in its trace.
+func (o *object) startSpan(ctx context.Context, method string, args []interface{}) (context.Context, trace.Span) {
+	tracer := o.c.cfg.Tracer
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	service, member := method, ""
+	if i := strings.LastIndexByte(method, '.'); i >= 0 {
+		service, member = method[:i], method[i+1:]
+	}
+	attrs := []attribute.KeyValue{
+		attrRPCSystem.String("dbus"),
+		attrRPCService.String(service),
+		attrRPCMethod.String(member),
+		attrObjectPath.String(string(o.path)),
+	}
+	node, unit := o.subject(method, args)
+	if node != "" {
+		attrs = append(attrs, attrNode.String(node))
+	}
+	if unit != "" {
+		attrs = append(attrs, attrUnit.String(unit))
+	}
+	attrs = append(attrs, metadataAttributes(ctx)...)
+	return tracer.Start(ctx, service+"/"+member, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span with the outcome of the call, if span is set.
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
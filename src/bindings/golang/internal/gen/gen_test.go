@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGoType(t *testing.T) {
+	tests := []struct {
+		sig     string
+		want    string
+		structs []string
+	}{
+		{sig: "s", want: "string"},
+		{sig: "u", want: "uint32"},
+		{sig: "t", want: "uint64"},
+		{sig: "b", want: "bool"},
+		{sig: "o", want: "dbus.ObjectPath"},
+		{sig: "v", want: "dbus.Variant"},
+		{sig: "as", want: "[]string"},
+		{sig: "a{sv}", want: "map[string]dbus.Variant"},
+		{sig: "a{sas}", want: "map[string][]string"},
+		{sig: "a(sv)", want: "[]Value", structs: []string{"Value"}},
+		{sig: "(s(ou))", want: "Values", structs: []string{"ValuesField2", "Values"}},
+	}
+	for _, tt := range tests {
+		var m typeMapper
+		got, err := m.goType(tt.sig, "Values", nil)
+		if err != nil {
+			t.Errorf("goType(%q): %v", tt.sig, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("goType(%q) = %q, expected %q", tt.sig, got, tt.want)
+		}
+		var structs []string
+		for _, s := range m.structs {
+			structs = append(structs, s.Name)
+		}
+		if !reflect.DeepEqual(structs, tt.structs) {
+			t.Errorf("goType(%q) generated structs %v, expected %v", tt.sig, structs, tt.structs)
+		}
+	}
+}
+
+func TestGoTypeInvalid(t *testing.T) {
+	for _, sig := range []string{"", "ss", "a{sv", "(s", "z"} {
+		var m typeMapper
+		if _, err := m.goType(sig, "Value", nil); err == nil {
+			t.Errorf("expected error for signature %q", sig)
+		}
+	}
+}
+
+func TestExported(t *testing.T) {
+	tests := map[string]string{
+		"node":           "Node",
+		"active_state":   "ActiveState",
+		"JobType":        "JobType",
+		"job_id":         "JobID",
+		"id":             "ID",
+		"interface":      "Interface",
+		"carriesInstall": "CarriesInstall",
+	}
+	for name, want := range tests {
+		if got := exported(name); got != want {
+			t.Errorf("exported(%q) = %q, expected %q", name, got, want)
+		}
+	}
+	if got := unexported("interface"); got != "iface" {
+		t.Errorf("unexported(interface) = %q, expected iface", got)
+	}
+	if got := unexported("id"); got != "id" {
+		t.Errorf("unexported(id) = %q, expected id", got)
+	}
+}
+
+func TestParseDoc(t *testing.T) {
+	d := parseDoc(`
+      StartUnit:
+      @name: The name of the unit
+      @changes: The changes made:
+        - type of change
+        - file name
+
+      Starts the unit.
+      On the node.
+
+      Second paragraph.
+    `)
+	want := doc{
+		Paragraphs: []string{"Starts the unit. On the node.", "Second paragraph."},
+		Args: map[string][]string{
+			"name":    {"The name of the unit"},
+			"changes": {"The changes made:", "- type of change", "- file name"},
+		},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("unexpected doc %+v, expected %+v", d, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	ifaces, err := parse(strings.NewReader(`<node>
+  <!-- Test interface. -->
+  <interface name="org.eclipse.bluechi.Test">
+    <!--
+      Do:
+      @in1: first input
+
+      Does it.
+    -->
+    <method name="Do">
+      <arg name="in1" type="s" />
+      <arg name="out1" type="u" direction="out" />
+    </method>
+    <signal name="Done">
+      <arg name="id" type="u" />
+    </signal>
+    <property name="Name" type="s" access="read" />
+  </interface>
+</node>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ifaces) != 1 {
+		t.Fatalf("expected one interface, got %d", len(ifaces))
+	}
+	i := ifaces[0]
+	if i.Name != "org.eclipse.bluechi.Test" || !reflect.DeepEqual(i.Doc.Paragraphs, []string{"Test interface."}) {
+		t.Errorf("unexpected interface %+v", i)
+	}
+	if len(i.Methods) != 1 || !reflect.DeepEqual(i.Methods[0].Args, []arg{{"in1", "s", "in"}, {"out1", "u", "out"}}) {
+		t.Errorf("unexpected methods %+v", i.Methods)
+	}
+	if len(i.Signals) != 1 || !reflect.DeepEqual(i.Signals[0].Args, []arg{{"id", "u", "out"}}) {
+		t.Errorf("unexpected signals %+v", i.Signals)
+	}
+	if len(i.Properties) != 1 || i.Properties[0].Access != "read" {
+		t.Errorf("unexpected properties %+v", i.Properties)
+	}
+
+	src, err := generate("api", "test.xml", ifaces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func (p *Test) Do(ctx context.Context, in1 string) (uint32, error)",
+		"func (p *Test) Name(ctx context.Context) (string, error)",
+		"func DecodeTestDone(sig *dbus.Signal) (TestDone, error)",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("generated code lacks %q:\n%s", want, src)
+		}
+	}
+}
+
+// TestUpToDate checks that the committed api package matches the XML files,
+// i.e. that go generate has been run after changing them.
+func TestUpToDate(t *testing.T) {
+	dataDir := filepath.Join("..", "..", "..", "..", "..", "data")
+	if _, err := os.Stat(dataDir); err != nil {
+		t.Skipf("introspection XML files not available: %v", err)
+	}
+	files, err := generateDir(dataDir, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		existing, err := os.ReadFile(filepath.Join("..", "..", "api", name))
+		if err != nil {
+			t.Errorf("%v, run go generate ./api", err)
+			continue
+		}
+		if !bytes.Equal(existing, src) {
+			t.Errorf("api/%s is out of date, run go generate ./api", name)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// interfacePrefix is the common prefix of the public BlueChi interfaces,
+// stripped to name the generated types.
+const interfacePrefix = "org.eclipse.bluechi."
+
+// wrapWidth is the width doc comments are wrapped at.
+const wrapWidth = 77
+
+// generator renders the Go code for the interfaces of one XML file.
+type generator struct {
+	pkg    string
+	source string
+	buf    bytes.Buffer
+	types  typeMapper
+}
+
+// generate returns the formatted Go source for ifaces, read from the XML
+// file named source.
+func generate(pkg string, source string, ifaces []iface) ([]byte, error) {
+	g := &generator{pkg: pkg, source: source}
+	var body bytes.Buffer
+	for _, i := range ifaces {
+		if err := g.iface(&body, i); err != nil {
+			return nil, fmt.Errorf("interface %s: %w", i.Name, err)
+		}
+	}
+
+	g.printf("// SPDX-License-Identifier: LGPL-2.1-or-later\n\n")
+	g.printf("// Code generated by internal/gen from %s. DO NOT EDIT.\n\n", source)
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n")
+	if usesContext(ifaces) {
+		g.printf("\t\"context\"\n")
+	}
+	g.printf("\t\"fmt\"\n\n\t\"github.com/godbus/dbus/v5\"\n\n")
+	g.printf("\t\"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common\"\n")
+	g.printf("\t\"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus\"\n)\n\n")
+	g.buf.Write(body.Bytes())
+	for _, s := range g.types.structs {
+		g.structType(s)
+	}
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w\n%s", err, g.buf.Bytes())
+	}
+	return src, nil
+}
+
+// usesContext reports whether the code for ifaces contains calls, which
+// take a context.
+func usesContext(ifaces []iface) bool {
+	for _, i := range ifaces {
+		if len(i.Methods) > 0 || len(i.Properties) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func goName(i iface) string {
+	return exported(strings.ReplaceAll(strings.TrimPrefix(i.Name, interfacePrefix), ".", "_"))
+}
+
+func (g *generator) iface(w *bytes.Buffer, i iface) error {
+	name := goName(i)
+	dest := "common.BC_DBUS_NAME"
+	if i.Name == "org.eclipse.bluechi.Agent" {
+		dest = "common.BC_AGENT_DBUS_NAME"
+	}
+
+	fmt.Fprintf(w, "// %sInterface is the name of the %s interface.\n", name, i.Name)
+	fmt.Fprintf(w, "const %sInterface = %q\n\n", name, i.Name)
+
+	writeDoc(w, "", append([]string{fmt.Sprintf("%s is a proxy for the %s interface.", name, i.Name)}, i.Doc.Paragraphs...))
+	fmt.Fprintf(w, "type %s struct {\n\tobj dbus.BusObject\n}\n\n", name)
+	writeDoc(w, "", []string{fmt.Sprintf("New%s returns a proxy for the %s interface of the object at path.", name, i.Name)})
+	fmt.Fprintf(w, "func New%s(conn common.Connection, path dbus.ObjectPath) *%s {\n", name, name)
+	fmt.Fprintf(w, "\treturn &%s{obj: bus.Object(conn, %s, path)}\n}\n\n", name, dest)
+	fmt.Fprintf(w, "// ObjectPath returns the path of the object.\n")
+	fmt.Fprintf(w, "func (p *%s) ObjectPath() dbus.ObjectPath {\n\treturn p.obj.Path()\n}\n\n", name)
+
+	methods := make(map[string]bool)
+	for _, m := range i.Methods {
+		methods[m.Name] = true
+		if err := g.method(w, name, i.Name, m); err != nil {
+			return fmt.Errorf("method %s: %w", m.Name, err)
+		}
+	}
+	for _, p := range i.Properties {
+		if err := g.property(w, name, i.Name, p, methods); err != nil {
+			return fmt.Errorf("property %s: %w", p.Name, err)
+		}
+	}
+	for _, s := range i.Signals {
+		if err := g.signal(w, name, i.Name, s); err != nil {
+			return fmt.Errorf("signal %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+type param struct {
+	name string
+	typ  string
+	doc  []string
+}
+
+func (g *generator) params(owner string, args []arg, d doc, direction string, taken map[string]bool) ([]param, error) {
+	var params []param
+	for idx, a := range args {
+		if a.Direction != direction {
+			continue
+		}
+		name := unexported(a.Name)
+		if name == "" {
+			name = fmt.Sprintf("%s%d", direction, idx)
+		}
+		for taken[name] {
+			name += "Out"
+		}
+		taken[name] = true
+
+		typ, err := g.types.goType(a.Type, owner+singular(exported(a.Name)), d.Args[a.Name])
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", a.Name, err)
+		}
+		params = append(params, param{name: name, typ: typ, doc: d.Args[a.Name]})
+	}
+	return params, nil
+}
+
+func (g *generator) method(w *bytes.Buffer, typeName string, ifaceName string, m member) error {
+	taken := map[string]bool{"ctx": true}
+	in, err := g.params(typeName+m.Name, m.Args, m.Doc, "in", taken)
+	if err != nil {
+		return err
+	}
+	out, err := g.params(typeName+m.Name, m.Args, m.Doc, "out", taken)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{fmt.Sprintf("%s calls the %s method.", m.Name, m.Name)}
+	lines = append(lines, m.Doc.Paragraphs...)
+	writeDoc(w, "", lines)
+	writeArgDocs(w, "", append(append([]param(nil), in...), out...))
+
+	sig := []string{"ctx context.Context"}
+	callArgs := []string{"ctx", "p.obj", fmt.Sprintf("%sInterface+\".%s\"", typeName, m.Name)}
+	for _, p := range in {
+		sig = append(sig, p.name+" "+p.typ)
+		callArgs = append(callArgs, p.name)
+	}
+	var results, stores []string
+	for _, p := range out {
+		results = append(results, p.typ)
+		stores = append(stores, "&"+p.name)
+	}
+	results = append(results, "error")
+
+	fmt.Fprintf(w, "func (p *%s) %s(%s) (%s) {\n", typeName, m.Name, strings.Join(sig, ", "), strings.Join(results, ", "))
+	switch len(out) {
+	case 0:
+		fmt.Fprintf(w, "\terr := bus.Exec(%s)\n", strings.Join(callArgs, ", "))
+	case 1:
+		fmt.Fprintf(w, "\t%s, err := bus.Call[%s](%s)\n", out[0].name, out[0].typ, strings.Join(callArgs, ", "))
+	default:
+		// bus.Call decodes a single return value only
+		for _, p := range out {
+			fmt.Fprintf(w, "\tvar %s %s\n", p.name, p.typ)
+		}
+		args := append([]string{"ctx", callArgs[2], "0"}, callArgs[3:]...)
+		fmt.Fprintf(w, "\terr := p.obj.CallWithContext(%s).Store(%s)\n", strings.Join(args, ", "), strings.Join(stores, ", "))
+	}
+	var names []string
+	for _, p := range out {
+		names = append(names, p.name)
+	}
+	ret := strings.Join(append(names, ""), ", ")
+	fmt.Fprintf(w, "\tif err != nil {\n\t\treturn %sfmt.Errorf(\"failed to call %s.%s: %%w\", err)\n\t}\n", ret, ifaceName, m.Name)
+	fmt.Fprintf(w, "\treturn %snil\n}\n\n", ret)
+	return nil
+}
+
+func (g *generator) property(w *bytes.Buffer, typeName string, ifaceName string, p property, methods map[string]bool) error {
+	typ, err := g.types.goType(p.Type, typeName+exported(p.Name), nil)
+	if err != nil {
+		return err
+	}
+	getter := exported(p.Name)
+	if methods[getter] {
+		getter = "Get" + getter
+	}
+
+	if p.Access == "read" || p.Access == "readwrite" {
+		writeDoc(w, "", append([]string{fmt.Sprintf("%s returns the %s property.", getter, p.Name)}, p.Doc.Paragraphs...))
+		fmt.Fprintf(w, "func (p *%s) %s(ctx context.Context) (%s, error) {\n", typeName, getter, typ)
+		fmt.Fprintf(w, "\tvar value %s\n", typ)
+		fmt.Fprintf(w, "\tv, err := bus.GetProperty(ctx, p.obj, %sInterface, %q)\n", typeName, p.Name)
+		fmt.Fprintf(w, "\tif err == nil {\n\t\terr = v.Store(&value)\n\t}\n")
+		fmt.Fprintf(w, "\tif err != nil {\n\t\treturn value, fmt.Errorf(\"failed to get property %s of %s: %%w\", err)\n\t}\n", p.Name, ifaceName)
+		fmt.Fprintf(w, "\treturn value, nil\n}\n\n")
+	}
+	if p.Access == "write" || p.Access == "readwrite" {
+		fmt.Fprintf(w, "// Set%s sets the %s property.\n", exported(p.Name), p.Name)
+		fmt.Fprintf(w, "func (p *%s) Set%s(ctx context.Context, value %s) error {\n", typeName, exported(p.Name), typ)
+		fmt.Fprintf(w, "\terr := bus.Exec(ctx, p.obj, common.METHOD_PROPERTIES_SET, %sInterface, %q, dbus.MakeVariant(value))\n", typeName, p.Name)
+		fmt.Fprintf(w, "\tif err != nil {\n\t\treturn fmt.Errorf(\"failed to set property %s of %s: %%w\", err)\n\t}\n", p.Name, ifaceName)
+		fmt.Fprintf(w, "\treturn nil\n}\n\n")
+	}
+	return nil
+}
+
+func (g *generator) signal(w *bytes.Buffer, typeName string, ifaceName string, s member) error {
+	name := typeName + s.Name
+	fields, err := g.params(name, s.Args, s.Doc, "out", map[string]bool{})
+	if err != nil {
+		return err
+	}
+
+	writeDoc(w, "", []string{fmt.Sprintf("%sSignal is the name of the %s signal of %s.", name, s.Name, ifaceName)})
+	fmt.Fprintf(w, "const %sSignal = %sInterface + \".%s\"\n\n", name, typeName, s.Name)
+
+	writeDoc(w, "", append([]string{fmt.Sprintf("%s is the %s signal of %s.", name, s.Name, ifaceName)}, s.Doc.Paragraphs...))
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	var stores []string
+	for idx, f := range fields {
+		field := exported(s.Args[idx].Name)
+		if idx > 0 {
+			w.WriteString("\n")
+		}
+		writeDoc(w, "\t", f.doc)
+		fmt.Fprintf(w, "\t%s %s\n", field, f.typ)
+		stores = append(stores, "&s."+field)
+	}
+	fmt.Fprintf(w, "}\n\n")
+
+	fmt.Fprintf(w, "// Decode%s decodes a %s signal.\n", name, s.Name)
+	fmt.Fprintf(w, "func Decode%s(sig *dbus.Signal) (%s, error) {\n\tvar s %s\n", name, name, name)
+	fmt.Fprintf(w, "\tif sig.Name != %sSignal {\n\t\treturn s, fmt.Errorf(\"unexpected signal %%s, expected %%s\", sig.Name, %sSignal)\n\t}\n", name, name)
+	if len(stores) > 0 {
+		fmt.Fprintf(w, "\tif err := dbus.Store(sig.Body, %s); err != nil {\n", strings.Join(stores, ", "))
+		fmt.Fprintf(w, "\t\treturn s, fmt.Errorf(\"failed to decode signal %%s: %%w\", sig.Name, err)\n\t}\n")
+	}
+	fmt.Fprintf(w, "\treturn s, nil\n}\n\n")
+	return nil
+}
+
+func (g *generator) structType(s structType) {
+	lines := []string{fmt.Sprintf("%s is the D-Bus struct %s.", s.Name, s.Sig)}
+	// the argument description lists the fields of the struct
+	var fieldDocs []string
+	for _, line := range s.Doc {
+		if strings.HasPrefix(line, "- ") {
+			fieldDocs = append(fieldDocs, strings.TrimPrefix(line, "- "))
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	writeDoc(&g.buf, "", []string{sentence(strings.Join(lines, " "))})
+	g.printf("type %s struct {\n", s.Name)
+	for idx, f := range s.Fields {
+		if len(fieldDocs) == len(s.Fields) {
+			writeDoc(&g.buf, "\t", []string{fieldDocs[idx]})
+		}
+		g.printf("\tField%d %s\n", idx+1, f)
+	}
+	g.printf("}\n\n")
+}
+
+// sentence terminates text, which may end with a colon introducing a list,
+// with a period.
+func sentence(text string) string {
+	text = strings.TrimSuffix(strings.TrimSpace(text), ":")
+	if !strings.HasSuffix(text, ".") {
+		text += "."
+	}
+	return text
+}
+
+// writeDoc writes paragraphs as doc comment, wrapped at wrapWidth.
+func writeDoc(w *bytes.Buffer, indent string, paragraphs []string) {
+	for idx, para := range paragraphs {
+		if idx > 0 {
+			fmt.Fprintf(w, "%s//\n", indent)
+		}
+		for _, line := range wrap(para, wrapWidth) {
+			fmt.Fprintf(w, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+// wrap splits text into lines of at most width characters, unless a single
+// word is longer.
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// writeArgDocs appends the descriptions of the arguments as list to a doc
+// comment.
+func writeArgDocs(w *bytes.Buffer, indent string, params []param) {
+	var documented []param
+	for _, p := range params {
+		if len(p.doc) > 0 {
+			documented = append(documented, p)
+		}
+	}
+	if len(documented) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s//\n", indent)
+	for _, p := range documented {
+		// field lists of structs are documented on the struct type
+		var desc []string
+		for _, line := range p.doc {
+			if !strings.HasPrefix(line, "- ") {
+				desc = append(desc, line)
+			}
+		}
+		text := p.name + ": " + strings.TrimSuffix(strings.Join(desc, " "), ":")
+		for idx, line := range wrap(text, wrapWidth-4) {
+			prefix := "  - "
+			if idx > 0 {
+				prefix = "    "
+			}
+			fmt.Fprintf(w, "%s//%s%s\n", indent, prefix, line)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Command gen generates typed Go wrappers for the public BlueChi D-Bus
+// interfaces from their introspection XML files.
+//
+// For every org.eclipse.bluechi.*.xml file in the input directory, except
+// the internal interfaces, one Go file is written to the output directory,
+// named after the interface, e.g. node.go for org.eclipse.bluechi.Node.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	in := flag.String("in", "data", "directory containing the introspection XML files")
+	out := flag.String("out", ".", "directory to write the generated files to")
+	pkg := flag.String("pkg", "api", "name of the generated package")
+	flag.Parse()
+
+	files, err := generateDir(*in, *pkg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen: %v\n", err)
+		os.Exit(1)
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(*out, name), src, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "gen: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// generateDir generates the code for the XML files in dir and returns it
+// keyed by the name of the Go file.
+func generateDir(dir string, pkg string) (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, interfacePrefix+"*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no introspection XML files found in %s", dir)
+	}
+
+	files := make(map[string][]byte)
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if strings.Contains(base, ".internal.") {
+			continue
+		}
+		src, err := generateFile(path, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", base, err)
+		}
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(base, interfacePrefix), ".xml"))
+		files[strings.ReplaceAll(name, ".", "_")+".go"] = src
+	}
+	return files, nil
+}
+
+func generateFile(path string, pkg string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ifaces, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	return generate(pkg, filepath.Base(path), ifaces)
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// iface is an interface of an introspection XML file together with the
+// documentation of the comments preceding its elements.
+type iface struct {
+	Name       string
+	Doc        doc
+	Methods    []member
+	Signals    []member
+	Properties []property
+}
+
+// member is a method or a signal.
+type member struct {
+	Name string
+	Doc  doc
+	Args []arg
+}
+
+type arg struct {
+	Name      string
+	Type      string
+	Direction string
+}
+
+type property struct {
+	Name   string
+	Type   string
+	Access string
+	Doc    doc
+}
+
+// doc is a parsed comment in the format used by the BlueChi XML files:
+//
+//	Name:
+//	@arg: description of arg
+//
+//	Description of the element.
+type doc struct {
+	// Paragraphs of the description.
+	Paragraphs []string
+	// Args maps argument names to the lines of their description.
+	Args map[string][]string
+}
+
+// parse reads the interfaces of an introspection XML document.
+func parse(r io.Reader) ([]iface, error) {
+	dec := xml.NewDecoder(r)
+
+	var ifaces []iface
+	var comment string
+	var cur *iface
+	var curMember *member
+	var curKind string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.Comment:
+			comment = string(t)
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "interface":
+				ifaces = append(ifaces, iface{Name: attr(t, "name"), Doc: parseDoc(comment)})
+				cur = &ifaces[len(ifaces)-1]
+			case "method", "signal":
+				if cur == nil {
+					return nil, fmt.Errorf("%s %s outside of an interface", t.Name.Local, attr(t, "name"))
+				}
+				curMember = &member{Name: attr(t, "name"), Doc: parseDoc(comment)}
+				curKind = t.Name.Local
+			case "arg":
+				if curMember == nil {
+					return nil, fmt.Errorf("argument %s outside of a method or signal", attr(t, "name"))
+				}
+				a := arg{Name: attr(t, "name"), Type: attr(t, "type"), Direction: attr(t, "direction")}
+				if a.Direction == "" {
+					// method arguments are inputs by default, signal
+					// arguments are always outputs
+					a.Direction = "in"
+					if curKind == "signal" {
+						a.Direction = "out"
+					}
+				}
+				curMember.Args = append(curMember.Args, a)
+			case "property":
+				if cur == nil {
+					return nil, fmt.Errorf("property %s outside of an interface", attr(t, "name"))
+				}
+				cur.Properties = append(cur.Properties, property{
+					Name:   attr(t, "name"),
+					Type:   attr(t, "type"),
+					Access: attr(t, "access"),
+					Doc:    parseDoc(comment),
+				})
+			}
+			comment = ""
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "method":
+				cur.Methods = append(cur.Methods, *curMember)
+				curMember = nil
+			case "signal":
+				cur.Signals = append(cur.Signals, *curMember)
+				curMember = nil
+			case "interface":
+				cur = nil
+			}
+		}
+	}
+	return ifaces, nil
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func parseDoc(comment string) doc {
+	d := doc{Args: make(map[string][]string)}
+	var curArg string
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			d.Paragraphs = append(d.Paragraphs, strings.Join(para, " "))
+			para = nil
+		}
+	}
+
+	header := true
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			curArg = ""
+			flush()
+		case strings.HasPrefix(line, "@"):
+			name, desc, _ := strings.Cut(line[1:], ":")
+			curArg = strings.TrimSpace(name)
+			d.Args[curArg] = nil
+			if desc = strings.TrimSpace(desc); desc != "" {
+				d.Args[curArg] = []string{desc}
+			}
+		case curArg != "":
+			d.Args[curArg] = append(d.Args[curArg], line)
+		case header && strings.HasSuffix(line, ":") && !strings.Contains(line, " "):
+			// the name of the element the comment belongs to
+		default:
+			para = append(para, line)
+		}
+		if line != "" {
+			header = false
+		}
+	}
+	flush()
+	return d
+}
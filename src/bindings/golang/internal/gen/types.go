@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var basicTypes = map[byte]string{
+	'y': "uint8",
+	'b': "bool",
+	'n': "int16",
+	'q': "uint16",
+	'i': "int32",
+	'u': "uint32",
+	'x': "int64",
+	't': "uint64",
+	'd': "float64",
+	's': "string",
+	'o': "dbus.ObjectPath",
+	'g': "dbus.Signature",
+	'h': "dbus.UnixFD",
+	'v': "dbus.Variant",
+}
+
+// structType is a Go struct generated for a D-Bus struct.
+type structType struct {
+	Name   string
+	Sig    string
+	Fields []string
+	Doc    []string
+}
+
+// typeMapper converts D-Bus signatures into Go types. Structs become named
+// types, whose definitions are collected in structs.
+type typeMapper struct {
+	structs []structType
+}
+
+// goType returns the Go type of the single complete type sig. Struct types
+// are named after name.
+func (m *typeMapper) goType(sig string, name string, doc []string) (string, error) {
+	typ, rest, err := m.parse(sig, name, doc)
+	if err != nil {
+		return "", err
+	}
+	if rest != "" {
+		return "", fmt.Errorf("signature %q is not a single complete type", sig)
+	}
+	return typ, nil
+}
+
+func (m *typeMapper) parse(sig string, name string, doc []string) (string, string, error) {
+	if sig == "" {
+		return "", "", fmt.Errorf("missing type")
+	}
+	if typ, ok := basicTypes[sig[0]]; ok {
+		return typ, sig[1:], nil
+	}
+
+	switch sig[0] {
+	case 'a':
+		if strings.HasPrefix(sig, "a{") {
+			key, rest, err := m.parse(sig[2:], name, doc)
+			if err != nil {
+				return "", "", err
+			}
+			value, rest, err := m.parse(rest, name, doc)
+			if err != nil {
+				return "", "", err
+			}
+			if !strings.HasPrefix(rest, "}") {
+				return "", "", fmt.Errorf("unterminated dict entry in %q", sig)
+			}
+			return "map[" + key + "]" + value, rest[1:], nil
+		}
+		elem, rest, err := m.parse(sig[1:], singular(name), doc)
+		if err != nil {
+			return "", "", err
+		}
+		return "[]" + elem, rest, nil
+	case '(':
+		var fields []string
+		rest := sig[1:]
+		for !strings.HasPrefix(rest, ")") {
+			var field string
+			var err error
+			field, rest, err = m.parse(rest, fmt.Sprintf("%sField%d", name, len(fields)+1), nil)
+			if err != nil {
+				return "", "", err
+			}
+			fields = append(fields, field)
+		}
+		m.structs = append(m.structs, structType{
+			Name:   name,
+			Sig:    sig[:len(sig)-len(rest)+1],
+			Fields: fields,
+			Doc:    doc,
+		})
+		return name, rest[1:], nil
+	}
+	return "", "", fmt.Errorf("unsupported type %q", sig)
+}
+
+// initialisms are name parts written in upper case in Go.
+var initialisms = map[string]bool{"id": true, "ip": true, "pid": true, "uid": true, "url": true}
+
+// exported converts a D-Bus name, e.g. active_state or JobType, into an
+// exported Go identifier.
+func exported(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		if initialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// reserved are identifiers arguments must not be named after, as they are
+// Go keywords or used by the generated code.
+var reserved = map[string]string{
+	"interface": "iface",
+	"type":      "typ",
+	"func":      "fn",
+	"default":   "def",
+	"range":     "rng",
+	"map":       "m",
+	"select":    "sel",
+	"ctx":       "ctxArg",
+	"err":       "errArg",
+	"p":         "pArg",
+	"v":         "vArg",
+}
+
+// unexported converts a D-Bus argument name into a Go parameter name.
+func unexported(name string) string {
+	e := exported(name)
+	if e == "" {
+		return ""
+	}
+	var p string
+	if initialisms[strings.ToLower(e)] {
+		p = strings.ToLower(e)
+	} else {
+		r := []rune(e)
+		r[0] = unicode.ToLower(r[0])
+		p = string(r)
+	}
+	if alt, ok := reserved[p]; ok {
+		return alt
+	}
+	return p
+}
+
+func singular(name string) string {
+	if strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") {
+		return name[:len(name)-1]
+	}
+	return name
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package testbus
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ServePeer listens as a D-Bus peer server on a local TCP port and returns
+// its address. serve is called with the connection of each client, to
+// export the objects the client calls. Clients authenticate anonymously.
+func ServePeer(t testing.TB, serve func(conn *dbus.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var conns []io.Closer
+	t.Cleanup(func() {
+		l.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range conns {
+			c.Close()
+		}
+	})
+	go func() {
+		for {
+			client, err := l.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, client)
+			mu.Unlock()
+			go func() {
+				conn, err := acceptPeer(client)
+				if err != nil {
+					client.Close()
+					return
+				}
+				mu.Lock()
+				conns = append(conns, conn)
+				mu.Unlock()
+				serve(conn)
+			}()
+		}
+	}()
+	return fmt.Sprintf("tcp:host=127.0.0.1,port=%d", l.Addr().(*net.TCPAddr).Port)
+}
+
+// acceptPeer authenticates client and returns a connection exchanging
+// messages with it. godbus only runs connections as clients, so the
+// returned connection authenticates on a pipe relayed to client.
+func acceptPeer(client net.Conn) (*dbus.Conn, error) {
+	in, err := acceptAuth(client)
+	if err != nil {
+		return nil, err
+	}
+
+	local, remote := net.Pipe()
+	conn, err := dbus.NewConn(local)
+	if err != nil {
+		return nil, err
+	}
+	relayed := make(chan *bufio.Reader, 1)
+	go func() {
+		in, err := acceptAuth(remote)
+		if err != nil {
+			remote.Close()
+		}
+		relayed <- in
+	}()
+	if err := conn.Auth([]dbus.Auth{dbus.AuthAnonymous()}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go func() {
+		_, _ = io.Copy(remote, in)
+		remote.Close()
+	}()
+	go func() {
+		_, _ = io.Copy(client, <-relayed)
+		client.Close()
+	}()
+	return conn, nil
+}
+
+// acceptAuth runs the server side of the authentication with the ANONYMOUS
+// mechanism on rw and returns the reader of the messages following it.
+func acceptAuth(rw io.ReadWriter) (*bufio.Reader, error) {
+	in := bufio.NewReader(rw)
+	if b, err := in.ReadByte(); err != nil || b != 0 {
+		return nil, errors.New("missing null byte")
+	}
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		reply := "ERROR"
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 1 && fields[0] == "BEGIN":
+			return in, nil
+		case len(fields) > 1 && fields[0] == "AUTH" && fields[1] == "ANONYMOUS":
+			reply = "OK 0123456789abcdef0123456789abcdef"
+		case len(fields) > 0 && fields[0] == "AUTH":
+			reply = "REJECTED ANONYMOUS"
+		}
+		if _, err := io.WriteString(rw, reply+"\r\n"); err != nil {
+			return nil, err
+		}
+	}
+}
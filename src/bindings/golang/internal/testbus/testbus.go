@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package testbus runs a private D-Bus daemon for the tests of the
+// bindings, on which fakes of the BlueChi objects can be exported.
+package testbus
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Start starts a private bus daemon, which is stopped when the test ends,
+// and returns its address. The test is skipped if dbus-daemon is not
+// installed.
+func Start(t testing.TB) string {
+	t.Helper()
+
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not available")
+	}
+
+	address := "unix:path=" + filepath.Join(t.TempDir(), "bus")
+	cmd := exec.Command(daemon, "--session", "--nofork", "--address="+address)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	// wait for the daemon to listen on its socket
+	for attempt := 0; attempt < 100; attempt++ {
+		conn, err := dbus.Connect(address)
+		if err == nil {
+			conn.Close()
+			return address
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("dbus-daemon did not start listening on %s", address)
+	return ""
+}
+
+// Connect connects to the bus at address for serving fake objects. The
+// connection is closed when the test ends.
+func Connect(t testing.TB, address string) *dbus.Conn {
+	t.Helper()
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", address, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// RequestName acquires name on conn, e.g. org.eclipse.bluechi after the
+// fakes of the controller objects have been exported.
+func RequestName(t testing.TB, conn *dbus.Conn, name string) {
+	t.Helper()
+
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		t.Fatalf("failed to acquire %s: %v", name, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("failed to acquire %s: already owned", name)
+	}
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package job_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+)
+
+// FuzzInfo feeds the decoding of the properties of a job with arbitrary
+// replies, which must fail with an error rather than panic.
+func FuzzInfo(f *testing.F) {
+	testbus.AddReply(f, map[string]dbus.Variant{
+		"Id":      dbus.MakeVariant(uint32(7)),
+		"Node":    dbus.MakeVariant("node_a"),
+		"Unit":    dbus.MakeVariant("a.service"),
+		"JobType": dbus.MakeVariant("start"),
+		"State":   dbus.MakeVariant("running"),
+	})
+	testbus.AddReply(f, map[string]dbus.Variant{"Id": dbus.MakeVariant("7"), "State": dbus.MakeVariant(uint32(1))})
+	testbus.AddReply(f, map[string]string{"Id": "7"})
+	testbus.AddReply(f, dbus.MakeVariant("running"))
+
+	f.Fuzz(func(t *testing.T, sig string, data []byte) {
+		body, ok := testbus.DecodeReply(sig, data)
+		if !ok {
+			return
+		}
+		j := job.New(&testbus.Reply{Body: body}, "/org/eclipse/bluechi/job/7")
+		_, _ = j.Info(context.Background())
+	})
+}
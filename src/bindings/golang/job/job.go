@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package job provides Go bindings for the org.eclipse.bluechi.Job
+// interface and for tracking the lifecycle of jobs via the JobNew and
+// JobRemoved signals of the controller.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// Results of a finished job as reported by the JobRemoved signal.
+const (
+	ResultDone       = "done"
+	ResultFailed     = "failed"
+	ResultCancelled  = "cancelled"
+	ResultTimeout    = "timeout"
+	ResultDependency = "dependency"
+	ResultSkipped    = "skipped"
+)
+
+// Info describes a job queued on the controller.
+type Info struct {
+	// ID is the id of the job.
+	ID uint32
+	// ObjectPath is the path of the job object on the controller.
+	ObjectPath dbus.ObjectPath
+	// Node is the name of the node the job is on.
+	Node string
+	// Unit is the name of the unit the job works on.
+	Unit string
+	// JobType is the type of the job, e.g. start or stop.
+	JobType string
+	// State is the current state of the job, either waiting or running.
+	State string
+}
+
+// MarshalJSON encodes the job info with lower camel case keys.
+func (i Info) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID         uint32          `json:"id"`
+		ObjectPath dbus.ObjectPath `json:"objectPath"`
+		Node       string          `json:"node"`
+		Unit       string          `json:"unit"`
+		JobType    string          `json:"jobType"`
+		State      string          `json:"state"`
+	}{i.ID, i.ObjectPath, i.Node, i.Unit, i.JobType, i.State})
+}
+
+// Job is a proxy for a job object exported by the BlueChi controller.
+type Job struct {
+	conn common.Connection
+	path dbus.ObjectPath
+	obj  dbus.BusObject
+}
+
+// New returns a proxy for the job object at path on the controller.
+func New(conn common.Connection, path dbus.ObjectPath) *Job {
+	return &Job{
+		conn: conn,
+		path: path,
+		obj:  bus.Object(conn, common.BC_DBUS_NAME, path),
+	}
+}
+
+// ObjectPath returns the path of the job object on the controller.
+func (j *Job) ObjectPath() dbus.ObjectPath {
+	return j.path
+}
+
+// Info returns the current properties of the job.
+func (j *Job) Info(ctx context.Context) (Info, error) {
+	props, err := bus.Call[map[string]dbus.Variant](ctx, j.obj, common.METHOD_PROPERTIES_GETALL, common.JOB_INTERFACE)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to get properties of job %s: %w", j.path, err)
+	}
+
+	info := Info{ObjectPath: j.path}
+	if v, ok := props["Id"]; ok {
+		_ = v.Store(&info.ID)
+	}
+	if v, ok := props["Node"]; ok {
+		_ = v.Store(&info.Node)
+	}
+	if v, ok := props["Unit"]; ok {
+		_ = v.Store(&info.Unit)
+	}
+	if v, ok := props["JobType"]; ok {
+		_ = v.Store(&info.JobType)
+	}
+	if v, ok := props["State"]; ok {
+		_ = v.Store(&info.State)
+	}
+	return info, nil
+}
+
+// jobGoneGracePeriod is how long Wait keeps listening for the JobRemoved
+// signal of a job whose object disappeared while the tracker was set up.
+const jobGoneGracePeriod = 500 * time.Millisecond
+
+// Wait blocks until the job at path has finished and returns its result.
+// Prefer a Tracker created before issuing the job-producing call to avoid
+// missing the JobRemoved signal of short-lived jobs.
+func Wait(ctx context.Context, conn common.Connection, path dbus.ObjectPath) (string, error) {
+	if path == bus.DryRunJob {
+		return ResultDone, nil
+	}
+	t, err := NewTracker(conn)
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+
+	if _, err := New(conn, path).Info(ctx); err != nil {
+		// the job might have finished right before the tracker was set up
+		graceCtx, cancel := context.WithTimeout(ctx, jobGoneGracePeriod)
+		defer cancel()
+		if result, waitErr := t.Wait(graceCtx, path); waitErr == nil {
+			return result, nil
+		}
+		return "", err
+	}
+	return t.Wait(ctx, path)
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package job
+
+import (
+	"sync"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+var (
+	sharedMu sync.Mutex
+	shared   = make(map[common.Connection]*Tracker)
+)
+
+// SharedTracker returns the tracker shared by all users of conn, e.g. the
+// asynchronous job calls of nodes, so that waiting for many jobs takes a
+// single subscription and goroutine. It is created on first use and closed
+// with the connection, its users must not close it.
+func SharedTracker(conn common.Connection) (*Tracker, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if t, ok := shared[conn]; ok {
+		return t, nil
+	}
+	t, err := NewTracker(conn)
+	if err != nil {
+		return nil, err
+	}
+	shared[conn] = t
+	go func() {
+		<-conn.Context().Done()
+		sharedMu.Lock()
+		delete(shared, conn)
+		sharedMu.Unlock()
+		t.Close()
+	}()
+	return t, nil
+}
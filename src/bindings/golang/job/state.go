@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// States of a job as reported by State.
+const (
+	// StateWaiting is the state of a job queued on the controller.
+	StateWaiting = "waiting"
+	// StateRunning is the state of a job processed by systemd on the node.
+	StateRunning = "running"
+)
+
+// stateBufferSize is the capacity of the channel returned by WatchState.
+const stateBufferSize = 4
+
+// Cancel cancels the job. The systemd job is cancelled if it was started
+// already, otherwise the job is dequeued on the controller. The job
+// finishes with ResultCancelled.
+func (j *Job) Cancel(ctx context.Context) error {
+	if err := bus.Exec(ctx, j.obj, common.METHOD_JOB_CANCEL); err != nil {
+		return fmt.Errorf("failed to cancel job %s: %w", j.path, err)
+	}
+	return nil
+}
+
+// State returns the current state of the job, StateWaiting or
+// StateRunning. The Job interface has no finer grained progress, the
+// transition from waiting to running is all there is to show.
+func (j *Job) State(ctx context.Context) (string, error) {
+	v, err := bus.GetProperty(ctx, j.obj, common.JOB_INTERFACE, "State")
+	if err != nil {
+		return "", fmt.Errorf("failed to get state of job %s: %w", j.path, err)
+	}
+	state, err := variant.String(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode state of job %s: %w", j.path, err)
+	}
+	return state, nil
+}
+
+// WatchState returns a channel on which the current state of the job is
+// delivered first, followed by each change of it, e.g. to show the
+// progress of a long-running start job. The channel is closed once the job
+// has finished, when ctx is done or the connection is closed. Use a Tracker
+// to learn the result of the job.
+func (j *Job) WatchState(ctx context.Context) (<-chan string, error) {
+	match := controllerMatch()
+	match.Rules = append(match.Rules, bus.PropertiesChangedRule(common.JOB_OBJECT_PATH_PREFIX, common.JOB_INTERFACE))
+	match.Filter = func(sig *dbus.Signal) bool {
+		return sig.Path == common.BC_OBJECT_PATH || (sig.Path == j.path && sig.Name == common.SIGNAL_PROPERTIES_CHANGED)
+	}
+	sub, err := bus.Subscribe(j.conn, match, stateBufferSize, bus.Block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to state of job %s: %w", j.path, err)
+	}
+
+	current, err := j.State(ctx)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	states := make(chan string, stateBufferSize)
+	last := current
+	states <- last
+	bus.Go(j.conn, func() {
+		defer close(states)
+		defer sub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-j.conn.Context().Done():
+				return
+			case sig, ok := <-sub.Signals():
+				if !ok {
+					return
+				}
+				if sig.Path == common.BC_OBJECT_PATH {
+					if e, ok := decodeEvent(sig); ok && e.JobPath() == j.path {
+						if _, removed := e.(JobRemoved); removed {
+							return
+						}
+					}
+					continue
+				}
+				state, ok := stateFromSignal(sig)
+				if !ok || state == last {
+					continue
+				}
+				last = state
+				select {
+				case states <- state:
+				case <-ctx.Done():
+					return
+				case <-j.conn.Context().Done():
+					return
+				}
+			}
+		}
+	})
+	return states, nil
+}
+
+func stateFromSignal(sig *dbus.Signal) (string, bool) {
+	var iface string
+	var changed map[string]dbus.Variant
+	var invalidated []string
+	if dbus.Store(sig.Body, &iface, &changed, &invalidated) != nil || iface != common.JOB_INTERFACE {
+		return "", false
+	}
+	v, ok := changed["State"]
+	if !ok {
+		return "", false
+	}
+	state, err := variant.String(v)
+	return state, err == nil
+}
@@ -0,0 +1,341 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package job
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+const (
+	// eventBufferSize is the capacity of the channel returned by Events.
+	eventBufferSize = 64
+	// maxPendingResults bounds the number of results of jobs that finished
+	// before anybody waited for them.
+	maxPendingResults = 256
+)
+
+// Event is a job lifecycle signal of the controller, either JobNew or
+// JobRemoved.
+type Event interface {
+	// JobPath returns the object path of the job the event refers to.
+	JobPath() dbus.ObjectPath
+}
+
+// JobNew is emitted each time a new job is queued.
+type JobNew struct {
+	ID   uint32
+	Path dbus.ObjectPath
+}
+
+// JobRemoved is emitted each time a job is dequeued or the underlying
+// systemd job finished.
+type JobRemoved struct {
+	ID     uint32
+	Path   dbus.ObjectPath
+	Node   string
+	Unit   string
+	Result string
+}
+
+func (e JobNew) JobPath() dbus.ObjectPath     { return e.Path }
+func (e JobRemoved) JobPath() dbus.ObjectPath { return e.Path }
+
+// Tracker listens for the job signals of the controller. It has to be
+// created before the job-producing call is issued, so that no JobRemoved
+// signal is missed.
+type Tracker struct {
+	conn common.Connection
+
+	sub       *bus.Subscription
+	events    chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu        sync.Mutex
+	waiters   map[dbus.ObjectPath][]chan string
+	notifiers map[dbus.ObjectPath][]*notifier
+	results   map[dbus.ObjectPath]string
+	order     []dbus.ObjectPath
+}
+
+// notifier is a callback registered by Notify.
+type notifier struct {
+	fn func(result string, err error)
+}
+
+// NewTracker registers for the JobNew and JobRemoved signals of the
+// controller and starts tracking jobs until Close is called.
+func NewTracker(conn common.Connection) (*Tracker, error) {
+	t := &Tracker{
+		conn:      conn,
+		events:    make(chan Event, eventBufferSize),
+		done:      make(chan struct{}),
+		waiters:   make(map[dbus.ObjectPath][]chan string),
+		notifiers: make(map[dbus.ObjectPath][]*notifier),
+		results:   make(map[dbus.ObjectPath]string),
+	}
+
+	sub, err := bus.Subscribe(conn, controllerMatch(), eventBufferSize, bus.Block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to jobs: %w", err)
+	}
+
+	t.sub = sub
+	bus.Go(conn, t.dispatch)
+	return t, nil
+}
+
+// Events returns the channel on which JobNew and JobRemoved events are
+// delivered. Events are dropped if the channel is full, waiting for jobs is
+// not affected by this. The channel is closed by Close or when the
+// connection is closed.
+func (t *Tracker) Events() <-chan Event {
+	return t.events
+}
+
+// Wait blocks until the job at path has been removed and returns its
+// result, e.g. "done", "failed" or "cancelled". The jobs replied in dry-run
+// mode finish with "done" right away.
+func (t *Tracker) Wait(ctx context.Context, path dbus.ObjectPath) (string, error) {
+	if path == bus.DryRunJob {
+		return ResultDone, nil
+	}
+	end, err := bus.Begin(t.conn)
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for job %s: %w", path, err)
+	}
+	defer end()
+
+	t.mu.Lock()
+	if result, ok := t.results[path]; ok {
+		delete(t.results, path)
+		t.mu.Unlock()
+		return result, nil
+	}
+	ch := make(chan string, 1)
+	t.waiters[path] = append(t.waiters[path], ch)
+	t.mu.Unlock()
+
+	// jobs do not survive losing the controller, also not across reconnects
+	lost := bus.Lost(t.conn)
+
+	select {
+	case result := <-ch:
+		return result, nil
+	case <-ctx.Done():
+		t.removeWaiter(path, ch)
+		return "", fmt.Errorf("failed to wait for job %s: %w", path, ctx.Err())
+	case <-t.done:
+		return "", fmt.Errorf("failed to wait for job %s: tracker closed", path)
+	case <-lost:
+		t.removeWaiter(path, ch)
+		return "", fmt.Errorf("failed to wait for job %s: connection to controller lost", path)
+	}
+}
+
+// Notify calls fn with the result of the job at path once it has been
+// removed, instead of blocking a goroutine in Wait. fn is called once, on
+// the goroutine of the tracker or right away if the job finished already,
+// and must not block. It receives an error instead if the connection to
+// the controller is lost or the tracker closed first. The returned function
+// unregisters fn, e.g. when the caller stops waiting.
+func (t *Tracker) Notify(path dbus.ObjectPath, fn func(result string, err error)) func() {
+	if path == bus.DryRunJob {
+		fn(ResultDone, nil)
+		return func() {}
+	}
+	end, err := bus.Begin(t.conn)
+	if err != nil {
+		fn("", fmt.Errorf("failed to wait for job %s: %w", path, err))
+		return func() {}
+	}
+	notify := fn
+	fn = func(result string, err error) {
+		end()
+		notify(result, err)
+	}
+
+	t.mu.Lock()
+	if result, ok := t.results[path]; ok {
+		delete(t.results, path)
+		t.mu.Unlock()
+		fn(result, nil)
+		return func() {}
+	}
+	select {
+	case <-t.done:
+		t.mu.Unlock()
+		fn("", fmt.Errorf("failed to wait for job %s: tracker closed", path))
+		return func() {}
+	case <-bus.Lost(t.conn):
+		t.mu.Unlock()
+		fn("", fmt.Errorf("failed to wait for job %s: connection to controller lost", path))
+		return func() {}
+	default:
+	}
+	n := &notifier{fn: fn}
+	t.notifiers[path] = append(t.notifiers[path], n)
+	t.mu.Unlock()
+
+	return func() {
+		end()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		notifiers := slices.DeleteFunc(t.notifiers[path], func(other *notifier) bool { return other == n })
+		if len(notifiers) == 0 {
+			delete(t.notifiers, path)
+		} else {
+			t.notifiers[path] = notifiers
+		}
+	}
+}
+
+// Close stops tracking jobs and closes the Events channel.
+func (t *Tracker) Close() {
+	t.closeOnce.Do(func() {
+		close(t.done)
+		t.sub.Close()
+		t.failNotifiers("tracker closed")
+	})
+}
+
+// failNotifiers calls all registered notifiers with an error for reason.
+func (t *Tracker) failNotifiers(reason string) {
+	t.mu.Lock()
+	notifiers := t.notifiers
+	t.notifiers = make(map[dbus.ObjectPath][]*notifier)
+	t.mu.Unlock()
+
+	for path, ns := range notifiers {
+		for _, n := range ns {
+			n.fn("", fmt.Errorf("failed to wait for job %s: %s", path, reason))
+		}
+	}
+}
+
+func (t *Tracker) removeWaiter(path dbus.ObjectPath, ch chan string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	waiters := t.waiters[path]
+	for i, w := range waiters {
+		if w == ch {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(t.waiters, path)
+	} else {
+		t.waiters[path] = waiters
+	}
+}
+
+func (t *Tracker) complete(path dbus.ObjectPath, result string) {
+	t.mu.Lock()
+	notifiers, notify := t.notifiers[path]
+	delete(t.notifiers, path)
+	// the notifiers are called without holding the lock, they may wait
+	// for further jobs
+	defer func() {
+		for _, n := range notifiers {
+			n.fn(result, nil)
+		}
+	}()
+	defer t.mu.Unlock()
+
+	waiters, ok := t.waiters[path]
+	if ok || notify {
+		delete(t.waiters, path)
+		for _, w := range waiters {
+			w <- result
+		}
+		return
+	}
+
+	// nobody is waiting (yet), keep the result for a later Wait
+	if len(t.order) >= maxPendingResults {
+		delete(t.results, t.order[0])
+		t.order = t.order[1:]
+	}
+	t.results[path] = result
+	t.order = append(t.order, path)
+}
+
+func (t *Tracker) dispatch() {
+	defer close(t.events)
+
+	// jobs do not survive losing the controller, the channel is renewed
+	// once the connection is restored and signals arrive again
+	lost := bus.Lost(t.conn)
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-t.conn.Context().Done():
+			t.failNotifiers("connection closed")
+			return
+		case <-lost:
+			t.failNotifiers("connection to controller lost")
+			lost = nil
+		case sig, ok := <-t.sub.Signals():
+			if !ok {
+				return
+			}
+			if lost == nil {
+				lost = bus.Lost(t.conn)
+			}
+			event, ok := decodeEvent(sig)
+			if !ok {
+				continue
+			}
+			if removed, ok := event.(JobRemoved); ok {
+				t.complete(removed.Path, removed.Result)
+			}
+			select {
+			case t.events <- event:
+			default:
+			}
+		}
+	}
+}
+
+// controllerMatch selects the signals of the controller object, which
+// include the job lifecycle signals.
+func controllerMatch() bus.Match {
+	return bus.Match{
+		Rules: [][]dbus.MatchOption{{
+			dbus.WithMatchObjectPath(common.BC_OBJECT_PATH),
+			dbus.WithMatchInterface(common.CONTROLLER_INTERFACE),
+		}},
+		Filter: func(sig *dbus.Signal) bool {
+			return sig.Path == common.BC_OBJECT_PATH
+		},
+	}
+}
+
+func decodeEvent(sig *dbus.Signal) (Event, bool) {
+	switch sig.Name {
+	case common.SIGNAL_JOB_NEW:
+		var e JobNew
+		if dbus.Store(sig.Body, &e.ID, &e.Path) != nil {
+			return nil, false
+		}
+		return e, true
+	case common.SIGNAL_JOB_REMOVED:
+		var e JobRemoved
+		if dbus.Store(sig.Body, &e.ID, &e.Path, &e.Node, &e.Unit, &e.Result) != nil {
+			return nil, false
+		}
+		return e, true
+	}
+	return nil, false
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package k8s maps the nodes and units managed by BlueChi to the list and
+// watch semantics of Kubernetes informers, as a base for operators written
+// in Go. NodeListerWatcher and UnitListerWatcher keep the state of all
+// objects in memory, fed by the signals of the controller, and number every
+// change with a resource version. List returns all objects together with
+// the version they reflect, and Watch delivers the changes following a
+// version, so that no change between a List and a Watch is lost.
+//
+// The package does not depend on client-go to keep the bindings light. An
+// operator adapts a lister watcher to cache.ListerWatcher by wrapping the
+// objects into its own runtime.Object types, using the EventType values,
+// which equal those of k8s.io/apimachinery/pkg/watch, and the resource
+// versions as they are.
+package k8s
+
+import (
+	"errors"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// ErrResourceVersionTooOld is returned by Watch if the changes following
+// the requested resource version are no longer kept. Callers have to List
+// again, like on a 410 Gone response of the Kubernetes API server.
+var ErrResourceVersionTooOld = errors.New("resource version too old")
+
+// ErrClosed is returned by the methods of a closed lister watcher.
+var ErrClosed = errors.New("lister watcher is closed")
+
+// EventType is the type of a change delivered by Watch.
+type EventType string
+
+// Types of changes delivered by Watch.
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+)
+
+// Event is a change of an object. The object of a Deleted event is its last
+// state, with the resource version of the deletion.
+type Event struct {
+	Type   EventType
+	Object Object
+}
+
+// Object is a BlueChi resource, either a Node or a Unit.
+type Object interface {
+	// Key returns the key identifying the object in a store, the name of a
+	// node and node/unit for units, like namespace/name of Kubernetes
+	// objects.
+	Key() string
+	// ResourceVersion returns the version of the last change of the object.
+	ResourceVersion() string
+
+	withVersion(version string) Object
+}
+
+// List is the result of List, all objects sorted by key.
+type List struct {
+	// ResourceVersion is the version the objects reflect, to be passed to
+	// Watch.
+	ResourceVersion string
+	Items           []Object
+}
+
+// Watcher delivers the changes of a Watch, see watch.Interface.
+type Watcher interface {
+	// ResultChan returns the channel delivering the changes, which is closed
+	// when the watcher is stopped.
+	ResultChan() <-chan Event
+	// Stop stops the watcher.
+	Stop()
+}
+
+// Node is a node managed by BlueChi.
+type Node struct {
+	// Name is the name of the node.
+	Name string
+	// Status is the connection state of the node, either online or offline.
+	Status node.NodeStatus
+	// PeerIP is the IP address of the connected agent, empty if the node is
+	// offline or the controller does not report it.
+	PeerIP string
+
+	version string
+}
+
+// Key returns the name of the node.
+func (n Node) Key() string { return n.Name }
+
+// ResourceVersion returns the version of the last change of the node.
+func (n Node) ResourceVersion() string { return n.version }
+
+func (n Node) withVersion(version string) Object {
+	n.version = version
+	return n
+}
+
+func newNode(info manager.NodeInfo) Node {
+	return Node{Name: info.Name, Status: info.Status, PeerIP: info.PeerIP}
+}
+
+// Unit is a systemd unit loaded on a node.
+type Unit struct {
+	// Node is the name of the node the unit is loaded on.
+	Node string
+	// Name is the name of the unit.
+	Name        string
+	Description string
+	LoadState   node.LoadState
+	ActiveState node.ActiveState
+	SubState    node.SubState
+
+	version string
+}
+
+// Key returns node/unit.
+func (u Unit) Key() string { return u.Node + "/" + u.Name }
+
+// ResourceVersion returns the version of the last change of the unit.
+func (u Unit) ResourceVersion() string { return u.version }
+
+func (u Unit) withVersion(version string) Object {
+	u.version = version
+	return u
+}
+
+func newUnit(nodeName string, info node.UnitInfo) Unit {
+	return Unit{
+		Node:        nodeName,
+		Name:        info.Name,
+		Description: info.Description,
+		LoadState:   info.LoadState,
+		ActiveState: info.ActiveState,
+		SubState:    info.SubState,
+	}
+}
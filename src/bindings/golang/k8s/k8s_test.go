@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// next returns the next change delivered by w.
+func next(t *testing.T, w Watcher) Event {
+	t.Helper()
+	select {
+	case e, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatal("watcher was stopped")
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+	}
+	return Event{}
+}
+
+func keys(list List) []string {
+	var keys []string
+	for _, obj := range list.Items {
+		keys = append(keys, obj.Key())
+	}
+	return keys
+}
+
+func TestUnitListerWatcher(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("a.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	f.AddNode("n2").AddUnit("b.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+
+	lw, err := NewUnitListerWatcher(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lw.Close()
+
+	list, err := lw.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys(list); len(got) != 2 || got[0] != "n1/a.service" || got[1] != "n2/b.service" {
+		t.Fatalf("unexpected units %v", got)
+	}
+	w, err := lw.Watch(ctx, list.ResourceVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	f.Node("n2").SetUnitState("b.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	e := next(t, w)
+	if u := e.Object.(Unit); e.Type != Modified || u.ActiveState != managertest.ActiveStateActive || u.LoadState != "loaded" {
+		t.Errorf("unexpected change %+v", e)
+	}
+	if v, _ := strconv.Atoi(e.Object.ResourceVersion()); strconv.Itoa(v-1) != list.ResourceVersion {
+		t.Errorf("resource version %s does not follow %s", e.Object.ResourceVersion(), list.ResourceVersion)
+	}
+
+	f.Node("n1").RemoveUnit("a.service")
+	if e := next(t, w); e.Type != Deleted || e.Object.Key() != "n1/a.service" {
+		t.Errorf("unexpected change %+v", e)
+	}
+
+	// units of offline nodes are deleted and added again once it is back
+	f.SetNodeStatus("n2", managertest.NodeOffline)
+	if e := next(t, w); e.Type != Deleted || e.Object.Key() != "n2/b.service" {
+		t.Errorf("unexpected change %+v", e)
+	}
+	f.SetNodeStatus("n2", managertest.NodeOnline)
+	if e := next(t, w); e.Type != Added || e.Object.Key() != "n2/b.service" {
+		t.Errorf("unexpected change %+v", e)
+	}
+
+	// watching from the first list replays all changes
+	replay, err := lw.Watch(ctx, list.ResourceVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []EventType{Modified, Deleted, Deleted, Added} {
+		if e := next(t, replay); e.Type != want {
+			t.Errorf("replayed %s, want %s", e.Type, want)
+		}
+	}
+
+	if err := lw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-w.ResultChan(); ok {
+		t.Error("expected the watcher to be stopped by Close")
+	}
+	if _, err := lw.List(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected List to fail after Close, got %v", err)
+	}
+}
+
+func TestNodeListerWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := managertest.New()
+	f.AddNode("n1")
+	f.AddNode("n2")
+
+	lw, err := NewNodeListerWatcher(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lw.Close()
+
+	list, err := lw.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys(list); len(got) != 2 || list.Items[0].(Node).Status != managertest.NodeOnline {
+		t.Fatalf("unexpected nodes %+v", list.Items)
+	}
+	watchCtx, stop := context.WithCancel(ctx)
+	w, err := lw.Watch(watchCtx, list.ResourceVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.SetNodeStatus("n1", managertest.NodeOffline)
+	e := next(t, w)
+	if n := e.Object.(Node); e.Type != Modified || n.Name != "n1" || n.Status != managertest.NodeOffline {
+		t.Errorf("unexpected change %+v", e)
+	}
+
+	stop()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("expected the watcher to be stopped with its context")
+		}
+	}
+}
+
+func TestWatchResourceVersion(t *testing.T) {
+	ctx := context.Background()
+	s := newStore()
+	for i := 0; i < historySize+10; i++ {
+		s.put(Node{Name: "n1", Status: node.NodeStatus(strconv.Itoa(i))})
+	}
+
+	if _, err := s.watch(ctx, "5"); !errors.Is(err, ErrResourceVersionTooOld) {
+		t.Errorf("expected watching from an old version to fail, got %v", err)
+	}
+	for _, rv := range []string{"latest", "100000"} {
+		if _, err := s.watch(ctx, rv); err == nil {
+			t.Errorf("expected watching from %s to fail", rv)
+		}
+	}
+
+	// the oldest kept change is replayed, together with all following ones
+	w, err := s.watch(ctx, "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := next(t, w); e.Object.ResourceVersion() != "11" {
+		t.Errorf("first replayed change has version %s", e.Object.ResourceVersion())
+	}
+
+	// equal objects are no change
+	list, _ := s.list()
+	s.put(list.Items[0])
+	if after, _ := s.list(); after.ResourceVersion != list.ResourceVersion {
+		t.Errorf("putting an unchanged object changed the version to %s", after.ResourceVersion)
+	}
+
+	// watchers falling behind are stopped
+	for i := 0; i < historySize; i++ {
+		s.put(Node{Name: "n2", Status: node.NodeStatus(strconv.Itoa(i))})
+	}
+	count := 0
+	for range w.ResultChan() {
+		count++
+	}
+	if count >= 2*historySize {
+		t.Errorf("expected the watcher to be stopped, received %d changes", count)
+	}
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package k8s
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+// NodeListerWatcher lists and watches the nodes managed by BlueChi. Nodes
+// are modified when they go online or offline. Its methods are safe for
+// concurrent use by multiple goroutines.
+type NodeListerWatcher struct {
+	api    manager.ManagerAPI
+	nodes  <-chan manager.NodeConnectionStateChanged
+	states <-chan manager.ConnState
+	store  *store
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewNodeListerWatcher lists the nodes of m. The nodes are kept up to date
+// until ctx is done or Close is called, and listed again after a reconnect
+// of m.
+func NewNodeListerWatcher(ctx context.Context, m manager.ManagerAPI) (*NodeListerWatcher, error) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	nodes, err := m.SubscribeNodeConnectionStateChanged(watchCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	infos, err := m.ListNodes(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	lw := &NodeListerWatcher{
+		api:    m,
+		nodes:  nodes,
+		states: m.ConnectionEvents(),
+		store:  newStore(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	lw.store.replace(nodeObjects(infos), func(Object) bool { return true })
+	go lw.run(watchCtx)
+	return lw, nil
+}
+
+// List returns all nodes.
+func (lw *NodeListerWatcher) List() (List, error) {
+	return lw.store.list()
+}
+
+// Watch returns a watcher delivering the changes following
+// resourceVersion, or following the current version if it is empty or 0.
+// The watcher is stopped when ctx is done.
+func (lw *NodeListerWatcher) Watch(ctx context.Context, resourceVersion string) (Watcher, error) {
+	return lw.store.watch(ctx, resourceVersion)
+}
+
+// Err returns the error of the last attempt to list nodes again, nil if it
+// succeeded.
+func (lw *NodeListerWatcher) Err() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.err
+}
+
+// Close stops updating the nodes and stops all watchers.
+func (lw *NodeListerWatcher) Close() error {
+	lw.cancel()
+	<-lw.done
+	return nil
+}
+
+func (lw *NodeListerWatcher) run(ctx context.Context) {
+	defer close(lw.done)
+	defer lw.store.close(ErrClosed)
+
+	nodes, states := lw.nodes, lw.states
+	connected := true
+	for nodes != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-nodes:
+			if !ok {
+				nodes = nil
+				continue
+			}
+			// list again to learn the peer IP of the node
+			if !lw.relist(ctx) {
+				if obj, ok := lw.store.get(e.Node); ok {
+					n := obj.(Node)
+					n.Status, n.PeerIP = e.NewState, ""
+					lw.store.put(n)
+				}
+			}
+		case state, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			if state == manager.Connected && !connected {
+				lw.relist(ctx)
+			}
+			connected = state == manager.Connected
+		}
+	}
+}
+
+// relist lists all nodes and replaces the stored ones, it reports whether
+// listing succeeded.
+func (lw *NodeListerWatcher) relist(ctx context.Context) bool {
+	infos, err := lw.api.ListNodes(ctx)
+
+	lw.mu.Lock()
+	lw.err = err
+	lw.mu.Unlock()
+	if err != nil {
+		return false
+	}
+	lw.store.replace(nodeObjects(infos), func(Object) bool { return true })
+	return true
+}
+
+func nodeObjects(infos []manager.NodeInfo) []Object {
+	objs := make([]Object, 0, len(infos))
+	for _, info := range infos {
+		objs = append(objs, newNode(info))
+	}
+	return objs
+}
@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// historySize is the number of changes kept for Watch calls following an
+// older resource version, and the capacity of the channel of a watcher.
+// Watchers falling behind by more are stopped, like by the API server, and
+// have to watch again from the last version they received.
+const historySize = 1024
+
+// store holds the objects of a lister watcher and numbers their changes.
+type store struct {
+	mu       sync.Mutex
+	version  uint64
+	objects  map[string]Object
+	history  []Event
+	watchers map[*watcher]struct{}
+	err      error
+}
+
+func newStore() *store {
+	return &store{
+		objects:  make(map[string]Object),
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+func (s *store) list() (List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return List{}, s.err
+	}
+	items := make([]Object, 0, len(s.objects))
+	for _, obj := range s.objects {
+		items = append(items, obj)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key() < items[j].Key() })
+	return List{ResourceVersion: strconv.FormatUint(s.version, 10), Items: items}, nil
+}
+
+func (s *store) get(key string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	return obj, ok
+}
+
+func (s *store) watch(ctx context.Context, resourceVersion string) (Watcher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	// the versions of the history are contiguous and end at s.version
+	history := s.history
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	oldest := s.version - uint64(len(history))
+	from := s.version
+	if resourceVersion != "" && resourceVersion != "0" {
+		v, err := strconv.ParseUint(resourceVersion, 10, 64)
+		if err != nil || v > s.version {
+			return nil, fmt.Errorf("invalid resource version %q", resourceVersion)
+		}
+		if v < oldest {
+			return nil, fmt.Errorf("failed to watch from resource version %s: %w", resourceVersion, ErrResourceVersionTooOld)
+		}
+		from = v
+	}
+
+	w := &watcher{s: s, ch: make(chan Event, historySize), done: make(chan struct{})}
+	for _, e := range history[from-oldest:] {
+		w.sendLocked(e)
+	}
+	s.watchers[w] = struct{}{}
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.Stop()
+		case <-w.done:
+		}
+	}()
+	return w, nil
+}
+
+// put stores obj, delivering a change unless it equals the stored one.
+func (s *store) put(obj Object) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.putLocked(obj)
+}
+
+func (s *store) putLocked(obj Object) {
+	typ := Added
+	if old, ok := s.objects[obj.Key()]; ok {
+		if old.withVersion("") == obj.withVersion("") {
+			return
+		}
+		typ = Modified
+	}
+	s.emitLocked(typ, obj)
+}
+
+func (s *store) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteLocked(key)
+}
+
+func (s *store) deleteLocked(key string) {
+	if old, ok := s.objects[key]; ok {
+		s.emitLocked(Deleted, old)
+	}
+}
+
+// replace stores objs and deletes all other objects for which owned
+// returns true.
+func (s *store) replace(objs []Object, owned func(obj Object) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keep := make(map[string]bool, len(objs))
+	for _, obj := range objs {
+		keep[obj.Key()] = true
+		s.putLocked(obj)
+	}
+	for key, obj := range s.objects {
+		if !keep[key] && owned(obj) {
+			s.deleteLocked(key)
+		}
+	}
+}
+
+func (s *store) emitLocked(typ EventType, obj Object) {
+	s.version++
+	obj = obj.withVersion(strconv.FormatUint(s.version, 10))
+	if typ == Deleted {
+		delete(s.objects, obj.Key())
+	} else {
+		s.objects[obj.Key()] = obj
+	}
+
+	e := Event{Type: typ, Object: obj}
+	// trim the history only once it doubled its size to avoid copying it on
+	// every change
+	if len(s.history) == 2*historySize {
+		s.history = append([]Event(nil), s.history[historySize:]...)
+	}
+	s.history = append(s.history, e)
+	for w := range s.watchers {
+		w.sendLocked(e)
+	}
+}
+
+// close stops all watchers and makes further calls fail with err.
+func (s *store) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == nil {
+		s.err = err
+	}
+	for w := range s.watchers {
+		w.stopLocked()
+	}
+}
+
+// watcher is a Watcher of a store, guarded by its lock.
+type watcher struct {
+	s       *store
+	ch      chan Event
+	done    chan struct{}
+	stopped bool
+}
+
+func (w *watcher) ResultChan() <-chan Event {
+	return w.ch
+}
+
+func (w *watcher) Stop() {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	w.stopLocked()
+}
+
+func (w *watcher) stopLocked() {
+	if w.stopped {
+		return
+	}
+	w.stopped = true
+	delete(w.s.watchers, w)
+	close(w.ch)
+	close(w.done)
+}
+
+func (w *watcher) sendLocked(e Event) {
+	if w.stopped {
+		return
+	}
+	select {
+	case w.ch <- e:
+	default:
+		w.stopLocked()
+	}
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// UnitListerWatcher lists and watches the units of all online nodes. The
+// units of a node are deleted when it goes offline and added again when it
+// comes back online. Its methods are safe for concurrent use by multiple
+// goroutines.
+type UnitListerWatcher struct {
+	api    manager.ManagerAPI
+	mon    manager.MonitorAPI
+	nodes  <-chan manager.NodeConnectionStateChanged
+	states <-chan manager.ConnState
+	store  *store
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewUnitListerWatcher creates a monitor for all units on m and lists the
+// units of all nodes. The units are kept up to date until ctx is done or
+// Close is called, and listed again after a reconnect of m.
+func NewUnitListerWatcher(ctx context.Context, m manager.ManagerAPI) (*UnitListerWatcher, error) {
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// subscribe before listing so that no change is missed
+	if _, err := mon.Subscribe(ctx, common.SYMBOL_WILDCARD, common.SYMBOL_WILDCARD); err != nil {
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	nodes, err := m.SubscribeNodeConnectionStateChanged(watchCtx)
+	if err != nil {
+		cancel()
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+	units, err := m.ListUnits(ctx)
+	if err != nil {
+		cancel()
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+
+	lw := &UnitListerWatcher{
+		api:    m,
+		mon:    mon,
+		nodes:  nodes,
+		states: m.ConnectionEvents(),
+		store:  newStore(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	lw.store.replace(unitObjects(units), func(Object) bool { return true })
+	go lw.run(watchCtx)
+	return lw, nil
+}
+
+// List returns all units.
+func (lw *UnitListerWatcher) List() (List, error) {
+	return lw.store.list()
+}
+
+// Watch returns a watcher delivering the changes following
+// resourceVersion, or following the current version if it is empty or 0.
+// The watcher is stopped when ctx is done.
+func (lw *UnitListerWatcher) Watch(ctx context.Context, resourceVersion string) (Watcher, error) {
+	return lw.store.watch(ctx, resourceVersion)
+}
+
+// Err returns the error of the last attempt to list units again, nil if it
+// succeeded. The previous units of the concerned nodes are kept meanwhile.
+func (lw *UnitListerWatcher) Err() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.err
+}
+
+// Close stops updating the units, closes the monitor and stops all
+// watchers.
+func (lw *UnitListerWatcher) Close() error {
+	lw.cancel()
+	<-lw.done
+	return lw.closeErr
+}
+
+func (lw *UnitListerWatcher) run(ctx context.Context) {
+	defer close(lw.done)
+	defer func() {
+		lw.cancel()
+		lw.store.close(ErrClosed)
+		if err := lw.mon.Close(context.Background()); err != nil {
+			lw.closeErr = fmt.Errorf("failed to close unit lister watcher: %w", err)
+		}
+	}()
+
+	events, nodes, states := lw.mon.Events(), lw.nodes, lw.states
+	connected := true
+	for events != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			lw.apply(e)
+		case e, ok := <-nodes:
+			if !ok {
+				nodes = nil
+				continue
+			}
+			if e.NewState.IsOnline() {
+				lw.relist(ctx, e.Node)
+			} else {
+				lw.store.replace(nil, onNode(e.Node))
+			}
+		case state, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			// changes were missed while disconnected
+			if state == manager.Connected && !connected {
+				lw.relist(ctx, "")
+			}
+			connected = state == manager.Connected
+		}
+	}
+}
+
+// relist lists the units of the named node, or of all nodes if nodeName is
+// empty, and replaces the stored ones.
+func (lw *UnitListerWatcher) relist(ctx context.Context, nodeName string) {
+	var opts []manager.ListUnitsOption
+	owned := func(Object) bool { return true }
+	if nodeName != "" {
+		opts = append(opts, manager.WithNodes(nodeName))
+		owned = onNode(nodeName)
+	}
+	units, err := lw.api.ListUnits(ctx, opts...)
+
+	lw.mu.Lock()
+	lw.err = err
+	lw.mu.Unlock()
+	if err == nil {
+		lw.store.replace(unitObjects(units), owned)
+	}
+}
+
+func (lw *UnitListerWatcher) apply(event monitor.Event) {
+	key := Unit{Node: event.NodeName(), Name: event.UnitName()}.Key()
+	if _, removed := event.(monitor.UnitRemoved); removed {
+		lw.store.delete(key)
+		return
+	}
+
+	var u Unit
+	if obj, ok := lw.store.get(key); ok {
+		u = obj.(Unit)
+	} else {
+		u = Unit{Node: event.NodeName(), Name: event.UnitName(), LoadState: node.LoadStateLoaded}
+	}
+	switch e := event.(type) {
+	case monitor.UnitStateChanged:
+		u.ActiveState, u.SubState = e.ActiveState, e.SubState
+	case monitor.UnitPropertiesChanged:
+		if e.Interface != common.SYSTEMD_UNIT_INTERFACE {
+			return
+		}
+		updateProperty(&u.ActiveState, e.Properties, "ActiveState")
+		updateProperty(&u.SubState, e.Properties, "SubState")
+		updateProperty(&u.LoadState, e.Properties, "LoadState")
+		updateProperty(&u.Description, e.Properties, "Description")
+	case monitor.UnitNew:
+	default:
+		return
+	}
+	lw.store.put(u)
+}
+
+func updateProperty[T ~string](field *T, props map[string]dbus.Variant, name string) {
+	v, ok := props[name]
+	if !ok {
+		return
+	}
+	if s, err := variant.String(v); err == nil {
+		*field = T(s)
+	}
+}
+
+func unitObjects(units map[string][]node.UnitInfo) []Object {
+	var objs []Object
+	for nodeName, list := range units {
+		for _, info := range list {
+			objs = append(objs, newUnit(nodeName, info))
+		}
+	}
+	return objs
+}
+
+// onNode returns a func reporting whether a unit is on the named node.
+func onNode(nodeName string) func(obj Object) bool {
+	return func(obj Object) bool {
+		return obj.(Unit).Node == nodeName
+	}
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// ManagerAPI is the set of operations on the controller. Code depending on
+// ManagerAPI instead of *Manager can be unit tested against a fake, e.g. the
+// in-memory one of package managertest. Manager.API returns the ManagerAPI
+// of a Manager. Proxies for single jobs, i.e. GetJob and TrackJobs,
+// consuming monitors as peer and Introspect are only available on the
+// Manager itself.
+type ManagerAPI interface {
+	// Connect opens the connection to the controller, see Manager.Connect.
+	Connect() error
+	// Close closes the connection to the controller.
+	Close() error
+	// Shutdown closes the connection to the controller after waiting for
+	// the calls in flight.
+	Shutdown(ctx context.Context) error
+	// State returns the current state of the connection.
+	State() ConnState
+	// ConnectionEvents returns a channel reporting the connection state.
+	ConnectionEvents() <-chan ConnState
+
+	// ListNodes returns all nodes managed by BlueChi, ordered by the
+	// options.
+	ListNodes(ctx context.Context, opts ...ListNodesOption) ([]NodeInfo, error)
+	// Ping measures the round trip times to the controller and the agents.
+	Ping(ctx context.Context) (PingReport, error)
+	// GetNode returns the named node.
+	GetNode(ctx context.Context, name string) (NodeAPI, error)
+	// ListUnits returns the units of all online nodes keyed by node name,
+	// restricted and ordered by the options.
+	ListUnits(ctx context.Context, opts ...ListUnitsOption) (map[string][]node.UnitInfo, error)
+	// ListUnitsFunc calls fn with the units of all online nodes until fn
+	// returns false, restricted by the options.
+	ListUnitsFunc(ctx context.Context, fn func(nodeName string, u node.UnitInfo) bool, opts ...ListUnitsOption) error
+	// SubscribeNodeConnectionStateChanged reports nodes going online or
+	// offline.
+	SubscribeNodeConnectionStateChanged(ctx context.Context) (<-chan NodeConnectionStateChanged, error)
+	// ListAndWatchNodes returns the nodes and the numbered changes after
+	// them, with no change missed or seen twice.
+	ListAndWatchNodes(ctx context.Context) ([]NodeInfo, <-chan NodeEvent, error)
+
+	// CreateMonitor creates a monitor for unit events.
+	CreateMonitor(ctx context.Context) (MonitorAPI, error)
+	// WaitForJob waits for the job at path to finish and returns its result.
+	WaitForJob(ctx context.Context, path dbus.ObjectPath) (string, error)
+
+	// Status returns the status of the overall system.
+	Status(ctx context.Context) (string, error)
+	// LogLevel returns the log level of the controller.
+	LogLevel(ctx context.Context) (string, error)
+	// LogTarget returns the log target of the controller.
+	LogTarget(ctx context.Context) (string, error)
+	// GetProperty returns the named property of the controller.
+	GetProperty(ctx context.Context, name string) (interface{}, error)
+	// SetLogLevel changes the log level of the controller.
+	SetLogLevel(ctx context.Context, level string) error
+	// EnableMetrics enables collecting performance metrics.
+	EnableMetrics(ctx context.Context) error
+	// DisableMetrics disables collecting performance metrics.
+	DisableMetrics(ctx context.Context) error
+	// SubscribeMetrics delivers the metrics signals of the controller.
+	SubscribeMetrics(ctx context.Context) (<-chan metrics.Event, error)
+
+	// NodeGate returns the gate shared by the batch and rolling operations
+	// on the nodes.
+	NodeGate() *NodeGate
+}
+
+// NodeAPI is the set of operations on a single node, implemented by
+// *node.Node. See there for the documentation of the methods.
+type NodeAPI interface {
+	Name() string
+	ObjectPath() dbus.ObjectPath
+	SetLogLevel(ctx context.Context, level string) error
+	Status(ctx context.Context) (node.NodeStatus, error)
+	WatchStatus(ctx context.Context) (<-chan node.NodeStatus, error)
+	PeerIP(ctx context.Context) (string, error)
+	LastSeenTimestamp(ctx context.Context) (time.Time, error)
+	Ping(ctx context.Context) (time.Duration, error)
+
+	ListUnits(ctx context.Context) ([]node.UnitInfo, error)
+	ListUnitsFunc(ctx context.Context, fn func(node.UnitInfo) bool) error
+	StartUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error)
+	StopUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error)
+	RestartUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error)
+	ReloadUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error)
+	StartUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error)
+	StopUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error)
+	RestartUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error)
+	ReloadUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error)
+	StartUnitAndWait(ctx context.Context, unit string, mode string) error
+	StopUnitAndWait(ctx context.Context, unit string, mode string) error
+	RestartUnitAndWait(ctx context.Context, unit string, mode string) error
+	ReloadUnitAndWait(ctx context.Context, unit string, mode string) error
+	StartUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult
+	StopUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult
+	RestartUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult
+	ReloadUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult
+	FreezeUnit(ctx context.Context, unit string) error
+	ThawUnit(ctx context.Context, unit string) error
+
+	GetUnitProperties(ctx context.Context, unit string, iface string) (map[string]interface{}, error)
+	GetUnitProperty(ctx context.Context, unit string, iface string, property string) (interface{}, error)
+	GetUnitsProperties(ctx context.Context, units []string, props []string, opts ...node.PropertiesOption) (map[string]map[string]interface{}, error)
+	GetUnitActiveState(ctx context.Context, unit string) (node.ActiveState, error)
+	GetUnitSubState(ctx context.Context, unit string) (node.SubState, error)
+	GetUnitCGroupPath(ctx context.Context, unit string) (string, error)
+	UnitStatus(ctx context.Context, unit string, opts ...node.UnitStatusOption) (node.UnitStatus, error)
+	WaitForUnitState(ctx context.Context, unit string, target node.ActiveState) error
+	UnitDependencies(ctx context.Context, unit string) (node.UnitDependencies, error)
+	SetUnitProperties(ctx context.Context, unit string, runtime bool, props map[string]interface{}) error
+	SetUnitCPUQuota(ctx context.Context, unit string, runtime bool, percent float64) error
+	SetUnitCPUWeight(ctx context.Context, unit string, runtime bool, weight uint64) error
+	SetUnitMemoryMax(ctx context.Context, unit string, runtime bool, bytes uint64) error
+
+	EnableUnitFiles(ctx context.Context, files []string, runtime bool, force bool) (node.EnableUnitFilesResult, error)
+	DisableUnitFiles(ctx context.Context, files []string, runtime bool) ([]node.UnitFileChange, error)
+	GetUnitFileState(ctx context.Context, unit string) (string, error)
+	ListUnitFiles(ctx context.Context) ([]node.UnitFile, error)
+	Reload(ctx context.Context) error
+}
+
+// MonitorAPI is the set of operations on a monitor, implemented by
+// *monitor.Monitor. See there for the documentation of the methods.
+type MonitorAPI interface {
+	ObjectPath() dbus.ObjectPath
+	Events() <-chan monitor.Event
+	Subscribe(ctx context.Context, node string, unit string) (uint32, error)
+	SubscribeList(ctx context.Context, node string, units []string) (uint32, error)
+	Watch(ctx context.Context, node string, unit string) (uint32, <-chan monitor.Event, error)
+	Unsubscribe(ctx context.Context, id uint32) error
+	AddPeer(ctx context.Context, name string) (uint32, error)
+	RemovePeer(ctx context.Context, id uint32, reason string) error
+	Close(ctx context.Context) error
+}
+
+var (
+	_ NodeAPI    = (*node.Node)(nil)
+	_ MonitorAPI = (*monitor.Monitor)(nil)
+)
+
+// API returns the ManagerAPI of the Manager, whose methods are those of the
+// Manager returning the node and monitor proxies as interfaces.
+func (m *Manager) API() ManagerAPI {
+	return api{m}
+}
+
+type api struct {
+	*Manager
+}
+
+func (a api) GetNode(ctx context.Context, name string) (NodeAPI, error) {
+	n, err := a.Manager.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (a api) CreateMonitor(ctx context.Context) (MonitorAPI, error) {
+	mon, err := a.Manager.CreateMonitor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mon, nil
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// Operation describes a mutating call passed to a Hook.
+type Operation struct {
+	// Method is the interface qualified name of the D-Bus method, e.g.
+	// common.METHOD_START_UNIT.
+	Method string
+	// Path is the object path called, e.g. the one of a node.
+	Path dbus.ObjectPath
+	// Node is the name of the node operated on, empty for operations on
+	// the controller.
+	Node string
+	// Unit is the name of the unit operated on, empty if the operation is
+	// not about a single unit, e.g. EnableUnitFiles.
+	Unit string
+	// Args are the arguments of the call, e.g. the unit and the job mode
+	// of StartUnit. They must not be modified.
+	Args []interface{}
+	// Metadata is the metadata attached to the context of the call with
+	// common.WithMetadata, e.g. who initiated the operation.
+	Metadata common.Metadata
+}
+
+func newOperation(info bus.CallInfo) Operation {
+	return Operation{Method: info.Method, Path: info.Path, Node: info.Node, Unit: info.Unit, Args: info.Args, Metadata: info.Metadata}
+}
+
+// Hook is called around the mutating calls issued by a Manager and the
+// proxies obtained from it: the lifecycle operations of units, setting unit
+// properties, enabling and disabling unit files, reloading, changing log
+// levels, toggling metrics and cancelling jobs. Reading calls are not
+// passed to hooks. Hooks are called synchronously on the goroutine issuing
+// the call and must be safe for concurrent use.
+type Hook interface {
+	// Before is called before the call is issued. Returning an error
+	// denies the operation, which then fails with the error wrapped.
+	Before(ctx context.Context, op Operation) error
+	// After is called with the outcome of each operation Before allowed,
+	// err is nil if it succeeded.
+	After(ctx context.Context, op Operation, err error)
+}
+
+// WithHook adds hook to the hooks called around mutating calls, e.g. to
+// write an audit log or to enforce a policy. Before is called in the order
+// the hooks were added, After in reverse order, once per operation
+// regardless of retries. If a hook denies the operation, the hooks after it
+// are not called and the After of the hooks before it receives the denial.
+func WithHook(hook Hook) Option {
+	return func(o *options) error {
+		if hook == nil {
+			return errors.New("nil hook")
+		}
+		o.hooks = append(o.hooks, hook)
+		return nil
+	}
+}
+
+// interceptor returns the bus.Interceptor calling hooks, nil without hooks.
+func interceptor(hooks []Hook) bus.Interceptor {
+	if len(hooks) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, info bus.CallInfo) (func(error), error) {
+		op := newOperation(info)
+		after := func(called int, err error) {
+			for i := called - 1; i >= 0; i-- {
+				hooks[i].After(ctx, op, err)
+			}
+		}
+		for i, hook := range hooks {
+			if err := hook.Before(ctx, op); err != nil {
+				after(i, err)
+				return nil, err
+			}
+		}
+		return func(err error) { after(len(hooks), err) }, nil
+	}
+}
@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// DefaultConcurrency is the number of nodes a batch operation works on at
+// the same time unless WithConcurrency is given.
+const DefaultConcurrency = 8
+
+// BatchOption configures a batch operation like StartUnitOnNodes.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	maxInFlight int
+	serial      bool
+	mode        string
+}
+
+// WithConcurrency limits the number of nodes worked on at the same time.
+// Values below 1 select DefaultConcurrency.
+func WithConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithMaxInFlight limits the number of operations in flight across all
+// batch and rolling operations of the manager to n while the node of the
+// batch is worked on, e.g. to bound the load on the controller. Values below
+// 1 set no limit.
+func WithMaxInFlight(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.maxInFlight = n
+	}
+}
+
+// WithPerNodeSerial runs the operation on a node only while no other batch
+// or rolling operation of the manager works on the node and keeps others
+// off the node until it is done, so that commands targeting the same node
+// never race each other. Different nodes are still worked on concurrently.
+func WithPerNodeSerial() BatchOption {
+	return func(o *batchOptions) {
+		o.serial = true
+	}
+}
+
+// WithJobMode sets the mode the jobs are queued with, node.ModeReplace by
+// default.
+func WithJobMode(mode string) BatchOption {
+	return func(o *batchOptions) {
+		o.mode = mode
+	}
+}
+
+func newBatchOptions(opts []BatchOption) batchOptions {
+	o := batchOptions{mode: node.ModeReplace}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = DefaultConcurrency
+	}
+	return o
+}
+
+// NodeResult is the outcome of a batch operation on a single node.
+type NodeResult struct {
+	// Node is the name of the node.
+	Node string
+	// JobPath is the object path of the job queued on the node, empty if
+	// queueing it failed.
+	JobPath dbus.ObjectPath
+	// Result is the result of the finished job, e.g. job.ResultDone, empty
+	// if the job did not finish.
+	Result string
+	// Err is the error of the operation on the node, nil if the job
+	// finished with job.ResultDone.
+	Err error
+}
+
+// MultiError collects the errors of a batch operation keyed by node name,
+// see common.MultiError.
+type MultiError = common.MultiError
+
+// NodeError is the error of a batch operation on a single node, see
+// common.NodeError.
+type NodeError = common.NodeError
+
+// StartUnitOnNodes starts the unit on each of the nodes concurrently and
+// waits for the jobs to finish. The results are in the order of nodes. If
+// any node fails, the error is a *MultiError keyed by the failed nodes,
+// with a *NodeError for each of them.
+func (m *Manager) StartUnitOnNodes(ctx context.Context, unit string, nodes []string, opts ...BatchOption) ([]NodeResult, error) {
+	return m.unitJobOnNodes(ctx, "start", unit, nodes, (*node.Node).StartUnit, opts)
+}
+
+// StopUnitOnNodes stops the unit on each of the nodes like
+// StartUnitOnNodes.
+func (m *Manager) StopUnitOnNodes(ctx context.Context, unit string, nodes []string, opts ...BatchOption) ([]NodeResult, error) {
+	return m.unitJobOnNodes(ctx, "stop", unit, nodes, (*node.Node).StopUnit, opts)
+}
+
+// RestartUnitOnNodes restarts the unit on each of the nodes like
+// StartUnitOnNodes.
+func (m *Manager) RestartUnitOnNodes(ctx context.Context, unit string, nodes []string, opts ...BatchOption) ([]NodeResult, error) {
+	return m.unitJobOnNodes(ctx, "restart", unit, nodes, (*node.Node).RestartUnit, opts)
+}
+
+// ReloadUnitOnNodes reloads the unit on each of the nodes like
+// StartUnitOnNodes.
+func (m *Manager) ReloadUnitOnNodes(ctx context.Context, unit string, nodes []string, opts ...BatchOption) ([]NodeResult, error) {
+	return m.unitJobOnNodes(ctx, "reload", unit, nodes, (*node.Node).ReloadUnit, opts)
+}
+
+// RunOnNodes calls fn for each of the nodes concurrently, respecting the
+// concurrency limit of the options. If fn fails for any node, the error is
+// a *MultiError keyed by the failed nodes.
+func (m *Manager) RunOnNodes(ctx context.Context, nodes []string, fn func(ctx context.Context, n *node.Node) error, opts ...BatchOption) error {
+	return m.runOnNodes(ctx, "run", nodes, fn, opts)
+}
+
+// runOnNodes is RunOnNodes for the operation op.
+func (m *Manager) runOnNodes(ctx context.Context, op string, nodes []string, fn func(ctx context.Context, n *node.Node) error, opts []BatchOption) error {
+	o := newBatchOptions(opts)
+	errs := make([]error, len(nodes))
+	m.fanOut(ctx, nodes, &o, func(i int, n *node.Node) {
+		errs[i] = fn(ctx, n)
+	}, errs)
+	return collectErrors(op, nodes, errs)
+}
+
+// SetAllAgentsLogLevel changes the log level of the agents on all online
+// nodes concurrently, e.g. to common.LOG_LEVEL_DEBUG for a debugging
+// session across the cluster. Offline nodes are skipped, they start with the
+// log level of their configuration when they connect. If any node fails,
+// the error is a *MultiError keyed by the failed nodes.
+func (m *Manager) SetAllAgentsLogLevel(ctx context.Context, level string, opts ...BatchOption) error {
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, n := range nodes {
+		if n.Status.IsOnline() {
+			names = append(names, n.Name)
+		}
+	}
+	return m.runOnNodes(ctx, "set log level to "+level, names, func(ctx context.Context, n *node.Node) error {
+		return n.SetLogLevel(ctx, level)
+	}, opts)
+}
+
+type unitJobFunc func(n *node.Node, ctx context.Context, unit string, mode string) (dbus.ObjectPath, error)
+
+func (m *Manager) unitJobOnNodes(ctx context.Context, op string, unit string, nodes []string, queue unitJobFunc, opts []BatchOption) ([]NodeResult, error) {
+	o := newBatchOptions(opts)
+
+	// track jobs before queueing them so that no JobRemoved signal is missed
+	tracker, err := m.TrackJobs()
+	if err != nil {
+		return nil, err
+	}
+	defer tracker.Close()
+
+	results := make([]NodeResult, len(nodes))
+	errs := make([]error, len(nodes))
+	m.fanOut(ctx, nodes, &o, func(i int, n *node.Node) {
+		r := &results[i]
+		r.JobPath, r.Err = queue(n, ctx, unit, o.mode)
+		if r.Err != nil {
+			return
+		}
+		r.Result, r.Err = tracker.Wait(ctx, r.JobPath)
+		if r.Err == nil && r.Result != job.ResultDone {
+			r.Err = fmt.Errorf("failed to %s unit %s on node %s: job %s finished with result %s", op, unit, n.Name(), r.JobPath, r.Result)
+		}
+	}, errs)
+
+	for i := range results {
+		results[i].Node = nodes[i]
+		if errs[i] != nil {
+			results[i].Err = errs[i]
+		}
+		errs[i] = results[i].Err
+	}
+	return results, collectErrors(op+" unit "+unit, nodes, errs)
+}
+
+// fanOut calls fn with the index and node of each of the named nodes, at
+// most the concurrency of o at a time. Unless o is nil, fn is only called
+// once the NodeGate of m admits it. Failing to get a node is stored in errs
+// instead.
+func (m *Manager) fanOut(ctx context.Context, names []string, o *batchOptions, fn func(i int, n *node.Node), errs []error) {
+	limit := DefaultConcurrency
+	if o != nil {
+		limit = o.concurrency
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("failed to run on node %s: %w", name, ctx.Err())
+				return
+			}
+			defer func() { <-sem }()
+
+			if o != nil {
+				leave, err := m.gate.Enter(ctx, name, o.maxInFlight, o.serial)
+				if err != nil {
+					errs[i] = fmt.Errorf("failed to run on node %s: %w", name, err)
+					return
+				}
+				defer leave()
+			}
+
+			n, err := m.GetNode(ctx, name)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			fn(i, n)
+		}()
+	}
+	wg.Wait()
+}
+
+func collectErrors(op string, nodes []string, errs []error) error {
+	multi := &MultiError{Op: op}
+	for i, err := range errs {
+		if err != nil {
+			multi.Add(nodes[i], err)
+		}
+	}
+	return multi.ErrorOrNil()
+}
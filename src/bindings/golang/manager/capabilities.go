@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// ErrVersionUnknown is returned by ControllerVersion for controllers which
+// do not report their version.
+var ErrVersionUnknown = errors.New("controller does not report its version")
+
+// Capabilities are the optional features supported by the controller, as
+// detected by Capabilities.
+type Capabilities struct {
+	// Metrics is true if the controller supports EnableMetrics and
+	// DisableMetrics and emits the metrics signals.
+	Metrics bool
+	// MonitorWildcards is true if monitors accept the wildcard
+	// common.SYMBOL_WILDCARD for node and unit names.
+	MonitorWildcards bool
+	// MonitorSubscribeList is true if monitors support SubscribeList.
+	MonitorSubscribeList bool
+	// MonitorPeers is true if monitors support AddPeer and RemovePeer.
+	MonitorPeers bool
+	// PeerIP is true if nodes report the PeerIp property.
+	PeerIP bool
+}
+
+// ControllerVersion returns the version of bluechi-controller. It fails
+// with ErrVersionUnknown for controllers which do not export a Version
+// property, which includes all released versions at the time of writing.
+// Use Capabilities to check for the features the caller depends on
+// instead of comparing versions.
+func (m *Manager) ControllerVersion(ctx context.Context) (string, error) {
+	i, err := m.Introspect(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get controller version: %w", err)
+	}
+	if !i.HasProperty(common.CONTROLLER_INTERFACE, "Version") {
+		return "", ErrVersionUnknown
+	}
+	return m.getStringProperty(ctx, "Version")
+}
+
+// Capabilities probes the controller for optional features, so that
+// callers can degrade gracefully when talking to an older controller. It
+// introspects the controller, a temporary monitor and the first node, if
+// any, and tries to subscribe the monitor with wildcards. Support for
+// proxy services is a feature of the agents, see
+// agent.Agent.SupportsProxies.
+func (m *Manager) Capabilities(ctx context.Context) (Capabilities, error) {
+	var caps Capabilities
+	i, err := m.Introspect(ctx)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("failed to detect capabilities: %w", err)
+	}
+	caps.Metrics = i.HasMethod(common.CONTROLLER_INTERFACE, "EnableMetrics")
+
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("failed to detect capabilities: %w", err)
+	}
+	defer mon.Close(ctx)
+	if _, err := mon.Subscribe(ctx, common.SYMBOL_WILDCARD, common.SYMBOL_WILDCARD); err == nil {
+		caps.MonitorWildcards = true
+	}
+	if i, err := mon.Introspect(ctx); err == nil {
+		caps.MonitorSubscribeList = i.HasMethod(common.MONITOR_INTERFACE, "SubscribeList")
+		caps.MonitorPeers = i.HasMethod(common.MONITOR_INTERFACE, "AddPeer")
+	}
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("failed to detect capabilities: %w", err)
+	}
+	if len(nodes) > 0 {
+		n, err := m.GetNode(ctx, nodes[0].Name)
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("failed to detect capabilities: %w", err)
+		}
+		i, err := n.Introspect(ctx)
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("failed to detect capabilities: %w", err)
+		}
+		caps.PeerIP = i.HasProperty(common.NODE_INTERFACE, "PeerIp")
+	}
+	return caps, nil
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/agent"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// Connection returns the connection of the Manager for the constructors of
+// the other bindings, e.g. node.New, monitor.New or agent.New, so that a
+// process opens a single bus connection. Clients on it share the signal
+// subscription of the Manager, are restored along with it by auto-reconnect
+// and go through its options, e.g. retries and dry-run mode. They stop
+// working once the Manager is closed.
+func (m *Manager) Connection() (common.Connection, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return s.conn, nil
+}
+
+// NodeClient returns a proxy for the named node at path on the connection
+// of the Manager without resolving it on the controller, e.g. for the
+// object paths reported by ListNodes. Use GetNode to resolve a node by
+// name.
+func (m *Manager) NodeClient(name string, path dbus.ObjectPath) (*node.Node, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return node.New(s.conn, name, path), nil
+}
+
+// MonitorClient returns a proxy for the monitor at path on the connection of
+// the Manager, e.g. of a monitor created by CreateMonitor in another part of
+// the process. Subscriptions added to it are its own, see monitor.New. Use
+// AttachMonitor for monitors to which this Manager was added as peer.
+func (m *Manager) MonitorClient(path dbus.ObjectPath) (*monitor.Monitor, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return monitor.New(s.conn, path)
+}
+
+// AgentClient returns a proxy for the BlueChi agent on the connection of the
+// Manager, for hosts on which the agent is reachable on the bus of the
+// controller, e.g. the system bus of a host running both.
+func (m *Manager) AgentClient() (*agent.Agent, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return agent.New(s.conn), nil
+}
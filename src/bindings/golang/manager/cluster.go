@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+)
+
+// clusterEventBufferSize is the capacity of the channel returned by
+// ClusterWatcher.Events.
+const clusterEventBufferSize = 64
+
+// ClusterEvent is an event delivered by a ClusterWatcher, one of NodeEvent,
+// UnitEvent or JobEvent.
+type ClusterEvent interface {
+	// Sequence returns the position of the event in the stream of the
+	// watcher, starting at 1.
+	Sequence() uint64
+}
+
+// NodeEvent reports a node going online or offline.
+type NodeEvent struct {
+	Seq uint64
+	NodeConnectionStateChanged
+}
+
+// UnitEvent reports a change of a unit on a node.
+type UnitEvent struct {
+	Seq uint64
+	monitor.Event
+}
+
+// JobEvent reports a job being queued or finished.
+type JobEvent struct {
+	Seq uint64
+	job.Event
+}
+
+func (e NodeEvent) Sequence() uint64 { return e.Seq }
+func (e UnitEvent) Sequence() uint64 { return e.Seq }
+func (e JobEvent) Sequence() uint64  { return e.Seq }
+
+// ClusterWatcher combines the node connection events, the unit events of
+// all nodes and the job lifecycle signals of the controller into a single
+// stream, e.g. to drive a reconcile loop.
+type ClusterWatcher struct {
+	mon    *monitor.Monitor
+	jobs   *job.Tracker
+	nodes  <-chan NodeConnectionStateChanged
+	events chan ClusterEvent
+	seq    uint64
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error
+}
+
+// WatchCluster returns a ClusterWatcher for all nodes and units managed by
+// the controller. The watcher delivers events until ctx is done, Close is
+// called or the Manager is closed.
+func (m *Manager) WatchCluster(ctx context.Context) (*ClusterWatcher, error) {
+	// the job tracker and monitor are set up first, so that the jobs and
+	// unit changes of nodes reported online are not missed
+	jobs, err := m.TrackJobs()
+	if err != nil {
+		return nil, err
+	}
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		jobs.Close()
+		return nil, err
+	}
+	if _, err := mon.Subscribe(ctx, common.SYMBOL_WILDCARD, common.SYMBOL_WILDCARD); err != nil {
+		jobs.Close()
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	nodes, err := m.SubscribeNodeConnectionStateChanged(watchCtx)
+	if err != nil {
+		cancel()
+		jobs.Close()
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+
+	w := &ClusterWatcher{
+		mon:    mon,
+		jobs:   jobs,
+		nodes:  nodes,
+		events: make(chan ClusterEvent, clusterEventBufferSize),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run(watchCtx)
+	return w, nil
+}
+
+// Events returns the channel on which the events are delivered in the
+// order they are received, numbered by their sequence. The events of each
+// source keep the order of the controller. As with job.Tracker, job events
+// are dropped while the consumer lags behind, the other events wait for it.
+// The channel is closed when the watcher stops.
+func (w *ClusterWatcher) Events() <-chan ClusterEvent {
+	return w.events
+}
+
+// Close stops the watcher and closes its monitor on the controller.
+func (w *ClusterWatcher) Close() error {
+	w.cancel()
+	<-w.done
+	return w.closeErr
+}
+
+func (w *ClusterWatcher) run(ctx context.Context) {
+	defer close(w.done)
+	defer close(w.events)
+	defer w.stop()
+
+	nodes, units, jobs := w.nodes, w.mon.Events(), w.jobs.Events()
+	for nodes != nil || units != nil || jobs != nil {
+		var event ClusterEvent
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-nodes:
+			if !ok {
+				nodes = nil
+				continue
+			}
+			event = NodeEvent{Seq: w.next(), NodeConnectionStateChanged: e}
+		case e, ok := <-units:
+			if !ok {
+				units = nil
+				continue
+			}
+			event = UnitEvent{Seq: w.next(), Event: e}
+		case e, ok := <-jobs:
+			if !ok {
+				jobs = nil
+				continue
+			}
+			event = JobEvent{Seq: w.next(), Event: e}
+		}
+
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *ClusterWatcher) next() uint64 {
+	w.seq++
+	return w.seq
+}
+
+func (w *ClusterWatcher) stop() {
+	w.cancel()
+	w.jobs.Close()
+	if err := w.mon.Close(context.Background()); err != nil {
+		w.closeErr = fmt.Errorf("failed to close cluster watcher: %w", err)
+	}
+}
@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ConfigSection is the section of a configuration file read by LoadConfig.
+// Settings before the first section are read as well, other sections are
+// ignored, so the file can be shared with other tools.
+const ConfigSection = "bluechi-client"
+
+// ConfigEnvPrefix prefixes the environment variables overriding the
+// settings of a configuration file, e.g. BLUECHI_BUS_ADDRESS for
+// BusAddress.
+const ConfigEnvPrefix = "BLUECHI_"
+
+// Config holds the connection settings of a Manager, read by LoadConfig so
+// that deployments do not hard-code them. Options turns them into the
+// options of NewManager.
+type Config struct {
+	// Bus is the bus the controller is connected on: "system", "session"
+	// or "auto" for WithAutoDetectBus. Empty means the system bus unless
+	// BusAddress or PeerAddress is set. Only one of the three is used,
+	// setting one of them in a file or the environment clears the others.
+	Bus string
+	// BusAddress is the D-Bus address of the bus, see WithBusAddress.
+	BusAddress string
+	// PeerAddress is the address of a peer-to-peer connection, see
+	// WithPeerAddress.
+	PeerAddress string
+	// Names are the D-Bus names of the controller, zero fields keep their
+	// default.
+	Names Names
+	// CallTimeout bounds each call, zero means no bound.
+	CallTimeout time.Duration
+	// LazyConnect defers connecting until the first call.
+	LazyConnect bool
+	// Reconnect enables WithAutoReconnect if set.
+	Reconnect *Backoff
+	// Retry enables WithRetry if set.
+	Retry *RetryPolicy
+}
+
+// configKeys are the settings of a configuration file by their key, which
+// is matched ignoring case, underscores and dashes, so that both the style
+// of the BlueChi configuration files, e.g. CallTimeout=5s, and of TOML,
+// e.g. call_timeout = "5s", are accepted.
+var configKeys = []struct {
+	key string
+	set func(c *Config, value string) error
+}{
+	{"Bus", func(c *Config, v string) error {
+		switch v {
+		case "system", "session", "auto":
+			c.Bus, c.BusAddress, c.PeerAddress = v, "", ""
+			return nil
+		}
+		return fmt.Errorf("unknown bus %q, expected system, session or auto", v)
+	}},
+	{"BusAddress", func(c *Config, v string) error { c.Bus, c.BusAddress, c.PeerAddress = "", v, ""; return nil }},
+	{"PeerAddress", func(c *Config, v string) error { c.Bus, c.BusAddress, c.PeerAddress = "", "", v; return nil }},
+	{"ControllerName", func(c *Config, v string) error { c.Names.Service = v; return nil }},
+	{"ControllerObjectPath", func(c *Config, v string) error { c.Names.ObjectPath = dbus.ObjectPath(v); return nil }},
+	{"InterfacePrefix", func(c *Config, v string) error { c.Names.Interface = v; return nil }},
+	{"CallTimeout", func(c *Config, v string) error { return parseConfigDuration(v, &c.CallTimeout) }},
+	{"LazyConnect", func(c *Config, v string) error { return parseConfigBool(v, &c.LazyConnect) }},
+	{"AutoReconnect", func(c *Config, v string) error {
+		var enabled bool
+		if err := parseConfigBool(v, &enabled); err != nil {
+			return err
+		}
+		if !enabled {
+			c.Reconnect = nil
+		} else if c.Reconnect == nil {
+			c.Reconnect = &Backoff{}
+		}
+		return nil
+	}},
+	{"ReconnectInitialDelay", func(c *Config, v string) error { return parseConfigDuration(v, &c.reconnect().Initial) }},
+	{"ReconnectMaxDelay", func(c *Config, v string) error { return parseConfigDuration(v, &c.reconnect().Max) }},
+	{"ReconnectMultiplier", func(c *Config, v string) error { return parseConfigFloat(v, &c.reconnect().Multiplier) }},
+	{"RetryMaxAttempts", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		if n == 0 {
+			c.Retry = nil
+			return nil
+		}
+		c.retry().MaxAttempts = n
+		return nil
+	}},
+	{"RetryInitialDelay", func(c *Config, v string) error { return parseConfigDuration(v, &c.retry().Backoff.Initial) }},
+	{"RetryMaxDelay", func(c *Config, v string) error { return parseConfigDuration(v, &c.retry().Backoff.Max) }},
+	{"RetryMultiplier", func(c *Config, v string) error { return parseConfigFloat(v, &c.retry().Backoff.Multiplier) }},
+	{"RetryNonIdempotent", func(c *Config, v string) error { return parseConfigBool(v, &c.retry().RetryNonIdempotent) }},
+}
+
+func (c *Config) reconnect() *Backoff {
+	if c.Reconnect == nil {
+		c.Reconnect = &Backoff{}
+	}
+	return c.Reconnect
+}
+
+func (c *Config) retry() *RetryPolicy {
+	if c.Retry == nil {
+		c.Retry = &RetryPolicy{MaxAttempts: 1}
+	}
+	return c.Retry
+}
+
+// LoadConfig reads the connection settings from the configuration file at
+// path and overrides them by the environment. The file has the format of
+// the BlueChi configuration files: lines of Key=Value, optionally in the
+// section [bluechi-client], and comments starting with # or ;. Values may
+// be quoted and keys written in snake case, so simple TOML files are read
+// as well:
+//
+//	[bluechi-client]
+//	BusAddress=unix:path=/run/bluechi/bus
+//	CallTimeout=5s
+//	RetryMaxAttempts=3
+//
+// Durations are Go durations like 1m30s or milliseconds like the intervals
+// of BlueChi, e.g. 2000. Setting a reconnect or retry key enables
+// WithAutoReconnect or WithRetry, AutoReconnect=false and
+// RetryMaxAttempts=0 disable them again. Each key is overridden by the
+// environment variable named after it with ConfigEnvPrefix, e.g.
+// BLUECHI_CALL_TIMEOUT=10s. With an empty path only the environment is
+// read. Unknown keys are errors.
+func LoadConfig(path string) (*Config, error) {
+	c := &Config{}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open config: %w", err)
+		}
+		defer f.Close()
+		if err := c.parse(f.Name(), bufio.NewScanner(f)); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range configKeys {
+		env := ConfigEnvPrefix + envName(k.key)
+		if v, ok := os.LookupEnv(env); ok {
+			if err := k.set(c, strings.TrimSpace(v)); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", env, err)
+			}
+		}
+	}
+	if _, err := c.Options(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return c, nil
+}
+
+func (c *Config) parse(name string, lines *bufio.Scanner) error {
+	section := ""
+	for n := 1; lines.Scan(); n++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return fmt.Errorf("%s:%d: unterminated section", name, n)
+			}
+			section = strings.TrimSpace(line[1:end])
+			continue
+		}
+		if section != "" && section != ConfigSection {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("%s:%d: expected Key=Value", name, n)
+		}
+		key = strings.TrimSpace(key)
+		value, err := unquoteConfig(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, n, err)
+		}
+		set := lookupConfigKey(key)
+		if set == nil {
+			return fmt.Errorf("%s:%d: unknown key %q", name, n, key)
+		}
+		if err := set(c, value); err != nil {
+			return fmt.Errorf("%s:%d: invalid %s: %w", name, n, key, err)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	return nil
+}
+
+func lookupConfigKey(key string) func(*Config, string) error {
+	normalize := strings.NewReplacer("_", "", "-", "")
+	key = normalize.Replace(key)
+	for _, k := range configKeys {
+		if strings.EqualFold(k.key, key) {
+			return k.set
+		}
+	}
+	return nil
+}
+
+// unquoteConfig strips the quotes of a TOML string and comments following
+// an unquoted value.
+func unquoteConfig(value string) (string, error) {
+	if value == "" || value[0] != '"' && value[0] != '\'' {
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+	end := strings.IndexByte(value[1:], value[0])
+	if end < 0 {
+		return "", errors.New("unterminated quoted value")
+	}
+	if rest := strings.TrimSpace(value[end+2:]); rest != "" && rest[0] != '#' {
+		return "", fmt.Errorf("unexpected %q after quoted value", rest)
+	}
+	return value[1 : end+1], nil
+}
+
+// envName converts a key like CallTimeout to CALL_TIMEOUT.
+func envName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func parseConfigDuration(value string, d *time.Duration) error {
+	if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+		*d = time.Duration(ms) * time.Millisecond
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	*d = parsed
+	return nil
+}
+
+func parseConfigBool(value string, b *bool) error {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		*b = true
+	case "false", "no", "off", "0":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %q", value)
+	}
+	return nil
+}
+
+func parseConfigFloat(value string, f *float64) error {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", value)
+	}
+	*f = parsed
+	return nil
+}
+
+// Options returns the options of NewManager for the settings. It fails with
+// the error of the option if a setting is invalid, e.g. a negative retry
+// delay.
+func (c *Config) Options() ([]Option, error) {
+	var opts []Option
+	switch {
+	case c.Bus == "session":
+		opts = append(opts, WithSessionBus())
+	case c.Bus == "auto":
+		opts = append(opts, WithAutoDetectBus())
+	case c.BusAddress != "":
+		opts = append(opts, WithBusAddress(c.BusAddress))
+	case c.PeerAddress != "":
+		opts = append(opts, WithPeerAddress(c.PeerAddress))
+	}
+	if c.Names != (Names{}) {
+		opts = append(opts, WithNames(c.Names))
+	}
+	if c.CallTimeout > 0 {
+		opts = append(opts, WithCallTimeout(c.CallTimeout))
+	}
+	if c.LazyConnect {
+		opts = append(opts, WithLazyConnect())
+	}
+	if c.Reconnect != nil {
+		opts = append(opts, WithAutoReconnect(*c.Reconnect))
+	}
+	if c.Retry != nil {
+		opts = append(opts, WithRetry(*c.Retry))
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
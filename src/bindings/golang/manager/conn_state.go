@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"sync"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// connStateBufferSize is the capacity of the channel returned by
+// ConnectionEvents.
+const connStateBufferSize = 16
+
+// ConnState is the state of the connection of a Manager to the controller.
+type ConnState int
+
+const (
+	// Connected means the controller is reachable.
+	Connected ConnState = iota
+	// Disconnected means the connection dropped or the controller left the
+	// bus.
+	Disconnected
+	// Reconnecting means the connection is being re-established.
+	Reconnecting
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	case Disconnected:
+		return "disconnected"
+	case Reconnecting:
+		return "reconnecting"
+	}
+	return "unknown"
+}
+
+// ConnectionEvents returns a new channel on which the state of the
+// connection to the controller is delivered, starting with the current
+// state and followed by each transition. Without auto-reconnect the only
+// transition is to Disconnected once the connection is lost. If the consumer
+// falls behind, the oldest states are dropped. The channel is closed after
+// Disconnected when the connection ends, i.e. by Close or when it is lost
+// without auto-reconnect. Each consumer should call ConnectionEvents once,
+// the channels are kept until the connection ends.
+func (m *Manager) ConnectionEvents() <-chan ConnState {
+	s, err := m.current()
+	if err != nil {
+		return closedStates()
+	}
+	return s.states.subscribe()
+}
+
+// State returns the current state of the connection to the controller, e.g.
+// to reject requests while it is not Connected.
+func (m *Manager) State() ConnState {
+	s, err := m.current()
+	if err != nil {
+		return Disconnected
+	}
+	return s.states.current()
+}
+
+func closedStates() <-chan ConnState {
+	ch := make(chan ConnState, 1)
+	ch <- Disconnected
+	close(ch)
+	return ch
+}
+
+// stateBroadcast delivers the state changes of a bus.Conn to all channels
+// returned by ConnectionEvents without ever blocking the connection.
+type stateBroadcast struct {
+	mu     sync.Mutex
+	state  ConnState
+	subs   []chan ConnState
+	closed bool
+}
+
+func newStateBroadcast() *stateBroadcast {
+	return &stateBroadcast{state: Connected}
+}
+
+func (b *stateBroadcast) subscribe() <-chan ConnState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return closedStates()
+	}
+	ch := make(chan ConnState, connStateBufferSize)
+	ch <- b.state
+	b.subs = append(b.subs, ch)
+	return ch
+}
+
+func (b *stateBroadcast) current() ConnState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *stateBroadcast) send(state bus.State) {
+	var cs ConnState
+	switch state {
+	case bus.StateConnected:
+		cs = Connected
+	case bus.StateDisconnected:
+		cs = Disconnected
+	case bus.StateReconnecting:
+		cs = Reconnecting
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed || b.state == cs {
+		return
+	}
+	b.state = cs
+	for _, ch := range b.subs {
+		deliverState(ch, cs)
+	}
+}
+
+func deliverState(ch chan ConnState, state ConnState) {
+	for {
+		select {
+		case ch <- state:
+			return
+		default:
+		}
+		// drop the oldest state to make room for the current one
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// close ends the broadcast, which is reported as Disconnected.
+func (b *stateBroadcast) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subs {
+		if b.state != Disconnected {
+			deliverState(ch, Disconnected)
+		}
+		close(ch)
+	}
+	b.state = Disconnected
+	b.subs = nil
+}
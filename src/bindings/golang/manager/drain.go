@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// RelocateFunc moves the named unit away from a drained node, e.g. by
+// starting it on another node, or back when the node is undrained.
+type RelocateFunc func(ctx context.Context, node string, unit string) error
+
+// DrainOption configures DrainNode and UndrainNode.
+type DrainOption func(*drainOptions)
+
+type drainOptions struct {
+	units    []string
+	patterns []string
+	relocate RelocateFunc
+	mode     string
+}
+
+// WithDrainUnits selects the named units to be stopped by DrainNode.
+func WithDrainUnits(units ...string) DrainOption {
+	return func(o *drainOptions) {
+		o.units = append(o.units, units...)
+	}
+}
+
+// WithDrainPattern selects the units whose name matches one of the glob
+// patterns, e.g. "app-*.service", to be stopped by DrainNode. See
+// monitor.Match for the syntax.
+func WithDrainPattern(patterns ...string) DrainOption {
+	return func(o *drainOptions) {
+		o.patterns = append(o.patterns, patterns...)
+	}
+}
+
+// WithRelocate calls fn for each unit stopped by DrainNode, after it was
+// stopped, and for each unit started again by UndrainNode, after it was
+// started, so that fn can run the unit elsewhere meanwhile.
+func WithRelocate(fn RelocateFunc) DrainOption {
+	return func(o *drainOptions) {
+		o.relocate = fn
+	}
+}
+
+// WithDrainJobMode sets the mode the stop and start jobs are queued with,
+// node.ModeReplace by default.
+func WithDrainJobMode(mode string) DrainOption {
+	return func(o *drainOptions) {
+		o.mode = mode
+	}
+}
+
+func newDrainOptions(opts []DrainOption) drainOptions {
+	o := drainOptions{mode: node.ModeReplace}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o drainOptions) selects(unit string) bool {
+	if slices.Contains(o.units, unit) {
+		return true
+	}
+	for _, pattern := range o.patterns {
+		if monitor.Match(pattern, unit) {
+			return true
+		}
+	}
+	return false
+}
+
+// DrainNode stops the units selected by WithDrainUnits and WithDrainPattern
+// which are active on the named node, e.g. before maintenance of the node,
+// and returns the names of the units it stopped. The Manager remembers them
+// until UndrainNode starts them again, draining a node once more adds to
+// them. Failing to stop a unit or to relocate it does not stop the drain,
+// the errors are joined.
+func (m *Manager) DrainNode(ctx context.Context, name string, opts ...DrainOption) ([]string, error) {
+	o := newDrainOptions(opts)
+	if len(o.units) == 0 && len(o.patterns) == 0 {
+		return nil, fmt.Errorf("failed to drain node %s: no units selected", name)
+	}
+
+	n, err := m.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	units, err := n.ListUnits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to drain node %s: %w", name, err)
+	}
+
+	var stopped []string
+	var errs []error
+	for _, u := range units {
+		if !o.selects(u.Name) || !u.ActiveState.IsActive() {
+			continue
+		}
+		if err := n.StopUnitAndWait(ctx, u.Name, o.mode); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		stopped = append(stopped, u.Name)
+		m.setDrained(name, u.Name, true)
+		if o.relocate != nil {
+			if err := o.relocate(ctx, name, u.Name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to relocate unit %s of node %s: %w", u.Name, name, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return stopped, fmt.Errorf("failed to drain node %s: %w", name, err)
+	}
+	return stopped, nil
+}
+
+// UndrainNode starts the units stopped by DrainNode on the named node again
+// and returns their names. Units which fail to start stay drained, so that
+// UndrainNode can be retried. Of the options only WithRelocate and
+// WithDrainJobMode apply.
+func (m *Manager) UndrainNode(ctx context.Context, name string, opts ...DrainOption) ([]string, error) {
+	o := newDrainOptions(opts)
+	units := m.DrainedUnits(name)
+	if len(units) == 0 {
+		return nil, nil
+	}
+
+	n, err := m.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var started []string
+	var errs []error
+	for _, unit := range units {
+		if err := n.StartUnitAndWait(ctx, unit, o.mode); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		started = append(started, unit)
+		m.setDrained(name, unit, false)
+		if o.relocate != nil {
+			if err := o.relocate(ctx, name, unit); err != nil {
+				errs = append(errs, fmt.Errorf("failed to relocate unit %s of node %s: %w", unit, name, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return started, fmt.Errorf("failed to undrain node %s: %w", name, err)
+	}
+	return started, nil
+}
+
+// DrainedUnits returns the names of the units stopped by DrainNode on the
+// named node which were not started again by UndrainNode, nil if the node
+// is not drained.
+func (m *Manager) DrainedUnits(name string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.drained[name])
+}
+
+func (m *Manager) setDrained(name string, unit string, drained bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	units := slices.DeleteFunc(m.drained[name], func(u string) bool { return u == unit })
+	if drained {
+		units = append(units, unit)
+	}
+	if len(units) == 0 {
+		delete(m.drained, name)
+		return
+	}
+	if m.drained == nil {
+		m.drained = make(map[string][]string)
+	}
+	m.drained[name] = units
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// jobModes are the modes systemd accepts for queueing unit jobs.
+var jobModes = []string{
+	node.ModeReplace, node.ModeFail, "isolate", "ignore-dependencies", "ignore-requirements",
+	"replace-irreversibly", "flush", "triggering", "restart-dependencies",
+}
+
+// WithDryRun validates the mutating calls issued by the Manager and the
+// proxies obtained from it instead of issuing them, e.g. to check
+// deployment scripts in CI against a live cluster. Reading calls, e.g.
+// ListUnits, are issued as usual. A mutating call succeeds in dry-run mode
+// if the node it is about is online, its job mode is known to systemd and
+// its unit is not reported as not found. Calls queueing jobs
+// reply a job which finishes with "done" immediately, so that waiting for
+// it works. The validated calls are logged and recorded, see
+// DryRunOperations. Hooks are called as without dry-run mode.
+func WithDryRun() Option {
+	return func(o *options) error {
+		o.dryRun = true
+		return nil
+	}
+}
+
+// DryRunOperations returns the operations validated in dry-run mode since
+// the Manager was created, in the order they were issued. Operations failing
+// validation are not included.
+func (m *Manager) DryRunOperations() []Operation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.dryRunOps)
+}
+
+// dryRun validates the call described by info and records it.
+func (m *Manager) dryRun(ctx context.Context, info bus.CallInfo) error {
+	s, err := m.session()
+	if err != nil {
+		return err
+	}
+	op := newOperation(info)
+	if err := validate(ctx, s.conn, op); err != nil {
+		return fmt.Errorf("dry run: %w", err)
+	}
+	bus.Logger(s.conn).InfoContext(ctx, "dry run", "method", op.Method, "path", op.Path, "node", op.Node, "unit", op.Unit)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dryRunOps = append(m.dryRunOps, op)
+	return nil
+}
+
+// validate checks the operations on nodes, the operations on the
+// controller always pass.
+func validate(ctx context.Context, conn common.Connection, op Operation) error {
+	if !strings.HasPrefix(op.Method, common.NODE_INTERFACE+".") {
+		return nil
+	}
+	n := node.New(conn, op.Node, op.Path)
+
+	status, err := n.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status != node.StatusOnline {
+		return fmt.Errorf("node %s: %w", op.Node, common.ErrNodeOffline)
+	}
+
+	switch op.Method {
+	case common.METHOD_START_UNIT, common.METHOD_STOP_UNIT, common.METHOD_RESTART_UNIT, common.METHOD_RELOAD_UNIT:
+		if mode, ok := stringArg(op.Args, 1); !ok || !slices.Contains(jobModes, mode) {
+			return fmt.Errorf("invalid job mode %q: %w", mode, common.ErrInvalidArgs)
+		}
+	}
+	if op.Unit == "" {
+		return nil
+	}
+
+	// the state stays empty for units which are not loaded, systemd loads
+	// them on demand
+	var state node.LoadState
+	v, err := n.GetUnitProperty(ctx, op.Unit, common.SYSTEMD_UNIT_INTERFACE, "LoadState")
+	switch {
+	case err == nil:
+		s, _ := v.(string)
+		state = node.LoadState(s)
+	case !errors.Is(err, common.ErrNoSuchUnit):
+		return err
+	}
+	if state == node.LoadStateNotFound {
+		return fmt.Errorf("unit %s on node %s: %w", op.Unit, op.Node, common.ErrNoSuchUnit)
+	}
+	return nil
+}
+
+func stringArg(args []interface{}, idx int) (string, bool) {
+	if idx >= len(args) {
+		return "", false
+	}
+	s, ok := args[idx].(string)
+	return s, ok
+}
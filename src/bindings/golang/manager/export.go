@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// StateVersion is the version of the ClusterState documents written by
+// ExportState. It is increased on incompatible changes of the document.
+const StateVersion = 1
+
+// ClusterState is a snapshot of the cluster taken by ExportState, e.g. for
+// support bundles or to compare the cluster before and after maintenance.
+// Nodes and units are sorted by name, so the JSON documents of two
+// snapshots can be compared line by line.
+type ClusterState struct {
+	// Version is the StateVersion the document was written with.
+	Version int `json:"version"`
+	// CapturedAt is the time the snapshot was taken.
+	CapturedAt time.Time `json:"capturedAt"`
+	// ControllerStatus is the Status property of the controller, e.g.
+	// common.SYSTEM_STATUS_UP.
+	ControllerStatus string `json:"controllerStatus"`
+	// Nodes are the nodes managed by the controller.
+	Nodes []NodeState `json:"nodes"`
+}
+
+// NodeState is a node in a ClusterState.
+type NodeState struct {
+	Name   string          `json:"name"`
+	Status node.NodeStatus `json:"status"`
+	PeerIP string          `json:"peerIP,omitempty"`
+	// Units are the units selected by the options of ExportState, none for
+	// offline nodes.
+	Units []UnitState `json:"units,omitempty"`
+}
+
+// UnitState is a unit in a ClusterState.
+type UnitState struct {
+	Name        string           `json:"name"`
+	LoadState   node.LoadState   `json:"loadState"`
+	ActiveState node.ActiveState `json:"activeState"`
+	SubState    node.SubState    `json:"subState"`
+}
+
+// ExportState takes a snapshot of the nodes, their statuses and the states
+// of the units selected by the options, e.g. WithPattern("*.service"), with
+// one call of the controller for the nodes and one for the units. Without
+// options the units are left out, as the document would list every unit of
+// every node otherwise.
+func (m *Manager) ExportState(ctx context.Context, opts ...ListUnitsOption) (*ClusterState, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export state: %w", err)
+	}
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export state: %w", err)
+	}
+	var units map[string][]node.UnitInfo
+	if len(opts) > 0 {
+		units, err = m.ListUnits(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export state: %w", err)
+		}
+	}
+
+	state := &ClusterState{
+		Version:          StateVersion,
+		CapturedAt:       time.Now().UTC(),
+		ControllerStatus: status,
+		Nodes:            make([]NodeState, 0, len(nodes)),
+	}
+	for _, n := range nodes {
+		ns := NodeState{Name: n.Name, Status: n.Status, PeerIP: n.PeerIP}
+		for _, u := range units[n.Name] {
+			ns.Units = append(ns.Units, UnitState{Name: u.Name, LoadState: u.LoadState, ActiveState: u.ActiveState, SubState: u.SubState})
+		}
+		sort.Slice(ns.Units, func(i, j int) bool { return ns.Units[i].Name < ns.Units[j].Name })
+		state.Nodes = append(state.Nodes, ns)
+	}
+	sort.Slice(state.Nodes, func(i, j int) bool { return state.Nodes[i].Name < state.Nodes[j].Name })
+	return state, nil
+}
+
+// ParseState decodes a JSON document written from a ClusterState. It fails
+// for documents of a later StateVersion.
+func ParseState(data []byte) (*ClusterState, error) {
+	var state ClusterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster state: %w", err)
+	}
+	if state.Version < 1 || state.Version > StateVersion {
+		return nil, fmt.Errorf("failed to parse cluster state: unsupported version %d", state.Version)
+	}
+	return &state, nil
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// ErrNoHealthyEndpoint is returned when none of the endpoints of a
+// MultiManager has a controller.
+var ErrNoHealthyEndpoint = errors.New("no healthy BlueChi controller endpoint")
+
+// Endpoint is a controller a MultiManager can connect to.
+type Endpoint struct {
+	// Address is the D-Bus address of the bus the controller is on, e.g.
+	// "unix:path=/run/bluechi/bus", or of the controller itself if Peer is
+	// set.
+	Address string
+	// Peer opens a direct peer-to-peer connection like WithPeerAddress.
+	Peer bool
+}
+
+// dial connects to the endpoint and checks that the controller named
+// service is reachable on it. The controller of a peer connection is the
+// peer itself.
+func (e Endpoint) dial(service string) (*dbus.Conn, error) {
+	if e.Peer {
+		return bus.DialPeer(e.Address)
+	}
+	conn, err := dbus.Connect(e.Address)
+	if err != nil {
+		return nil, err
+	}
+	if !hasOwner(conn, service) {
+		conn.Close()
+		return nil, fmt.Errorf("%s is not on the bus", service)
+	}
+	return conn, nil
+}
+
+// MultiManager is a Manager for a highly available controller deployment,
+// which connects to the first healthy of an ordered list of endpoints and
+// fails over to the next healthy one when the active controller becomes
+// unreachable. It is created by NewMultiManager.
+type MultiManager struct {
+	*Manager
+
+	endpoints []Endpoint
+	// active is the index of the endpoint of the current connection, -1
+	// while none is connected.
+	active atomic.Int64
+}
+
+// NewMultiManager returns a MultiManager for the controllers at endpoints,
+// which are tried in order: the endpoints of buses are healthy if the
+// controller is on the bus, the ones of peers if the connection can be
+// opened. NewMultiManager fails with an error matching ErrNoHealthyEndpoint
+// if none is healthy, unless WithLazyConnect is given.
+//
+// Auto-reconnect is always enabled, with DefaultBackoff unless
+// WithAutoReconnect is given. When the connection is lost or the controller
+// leaves its bus, the endpoints are tried in order again, so the first one
+// is preferred once it is back. Like after a reconnect, subscriptions and
+// monitors are registered again on the new controller and proxies stay
+// valid, while jobs pending at the time of the failover are not tracked any
+// further. Options selecting a bus are overridden by the endpoints.
+func NewMultiManager(endpoints []Endpoint, opts ...Option) (*MultiManager, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no controller endpoints")
+	}
+	for _, e := range endpoints {
+		if e.Address == "" {
+			return nil, errors.New("empty controller endpoint address")
+		}
+	}
+	mm := &MultiManager{endpoints: slices.Clone(endpoints)}
+	mm.active.Store(-1)
+
+	m, err := NewManager(append(slices.Clone(opts), mm.failover)...)
+	if err != nil {
+		return nil, err
+	}
+	mm.Manager = m
+	return mm, nil
+}
+
+// failover is the option making the Manager dial the endpoints.
+func (mm *MultiManager) failover(o *options) error {
+	if o.reconnect == nil {
+		b, err := DefaultBackoff.normalize("reconnect")
+		if err != nil {
+			return err
+		}
+		o.reconnect = &b
+	}
+	o.failover = true
+	o.dial = func() (*dbus.Conn, error) { return mm.dial(o.serviceName()) }
+	return nil
+}
+
+func (mm *MultiManager) dial(service string) (*dbus.Conn, error) {
+	var errs []error
+	for i, e := range mm.endpoints {
+		conn, err := e.dial(service)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Address, err))
+			continue
+		}
+		mm.active.Store(int64(i))
+		return conn, nil
+	}
+	mm.active.Store(-1)
+	return nil, fmt.Errorf("%w: %w", ErrNoHealthyEndpoint, errors.Join(errs...))
+}
+
+// Endpoints returns the endpoints in the order they are tried.
+func (mm *MultiManager) Endpoints() []Endpoint {
+	return slices.Clone(mm.endpoints)
+}
+
+// Active returns the endpoint of the controller the MultiManager is
+// connected to, false while it is connected to none, e.g. during a
+// failover.
+func (mm *MultiManager) Active() (Endpoint, bool) {
+	i := mm.active.Load()
+	if i < 0 || mm.State() != Connected {
+		return Endpoint{}, false
+	}
+	return mm.endpoints[i], true
+}
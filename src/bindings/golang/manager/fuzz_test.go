@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+)
+
+// FuzzDecodeNodes feeds the decoding of the nodes listed by the controller
+// with arbitrary replies, which must fail with an error rather than panic.
+func FuzzDecodeNodes(f *testing.F) {
+	type nodeInfo struct {
+		Name   string
+		Path   dbus.ObjectPath
+		Status string
+		PeerIP string
+	}
+	testbus.AddReply(f, []nodeInfo{
+		{"node_a", "/org/eclipse/bluechi/node/node_a", "online", "10.0.0.1"},
+		{"node_b", "/org/eclipse/bluechi/node/node_b", "offline", ""},
+	})
+	testbus.AddReply(f, []struct{ Name, Status string }{{"node_a", "online"}})
+	testbus.AddReply(f, []struct {
+		ID     uint32
+		Path   dbus.ObjectPath
+		Status string
+	}{{1, "/", "online"}})
+	testbus.AddReply(f, []string{"node_a"})
+	testbus.AddReply(f)
+
+	f.Fuzz(func(t *testing.T, sig string, data []byte) {
+		body, ok := testbus.DecodeReply(sig, data)
+		if !ok {
+			return
+		}
+		obj := bus.Object(&testbus.Reply{Body: body}, common.BC_DBUS_NAME, common.BC_OBJECT_PATH)
+		raw, err := bus.Call[[][]interface{}](context.Background(), obj, common.METHOD_LISTNODES)
+		if err != nil {
+			return
+		}
+		nodes, err := decodeNodes(raw)
+		if err == nil && len(nodes) != len(raw) {
+			t.Fatalf("expected %d nodes, got %d", len(raw), len(nodes))
+		}
+	})
+}
+
+// FuzzDecodeNodeUnits feeds the decoding of the units listed by the
+// controller with arbitrary replies.
+func FuzzDecodeNodeUnits(f *testing.F) {
+	type nodeUnit struct {
+		Node, Name, Description, LoadState, ActiveState, SubState, Followed string
+		ObjectPath                                                          dbus.ObjectPath
+		JobID                                                               uint32
+		JobType                                                             string
+		JobPath                                                             dbus.ObjectPath
+	}
+	unit := func(node, name string) nodeUnit {
+		return nodeUnit{node, name, "desc", "loaded", "active", "running", "", "/org/freedesktop/systemd1/unit/x", 0, "", "/"}
+	}
+	testbus.AddReply(f, []nodeUnit{unit("node_a", "a.service"), unit("node_b", "a.service"), unit("node_a", "b.service")})
+	testbus.AddReply(f, []struct{ Node, Name string }{{"node_a", "a.service"}})
+	testbus.AddReply(f, []struct{ Node uint32 }{{1}})
+	testbus.AddReply(f, map[string]string{"node_a": "a.service"})
+
+	f.Fuzz(func(t *testing.T, sig string, data []byte) {
+		body, ok := testbus.DecodeReply(sig, data)
+		if !ok {
+			return
+		}
+		obj := bus.Object(&testbus.Reply{Body: body}, common.BC_DBUS_NAME, common.BC_OBJECT_PATH)
+		raw, err := bus.Array(context.Background(), obj, common.METHOD_LISTUNITS)
+		if err != nil {
+			return
+		}
+		units, err := decodeNodeUnits(raw)
+		if err != nil {
+			return
+		}
+		count := 0
+		for _, u := range units {
+			count += len(u)
+		}
+		if count != len(raw) {
+			t.Fatalf("expected %d units, got %d", len(raw), count)
+		}
+	})
+}
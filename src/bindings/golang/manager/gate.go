@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// NodeGate admits the operations on nodes of concurrent batch and rolling
+// operations, so that limits given to one of them also account for the
+// others. Serial operations on a node exclude all others on the node and
+// the number of operations in flight can be bounded across all of them,
+// see WithPerNodeSerial and WithMaxInFlight. The zero value is ready to
+// use and admits everything.
+type NodeGate struct {
+	mu       sync.Mutex
+	inflight int
+	// active counts the operations per node, serial marks the nodes with
+	// a serial operation
+	active map[string]int
+	serial map[string]bool
+	// changed is closed and replaced whenever an operation leaves
+	changed chan struct{}
+}
+
+// Enter waits until an operation on the named node is admitted and returns
+// the function to call once it is done. A serial operation is admitted if
+// no other operation is active on the node, others if no serial one is.
+// With maxInFlight above zero, it is only admitted while fewer operations
+// of all nodes are in flight. Enter fails if ctx is done first.
+func (g *NodeGate) Enter(ctx context.Context, nodeName string, maxInFlight int, serial bool) (func(), error) {
+	for {
+		g.mu.Lock()
+		admitted := (maxInFlight < 1 || g.inflight < maxInFlight) &&
+			!g.serial[nodeName] && (!serial || g.active[nodeName] == 0)
+		if admitted {
+			if g.active == nil {
+				g.active = make(map[string]int)
+				g.serial = make(map[string]bool)
+			}
+			g.inflight++
+			g.active[nodeName]++
+			if serial {
+				g.serial[nodeName] = true
+			}
+			g.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(func() { g.leave(nodeName, serial) }) }, nil
+		}
+		if g.changed == nil {
+			g.changed = make(chan struct{})
+		}
+		changed := g.changed
+		g.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for node %s: %w", nodeName, ctx.Err())
+		}
+	}
+}
+
+func (g *NodeGate) leave(nodeName string, serial bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inflight--
+	if g.active[nodeName]--; g.active[nodeName] == 0 {
+		delete(g.active, nodeName)
+	}
+	if serial {
+		delete(g.serial, nodeName)
+	}
+	if g.changed != nil {
+		close(g.changed)
+		g.changed = nil
+	}
+}
+
+// InFlight returns the number of operations admitted and not done yet.
+func (g *NodeGate) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.inflight
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// DefaultHeartbeatInterval is the interval in which the agents send their
+// heartbeat to the controller unless configured otherwise with the
+// HeartbeatInterval option of the agent.
+const DefaultHeartbeatInterval = 2 * time.Second
+
+// healthEventBufferSize is the capacity of the channel returned by
+// HealthChecker.Events.
+const healthEventBufferSize = 16
+
+// HealthState is the health of a node as evaluated by a HealthChecker.
+type HealthState string
+
+// Health states of a node.
+const (
+	// Healthy nodes are online and sent a heartbeat within the stale
+	// threshold.
+	Healthy HealthState = "healthy"
+	// Degraded nodes are offline or missed their heartbeats for longer
+	// than the stale threshold.
+	Degraded HealthState = "degraded"
+)
+
+func (s HealthState) String() string { return string(s) }
+
+// HealthEvent reports the health of a node, once when the node is first
+// evaluated and then on each change.
+type HealthEvent struct {
+	// Node is the name of the node.
+	Node string
+	// State is the new health of the node.
+	State HealthState
+	// Status is the connection state of the node.
+	Status node.NodeStatus
+	// LastSeen is the time of the last heartbeat of the node, the zero time
+	// if the node has never been connected or is offline.
+	LastSeen time.Time
+}
+
+type healthOptions struct {
+	heartbeat time.Duration
+	interval  time.Duration
+	threshold time.Duration
+}
+
+// HealthOption configures a HealthChecker.
+type HealthOption func(*healthOptions) error
+
+// WithHeartbeatInterval sets the heartbeat interval configured for the
+// agents, DefaultHeartbeatInterval by default. Unless set explicitly, the
+// check interval is the heartbeat interval and the stale threshold three
+// heartbeat intervals.
+func WithHeartbeatInterval(interval time.Duration) HealthOption {
+	return func(o *healthOptions) error {
+		if interval <= 0 {
+			return errors.New("non-positive heartbeat interval")
+		}
+		o.heartbeat = interval
+		return nil
+	}
+}
+
+// WithCheckInterval sets how often the health of the nodes is evaluated.
+func WithCheckInterval(interval time.Duration) HealthOption {
+	return func(o *healthOptions) error {
+		if interval <= 0 {
+			return errors.New("non-positive check interval")
+		}
+		o.interval = interval
+		return nil
+	}
+}
+
+// WithStaleThreshold sets how long after its last heartbeat an online node
+// is considered degraded. The controller reports the last heartbeat in
+// seconds, so thresholds below a few seconds cause false alarms.
+func WithStaleThreshold(threshold time.Duration) HealthOption {
+	return func(o *healthOptions) error {
+		if threshold <= 0 {
+			return errors.New("non-positive stale threshold")
+		}
+		o.threshold = threshold
+		return nil
+	}
+}
+
+// HealthChecker periodically evaluates the health of all nodes from their
+// connection state and LastSeenTimestamp and reports the changes. Nodes
+// whose evaluation fails keep their previous health until the next check.
+type HealthChecker struct {
+	m      ManagerAPI
+	opts   healthOptions
+	states map[string]HealthState
+	events chan HealthEvent
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewHealthChecker returns a HealthChecker for the nodes of m. The first
+// check runs right away, the checker stops when ctx is done or Close is
+// called.
+func NewHealthChecker(ctx context.Context, m ManagerAPI, opts ...HealthOption) (*HealthChecker, error) {
+	o := healthOptions{heartbeat: DefaultHeartbeatInterval}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	if o.interval == 0 {
+		o.interval = o.heartbeat
+	}
+	if o.threshold == 0 {
+		o.threshold = 3 * o.heartbeat
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c := &HealthChecker{
+		m:      m,
+		opts:   o,
+		states: make(map[string]HealthState),
+		events: make(chan HealthEvent, healthEventBufferSize),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go c.run(ctx)
+	return c, nil
+}
+
+// Events returns the channel on which the health changes are delivered.
+// Checks wait while the consumer lags behind. The channel is closed when
+// the checker stops.
+func (c *HealthChecker) Events() <-chan HealthEvent {
+	return c.events
+}
+
+// Close stops the checker.
+func (c *HealthChecker) Close() {
+	c.cancel()
+	<-c.done
+}
+
+func (c *HealthChecker) run(ctx context.Context) {
+	defer close(c.done)
+	defer close(c.events)
+
+	ticker := time.NewTicker(c.opts.interval)
+	defer ticker.Stop()
+	for {
+		if !c.check(ctx) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check evaluates all nodes once and returns false if ctx is done.
+func (c *HealthChecker) check(ctx context.Context) bool {
+	nodes, err := c.m.ListNodes(ctx)
+	if err != nil {
+		return ctx.Err() == nil
+	}
+
+	seen := make(map[string]bool, len(nodes))
+	for _, info := range nodes {
+		seen[info.Name] = true
+		event, ok := c.evaluate(ctx, info)
+		if !ok || c.states[info.Name] == event.State {
+			continue
+		}
+		c.states[info.Name] = event.State
+		select {
+		case c.events <- event:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for name := range c.states {
+		if !seen[name] {
+			delete(c.states, name)
+		}
+	}
+	return ctx.Err() == nil
+}
+
+func (c *HealthChecker) evaluate(ctx context.Context, info NodeInfo) (HealthEvent, bool) {
+	event := HealthEvent{Node: info.Name, State: Degraded, Status: info.Status}
+	if !info.Status.IsOnline() {
+		return event, true
+	}
+	n, err := c.m.GetNode(ctx, info.Name)
+	if err != nil {
+		return HealthEvent{}, false
+	}
+	lastSeen, err := n.LastSeenTimestamp(ctx)
+	if err != nil {
+		return HealthEvent{}, false
+	}
+	event.LastSeen = lastSeen
+	if !lastSeen.IsZero() && time.Since(lastSeen) <= c.opts.threshold {
+		event.State = Healthy
+	}
+	return event, true
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// HeartbeatSettings are the heartbeat settings of the cluster.
+type HeartbeatSettings struct {
+	// AgentInterval is the interval in which the agents send their
+	// heartbeat, not above zero if they are disabled.
+	AgentInterval time.Duration
+	// ControllerInterval is the interval in which the controller checks
+	// the heartbeats of the nodes, zero if unknown.
+	ControllerInterval time.Duration
+	// NodeThreshold is how long the controller waits for the heartbeat of
+	// a node before it disconnects the node, zero if unknown or if it does
+	// not.
+	NodeThreshold time.Duration
+}
+
+// HeartbeatInterval returns the interval in which the controller checks
+// the heartbeats of the nodes. Controllers not exporting the
+// HeartbeatInterval property yet, which includes all released versions at
+// the time of writing, fail with common.ErrUnknownProperty. The interval
+// of the agents is read with agent.Agent.HeartbeatInterval.
+func (m *Manager) HeartbeatInterval(ctx context.Context) (time.Duration, error) {
+	return m.getMillisecondsProperty(ctx, "HeartbeatInterval")
+}
+
+// NodeHeartbeatThreshold returns how long the controller waits for the
+// heartbeat of a node before it disconnects the node, like
+// HeartbeatInterval.
+func (m *Manager) NodeHeartbeatThreshold(ctx context.Context) (time.Duration, error) {
+	return m.getMillisecondsProperty(ctx, "NodeHeartbeatThreshold")
+}
+
+// SetHeartbeatInterval changes the interval in which the controller checks
+// the heartbeats of the nodes at runtime, in whole milliseconds. It fails
+// with common.ErrUnknownProperty or common.ErrPropertyReadOnly for
+// controllers which do not support changing it.
+func (m *Manager) SetHeartbeatInterval(ctx context.Context, interval time.Duration) error {
+	return m.setMillisecondsProperty(ctx, "HeartbeatInterval", interval)
+}
+
+// SetNodeHeartbeatThreshold changes how long the controller waits for the
+// heartbeat of a node at runtime, like SetHeartbeatInterval.
+func (m *Manager) SetNodeHeartbeatThreshold(ctx context.Context, threshold time.Duration) error {
+	return m.setMillisecondsProperty(ctx, "NodeHeartbeatThreshold", threshold)
+}
+
+// HeartbeatSettings returns the heartbeat settings reported by the
+// controller, leaving those it does not report zero. The controller does
+// not know the interval of the agents, AgentInterval is
+// DefaultHeartbeatInterval.
+func (m *Manager) HeartbeatSettings(ctx context.Context) (HeartbeatSettings, error) {
+	s := HeartbeatSettings{AgentInterval: DefaultHeartbeatInterval}
+	var err error
+	if s.ControllerInterval, err = m.HeartbeatInterval(ctx); err != nil && !errors.Is(err, common.ErrUnknownProperty) {
+		return HeartbeatSettings{}, err
+	}
+	if s.NodeThreshold, err = m.NodeHeartbeatThreshold(ctx); err != nil && !errors.Is(err, common.ErrUnknownProperty) {
+		return HeartbeatSettings{}, err
+	}
+	return s, nil
+}
+
+// CheckStaleThreshold returns a warning for each way in which the stale
+// threshold of a HealthChecker is inconsistent with the heartbeat settings
+// of the cluster, none if it is consistent, e.g. to log them on startup.
+func CheckStaleThreshold(threshold time.Duration, s HeartbeatSettings) []string {
+	var warnings []string
+	if s.AgentInterval <= 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"heartbeats of the agents are disabled, online nodes are degraded %s after they connected", threshold))
+	} else if threshold < 2*s.AgentInterval {
+		warnings = append(warnings, fmt.Sprintf(
+			"stale threshold %s is below two heartbeat intervals of %s, a single late heartbeat degrades a node",
+			threshold, s.AgentInterval))
+	}
+	// the controller reports the last heartbeat in seconds
+	if threshold < 2*time.Second {
+		warnings = append(warnings, fmt.Sprintf(
+			"stale threshold %s is below the resolution of the last heartbeat of 1s", threshold))
+	}
+	if s.NodeThreshold > 0 && threshold >= s.NodeThreshold+s.ControllerInterval {
+		warnings = append(warnings, fmt.Sprintf(
+			"stale threshold %s is not below the node heartbeat threshold %s, nodes are disconnected before they are degraded",
+			threshold, s.NodeThreshold))
+	}
+	return warnings
+}
+
+// CheckStaleThreshold compares the stale threshold of a HealthChecker with
+// the heartbeat settings of the controller, assuming the agents use
+// DefaultHeartbeatInterval, see the function CheckStaleThreshold.
+func (m *Manager) CheckStaleThreshold(ctx context.Context, threshold time.Duration) ([]string, error) {
+	s, err := m.HeartbeatSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CheckStaleThreshold(threshold, s), nil
+}
+
+func (m *Manager) getMillisecondsProperty(ctx context.Context, name string) (time.Duration, error) {
+	v, err := m.getProperty(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	d, err := variant.Milliseconds(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode controller property %s: %w", name, err)
+	}
+	return d, nil
+}
+
+func (m *Manager) setMillisecondsProperty(ctx context.Context, name string, d time.Duration) error {
+	s, err := m.session()
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("failed to set controller property %s: negative duration %s: %w", name, d, common.ErrInvalidArgs)
+	}
+	if err := bus.SetProperty(ctx, s.obj, common.CONTROLLER_INTERFACE, name, uint64(d.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to set controller property %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Labels are key value pairs attached to a node, e.g. tier=edge. BlueChi
+// has no notion of labels, they are kept by the client in a LabelStore.
+type Labels map[string]string
+
+// LabelStore persists the labels of the nodes. Implementations must be safe
+// for concurrent use.
+type LabelStore interface {
+	// Labels returns the labels of all nodes which have labels.
+	Labels(ctx context.Context) (map[string]Labels, error)
+	// SetLabels replaces the labels of the named node, an empty set
+	// removes them.
+	SetLabels(ctx context.Context, node string, labels Labels) error
+}
+
+// MemoryLabelStore keeps the labels in memory. It is the store of a Manager
+// unless WithLabelStore is given.
+type MemoryLabelStore struct {
+	mu     sync.RWMutex
+	labels map[string]Labels
+}
+
+// NewMemoryLabelStore returns an empty MemoryLabelStore.
+func NewMemoryLabelStore() *MemoryLabelStore {
+	return &MemoryLabelStore{labels: make(map[string]Labels)}
+}
+
+// Labels returns a copy of the labels of all nodes.
+func (s *MemoryLabelStore) Labels(ctx context.Context) (map[string]Labels, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyLabels(s.labels), nil
+}
+
+// SetLabels replaces the labels of the named node.
+func (s *MemoryLabelStore) SetLabels(ctx context.Context, node string, labels Labels) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(labels) == 0 {
+		delete(s.labels, node)
+	} else {
+		s.labels[node] = maps.Clone(labels)
+	}
+	return nil
+}
+
+// FileLabelStore keeps the labels in a JSON file mapping node names to
+// their labels, so that they survive restarts of the client. The file is
+// read on each call and replaced atomically on each change.
+type FileLabelStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileLabelStore returns a FileLabelStore for the file at path, which is
+// created on the first change if it does not exist.
+func NewFileLabelStore(path string) *FileLabelStore {
+	return &FileLabelStore{path: path}
+}
+
+// Path returns the path of the file.
+func (s *FileLabelStore) Path() string {
+	return s.path
+}
+
+// Labels reads the labels of all nodes from the file.
+func (s *FileLabelStore) Labels(ctx context.Context) (map[string]Labels, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// SetLabels replaces the labels of the named node in the file.
+func (s *FileLabelStore) SetLabels(ctx context.Context, node string, labels Labels) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		delete(all, node)
+	} else {
+		all[node] = labels
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode labels: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	return nil
+}
+
+func (s *FileLabelStore) read() (map[string]Labels, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]Labels), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+	all := make(map[string]Labels)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to decode labels in %s: %w", s.path, err)
+	}
+	return all, nil
+}
+
+func copyLabels(labels map[string]Labels) map[string]Labels {
+	c := make(map[string]Labels, len(labels))
+	for name, l := range labels {
+		c[name] = maps.Clone(l)
+	}
+	return c
+}
+
+// labelOp is the operator of a requirement of a Selector.
+type labelOp int
+
+const (
+	opEquals labelOp = iota
+	opNotEquals
+	opExists
+	opNotExists
+)
+
+type requirement struct {
+	key   string
+	op    labelOp
+	value string
+}
+
+func (r requirement) matches(labels Labels) bool {
+	value, ok := labels[r.key]
+	switch r.op {
+	case opEquals:
+		return ok && value == r.value
+	case opNotEquals:
+		return !ok || value != r.value
+	case opExists:
+		return ok
+	default:
+		return !ok
+	}
+}
+
+func (r requirement) String() string {
+	switch r.op {
+	case opEquals:
+		return r.key + "=" + r.value
+	case opNotEquals:
+		return r.key + "!=" + r.value
+	case opExists:
+		return r.key
+	default:
+		return "!" + r.key
+	}
+}
+
+// Selector selects nodes by their labels. It is a comma separated list of
+// requirements, all of which must be met: key=value (or key==value),
+// key!=value, which includes nodes lacking the label, key for nodes having
+// the label and !key for nodes lacking it. The empty selector selects all
+// nodes.
+type Selector struct {
+	reqs []requirement
+}
+
+// ParseSelector parses a selector like "tier=edge,region!=eu".
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if strings.TrimSpace(s) == "" {
+				break
+			}
+			return Selector{}, fmt.Errorf("invalid selector %q: empty requirement", s)
+		}
+
+		var r requirement
+		switch {
+		case strings.Contains(part, "!="):
+			r.key, r.value, _ = strings.Cut(part, "!=")
+			r.op = opNotEquals
+		case strings.Contains(part, "=="):
+			r.key, r.value, _ = strings.Cut(part, "==")
+		case strings.Contains(part, "="):
+			r.key, r.value, _ = strings.Cut(part, "=")
+		case strings.HasPrefix(part, "!"):
+			r.key, r.op = part[1:], opNotExists
+		default:
+			r.key, r.op = part, opExists
+		}
+		r.key, r.value = strings.TrimSpace(r.key), strings.TrimSpace(r.value)
+		if r.key == "" {
+			return Selector{}, fmt.Errorf("invalid selector %q: empty label key", s)
+		}
+		sel.reqs = append(sel.reqs, r)
+	}
+	return sel, nil
+}
+
+// Matches reports whether labels meet all requirements of the selector.
+func (s Selector) Matches(labels Labels) bool {
+	for _, r := range s.reqs {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	parts := make([]string, len(s.reqs))
+	for idx, r := range s.reqs {
+		parts[idx] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// SetNodeLabels replaces the labels of the named node in the label store
+// of the Manager. The node does not need to be known to the controller,
+// e.g. to label nodes before they are added.
+func (m *Manager) SetNodeLabels(ctx context.Context, name string, labels Labels) error {
+	if err := m.labelStore().SetLabels(ctx, name, labels); err != nil {
+		return fmt.Errorf("failed to set labels of node %s: %w", name, err)
+	}
+	return nil
+}
+
+// NodeLabels returns the labels of the named node, nil if it has none.
+func (m *Manager) NodeLabels(ctx context.Context, name string) (Labels, error) {
+	all, err := m.labelStore().Labels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels of node %s: %w", name, err)
+	}
+	return all[name], nil
+}
+
+// NodesBySelector returns the names of the nodes of the controller whose
+// labels match selector, see ParseSelector, in the order of ListNodes. The
+// names can be passed to the batch operations like StartUnitOnNodes or to
+// WithNodes.
+func (m *Manager) NodesBySelector(ctx context.Context, selector string) ([]string, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	all, err := m.labelStore().Labels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select nodes: %w", err)
+	}
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if sel.Matches(all[n.Name]) {
+			names = append(names, n.Name)
+		}
+	}
+	return names, nil
+}
+
+func (m *Manager) labelStore() LabelStore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.opts == nil {
+		o := defaultOptions()
+		m.opts = &o
+	}
+	return m.opts.labels
+}
@@ -0,0 +1,736 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package manager provides Go bindings for the public D-Bus API of the
+// BlueChi controller.
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// ErrNotConnected is returned when a method is called on a Manager which is
+// not connected, i.e. before Connect succeeded or after Close.
+var ErrNotConnected = errors.New("not connected to the BlueChi controller")
+
+// ErrShuttingDown is returned by the calls and job waits started while the
+// Manager is shut down by Shutdown.
+var ErrShuttingDown = bus.ErrShuttingDown
+
+// ErrDisconnected is returned by the calls issued while a Manager using
+// WithAutoReconnect has lost the connection to the controller and not
+// re-established it yet.
+var ErrDisconnected = bus.ErrDisconnected
+
+// ErrRateLimited is matched, together with the context error, by the errors
+// of the calls of a Manager using WithRateLimit whose context expires before
+// they get their turn.
+var ErrRateLimited = bus.ErrRateLimited
+
+// Manager is a client for the BlueChi controller. It is created by
+// NewManager and its methods are safe for concurrent use by multiple
+// goroutines.
+type Manager struct {
+	mu     sync.RWMutex
+	opts   *options
+	sess   *session
+	closed bool
+
+	// drained are the units stopped by DrainNode keyed by node name.
+	drained map[string][]string
+	// dryRunOps are the operations validated in dry-run mode.
+	dryRunOps []Operation
+
+	// overflows counts the events lost on the channels of all sessions.
+	overflows atomic.Uint64
+	// nodeHits, nodeMisses and nodeInvalidations count the lookups and
+	// removals of the node proxies cached by the sessions.
+	nodeHits          atomic.Uint64
+	nodeMisses        atomic.Uint64
+	nodeInvalidations atomic.Uint64
+
+	// gate admits the operations of batch and rolling operations on nodes.
+	gate NodeGate
+}
+
+// session is the state of a single connection established by Connect. It
+// is never modified after Connect, except for the bookkeeping guarded by
+// its mutex, so methods can use it without holding the lock of the
+// Manager.
+type session struct {
+	conn   *bus.Conn
+	obj    dbus.BusObject
+	states *stateBroadcast
+
+	// nodes caches the proxies returned by GetNode.
+	nodes nodeCache
+
+	mu            sync.Mutex
+	unhookMetrics func()
+}
+
+// session returns the session for issuing a call. With WithLazyConnect the
+// connection is opened on demand, otherwise ErrNotConnected is returned if
+// there is no session.
+func (m *Manager) session() (*session, error) {
+	m.mu.RLock()
+	s := m.sess
+	lazy := m.opts != nil && m.opts.lazy && !m.closed
+	m.mu.RUnlock()
+
+	if lazy && (s == nil || s.conn.Context().Err() != nil) {
+		if err := m.Connect(); err != nil {
+			return nil, err
+		}
+		return m.current()
+	}
+	if s == nil {
+		return nil, ErrNotConnected
+	}
+	return s, nil
+}
+
+// current returns the current session without connecting.
+func (m *Manager) current() (*session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.sess == nil {
+		return nil, ErrNotConnected
+	}
+	return m.sess, nil
+}
+
+// NodeInfo describes a node managed by BlueChi as reported by ListNodes.
+type NodeInfo struct {
+	// Name is the name of the node.
+	Name string
+	// ObjectPath is the path of the node object on the controller.
+	ObjectPath dbus.ObjectPath
+	// Status is the connection state of the node, either online or offline.
+	Status node.NodeStatus
+	// PeerIP is the IP address of the connected agent. It is only set by
+	// controller versions that report it.
+	PeerIP string
+}
+
+// MarshalJSON encodes the node info with lower camel case keys, omitting
+// an unknown peer IP.
+func (i NodeInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name       string          `json:"name"`
+		ObjectPath dbus.ObjectPath `json:"objectPath"`
+		Status     node.NodeStatus `json:"status"`
+		PeerIP     string          `json:"peerIP,omitempty"`
+	}{i.Name, i.ObjectPath, i.Status, i.PeerIP})
+}
+
+// NewManager validates the given options and returns a Manager for the
+// controller. Without options the controller is expected on the system bus.
+// The connection is opened right away, unless WithLazyConnect is given.
+func NewManager(opts ...Option) (*Manager, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+
+	m := &Manager{opts: &o}
+	if o.lazy {
+		return m, nil
+	}
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Connect opens the connection to the controller configured by the options
+// passed to NewManager. Calling Connect on a connected Manager is a no-op,
+// after Close it opens a new connection.
+func (m *Manager) Connect() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sess != nil && m.sess.conn.Context().Err() == nil {
+		return nil
+	}
+	if m.opts == nil {
+		o := defaultOptions()
+		m.opts = &o
+	}
+
+	var dryRun bus.DryRun
+	if m.opts.dryRun {
+		dryRun = m.dryRun
+	}
+	states := newStateBroadcast()
+	conn, err := bus.Open(bus.Config{
+		Dial:        m.opts.dial,
+		Service:     m.opts.serviceName(),
+		Names:       m.opts.names,
+		Tracer:      m.opts.tracer,
+		Intercept:   interceptor(m.opts.hooks),
+		DryRun:      dryRun,
+		Flags:       m.opts.flags,
+		Strict:      m.opts.strict,
+		Reconnect:   m.opts.reconnect,
+		Failover:    m.opts.failover,
+		OnState:     states.send,
+		Logger:      m.opts.logger,
+		CallTimeout: m.opts.callTimeout,
+		Retry:       m.opts.retry,
+		Breaker:     m.opts.breaker,
+		RateLimit:   m.opts.rateLimit,
+		Overflow:    m.opts.overflow,
+		Overflows:   &m.overflows,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to bus: %w", err)
+	}
+
+	s := &session{
+		conn:   conn,
+		obj:    conn.Object(common.BC_DBUS_NAME, common.BC_OBJECT_PATH),
+		states: states,
+	}
+	// BlueChi emits no signal for removed nodes, which takes a restart of
+	// the controller, so all proxies are dropped once it is back
+	bus.OnRestore(conn, func(context.Context) { m.invalidateNodes(s, nil) })
+	m.sess = s
+	bus.Go(conn, func() {
+		// the connection also ends when lost without auto-reconnect
+		<-conn.Context().Done()
+		states.close()
+	})
+	m.closed = false
+	return nil
+}
+
+// Close closes the connection to the controller. All subscriptions, monitors
+// and job trackers using the connection are ended and their channels are
+// closed. Monitors are closed on the controller as well. Closing a Manager
+// that is not connected is a no-op, Connect can be used to reconnect. Close
+// also stops reconnecting if auto-reconnect is enabled and connecting on
+// demand if WithLazyConnect is given.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	s := m.sess
+	m.sess = nil
+	m.closed = true
+	m.mu.Unlock()
+
+	if s == nil {
+		return nil
+	}
+	s.states.close()
+	if err := s.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close connection: %w", err)
+	}
+	return nil
+}
+
+// Shutdown closes the Manager gracefully, e.g. when a service embedding the
+// bindings receives SIGTERM. Calls and job waits started afterwards fail
+// with ErrShuttingDown, while the ones in flight, including the
+// asynchronous job calls of nodes, are waited for until ctx is done. The
+// connection is closed then like by Close, and Shutdown returns once the
+// channels fed by its signals, e.g. the Events of monitors and job trackers,
+// are closed. If ctx is done first, the connection is closed right away and
+// the error matches the error of ctx.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	s := m.sess
+	m.closed = true
+	m.mu.Unlock()
+
+	if s == nil {
+		return nil
+	}
+	err := s.conn.Shutdown(ctx)
+	m.mu.Lock()
+	if m.sess == s {
+		m.sess = nil
+	}
+	m.mu.Unlock()
+	s.states.close()
+	if err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	return nil
+}
+
+// OverflowCount returns the number of events lost on the channels of the
+// Manager and of the proxies obtained from it because their consumer
+// lagged behind, see WithEventOverflow. It stays zero with OverflowBlock.
+func (m *Manager) OverflowCount() uint64 {
+	return m.overflows.Load()
+}
+
+// NodeGate returns the gate admitting the operations of the batch
+// operations of the Manager and of rolling operations built on it, see
+// WithPerNodeSerial and WithMaxInFlight.
+func (m *Manager) NodeGate() *NodeGate {
+	return &m.gate
+}
+
+// ListNodes returns all nodes managed by BlueChi regardless if they are
+// online or offline, in the order of the controller unless WithNodeOrder is
+// given.
+func (m *Manager) ListNodes(ctx context.Context, opts ...ListNodesOption) ([]NodeInfo, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, _, err := listNodes(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return OrderNodes(nodes, opts...), nil
+}
+
+// listNodes lists the nodes on the controller of s and returns them with
+// the sequence of the reply, see bus.CallSequence.
+func listNodes(ctx context.Context, s *session) ([]NodeInfo, dbus.Sequence, error) {
+	raw, seq, err := bus.CallSequence[[][]interface{}](ctx, s.obj, common.METHOD_LISTNODES)
+	if err != nil {
+		return nil, dbus.NoSequence, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodes, err := decodeNodes(raw)
+	if err != nil {
+		return nil, dbus.NoSequence, err
+	}
+	return nodes, seq, nil
+}
+
+// GetNode resolves the named node on the controller and returns a proxy
+// for its org.eclipse.bluechi.Node interface. The proxy is cached, so later
+// calls for the same node issue no call until the connection to the
+// controller is restored or InvalidateNodes removes it.
+func (m *Manager) GetNode(ctx context.Context, name string) (*node.Node, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	if n, ok := s.nodes.get(name); ok {
+		m.nodeHits.Add(1)
+		return n, nil
+	}
+	m.nodeMisses.Add(1)
+
+	path, err := bus.Call[dbus.ObjectPath](ctx, s.obj, common.METHOD_GETNODE, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+	n := node.New(s.conn, name, path)
+	s.nodes.put(name, n)
+	return n, nil
+}
+
+// CreateMonitor creates a new monitor on the controller. Subscriptions can
+// be added to the returned monitor, which delivers the matching unit events
+// on its Events channel until it is closed.
+func (m *Manager) CreateMonitor(ctx context.Context) (*monitor.Monitor, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := bus.Call[dbus.ObjectPath](ctx, s.obj, common.METHOD_CREATE_MONITOR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create monitor: %w", err)
+	}
+	return monitor.New(s.conn, path)
+}
+
+// AttachMonitor returns a proxy for the monitor at path created by another
+// client, which added this Manager as peer with monitor.Monitor.AddPeer,
+// passing the name returned by UniqueName. See monitor.Attach.
+func (m *Manager) AttachMonitor(path dbus.ObjectPath) (*monitor.Monitor, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return monitor.Attach(s.conn, path)
+}
+
+// UniqueName returns the unique name of the connection on the bus, which
+// identifies this Manager as peer of a monitor. The name changes when the
+// connection is re-established by auto-reconnect.
+func (m *Manager) UniqueName() (string, error) {
+	s, err := m.session()
+	if err != nil {
+		return "", err
+	}
+	raw := s.conn.Current()
+	if raw == nil {
+		return "", ErrDisconnected
+	}
+	names := raw.Names()
+	if len(names) == 0 || names[0] == "" {
+		return "", fmt.Errorf("connection has no unique name")
+	}
+	return names[0], nil
+}
+
+// GetJob returns a proxy for the job object at path on the controller.
+func (m *Manager) GetJob(path dbus.ObjectPath) (*job.Job, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return job.New(s.conn, path), nil
+}
+
+// ListJobs returns all jobs currently queued or running on the controller.
+func (m *Manager) ListJobs(ctx context.Context) ([]job.Info, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+
+	// the controller has no method listing jobs, but exports each job as a
+	// child of the job object path prefix
+	prefix := dbus.ObjectPath(common.JOB_OBJECT_PATH_PREFIX)
+	tree, err := common.Introspect(ctx, s.conn.Object(common.BC_DBUS_NAME, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	jobs := make([]job.Info, 0, len(tree.Children))
+	for _, child := range tree.Children {
+		path := dbus.ObjectPath(string(prefix) + "/" + child.Name)
+		info, err := job.New(s.conn, path).Info(ctx)
+		if err != nil {
+			// the job finished in the meantime
+			continue
+		}
+		jobs = append(jobs, info)
+	}
+	return jobs, nil
+}
+
+// Introspect returns the introspection data of the controller object, e.g.
+// to check whether the controller supports a method:
+//
+//	i, err := m.Introspect(ctx)
+//	if err == nil && i.HasMethod(common.CONTROLLER_INTERFACE, "EnableMetrics") {
+//		...
+//	}
+//
+// The names of the children are the first elements of the object paths
+// below the controller object, e.g. node, job and monitor.
+func (m *Manager) Introspect(ctx context.Context) (common.Introspection, error) {
+	s, err := m.session()
+	if err != nil {
+		return common.Introspection{}, err
+	}
+	return common.Introspect(ctx, s.obj)
+}
+
+// TrackJobs returns a tracker delivering the JobNew and JobRemoved signals
+// of the controller. The caller has to close the tracker.
+func (m *Manager) TrackJobs() (*job.Tracker, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return job.NewTracker(s.conn)
+}
+
+// WaitForJob blocks until the job at path has finished and returns its
+// result, one of "done", "failed", "cancelled", "timeout", "dependency" or
+// "skipped".
+func (m *Manager) WaitForJob(ctx context.Context, path dbus.ObjectPath) (string, error) {
+	s, err := m.session()
+	if err != nil {
+		return "", err
+	}
+	return job.Wait(ctx, s.conn, path)
+}
+
+// SetLogLevel changes the log level of the controller at runtime, e.g. to
+// common.LOG_LEVEL_DEBUG.
+func (m *Manager) SetLogLevel(ctx context.Context, level string) error {
+	s, err := m.session()
+	if err != nil {
+		return err
+	}
+
+	err = bus.Exec(ctx, s.obj, common.METHOD_SET_LOG_LEVEL, level)
+	if err != nil {
+		return fmt.Errorf("failed to set log level of controller to %s: %w", level, err)
+	}
+	return nil
+}
+
+// EnableMetrics enables collecting performance metrics on the controller
+// and all agents. With auto-reconnect, metrics are enabled again after the
+// controller restarted.
+func (m *Manager) EnableMetrics(ctx context.Context) error {
+	s, err := m.session()
+	if err != nil {
+		return err
+	}
+
+	err = bus.Exec(ctx, s.obj, common.METHOD_ENABLE_METRICS)
+	if err != nil {
+		return fmt.Errorf("failed to enable metrics: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unhookMetrics == nil {
+		s.unhookMetrics = bus.OnRestore(s.conn, func(ctx context.Context) {
+			if err := bus.Exec(ctx, s.obj, common.METHOD_ENABLE_METRICS); err != nil {
+				bus.Logger(s.conn).Error("failed to enable metrics again", "error", err)
+			}
+		})
+	}
+	return nil
+}
+
+// DisableMetrics disables collecting performance metrics.
+func (m *Manager) DisableMetrics(ctx context.Context) error {
+	s, err := m.session()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.unhookMetrics != nil {
+		s.unhookMetrics()
+		s.unhookMetrics = nil
+	}
+	s.mu.Unlock()
+	err = bus.Exec(ctx, s.obj, common.METHOD_DISABLE_METRICS)
+	if err != nil {
+		return fmt.Errorf("failed to disable metrics: %w", err)
+	}
+	return nil
+}
+
+// SubscribeMetrics returns a channel on which the metrics signals of the
+// controller are delivered. Metrics are only emitted after EnableMetrics
+// has been called. The subscription ends and the channel is closed when ctx
+// is done or the Manager is closed.
+func (m *Manager) SubscribeMetrics(ctx context.Context) (<-chan metrics.Event, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	return metrics.Subscribe(ctx, s.conn)
+}
+
+// ListUnits returns all loaded systemd units on all nodes which are online,
+// keyed by node name. The options restrict the units returned, which is
+// done on the client except for WithNodes. With options restricting the
+// units, nodes without matching units are omitted. WithUnitOrder sorts the
+// units of each node.
+func (m *Manager) ListUnits(ctx context.Context, opts ...ListUnitsOption) (map[string][]node.UnitInfo, error) {
+	f := newUnitFilter(opts)
+	if len(f.nodes) > 0 {
+		units, err := m.listNodeUnits(ctx, f.nodes)
+		if err != nil {
+			return nil, err
+		}
+		units = f.apply(units)
+		f.sort(units)
+		return units, nil
+	}
+
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := bus.Array(ctx, s.obj, common.METHOD_LISTUNITS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list units: %w", err)
+	}
+	units, err := decodeNodeUnits(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list units: %w", err)
+	}
+	if !f.empty() {
+		units = f.apply(units)
+	}
+	f.sort(units)
+	return units, nil
+}
+
+// listNodeUnits returns the units of the named nodes which are online,
+// unknown nodes are skipped.
+func (m *Manager) listNodeUnits(ctx context.Context, names []string) (map[string][]node.UnitInfo, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make(map[string][]node.UnitInfo)
+	for _, n := range nodes {
+		if !n.Status.IsOnline() || !contains(names, n.Name) {
+			continue
+		}
+		nodeUnits, err := node.New(s.conn, n.Name, n.ObjectPath).ListUnits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		units[n.Name] = nodeUnits
+	}
+	return units, nil
+}
+
+// ListUnitsFunc calls fn with each loaded systemd unit on the nodes which
+// are online and the name of its node, until fn returns false. The options
+// restrict the units as for ListUnits. Unlike ListUnits it builds no map of
+// all units, e.g. for clusters with thousands of units per node of which
+// only a few are of interest.
+func (m *Manager) ListUnitsFunc(ctx context.Context, fn func(nodeName string, u node.UnitInfo) bool, opts ...ListUnitsOption) error {
+	f := newUnitFilter(opts)
+	if len(f.nodes) > 0 {
+		return m.listNodeUnitsFunc(ctx, f, fn)
+	}
+
+	s, err := m.session()
+	if err != nil {
+		return err
+	}
+	err = bus.Each(ctx, s.obj, common.METHOD_LISTUNITS, func(fields []interface{}) (bool, error) {
+		nodeName, err := unitNode(fields)
+		if err != nil {
+			return false, err
+		}
+		u, err := node.DecodeUnitInfo(fields[1:])
+		if err != nil {
+			return false, err
+		}
+		return !f.matches(u) || fn(nodeName, u), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list units: %w", err)
+	}
+	return nil
+}
+
+// listNodeUnitsFunc is ListUnitsFunc for the nodes selected by f.
+func (m *Manager) listNodeUnitsFunc(ctx context.Context, f unitFilter, fn func(nodeName string, u node.UnitInfo) bool) error {
+	s, err := m.session()
+	if err != nil {
+		return err
+	}
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if !n.Status.IsOnline() || !f.matchesNode(n.Name) {
+			continue
+		}
+		stopped := false
+		err := node.New(s.conn, n.Name, n.ObjectPath).ListUnitsFunc(ctx, func(u node.UnitInfo) bool {
+			if !f.matches(u) {
+				return true
+			}
+			stopped = !fn(n.Name, u)
+			return !stopped
+		})
+		if err != nil || stopped {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeNodeUnits decodes the units listed by the controller, each a
+// struct of the node name and the fields of node.DecodeUnitInfo. The units
+// of all nodes share a single slice, which is carved up by node after
+// counting them, rather than growing a slice per node.
+func decodeNodeUnits(raw [][]interface{}) (map[string][]node.UnitInfo, error) {
+	counts := make(map[string]int)
+	for _, fields := range raw {
+		name, err := unitNode(fields)
+		if err != nil {
+			return nil, err
+		}
+		counts[name]++
+	}
+
+	all := make([]node.UnitInfo, 0, len(raw))
+	units := make(map[string][]node.UnitInfo, len(counts))
+	for _, fields := range raw {
+		name := fields[0].(string)
+		nodeUnits, ok := units[name]
+		if !ok {
+			// the capacity is limited, so appending to the units of a node
+			// does not overwrite those of the next one
+			n := len(all)
+			all = all[:n+counts[name]]
+			nodeUnits = all[n:n:len(all)]
+		}
+		u, err := node.DecodeUnitInfo(fields[1:])
+		if err != nil {
+			return nil, err
+		}
+		units[name] = append(nodeUnits, u)
+	}
+	return units, nil
+}
+
+// unitNode returns the node name of a unit listed by the controller.
+func unitNode(fields []interface{}) (string, error) {
+	if len(fields) == 0 {
+		return "", errors.New("failed to decode unit: no fields")
+	}
+	name, ok := fields[0].(string)
+	if !ok {
+		return "", fmt.Errorf("failed to decode unit: invalid node %v", fields[0])
+	}
+	return name, nil
+}
+
+func decodeNodes(raw [][]interface{}) ([]NodeInfo, error) {
+	nodes := make([]NodeInfo, 0, len(raw))
+	for idx, fields := range raw {
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("failed to decode node %d: expected at least 3 fields, got %d", idx, len(fields))
+		}
+
+		var info NodeInfo
+		var ok bool
+		if info.Name, ok = fields[0].(string); !ok {
+			return nil, fmt.Errorf("failed to decode node %d: invalid name %v", idx, fields[0])
+		}
+		if info.ObjectPath, ok = fields[1].(dbus.ObjectPath); !ok {
+			return nil, fmt.Errorf("failed to decode node %d: invalid object path %v", idx, fields[1])
+		}
+		status, ok := fields[2].(string)
+		if !ok {
+			return nil, fmt.Errorf("failed to decode node %d: invalid status %v", idx, fields[2])
+		}
+		info.Status = node.NodeStatus(status)
+		if len(fields) > 3 {
+			info.PeerIP, _ = fields[3].(string)
+		}
+		nodes = append(nodes, info)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,4658 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+type fakeNodeEntry struct {
+	Name   string
+	Path   dbus.ObjectPath
+	Status string
+}
+
+// fakeController implements the controller and node objects used by the
+// tests. Started units finish right away with JobRemoved.
+type fakeController struct {
+	address   string
+	conn      *dbus.Conn
+	nodes     []string
+	nodeProps map[string]*prop.Properties
+	jobs      uint32
+	// listed counts the calls of ListUnits on the controller
+	listed uint32
+	// flaky is the number of calls of ListNodes and StartUnit still to
+	// fail with NoReply, slow delays ListNodes
+	flaky int32
+	slow  int64
+
+	mu      sync.Mutex
+	monitor *fakeMonitor
+	// activeStates are the active states of units on all nodes,
+	// inactive if not set
+	activeStates map[string]string
+	// units are the units loaded on each node, fakeUnits if not set
+	units []node.UnitInfo
+	// logLevels are the log levels set on the agents by node name,
+	// SetLogLevel waits for logLevelGate to close if set
+	logLevels    map[string]string
+	logLevelGate chan struct{}
+	// reloads counts the calls of Reload
+	reloads int
+	// beforeList is called once by the next ListNodes before it lists the
+	// nodes if set
+	beforeList func()
+	// logLevel is the log level set on the controller
+	logLevel string
+	setProps map[string]dbus.Variant
+	frozen   map[string]bool
+	metrics  bool
+}
+
+func (c *fakeController) loadedUnits() []node.UnitInfo {
+	if c.units != nil {
+		return c.units
+	}
+	return fakeUnits
+}
+
+func (c *fakeController) ListNodes() ([]fakeNodeEntry, *dbus.Error) {
+	if err := c.flake(); err != nil {
+		return nil, err
+	}
+	time.Sleep(time.Duration(atomic.LoadInt64(&c.slow)))
+	c.mu.Lock()
+	before := c.beforeList
+	c.beforeList = nil
+	c.mu.Unlock()
+	if before != nil {
+		before()
+	}
+	entries := make([]fakeNodeEntry, 0, len(c.nodes))
+	for _, name := range c.nodes {
+		status := c.nodeProps[name].GetMust(common.NODE_INTERFACE, "Status").(string)
+		entries = append(entries, fakeNodeEntry{Name: name, Path: nodePath(name), Status: status})
+	}
+	return entries, nil
+}
+
+// flake returns the error of a call if it is one of the flaky ones.
+func (c *fakeController) flake() *dbus.Error {
+	if atomic.AddInt32(&c.flaky, -1) >= 0 {
+		return dbus.NewError(common.ERROR_NO_REPLY, []interface{}{"Did not receive a reply."})
+	}
+	return nil
+}
+
+func (c *fakeController) GetNode(name string) (dbus.ObjectPath, *dbus.Error) {
+	for _, n := range c.nodes {
+		if n == name {
+			return nodePath(name), nil
+		}
+	}
+	return "", dbus.NewError(common.ERROR_SERVICE_UNKNOWN, []interface{}{"Node not found"})
+}
+
+// SetLogLevel records the log level of the controller.
+func (c *fakeController) SetLogLevel(level string) *dbus.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logLevel = level
+	return nil
+}
+
+// EnableMetrics emits a StartUnitJobMetrics signal for node_a.
+func (c *fakeController) EnableMetrics() *dbus.Error {
+	c.mu.Lock()
+	c.metrics = true
+	c.mu.Unlock()
+	err := c.conn.Emit(common.METRICS_OBJECT_PATH, common.SIGNAL_START_UNIT_JOB_METRICS, "node_a", "1", "nginx.service", uint64(1500), uint64(500))
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (c *fakeController) DisableMetrics() *dbus.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metrics = false
+	return nil
+}
+
+// fakeUnits are the units loaded on each fake node.
+var fakeUnits = []node.UnitInfo{
+	{Name: "nginx.service", LoadState: "loaded", ActiveState: "active", SubState: "running", ObjectPath: "/", JobPath: "/"},
+	{Name: "nginx-proxy.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed", ObjectPath: "/", JobPath: "/"},
+	{Name: "sshd.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed", ObjectPath: "/", JobPath: "/"},
+}
+
+// fakeNodeUnit is an entry of ListUnits of the controller.
+type fakeNodeUnit struct {
+	Node        string
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	ObjectPath  dbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     dbus.ObjectPath
+}
+
+func (c *fakeController) ListUnits() ([]fakeNodeUnit, *dbus.Error) {
+	atomic.AddUint32(&c.listed, 1)
+	var units []fakeNodeUnit
+	for _, name := range c.nodes {
+		for _, u := range c.loadedUnits() {
+			units = append(units, fakeNodeUnit{name, u.Name, u.Description, string(u.LoadState), string(u.ActiveState),
+				string(u.SubState), u.Followed, u.ObjectPath, u.JobID, u.JobType, u.JobPath})
+		}
+	}
+	return units, nil
+}
+
+func (c *fakeController) CreateMonitor() (dbus.ObjectPath, *dbus.Error) {
+	path := dbus.ObjectPath(common.MONITOR_OBJECT_PATH_PREFIX + "/1")
+	mon := &fakeMonitor{conn: c.conn, path: path, peers: make(map[uint32]string)}
+	// exporting is not synchronized with exportIntrospection by godbus
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.conn.Export(mon, path, common.MONITOR_INTERFACE); err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	c.monitor = mon
+	return path, nil
+}
+
+// fakeMonitorUnits are the units reported by the fake monitor.
+var fakeMonitorUnits = []string{"foo@1.service", "bar.service", "foo@2.service"}
+
+// fakeMonitor sends a UnitNew signal to each peer added and a PeerRemoved
+// signal when it is removed.
+type fakeMonitor struct {
+	conn       *dbus.Conn
+	path       dbus.ObjectPath
+	mu         sync.Mutex
+	peers      map[uint32]string
+	subscribed []string
+}
+
+func (m *fakeMonitor) AddPeer(name string) (uint32, *dbus.Error) {
+	m.mu.Lock()
+	id := uint32(len(m.peers) + 1)
+	m.peers[id] = name
+	m.mu.Unlock()
+	return id, m.sendTo(name, "UnitNew", "node_a", "a.service", "real")
+}
+
+func (m *fakeMonitor) RemovePeer(id uint32, reason string) *dbus.Error {
+	m.mu.Lock()
+	name, ok := m.peers[id]
+	delete(m.peers, id)
+	m.mu.Unlock()
+	if !ok {
+		return dbus.MakeFailedError(fmt.Errorf("Peer with ID '%d' not found.", id))
+	}
+	return m.sendTo(name, "PeerRemoved", reason)
+}
+
+// Subscribe records the subscription and emits a UnitNew signal for each
+// of fakeMonitorUnits on node_a.
+func (m *fakeMonitor) Subscribe(node string, unit string) (uint32, *dbus.Error) {
+	m.mu.Lock()
+	m.subscribed = append(m.subscribed, node+" "+unit)
+	id := uint32(len(m.subscribed))
+	m.mu.Unlock()
+	for _, u := range fakeMonitorUnits {
+		if err := m.conn.Emit(m.path, common.SIGNAL_UNIT_NEW, "node_a", u, "real"); err != nil {
+			return 0, dbus.MakeFailedError(err)
+		}
+	}
+	return id, nil
+}
+
+// SubscribeList records the subscription and emits a UnitStateChanged and
+// a UnitPropertiesChanged signal for each of the units.
+func (m *fakeMonitor) SubscribeList(node string, units []string) (uint32, *dbus.Error) {
+	m.mu.Lock()
+	m.subscribed = append(m.subscribed, node+" "+fmt.Sprint(units))
+	id := uint32(len(m.subscribed))
+	m.mu.Unlock()
+	for _, u := range units {
+		if err := m.conn.Emit(m.path, common.SIGNAL_UNIT_STATE_CHANGED, node, u, "active", "running", "real"); err != nil {
+			return 0, dbus.MakeFailedError(err)
+		}
+		props := map[string]dbus.Variant{"MainPID": dbus.MakeVariant(uint32(42))}
+		if err := m.conn.Emit(m.path, common.SIGNAL_UNIT_PROPERTIES_CHANGED, node, u, "org.freedesktop.systemd1.Service", props); err != nil {
+			return 0, dbus.MakeFailedError(err)
+		}
+	}
+	return id, nil
+}
+
+func (m *fakeMonitor) Unsubscribe(id uint32) *dbus.Error {
+	return nil
+}
+
+func (m *fakeMonitor) Close() *dbus.Error {
+	return nil
+}
+
+// sendTo sends a signal only to the peer, as the controller does.
+func (m *fakeMonitor) sendTo(peer string, member string, body ...interface{}) *dbus.Error {
+	msg := &dbus.Message{
+		Type: dbus.TypeSignal,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldPath:        dbus.MakeVariant(m.path),
+			dbus.FieldInterface:   dbus.MakeVariant(common.MONITOR_INTERFACE),
+			dbus.FieldMember:      dbus.MakeVariant(member),
+			dbus.FieldDestination: dbus.MakeVariant(peer),
+		},
+		Body: body,
+	}
+	msg.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(body...))
+	if err := m.conn.Send(msg, nil).Err; err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+type fakeNode struct {
+	controller *fakeController
+	name       string
+}
+
+// ListUnits fails with ERROR_OFFLINE while the node is offline, like the
+// calls of the controller relayed to the agent.
+func (n *fakeNode) ListUnits() ([]node.UnitInfo, *dbus.Error) {
+	if n.controller.nodeProps[n.name].GetMust(common.NODE_INTERFACE, "Status") == "offline" {
+		return nil, dbus.NewError(common.ERROR_OFFLINE, []interface{}{"Node is offline"})
+	}
+	return n.controller.loadedUnits(), nil
+}
+
+// GetUnitProperty returns the unit file properties of fakeUnits, of which
+// only nginx.service is enabled, the active states of the controller and
+// the properties of GetUnitProperties.
+func (n *fakeNode) GetUnitProperty(unit string, iface string, property string) (dbus.Variant, *dbus.Error) {
+	switch property {
+	case "Id":
+		return dbus.MakeVariant(unit), nil
+	case "LoadState":
+		for _, u := range fakeUnits {
+			if u.Name == unit {
+				return dbus.MakeVariant(string(u.LoadState)), nil
+			}
+		}
+		return dbus.MakeVariant(string(node.LoadStateNotFound)), nil
+	case "FragmentPath":
+		return dbus.MakeVariant("/usr/lib/systemd/system/" + unit), nil
+	case "UnitFileState":
+		if unit == "nginx.service" {
+			return dbus.MakeVariant(node.UnitFileEnabled), nil
+		}
+		return dbus.MakeVariant(node.UnitFileDisabled), nil
+	case "ActiveState":
+		n.controller.mu.Lock()
+		defer n.controller.mu.Unlock()
+		if state, ok := n.controller.activeStates[unit]; ok {
+			return dbus.MakeVariant(state), nil
+		}
+		return dbus.MakeVariant(string(node.ActiveStateInactive)), nil
+	}
+	props, _ := n.GetUnitProperties(unit, iface)
+	if v, ok := props[property]; ok {
+		return v, nil
+	}
+	return dbus.Variant{}, dbus.NewError(common.ERROR_UNKNOWN_PROPERTY, []interface{}{"Unknown property"})
+}
+
+// fakeDependencies are the dependency properties of units on all nodes.
+var fakeDependencies = map[string]map[string][]string{
+	"app.service": {
+		"Requires": {"db.service"},
+		"Wants":    {"cache.service"},
+		"After":    {"network.target", "db.service"},
+	},
+	"db.service": {
+		"Requires": {"storage.mount"},
+		"After":    {"storage.mount"},
+	},
+	"cache.service": {
+		"Before": {"app.service"},
+	},
+}
+
+// GetUnitProperties returns the properties of a running service and the
+// fakeDependencies of the unit.
+func (n *fakeNode) GetUnitProperties(unit string, iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if unit == "missing.service" {
+		return nil, dbus.NewError(common.ERROR_SYSTEMD_NO_SUCH_UNIT, []interface{}{"Unit missing.service not loaded."})
+	}
+	props := make(map[string]dbus.Variant)
+	switch iface {
+	case common.SYSTEMD_UNIT_INTERFACE:
+		props["Id"] = dbus.MakeVariant(unit)
+		props["LoadState"] = dbus.MakeVariant("loaded")
+		props["ActiveState"] = dbus.MakeVariant("active")
+		props["SubState"] = dbus.MakeVariant("running")
+		if unit == crashedUnit {
+			props["ActiveState"] = dbus.MakeVariant("failed")
+			props["SubState"] = dbus.MakeVariant("failed")
+		}
+		for name, units := range fakeDependencies[unit] {
+			props[name] = dbus.MakeVariant(units)
+		}
+	case common.SYSTEMD_SERVICE_INTERFACE:
+		props["ControlGroup"] = dbus.MakeVariant("/system.slice/" + unit)
+		props["MainPID"] = dbus.MakeVariant(uint32(42))
+		props["Result"] = dbus.MakeVariant("success")
+		props["ExecMainCode"] = dbus.MakeVariant(int32(0))
+		props["ExecMainStatus"] = dbus.MakeVariant(int32(0))
+		if unit == crashedUnit {
+			props["Result"] = dbus.MakeVariant("exit-code")
+			props["ExecMainCode"] = dbus.MakeVariant(int32(1))
+			props["ExecMainStatus"] = dbus.MakeVariant(int32(3))
+		}
+	}
+	return props, nil
+}
+
+type fakeUnitProperty struct {
+	Name  string
+	Value dbus.Variant
+}
+
+// SetUnitProperties records the properties in the controller.
+func (n *fakeNode) SetUnitProperties(unit string, runtime bool, props []fakeUnitProperty) *dbus.Error {
+	n.controller.mu.Lock()
+	defer n.controller.mu.Unlock()
+	for _, p := range props {
+		n.controller.setProps[p.Name] = p.Value
+	}
+	return nil
+}
+
+// EnableUnitFiles reports a symlink for each file.
+func (n *fakeNode) EnableUnitFiles(files []string, runtime bool, force bool) (bool, []node.UnitFileChange, *dbus.Error) {
+	changes := make([]node.UnitFileChange, 0, len(files))
+	for _, f := range files {
+		changes = append(changes, node.UnitFileChange{
+			Type:        node.ChangeSymlink,
+			FileName:    "/etc/systemd/system/multi-user.target.wants/" + f,
+			Destination: "/usr/lib/systemd/system/" + f,
+		})
+	}
+	return true, changes, nil
+}
+
+// DisableUnitFiles reports an unlink for each file.
+func (n *fakeNode) DisableUnitFiles(files []string, runtime bool) ([]node.UnitFileChange, *dbus.Error) {
+	changes := make([]node.UnitFileChange, 0, len(files))
+	for _, f := range files {
+		changes = append(changes, node.UnitFileChange{Type: node.ChangeUnlink, FileName: "/etc/systemd/system/multi-user.target.wants/" + f})
+	}
+	return changes, nil
+}
+
+// FreezeUnit marks the unit frozen in the controller.
+func (n *fakeNode) FreezeUnit(unit string) *dbus.Error {
+	return n.setFrozen(unit, true)
+}
+
+// ThawUnit clears the frozen mark of the unit.
+func (n *fakeNode) ThawUnit(unit string) *dbus.Error {
+	return n.setFrozen(unit, false)
+}
+
+func (n *fakeNode) setFrozen(unit string, frozen bool) *dbus.Error {
+	for _, u := range fakeUnits {
+		if u.Name == unit {
+			n.controller.mu.Lock()
+			defer n.controller.mu.Unlock()
+			n.controller.frozen[unit] = frozen
+			return nil
+		}
+	}
+	return dbus.NewError("org.freedesktop.systemd1.NoSuchUnit", []interface{}{"Unit " + unit + " not loaded."})
+}
+
+// SetLogLevel records the log level of the agent.
+func (n *fakeNode) SetLogLevel(level string) *dbus.Error {
+	n.controller.mu.Lock()
+	gate := n.controller.logLevelGate
+	n.controller.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+
+	n.controller.mu.Lock()
+	defer n.controller.mu.Unlock()
+	if n.controller.logLevels == nil {
+		n.controller.logLevels = make(map[string]string)
+	}
+	n.controller.logLevels[n.name] = level
+	return nil
+}
+
+// crashedUnit is a service whose main process exited with status 3.
+const crashedUnit = "crash.service"
+
+// hangingUnit is a unit whose jobs never finish.
+const hangingUnit = "hang.service"
+
+func (n *fakeNode) StartUnit(unit string, mode string) (dbus.ObjectPath, *dbus.Error) {
+	if err := n.controller.flake(); err != nil {
+		return "", err
+	}
+	id := atomic.AddUint32(&n.controller.jobs, 1)
+	path := dbus.ObjectPath(fmt.Sprintf("%s/%d", common.JOB_OBJECT_PATH_PREFIX, id))
+	if unit == hangingUnit {
+		return path, nil
+	}
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_ = n.controller.conn.Emit(common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE+".JobRemoved",
+			id, path, n.name, unit, "done")
+	}()
+	return path, nil
+}
+
+// StopUnit finishes like StartUnit.
+func (n *fakeNode) StopUnit(unit string, mode string) (dbus.ObjectPath, *dbus.Error) {
+	return n.StartUnit(unit, mode)
+}
+
+// ReloadUnit finishes like StartUnit, except for missing.service, which is
+// not loaded.
+func (n *fakeNode) ReloadUnit(unit string, mode string) (dbus.ObjectPath, *dbus.Error) {
+	if unit == "missing.service" {
+		return "", dbus.NewError(common.ERROR_SYSTEMD_NO_SUCH_UNIT, []interface{}{"Unit missing.service not loaded."})
+	}
+	return n.StartUnit(unit, mode)
+}
+
+// RestartUnit queues jobs like StartUnit, which fail.
+func (n *fakeNode) RestartUnit(unit string, mode string) (dbus.ObjectPath, *dbus.Error) {
+	id := atomic.AddUint32(&n.controller.jobs, 1)
+	path := dbus.ObjectPath(fmt.Sprintf("%s/%d", common.JOB_OBJECT_PATH_PREFIX, id))
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_ = n.controller.conn.Emit(common.BC_OBJECT_PATH, common.SIGNAL_JOB_REMOVED, id, path, n.name, unit, job.ResultFailed)
+	}()
+	return path, nil
+}
+
+func (n *fakeNode) Reload() *dbus.Error {
+	n.controller.mu.Lock()
+	defer n.controller.mu.Unlock()
+	n.controller.reloads++
+	return nil
+}
+
+// fakeLastSeen is the last heartbeat reported for all fake nodes.
+var fakeLastSeen = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func nodePath(name string) dbus.ObjectPath {
+	return dbus.ObjectPath(common.NODE_OBJECT_PATH_PREFIX + "/" + name)
+}
+
+// startController serves a fake controller with the given nodes on a
+// private bus and returns the bus address.
+func startController(t *testing.T, nodes ...string) string {
+	return serveController(t, nodes...).address
+}
+
+// serveController is startController returning the fake controller, e.g.
+// to change the properties of its nodes.
+func serveController(t testing.TB, nodes ...string) *fakeController {
+	address := testbus.Start(t)
+	conn := testbus.Connect(t, address)
+
+	c := &fakeController{address: address, conn: conn, nodes: nodes, nodeProps: make(map[string]*prop.Properties), setProps: make(map[string]dbus.Variant), frozen: make(map[string]bool)}
+	if err := conn.Export(c, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+	_, err := prop.Export(conn, common.BC_OBJECT_PATH, prop.Map{
+		common.CONTROLLER_INTERFACE: {
+			"Status":    {Value: common.SYSTEM_STATUS_UP},
+			"LogLevel":  {Value: common.LOG_LEVEL_INFO},
+			"LogTarget": {Value: "journald"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range nodes {
+		if err := conn.Export(&fakeNode{controller: c, name: name}, nodePath(name), common.NODE_INTERFACE); err != nil {
+			t.Fatal(err)
+		}
+		props, err := prop.Export(conn, nodePath(name), prop.Map{
+			common.NODE_INTERFACE: {
+				"Name":              {Value: name},
+				"Status":            {Value: "online", Emit: prop.EmitTrue},
+				"LastSeenTimestamp": {Value: uint64(fakeLastSeen.Unix())},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.nodeProps[name] = props
+	}
+	testbus.RequestName(t, conn, common.BC_DBUS_NAME)
+	return c
+}
+
+// connect connects a Manager to the controller on the bus at address.
+func connect(t *testing.T, address string) *manager.Manager {
+	t.Helper()
+	m, err := manager.NewManager(manager.WithBusAddress(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+// awaitClosed drains ch and fails the test if it is not closed in time.
+func awaitClosed[T any](t *testing.T, what string, ch <-chan T) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%s not closed", what)
+		}
+	}
+}
+
+func TestNewManager(t *testing.T) {
+	address := startController(t, "node_a")
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", address)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", address)
+
+	for name, opts := range map[string][]manager.Option{
+		"default": nil,
+		"system":  {manager.WithSystemBus()},
+		"session": {manager.WithSessionBus()},
+	} {
+		m, err := manager.NewManager(opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		nodes, err := m.ListNodes(context.Background())
+		m.Close()
+		if err != nil || len(nodes) != 1 {
+			t.Fatalf("%s: expected one node, got %v, %v", name, nodes, err)
+		}
+	}
+
+	if _, err := manager.NewManager(manager.WithBusAddress("")); err == nil {
+		t.Fatal("expected an error for an empty bus address")
+	}
+	if _, err := manager.NewManager(manager.WithBusAddress("unix:path=/nonexistent")); err == nil {
+		t.Fatal("expected an error connecting to a missing bus")
+	}
+}
+
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+	m := connect(t, startController(t, "node_a"))
+
+	nodeEvents, err := m.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricEvents, err := m.SubscribeMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := m.TrackJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	awaitClosed(t, "node events", nodeEvents)
+	awaitClosed(t, "metrics", metricEvents)
+	awaitClosed(t, "monitor events", mon.Events())
+	awaitClosed(t, "job events", tracker.Events())
+	if _, err := m.ListNodes(ctx); !errors.Is(err, manager.ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected after Close, got %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("expected closing twice to be a no-op, got %v", err)
+	}
+
+	if err := m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Connect(); err != nil {
+		t.Fatalf("expected connecting twice to be a no-op, got %v", err)
+	}
+	if nodes, err := m.ListNodes(ctx); err != nil || len(nodes) != 1 {
+		t.Fatalf("expected one node after reconnecting, got %v, %v", nodes, err)
+	}
+}
+
+// awaitStates fails the test unless the states are delivered on ch in order.
+func awaitStates(t *testing.T, ch <-chan manager.ConnState, states ...manager.ConnState) {
+	t.Helper()
+	for _, want := range states {
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Fatalf("got connection state %s, want %s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for connection state %s", want)
+		}
+	}
+}
+
+// reconnectBackoff retries quickly to keep the tests short.
+var reconnectBackoff = manager.Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+
+func TestAutoReconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithAutoReconnect(reconnectBackoff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	states := m.ConnectionEvents()
+	awaitStates(t, states, manager.Connected)
+
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	awaitUnitNew := func() {
+		t.Helper()
+		for range fakeMonitorUnits {
+			select {
+			case event := <-mon.Events():
+				if _, ok := event.(monitor.UnitNew); !ok {
+					t.Fatalf("expected UnitNew, got %+v", event)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for UnitNew")
+			}
+		}
+	}
+	if _, err := mon.Subscribe(ctx, common.SYMBOL_WILDCARD, common.SYMBOL_WILDCARD); err != nil {
+		t.Fatal(err)
+	}
+	awaitUnitNew()
+	nodeEvents, err := m.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EnableMetrics(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the controller leaves the bus and comes back without its state
+	if _, err := c.conn.ReleaseName(common.BC_DBUS_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+	awaitStates(t, states, manager.Disconnected, manager.Reconnecting)
+	c.mu.Lock()
+	c.metrics = false
+	c.mu.Unlock()
+	c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	testbus.RequestName(t, c.conn, common.BC_DBUS_INTERFACE)
+	awaitStates(t, states, manager.Connected)
+
+	// the monitor is subscribed again, the missed status change is reported
+	// and metrics are enabled again
+	awaitUnitNew()
+	select {
+	case event := <-nodeEvents:
+		if event.Node != "node_a" || event.NewState != "offline" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the missed status change")
+	}
+	c.mu.Lock()
+	enabled := c.metrics
+	c.mu.Unlock()
+	if !enabled {
+		t.Fatal("expected metrics to be enabled again")
+	}
+	if nodes, err := m.ListNodes(ctx); err != nil || len(nodes) != 1 {
+		t.Fatalf("expected one node after reconnecting, got %v, %v", nodes, err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	awaitClosed(t, "connection events", states)
+}
+
+func TestAutoReconnectPeer(t *testing.T) {
+	ctx := context.Background()
+	c := &fakeController{nodes: []string{"node_a"}, nodeProps: make(map[string]*prop.Properties)}
+	conns := make(chan *dbus.Conn, 2)
+	address := testbus.ServePeer(t, func(conn *dbus.Conn) {
+		if err := conn.Export(c, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE); err != nil {
+			t.Error(err)
+		}
+		props, err := prop.Export(conn, nodePath("node_a"), prop.Map{
+			common.NODE_INTERFACE: {"Name": {Value: "node_a"}, "Status": {Value: "online"}},
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		c.mu.Lock()
+		c.nodeProps["node_a"] = props
+		c.mu.Unlock()
+		conns <- conn
+	})
+
+	// a slow first retry leaves time for a call while disconnected
+	backoff := manager.Backoff{Initial: 200 * time.Millisecond, Max: time.Second}
+	m, err := manager.NewManager(manager.WithPeerAddress(address), manager.WithAutoReconnect(backoff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	states := m.ConnectionEvents()
+
+	(<-conns).Close()
+	awaitStates(t, states, manager.Connected, manager.Disconnected, manager.Reconnecting)
+	if m.State() != manager.Reconnecting {
+		t.Fatalf("expected state reconnecting, got %s", m.State())
+	}
+	if _, err := m.ListNodes(ctx); !errors.Is(err, manager.ErrDisconnected) {
+		t.Fatalf("expected ErrDisconnected listing nodes while disconnected, got %v", err)
+	}
+	awaitStates(t, states, manager.Connected)
+	<-conns
+	if nodes, err := m.ListNodes(ctx); err != nil || len(nodes) != 1 {
+		t.Fatalf("expected one node after reconnecting, got %v, %v", nodes, err)
+	}
+}
+
+func TestAutoReconnectOptions(t *testing.T) {
+	for _, backoff := range []manager.Backoff{
+		{Initial: -time.Second},
+		{Initial: time.Minute},
+		{Multiplier: 0.5},
+	} {
+		if _, err := manager.NewManager(manager.WithAutoReconnect(backoff)); err == nil {
+			t.Errorf("expected backoff %+v to be rejected", backoff)
+		}
+	}
+
+	i := &manager.Manager{}
+	if i.State() != manager.Disconnected {
+		t.Fatalf("expected state disconnected before connecting, got %s", i.State())
+	}
+	states := i.ConnectionEvents()
+	awaitStates(t, states, manager.Disconnected)
+	awaitClosed(t, "connection events", states)
+}
+
+func TestConnectionEvents(t *testing.T) {
+	conns := make(chan *dbus.Conn, 1)
+	address := testbus.ServePeer(t, func(conn *dbus.Conn) { conns <- conn })
+	m, err := manager.NewManager(manager.WithPeerAddress(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// each consumer gets the current state and the transitions
+	first, second := m.ConnectionEvents(), m.ConnectionEvents()
+	if m.State() != manager.Connected {
+		t.Fatalf("expected state connected, got %s", m.State())
+	}
+	(<-conns).Close()
+	for _, states := range []<-chan manager.ConnState{first, second} {
+		awaitStates(t, states, manager.Connected, manager.Disconnected)
+		// without auto-reconnect the connection ends when lost
+		awaitClosed(t, "connection events", states)
+	}
+	if m.State() != manager.Disconnected {
+		t.Fatalf("expected state disconnected, got %s", m.State())
+	}
+	late := m.ConnectionEvents()
+	awaitStates(t, late, manager.Disconnected)
+	awaitClosed(t, "connection events", late)
+}
+
+func TestPeerConnection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &fakeController{nodes: []string{"node_a"}, nodeProps: make(map[string]*prop.Properties)}
+	served := make(chan error, 1)
+	address := testbus.ServePeer(t, func(conn *dbus.Conn) {
+		c.conn = conn
+		if err := conn.Export(c, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE); err != nil {
+			served <- err
+			return
+		}
+		if err := conn.Export(&fakeNode{controller: c, name: "node_a"}, nodePath("node_a"), common.NODE_INTERFACE); err != nil {
+			served <- err
+			return
+		}
+		props, err := prop.Export(conn, nodePath("node_a"), prop.Map{
+			common.NODE_INTERFACE: {
+				"Name":   {Value: "node_a"},
+				"Status": {Value: "online", Emit: prop.EmitTrue},
+			},
+		})
+		c.nodeProps["node_a"] = props
+		served <- err
+	})
+
+	m, err := manager.NewManager(manager.WithPeerAddress(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil || len(nodes) != 1 || nodes[0].Name != "node_a" {
+		t.Fatalf("expected node_a, got %+v, %v", nodes, err)
+	}
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if units, err := n.ListUnits(ctx); err != nil || len(units) != len(fakeUnits) {
+		t.Fatalf("expected %d units, got %v, %v", len(fakeUnits), units, err)
+	}
+
+	// peer connections deliver signals without match rules
+	events, err := m.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	select {
+	case event := <-events:
+		if event.Node != "node_a" || event.NewState != "offline" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the node to go offline")
+	}
+}
+
+func TestPeerOptions(t *testing.T) {
+	if _, err := manager.NewManager(manager.WithPeerAddress("")); err == nil {
+		t.Fatal("expected an error for an empty peer address")
+	}
+}
+
+func TestListNodes(t *testing.T) {
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a", "node_b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	nodes, err := m.ListNodes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "node_a" || nodes[1].Status != "online" {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+	if nodes[0].ObjectPath != nodePath("node_a") {
+		t.Fatalf("unexpected object path %s", nodes[0].ObjectPath)
+	}
+}
+
+func TestNodesBySelector(t *testing.T) {
+	ctx := context.Background()
+	store := manager.NewFileLabelStore(filepath.Join(t.TempDir(), "labels.json"))
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a", "node_b", "node_c")), manager.WithLabelStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	for name, labels := range map[string]manager.Labels{
+		"node_a": {"tier": "edge", "region": "eu"},
+		"node_b": {"tier": "edge"},
+		"node_c": {"tier": "core"},
+		"node_x": {"tier": "edge"},
+	} {
+		if err := m.SetNodeLabels(ctx, name, labels); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := m.NodesBySelector(ctx, "tier=edge, region!=eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"node_b"}) {
+		t.Fatalf("unexpected nodes %v", names)
+	}
+
+	// the labels are read from the file by other stores as well
+	labels, err := manager.NewFileLabelStore(store.Path()).Labels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["node_x"]["tier"] != "edge" {
+		t.Fatalf("unexpected labels %v", labels)
+	}
+	if err := m.SetNodeLabels(ctx, "node_a", nil); err != nil {
+		t.Fatal(err)
+	}
+	if labels, err := m.NodeLabels(ctx, "node_a"); err != nil || labels != nil {
+		t.Fatalf("expected labels to be removed, got %v, %v", labels, err)
+	}
+	if _, err := m.NodesBySelector(ctx, "tier=edge,,"); err == nil {
+		t.Fatal("expected error for invalid selector")
+	}
+}
+
+func TestSelector(t *testing.T) {
+	labels := manager.Labels{"tier": "edge", "gpu": ""}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"tier=edge", true},
+		{"tier==edge", true},
+		{"tier!=edge", false},
+		{"region!=eu", true},
+		{"gpu", true},
+		{"!gpu", false},
+		{"!region,tier=edge", true},
+		{"tier=core", false},
+	}
+	for _, tt := range tests {
+		sel, err := manager.ParseSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.selector, err)
+		}
+		if got := sel.Matches(labels); got != tt.want {
+			t.Errorf("%q: expected %t, got %t", tt.selector, tt.want, got)
+		}
+	}
+	for _, invalid := range []string{"=edge", "!", "tier=edge,"} {
+		if _, err := manager.ParseSelector(invalid); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
+
+// exportIntrospection exports introspection data of the given interfaces
+// at path, which the fake objects lack otherwise.
+func exportIntrospection(t *testing.T, c *fakeController, path dbus.ObjectPath, ifaces ...introspect.Interface) {
+	t.Helper()
+	data := &introspect.Node{Interfaces: append([]introspect.Interface{introspect.IntrospectData}, ifaces...)}
+	if path == common.BC_OBJECT_PATH {
+		data.Children = []introspect.Node{{Name: "node"}}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.conn.Export(introspect.NewIntrospectable(data), path, common.INTROSPECTABLE_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	c := serveController(t, "node_a")
+	exportIntrospection(t, c, common.BC_OBJECT_PATH, introspect.Interface{
+		Name:       common.CONTROLLER_INTERFACE,
+		Methods:    introspect.Methods(c),
+		Properties: []introspect.Property{{Name: "Status", Type: "s", Access: "read"}},
+	})
+	exportIntrospection(t, c, nodePath("node_a"), introspect.Interface{
+		Name:    common.NODE_INTERFACE,
+		Methods: introspect.Methods(&fakeNode{}),
+		Signals: []introspect.Signal{{Name: "JobRemoved"}},
+	})
+
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	i, err := m.Introspect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !i.HasInterface(common.CONTROLLER_INTERFACE) || !i.HasMethod(common.CONTROLLER_INTERFACE, "ListNodes") ||
+		!i.HasProperty(common.CONTROLLER_INTERFACE, "Status") {
+		t.Errorf("unexpected introspection data of the controller %+v", i)
+	}
+	if i.HasMethod(common.CONTROLLER_INTERFACE, "Frobnicate") || i.HasMethod(common.NODE_INTERFACE, "ListNodes") {
+		t.Error("expected unknown methods to be reported missing")
+	}
+	if len(i.Children) != 1 || i.Children[0].Name != "node" {
+		t.Errorf("unexpected children %+v", i.Children)
+	}
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err = n.Introspect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !i.HasMethod(common.NODE_INTERFACE, "StartUnit") || !i.HasSignal(common.NODE_INTERFACE, "JobRemoved") ||
+		i.HasSignal(common.NODE_INTERFACE, "JobNew") {
+		t.Errorf("unexpected introspection data of the node %+v", i)
+	}
+}
+
+// fakeControllerProperties serves the properties of a controller like
+// sd-bus, which reports unknown and read-only properties with the standard
+// D-Bus errors.
+type fakeControllerProperties struct {
+	mu       sync.Mutex
+	values   map[string]dbus.Variant
+	writable bool
+}
+
+func (p *fakeControllerProperties) Get(iface string, name string) (dbus.Variant, *dbus.Error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.values[name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError(common.ERROR_UNKNOWN_PROPERTY, []interface{}{"Unknown property"})
+	}
+	return v, nil
+}
+
+func (p *fakeControllerProperties) Set(iface string, name string, v dbus.Variant) *dbus.Error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.values[name]; !ok {
+		return dbus.NewError(common.ERROR_UNKNOWN_PROPERTY, []interface{}{"Unknown property"})
+	}
+	if !p.writable {
+		return dbus.NewError(common.ERROR_PROPERTY_READ_ONLY, []interface{}{"Property is read-only"})
+	}
+	p.values[name] = v
+	return nil
+}
+
+func TestHeartbeatSettings(t *testing.T) {
+	ctx := context.Background()
+
+	serve := func(props *fakeControllerProperties) *manager.Manager {
+		address := testbus.Start(t)
+		conn := testbus.Connect(t, address)
+		if err := conn.Export(props, common.BC_OBJECT_PATH, common.PROPERTIES_INTERFACE); err != nil {
+			t.Fatal(err)
+		}
+		testbus.RequestName(t, conn, common.BC_DBUS_NAME)
+		m, err := manager.NewManager(manager.WithBusAddress(address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { m.Close() })
+		return m
+	}
+
+	// controllers not reporting the settings
+	m := serve(&fakeControllerProperties{})
+	if _, err := m.HeartbeatInterval(ctx); !errors.Is(err, common.ErrUnknownProperty) {
+		t.Fatalf("expected ErrUnknownProperty, got %v", err)
+	}
+	settings, err := m.HeartbeatSettings(ctx)
+	if err != nil || settings != (manager.HeartbeatSettings{AgentInterval: manager.DefaultHeartbeatInterval}) {
+		t.Fatalf("expected the default settings, got %+v, %v", settings, err)
+	}
+	if warnings, err := m.CheckStaleThreshold(ctx, 3*manager.DefaultHeartbeatInterval); err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no warnings for the default threshold, got %v, %v", warnings, err)
+	}
+
+	props := &fakeControllerProperties{values: map[string]dbus.Variant{
+		"HeartbeatInterval":      dbus.MakeVariant(uint64(2000)),
+		"NodeHeartbeatThreshold": dbus.MakeVariant(uint64(6000)),
+	}}
+	m = serve(props)
+
+	settings, err = m.HeartbeatSettings(ctx)
+	want := manager.HeartbeatSettings{AgentInterval: manager.DefaultHeartbeatInterval, ControllerInterval: 2 * time.Second, NodeThreshold: 6 * time.Second}
+	if err != nil || settings != want {
+		t.Fatalf("expected %+v, got %+v, %v", want, settings, err)
+	}
+	warnings := manager.CheckStaleThreshold(time.Second, settings)
+	if len(warnings) != 2 {
+		t.Errorf("expected warnings for the interval and the resolution, got %q", warnings)
+	}
+	if warnings := manager.CheckStaleThreshold(10*time.Second, settings); len(warnings) != 1 {
+		t.Errorf("expected a warning for the node threshold, got %q", warnings)
+	}
+
+	if err := m.SetNodeHeartbeatThreshold(ctx, 10*time.Second); !errors.Is(err, common.ErrPropertyReadOnly) {
+		t.Fatalf("expected ErrPropertyReadOnly, got %v", err)
+	}
+	props.mu.Lock()
+	props.writable = true
+	props.mu.Unlock()
+	if err := m.SetNodeHeartbeatThreshold(ctx, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := m.NodeHeartbeatThreshold(ctx); err != nil || d != 10*time.Second {
+		t.Fatalf("expected the changed threshold, got %v, %v", d, err)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	ctx := context.Background()
+	probe := func(c *fakeController) (manager.Capabilities, string, error) {
+		m, err := manager.NewManager(manager.WithBusAddress(c.address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer m.Close()
+		caps, err := m.Capabilities(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		version, err := m.ControllerVersion(ctx)
+		return caps, version, err
+	}
+
+	// an old controller without introspection data of monitors
+	old := serveController(t, "node_a")
+	exportIntrospection(t, old, common.BC_OBJECT_PATH, introspect.Interface{
+		Name: common.CONTROLLER_INTERFACE, Methods: []introspect.Method{{Name: "ListNodes"}},
+	})
+	exportIntrospection(t, old, nodePath("node_a"), introspect.Interface{
+		Name: common.NODE_INTERFACE, Methods: []introspect.Method{{Name: "StartUnit"}},
+	})
+	caps, _, err := probe(old)
+	if (caps != manager.Capabilities{MonitorWildcards: true}) {
+		t.Errorf("unexpected capabilities %+v", caps)
+	}
+	if !errors.Is(err, manager.ErrVersionUnknown) {
+		t.Errorf("expected an unknown version, got %v", err)
+	}
+
+	c := serveController(t, "node_a")
+	exportIntrospection(t, c, common.BC_OBJECT_PATH, introspect.Interface{
+		Name:       common.CONTROLLER_INTERFACE,
+		Methods:    []introspect.Method{{Name: "ListNodes"}, {Name: "EnableMetrics"}},
+		Properties: []introspect.Property{{Name: "Version", Type: "s", Access: "read"}},
+	})
+	exportIntrospection(t, c, common.MONITOR_OBJECT_PATH_PREFIX+"/1", introspect.Interface{
+		Name: common.MONITOR_INTERFACE, Methods: []introspect.Method{{Name: "SubscribeList"}, {Name: "AddPeer"}},
+	})
+	exportIntrospection(t, c, nodePath("node_a"), introspect.Interface{
+		Name:       common.NODE_INTERFACE,
+		Properties: []introspect.Property{{Name: "PeerIp", Type: "s", Access: "read"}},
+	})
+	_, err = prop.Export(c.conn, common.BC_OBJECT_PATH, prop.Map{
+		common.CONTROLLER_INTERFACE: {"Version": {Value: "1.2.3"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps, version, err := probe(c)
+	want := manager.Capabilities{Metrics: true, MonitorWildcards: true, MonitorSubscribeList: true, MonitorPeers: true, PeerIP: true}
+	if caps != want {
+		t.Errorf("capabilities %+v, want %+v", caps, want)
+	}
+	if err != nil || version != "1.2.3" {
+		t.Errorf("ControllerVersion() = %q, %v", version, err)
+	}
+}
+
+func TestGetUnknownNode(t *testing.T) {
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	_, err = m.GetNode(context.Background(), "node_x")
+	if !errors.Is(err, common.ErrNoSuchNode) {
+		t.Fatalf("expected ErrNoSuchNode, got %v", err)
+	}
+	var bcErr *common.Error
+	if !errors.As(err, &bcErr) || bcErr.Name != common.ERROR_SERVICE_UNKNOWN {
+		t.Fatalf("expected a *common.Error named %s, got %v", common.ERROR_SERVICE_UNKNOWN, err)
+	}
+}
+
+func TestProperties(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if status, err := m.Status(ctx); err != nil || status != common.SYSTEM_STATUS_UP {
+		t.Fatalf("expected status %s, got %q (%v)", common.SYSTEM_STATUS_UP, status, err)
+	}
+	if level, err := m.LogLevel(ctx); err != nil || level != common.LOG_LEVEL_INFO {
+		t.Fatalf("expected log level %s, got %q (%v)", common.LOG_LEVEL_INFO, level, err)
+	}
+	if target, err := m.GetProperty(ctx, "LogTarget"); err != nil || target != "journald" {
+		t.Fatalf("expected log target journald, got %v (%v)", target, err)
+	}
+	if _, err := m.GetProperty(ctx, "Unknown"); err == nil {
+		t.Fatal("expected error for unknown property")
+	}
+}
+
+func TestNodeProperties(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, err := n.Status(ctx); err != nil || status != "online" {
+		t.Fatalf("expected node to be online, got %q (%v)", status, err)
+	}
+	if lastSeen, err := n.LastSeenTimestamp(ctx); err != nil || !lastSeen.Equal(fakeLastSeen) {
+		t.Fatalf("expected last seen %s, got %s (%v)", fakeLastSeen, lastSeen, err)
+	}
+	// the fake controller does not export PeerIp, like older controllers
+	if _, err := n.PeerIP(ctx); err == nil {
+		t.Fatal("expected error for missing property PeerIp")
+	}
+}
+
+func TestListUnitsFilter(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	units, err := m.ListUnits(ctx, manager.WithActiveState("failed"), manager.WithPattern("nginx*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 2 {
+		t.Fatalf("expected units of 2 nodes, got %v", units)
+	}
+	for name, nodeUnits := range units {
+		if len(nodeUnits) != 1 || nodeUnits[0].Name != "nginx-proxy.service" {
+			t.Fatalf("unexpected units of %s: %v", name, nodeUnits)
+		}
+	}
+
+	// the units of single nodes are read from the nodes
+	listed := atomic.LoadUint32(&c.listed)
+	units, err = m.ListUnits(ctx, manager.WithNodes("node_b", "node_c"), manager.WithPattern("*.service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 1 || len(units["node_b"]) != len(fakeUnits) {
+		t.Fatalf("expected all units of node_b, got %v", units)
+	}
+	if atomic.LoadUint32(&c.listed) != listed {
+		t.Fatal("expected the units not to be listed on the controller")
+	}
+
+	units, err = m.ListUnits(ctx, manager.WithActiveState("activating"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 0 {
+		t.Fatalf("expected no units, got %v", units)
+	}
+}
+
+func TestListOrder(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_b", "node_a")
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	nodeTests := []struct {
+		order manager.SortOrder
+		want  []string
+	}{
+		{manager.Unsorted, []string{"node_b", "node_a"}},
+		{manager.SortByName, []string{"node_a", "node_b"}},
+		{manager.SortByState, []string{"node_b", "node_a"}},
+	}
+	for _, tt := range nodeTests {
+		nodes, err := m.ListNodes(ctx, manager.WithNodeOrder(tt.order))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, n := range nodes {
+			names = append(names, n.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("order %d: expected nodes %v, got %v", tt.order, tt.want, names)
+		}
+	}
+
+	unitTests := []struct {
+		order manager.SortOrder
+		want  []string
+	}{
+		{manager.SortByName, []string{"nginx-proxy.service", "nginx.service", "sshd.service"}},
+		{manager.SortByState, []string{"nginx.service", "nginx-proxy.service", "sshd.service"}},
+	}
+	for _, tt := range unitTests {
+		units, err := m.ListUnits(ctx, manager.WithUnitOrder(tt.order))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, u := range units["node_a"] {
+			names = append(names, u.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("order %d: expected units %v, got %v", tt.order, tt.want, names)
+		}
+	}
+}
+
+func TestUnitSummary(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	listed := atomic.LoadUint32(&c.listed)
+	summary, err := m.UnitSummary(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint32(&c.listed) != listed+1 {
+		t.Fatal("expected the units to be listed by a single call")
+	}
+	want := manager.UnitSummary{Total: 3, Active: 1, Failed: 2}
+	if len(summary) != 2 || summary["node_a"] != want || summary["node_b"] != want {
+		t.Fatalf("expected %+v for both nodes, got %+v", want, summary)
+	}
+
+	summary, err = m.UnitSummary(ctx, manager.WithPattern("nginx*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (manager.UnitSummary{Total: 2, Active: 1, Failed: 1}); summary["node_a"] != want {
+		t.Fatalf("expected %+v, got %+v", want, summary["node_a"])
+	}
+
+	summary = manager.SummarizeUnits(map[string][]node.UnitInfo{
+		"node_c": {{Name: "a.service", ActiveState: "inactive"}, {Name: "b.service", ActiveState: "activating"}},
+	})
+	if want := (manager.UnitSummary{Total: 2, Inactive: 1, Other: 1}); summary["node_c"] != want {
+		t.Fatalf("expected %+v, got %+v", want, summary["node_c"])
+	}
+}
+
+func TestListUnitsFunc(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	want, err := m.ListUnits(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]node.UnitInfo)
+	err = m.ListUnitsFunc(ctx, func(nodeName string, u node.UnitInfo) bool {
+		got[nodeName] = append(got[nodeName], u)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, opts := range [][]manager.ListUnitsOption{
+		{manager.WithActiveState(node.ActiveStateFailed)},
+		{manager.WithActiveState(node.ActiveStateFailed), manager.WithNodes("node_a", "node_b")},
+	} {
+		var names []string
+		err = m.ListUnitsFunc(ctx, func(nodeName string, u node.UnitInfo) bool {
+			names = append(names, nodeName+"/"+u.Name)
+			return len(names) < 3
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"node_a/nginx-proxy.service", "node_a/sshd.service", "node_b/nginx-proxy.service"}
+		if !slices.Equal(names, want) {
+			t.Fatalf("expected the listing to stop after %v, got %v", want, names)
+		}
+	}
+
+	n, err := m.GetNode(ctx, "node_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	if err := n.ListUnitsFunc(ctx, func(node.UnitInfo) bool { count++; return true }); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(fakeUnits) {
+		t.Fatalf("expected %d units, got %d", len(fakeUnits), count)
+	}
+}
+
+// BenchmarkListUnits compares the allocations of ListUnits and
+// ListUnitsFunc for 2 nodes with 5000 units each. They include those of the
+// fake controller encoding the reply in the same process.
+func BenchmarkListUnits(b *testing.B) {
+	ctx := context.Background()
+	c := serveController(b, "node_a", "node_b")
+	for i := 0; i < 5000; i++ {
+		c.units = append(c.units, node.UnitInfo{
+			Name:        fmt.Sprintf("app-%d.service", i),
+			Description: "Application instance",
+			LoadState:   node.LoadStateLoaded,
+			ActiveState: node.ActiveStateActive,
+			SubState:    node.SubStateRunning,
+			ObjectPath:  dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/systemd1/unit/app_2d%d_2eservice", i)),
+			JobPath:     "/",
+		})
+	}
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close()
+
+	b.Run("ListUnits", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := m.ListUnits(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ListUnitsFunc", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := m.ListUnitsFunc(ctx, func(string, node.UnitInfo) bool { return true }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Node.ListUnits", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := n.ListUnits(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkDecodeUnits compares decoding the fields of a 5k-unit reply of
+// ListUnits of a node by dbus.Store with node.DecodeUnitInfo, without the
+// D-Bus wire format decoded by godbus.
+func BenchmarkDecodeUnits(b *testing.B) {
+	raw := make([][]interface{}, 5000)
+	for i := range raw {
+		raw[i] = []interface{}{fmt.Sprintf("app-%d.service", i), "Application instance", "loaded", "active", "running", "",
+			dbus.ObjectPath("/org/freedesktop/systemd1/unit/app"), uint32(0), "", dbus.ObjectPath("/")}
+	}
+	b.Run("Store", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var units []node.UnitInfo
+			if err := dbus.Store([]interface{}{raw}, &units); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("DecodeUnitInfo", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			units := make([]node.UnitInfo, len(raw))
+			for j, fields := range raw {
+				var err error
+				if units[j], err = node.DecodeUnitInfo(fields); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// decodeUnitInfoAllocs is the allocation budget of decoding a unit listed
+// by ListUnits, which reuses the strings decoded by godbus.
+const decodeUnitInfoAllocs = 0
+
+// TestDecodeUnitInfoAllocs guards the allocations of decoding the units of
+// ListUnits, which are decoded for thousands of units per node.
+func TestDecodeUnitInfoAllocs(t *testing.T) {
+	fields := []interface{}{"app.service", "Application instance", "loaded", "active", "running", "",
+		dbus.ObjectPath("/org/freedesktop/systemd1/unit/app"), uint32(0), "", dbus.ObjectPath("/")}
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := node.DecodeUnitInfo(fields); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > decodeUnitInfoAllocs {
+		t.Errorf("decoding a unit allocates %v times, the budget is %d", allocs, decodeUnitInfoAllocs)
+	}
+}
+
+// runOnNodesAllocs is the allocation budget per node of fanning out over
+// resolved nodes, without the calls issued on them.
+const runOnNodesAllocs = 4
+
+// TestRunOnNodesAllocs guards the allocations of the fan-out of the batch
+// operations.
+func TestRunOnNodesAllocs(t *testing.T) {
+	ctx := context.Background()
+	nodes := benchmarkNodes(50)
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, nodes...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	noOp := func(context.Context, *node.Node) error { return nil }
+	if err := m.RunOnNodes(ctx, nodes, noOp); err != nil {
+		t.Fatal(err)
+	}
+
+	allocs := testing.AllocsPerRun(20, func() {
+		if err := m.RunOnNodes(ctx, nodes, noOp); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if perNode := allocs / float64(len(nodes)); perNode > runOnNodesAllocs {
+		t.Errorf("fanning out allocates %v times per node, the budget is %d", perNode, runOnNodesAllocs)
+	}
+}
+
+// benchmarkNodes returns the names of n nodes of a fake controller.
+func benchmarkNodes(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("node_%d", i)
+	}
+	return names
+}
+
+// BenchmarkConnect measures connecting to a controller and closing the
+// connection, e.g. for short-lived tools on edge devices.
+func BenchmarkConnect(b *testing.B) {
+	address := serveController(b).address
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m, err := manager.NewManager(manager.WithBusAddress(address))
+		if err != nil {
+			b.Fatal(err)
+		}
+		m.Close()
+	}
+}
+
+// BenchmarkListNodes measures listing the 100 nodes of a controller,
+// including the allocations of the fake controller like BenchmarkListUnits.
+func BenchmarkListNodes(b *testing.B) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(serveController(b, benchmarkNodes(100)...).address))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.ListNodes(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRunOnNodes measures fanning out over 50 nodes with the default
+// concurrency, with the overhead of the fan-out alone and with a call on
+// each node.
+func BenchmarkRunOnNodes(b *testing.B) {
+	ctx := context.Background()
+	nodes := benchmarkNodes(50)
+	m, err := manager.NewManager(manager.WithBusAddress(serveController(b, nodes...).address))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close()
+	// resolve the nodes once, as long-running callers do
+	if err := m.RunOnNodes(ctx, nodes, func(context.Context, *node.Node) error { return nil }); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("NoOp", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := m.RunOnNodes(ctx, nodes, func(context.Context, *node.Node) error { return nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ListUnits", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			err := m.RunOnNodes(ctx, nodes, func(ctx context.Context, n *node.Node) error {
+				_, err := n.ListUnits(ctx)
+				return err
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestEscapeInstanceName(t *testing.T) {
+	tests := []struct {
+		instance string
+		want     string
+	}{
+		{"42", "42"},
+		{"/dev/sda1", "-dev-sda1"},
+		{"my-worker", `my\x2dworker`},
+		{".hidden.conf", `\x2ehidden.conf`},
+		{"a b\\c", `a\x20b\x5cc`},
+		{"host:8080_x", "host:8080_x"},
+		{"ä", `\xc3\xa4`},
+	}
+	for _, tt := range tests {
+		got := manager.EscapeInstanceName(tt.instance)
+		if got != tt.want {
+			t.Errorf("EscapeInstanceName(%q) = %q, want %q", tt.instance, got, tt.want)
+		}
+		back, err := manager.UnescapeInstanceName(got)
+		if err != nil || back != tt.instance {
+			t.Errorf("UnescapeInstanceName(%q) = %q, %v, want %q", got, back, err, tt.instance)
+		}
+	}
+	for _, s := range []string{`a\x2`, `a\y20`, `a\xzz`} {
+		if _, err := manager.UnescapeInstanceName(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestTemplateUnits(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"worker@", `worker@my\x2dworker.service`},
+		{"worker@.service", `worker@my\x2dworker.service`},
+		{"backup@.timer", `backup@my\x2dworker.timer`},
+	}
+	for _, tt := range tests {
+		name, err := manager.InstanceName(tt.template, "my-worker")
+		if err != nil || name != tt.want {
+			t.Errorf("InstanceName(%q) = %q, %v, want %q", tt.template, name, err, tt.want)
+		}
+	}
+	for _, template := range []string{"worker", "@.service", "worker@service", "worker@a@.service"} {
+		if _, err := manager.InstanceName(template, "1"); err == nil {
+			t.Errorf("expected an error for template %q", template)
+		}
+	}
+	if _, err := manager.InstanceName("worker@", ""); err == nil {
+		t.Error("expected an error for an empty instance")
+	}
+
+	instances, err := manager.FilterInstances(map[string][]node.UnitInfo{
+		"node_b": {{Name: "worker@2.service"}, {Name: "worker@.service"}, {Name: "worker-x@1.service"}},
+		"node_a": {{Name: `worker@vol-data.service`}, {Name: "worker@1.service"}, {Name: "worker@1.timer"}},
+	}, "worker@")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, i := range instances {
+		got = append(got, i.Node+"/"+i.Instance+"/"+i.Unit.Name)
+	}
+	want := []string{"node_a/1/worker@1.service", "node_a/vol/data/worker@vol-data.service", "node_b/2/worker@2.service"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected instances %v, got %v", want, got)
+	}
+
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	u, err := m.InstanceUnit("node_a", "nginx@", "1")
+	if err != nil || u.Name() != "nginx@1.service" || u.Node() != "node_a" {
+		t.Fatalf("unexpected unit %+v, %v", u, err)
+	}
+	listed := atomic.LoadUint32(&c.listed)
+	instances, err = m.ListInstances(ctx, "nginx@")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 0 || atomic.LoadUint32(&c.listed) != listed+1 {
+		t.Fatalf("expected no instances listed by a single call, got %v", instances)
+	}
+	if _, err := m.ListInstances(ctx, "nginx"); err == nil {
+		t.Fatal("expected an error for a unit which is no template")
+	}
+}
+
+func TestUnitFiles(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := n.GetUnitFileState(ctx, "nginx.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != node.UnitFileEnabled {
+		t.Fatalf("got state %s, want %s", state, node.UnitFileEnabled)
+	}
+
+	files, err := n.ListUnitFiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(fakeUnits) {
+		t.Fatalf("got %d unit files, want %d", len(files), len(fakeUnits))
+	}
+	want := node.UnitFile{Name: "sshd.service", Path: "/usr/lib/systemd/system/sshd.service", State: node.UnitFileDisabled}
+	if files[2] != want {
+		t.Fatalf("got %+v, want %+v", files[2], want)
+	}
+}
+
+func TestStartUnitOnNodes(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a", "node_b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	results, err := m.StartUnitOnNodes(ctx, "a.service", []string{"node_a", "node_c", "node_b"})
+	var multi *manager.MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 1 || multi.Errors["node_c"] == nil {
+		t.Fatalf("expected node_c to fail, got %v", err)
+	}
+	if !errors.Is(err, common.ErrNoSuchNode) {
+		t.Fatalf("expected %v to match common.ErrNoSuchNode", err)
+	}
+	var nodeErr *manager.NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Node != "node_c" || nodeErr.Op != "start unit a.service" {
+		t.Fatalf("expected the node error of node_c, got %+v", nodeErr)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %v", results)
+	}
+	for _, i := range []int{0, 2} {
+		if results[i].Err != nil || results[i].Result != "done" {
+			t.Fatalf("unexpected result %+v", results[i])
+		}
+	}
+	if results[1].Node != "node_c" || results[1].Err == nil {
+		t.Fatalf("unexpected result %+v", results[1])
+	}
+}
+
+func TestDrainNode(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a", "node_b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if _, err := m.DrainNode(ctx, "node_a"); err == nil {
+		t.Fatal("expected draining without units to fail")
+	}
+
+	var relocated []string
+	relocate := manager.WithRelocate(func(ctx context.Context, node string, unit string) error {
+		relocated = append(relocated, node+"/"+unit)
+		return nil
+	})
+	// only the active units are stopped, nginx-proxy.service has failed
+	stopped, err := m.DrainNode(ctx, "node_a", manager.WithDrainPattern("nginx*"), manager.WithDrainUnits("sshd.service"), relocate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(stopped, []string{"nginx.service"}) || !slices.Equal(m.DrainedUnits("node_a"), stopped) {
+		t.Fatalf("unexpected drained units %v, %v", stopped, m.DrainedUnits("node_a"))
+	}
+	if m.DrainedUnits("node_b") != nil {
+		t.Fatalf("expected node_b not to be drained, got %v", m.DrainedUnits("node_b"))
+	}
+
+	started, err := m.UndrainNode(ctx, "node_a", relocate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(started, stopped) || m.DrainedUnits("node_a") != nil {
+		t.Fatalf("unexpected undrained units %v, %v", started, m.DrainedUnits("node_a"))
+	}
+	if want := []string{"node_a/nginx.service", "node_a/nginx.service"}; !slices.Equal(relocated, want) {
+		t.Fatalf("expected relocations %v, got %v", want, relocated)
+	}
+}
+
+func TestWaitForUnitState(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	c.mu.Lock()
+	c.activeStates = map[string]string{"app.service": string(node.ActiveStateActivating)}
+	c.mu.Unlock()
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.WaitForUnitState(ctx, "app.service", node.ActiveStateActivating); err != nil {
+		t.Fatalf("expected the current state to end the wait, got %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = n.WaitForUnitState(timeoutCtx, "app.service", node.ActiveStateActive)
+	var stateErr *node.UnitStateError
+	if !errors.As(err, &stateErr) || stateErr.Last != node.ActiveStateActivating || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a timeout in state activating, got %v", err)
+	}
+
+	// the monitors of the waits above are replaced by the one of this wait
+	c.mu.Lock()
+	c.monitor = nil
+	c.mu.Unlock()
+	done := make(chan error, 1)
+	go func() {
+		done <- n.WaitForUnitState(ctx, "app.service", node.ActiveStateActive)
+	}()
+	for {
+		c.mu.Lock()
+		mon := c.monitor
+		c.mu.Unlock()
+		if mon != nil {
+			mon.mu.Lock()
+			subscribed := slices.Contains(mon.subscribed, "node_a app.service")
+			mon.mu.Unlock()
+			if subscribed {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.conn.Emit(c.monitor.path, common.SIGNAL_UNIT_STATE_CHANGED, "node_a", "app.service", "active", "running", "real"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("unit state change not received")
+	}
+}
+
+func TestUnitDependencies(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := n.UnitDependencies(ctx, "app.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(d.Requires, []string{"db.service"}) || !slices.Equal(d.After, []string{"db.service", "network.target"}) || d.Before != nil {
+		t.Fatalf("unexpected dependencies %+v", d)
+	}
+
+	g, err := node.ReadDependencyGraph(ctx, n, []string{"app.service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g) != 4 {
+		t.Fatalf("expected the units required or wanted by app.service, got %v", g)
+	}
+	closure := g.Closure("app.service", node.DependencyRequires)
+	if want := []string{"db.service", "storage.mount"}; !slices.Equal(closure, want) {
+		t.Fatalf("expected closure %v, got %v", want, closure)
+	}
+
+	order, err := g.StartOrder([]string{"app.service", "cache.service", "db.service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cache.service", "db.service", "app.service"}; !slices.Equal(order, want) {
+		t.Fatalf("expected start order %v, got %v", want, order)
+	}
+
+	g["db.service"] = node.UnitDependencies{Unit: "db.service", After: []string{"app.service"}}
+	if _, err := g.StartOrder([]string{"app.service", "db.service"}); err == nil {
+		t.Fatal("expected an ordering cycle")
+	}
+}
+
+func TestGetUnitsProperties(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	units := []string{"app.service", "db.service", "storage.mount", "app.service"}
+	props, err := n.GetUnitsProperties(ctx, units, []string{"Id", "Requires"}, node.WithPropertiesConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(props) != 3 || props["app.service"]["Id"] != "app.service" || len(props["storage.mount"]) != 1 {
+		t.Fatalf("unexpected properties %v", props)
+	}
+	if requires, _ := props["app.service"]["Requires"].([]string); !slices.Equal(requires, []string{"db.service"}) {
+		t.Fatalf("unexpected Requires %v", props["app.service"]["Requires"])
+	}
+
+	// MainPID is read from the Service interface of services only
+	props, err = n.GetUnitsProperties(ctx, []string{"app.service", "storage.mount", "missing.service"}, []string{"Id", "MainPID"})
+	if !errors.Is(err, common.ErrNoSuchUnit) || !strings.Contains(err.Error(), "missing.service") {
+		t.Fatalf("expected the error of missing.service, got %v", err)
+	}
+	if len(props) != 2 || props["app.service"]["MainPID"] != uint32(42) || props["storage.mount"]["Id"] != "storage.mount" {
+		t.Fatalf("unexpected properties %v", props)
+	}
+	if _, ok := props["storage.mount"]["MainPID"]; ok {
+		t.Fatal("expected no MainPID for a mount")
+	}
+
+	props, err = n.GetUnitsProperties(ctx, []string{"app.service"}, nil)
+	if err != nil || props["app.service"]["Id"] != "app.service" || props["app.service"]["MainPID"] != uint32(42) {
+		t.Fatalf("expected the properties of both interfaces, got %v, %v", props, err)
+	}
+}
+
+// fakeLogs returns a line naming the node and unit for each line requested.
+type fakeLogs struct {
+	calls int
+}
+
+func (l *fakeLogs) UnitLogs(ctx context.Context, nodeName string, unit string, lines int) ([]string, error) {
+	l.calls++
+	logs := make([]string, lines)
+	for i := range logs {
+		logs[i] = fmt.Sprintf("%s %s %d", nodeName, unit, i)
+	}
+	return logs, nil
+}
+
+func TestUnitStatus(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := &fakeLogs{}
+	status, err := n.UnitStatus(ctx, "app.service", node.WithLogs(logs, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := node.UnitStatus{Unit: "app.service", LoadState: node.LoadStateLoaded, ActiveState: node.ActiveStateActive,
+		SubState: node.SubStateRunning, Result: "success"}
+	if !reflect.DeepEqual(status, want) || logs.calls != 0 {
+		t.Fatalf("expected %+v without logs, got %+v", want, status)
+	}
+
+	status, err = n.UnitStatus(ctx, crashedUnit, node.WithLogs(logs, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Result != "exit-code" || status.ExecMainCode != 1 || status.ExecMainStatus != 3 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if len(status.Logs) != 3 || status.Logs[0] != "node_a crash.service 0" {
+		t.Fatalf("unexpected logs %v", status.Logs)
+	}
+
+	// targets have no type interface and no result
+	status, err = n.UnitStatus(ctx, "multi-user.target")
+	if err != nil || status.ActiveState != node.ActiveStateActive || status.Result != "" {
+		t.Fatalf("unexpected status %+v, %v", status, err)
+	}
+	if _, err := n.UnitStatus(ctx, "missing.service"); !errors.Is(err, common.ErrNoSuchUnit) {
+		t.Fatalf("expected ErrNoSuchUnit, got %v", err)
+	}
+}
+
+func TestStartUnitIf(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := node.PropertyEquals("ActiveState", "failed")
+
+	path, ok, err := n.StartUnitIf(ctx, "app.service", node.ModeReplace, failed)
+	if err != nil || ok || path != "" {
+		t.Fatalf("expected no job for an active unit, got %q, %v, %v", path, ok, err)
+	}
+	if jobs := atomic.LoadUint32(&c.jobs); jobs != 0 {
+		t.Fatalf("expected no jobs, got %d", jobs)
+	}
+
+	// the condition is checked again after the settle time
+	var checks int
+	cond := func(props map[string]interface{}) bool {
+		checks++
+		return len(props) == 1 && failed(props)
+	}
+	path, ok, err = n.StartUnitIf(ctx, crashedUnit, node.ModeReplace, cond,
+		node.WithConditionProperties("ActiveState"), node.WithSettleTime(time.Millisecond))
+	if err != nil || !ok || path == "" {
+		t.Fatalf("expected a job for the failed unit, got %q, %v, %v", path, ok, err)
+	}
+	if checks != 2 {
+		t.Errorf("expected the condition checked twice, got %d", checks)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, ok, err := n.StartUnitIf(cancelled, crashedUnit, node.ModeReplace, failed); ok || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled context, got %v, %v", ok, err)
+	}
+}
+
+func TestRunOnNodesConcurrency(t *testing.T) {
+	ctx := context.Background()
+	nodes := []string{"node_a", "node_b", "node_c", "node_d"}
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, nodes...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	var running, peak int32
+	err = m.RunOnNodes(ctx, nodes, func(ctx context.Context, n *node.Node) error {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}, manager.WithConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent calls, got %d", peak)
+	}
+}
+
+func TestRunOnNodesPerNodeSerial(t *testing.T) {
+	ctx := context.Background()
+	nodes := []string{"node_a", "node_b", "node_c", "node_d"}
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, nodes...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	var mu sync.Mutex
+	running := make(map[string]int)
+	var total, peak int
+	var raced bool
+	fn := func(ctx context.Context, n *node.Node) error {
+		mu.Lock()
+		running[n.Name()]++
+		raced = raced || running[n.Name()] > 1
+		total++
+		peak = max(peak, total)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running[n.Name()]--
+		total--
+		mu.Unlock()
+		return nil
+	}
+
+	// two batches on the same nodes, each of which may run on all of them
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = m.RunOnNodes(ctx, nodes, fn, manager.WithPerNodeSerial(), manager.WithMaxInFlight(3))
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	if raced {
+		t.Error("expected the batches to work on a node one at a time")
+	}
+	if peak > 3 {
+		t.Errorf("expected at most 3 operations in flight, got %d", peak)
+	}
+	if n := m.NodeGate().InFlight(); n != 0 {
+		t.Errorf("expected no operations in flight after the batches, got %d", n)
+	}
+
+	// a node worked on serially admits no other operation until it is done
+	leave, err := m.NodeGate().Enter(ctx, "node_a", 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer leave()
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = m.RunOnNodes(waitCtx, []string{"node_a"}, fn)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the operation to wait for the node, got %v", err)
+	}
+}
+
+func TestSetAllAgentsLogLevel(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b", "node_c")
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.SetAllAgentsLogLevel(ctx, common.LOG_LEVEL_DEBUG, manager.WithConcurrency(1)); err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	want := map[string]string{"node_a": common.LOG_LEVEL_DEBUG, "node_c": common.LOG_LEVEL_DEBUG}
+	if !maps.Equal(c.logLevels, want) {
+		t.Fatalf("expected log levels %v, got %v", want, c.logLevels)
+	}
+}
+
+func TestSetAllAgentsLogLevelNoReply(t *testing.T) {
+	nodes := []string{"node_a", "node_b", "node_c"}
+	c := serveController(t, nodes...)
+	gate := make(chan struct{})
+	c.mu.Lock()
+	c.logLevelGate = gate
+	c.mu.Unlock()
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// the calls return while the agents have not replied yet
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ctx = common.WithCallFlags(ctx, dbus.FlagNoReplyExpected)
+	if err := m.SetAllAgentsLogLevel(ctx, common.LOG_LEVEL_DEBUG, manager.WithConcurrency(1)); err != nil {
+		t.Fatal(err)
+	}
+	// calls decoding their reply ignore the flag
+	if _, err := m.ListNodes(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	close(gate)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		n := len(c.logLevels)
+		c.mu.Unlock()
+		if n == len(nodes) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the log level set on %d nodes, got %d", len(nodes), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatchStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a", "node_b")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := n.WatchStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	awaitStatus(t, statuses, node.StatusOnline)
+
+	// changes of other nodes are not reported
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	awaitStatus(t, statuses, node.StatusOffline)
+	c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "online")
+	awaitStatus(t, statuses, node.StatusOnline)
+
+	cancel()
+	select {
+	case _, ok := <-statuses:
+		if ok {
+			t.Fatal("unexpected status after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestListAndWatchNodes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a", "node_b")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	nodes, events, err := m.ListAndWatchNodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Status != node.StatusOnline || nodes[1].Status != node.StatusOnline {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+
+	// a change already contained in the list is not reported
+	err = c.conn.Emit(nodePath("node_a"), common.SIGNAL_PROPERTIES_CHANGED, common.NODE_INTERFACE,
+		map[string]dbus.Variant{"Status": dbus.MakeVariant("online")}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+
+	want := []manager.NodeEvent{
+		{Seq: 1, NodeConnectionStateChanged: manager.NodeConnectionStateChanged{Node: "node_b", OldState: node.StatusOnline, NewState: node.StatusOffline}},
+		{Seq: 2, NodeConnectionStateChanged: manager.NodeConnectionStateChanged{Node: "node_a", OldState: node.StatusOnline, NewState: node.StatusOffline}},
+	}
+	for _, w := range want {
+		select {
+		case event := <-events:
+			if event != w {
+				t.Fatalf("expected %+v, got %+v", w, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %+v", w)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestListAndWatchNodesDropsListedChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a", "node_b")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// the node flaps before the reply, the list contains the outcome
+	c.mu.Lock()
+	c.beforeList = func() {
+		c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+		c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "online")
+	}
+	c.mu.Unlock()
+	nodes, events, err := m.ListAndWatchNodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Status != node.StatusOnline {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+
+	// the first change reported is the one after the list
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	want := manager.NodeEvent{Seq: 1, NodeConnectionStateChanged: manager.NodeConnectionStateChanged{
+		Node: "node_b", OldState: node.StatusOnline, NewState: node.StatusOffline,
+	}}
+	select {
+	case event := <-events:
+		if event != want {
+			t.Fatalf("expected %+v, got %+v", want, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %+v", want)
+	}
+}
+
+func awaitStatus(t *testing.T, statuses <-chan node.NodeStatus, want node.NodeStatus) {
+	t.Helper()
+	select {
+	case status, ok := <-statuses:
+		if !ok || status != want {
+			t.Fatalf("expected status %s, got %q", want, status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for status %s", want)
+	}
+}
+
+// fakeJob is a job of the fake controller, cancelling it removes it with
+// result "cancelled".
+type fakeJob struct {
+	conn *dbus.Conn
+	id   uint32
+	path dbus.ObjectPath
+}
+
+func (j *fakeJob) Cancel() *dbus.Error {
+	_ = j.conn.Emit(common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE+".JobRemoved",
+		j.id, j.path, "node_a", "a.service", "cancelled")
+	return nil
+}
+
+func TestJobState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a")
+	j := &fakeJob{conn: c.conn, id: 7, path: dbus.ObjectPath(common.JOB_OBJECT_PATH_PREFIX + "/7")}
+	c.mu.Lock()
+	err := c.conn.Export(j, j.path, common.JOB_INTERFACE)
+	c.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, err := prop.Export(c.conn, j.path, prop.Map{
+		common.JOB_INTERFACE: {
+			"Id":      {Value: j.id},
+			"Node":    {Value: "node_a"},
+			"Unit":    {Value: "a.service"},
+			"JobType": {Value: "start"},
+			"State":   {Value: job.StateWaiting, Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	proxy, err := m.GetJob(j.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, err := proxy.State(ctx); err != nil || state != job.StateWaiting {
+		t.Fatalf("expected state %s, got %q, %v", job.StateWaiting, state, err)
+	}
+	states, err := proxy.WatchState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	awaitJobState(t, states, job.StateWaiting)
+	props.SetMust(common.JOB_INTERFACE, "State", job.StateRunning)
+	awaitJobState(t, states, job.StateRunning)
+
+	if err := proxy.Cancel(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case state, ok := <-states:
+		if ok {
+			t.Fatalf("unexpected state %q after cancel", state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after the job was removed")
+	}
+}
+
+func awaitJobState(t *testing.T, states <-chan string, want string) {
+	t.Helper()
+	select {
+	case state, ok := <-states:
+		if !ok || state != want {
+			t.Fatalf("expected state %s, got %q", want, state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for state %s", want)
+	}
+}
+
+func TestMonitorReplay(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mon.ReplaySince(0); err == nil {
+		t.Fatal("expected replay to fail before it is enabled")
+	}
+	records := mon.EnableReplay(2)
+	if _, err := mon.Subscribe(ctx, "*", "*"); err != nil {
+		t.Fatal(err)
+	}
+	for i, unit := range fakeMonitorUnits {
+		select {
+		case r := <-records:
+			if r.Seq != uint64(i+1) || r.Event.UnitName() != unit {
+				t.Fatalf("got record %+v, want %d of unit %s", r, i+1, unit)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no record")
+		}
+	}
+
+	replayed, err := mon.ReplaySince(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 2 || replayed[0].Seq != 2 || replayed[1].Event.UnitName() != fakeMonitorUnits[2] {
+		t.Fatalf("unexpected records %+v", replayed)
+	}
+	if replayed, err := mon.ReplaySince(3); err != nil || len(replayed) != 0 {
+		t.Fatalf("expected no records after the last one, got %+v (%v)", replayed, err)
+	}
+	if _, err := mon.ReplaySince(0); !errors.Is(err, monitor.ErrReplayGap) {
+		t.Fatalf("expected the first record to be dropped, got %v", err)
+	}
+
+	if err := mon.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-records; ok {
+		t.Fatal("expected the records channel to be closed")
+	}
+}
+
+func TestSharedClients(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	agentConn := testbus.Connect(t, c.address)
+	if _, err := prop.Export(agentConn, common.BC_OBJECT_PATH, prop.Map{
+		common.AGENT_INTERFACE: {"Status": {Value: "online"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	testbus.RequestName(t, agentConn, common.BC_AGENT_DBUS_NAME)
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := m.NodeClient(nodes[0].Name, nodes[0].ObjectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, err := n.Status(ctx); err != nil || !status.IsOnline() {
+		t.Fatalf("expected node_a online, got %q, %v", status, err)
+	}
+
+	a, err := m.AgentClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := a.IsConnected(ctx); err != nil || !ok {
+		t.Fatalf("expected the agent connected, got %v, %v", ok, err)
+	}
+
+	created, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mon, err := m.MonitorClient(created.ObjectPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mon.Subscribe(ctx, "*", "*"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-mon.Events():
+		if e.UnitName() != fakeMonitorUnits[0] {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event on the shared connection")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Connection(); !errors.Is(err, manager.ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected after Close, got %v", err)
+	}
+}
+
+func TestMonitorPeer(t *testing.T) {
+	ctx := context.Background()
+	address := startController(t, "node_a")
+	owner, err := manager.NewManager(manager.WithBusAddress(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer owner.Close()
+	sidecar, err := manager.NewManager(manager.WithBusAddress(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sidecar.Close()
+
+	mon, err := owner.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mon.Close(ctx)
+	shared, err := sidecar.AttachMonitor(mon.ObjectPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := sidecar.UniqueName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := mon.AddPeer(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mon.RemovePeer(ctx, id+1, "unknown"); !errors.Is(err, common.ErrNoSuchPeer) {
+		t.Fatalf("expected ErrNoSuchPeer, got %v", err)
+	}
+	if err := mon.RemovePeer(ctx, id, "done"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []monitor.Event{
+		monitor.UnitNew{Node: "node_a", Unit: "a.service", Reason: "real"},
+		monitor.PeerRemoved{Reason: "done"},
+	}
+	for _, w := range want {
+		select {
+		case event := <-shared.Events():
+			if event != w {
+				t.Fatalf("expected event %+v, got %+v", w, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %+v", w)
+		}
+	}
+	select {
+	case event, ok := <-shared.Events():
+		if ok {
+			t.Fatalf("unexpected event %+v after PeerRemoved", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("events not closed after PeerRemoved")
+	}
+}
+
+func TestWatchPattern(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mon.Close(ctx)
+	id, events, err := mon.Watch(ctx, "node_*", "foo@*.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unit := range []string{"foo@1.service", "foo@2.service"} {
+		select {
+		case event := <-events:
+			if event.UnitName() != unit {
+				t.Fatalf("expected event of %s, got %+v", unit, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", unit)
+		}
+	}
+	c.mu.Lock()
+	fm := c.monitor
+	c.mu.Unlock()
+	fm.mu.Lock()
+	subscribed := fm.subscribed
+	fm.mu.Unlock()
+	if len(subscribed) != 1 || subscribed[0] != "* *" {
+		t.Fatalf("expected a wildcard subscription on the controller, got %v", subscribed)
+	}
+	select {
+	case event := <-mon.Events():
+		t.Fatalf("unexpected event %+v on Events of a watched subscription", event)
+	default:
+	}
+
+	if err := mon.Unsubscribe(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-events; ok {
+		t.Fatal("expected watch to be closed by Unsubscribe")
+	}
+}
+
+func TestWatchCluster(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	w, err := m.WatchCluster(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() manager.ClusterEvent {
+		t.Helper()
+		select {
+		case e := <-w.Events():
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no cluster event")
+			return nil
+		}
+	}
+
+	var seq uint64
+	for range fakeMonitorUnits {
+		e := next()
+		if u, ok := e.(manager.UnitEvent); !ok || u.NodeName() != "node_a" {
+			t.Fatalf("got %#v, want unit event of node_a", e)
+		}
+		if e.Sequence() != seq+1 {
+			t.Fatalf("got sequence %d, want %d", e.Sequence(), seq+1)
+		}
+		seq = e.Sequence()
+	}
+
+	c.nodeProps["node_a"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	e := next()
+	want := manager.NodeConnectionStateChanged{Node: "node_a", OldState: "online", NewState: "offline"}
+	if n, ok := e.(manager.NodeEvent); !ok || n.NodeConnectionStateChanged != want || n.Seq != seq+1 {
+		t.Fatalf("got %#v, want node event %d %+v", e, seq+1, want)
+	}
+	seq = e.Sequence()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := n.StartUnit(ctx, "a.service", "replace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = next()
+	j, ok := e.(manager.JobEvent)
+	if !ok || j.JobPath() != path || j.Seq != seq+1 {
+		t.Fatalf("got %#v, want job event %d of %s", e, seq+1, path)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-w.Events(); ok {
+		t.Fatal("events not closed")
+	}
+}
+
+func TestAPI(t *testing.T) {
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	var api manager.ManagerAPI = m.API()
+	n, err := api.GetNode(context.Background(), "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Name() != "node_a" {
+		t.Fatalf("unexpected node %s", n.Name())
+	}
+	if n, err := api.GetNode(context.Background(), "node_x"); err == nil || n != nil {
+		t.Fatalf("expected no node and an error, got %v and %v", n, err)
+	}
+}
+
+func TestConcurrentCalls(t *testing.T) {
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a", "node_b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			name := "node_a"
+			if worker%2 == 1 {
+				name = "node_b"
+			}
+			for iteration := 0; iteration < 10; iteration++ {
+				if _, err := m.ListNodes(ctx); err != nil {
+					errs <- err
+					return
+				}
+				n, err := m.GetNode(ctx, name)
+				if err != nil {
+					errs <- err
+					return
+				}
+				unit := fmt.Sprintf("unit-%d-%d.service", worker, iteration)
+				if err := n.StartUnitAndWait(ctx, unit, node.ModeReplace); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestConcurrentClose(t *testing.T) {
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for iteration := 0; iteration < 50; iteration++ {
+				_, err := m.ListNodes(context.Background())
+				if errors.Is(err, manager.ErrNotConnected) {
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	if err := m.Close(); err != nil {
+		t.Error(err)
+	}
+	wg.Wait()
+
+	if _, err := m.ListNodes(context.Background()); !errors.Is(err, manager.ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected after Close, got %v", err)
+	}
+}
+
+func TestListUnits(t *testing.T) {
+	m := connect(t, startController(t, "node_a", "node_b"))
+
+	units, err := m.ListUnits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 2 || len(units["node_a"]) != len(fakeUnits) || units["node_b"][1] != fakeUnits[1] {
+		t.Fatalf("unexpected units %v", units)
+	}
+
+	n, err := m.GetNode(context.Background(), "node_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeUnits, err := n.ListUnits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodeUnits) != len(fakeUnits) || nodeUnits[0] != fakeUnits[0] {
+		t.Fatalf("unexpected units of node_b %v", nodeUnits)
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	m := connect(t, startController(t, "node_a"))
+
+	n, err := m.GetNode(context.Background(), "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Name() != "node_a" || n.ObjectPath() != nodePath("node_a") {
+		t.Fatalf("unexpected node %s at %s", n.Name(), n.ObjectPath())
+	}
+	if _, err := m.GetNode(context.Background(), "node_b"); err == nil {
+		t.Fatal("expected an error for an unknown node")
+	}
+}
+
+func TestUnitLifecycle(t *testing.T) {
+	m := connect(t, startController(t, "node_a"))
+	n, err := m.GetNode(context.Background(), "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []func(context.Context, string, string) (dbus.ObjectPath, error){n.StartUnit, n.StopUnit, n.RestartUnit, n.ReloadUnit}
+	for idx, op := range ops {
+		job, err := op(context.Background(), "nginx.service", node.ModeReplace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := dbus.ObjectPath(fmt.Sprintf("%s/%d", common.JOB_OBJECT_PATH_PREFIX, idx+1)); job != expected {
+			t.Fatalf("expected job %s, got %s", expected, job)
+		}
+	}
+}
+
+func TestSubscribeNodeConnectionStateChanged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a", "node_b")
+	m := connect(t, c.address)
+
+	events, err := m.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "online")
+	want := []manager.NodeConnectionStateChanged{
+		{Node: "node_b", OldState: "online", NewState: "offline"},
+		{Node: "node_b", OldState: "offline", NewState: "online"},
+	}
+	for _, w := range want {
+		select {
+		case event := <-events:
+			if event != w {
+				t.Fatalf("expected event %+v, got %+v", w, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %+v", w)
+		}
+	}
+
+	cancel()
+	select {
+	case event, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected event %+v after cancel", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	m := connect(t, startController(t, "node_a"))
+
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mon.Subscribe(ctx, common.SYMBOL_WILDCARD, common.SYMBOL_WILDCARD); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mon.SubscribeList(ctx, "node_a", []string{"nginx.service"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var want []monitor.Event
+	for _, unit := range fakeMonitorUnits {
+		want = append(want, monitor.UnitNew{Node: "node_a", Unit: unit, Reason: "real"})
+	}
+	want = append(want, monitor.UnitStateChanged{Node: "node_a", Unit: "nginx.service", ActiveState: "active", SubState: "running", Reason: "real"})
+	for _, w := range want {
+		select {
+		case event := <-mon.Events():
+			if event != w {
+				t.Fatalf("expected event %+v, got %+v", w, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %+v", w)
+		}
+	}
+	select {
+	case event := <-mon.Events():
+		changed, ok := event.(monitor.UnitPropertiesChanged)
+		if !ok || changed.Unit != "nginx.service" || changed.Properties["MainPID"].Value() != uint32(42) {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for UnitPropertiesChanged")
+	}
+
+	if err := mon.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event, ok := <-mon.Events():
+		if ok {
+			t.Fatalf("unexpected event %+v after Close", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("events not closed by Close")
+	}
+}
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m := connect(t, c.address)
+
+	path := dbus.ObjectPath(common.JOB_OBJECT_PATH_PREFIX + "/7")
+	_, err := prop.Export(c.conn, path, prop.Map{
+		common.JOB_INTERFACE: {
+			"Id":      {Value: uint32(7)},
+			"Node":    {Value: "node_a"},
+			"Unit":    {Value: "a.service"},
+			"JobType": {Value: "start"},
+			"State":   {Value: "running"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := introspect.NewIntrospectable(&introspect.Node{Children: []introspect.Node{{Name: "7"}}})
+	if err := c.conn.Export(jobs, common.JOB_OBJECT_PATH_PREFIX, common.INTROSPECTABLE_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+
+	want := job.Info{ID: 7, ObjectPath: path, Node: "node_a", Unit: "a.service", JobType: "start", State: "running"}
+	infos, err := m.ListJobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0] != want {
+		t.Fatalf("expected job %+v, got %+v", want, infos)
+	}
+
+	tracker, err := m.TrackJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Close()
+	if err := c.conn.Emit(common.BC_OBJECT_PATH, common.SIGNAL_JOB_NEW, uint32(8), path); err != nil {
+		t.Fatal(err)
+	}
+
+	// WaitForJob misses signals emitted before it listens
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			_ = c.conn.Emit(common.BC_OBJECT_PATH, common.SIGNAL_JOB_REMOVED, uint32(7), path, "node_a", "a.service", job.ResultDone)
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if result, err := m.WaitForJob(waitCtx, path); err != nil || result != job.ResultDone {
+		t.Fatalf("expected result done, got %q, %v", result, err)
+	}
+
+	wantEvents := []job.Event{
+		job.JobNew{ID: 8, Path: path},
+		job.JobRemoved{ID: 7, Path: path, Node: "node_a", Unit: "a.service", Result: job.ResultDone},
+	}
+	for _, w := range wantEvents {
+		select {
+		case event := <-tracker.Events():
+			if event != w {
+				t.Fatalf("expected event %+v, got %+v", w, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %+v", w)
+		}
+	}
+}
+
+func TestCanceled(t *testing.T) {
+	m := connect(t, startController(t, "node_a"))
+	n, err := m.GetNode(context.Background(), "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.ListNodes(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled listing the nodes, got %v", err)
+	}
+	if _, err := n.StartUnit(ctx, "nginx.service", node.ModeReplace); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled starting a unit, got %v", err)
+	}
+}
+
+func TestUnitProperties(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	c.activeStates = map[string]string{"nginx.service": "active"}
+	m := connect(t, c.address)
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	props, err := n.GetUnitProperties(ctx, "nginx.service", common.SYSTEMD_UNIT_INTERFACE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props["Id"] != "nginx.service" || props["SubState"] != "running" {
+		t.Fatalf("unexpected properties %v", props)
+	}
+	if pid, err := n.GetUnitProperty(ctx, "nginx.service", common.SYSTEMD_SERVICE_INTERFACE, "MainPID"); err != nil || pid != uint32(42) {
+		t.Fatalf("expected MainPID 42, got %v, %v", pid, err)
+	}
+	if state, err := n.GetUnitActiveState(ctx, "nginx.service"); err != nil || state != "active" {
+		t.Fatalf("expected active state active, got %q, %v", state, err)
+	}
+	if state, err := n.GetUnitSubState(ctx, "nginx.service"); err != nil || state != "running" {
+		t.Fatalf("expected sub state running, got %q, %v", state, err)
+	}
+	if cgroup, err := n.GetUnitCGroupPath(ctx, "nginx.service"); err != nil || cgroup != "/system.slice/nginx.service" {
+		t.Fatalf("expected the control group of nginx.service, got %q, %v", cgroup, err)
+	}
+	if _, err := n.GetUnitCGroupPath(ctx, "backup.timer"); err == nil {
+		t.Fatal("expected an error for the control group of a timer")
+	}
+	if _, err := n.GetUnitProperty(ctx, "nginx.service", common.SYSTEMD_UNIT_INTERFACE, "Bogus"); err == nil {
+		t.Fatal("expected an error for an unknown property")
+	}
+}
+
+func TestSetUnitProperties(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m := connect(t, c.address)
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.SetUnitCPUQuota(ctx, "nginx.service", true, 150); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetUnitCPUWeight(ctx, "nginx.service", true, 200); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetUnitMemoryMax(ctx, "nginx.service", true, 1<<30); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetUnitProperties(ctx, "nginx.service", false, map[string]interface{}{"Description": dbus.MakeVariant("web server")}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"CPUQuotaPerSecUSec": uint64(1500000),
+		"CPUWeight":          uint64(200),
+		"MemoryMax":          uint64(1 << 30),
+		"Description":        "web server",
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, value := range want {
+		if got := c.setProps[name].Value(); got != value {
+			t.Errorf("expected %s %v, got %v", name, value, got)
+		}
+	}
+
+	for _, percent := range []float64{0, -50, math.NaN(), math.Inf(1)} {
+		if err := n.SetUnitCPUQuota(ctx, "nginx.service", true, percent); err == nil {
+			t.Errorf("expected an error for CPU quota %v", percent)
+		}
+	}
+	for _, weight := range []uint64{0, 10001} {
+		if err := n.SetUnitCPUWeight(ctx, "nginx.service", true, weight); err == nil {
+			t.Errorf("expected an error for CPU weight %d", weight)
+		}
+	}
+}
+
+func TestEnableUnitFiles(t *testing.T) {
+	ctx := context.Background()
+	m := connect(t, startController(t, "node_a"))
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := n.EnableUnitFiles(ctx, []string{"nginx.service", "sshd.service"}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.CarriesInstallInfo || len(result.Changes) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	want := node.UnitFileChange{
+		Type:        node.ChangeSymlink,
+		FileName:    "/etc/systemd/system/multi-user.target.wants/sshd.service",
+		Destination: "/usr/lib/systemd/system/sshd.service",
+	}
+	if result.Changes[1] != want {
+		t.Fatalf("got change %+v, want %+v", result.Changes[1], want)
+	}
+
+	changes, err := n.DisableUnitFiles(ctx, []string{"nginx.service"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Type != node.ChangeUnlink {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+}
+
+func TestFreezeUnit(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m := connect(t, c.address)
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frozen := func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.frozen["nginx.service"]
+	}
+	if err := n.FreezeUnit(ctx, "nginx.service"); err != nil || !frozen() {
+		t.Fatalf("expected nginx.service to be frozen, got %v", err)
+	}
+	if err := n.ThawUnit(ctx, "nginx.service"); err != nil || frozen() {
+		t.Fatalf("expected nginx.service to be thawed, got %v", err)
+	}
+	if err := n.FreezeUnit(ctx, "missing.service"); err == nil {
+		t.Fatal("expected an error freezing an unknown unit")
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m := connect(t, c.address)
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reloads != 1 {
+		t.Fatalf("got %d reloads, want 1", c.reloads)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b")
+	m := connect(t, c.address)
+
+	if err := m.SetLogLevel(ctx, common.LOG_LEVEL_DEBUG); err != nil {
+		t.Fatal(err)
+	}
+	n, err := m.GetNode(ctx, "node_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetLogLevel(ctx, common.LOG_LEVEL_WARN); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.logLevel != common.LOG_LEVEL_DEBUG {
+		t.Fatalf("expected controller log level %s, got %q", common.LOG_LEVEL_DEBUG, c.logLevel)
+	}
+	if len(c.logLevels) != 1 || c.logLevels["node_b"] != common.LOG_LEVEL_WARN {
+		t.Fatalf("expected log level %s on node_b only, got %v", common.LOG_LEVEL_WARN, c.logLevels)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := serveController(t, "node_a")
+	m := connect(t, c.address)
+
+	events, err := m.SubscribeMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EnableMetrics(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		if e.NodeName() != "node_a" || e.(metrics.StartUnitJobMetrics).JobMeasuredTime != 1500*time.Microsecond {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for metrics")
+	}
+	if err := m.DisableMetrics(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metrics {
+		t.Fatal("expected metrics to be disabled")
+	}
+}
+
+func TestStartUnitAndWait(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m := connect(t, startController(t, "node_a"))
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.StartUnitAndWait(ctx, "nginx.service", node.ModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.StopUnitAndWait(ctx, "nginx.service", node.ModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.RestartUnitAndWait(ctx, "nginx.service", node.ModeReplace); err == nil {
+		t.Fatal("expected an error for a failed job")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := (&manager.Manager{}).ListNodes(context.Background()); !errors.Is(err, manager.ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected before Connect, got %v", err)
+	}
+
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", "unix:path="+t.TempDir()+"/missing")
+	if err := (&manager.Manager{}).Connect(); err == nil {
+		t.Fatal("expected an error connecting to a missing bus")
+	}
+
+	// a bus without controller
+	m := connect(t, testbus.Start(t))
+	if _, err := m.ListNodes(context.Background()); err == nil {
+		t.Fatal("expected an error listing the nodes without controller")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b")
+	policy := manager.CircuitBreakerPolicy{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond}
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithCircuitBreaker(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	a, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := m.GetNode(ctx, "node_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "offline")
+	for i := 0; i < 2; i++ {
+		if _, err := b.ListUnits(ctx); !errors.Is(err, common.ErrNodeOffline) {
+			t.Fatalf("expected node_b to be offline, got %v", err)
+		}
+	}
+	if _, err := b.ListUnits(ctx); !errors.Is(err, common.ErrNodeCircuitOpen) {
+		t.Fatalf("expected the breaker of node_b to be open, got %v", err)
+	}
+	if _, err := a.ListUnits(ctx); err != nil {
+		t.Fatalf("expected node_a not to be affected, got %v", err)
+	}
+
+	// a failing probe opens the breaker again
+	time.Sleep(60 * time.Millisecond)
+	if _, err := b.ListUnits(ctx); !errors.Is(err, common.ErrNodeOffline) {
+		t.Fatalf("expected the probe to reach node_b, got %v", err)
+	}
+	if _, err := b.ListUnits(ctx); !errors.Is(err, common.ErrNodeCircuitOpen) {
+		t.Fatalf("expected the breaker of node_b to be open again, got %v", err)
+	}
+
+	c.nodeProps["node_b"].SetMust(common.NODE_INTERFACE, "Status", "online")
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := b.ListUnits(ctx); err != nil {
+			t.Fatalf("expected the breaker of node_b to close, got %v", err)
+		}
+	}
+}
+
+func TestRawCall(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b")
+	policy := manager.RetryPolicy{MaxAttempts: 3, Backoff: manager.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}}
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithRetry(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	body, err := m.RawCall(ctx, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE, "ListNodes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes, ok := body[0].([][]interface{}); !ok || len(nodes) != 2 || nodes[0][0] != "node_a" {
+		t.Fatalf("unexpected reply %#v", body)
+	}
+	if _, err := m.RawCall(ctx, nodePath("node_a"), common.NODE_INTERFACE, "NoSuchMethod"); !errors.Is(err, common.ErrUnknownMethod) {
+		t.Fatalf("expected %v, got %v", common.ErrUnknownMethod, err)
+	}
+	for _, bad := range []struct {
+		path          dbus.ObjectPath
+		iface, method string
+	}{
+		{"/org/freedesktop/systemd1", common.CONTROLLER_INTERFACE, "ListNodes"},
+		{common.BC_OBJECT_PATH, "org.freedesktop.systemd1.Manager", "ListUnits"},
+		{common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE, "Node.ListUnits"},
+	} {
+		if _, err := m.RawCall(ctx, bad.path, bad.iface, bad.method); !errors.Is(err, common.ErrInvalidArgs) {
+			t.Fatalf("expected raw call of %s.%s on %s to be rejected, got %v", bad.iface, bad.method, bad.path, err)
+		}
+	}
+
+	// raw calls are not retried, they might not be idempotent
+	atomic.StoreInt32(&c.flaky, 1)
+	if _, err := m.RawCall(ctx, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE, "ListNodes"); err == nil {
+		t.Fatal("expected the raw call not to be retried")
+	}
+
+	// raw calls are treated as mutating in dry-run mode
+	dry, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dry.Close()
+	if body, err := dry.RawCall(ctx, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE, "ListNodes"); err != nil || len(body) != 0 {
+		t.Fatalf("expected an empty reply in dry-run mode, got %#v (%v)", body, err)
+	}
+	if ops := dry.DryRunOperations(); len(ops) != 1 || ops[0].Method != common.CONTROLLER_INTERFACE+".ListNodes" {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	policy := manager.RetryPolicy{MaxAttempts: 3, Backoff: manager.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}}
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithRetry(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	atomic.StoreInt32(&c.flaky, 2)
+	if _, err := m.ListNodes(ctx); err != nil {
+		t.Fatalf("expected ListNodes to succeed on the third attempt, got %v", err)
+	}
+	atomic.StoreInt32(&c.flaky, 3)
+	if _, err := m.ListNodes(ctx); err == nil {
+		t.Fatal("expected ListNodes to fail after three attempts")
+	}
+
+	// jobs are not queued twice
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&c.flaky, 1)
+	if _, err := n.StartUnit(ctx, "a.service", node.ModeReplace); err == nil {
+		t.Fatal("expected StartUnit not to be retried")
+	}
+	if atomic.LoadInt32(&c.flaky) != 0 {
+		t.Fatal("expected StartUnit to be attempted once")
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	c := serveController(t)
+	atomic.StoreInt64(&c.slow, int64(200*time.Millisecond))
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithCallTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if _, err := m.ListNodes(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	if _, err := manager.NewManager(manager.WithBusAddress("")); err == nil {
+		t.Fatal("expected an error for an empty bus address")
+	}
+	if _, err := manager.NewManager(manager.WithAutoReconnect(manager.Backoff{Initial: time.Second, Max: time.Millisecond})); err == nil {
+		t.Fatal("expected an error for a maximum delay shorter than the initial delay")
+	}
+	if _, err := manager.NewManager(manager.WithLogger(nil)); err == nil {
+		t.Fatal("expected an error for a nil logger")
+	}
+	if _, err := manager.NewManager(manager.WithCallTimeout(0)); err == nil {
+		t.Fatal("expected an error for a zero call timeout")
+	}
+	if _, err := manager.NewManager(manager.WithRetry(manager.RetryPolicy{})); err == nil {
+		t.Fatal("expected an error for a retry policy without attempts")
+	}
+	if _, err := manager.NewManager(manager.WithEventOverflow(manager.OverflowPolicy(42))); err == nil {
+		t.Fatal("expected an error for an unknown overflow policy")
+	}
+	if _, err := manager.NewManager(manager.WithLabelStore(nil)); err == nil {
+		t.Fatal("expected an error for a nil label store")
+	}
+	if _, err := manager.NewManager(manager.WithHook(nil)); err == nil {
+		t.Fatal("expected an error for a nil hook")
+	}
+	if _, err := manager.NewManager(manager.WithTracerProvider(nil)); err == nil {
+		t.Fatal("expected an error for a nil tracer provider")
+	}
+	if _, err := manager.NewManager(manager.WithNames(manager.Names{ObjectPath: "ctl"})); err == nil {
+		t.Fatal("expected an error for a relative controller object path")
+	}
+	if _, err := manager.NewManager(manager.WithNames(manager.Names{Service: "bluechi"})); err == nil {
+		t.Fatal("expected an error for a bus name without dots")
+	}
+}
+
+func TestPing(t *testing.T) {
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a", "node_b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	report, err := m.Ping(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Controller <= 0 || len(report.Nodes) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for _, r := range report.Nodes {
+		if r.Err != nil || r.RTT <= 0 {
+			t.Fatalf("unexpected result %+v", r)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")), manager.WithTracerProvider(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "deploy")
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.GetUnitActiveState(ctx, "nginx.service"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.GetUnitProperty(ctx, "nginx.service", common.SYSTEMD_UNIT_INTERFACE, "Unknown"); err == nil {
+		t.Fatal("expected reading an unknown property to fail")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	want := []struct {
+		name string
+		node string
+		unit string
+		code codes.Code
+	}{
+		{"org.eclipse.bluechi.Controller/GetNode", "node_a", "", codes.Unset},
+		{"org.eclipse.bluechi.Node/GetUnitProperty", "node_a", "nginx.service", codes.Unset},
+		{"org.eclipse.bluechi.Node/GetUnitProperty", "node_a", "nginx.service", codes.Error},
+	}
+	for i, w := range want {
+		s := spans[i]
+		attrs := make(map[attribute.Key]string)
+		for _, kv := range s.Attributes() {
+			attrs[kv.Key] = kv.Value.Emit()
+		}
+		if s.Name() != w.name || attrs["bluechi.node"] != w.node || attrs["bluechi.unit"] != w.unit || s.Status().Code != w.code {
+			t.Fatalf("unexpected span %s with attributes %v and status %v", s.Name(), attrs, s.Status())
+		}
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("span %s is not a child of the span of the context", s.Name())
+		}
+	}
+}
+
+// recordHook records the operations passed to it and denies stopping
+// units if deny is set.
+type recordHook struct {
+	mu     sync.Mutex
+	deny   bool
+	before []manager.Operation
+	after  []error
+}
+
+var errDenied = errors.New("denied by policy")
+
+func (h *recordHook) Before(ctx context.Context, op manager.Operation) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.before = append(h.before, op)
+	if h.deny && op.Method == common.METHOD_STOP_UNIT {
+		return errDenied
+	}
+	return nil
+}
+
+func (h *recordHook) After(ctx context.Context, op manager.Operation, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.after = append(h.after, err)
+}
+
+func TestHooks(t *testing.T) {
+	ctx := context.Background()
+	audit, policy := &recordHook{}, &recordHook{deny: true}
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")), manager.WithHook(audit), manager.WithHook(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.ListUnits(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.StartUnit(ctx, "nginx.service", node.ModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.StopUnit(ctx, "nginx.service", node.ModeReplace); !errors.Is(err, errDenied) {
+		t.Fatalf("expected the policy to deny stopping, got %v", err)
+	}
+
+	if len(audit.before) != 2 || len(policy.before) != 2 {
+		t.Fatalf("expected only the mutating calls to be passed to the hooks, got %+v", audit.before)
+	}
+	op := audit.before[0]
+	if op.Method != common.METHOD_START_UNIT || op.Node != "node_a" || op.Unit != "nginx.service" || op.Path != nodePath("node_a") {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+	if len(audit.after) != 2 || audit.after[0] != nil || !errors.Is(audit.after[1], errDenied) {
+		t.Fatalf("unexpected outcomes %v", audit.after)
+	}
+	if len(policy.after) != 1 {
+		t.Fatalf("expected After of the denying hook only for the allowed operation, got %v", policy.after)
+	}
+}
+
+func TestUnitJobAsync(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pending []<-chan node.JobResult
+	for i := 0; i < 50; i++ {
+		pending = append(pending, n.StartUnitAsync(ctx, fmt.Sprintf("app-%d.service", i), node.ModeReplace))
+	}
+	paths := make(map[dbus.ObjectPath]bool)
+	for i, ch := range pending {
+		res := <-ch
+		if res.Err != nil || res.Result != job.ResultDone || res.Node != "node_a" || res.Unit != fmt.Sprintf("app-%d.service", i) {
+			t.Fatalf("unexpected result %+v", res)
+		}
+		paths[res.Path] = true
+		if _, ok := <-ch; ok {
+			t.Fatal("expected the channel to be closed after the result")
+		}
+	}
+	if len(paths) != len(pending) {
+		t.Fatalf("expected %d jobs, got %d", len(pending), len(paths))
+	}
+
+	if res := <-n.ReloadUnitAsync(ctx, "missing.service", node.ModeReplace); res.Err == nil || res.Path != "" {
+		t.Fatalf("expected the call to fail, got %+v", res)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	res := <-n.StartUnitAsync(waitCtx, hangingUnit, node.ModeReplace)
+	if !errors.Is(res.Err, context.DeadlineExceeded) || res.Path == "" || res.Result != "" {
+		t.Fatalf("expected the wait to time out, got %+v", res)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.StartUnitAndWait(ctx, "nginx.service", node.ModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.EnableUnitFiles(ctx, []string{"nginx.service"}, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.DisableUnitFiles(ctx, []string{"nginx.service"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.StopUnit(ctx, "missing.service", node.ModeReplace); !errors.Is(err, common.ErrNoSuchUnit) {
+		t.Fatalf("expected common.ErrNoSuchUnit for a unit not found, got %v", err)
+	}
+	if _, err := n.RestartUnit(ctx, "nginx.service", "bogus"); !errors.Is(err, common.ErrInvalidArgs) {
+		t.Fatalf("expected common.ErrInvalidArgs for an unknown job mode, got %v", err)
+	}
+	if jobs := atomic.LoadUint32(&c.jobs); jobs != 0 {
+		t.Fatalf("expected no jobs queued in dry-run mode, got %d", jobs)
+	}
+
+	ops := m.DryRunOperations()
+	if len(ops) != 3 || ops[0].Method != common.METHOD_START_UNIT || ops[0].Unit != "nginx.service" || ops[1].Method != common.METHOD_ENABLE_UNIT_FILES {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+}
+
+// TestDryRunConnection checks that the objects of the connection of a
+// manager are intercepted like its own calls, including setting properties.
+func TestDryRunConnection(t *testing.T) {
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	conn, err := m.Connection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := conn.Object(common.BC_DBUS_NAME, common.BC_OBJECT_PATH)
+	logLevel := common.CONTROLLER_INTERFACE + ".LogLevel"
+	if err := obj.SetProperty(logLevel, common.LOG_LEVEL_DEBUG); err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.SetProperty("LogLevel", common.LOG_LEVEL_DEBUG); err == nil {
+		t.Fatal("expected an error for a property without interface")
+	}
+	v, err := obj.GetProperty(logLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value() != common.LOG_LEVEL_INFO {
+		t.Fatalf("expected the log level to be left unchanged, got %v", v.Value())
+	}
+	call := <-obj.Go(common.METHOD_PROPERTIES_SET, 0, nil, common.CONTROLLER_INTERFACE, "LogTarget", dbus.MakeVariant("stderr")).Done
+	if call.Err != nil {
+		t.Fatal(call.Err)
+	}
+
+	ops := m.DryRunOperations()
+	if len(ops) != 2 || ops[0].Method != common.METHOD_PROPERTIES_SET || ops[1].Method != common.METHOD_PROPERTIES_SET {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+}
+
+// polkitController requires interactive authorization to change the log
+// level like a controller whose polkit policy asks for authentication.
+type polkitController struct{}
+
+func (polkitController) SetLogLevel(msg dbus.Message, level string) *dbus.Error {
+	if msg.Flags&dbus.FlagAllowInteractiveAuthorization == 0 {
+		return dbus.NewError(common.ERROR_INTERACTIVE_AUTH_REQUIRED, []interface{}{"Interactive authentication required."})
+	}
+	return nil
+}
+
+func TestInteractiveAuthorization(t *testing.T) {
+	ctx := context.Background()
+	address := testbus.Start(t)
+	conn := testbus.Connect(t, address)
+	if err := conn.Export(polkitController{}, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+	testbus.RequestName(t, conn, common.BC_DBUS_NAME)
+
+	m, err := manager.NewManager(manager.WithBusAddress(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.SetLogLevel(ctx, common.LOG_LEVEL_DEBUG); !errors.Is(err, common.ErrNotAuthorized) {
+		t.Fatalf("expected common.ErrNotAuthorized, got %v", err)
+	}
+
+	interactive, err := manager.NewManager(manager.WithBusAddress(address), manager.WithInteractiveAuthorization())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer interactive.Close()
+	if err := interactive.SetLogLevel(ctx, common.LOG_LEVEL_DEBUG); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// customController serves a controller deployed with other D-Bus names.
+type customController struct{}
+
+func (customController) ListNodes() ([]fakeNodeEntry, *dbus.Error) {
+	return []fakeNodeEntry{{Name: "node_a", Path: "/com/example/ctl/node/node_a", Status: "online"}}, nil
+}
+
+func (customController) GetNode(name string) (dbus.ObjectPath, *dbus.Error) {
+	return "/com/example/ctl/node/" + dbus.ObjectPath(name), nil
+}
+
+func TestNames(t *testing.T) {
+	ctx := context.Background()
+	address := testbus.Start(t)
+	conn := testbus.Connect(t, address)
+	if err := conn.Export(customController{}, "/com/example/ctl", "com.example.ctl.Controller"); err != nil {
+		t.Fatal(err)
+	}
+	props, err := prop.Export(conn, "/com/example/ctl/node/node_a", prop.Map{
+		"com.example.ctl.Node": {
+			"Name":   {Value: "node_a"},
+			"Status": {Value: "online", Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testbus.RequestName(t, conn, "com.example.ctl")
+
+	m, err := manager.NewManager(manager.WithBusAddress(address), manager.WithNames(manager.Names{
+		Service:    "com.example.ctl",
+		ObjectPath: "/com/example/ctl",
+		Interface:  "com.example.ctl",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].ObjectPath != nodePath("node_a") {
+		t.Fatalf("expected the node at its standard path, got %+v", nodes)
+	}
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, err := n.Status(ctx); err != nil || status != "online" {
+		t.Fatalf("expected status online, got %q, %v", status, err)
+	}
+
+	events, err := m.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props.SetMust("com.example.ctl.Node", "Status", "offline")
+	select {
+	case e := <-events:
+		if e.Node != "node_a" || e.NewState != "offline" {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the node status change")
+	}
+}
+
+// recordHandler is a slog.Handler passing the messages of all records on.
+type recordHandler struct {
+	messages chan string
+}
+
+func (h recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h recordHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h recordHandler) Handle(_ context.Context, r slog.Record) error {
+	select {
+	case h.messages <- r.Message:
+	default:
+	}
+	return nil
+}
+
+func TestLogger(t *testing.T) {
+	address := testbus.Start(t)
+	handler := recordHandler{messages: make(chan string, 16)}
+	m, err := manager.NewManager(
+		manager.WithBusAddress(address),
+		manager.WithAutoReconnect(manager.Backoff{Initial: 10 * time.Millisecond}),
+		manager.WithLogger(slog.New(handler)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	states := m.ConnectionEvents()
+	controller := testbus.Connect(t, address)
+	testbus.RequestName(t, controller, common.BC_DBUS_NAME)
+	awaitState(t, states, manager.Connected)
+	if _, err := controller.ReleaseName(common.BC_DBUS_NAME); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-handler.messages:
+			if msg == "controller left the bus" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected the controller leaving the bus to be logged")
+		}
+	}
+}
+
+func awaitState(t *testing.T, states <-chan manager.ConnState, want manager.ConnState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state := <-states:
+			if state == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %s", want)
+		}
+	}
+}
+
+func TestNodeCache(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a", "node_b")
+	m, err := manager.NewManager(
+		manager.WithBusAddress(c.address),
+		manager.WithAutoReconnect(manager.Backoff{Initial: 10 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	first, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatal("expected the cached proxy to be returned")
+	}
+	if _, err := m.GetNode(ctx, "node_b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetNode(ctx, "node_x"); err == nil {
+		t.Fatal("expected an unknown node to fail")
+	}
+	if want := (manager.NodeCacheStats{Hits: 1, Misses: 3, Size: 2}); m.NodeCacheStats() != want {
+		t.Fatalf("expected %+v, got %+v", want, m.NodeCacheStats())
+	}
+
+	m.InvalidateNodes("node_a", "node_x")
+	if want := (manager.NodeCacheStats{Hits: 1, Misses: 3, Invalidations: 1, Size: 1}); m.NodeCacheStats() != want {
+		t.Fatalf("expected %+v, got %+v", want, m.NodeCacheStats())
+	}
+	if n, err := m.GetNode(ctx, "node_a"); err != nil || n == first {
+		t.Fatalf("expected a new proxy, got %v", err)
+	}
+
+	// the controller leaving and rejoining the bus drops all proxies
+	states := m.ConnectionEvents()
+	if _, err := c.conn.ReleaseName(common.BC_DBUS_NAME); err != nil {
+		t.Fatal(err)
+	}
+	awaitState(t, states, manager.Reconnecting)
+	testbus.RequestName(t, c.conn, common.BC_DBUS_NAME)
+	awaitState(t, states, manager.Connected)
+	deadline := time.Now().Add(5 * time.Second)
+	for m.NodeCacheStats().Size != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the cache to be flushed, got %+v", m.NodeCacheStats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// mismatchedController replies to ListNodes with the status of the nodes
+// as a number, like a controller of another version.
+type mismatchedController struct{}
+
+type mismatchedNodeEntry struct {
+	Name   string
+	Path   dbus.ObjectPath
+	Status uint32
+}
+
+func (mismatchedController) ListNodes() ([]mismatchedNodeEntry, *dbus.Error) {
+	return []mismatchedNodeEntry{{Name: "n1", Path: nodePath("n1"), Status: 1}}, nil
+}
+
+func TestStrictDecode(t *testing.T) {
+	ctx := context.Background()
+
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "n1")), manager.WithStrictDecode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, err := m.ListNodes(ctx); err != nil {
+		t.Fatalf("failed to list the nodes of a matching controller: %v", err)
+	}
+	if _, err := m.GetNode(ctx, "n1"); err != nil {
+		t.Fatalf("failed to get a node of a matching controller: %v", err)
+	}
+
+	address := testbus.Start(t)
+	conn := testbus.Connect(t, address)
+	if err := conn.Export(mismatchedController{}, common.BC_OBJECT_PATH, common.CONTROLLER_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+	testbus.RequestName(t, conn, common.BC_DBUS_NAME)
+
+	lenient, err := manager.NewManager(manager.WithBusAddress(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lenient.Close()
+	_, err = lenient.ListNodes(ctx)
+	var e *common.SchemaError
+	if err == nil || errors.As(err, &e) {
+		t.Fatalf("expected a generic decode error without strict mode, got %v", err)
+	}
+
+	strict, err := manager.NewManager(manager.WithBusAddress(address), manager.WithStrictDecode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer strict.Close()
+	_, err = strict.ListNodes(ctx)
+	if !errors.As(err, &e) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	want := common.SchemaError{Method: common.METHOD_LISTNODES, Field: "[0].2", Expected: "s", Actual: "u"}
+	if *e != want {
+		t.Fatalf("expected %+v, got %+v", want, *e)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := n.StartUnit(ctx, hangingUnit, node.ModeReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waited := make(chan error, 1)
+	go func() {
+		result, err := m.WaitForJob(ctx, path)
+		if err == nil && result != job.ResultDone {
+			err = fmt.Errorf("unexpected result %s", result)
+		}
+		waited <- err
+	}()
+	// let the wait start before shutting down
+	time.Sleep(50 * time.Millisecond)
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- m.Shutdown(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := m.ListNodes(ctx); errors.Is(err, manager.ErrShuttingDown) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected new calls to fail while shutting down")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("expected Shutdown to wait for the job, got %v", err)
+	default:
+	}
+
+	if err := c.conn.Emit(common.BC_OBJECT_PATH, common.SIGNAL_JOB_REMOVED, uint32(1), path, "node_a", hangingUnit, job.ResultDone); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-waited; err != nil {
+		t.Fatalf("expected the wait in flight to finish, got %v", err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-mon.Events():
+		if ok {
+			t.Fatal("unexpected monitor event")
+		}
+	default:
+		t.Fatal("expected the monitor events to be closed when Shutdown returns")
+	}
+	if _, err := m.ListNodes(ctx); !errors.Is(err, manager.ErrNotConnected) {
+		t.Fatalf("expected %v after Shutdown, got %v", manager.ErrNotConnected, err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	ctx := context.Background()
+	m, err := manager.NewManager(manager.WithBusAddress(startController(t, "node_a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := n.StartUnitAsync(ctx, hangingUnit, node.ModeReplace)
+	time.Sleep(50 * time.Millisecond)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(shutdownCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Shutdown to give up waiting, got %v", err)
+	}
+	if r := <-res; r.Err == nil {
+		t.Fatalf("expected the job wait to fail once the connection closed, got %+v", r)
+	}
+}
+
+func TestLazyConnect(t *testing.T) {
+	address := startController(t, "node_a")
+
+	m, err := manager.NewManager(manager.WithBusAddress("unix:path=/nonexistent"), manager.WithLazyConnect())
+	if err != nil {
+		t.Fatalf("lazy NewManager must not connect: %v", err)
+	}
+	if _, err := m.ListNodes(context.Background()); err == nil {
+		t.Fatal("expected ListNodes to fail connecting")
+	}
+
+	m, err = manager.NewManager(manager.WithBusAddress(address), manager.WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.State() != manager.Disconnected {
+		t.Fatalf("expected no connection before the first call, got %s", m.State())
+	}
+	if _, err := m.ListNodes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if m.State() != manager.Connected {
+		t.Fatalf("expected a connection after the first call, got %s", m.State())
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ListNodes(context.Background()); !errors.Is(err, manager.ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected after Close, got %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	address := startController(t, "node_a")
+	path := filepath.Join(t.TempDir(), "client.conf")
+	conf := `# connection of the fleet tools
+[bluechi-client]
+BusAddress=` + address + `
+CallTimeout=2000
+retry_max_attempts = 3
+retry_initial_delay = "50ms" # TOML style
+
+[other-tool]
+Unknown=ignored
+`
+	if err := os.WriteFile(path, []byte(conf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BLUECHI_CALL_TIMEOUT", "5s")
+
+	c, err := manager.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.BusAddress != address || c.CallTimeout != 5*time.Second {
+		t.Fatalf("unexpected config %+v", c)
+	}
+	if c.Retry == nil || c.Retry.MaxAttempts != 3 || c.Retry.Backoff.Initial != 50*time.Millisecond {
+		t.Fatalf("unexpected retry policy %+v", c.Retry)
+	}
+	opts, err := c.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manager.NewManager(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, err := m.ListNodes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the environment replaces the transport of the file
+	t.Setenv("BLUECHI_BUS", "session")
+	if c, err := manager.LoadConfig(path); err != nil || c.Bus != "session" || c.BusAddress != "" {
+		t.Fatalf("unexpected config %+v (%v)", c, err)
+	}
+
+	for _, invalid := range []string{
+		"Timeout=5s",
+		"CallTimeout=soon",
+		"RetryInitialDelay=1s\nRetryMaxDelay=10ms",
+		"ControllerName=bluechi",
+		"BusAddress=\"unterminated",
+	} {
+		if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := manager.LoadConfig(path); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestMultiManager(t *testing.T) {
+	ctx := context.Background()
+	idle := testbus.Start(t)
+	primary := serveController(t, "node_a")
+	secondary := serveController(t, "node_b")
+
+	if _, err := manager.NewMultiManager([]manager.Endpoint{{Address: idle}}); !errors.Is(err, manager.ErrNoHealthyEndpoint) {
+		t.Fatalf("expected %v, got %v", manager.ErrNoHealthyEndpoint, err)
+	}
+
+	m, err := manager.NewMultiManager(
+		[]manager.Endpoint{{Address: idle}, {Address: primary.address}, {Address: secondary.address}},
+		manager.WithAutoReconnect(manager.Backoff{Initial: 10 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	api := m.API()
+	names := func() []string {
+		t.Helper()
+		nodes, err := api.ListNodes(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, n := range nodes {
+			names = append(names, n.Name)
+		}
+		return names
+	}
+	if active, ok := m.Active(); !ok || active.Address != primary.address {
+		t.Fatalf("expected the primary to be active, got %+v", active)
+	}
+	if got := names(); !slices.Equal(got, []string{"node_a"}) {
+		t.Fatalf("expected the nodes of the primary, got %v", got)
+	}
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := m.ConnectionEvents()
+	if _, err := primary.conn.ReleaseName(common.BC_DBUS_NAME); err != nil {
+		t.Fatal(err)
+	}
+	awaitState(t, states, manager.Reconnecting)
+	awaitState(t, states, manager.Connected)
+	if active, ok := m.Active(); !ok || active.Address != secondary.address {
+		t.Fatalf("expected the secondary to be active, got %+v", active)
+	}
+	if got := names(); !slices.Equal(got, []string{"node_b"}) {
+		t.Fatalf("expected the nodes of the secondary, got %v", got)
+	}
+	// the monitor is recreated on the secondary
+	if _, err := mon.Subscribe(ctx, "node_b", "a.service"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the primary is preferred once the secondary fails as well
+	testbus.RequestName(t, primary.conn, common.BC_DBUS_NAME)
+	if _, err := secondary.conn.ReleaseName(common.BC_DBUS_NAME); err != nil {
+		t.Fatal(err)
+	}
+	awaitState(t, states, manager.Reconnecting)
+	awaitState(t, states, manager.Connected)
+	if got := names(); !slices.Equal(got, []string{"node_a"}) {
+		t.Fatalf("expected the nodes of the primary, got %v", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	address := startController(t, "node_a")
+	if _, err := manager.NewManager(manager.WithBusAddress(address), manager.WithRateLimit(0, 1)); err == nil {
+		t.Fatal("expected a zero rate to be rejected")
+	}
+	m, err := manager.NewManager(manager.WithBusAddress(address), manager.WithRateLimit(20, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// the burst passes right away, the others wait 50ms each
+	start := time.Now()
+	for range 6 {
+		if _, err := m.ListNodes(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("expected the calls to be limited, took %s", elapsed)
+	}
+
+	// a call which cannot get its turn before its deadline fails
+	for range 2 {
+		go m.ListNodes(ctx)
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := m.ListNodes(short); !errors.Is(err, manager.ErrRateLimited) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v and %v, got %v", manager.ErrRateLimited, context.DeadlineExceeded, err)
+	}
+}
+
+func TestRateLimitCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_a")
+	policy := manager.CircuitBreakerPolicy{FailureThreshold: 2, OpenTimeout: time.Minute}
+	m, err := manager.NewManager(manager.WithBusAddress(c.address), manager.WithRateLimit(10, 1),
+		manager.WithCircuitBreaker(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	a, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the calls refused by the rate limit do not open the breaker
+	for range 3 {
+		short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		_, err := a.ListUnits(short)
+		cancel()
+		if !errors.Is(err, manager.ErrRateLimited) {
+			t.Fatalf("expected %v, got %v", manager.ErrRateLimited, err)
+		}
+	}
+	if _, err := a.ListUnits(ctx); err != nil {
+		t.Fatalf("expected the breaker of node_a to stay closed, got %v", err)
+	}
+}
+
+func TestExportState(t *testing.T) {
+	ctx := context.Background()
+	c := serveController(t, "node_b", "node_a")
+	m, err := manager.NewManager(manager.WithBusAddress(c.address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	state, err := m.ExportState(ctx, manager.WithPattern("nginx*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != manager.StateVersion || state.ControllerStatus != common.SYSTEM_STATUS_UP || state.CapturedAt.IsZero() {
+		t.Fatalf("unexpected state %+v", state)
+	}
+	if len(state.Nodes) != 2 || state.Nodes[0].Name != "node_a" || state.Nodes[0].Status != node.StatusOnline {
+		t.Fatalf("expected the nodes sorted by name, got %+v", state.Nodes)
+	}
+	want := []manager.UnitState{
+		{Name: "nginx-proxy.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed"},
+		{Name: "nginx.service", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+	}
+	if !slices.Equal(state.Nodes[1].Units, want) {
+		t.Fatalf("expected the selected units sorted by name, got %+v", state.Nodes[1].Units)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := manager.ParseState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.CapturedAt.Equal(state.CapturedAt) || !slices.Equal(parsed.Nodes[0].Units, state.Nodes[0].Units) {
+		t.Fatalf("expected the document to round-trip, got %+v", parsed)
+	}
+	if _, err := manager.ParseState([]byte(`{"version": 2}`)); err == nil {
+		t.Fatal("expected a later version to be rejected")
+	}
+
+	// without options only the nodes are exported
+	state, err = m.ExportState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Nodes) != 2 || state.Nodes[0].Units != nil {
+		t.Fatalf("expected no units, got %+v", state.Nodes)
+	}
+}
+
+// attrHandler is a slog.Handler passing the attributes of all records on.
+type attrHandler struct {
+	records chan map[string]string
+}
+
+func (h attrHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h attrHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h attrHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h attrHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := map[string]string{"msg": r.Message}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	select {
+	case h.records <- attrs:
+	default:
+	}
+	return nil
+}
+
+func TestOperationMetadata(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	audit := &recordHook{}
+	handler := attrHandler{records: make(chan map[string]string, 16)}
+	m, err := manager.NewManager(
+		manager.WithBusAddress(startController(t, "node_a")),
+		manager.WithDryRun(),
+		manager.WithHook(audit),
+		manager.WithLogger(slog.New(handler)),
+		manager.WithTracerProvider(provider),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	ctx := common.WithMetadata(context.Background(), common.Metadata{Initiator: "alice", TicketID: "CHG-42"})
+	ctx = common.WithMetadata(ctx, common.Metadata{Reason: "rollout"})
+	want := common.Metadata{Initiator: "alice", Reason: "rollout", TicketID: "CHG-42"}
+	if md, ok := common.MetadataFromContext(ctx); !ok || md != want {
+		t.Fatalf("expected the metadata to be merged, got %+v", md)
+	}
+
+	n, err := m.GetNode(ctx, "node_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.StartUnit(ctx, "nginx.service", node.ModeReplace); err != nil {
+		t.Fatal(err)
+	}
+
+	audit.mu.Lock()
+	ops := slices.Clone(audit.before)
+	audit.mu.Unlock()
+	if len(ops) != 1 || ops[0].Metadata != want {
+		t.Fatalf("expected the metadata to be passed to the hook, got %+v", ops)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for logged := false; !logged; {
+		select {
+		case attrs := <-handler.records:
+			if attrs["msg"] != "dry run" {
+				continue
+			}
+			if attrs["initiator"] != "alice" || attrs["reason"] != "rollout" || attrs["ticket_id"] != "CHG-42" {
+				t.Fatalf("expected the metadata in the log record, got %v", attrs)
+			}
+			logged = true
+		case <-timeout:
+			t.Fatal("expected the dry run to be logged")
+		}
+	}
+
+	for _, s := range recorder.Ended() {
+		if s.Name() != "org.eclipse.bluechi.Node/StartUnit" {
+			continue
+		}
+		attrs := make(map[attribute.Key]string)
+		for _, kv := range s.Attributes() {
+			attrs[kv.Key] = kv.Value.Emit()
+		}
+		if attrs["bluechi.initiator"] != "alice" || attrs["bluechi.reason"] != "rollout" || attrs["bluechi.ticket_id"] != "CHG-42" {
+			t.Fatalf("expected the metadata in the span, got %v", attrs)
+		}
+		return
+	}
+	t.Fatal("expected a span of StartUnit")
+}
@@ -0,0 +1,633 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package managertest provides an in-memory fake of the BlueChi controller
+// implementing manager.ManagerAPI, so code orchestrating BlueChi can be unit
+// tested without a running controller.
+//
+// Nodes and their units are set up with AddNode and Node.AddUnit. Lifecycle
+// operations change the state of the unit right away and finish their job
+// immediately, by default with job.ResultDone. The resulting unit events are
+// delivered to the monitors subscribed to the unit. Failures of single
+// methods can be injected with FailCall. Events are delivered on buffered
+// channels and dropped if the consumer does not keep up.
+package managertest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// eventBufferSize is the capacity of all event channels of the fake.
+const eventBufferSize = 64
+
+// Statuses of a node as reported by ListNodes.
+const (
+	NodeOnline  = node.StatusOnline
+	NodeOffline = node.StatusOffline
+)
+
+// LogTargetJournald is the log target reported by the fake.
+const LogTargetJournald = "journald"
+
+// Manager is an in-memory fake of the controller. The zero value is not
+// usable, use New. It is safe for concurrent use.
+type Manager struct {
+	mu       sync.Mutex
+	closed   bool
+	state    manager.ConnState
+	nodes    []*Node
+	failures map[string]error
+
+	connSubs    map[chan manager.ConnState]struct{}
+	nodeSubs    map[chan manager.NodeConnectionStateChanged]struct{}
+	metricsSubs map[chan metrics.Event]struct{}
+	monitors    map[*Monitor]struct{}
+
+	jobs        map[dbus.ObjectPath]string
+	nextJob     uint32
+	nextMonitor uint32
+
+	logLevel       string
+	metricsEnabled bool
+
+	gate manager.NodeGate
+}
+
+var _ manager.ManagerAPI = (*Manager)(nil)
+
+// New returns a connected fake without nodes.
+func New() *Manager {
+	return &Manager{
+		state:       manager.Connected,
+		failures:    make(map[string]error),
+		connSubs:    make(map[chan manager.ConnState]struct{}),
+		nodeSubs:    make(map[chan manager.NodeConnectionStateChanged]struct{}),
+		metricsSubs: make(map[chan metrics.Event]struct{}),
+		monitors:    make(map[*Monitor]struct{}),
+		jobs:        make(map[dbus.ObjectPath]string),
+		logLevel:    common.LOG_LEVEL_INFO,
+	}
+}
+
+// AddNode adds an online node without units and returns it. Adding a node
+// twice returns the existing one.
+func (f *Manager) AddNode(name string) *Node {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if n := f.nodeLocked(name); n != nil {
+		return n
+	}
+	n := &Node{
+		f:          f,
+		name:       name,
+		status:     NodeOnline,
+		logLevel:   common.LOG_LEVEL_INFO,
+		enabled:    make(map[string]bool),
+		results:    make(map[string]string),
+		statusSubs: make(map[chan node.NodeStatus]struct{}),
+	}
+	f.nodes = append(f.nodes, n)
+	return n
+}
+
+// SetNodeStatus changes the status of the named node to NodeOnline or
+// NodeOffline and reports the change to the node state subscribers and the
+// watchers of the node status. Calls
+// on an offline node fail with common.ErrNodeOffline.
+func (f *Manager) SetNodeStatus(name string, status node.NodeStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := f.nodeLocked(name)
+	if n == nil || n.status == status {
+		return
+	}
+	event := manager.NodeConnectionStateChanged{Node: name, OldState: n.status, NewState: status}
+	n.status = status
+	for ch := range f.nodeSubs {
+		deliver(ch, event)
+	}
+	for ch := range n.statusSubs {
+		deliver(ch, node.NodeStatus(status))
+	}
+}
+
+// FailCall makes all further calls of the named method fail with err, until
+// FailCall is called again for it with a nil error. The name is the one of
+// the method of manager.ManagerAPI, manager.NodeAPI or manager.MonitorAPI,
+// e.g. "StartUnit". Methods with the same name, e.g. ListUnits of the
+// Manager and of the nodes, fail together. The ...AndWait methods of a node
+// fail with the method they wrap.
+func (f *Manager) FailCall(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// SetState changes the reported connection state, e.g. to Reconnecting to
+// test how callers handle an unreachable controller. It fails no calls.
+func (f *Manager) SetState(state manager.ConnState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed || f.state == state {
+		return
+	}
+	f.state = state
+	for ch := range f.connSubs {
+		deliver(ch, state)
+	}
+}
+
+// EmitMetrics delivers event to all metrics subscribers if metrics are
+// enabled.
+func (f *Manager) EmitMetrics(event metrics.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.emitMetricsLocked(event)
+}
+
+// MetricsEnabled reports whether metrics are enabled.
+func (f *Manager) MetricsEnabled() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.metricsEnabled
+}
+
+// Connect reconnects a closed fake.
+func (f *Manager) Connect() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.failures["Connect"]; err != nil {
+		return err
+	}
+	f.closed = false
+	f.state = manager.Connected
+	return nil
+}
+
+// Shutdown closes the fake like Close, whose calls are not in flight for
+// longer than they hold its lock.
+func (f *Manager) Shutdown(ctx context.Context) error {
+	return f.Close()
+}
+
+// Close ends all subscriptions and monitors. Further calls fail with
+// manager.ErrNotConnected until Connect is called.
+func (f *Manager) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	f.state = manager.Disconnected
+	for ch := range f.connSubs {
+		deliver(ch, manager.Disconnected)
+		close(ch)
+	}
+	for ch := range f.nodeSubs {
+		close(ch)
+	}
+	for ch := range f.metricsSubs {
+		close(ch)
+	}
+	for _, n := range f.nodes {
+		for ch := range n.statusSubs {
+			close(ch)
+		}
+		n.statusSubs = make(map[chan node.NodeStatus]struct{})
+	}
+	for mon := range f.monitors {
+		mon.closeLocked()
+	}
+	f.connSubs = make(map[chan manager.ConnState]struct{})
+	f.nodeSubs = make(map[chan manager.NodeConnectionStateChanged]struct{})
+	f.metricsSubs = make(map[chan metrics.Event]struct{})
+	return nil
+}
+
+// State returns the current connection state.
+func (f *Manager) State() manager.ConnState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state
+}
+
+// ConnectionEvents returns a channel receiving the current state and all
+// changes made by SetState and Close.
+func (f *Manager) ConnectionEvents() <-chan manager.ConnState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan manager.ConnState, eventBufferSize)
+	ch <- f.state
+	if f.closed {
+		close(ch)
+		return ch
+	}
+	f.connSubs[ch] = struct{}{}
+	return ch
+}
+
+// ListNodes returns all nodes in the order they were added, ordered by the
+// options like manager.OrderNodes.
+func (f *Manager) ListNodes(ctx context.Context, opts ...manager.ListNodesOption) ([]manager.NodeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "ListNodes"); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodes := make([]manager.NodeInfo, 0, len(f.nodes))
+	for _, n := range f.nodes {
+		nodes = append(nodes, manager.NodeInfo{Name: n.name, ObjectPath: n.ObjectPath(), Status: n.status})
+	}
+	return manager.OrderNodes(nodes, opts...), nil
+}
+
+// Ping returns the time taken to check the fake and each node, offline nodes
+// fail like on the controller.
+func (f *Manager) Ping(ctx context.Context) (manager.PingReport, error) {
+	start := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "Ping"); err != nil {
+		return manager.PingReport{}, fmt.Errorf("failed to ping controller: %w", err)
+	}
+	report := manager.PingReport{Controller: time.Since(start)}
+	for _, n := range f.nodes {
+		r := manager.PingResult{Node: n.name}
+		if err := n.checkLocked(ctx, "Ping"); err != nil {
+			r.Err = fmt.Errorf("failed to ping node %s: %w", n.name, err)
+		} else {
+			r.RTT = time.Since(start)
+		}
+		report.Nodes = append(report.Nodes, r)
+	}
+	return report, nil
+}
+
+// GetNode returns the named node, failing with common.ErrNoSuchNode for
+// nodes which were not added.
+func (f *Manager) GetNode(ctx context.Context, name string) (manager.NodeAPI, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "GetNode"); err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+	n := f.nodeLocked(name)
+	if n == nil {
+		err := &common.Error{Name: common.ERROR_SERVICE_UNKNOWN, Message: common.ERROR_MESSAGE_NODE_NOT_FOUND}
+		return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// Node returns the named fake node to set up and inspect it, nil if it was
+// not added.
+func (f *Manager) Node(name string) *Node {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nodeLocked(name)
+}
+
+// ListUnits returns the units of all online nodes, restricted and ordered
+// by the options like manager.FilterUnits.
+func (f *Manager) ListUnits(ctx context.Context, opts ...manager.ListUnitsOption) (map[string][]node.UnitInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "ListUnits"); err != nil {
+		return nil, fmt.Errorf("failed to list units: %w", err)
+	}
+	units := make(map[string][]node.UnitInfo)
+	for _, n := range f.nodes {
+		if n.status == NodeOnline {
+			units[n.name] = n.unitsLocked()
+		}
+	}
+	return manager.FilterUnits(units, opts...), nil
+}
+
+// ListUnitsFunc calls fn with the units of all online nodes in the order
+// the nodes and units were added, restricted by the options like
+// manager.FilterUnits, until fn returns false. fn may call the fake.
+func (f *Manager) ListUnitsFunc(ctx context.Context, fn func(nodeName string, u node.UnitInfo) bool, opts ...manager.ListUnitsOption) error {
+	f.mu.Lock()
+	if err := f.checkLocked(ctx, "ListUnits"); err != nil {
+		f.mu.Unlock()
+		return fmt.Errorf("failed to list units: %w", err)
+	}
+	var names []string
+	units := make(map[string][]node.UnitInfo)
+	for _, n := range f.nodes {
+		if n.status == NodeOnline {
+			names = append(names, n.name)
+			units[n.name] = n.unitsLocked()
+		}
+	}
+	f.mu.Unlock()
+
+	units = manager.FilterUnits(units, opts...)
+	for _, name := range names {
+		for _, u := range units[name] {
+			if !fn(name, u) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// SubscribeNodeConnectionStateChanged returns a channel receiving the
+// changes made by SetNodeStatus until ctx is done or the fake is closed.
+func (f *Manager) SubscribeNodeConnectionStateChanged(ctx context.Context) (<-chan manager.NodeConnectionStateChanged, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "SubscribeNodeConnectionStateChanged"); err != nil {
+		return nil, err
+	}
+	ch := make(chan manager.NodeConnectionStateChanged, eventBufferSize)
+	f.nodeSubs[ch] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.nodeSubs[ch]; ok {
+			delete(f.nodeSubs, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
+
+// ListAndWatchNodes returns the nodes and the changes made by
+// SetNodeStatus after them, taken atomically.
+func (f *Manager) ListAndWatchNodes(ctx context.Context) ([]manager.NodeInfo, <-chan manager.NodeEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "ListAndWatchNodes"); err != nil {
+		return nil, nil, err
+	}
+	nodes := make([]manager.NodeInfo, 0, len(f.nodes))
+	for _, n := range f.nodes {
+		nodes = append(nodes, manager.NodeInfo{Name: n.name, ObjectPath: n.ObjectPath(), Status: n.status})
+	}
+	ch := make(chan manager.NodeConnectionStateChanged, eventBufferSize)
+	f.nodeSubs[ch] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.nodeSubs[ch]; ok {
+			delete(f.nodeSubs, ch)
+			close(ch)
+		}
+	}()
+	return nodes, manager.NumberNodeEvents(ctx, ch), nil
+}
+
+// CreateMonitor returns a new monitor without subscriptions.
+func (f *Manager) CreateMonitor(ctx context.Context) (manager.MonitorAPI, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "CreateMonitor"); err != nil {
+		return nil, fmt.Errorf("failed to create monitor: %w", err)
+	}
+	f.nextMonitor++
+	mon := &Monitor{
+		f:      f,
+		path:   dbus.ObjectPath(common.MONITOR_OBJECT_PATH_PREFIX + "/" + strconv.FormatUint(uint64(f.nextMonitor), 10)),
+		events: make(chan monitor.Event, eventBufferSize),
+		subs:   make(map[uint32]subscription),
+		peers:  make(map[uint32]string),
+	}
+	f.monitors[mon] = struct{}{}
+	return mon, nil
+}
+
+// WaitForJob returns the result of a job started on one of the nodes. Jobs
+// of the fake finish right away, so it never blocks.
+func (f *Manager) WaitForJob(ctx context.Context, path dbus.ObjectPath) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "WaitForJob"); err != nil {
+		return "", err
+	}
+	result, ok := f.jobs[path]
+	if !ok {
+		return "", fmt.Errorf("failed to get properties of job %s: %w", path,
+			&common.Error{Name: common.ERROR_UNKNOWN_OBJECT, Message: "Unknown object '" + string(path) + "'."})
+	}
+	return result, nil
+}
+
+// SetLogLevel records the log level of the controller.
+func (f *Manager) SetLogLevel(ctx context.Context, level string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "SetLogLevel"); err != nil {
+		return fmt.Errorf("failed to set log level of controller to %s: %w", level, err)
+	}
+	f.logLevel = level
+	return nil
+}
+
+// Status returns common.SYSTEM_STATUS_UP if all nodes are online,
+// common.SYSTEM_STATUS_DOWN if none is and common.SYSTEM_STATUS_DEGRADED
+// otherwise.
+func (f *Manager) Status(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "Status"); err != nil {
+		return "", fmt.Errorf("failed to get controller property Status: %w", err)
+	}
+	return f.statusLocked(), nil
+}
+
+// LogLevel returns the log level last set with SetLogLevel,
+// common.LOG_LEVEL_INFO initially.
+func (f *Manager) LogLevel(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "LogLevel"); err != nil {
+		return "", fmt.Errorf("failed to get controller property LogLevel: %w", err)
+	}
+	return f.logLevel, nil
+}
+
+// LogTarget returns LogTargetJournald.
+func (f *Manager) LogTarget(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "LogTarget"); err != nil {
+		return "", fmt.Errorf("failed to get controller property LogTarget: %w", err)
+	}
+	return LogTargetJournald, nil
+}
+
+// GetProperty returns the Status, LogLevel or LogTarget property.
+func (f *Manager) GetProperty(ctx context.Context, name string) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.checkLocked(ctx, "GetProperty")
+	if err == nil {
+		switch name {
+		case "Status":
+			return f.statusLocked(), nil
+		case "LogLevel":
+			return f.logLevel, nil
+		case "LogTarget":
+			return LogTargetJournald, nil
+		}
+		err = &common.Error{Name: common.ERROR_UNKNOWN_PROPERTY, Message: "Unknown property or interface."}
+	}
+	return nil, fmt.Errorf("failed to get controller property %s: %w", name, err)
+}
+
+// EnableMetrics enables delivering metrics to the subscribers. Finished
+// jobs emit metrics.AgentJobMetrics while metrics are enabled.
+func (f *Manager) EnableMetrics(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "EnableMetrics"); err != nil {
+		return fmt.Errorf("failed to enable metrics: %w", err)
+	}
+	f.metricsEnabled = true
+	return nil
+}
+
+// DisableMetrics disables delivering metrics.
+func (f *Manager) DisableMetrics(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "DisableMetrics"); err != nil {
+		return fmt.Errorf("failed to disable metrics: %w", err)
+	}
+	f.metricsEnabled = false
+	return nil
+}
+
+// SubscribeMetrics returns a channel receiving the metrics emitted while
+// they are enabled, until ctx is done or the fake is closed.
+func (f *Manager) SubscribeMetrics(ctx context.Context) (<-chan metrics.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkLocked(ctx, "SubscribeMetrics"); err != nil {
+		return nil, err
+	}
+	ch := make(chan metrics.Event, eventBufferSize)
+	f.metricsSubs[ch] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.metricsSubs[ch]; ok {
+			delete(f.metricsSubs, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
+
+// NodeGate returns the gate of the fake shared by the rolling operations
+// run on it.
+func (f *Manager) NodeGate() *manager.NodeGate {
+	return &f.gate
+}
+
+// checkLocked returns the error a call of method fails with, if any.
+func (f *Manager) checkLocked(ctx context.Context, method string) error {
+	if f.closed {
+		return manager.ErrNotConnected
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.failures[method]
+}
+
+func (f *Manager) statusLocked() string {
+	online := 0
+	for _, n := range f.nodes {
+		if n.status == NodeOnline {
+			online++
+		}
+	}
+	switch {
+	case online == 0:
+		return common.SYSTEM_STATUS_DOWN
+	case online < len(f.nodes):
+		return common.SYSTEM_STATUS_DEGRADED
+	}
+	return common.SYSTEM_STATUS_UP
+}
+
+func (f *Manager) nodeLocked(name string) *Node {
+	for _, n := range f.nodes {
+		if n.name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// finishJobLocked records a finished job and returns its path.
+func (f *Manager) finishJobLocked(n *Node, unit string, method string, result string) dbus.ObjectPath {
+	f.nextJob++
+	path := dbus.ObjectPath(common.JOB_OBJECT_PATH_PREFIX + "/" + strconv.FormatUint(uint64(f.nextJob), 10))
+	f.jobs[path] = result
+	f.emitMetricsLocked(metrics.AgentJobMetrics{Node: n.name, Unit: unit, Method: method})
+	return path
+}
+
+func (f *Manager) emitMetricsLocked(event metrics.Event) {
+	if !f.metricsEnabled {
+		return
+	}
+	for ch := range f.metricsSubs {
+		deliver(ch, event)
+	}
+}
+
+// deliver sends v on ch, dropping it if ch is full.
+func deliver[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+	default:
+	}
+}
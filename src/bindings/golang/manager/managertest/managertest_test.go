@@ -0,0 +1,440 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package managertest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// startEverywhere is the kind of orchestration code the fake is meant for:
+// it starts unit on all online nodes and returns the names of the nodes it
+// was started on.
+func startEverywhere(ctx context.Context, m manager.ManagerAPI, unit string) ([]string, error) {
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var started []string
+	for _, info := range nodes {
+		if info.Status != managertest.NodeOnline {
+			continue
+		}
+		n, err := m.GetNode(ctx, info.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := n.StartUnitAndWait(ctx, unit, node.ModeReplace); err != nil {
+			return nil, err
+		}
+		started = append(started, info.Name)
+	}
+	return started, nil
+}
+
+func TestStartUnit(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("a.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.AddNode("n2").AddUnit("a.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.AddNode("n3")
+	f.SetNodeStatus("n3", managertest.NodeOffline)
+
+	mon, err := f.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mon.Subscribe(ctx, "*", "a.service"); err != nil {
+		t.Fatal(err)
+	}
+
+	started, err := startEverywhere(ctx, f, "a.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(started) != 2 {
+		t.Fatalf("expected the unit to be started on two nodes, got %v", started)
+	}
+	if u, _ := f.Node("n2").Unit("a.service"); u.ActiveState != managertest.ActiveStateActive {
+		t.Fatalf("expected the unit to be active, got %s", u.ActiveState)
+	}
+
+	for _, want := range started {
+		event := (<-mon.Events()).(monitor.UnitStateChanged)
+		if event.Node != want || event.SubState != managertest.SubStateRunning {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	}
+}
+
+func TestListUnitsFilter(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("nginx.service", managertest.ActiveStateFailed, managertest.SubStateFailed)
+	f.AddNode("n2").AddUnit("nginx.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	f.Node("n2").AddUnit("sshd.service", managertest.ActiveStateFailed, managertest.SubStateFailed)
+
+	units, err := f.ListUnits(ctx, manager.WithActiveState(managertest.ActiveStateFailed), manager.WithPattern("nginx*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 1 || len(units["n1"]) != 1 {
+		t.Fatalf("expected the failed nginx of n1, got %v", units)
+	}
+
+	units, err = f.ListUnits(ctx, manager.WithNodes("n2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 1 || len(units["n2"]) != 2 {
+		t.Fatalf("expected the units of n2, got %v", units)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	n := f.AddNode("n1")
+	n.AddUnit("a.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+
+	if _, err := f.GetNode(ctx, "n2"); !errors.Is(err, common.ErrNoSuchNode) {
+		t.Fatalf("expected ErrNoSuchNode, got %v", err)
+	}
+	if _, err := n.StartUnit(ctx, "b.service", node.ModeReplace); !errors.Is(err, common.ErrNoSuchUnit) {
+		t.Fatalf("expected ErrNoSuchUnit, got %v", err)
+	}
+
+	n.SetJobResult("a.service", job.ResultFailed)
+	if err := n.StartUnitAndWait(ctx, "a.service", node.ModeReplace); err == nil {
+		t.Fatal("expected the failed job to fail StartUnitAndWait")
+	}
+	if state, _ := n.GetUnitActiveState(ctx, "a.service"); state != managertest.ActiveStateFailed {
+		t.Fatalf("expected the unit to have failed, got %s", state)
+	}
+
+	injected := errors.New("injected")
+	f.FailCall("ListNodes", injected)
+	if _, err := startEverywhere(ctx, f, "a.service"); !errors.Is(err, injected) {
+		t.Fatalf("expected the injected error, got %v", err)
+	}
+	f.FailCall("ListNodes", nil)
+
+	f.SetNodeStatus("n1", managertest.NodeOffline)
+	if _, err := n.ListUnits(ctx); !errors.Is(err, common.ErrNodeOffline) {
+		t.Fatalf("expected ErrNodeOffline, got %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.ListNodes(ctx); !errors.Is(err, manager.ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestNodeConnectionStateChanged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := managertest.New()
+	f.AddNode("n1")
+
+	events, err := f.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetNodeStatus("n1", managertest.NodeOffline)
+	event := <-events
+	if event.Node != "n1" || event.OldState != managertest.NodeOnline || event.NewState != managertest.NodeOffline {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatal("expected the channel to be closed once ctx is done")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	for _, step := range []struct {
+		setup func()
+		want  string
+	}{
+		{func() {}, common.SYSTEM_STATUS_DOWN},
+		{func() { f.AddNode("n1"); f.AddNode("n2") }, common.SYSTEM_STATUS_UP},
+		{func() { f.SetNodeStatus("n2", managertest.NodeOffline) }, common.SYSTEM_STATUS_DEGRADED},
+	} {
+		step.setup()
+		if status, err := f.Status(ctx); err != nil || status != step.want {
+			t.Fatalf("expected status %s, got %q (%v)", step.want, status, err)
+		}
+	}
+
+	lastSeen := time.Unix(1714564800, 0)
+	f.Node("n1").SetPeer("10.0.0.1", lastSeen)
+	if ip, err := f.Node("n1").PeerIP(ctx); err != nil || ip != "10.0.0.1" {
+		t.Fatalf("expected peer 10.0.0.1, got %q (%v)", ip, err)
+	}
+	if seen, err := f.Node("n1").LastSeenTimestamp(ctx); err != nil || !seen.Equal(lastSeen) {
+		t.Fatalf("expected last seen %s, got %s (%v)", lastSeen, seen, err)
+	}
+	if status, err := f.Node("n2").Status(ctx); err != nil || status != managertest.NodeOffline {
+		t.Fatalf("expected node n2 to be offline, got %q (%v)", status, err)
+	}
+
+	if err := f.SetLogLevel(ctx, common.LOG_LEVEL_DEBUG); err != nil {
+		t.Fatal(err)
+	}
+	if level, err := f.GetProperty(ctx, "LogLevel"); err != nil || level != common.LOG_LEVEL_DEBUG {
+		t.Fatalf("expected log level %s, got %v (%v)", common.LOG_LEVEL_DEBUG, level, err)
+	}
+	if _, err := f.GetProperty(ctx, "Unknown"); err == nil {
+		t.Fatal("expected error for unknown property")
+	}
+
+	if err := f.Node("n1").SetLogLevel(ctx, common.LOG_LEVEL_DEBUG); err != nil {
+		t.Fatal(err)
+	}
+	if level := f.Node("n1").LogLevel(); level != common.LOG_LEVEL_DEBUG {
+		t.Fatalf("expected agent log level %s, got %q", common.LOG_LEVEL_DEBUG, level)
+	}
+	if level := f.Node("n2").LogLevel(); level != common.LOG_LEVEL_INFO {
+		t.Fatalf("expected agent log level %s, got %q", common.LOG_LEVEL_INFO, level)
+	}
+}
+
+func TestWatchStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := managertest.New()
+	n := f.AddNode("n1")
+
+	statuses, err := n.WatchStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetNodeStatus("n1", managertest.NodeOffline)
+	for _, want := range []node.NodeStatus{node.StatusOnline, node.StatusOffline} {
+		if status := <-statuses; status != want {
+			t.Fatalf("expected status %s, got %s", want, status)
+		}
+	}
+	f.Close()
+	if _, ok := <-statuses; ok {
+		t.Fatal("expected channel to be closed with the fake")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := managertest.New()
+	n := f.AddNode("n1")
+
+	mon, err := f.CreateMonitor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mon.Subscribe(ctx, "n1", "*"); err != nil {
+		t.Fatal(err)
+	}
+	_, events, err := mon.Watch(ctx, "*", "foo@*.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.AddUnit("foo@1.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	n.AddUnit("bar.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+
+	if event := <-events; event.UnitName() != "foo@1.service" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	for _, unit := range []string{"foo@1.service", "bar.service"} {
+		if event := <-mon.Events(); event.UnitName() != unit {
+			t.Fatalf("expected event of %s, got %+v", unit, event)
+		}
+	}
+	cancel()
+	for range events {
+	}
+}
+
+func TestHealthChecker(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	n1 := f.AddNode("n1")
+	n1.SetPeer("10.0.0.1", time.Now())
+	f.AddNode("n2").SetPeer("10.0.0.2", time.Now().Add(-time.Minute))
+
+	c, err := manager.NewHealthChecker(ctx, f,
+		manager.WithCheckInterval(10*time.Millisecond), manager.WithStaleThreshold(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	initial := map[string]manager.HealthState{}
+	for range 2 {
+		event := <-c.Events()
+		initial[event.Node] = event.State
+	}
+	if initial["n1"] != manager.Healthy || initial["n2"] != manager.Degraded {
+		t.Fatalf("unexpected initial health %v", initial)
+	}
+
+	f.SetNodeStatus("n1", managertest.NodeOffline)
+	if event := <-c.Events(); event.Node != "n1" || event.State != manager.Degraded || event.Status != managertest.NodeOffline {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	f.SetNodeStatus("n1", managertest.NodeOnline)
+	if event := <-c.Events(); event.Node != "n1" || event.State != manager.Healthy {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	if _, err := manager.NewHealthChecker(ctx, f, manager.WithStaleThreshold(0)); err == nil {
+		t.Fatal("expected error for non-positive threshold")
+	}
+}
+
+func TestWaitForUnitState(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	n := f.AddNode("n1")
+	n.AddUnit("a.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		n.SetUnitState("a.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	}()
+	if err := n.WaitForUnitState(ctx, "a.service", managertest.ActiveStateActive); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err := n.WaitForUnitState(ctx, "a.service", managertest.ActiveStateFailed)
+	var stateErr *node.UnitStateError
+	if !errors.As(err, &stateErr) || stateErr.Last != managertest.ActiveStateActive {
+		t.Fatalf("expected a timeout in state active, got %v", err)
+	}
+}
+
+func TestUnitDependencies(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	n := f.AddNode("n1")
+	n.AddUnit("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	n.AddUnit("db.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	if err := n.SetUnitProperties(ctx, "app.service", true, map[string]interface{}{"Requires": []string{"db.service"}, "After": []string{"db.service"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := node.ReadDependencyGraph(ctx, n, []string{"app.service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := g.StartOrder([]string{"app.service", "db.service"})
+	if err != nil || len(order) != 2 || order[0] != "db.service" {
+		t.Fatalf("expected db.service to start first, got %v, %v", order, err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1")
+	f.AddNode("n2")
+	f.SetNodeStatus("n2", managertest.NodeOffline)
+
+	report, err := f.Ping(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Nodes) != 2 || report.Nodes[0].Err != nil || !errors.Is(report.Nodes[1].Err, common.ErrNodeOffline) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestUnit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	u := manager.NewUnit(f, "n1", "app.service")
+
+	events, err := u.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	status, err := u.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ActiveState != managertest.ActiveStateActive || status.SubState != managertest.SubStateRunning || status.LoadState != node.LoadStateLoaded {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	event, ok := (<-events).(monitor.UnitStateChanged)
+	if !ok || event.Node != "n1" || event.ActiveState != managertest.ActiveStateActive {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if err := u.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	for range events {
+	}
+	if _, err := manager.NewUnit(f, "n2", "app.service").Status(context.Background()); !errors.Is(err, common.ErrNoSuchNode) {
+		t.Fatalf("expected common.ErrNoSuchNode for an unknown node, got %v", err)
+	}
+}
+
+func TestUnitJobAsync(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	n := f.AddNode("n1")
+	n.AddUnit("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	n.AddUnit("db.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	n.SetJobResult("db.service", job.ResultFailed)
+
+	if res := <-n.StartUnitAsync(ctx, "app.service", node.ModeReplace); res.Err != nil || res.Result != job.ResultDone {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res := <-n.StartUnitAsync(ctx, "db.service", node.ModeReplace); res.Err == nil || res.Result != job.ResultFailed {
+		t.Fatalf("expected the failed job to be reported, got %+v", res)
+	}
+	if res := <-n.StopUnitAsync(ctx, "missing.service", node.ModeReplace); !errors.Is(res.Err, common.ErrNoSuchUnit) {
+		t.Fatalf("expected an unknown unit to fail, got %+v", res)
+	}
+}
+
+func TestListAndWatchNodes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := managertest.New()
+	f.AddNode("n1")
+
+	nodes, events, err := f.ListAndWatchNodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Status != managertest.NodeOnline {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+	f.SetNodeStatus("n1", managertest.NodeOffline)
+	event := <-events
+	if event.Seq != 1 || event.Node != "n1" || event.OldState != managertest.NodeOnline || event.NewState != managertest.NodeOffline {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package managertest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+)
+
+// Monitor is a fake monitor of a fake Manager, implementing
+// manager.MonitorAPI. It receives the unit events of the fake nodes
+// matching its subscriptions.
+type Monitor struct {
+	f      *Manager
+	path   dbus.ObjectPath
+	events chan monitor.Event
+	subs   map[uint32]subscription
+	peers  map[uint32]string
+	nextID uint32
+	closed bool
+}
+
+var _ manager.MonitorAPI = (*Monitor)(nil)
+
+type subscription struct {
+	node  string
+	units []string
+	// events is the channel of a subscription created by Watch, nil for
+	// the subscriptions delivering on the channel of the monitor.
+	events chan monitor.Event
+}
+
+func (s subscription) matches(node string, unit string) bool {
+	if !monitor.Match(s.node, node) {
+		return false
+	}
+	for _, u := range s.units {
+		if monitor.Match(u, unit) {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectPath returns the path of the monitor.
+func (m *Monitor) ObjectPath() dbus.ObjectPath {
+	return m.path
+}
+
+// Events returns the channel the matching unit events are delivered on. It
+// is closed by Close or when the fake Manager is closed.
+func (m *Monitor) Events() <-chan monitor.Event {
+	return m.events
+}
+
+// Subscribe subscribes to a unit on a node, both can be the wildcard "*" or
+// a glob pattern.
+func (m *Monitor) Subscribe(ctx context.Context, node string, unit string) (uint32, error) {
+	id, err := m.subscribe(ctx, "Subscribe", subscription{node: node, units: []string{unit}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to subscribe to unit %s on node %s: %w", unit, node, err)
+	}
+	return id, nil
+}
+
+// SubscribeList subscribes to a list of units on a node.
+func (m *Monitor) SubscribeList(ctx context.Context, node string, units []string) (uint32, error) {
+	id, err := m.subscribe(ctx, "SubscribeList", subscription{node: node, units: append([]string(nil), units...)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to subscribe to units %v on node %s: %w", units, node, err)
+	}
+	return id, nil
+}
+
+// Watch subscribes to a unit on a node like Subscribe and delivers the
+// matching events on the returned channel instead of Events. The channel
+// is closed by Unsubscribe, when ctx is done or the monitor is closed.
+func (m *Monitor) Watch(ctx context.Context, node string, unit string) (uint32, <-chan monitor.Event, error) {
+	ch := make(chan monitor.Event, eventBufferSize)
+	id, err := m.subscribe(ctx, "Watch", subscription{node: node, units: []string{unit}, events: ch})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to subscribe to unit %s on node %s: %w", unit, node, err)
+	}
+	go func() {
+		<-ctx.Done()
+		m.f.mu.Lock()
+		defer m.f.mu.Unlock()
+		if s, ok := m.subs[id]; ok && s.events == ch {
+			m.unsubscribeLocked(id)
+		}
+	}()
+	return id, ch, nil
+}
+
+// Unsubscribe cancels a subscription, failing with
+// common.ErrNoSuchSubscription for unknown ids.
+func (m *Monitor) Unsubscribe(ctx context.Context, id uint32) error {
+	m.f.mu.Lock()
+	defer m.f.mu.Unlock()
+
+	err := m.checkLocked(ctx, "Unsubscribe")
+	if err == nil {
+		if _, ok := m.subs[id]; !ok {
+			err = common.ErrNoSuchSubscription
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe %d: %w", id, err)
+	}
+	m.unsubscribeLocked(id)
+	return nil
+}
+
+func (m *Monitor) unsubscribeLocked(id uint32) {
+	if s := m.subs[id]; s.events != nil {
+		close(s.events)
+	}
+	delete(m.subs, id)
+}
+
+// AddPeer records name as peer of the monitor. Adding the same name twice
+// fails with common.ErrInvalidArgs, like on the controller.
+func (m *Monitor) AddPeer(ctx context.Context, name string) (uint32, error) {
+	m.f.mu.Lock()
+	defer m.f.mu.Unlock()
+
+	err := m.checkLocked(ctx, "AddPeer")
+	if err == nil && m.hasPeerLocked(name) {
+		err = &common.Error{Name: common.ERROR_INVALID_ARGS, Message: "Peer name '" + name + "' has already been added"}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to add peer %s: %w", name, err)
+	}
+	m.nextID++
+	m.peers[m.nextID] = name
+	return m.nextID, nil
+}
+
+// RemovePeer removes a peer, failing for unknown ids with the error of the
+// controller, which matches common.ErrNoSuchPeer.
+func (m *Monitor) RemovePeer(ctx context.Context, id uint32, reason string) error {
+	m.f.mu.Lock()
+	defer m.f.mu.Unlock()
+
+	err := m.checkLocked(ctx, "RemovePeer")
+	if err == nil {
+		if _, ok := m.peers[id]; !ok {
+			err = &common.Error{Name: common.ERROR_FAILED, Message: fmt.Sprintf(common.ERROR_MESSAGE_PEER_NOT_FOUND, id)}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove peer %d: %w", id, err)
+	}
+	delete(m.peers, id)
+	return nil
+}
+
+// Peers returns the names of the peers of the monitor sorted by name.
+func (m *Monitor) Peers() []string {
+	m.f.mu.Lock()
+	defer m.f.mu.Unlock()
+
+	peers := make([]string, 0, len(m.peers))
+	for _, name := range m.peers {
+		peers = append(peers, name)
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// Close closes the monitor and its Events channel.
+func (m *Monitor) Close(ctx context.Context) error {
+	m.f.mu.Lock()
+	defer m.f.mu.Unlock()
+
+	if err := m.checkLocked(ctx, "Close"); err != nil {
+		return fmt.Errorf("failed to close monitor %s: %w", m.path, err)
+	}
+	m.closeLocked()
+	return nil
+}
+
+func (m *Monitor) subscribe(ctx context.Context, method string, s subscription) (uint32, error) {
+	m.f.mu.Lock()
+	defer m.f.mu.Unlock()
+
+	if err := m.checkLocked(ctx, method); err != nil {
+		return 0, err
+	}
+	m.nextID++
+	m.subs[m.nextID] = s
+	return m.nextID, nil
+}
+
+func (m *Monitor) hasPeerLocked(name string) bool {
+	for _, peer := range m.peers {
+		if peer == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Monitor) checkLocked(ctx context.Context, method string) error {
+	if err := m.f.checkLocked(ctx, method); err != nil {
+		return err
+	}
+	if m.closed {
+		return &common.Error{Name: common.ERROR_UNKNOWN_OBJECT, Message: "Unknown object '" + string(m.path) + "'."}
+	}
+	return nil
+}
+
+func (m *Monitor) closeLocked() {
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.events)
+	for id := range m.subs {
+		m.unsubscribeLocked(id)
+	}
+	delete(m.f.monitors, m)
+}
+
+// emitUnitLocked delivers event to all monitors subscribed to its unit,
+// once on the channel of the monitor and on the channel of each matching
+// watch.
+func (f *Manager) emitUnitLocked(event monitor.Event) {
+	for m := range f.monitors {
+		toEvents := false
+		for _, s := range m.subs {
+			if !s.matches(event.NodeName(), event.UnitName()) {
+				continue
+			}
+			if s.events != nil {
+				deliver(s.events, event)
+			} else {
+				toEvents = true
+			}
+		}
+		if toEvents {
+			deliver(m.events, event)
+		}
+	}
+}
@@ -0,0 +1,854 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package managertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// Unit states set by the lifecycle operations of the fake.
+const (
+	ActiveStateActive   = node.ActiveStateActive
+	ActiveStateInactive = node.ActiveStateInactive
+	ActiveStateFailed   = node.ActiveStateFailed
+	SubStateRunning     = node.SubStateRunning
+	SubStateDead        = node.SubStateDead
+	SubStateFailed      = node.SubStateFailed
+)
+
+// waitPollInterval is the interval in which WaitForUnitState reads the
+// state of a unit of the fake.
+const waitPollInterval = time.Millisecond
+
+// Node is a fake node of a fake Manager, implementing manager.NodeAPI. All
+// of its state is guarded by the lock of the Manager.
+type Node struct {
+	f        *Manager
+	name     string
+	status   node.NodeStatus
+	units    []*unit
+	enabled  map[string]bool
+	results  map[string]string
+	logLevel string
+	peerIP   string
+	lastSeen time.Time
+
+	statusSubs map[chan node.NodeStatus]struct{}
+}
+
+var _ manager.NodeAPI = (*Node)(nil)
+
+type unit struct {
+	info  node.UnitInfo
+	props map[string]interface{}
+}
+
+func newUnit(name string) *unit {
+	return &unit{
+		info: node.UnitInfo{
+			Name:       name,
+			LoadState:  node.LoadStateLoaded,
+			ObjectPath: dbus.ObjectPath("/org/freedesktop/systemd1/unit/" + escapePath(name)),
+		},
+		props: make(map[string]interface{}),
+	}
+}
+
+// AddUnit loads a unit with the given states on the node, or changes the
+// states of an already loaded one, and emits a UnitNew event.
+func (n *Node) AddUnit(name string, activeState node.ActiveState, subState node.SubState) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u := n.unitLocked(name)
+	if u == nil {
+		u = newUnit(name)
+		n.units = append(n.units, u)
+	}
+	u.info.ActiveState = activeState
+	u.info.SubState = subState
+	n.f.emitUnitLocked(monitor.UnitNew{Node: n.name, Unit: name, Reason: "real"})
+}
+
+// RemoveUnit unloads the named unit and emits a UnitRemoved event.
+func (n *Node) RemoveUnit(name string) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	for idx, u := range n.units {
+		if u.info.Name == name {
+			n.units = append(n.units[:idx], n.units[idx+1:]...)
+			n.f.emitUnitLocked(monitor.UnitRemoved{Node: n.name, Unit: name, Reason: "real"})
+			return
+		}
+	}
+}
+
+// SetUnitState changes the states of the named unit and emits a
+// UnitStateChanged event, e.g. to simulate a crashing service.
+func (n *Node) SetUnitState(name string, activeState node.ActiveState, subState node.SubState) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if u := n.unitLocked(name); u != nil {
+		n.setStateLocked(u, activeState, subState)
+	}
+}
+
+// SetJobResult makes all further jobs of the named unit finish with result,
+// e.g. job.ResultFailed, which also leaves started units failed.
+func (n *Node) SetJobResult(unit string, result string) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+	n.results[unit] = result
+}
+
+// Unit returns the named unit.
+func (n *Node) Unit(name string) (node.UnitInfo, bool) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u := n.unitLocked(name)
+	if u == nil {
+		return node.UnitInfo{}, false
+	}
+	return u.info, true
+}
+
+// UnitFileEnabled reports whether the named unit file is enabled.
+func (n *Node) UnitFileEnabled(file string) bool {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+	return n.enabled[path.Base(file)]
+}
+
+// LogLevel returns the log level last set with SetLogLevel,
+// common.LOG_LEVEL_INFO initially.
+func (n *Node) LogLevel() string {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+	return n.logLevel
+}
+
+// SetPeer sets the address the agent of the node connects from and the
+// time of its last heartbeat.
+func (n *Node) SetPeer(ip string, lastSeen time.Time) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+	n.peerIP = ip
+	n.lastSeen = lastSeen
+}
+
+// Name returns the name of the node.
+func (n *Node) Name() string {
+	return n.name
+}
+
+// ObjectPath returns the path the controller would export the node at.
+func (n *Node) ObjectPath() dbus.ObjectPath {
+	return dbus.ObjectPath(common.NODE_OBJECT_PATH_PREFIX + "/" + escapePath(n.name))
+}
+
+// SetLogLevel records the log level of the agent.
+func (n *Node) SetLogLevel(ctx context.Context, level string) error {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "SetLogLevel"); err != nil {
+		return fmt.Errorf("failed to set log level of node %s to %s: %w", n.name, level, err)
+	}
+	n.logLevel = level
+	return nil
+}
+
+// Status returns NodeOnline or NodeOffline.
+func (n *Node) Status(ctx context.Context) (node.NodeStatus, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.f.checkLocked(ctx, "Status"); err != nil {
+		return "", fmt.Errorf("failed to get property Status of node %s: %w", n.name, err)
+	}
+	return n.status, nil
+}
+
+// WatchStatus returns a channel receiving the current status and the
+// changes made by SetNodeStatus until ctx is done or the fake is closed.
+func (n *Node) WatchStatus(ctx context.Context) (<-chan node.NodeStatus, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.f.checkLocked(ctx, "WatchStatus"); err != nil {
+		return nil, fmt.Errorf("failed to get property Status of node %s: %w", n.name, err)
+	}
+	ch := make(chan node.NodeStatus, eventBufferSize)
+	ch <- node.NodeStatus(n.status)
+	n.statusSubs[ch] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		n.f.mu.Lock()
+		defer n.f.mu.Unlock()
+		if _, ok := n.statusSubs[ch]; ok {
+			delete(n.statusSubs, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
+
+// PeerIP returns the address set with SetPeer while the node is online.
+func (n *Node) PeerIP(ctx context.Context) (string, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.f.checkLocked(ctx, "PeerIP"); err != nil {
+		return "", fmt.Errorf("failed to get property PeerIp of node %s: %w", n.name, err)
+	}
+	if n.status != NodeOnline {
+		return "", nil
+	}
+	return n.peerIP, nil
+}
+
+// LastSeenTimestamp returns the time set with SetPeer, truncated to seconds
+// like the timestamps of the controller.
+func (n *Node) LastSeenTimestamp(ctx context.Context) (time.Time, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.f.checkLocked(ctx, "LastSeenTimestamp"); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get property LastSeenTimestamp of node %s: %w", n.name, err)
+	}
+	if n.lastSeen.IsZero() {
+		return time.Time{}, nil
+	}
+	return time.Unix(n.lastSeen.Unix(), 0), nil
+}
+
+// Ping returns the time taken to check the node, failing like the
+// controller if it is offline.
+func (n *Node) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "Ping"); err != nil {
+		return 0, fmt.Errorf("failed to ping node %s: %w", n.name, err)
+	}
+	return time.Since(start), nil
+}
+
+// ListUnits returns the units of the node in the order they were added.
+func (n *Node) ListUnits(ctx context.Context) ([]node.UnitInfo, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "ListUnits"); err != nil {
+		return nil, fmt.Errorf("failed to list units on node %s: %w", n.name, err)
+	}
+	return n.unitsLocked(), nil
+}
+
+// ListUnitsFunc calls fn with the units of the node in the order they were
+// added until fn returns false. fn may call the fake.
+func (n *Node) ListUnitsFunc(ctx context.Context, fn func(node.UnitInfo) bool) error {
+	units, err := n.ListUnits(ctx)
+	if err != nil {
+		return err
+	}
+	for _, u := range units {
+		if !fn(u) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// StartUnit makes the unit active and running.
+func (n *Node) StartUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, "StartUnit", "start", unit, ActiveStateActive, SubStateRunning)
+}
+
+// StopUnit makes the unit inactive and dead.
+func (n *Node) StopUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, "StopUnit", "stop", unit, ActiveStateInactive, SubStateDead)
+}
+
+// RestartUnit makes the unit active and running.
+func (n *Node) RestartUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, "RestartUnit", "restart", unit, ActiveStateActive, SubStateRunning)
+}
+
+// ReloadUnit keeps the states of the unit.
+func (n *Node) ReloadUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, "ReloadUnit", "reload", unit, "", "")
+}
+
+// StartUnitIf runs StartUnit if cond holds on the properties of the unit,
+// see node.QueueIf.
+func (n *Node) StartUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, "start", unit, mode, n.StartUnit, cond, opts)
+}
+
+// StopUnitIf runs StopUnit if cond holds.
+func (n *Node) StopUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, "stop", unit, mode, n.StopUnit, cond, opts)
+}
+
+// RestartUnitIf runs RestartUnit if cond holds.
+func (n *Node) RestartUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, "restart", unit, mode, n.RestartUnit, cond, opts)
+}
+
+// ReloadUnitIf runs ReloadUnit if cond holds.
+func (n *Node) ReloadUnitIf(ctx context.Context, unit string, mode string, cond node.UnitCondition, opts ...node.ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, "reload", unit, mode, n.ReloadUnit, cond, opts)
+}
+
+// StartUnitAndWait starts the unit and fails unless the job is done.
+func (n *Node) StartUnitAndWait(ctx context.Context, unit string, mode string) error {
+	path, err := n.StartUnit(ctx, unit, mode)
+	return n.wait(ctx, "start", unit, path, err)
+}
+
+// StopUnitAndWait stops the unit and fails unless the job is done.
+func (n *Node) StopUnitAndWait(ctx context.Context, unit string, mode string) error {
+	path, err := n.StopUnit(ctx, unit, mode)
+	return n.wait(ctx, "stop", unit, path, err)
+}
+
+// RestartUnitAndWait restarts the unit and fails unless the job is done.
+func (n *Node) RestartUnitAndWait(ctx context.Context, unit string, mode string) error {
+	path, err := n.RestartUnit(ctx, unit, mode)
+	return n.wait(ctx, "restart", unit, path, err)
+}
+
+// ReloadUnitAndWait reloads the unit and fails unless the job is done.
+func (n *Node) ReloadUnitAndWait(ctx context.Context, unit string, mode string) error {
+	path, err := n.ReloadUnit(ctx, unit, mode)
+	return n.wait(ctx, "reload", unit, path, err)
+}
+
+// StartUnitAsync is StartUnitAndWait delivering the outcome on a channel.
+func (n *Node) StartUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult {
+	path, err := n.StartUnit(ctx, unit, mode)
+	return n.async(ctx, "start", unit, path, err)
+}
+
+// StopUnitAsync is StopUnitAndWait delivering the outcome on a channel.
+func (n *Node) StopUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult {
+	path, err := n.StopUnit(ctx, unit, mode)
+	return n.async(ctx, "stop", unit, path, err)
+}
+
+// RestartUnitAsync is RestartUnitAndWait delivering the outcome on a
+// channel.
+func (n *Node) RestartUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult {
+	path, err := n.RestartUnit(ctx, unit, mode)
+	return n.async(ctx, "restart", unit, path, err)
+}
+
+// ReloadUnitAsync is ReloadUnitAndWait delivering the outcome on a channel.
+func (n *Node) ReloadUnitAsync(ctx context.Context, unit string, mode string) <-chan node.JobResult {
+	path, err := n.ReloadUnit(ctx, unit, mode)
+	return n.async(ctx, "reload", unit, path, err)
+}
+
+// FreezeUnit sets the FreezerState property of the unit to frozen.
+func (n *Node) FreezeUnit(ctx context.Context, unit string) error {
+	return n.setFreezerState(ctx, "FreezeUnit", "freeze", unit, "frozen")
+}
+
+// ThawUnit sets the FreezerState property of the unit to running.
+func (n *Node) ThawUnit(ctx context.Context, unit string) error {
+	return n.setFreezerState(ctx, "ThawUnit", "thaw", unit, "running")
+}
+
+// GetUnitProperties returns the properties set on the unit together with
+// its Id, Description, LoadState, ActiveState and SubState, regardless of
+// iface.
+func (n *Node) GetUnitProperties(ctx context.Context, unit string, iface string) (map[string]interface{}, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u, err := n.loadedLocked(ctx, "GetUnitProperties", unit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties of unit %s on node %s: %w", unit, n.name, err)
+	}
+	return u.propertiesLocked(), nil
+}
+
+// GetUnitProperty returns a single property as returned by
+// GetUnitProperties.
+func (n *Node) GetUnitProperty(ctx context.Context, unit string, iface string, property string) (interface{}, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u, err := n.loadedLocked(ctx, "GetUnitProperty", unit)
+	if err == nil {
+		v, ok := u.propertiesLocked()[property]
+		if ok {
+			return v, nil
+		}
+		err = &common.Error{Name: common.ERROR_UNKNOWN_PROPERTY, Message: "Unknown property or interface."}
+	}
+	return nil, fmt.Errorf("failed to get property %s of unit %s on node %s: %w", property, unit, n.name, err)
+}
+
+// GetUnitsProperties returns the named properties of the units as returned
+// by GetUnitProperties, all of them without names. Units which are not
+// loaded are omitted and their errors joined.
+func (n *Node) GetUnitsProperties(ctx context.Context, units []string, props []string, opts ...node.PropertiesOption) (map[string]map[string]interface{}, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	values := make(map[string]map[string]interface{}, len(units))
+	var errs []error
+	for _, unit := range units {
+		u, err := n.loadedLocked(ctx, "GetUnitProperties", unit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get properties of unit %s on node %s: %w", unit, n.name, err))
+			continue
+		}
+		all := u.propertiesLocked()
+		if len(props) == 0 {
+			values[unit] = all
+			continue
+		}
+		selected := make(map[string]interface{}, len(props))
+		for _, prop := range props {
+			if v, ok := all[prop]; ok {
+				selected[prop] = v
+			}
+		}
+		values[unit] = selected
+	}
+	return values, errors.Join(errs...)
+}
+
+// GetUnitActiveState returns the active state of the unit.
+func (n *Node) GetUnitActiveState(ctx context.Context, unit string) (node.ActiveState, error) {
+	state, err := n.getUnitStringProperty(ctx, unit, "ActiveState")
+	return node.ActiveState(state), err
+}
+
+// GetUnitSubState returns the sub state of the unit.
+func (n *Node) GetUnitSubState(ctx context.Context, unit string) (node.SubState, error) {
+	state, err := n.getUnitStringProperty(ctx, unit, "SubState")
+	return node.SubState(state), err
+}
+
+// GetUnitCGroupPath returns the ControlGroup property of the unit.
+func (n *Node) GetUnitCGroupPath(ctx context.Context, unit string) (string, error) {
+	return n.getUnitStringProperty(ctx, unit, "ControlGroup")
+}
+
+// UnitStatus returns the states of the unit together with its Result,
+// ExecMainCode and ExecMainStatus properties as set by SetUnitProperties.
+// The logs of WithLogs are attached if the unit failed.
+func (n *Node) UnitStatus(ctx context.Context, unit string, opts ...node.UnitStatusOption) (node.UnitStatus, error) {
+	n.f.mu.Lock()
+	u, err := n.loadedLocked(ctx, "UnitStatus", unit)
+	if err != nil {
+		n.f.mu.Unlock()
+		return node.UnitStatus{}, fmt.Errorf("failed to get status of unit %s on node %s: %w", unit, n.name, err)
+	}
+	status := node.NewUnitStatus(unit, u.propertiesLocked())
+	n.f.mu.Unlock()
+
+	return status, status.AttachLogs(ctx, n.name, opts...)
+}
+
+// UnitDependencies returns the Requires, Wants, After and Before properties
+// of the unit, as set by SetUnitProperties with []string values.
+func (n *Node) UnitDependencies(ctx context.Context, unit string) (node.UnitDependencies, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u, err := n.loadedLocked(ctx, "UnitDependencies", unit)
+	if err != nil {
+		return node.UnitDependencies{}, fmt.Errorf("failed to get dependencies of unit %s on node %s: %w", unit, n.name, err)
+	}
+	d := node.UnitDependencies{Unit: unit}
+	for kind, field := range map[node.DependencyKind]*[]string{
+		node.DependencyRequires: &d.Requires,
+		node.DependencyWants:    &d.Wants,
+		node.DependencyAfter:    &d.After,
+		node.DependencyBefore:   &d.Before,
+	} {
+		if units, ok := u.props[string(kind)].([]string); ok {
+			*field = slices.Sorted(slices.Values(units))
+		}
+	}
+	return d, nil
+}
+
+// WaitForUnitState blocks until the active state of the unit is target,
+// polling the state of the fake in short intervals. A *node.UnitStateError
+// is returned when ctx is done first.
+func (n *Node) WaitForUnitState(ctx context.Context, unit string, target node.ActiveState) error {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	var last node.ActiveState
+	for {
+		state, err := n.GetUnitActiveState(ctx, unit)
+		switch {
+		case err == nil:
+			last = state
+		case ctx.Err() == nil:
+			return err
+		}
+		if last == target {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return &node.UnitStateError{Node: n.name, Unit: unit, Target: target, Last: last, Err: ctx.Err()}
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetUnitProperties stores the given properties on the unit.
+func (n *Node) SetUnitProperties(ctx context.Context, unit string, runtime bool, props map[string]interface{}) error {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u, err := n.loadedLocked(ctx, "SetUnitProperties", unit)
+	if err != nil {
+		return fmt.Errorf("failed to set properties of unit %s on node %s: %w", unit, n.name, err)
+	}
+	for name, value := range props {
+		if v, ok := value.(dbus.Variant); ok {
+			value = v.Value()
+		}
+		u.props[name] = value
+	}
+	return nil
+}
+
+// SetUnitCPUQuota stores the quota as CPUQuotaPerSecUSec property.
+func (n *Node) SetUnitCPUQuota(ctx context.Context, unit string, runtime bool, percent float64) error {
+	if !(percent > 0) || math.IsInf(percent, 1) {
+		return fmt.Errorf("failed to set CPU quota of unit %s on node %s: invalid quota %v%%", unit, n.name, percent)
+	}
+	perSec := uint64(percent / 100 * float64(time.Second/time.Microsecond))
+	return n.SetUnitProperties(ctx, unit, runtime, map[string]interface{}{"CPUQuotaPerSecUSec": perSec})
+}
+
+// SetUnitCPUWeight stores the weight as CPUWeight property.
+func (n *Node) SetUnitCPUWeight(ctx context.Context, unit string, runtime bool, weight uint64) error {
+	if weight < 1 || weight > 10000 {
+		return fmt.Errorf("failed to set CPU weight of unit %s on node %s: invalid weight %d", unit, n.name, weight)
+	}
+	return n.SetUnitProperties(ctx, unit, runtime, map[string]interface{}{"CPUWeight": weight})
+}
+
+// SetUnitMemoryMax stores the limit as MemoryMax property.
+func (n *Node) SetUnitMemoryMax(ctx context.Context, unit string, runtime bool, bytes uint64) error {
+	return n.SetUnitProperties(ctx, unit, runtime, map[string]interface{}{"MemoryMax": bytes})
+}
+
+// EnableUnitFiles marks the unit files enabled and reports a symlink for
+// each file which was not enabled before.
+func (n *Node) EnableUnitFiles(ctx context.Context, files []string, runtime bool, force bool) (node.EnableUnitFilesResult, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "EnableUnitFiles"); err != nil {
+		return node.EnableUnitFilesResult{}, fmt.Errorf("failed to enable unit files %v on node %s: %w", files, n.name, err)
+	}
+	result := node.EnableUnitFilesResult{CarriesInstallInfo: true}
+	for _, file := range files {
+		name := path.Base(file)
+		if n.enabled[name] {
+			continue
+		}
+		n.enabled[name] = true
+		result.Changes = append(result.Changes, node.UnitFileChange{
+			Type:        node.ChangeSymlink,
+			FileName:    unitFileDir(runtime) + "/multi-user.target.wants/" + name,
+			Destination: "/usr/lib/systemd/system/" + name,
+		})
+	}
+	return result, nil
+}
+
+// DisableUnitFiles marks the unit files disabled and reports an unlink for
+// each file which was enabled.
+func (n *Node) DisableUnitFiles(ctx context.Context, files []string, runtime bool) ([]node.UnitFileChange, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "DisableUnitFiles"); err != nil {
+		return nil, fmt.Errorf("failed to disable unit files %v on node %s: %w", files, n.name, err)
+	}
+	var changes []node.UnitFileChange
+	for _, file := range files {
+		name := path.Base(file)
+		if !n.enabled[name] {
+			continue
+		}
+		delete(n.enabled, name)
+		changes = append(changes, node.UnitFileChange{
+			Type:     node.ChangeUnlink,
+			FileName: unitFileDir(runtime) + "/multi-user.target.wants/" + name,
+		})
+	}
+	return changes, nil
+}
+
+// GetUnitFileState returns node.UnitFileEnabled for the unit files enabled
+// with EnableUnitFiles and node.UnitFileDisabled for the other loaded
+// units.
+func (n *Node) GetUnitFileState(ctx context.Context, unit string) (string, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "GetUnitFileState"); err != nil {
+		return "", fmt.Errorf("failed to get property UnitFileState of unit %s on node %s: %w", unit, n.name, err)
+	}
+	if n.enabled[unit] {
+		return node.UnitFileEnabled, nil
+	}
+	if n.unitLocked(unit) == nil {
+		err := &common.Error{Name: common.ERROR_SYSTEMD_NO_SUCH_UNIT, Message: "Unit " + unit + " not loaded."}
+		return "", fmt.Errorf("failed to get property UnitFileState of unit %s on node %s: %w", unit, n.name, err)
+	}
+	return node.UnitFileDisabled, nil
+}
+
+// ListUnitFiles returns the unit files of the loaded units in the order
+// they were added, all located in /usr/lib/systemd/system.
+func (n *Node) ListUnitFiles(ctx context.Context) ([]node.UnitFile, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "ListUnitFiles"); err != nil {
+		return nil, fmt.Errorf("failed to list unit files on node %s: %w", n.name, err)
+	}
+	files := make([]node.UnitFile, 0, len(n.units))
+	for _, u := range n.units {
+		state := node.UnitFileDisabled
+		if n.enabled[u.info.Name] {
+			state = node.UnitFileEnabled
+		}
+		files = append(files, node.UnitFile{Name: u.info.Name, Path: "/usr/lib/systemd/system/" + u.info.Name, State: state})
+	}
+	return files, nil
+}
+
+// Reload does nothing besides failing like the other calls.
+func (n *Node) Reload(ctx context.Context) error {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	if err := n.checkLocked(ctx, "Reload"); err != nil {
+		return fmt.Errorf("failed to reload unit files on node %s: %w", n.name, err)
+	}
+	return nil
+}
+
+// checkLocked returns the error a call of method on the node fails with,
+// if any.
+func (n *Node) checkLocked(ctx context.Context, method string) error {
+	if err := n.f.checkLocked(ctx, method); err != nil {
+		return err
+	}
+	if n.status != NodeOnline {
+		return &common.Error{Name: common.ERROR_OFFLINE, Message: "Node is offline"}
+	}
+	return nil
+}
+
+// loadedLocked returns the named unit, failing like systemd if it is not
+// loaded.
+func (n *Node) loadedLocked(ctx context.Context, method string, name string) (*unit, error) {
+	if err := n.checkLocked(ctx, method); err != nil {
+		return nil, err
+	}
+	u := n.unitLocked(name)
+	if u == nil {
+		return nil, &common.Error{Name: common.ERROR_SYSTEMD_NO_SUCH_UNIT, Message: "Unit " + name + " not loaded."}
+	}
+	return u, nil
+}
+
+func (n *Node) unitLocked(name string) *unit {
+	for _, u := range n.units {
+		if u.info.Name == name {
+			return u
+		}
+	}
+	return nil
+}
+
+func (n *Node) unitsLocked() []node.UnitInfo {
+	units := make([]node.UnitInfo, 0, len(n.units))
+	for _, u := range n.units {
+		units = append(units, u.info)
+	}
+	return units
+}
+
+func (n *Node) setStateLocked(u *unit, activeState node.ActiveState, subState node.SubState) {
+	if u.info.ActiveState == activeState && u.info.SubState == subState {
+		return
+	}
+	u.info.ActiveState = activeState
+	u.info.SubState = subState
+	n.f.emitUnitLocked(monitor.UnitStateChanged{
+		Node:        n.name,
+		Unit:        u.info.Name,
+		ActiveState: activeState,
+		SubState:    subState,
+		Reason:      "real",
+	})
+}
+
+// unitJob runs a lifecycle job, which leaves the unit in the given states
+// if it is done and failed otherwise. Empty states are kept.
+func (n *Node) unitJob(ctx context.Context, method string, op string, name string, activeState node.ActiveState, subState node.SubState) (dbus.ObjectPath, error) {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u, err := n.loadedLocked(ctx, method, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s unit %s on node %s: %w", op, name, n.name, err)
+	}
+	result, ok := n.results[name]
+	if !ok {
+		result = job.ResultDone
+	}
+	if result != job.ResultDone {
+		activeState, subState = ActiveStateFailed, SubStateFailed
+	}
+	if activeState != "" {
+		n.setStateLocked(u, activeState, subState)
+	}
+	return n.f.finishJobLocked(n, name, method, result), nil
+}
+
+func (n *Node) unitJobIf(
+	ctx context.Context,
+	op string,
+	unit string,
+	mode string,
+	queue func(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error),
+	cond node.UnitCondition,
+	opts []node.ConditionOption,
+) (dbus.ObjectPath, bool, error) {
+	return node.QueueIf(ctx, n.name, unit, op,
+		func(ctx context.Context, props []string) (map[string]interface{}, error) {
+			values, err := n.GetUnitsProperties(ctx, []string{unit}, props)
+			return values[unit], err
+		}, cond,
+		func(ctx context.Context) (dbus.ObjectPath, error) {
+			return queue(ctx, unit, mode)
+		}, opts...)
+}
+
+func (n *Node) wait(ctx context.Context, op string, unit string, path dbus.ObjectPath, err error) error {
+	if err != nil {
+		return err
+	}
+	result, err := n.f.WaitForJob(ctx, path)
+	if err != nil {
+		return err
+	}
+	if result != job.ResultDone {
+		return fmt.Errorf("failed to %s unit %s on node %s: job %s finished with result %s", op, unit, n.name, path, result)
+	}
+	return nil
+}
+
+// async returns a channel delivering the outcome of the job at path, the
+// jobs of the fake finish right away.
+func (n *Node) async(ctx context.Context, op string, unit string, path dbus.ObjectPath, err error) <-chan node.JobResult {
+	res := node.JobResult{Node: n.name, Unit: unit, Path: path, Err: err}
+	if err == nil {
+		res.Result, res.Err = n.f.WaitForJob(ctx, path)
+		if res.Err == nil && res.Result != job.ResultDone {
+			res.Err = fmt.Errorf("failed to %s unit %s on node %s: job %s finished with result %s", op, unit, n.name, path, res.Result)
+		}
+	}
+	ch := make(chan node.JobResult, 1)
+	ch <- res
+	close(ch)
+	return ch
+}
+
+func (n *Node) setFreezerState(ctx context.Context, method string, op string, name string, state string) error {
+	n.f.mu.Lock()
+	defer n.f.mu.Unlock()
+
+	u, err := n.loadedLocked(ctx, method, name)
+	if err != nil {
+		return fmt.Errorf("failed to %s unit %s on node %s: %w", op, name, n.name, err)
+	}
+	u.props["FreezerState"] = state
+	return nil
+}
+
+func (n *Node) getUnitStringProperty(ctx context.Context, unit string, property string) (string, error) {
+	v, err := n.GetUnitProperty(ctx, unit, common.SYSTEMD_UNIT_INTERFACE, property)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("failed to get property %s of unit %s on node %s: unexpected type %T", property, unit, n.name, v)
+	}
+	return s, nil
+}
+
+func (u *unit) propertiesLocked() map[string]interface{} {
+	props := make(map[string]interface{}, len(u.props)+5)
+	for name, value := range u.props {
+		props[name] = value
+	}
+	props["Id"] = u.info.Name
+	props["Description"] = u.info.Description
+	props["LoadState"] = string(u.info.LoadState)
+	props["ActiveState"] = string(u.info.ActiveState)
+	props["SubState"] = string(u.info.SubState)
+	return props
+}
+
+func unitFileDir(runtime bool) string {
+	if runtime {
+		return "/run/systemd/system"
+	}
+	return "/etc/systemd/system"
+}
+
+// escapePath escapes name for use as object path element like sd-bus does,
+// replacing all characters except letters and digits by _ and their hex
+// value.
+func escapePath(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9' && i > 0) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "_%02x", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// nodeEventBufferSize is the capacity of the channel returned by
+// SubscribeNodeConnectionStateChanged.
+const nodeEventBufferSize = 16
+
+// NodeConnectionStateChanged is emitted when the connection status of a
+// node with the controller changes.
+type NodeConnectionStateChanged struct {
+	// Node is the name of the node.
+	Node string
+	// OldState is the previous status of the node, empty if it was unknown.
+	OldState node.NodeStatus
+	// NewState is the current status of the node.
+	NewState node.NodeStatus
+}
+
+// SubscribeNodeConnectionStateChanged returns a channel on which a
+// NodeConnectionStateChanged event is delivered each time a node goes online
+// or offline. The subscription ends and the channel is closed when ctx is
+// done or the Manager is closed. With auto-reconnect, changes that happened
+// while disconnected are reported after the reconnect.
+func (m *Manager) SubscribeNodeConnectionStateChanged(ctx context.Context) (<-chan NodeConnectionStateChanged, error) {
+	_, events, err := m.watchNodes(ctx)
+	return events, err
+}
+
+// ListAndWatchNodes returns the current nodes and a channel receiving the
+// changes after them, numbered from 1 by their Seq. The watch is set up
+// before the nodes are listed and changes already contained in the list
+// are not reported, so applying the events in order to the list yields the
+// nodes of the controller with no change missed or seen twice, e.g. to
+// build a cache without races. A node unknown to the list is reported with
+// an empty OldState. The channel is closed like the one of
+// SubscribeNodeConnectionStateChanged.
+func (m *Manager) ListAndWatchNodes(ctx context.Context) ([]NodeInfo, <-chan NodeEvent, error) {
+	nodes, changes, err := m.watchNodes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, NumberNodeEvents(ctx, changes), nil
+}
+
+// NumberNodeEvents returns a channel receiving the changes of in as
+// NodeEvent numbered from 1, as ListAndWatchNodes does, e.g. for fakes of
+// ManagerAPI. It is closed when in is closed or ctx is done.
+func NumberNodeEvents(ctx context.Context, in <-chan NodeConnectionStateChanged) <-chan NodeEvent {
+	out := make(chan NodeEvent, nodeEventBufferSize)
+	go func() {
+		defer close(out)
+		var seq uint64
+		for change := range in {
+			seq++
+			select {
+			case out <- NodeEvent{Seq: seq, NodeConnectionStateChanged: change}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// watchNodes subscribes to the node status changes, lists the nodes and
+// returns them with a channel of the changes not contained in the list.
+func (m *Manager) watchNodes(ctx context.Context) ([]NodeInfo, <-chan NodeConnectionStateChanged, error) {
+	s, err := m.session()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn := s.conn
+
+	match := bus.Match{
+		Rules: [][]dbus.MatchOption{bus.PropertiesChangedRule(common.NODE_OBJECT_PATH_PREFIX, common.NODE_INTERFACE)},
+		Filter: func(sig *dbus.Signal) bool {
+			return sig.Name == common.SIGNAL_PROPERTIES_CHANGED
+		},
+	}
+	sub, err := bus.Subscribe(conn, match, nodeEventBufferSize, bus.Block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to subscribe to node status: %w", err)
+	}
+
+	// remember the current states to report them as old states later on.
+	// The signals received before the reply are contained in the list and
+	// dropped, they have a lower sequence than the reply.
+	nodes, listed, err := listNodes(ctx, s)
+	if err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+	names := make(map[dbus.ObjectPath]string, len(nodes))
+	states := make(map[string]node.NodeStatus, len(nodes))
+	for _, n := range nodes {
+		names[n.ObjectPath] = n.Name
+		states[n.Name] = n.Status
+	}
+
+	restored := make(chan struct{}, 1)
+	unhook := bus.OnRestore(conn, func(context.Context) {
+		select {
+		case restored <- struct{}{}:
+		default:
+		}
+	})
+
+	events := bus.NewOutbox(conn, nodeEventBufferSize, coalesceNodeEvents)
+	emit := func(name string, status node.NodeStatus) bool {
+		if old, ok := states[name]; ok && old == status {
+			return true
+		}
+		event := NodeConnectionStateChanged{Node: name, OldState: states[name], NewState: status}
+		states[name] = status
+		if events.Offer(event) {
+			return true
+		}
+		select {
+		case events.C <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-conn.Context().Done():
+			return false
+		}
+	}
+	bus.Go(conn, func() {
+		defer close(events.C)
+		defer func() {
+			unhook()
+			sub.Close()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-conn.Context().Done():
+				return
+			case <-restored:
+				// signals were missed while disconnected
+				nodes, seq, err := listNodes(ctx, s)
+				if err != nil {
+					bus.Logger(conn).Error("failed to list nodes after reconnect", "error", err)
+					continue
+				}
+				listed = seq
+				for _, n := range nodes {
+					names[n.ObjectPath] = n.Name
+					if !emit(n.Name, n.Status) {
+						return
+					}
+				}
+			case sig, ok := <-sub.Signals():
+				if !ok {
+					return
+				}
+				if sig.Sequence != dbus.NoSequence && sig.Sequence < listed {
+					continue
+				}
+				status, ok := nodeStatusFromSignal(sig)
+				if !ok {
+					continue
+				}
+				name, ok := names[sig.Path]
+				if !ok {
+					name, ok = nodeName(ctx, conn, sig.Path)
+					if !ok {
+						continue
+					}
+					names[sig.Path] = name
+				}
+
+				if !emit(name, status) {
+					return
+				}
+			}
+		}
+	})
+	return nodes, events.C, nil
+}
+
+// coalesceNodeEvents merges two changes of the same node into one from the
+// old state of the first to the new state of the second.
+func coalesceNodeEvents(prev, next NodeConnectionStateChanged) (NodeConnectionStateChanged, bool) {
+	if prev.Node != next.Node {
+		return NodeConnectionStateChanged{}, false
+	}
+	next.OldState = prev.OldState
+	return next, true
+}
+
+func nodeName(ctx context.Context, conn common.Connection, path dbus.ObjectPath) (string, bool) {
+	obj := bus.Object(conn, common.BC_DBUS_NAME, path)
+	v, err := bus.GetProperty(ctx, obj, common.NODE_INTERFACE, "Name")
+	if err != nil {
+		return "", false
+	}
+	name, err := variant.String(v)
+	return name, err == nil
+}
+
+func nodeStatusFromSignal(sig *dbus.Signal) (node.NodeStatus, bool) {
+	var iface string
+	var changed map[string]dbus.Variant
+	var invalidated []string
+	if dbus.Store(sig.Body, &iface, &changed, &invalidated) != nil || iface != common.NODE_INTERFACE {
+		return "", false
+	}
+	v, ok := changed["Status"]
+	if !ok {
+		return "", false
+	}
+	status, err := variant.String(v)
+	return node.NodeStatus(status), err == nil
+}
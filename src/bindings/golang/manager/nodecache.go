@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"sync"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// NodeCacheStats are the statistics of the node proxies cached by GetNode.
+type NodeCacheStats struct {
+	// Hits is the number of calls of GetNode served from the cache.
+	Hits uint64
+	// Misses is the number of calls of GetNode which resolved the node on
+	// the controller.
+	Misses uint64
+	// Invalidations is the number of proxies removed from the cache.
+	Invalidations uint64
+	// Size is the number of proxies currently cached.
+	Size int
+}
+
+// nodeCache holds the node proxies of a session keyed by node name.
+type nodeCache struct {
+	mu    sync.Mutex
+	nodes map[string]*node.Node
+}
+
+func (c *nodeCache) get(name string) (*node.Node, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.nodes[name]
+	return n, ok
+}
+
+func (c *nodeCache) put(name string, n *node.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nodes == nil {
+		c.nodes = make(map[string]*node.Node)
+	}
+	c.nodes[name] = n
+}
+
+// invalidate removes the named proxies, all of them if no names are given,
+// and returns the number of proxies removed.
+func (c *nodeCache) invalidate(names []string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(names) == 0 {
+		removed := len(c.nodes)
+		c.nodes = nil
+		return removed
+	}
+	removed := 0
+	for _, name := range names {
+		if _, ok := c.nodes[name]; ok {
+			delete(c.nodes, name)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *nodeCache) size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.nodes)
+}
+
+// InvalidateNodes removes the proxies of the named nodes from the cache of
+// GetNode, all of them if no names are given, e.g. after a node was removed
+// from the configuration of the controller.
+func (m *Manager) InvalidateNodes(names ...string) {
+	s, err := m.current()
+	if err != nil {
+		return
+	}
+	m.invalidateNodes(s, names)
+}
+
+func (m *Manager) invalidateNodes(s *session, names []string) {
+	m.nodeInvalidations.Add(uint64(s.nodes.invalidate(names)))
+}
+
+// NodeCacheStats returns the statistics of the node proxies cached by
+// GetNode.
+func (m *Manager) NodeCacheStats() NodeCacheStats {
+	stats := NodeCacheStats{
+		Hits:          m.nodeHits.Load(),
+		Misses:        m.nodeMisses.Load(),
+		Invalidations: m.nodeInvalidations.Load(),
+	}
+	if s, err := m.current(); err == nil {
+		stats.Size = s.nodes.size()
+	}
+	return stats
+}
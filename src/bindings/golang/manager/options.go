@@ -0,0 +1,522 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// Option configures a Manager created by NewManager. Invalid options make
+// NewManager fail.
+type Option func(*options) error
+
+type options struct {
+	// dial opens the connection to the bus the controller is reachable on.
+	dial func() (*dbus.Conn, error)
+	// reconnect enables re-establishing a lost connection if set.
+	reconnect *bus.Backoff
+	// failover dials again when the controller leaves the bus.
+	failover bool
+	// lazy defers connecting until the first call.
+	lazy bool
+	// logger receives the diagnostics, nil discards them.
+	logger *slog.Logger
+	// callTimeout bounds each call, zero means no bound.
+	callTimeout time.Duration
+	// retry repeats calls failing with a transient error if set.
+	retry *bus.Retry
+	// breaker fails the calls on failing nodes fast if set.
+	breaker *bus.Breaker
+	// rateLimit limits the calls of all sessions if set.
+	rateLimit *bus.RateLimiter
+	// overflow is the overflow policy of the event channels.
+	overflow bus.Overflow
+	// labels keeps the labels of the nodes.
+	labels LabelStore
+	// names are the D-Bus names of the controller, nil for the standard
+	// ones.
+	names *bus.Names
+	// tracer creates the spans of the calls if set.
+	tracer trace.Tracer
+	// hooks are called around the mutating calls.
+	hooks []Hook
+	// dryRun validates the mutating calls instead of issuing them.
+	dryRun bool
+	// flags are set on all calls.
+	flags dbus.Flags
+	// strict checks the replies against the signatures of their methods.
+	strict bool
+}
+
+// Names are the D-Bus names a controller is deployed with: its bus name,
+// the object path of the controller, which is also the root of the paths of
+// the nodes, jobs and monitors, and the interface prefix, e.g.
+// "org.eclipse.bluechi" for the interfaces "org.eclipse.bluechi.Node" and
+// so on.
+type Names struct {
+	Service    string
+	ObjectPath dbus.ObjectPath
+	Interface  string
+}
+
+// DefaultNames are the names of BlueChi, used by the controller both on the
+// system bus and in user mode on the session bus.
+var DefaultNames = Names{
+	Service:    common.BC_DBUS_NAME,
+	ObjectPath: common.BC_OBJECT_PATH,
+	Interface:  common.BC_INTERFACE_BASE_NAME,
+}
+
+// Backoff configures the delay between two reconnection attempts, which
+// starts at Initial and is multiplied by Multiplier after each failed
+// attempt, up to Max. Zero fields are replaced by the values of
+// DefaultBackoff.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// DefaultBackoff is the backoff used by WithAutoReconnect for fields which
+// are not set.
+var DefaultBackoff = Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+}
+
+// normalize replaces the zero fields of b by the values of DefaultBackoff
+// and validates the result. what names the delay in errors.
+func (b Backoff) normalize(what string) (bus.Backoff, error) {
+	if b.Initial < 0 || b.Max < 0 || b.Multiplier < 0 {
+		return bus.Backoff{}, fmt.Errorf("negative %s backoff", what)
+	}
+	if b.Initial == 0 {
+		b.Initial = DefaultBackoff.Initial
+	}
+	if b.Max == 0 {
+		b.Max = DefaultBackoff.Max
+	}
+	if b.Multiplier == 0 {
+		b.Multiplier = DefaultBackoff.Multiplier
+	}
+	if b.Max < b.Initial {
+		return bus.Backoff{}, fmt.Errorf("maximum %s delay shorter than initial delay", what)
+	}
+	if b.Multiplier < 1 {
+		return bus.Backoff{}, fmt.Errorf("%s backoff multiplier smaller than 1", what)
+	}
+	return bus.Backoff{Initial: b.Initial, Max: b.Max, Multiplier: b.Multiplier}, nil
+}
+
+// RetryPolicy configures WithRetry. A call is attempted up to MaxAttempts
+// times, waiting according to Backoff between the attempts, whose zero
+// fields are replaced by the values of DefaultBackoff.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     Backoff
+	// RetryNonIdempotent also repeats the calls queueing jobs, e.g.
+	// StartUnit, or creating objects on the controller, e.g. CreateMonitor.
+	// They are executed twice if only the reply of the first attempt was
+	// lost.
+	RetryNonIdempotent bool
+}
+
+// CircuitBreakerPolicy configures WithCircuitBreaker. The breaker of a node
+// opens after FailureThreshold consecutive calls on it failed and lets a
+// probe call through after OpenTimeout. Zero fields are replaced by the
+// values of DefaultCircuitBreaker.
+type CircuitBreakerPolicy struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+// DefaultCircuitBreaker is the policy used by WithCircuitBreaker for fields
+// which are not set.
+var DefaultCircuitBreaker = CircuitBreakerPolicy{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+}
+
+// OverflowPolicy decides what happens with an event for a consumer which
+// lags behind and whose channel is full. Events lost are counted by
+// Manager.OverflowCount.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for the consumer to make room. No event is lost,
+	// but the delivery of the signals of the connection to all other
+	// channels is held up meanwhile. This is the default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest event in the channel.
+	OverflowDropOldest
+	// OverflowCoalesce replaces an event in the channel by a later event
+	// superseding it: state changes of the same unit or node and property
+	// changes of the same unit are merged. The oldest event is dropped if
+	// there is no such event.
+	OverflowCoalesce
+)
+
+func defaultOptions() options {
+	return options{
+		dial:   func() (*dbus.Conn, error) { return dbus.ConnectSystemBus() },
+		labels: NewMemoryLabelStore(),
+	}
+}
+
+// WithSystemBus connects to the controller on the system bus. This is the
+// default.
+func WithSystemBus() Option {
+	return func(o *options) error {
+		o.dial = func() (*dbus.Conn, error) { return dbus.ConnectSystemBus() }
+		return nil
+	}
+}
+
+// WithSessionBus connects to the controller on the session bus of the
+// current user, e.g. for rootless setups.
+func WithSessionBus() Option {
+	return func(o *options) error {
+		o.dial = func() (*dbus.Conn, error) { return dbus.ConnectSessionBus() }
+		return nil
+	}
+}
+
+// WithAutoDetectBus connects to the controller on the system bus if it is
+// running there and on the session bus of the current user otherwise, e.g.
+// for a controller in user mode on a rootless container host. The system bus
+// is used if the controller is running on neither, the session bus if the
+// system bus is not reachable. The bus is detected again on each
+// reconnection.
+func WithAutoDetectBus() Option {
+	return func(o *options) error {
+		o.dial = func() (*dbus.Conn, error) { return detectBus(o.serviceName()) }
+		return nil
+	}
+}
+
+// detectBus returns a connection to the bus service is owned on.
+func detectBus(service string) (*dbus.Conn, error) {
+	system, systemErr := dbus.ConnectSystemBus()
+	if systemErr == nil && hasOwner(system, service) {
+		return system, nil
+	}
+	session, err := dbus.ConnectSessionBus()
+	switch {
+	case err != nil && systemErr != nil:
+		return nil, errors.Join(systemErr, err)
+	case err != nil:
+		return system, nil
+	case systemErr != nil:
+		return session, nil
+	case hasOwner(session, service):
+		system.Close()
+		return session, nil
+	}
+	session.Close()
+	return system, nil
+}
+
+func hasOwner(conn *dbus.Conn, service string) bool {
+	var owned bool
+	err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, service).Store(&owned)
+	return err == nil && owned
+}
+
+// WithNames talks to a controller deployed with other D-Bus names than
+// DefaultNames. Zero fields keep their default.
+func WithNames(names Names) Option {
+	return func(o *options) error {
+		if names.Service == "" {
+			names.Service = DefaultNames.Service
+		}
+		if names.ObjectPath == "" {
+			names.ObjectPath = DefaultNames.ObjectPath
+		}
+		if names.Interface == "" {
+			names.Interface = DefaultNames.Interface
+		}
+		if !names.ObjectPath.IsValid() || names.ObjectPath == "/" {
+			return fmt.Errorf("invalid controller object path %q", names.ObjectPath)
+		}
+		if !validName(names.Service) {
+			return fmt.Errorf("invalid controller bus name %q", names.Service)
+		}
+		if !validName(names.Interface) {
+			return fmt.Errorf("invalid controller interface prefix %q", names.Interface)
+		}
+		o.names = &bus.Names{Service: names.Service, ObjectPath: names.ObjectPath, Interface: names.Interface}
+		return nil
+	}
+}
+
+// validName reports whether name is a well-known bus name or an interface
+// name, i.e. at least two dot-separated elements of letters, digits and
+// underscores not starting with a digit.
+func validName(name string) bool {
+	elements := strings.Split(name, ".")
+	if len(elements) < 2 || len(name) > 255 {
+		return false
+	}
+	for _, element := range elements {
+		if element == "" || element[0] >= '0' && element[0] <= '9' {
+			return false
+		}
+		for _, r := range element {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (o *options) serviceName() string {
+	if o.names != nil {
+		return o.names.Service
+	}
+	return common.BC_DBUS_NAME
+}
+
+// WithBusAddress connects to the controller on the bus at the given D-Bus
+// address, e.g. "unix:path=/run/bluechi/bus" for a private test bus.
+func WithBusAddress(address string) Option {
+	return func(o *options) error {
+		if address == "" {
+			return errors.New("empty bus address")
+		}
+		o.dial = func() (*dbus.Conn, error) { return dbus.Connect(address) }
+		return nil
+	}
+}
+
+// WithPeerAddress opens a direct peer-to-peer D-Bus connection to the server
+// at the given address, e.g. "unix:path=/run/bluechi-api.sock", instead of
+// connecting to a bus. It is meant for custom peer servers exporting the
+// public API of the controller, e.g. a proxy or a test double: the port the
+// controller listens on serves only its internal agent protocol. The
+// connection is authenticated anonymously.
+func WithPeerAddress(address string) Option {
+	return func(o *options) error {
+		if address == "" {
+			return errors.New("empty peer address")
+		}
+		o.dial = func() (*dbus.Conn, error) { return bus.DialPeer(address) }
+		return nil
+	}
+}
+
+// WithLazyConnect defers opening the connection from NewManager to the
+// first call issued on the Manager, which then fails if the controller is
+// not reachable. A connection lost without auto-reconnect is opened again
+// by the next call.
+func WithLazyConnect() Option {
+	return func(o *options) error {
+		o.lazy = true
+		return nil
+	}
+}
+
+// WithAutoReconnect keeps the Manager connected to the controller. When the
+// connection drops or the controller restarts, it is re-established using
+// the given backoff, all signal subscriptions are registered again and
+// monitors are recreated on the controller together with their
+// subscriptions. Proxies returned by the Manager stay valid, calls issued
+// while disconnected fail with ErrDisconnected. Jobs do not survive a
+// reconnect, waiting for them fails. The transitions are reported on
+// ConnectionEvents.
+func WithAutoReconnect(backoff Backoff) Option {
+	return func(o *options) error {
+		b, err := backoff.normalize("reconnect")
+		if err != nil {
+			return err
+		}
+		o.reconnect = &b
+		return nil
+	}
+}
+
+// WithLogger routes the diagnostics of the Manager and of the proxies
+// obtained from it to logger, e.g. reconnection attempts and failures to
+// restore monitors after a reconnect, which cannot be returned to a caller.
+// Without it nothing is logged. Errors of calls are returned and never
+// logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) error {
+		if logger == nil {
+			return errors.New("nil logger")
+		}
+		o.logger = logger
+		return nil
+	}
+}
+
+// WithCallTimeout bounds each D-Bus call issued by the Manager and the
+// proxies obtained from it to timeout, on top of the context passed to the
+// call. With WithRetry, the timeout applies to each attempt.
+func WithCallTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		if timeout <= 0 {
+			return errors.New("non-positive call timeout")
+		}
+		o.callTimeout = timeout
+		return nil
+	}
+}
+
+// WithInteractiveAuthorization allows polkit to authenticate the user
+// interactively, e.g. with a password prompt of the desktop, to authorize
+// the calls issued by the Manager and the proxies obtained from it. Without
+// it, calls needing interactive authentication fail with an error matching
+// common.ErrNotAuthorized. A call waits for the authentication, which can
+// take long, the call timeout should allow for it.
+func WithInteractiveAuthorization() Option {
+	return func(o *options) error {
+		o.flags |= dbus.FlagAllowInteractiveAuthorization
+		return nil
+	}
+}
+
+// WithStrictDecode checks the replies to the D-Bus calls issued by the
+// Manager and the proxies obtained from it against the signatures of their
+// methods in the introspection XML files the bindings were built from.
+// Calls whose reply does not match fail with a *common.SchemaError telling
+// the return value and field, and the expected and actual type, instead of
+// the generic error of decoding it, e.g. to debug a controller of another
+// version. Replies of methods without a known schema are not checked, see
+// common.ReplySignature.
+func WithStrictDecode() Option {
+	return func(o *options) error {
+		o.strict = true
+		return nil
+	}
+}
+
+// WithTracerProvider creates an OpenTelemetry span for each D-Bus call
+// issued by the Manager and the proxies obtained from it, using the tracer
+// of provider named after the module of the bindings. The spans are named
+// after the interface and method, e.g.
+// "org.eclipse.bluechi.Node/StartUnit", and carry the node and unit called
+// on as attributes bluechi.node and bluechi.unit. They are children of the
+// span in the context passed to the call, retries are recorded as events.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) error {
+		if provider == nil {
+			return errors.New("nil tracer provider")
+		}
+		o.tracer = provider.Tracer(bus.TracerName)
+		return nil
+	}
+}
+
+// WithRetry repeats the D-Bus calls issued by the Manager and the proxies
+// obtained from it which fail with a transient error, i.e. while
+// disconnected from the controller, when the reply timed out or the bus
+// connection closed. Other errors are returned right away. Calls queueing
+// jobs or creating objects on the controller are not repeated unless the
+// policy allows it. Retrying ends early when the context of the call is
+// done.
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) error {
+		if policy.MaxAttempts < 1 {
+			return errors.New("retry policy with less than one attempt")
+		}
+		b, err := policy.Backoff.normalize("retry")
+		if err != nil {
+			return err
+		}
+		o.retry = &bus.Retry{
+			MaxAttempts:   policy.MaxAttempts,
+			Backoff:       b,
+			NonIdempotent: policy.RetryNonIdempotent,
+		}
+		return nil
+	}
+}
+
+// WithCircuitBreaker keeps a circuit breaker for each node, which trips
+// after repeated calls on the node failed because it was offline or did not
+// reply in time. While it is open, calls on the node fail right away with an
+// error matching common.ErrNodeCircuitOpen, e.g. to keep batch operations
+// from waiting for a flapping node again and again. Once the open timeout
+// passed, the next call probes the node, it closes the breaker if the node
+// replies and opens it again otherwise. Calls on the controller, e.g.
+// ListUnits of all nodes, are not affected.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) Option {
+	return func(o *options) error {
+		if policy.FailureThreshold < 0 || policy.OpenTimeout < 0 {
+			return errors.New("negative circuit breaker policy")
+		}
+		if policy.FailureThreshold == 0 {
+			policy.FailureThreshold = DefaultCircuitBreaker.FailureThreshold
+		}
+		if policy.OpenTimeout == 0 {
+			policy.OpenTimeout = DefaultCircuitBreaker.OpenTimeout
+		}
+		o.breaker = &bus.Breaker{Threshold: policy.FailureThreshold, OpenTimeout: policy.OpenTimeout}
+		return nil
+	}
+}
+
+// WithRateLimit limits the D-Bus calls issued by the Manager and the proxies
+// obtained from it to rps per second on average with bursts of up to burst
+// calls, e.g. to protect a small edge controller from aggressive reconcile
+// loops. The limit is shared by all calls of the Manager, including retries
+// and the calls after a reconnect. A call waits for its turn as long as its
+// context allows, it fails right away if its deadline would pass before, with
+// an error matching ErrRateLimited. Such refusals neither count against the
+// circuit breaker of WithCircuitBreaker nor are retried.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *options) error {
+		if rps <= 0 {
+			return errors.New("non-positive rate limit")
+		}
+		if burst < 1 {
+			return errors.New("rate limit burst smaller than 1")
+		}
+		o.rateLimit = bus.NewRateLimiter(rps, burst)
+		return nil
+	}
+}
+
+// WithEventOverflow sets the overflow policy of the channels delivering
+// the events of monitors, the node status and metrics, e.g. to keep a slow
+// consumer from holding up the others. The job events of a job.Tracker are
+// always dropped when the consumer lags behind.
+func WithEventOverflow(policy OverflowPolicy) Option {
+	return func(o *options) error {
+		switch policy {
+		case OverflowBlock:
+			o.overflow = bus.OverflowBlock
+		case OverflowDropOldest:
+			o.overflow = bus.OverflowDropOldest
+		case OverflowCoalesce:
+			o.overflow = bus.OverflowCoalesce
+		default:
+			return fmt.Errorf("unknown overflow policy %d", policy)
+		}
+		return nil
+	}
+}
+
+// WithLabelStore keeps the labels set with SetNodeLabels in store instead
+// of in memory, e.g. in a FileLabelStore shared by several tools.
+func WithLabelStore(store LabelStore) Option {
+	return func(o *options) error {
+		if store == nil {
+			return errors.New("nil label store")
+		}
+		o.labels = store
+		return nil
+	}
+}
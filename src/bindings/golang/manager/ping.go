@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// PingResult is the round trip time to the agent of a single node.
+type PingResult struct {
+	// Node is the name of the node.
+	Node string
+	// RTT is the round trip time, zero if the ping failed.
+	RTT time.Duration
+	// Err is the error of the ping, e.g. one matching common.ErrNodeOffline.
+	Err error
+}
+
+// PingReport holds the round trip times measured by Ping.
+type PingReport struct {
+	// Controller is the round trip time to the controller.
+	Controller time.Duration
+	// Nodes are the results of the nodes in the order returned by
+	// ListNodes.
+	Nodes []PingResult
+}
+
+// Ping measures the round trip time to the controller, by calling
+// org.freedesktop.DBus.Peer.Ping on it, and to the agents of all nodes, see
+// node.Node.Ping. Up to DefaultConcurrency nodes are pinged at the same
+// time. An error is returned only if the controller cannot be reached,
+// failures of single nodes are reported in their results.
+func (m *Manager) Ping(ctx context.Context) (PingReport, error) {
+	s, err := m.session()
+	if err != nil {
+		return PingReport{}, err
+	}
+
+	start := time.Now()
+	if err := bus.Exec(ctx, s.obj, common.METHOD_PEER_PING); err != nil {
+		return PingReport{}, fmt.Errorf("failed to ping controller: %w", err)
+	}
+	report := PingReport{Controller: time.Since(start)}
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return PingReport{}, err
+	}
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = n.Name
+	}
+	report.Nodes = make([]PingResult, len(names))
+	errs := make([]error, len(names))
+	m.fanOut(ctx, names, nil, func(i int, n *node.Node) {
+		report.Nodes[i].RTT, report.Nodes[i].Err = n.Ping(ctx)
+	}, errs)
+	for i, name := range names {
+		report.Nodes[i].Node = name
+		if errs[i] != nil {
+			report.Nodes[i].Err = errs[i]
+		}
+	}
+	return report, nil
+}
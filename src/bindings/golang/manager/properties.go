@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// Status returns the status of the overall system, one of
+// common.SYSTEM_STATUS_UP if all expected nodes are connected,
+// common.SYSTEM_STATUS_DEGRADED if at least one of them is not, or
+// common.SYSTEM_STATUS_DOWN if no node is connected.
+func (m *Manager) Status(ctx context.Context) (string, error) {
+	return m.getStringProperty(ctx, "Status")
+}
+
+// LogLevel returns the log level currently used by the controller, e.g.
+// common.LOG_LEVEL_INFO.
+func (m *Manager) LogLevel(ctx context.Context) (string, error) {
+	return m.getStringProperty(ctx, "LogLevel")
+}
+
+// LogTarget returns the log target currently used by the controller, one
+// of stderr, stderr-full or journald.
+func (m *Manager) LogTarget(ctx context.Context) (string, error) {
+	return m.getStringProperty(ctx, "LogTarget")
+}
+
+// GetProperty returns the named property of the controller decoded into
+// its Go type. It gives access to properties added to the controller
+// interface which have no getter of their own yet.
+func (m *Manager) GetProperty(ctx context.Context, name string) (interface{}, error) {
+	v, err := m.getProperty(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return v.Value(), nil
+}
+
+func (m *Manager) getProperty(ctx context.Context, name string) (dbus.Variant, error) {
+	s, err := m.session()
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+
+	v, err := bus.GetProperty(ctx, s.obj, common.CONTROLLER_INTERFACE, name)
+	if err != nil {
+		return dbus.Variant{}, fmt.Errorf("failed to get controller property %s: %w", name, err)
+	}
+	return v, nil
+}
+
+func (m *Manager) getStringProperty(ctx context.Context, name string) (string, error) {
+	v, err := m.getProperty(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	s, err := variant.String(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode controller property %s: %w", name, err)
+	}
+	return s, nil
+}
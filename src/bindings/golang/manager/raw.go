@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// RawCall calls method of iface on the object of the controller at path and
+// returns the body of the reply, e.g. to use a method added to BlueChi
+// which the bindings do not wrap yet. The call is subject to the call
+// timeout, tracing and the standard names of WithNames like all others.
+// As the bindings know nothing about the method, it is treated as mutating
+// and not idempotent: it passes the hooks and WithDryRun, which replies
+// with an empty body, and is only repeated by WithRetry with
+// RetryNonIdempotent. path must be at or below common.BC_OBJECT_PATH and
+// iface a BlueChi interface or the standard Properties or Introspectable
+// interface. Errors replied by the controller are wrapped as *common.Error.
+// If ctx carries dbus.FlagNoReplyExpected, see common.WithCallFlags, the
+// call returns an empty body once it is sent.
+func (m *Manager) RawCall(ctx context.Context, path dbus.ObjectPath, iface string, method string, args ...interface{}) ([]interface{}, error) {
+	if err := checkRawCall(path, iface, method); err != nil {
+		return nil, err
+	}
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := bus.RawObject(s.conn, common.BC_DBUS_NAME, path)
+	call := obj.CallWithContext(ctx, iface+"."+method, common.CallFlagsFromContext(ctx), args...)
+	if call.Err != nil {
+		return nil, fmt.Errorf("failed to call %s.%s on %s: %w", iface, method, path, call.Err)
+	}
+	return call.Body, nil
+}
+
+// checkRawCall keeps RawCall to the objects and interfaces of BlueChi.
+func checkRawCall(path dbus.ObjectPath, iface string, method string) error {
+	if !path.IsValid() || path != common.BC_OBJECT_PATH && !strings.HasPrefix(string(path), common.BC_OBJECT_PATH+"/") {
+		return fmt.Errorf("failed to call %s.%s: %q is not a BlueChi object path: %w", iface, method, path, common.ErrInvalidArgs)
+	}
+	switch {
+	case iface == common.PROPERTIES_INTERFACE, iface == common.INTROSPECTABLE_INTERFACE:
+	case strings.HasPrefix(iface, common.BC_INTERFACE_BASE_NAME+".") && !strings.HasSuffix(iface, "."):
+	default:
+		return fmt.Errorf("failed to call %s.%s: %q is not a BlueChi interface: %w", iface, method, iface, common.ErrInvalidArgs)
+	}
+	if method == "" || strings.ContainsAny(method, "./") {
+		return fmt.Errorf("failed to call %s.%s: invalid method name: %w", iface, method, common.ErrInvalidArgs)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// SortOrder is the order of the nodes returned by ListNodes with
+// WithNodeOrder and of the units of each node returned by ListUnits with
+// WithUnitOrder, e.g. for tools diffing the results of two calls or golden
+// tests. Without one, they are in the order of the controller, which may
+// change between calls.
+type SortOrder int
+
+const (
+	// Unsorted keeps the order of the controller.
+	Unsorted SortOrder = iota
+	// SortByName sorts by name.
+	SortByName
+	// SortByState sorts by the status of nodes or the active state of
+	// units, then by name.
+	SortByState
+)
+
+// ListNodesOption configures ListNodes.
+type ListNodesOption func(*listNodesOptions)
+
+type listNodesOptions struct {
+	order SortOrder
+}
+
+// WithNodeOrder returns the nodes in the given order.
+func WithNodeOrder(order SortOrder) ListNodesOption {
+	return func(o *listNodesOptions) {
+		o.order = order
+	}
+}
+
+// OrderNodes returns nodes sorted in place as requested by the options, as
+// ListNodes does with the nodes of the controller, e.g. for fakes of
+// ManagerAPI.
+func OrderNodes(nodes []NodeInfo, opts ...ListNodesOption) []NodeInfo {
+	var o listNodesOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	SortNodes(nodes, o.order)
+	return nodes
+}
+
+// SortNodes sorts nodes in place in the given order.
+func SortNodes(nodes []NodeInfo, order SortOrder) {
+	switch order {
+	case SortByName:
+		slices.SortStableFunc(nodes, func(a, b NodeInfo) int { return cmp.Compare(a.Name, b.Name) })
+	case SortByState:
+		slices.SortStableFunc(nodes, func(a, b NodeInfo) int {
+			return cmp.Or(cmp.Compare(a.Status, b.Status), cmp.Compare(a.Name, b.Name))
+		})
+	}
+}
+
+// SortUnits sorts units in place in the given order.
+func SortUnits(units []node.UnitInfo, order SortOrder) {
+	switch order {
+	case SortByName:
+		slices.SortStableFunc(units, func(a, b node.UnitInfo) int { return cmp.Compare(a.Name, b.Name) })
+	case SortByState:
+		slices.SortStableFunc(units, func(a, b node.UnitInfo) int {
+			return cmp.Or(cmp.Compare(a.ActiveState, b.ActiveState), cmp.Compare(a.Name, b.Name))
+		})
+	}
+}
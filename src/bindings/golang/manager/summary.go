@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// UnitSummary counts the units of a node by their active state.
+type UnitSummary struct {
+	// Total is the number of units on the node.
+	Total int `json:"total"`
+	// Active is the number of active units.
+	Active int `json:"active"`
+	// Inactive is the number of inactive units.
+	Inactive int `json:"inactive"`
+	// Failed is the number of failed units.
+	Failed int `json:"failed"`
+	// Other is the number of units in a transitional state, e.g.
+	// activating or reloading.
+	Other int `json:"other"`
+}
+
+// UnitSummary returns the number of units of each online node by active
+// state, restricted by the options, e.g. WithPattern("*.service"). The units
+// of all nodes are listed by a single call of the controller, only the
+// counts are kept.
+func (m *Manager) UnitSummary(ctx context.Context, opts ...ListUnitsOption) (map[string]UnitSummary, error) {
+	units, err := m.ListUnits(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return SummarizeUnits(units), nil
+}
+
+// SummarizeUnits returns the number of units keyed by node name by active
+// state, as UnitSummary does on the units of the controller.
+func SummarizeUnits(units map[string][]node.UnitInfo) map[string]UnitSummary {
+	summaries := make(map[string]UnitSummary, len(units))
+	for name, nodeUnits := range units {
+		var s UnitSummary
+		for _, u := range nodeUnits {
+			s.Total++
+			switch u.ActiveState {
+			case node.ActiveStateActive:
+				s.Active++
+			case node.ActiveStateInactive:
+				s.Inactive++
+			case node.ActiveStateFailed:
+				s.Failed++
+			default:
+				s.Other++
+			}
+		}
+		summaries[name] = s
+	}
+	return summaries
+}
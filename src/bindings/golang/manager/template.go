@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// Instance is an instance of a template unit on a node as returned by
+// ListInstances.
+type Instance struct {
+	// Node is the name of the node.
+	Node string
+	// Instance is the unescaped instance name, e.g. "42" for
+	// worker@42.service.
+	Instance string
+	// Unit is the instantiated unit.
+	Unit node.UnitInfo
+}
+
+// EscapeInstanceName escapes s for use as the instance of a template unit
+// as systemd-escape does: "/" becomes "-", ASCII letters, digits, ":", "_"
+// and "." are kept except for a leading ".", all other bytes are written as
+// "\xNN".
+func EscapeInstanceName(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/':
+			b.WriteByte('-')
+		case c == '.' && i == 0, !validInstanceChar(c):
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// UnescapeInstanceName reverses EscapeInstanceName, e.g. for the instance
+// of a unit listed by systemd.
+func UnescapeInstanceName(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '-':
+			b.WriteByte('/')
+		case '\\':
+			if i+4 > len(s) || s[i+1] != 'x' {
+				return "", fmt.Errorf("invalid escape sequence in instance name %q", s)
+			}
+			v, err := strconv.ParseUint(s[i+2:i+4], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence in instance name %q", s)
+			}
+			b.WriteByte(byte(v))
+			i += 3
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func validInstanceChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == ':' || c == '_' || c == '.'
+}
+
+// splitTemplate returns the prefix up to and including the "@" and the
+// suffix of a template unit name, e.g. "worker@" and ".service" for both
+// "worker@" and "worker@.service".
+func splitTemplate(template string) (string, string, error) {
+	prefix, suffix, ok := strings.Cut(template, "@")
+	if !ok || prefix == "" || (suffix != "" && !strings.HasPrefix(suffix, ".")) || strings.Contains(suffix, "@") {
+		return "", "", fmt.Errorf("invalid template unit %q", template)
+	}
+	if suffix == "" {
+		suffix = ".service"
+	}
+	return prefix + "@", suffix, nil
+}
+
+// InstanceName returns the name of the unit instantiating template with
+// instance, which is escaped by EscapeInstanceName, e.g.
+// worker@42.service for "worker@" and "42". A template without a type
+// suffix is a service.
+func InstanceName(template string, instance string) (string, error) {
+	prefix, suffix, err := splitTemplate(template)
+	if err != nil {
+		return "", err
+	}
+	if instance == "" {
+		return "", fmt.Errorf("empty instance of template unit %q", template)
+	}
+	return prefix + EscapeInstanceName(instance) + suffix, nil
+}
+
+// InstanceUnit returns a handle for the unit instantiating template with
+// instance on the named node, see InstanceName.
+func (m *Manager) InstanceUnit(node string, template string, instance string) (*Unit, error) {
+	name, err := InstanceName(template, instance)
+	if err != nil {
+		return nil, err
+	}
+	return m.Unit(node, name), nil
+}
+
+// ListInstances returns the loaded instances of template on all online
+// nodes, sorted by node and instance, restricted by the options, e.g.
+// WithActiveState(node.ActiveStateActive) for the running ones. The units
+// are listed by a single call of the controller.
+func (m *Manager) ListInstances(ctx context.Context, template string, opts ...ListUnitsOption) ([]Instance, error) {
+	prefix, suffix, err := splitTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	units, err := m.ListUnits(ctx, append(opts, WithPattern(prefix+"*"+suffix))...)
+	if err != nil {
+		return nil, err
+	}
+	return FilterInstances(units, template)
+}
+
+// FilterInstances returns the instances of template among the units keyed
+// by node name, as ListInstances does on the units of the controller.
+func FilterInstances(units map[string][]node.UnitInfo, template string) ([]Instance, error) {
+	prefix, suffix, err := splitTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	var instances []Instance
+	for name, nodeUnits := range units {
+		for _, u := range nodeUnits {
+			escaped, ok := strings.CutPrefix(u.Name, prefix)
+			if !ok {
+				continue
+			}
+			escaped, ok = strings.CutSuffix(escaped, suffix)
+			if !ok || escaped == "" {
+				continue
+			}
+			instance, err := UnescapeInstanceName(escaped)
+			if err != nil {
+				// keep names not escaped by the rules of systemd as they are
+				instance = escaped
+			}
+			instances = append(instances, Instance{Node: name, Instance: instance, Unit: u})
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].Node != instances[j].Node {
+			return instances[i].Node < instances[j].Node
+		}
+		return instances[i].Instance < instances[j].Instance
+	})
+	return instances, nil
+}
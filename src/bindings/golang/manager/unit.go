@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// unitWatchBufferSize is the capacity of the channel returned by
+// Unit.Watch.
+const unitWatchBufferSize = 16
+
+// UnitStatus is the state of a unit as returned by Unit.Status.
+type UnitStatus struct {
+	// Node is the name of the node.
+	Node string
+	// Unit is the name of the unit.
+	Unit        string
+	LoadState   node.LoadState
+	ActiveState node.ActiveState
+	SubState    node.SubState
+}
+
+// Unit is a handle for a unit on a node. It resolves the node on first use
+// and is safe for concurrent use. The lifecycle methods queue their job in
+// node.ModeReplace and wait for it to finish.
+type Unit struct {
+	m    ManagerAPI
+	node string
+	name string
+
+	mu sync.Mutex
+	n  NodeAPI
+}
+
+// Unit returns a handle for the named unit on the named node. No call is
+// issued until a method of the handle is used.
+func (m *Manager) Unit(node string, name string) *Unit {
+	return NewUnit(m.API(), node, name)
+}
+
+// NewUnit returns a handle for the named unit on the named node of m, e.g.
+// of a fake of package managertest.
+func NewUnit(m ManagerAPI, node string, name string) *Unit {
+	return &Unit{m: m, node: node, name: name}
+}
+
+// Node returns the name of the node of the unit.
+func (u *Unit) Node() string {
+	return u.node
+}
+
+// Name returns the name of the unit.
+func (u *Unit) Name() string {
+	return u.name
+}
+
+func (u *Unit) proxy(ctx context.Context) (NodeAPI, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.n == nil {
+		n, err := u.m.GetNode(ctx, u.node)
+		if err != nil {
+			return nil, err
+		}
+		u.n = n
+	}
+	return u.n, nil
+}
+
+// Start starts the unit and waits for the job to finish.
+func (u *Unit) Start(ctx context.Context) error {
+	n, err := u.proxy(ctx)
+	if err != nil {
+		return err
+	}
+	return n.StartUnitAndWait(ctx, u.name, node.ModeReplace)
+}
+
+// Stop stops the unit and waits for the job to finish.
+func (u *Unit) Stop(ctx context.Context) error {
+	n, err := u.proxy(ctx)
+	if err != nil {
+		return err
+	}
+	return n.StopUnitAndWait(ctx, u.name, node.ModeReplace)
+}
+
+// Restart restarts the unit and waits for the job to finish.
+func (u *Unit) Restart(ctx context.Context) error {
+	n, err := u.proxy(ctx)
+	if err != nil {
+		return err
+	}
+	return n.RestartUnitAndWait(ctx, u.name, node.ModeReplace)
+}
+
+// Status returns the load, active and sub state of the unit, read with a
+// single call.
+func (u *Unit) Status(ctx context.Context) (UnitStatus, error) {
+	props, err := u.Properties(ctx)
+	if err != nil {
+		return UnitStatus{}, err
+	}
+	s := UnitStatus{Node: u.node, Unit: u.name}
+	if v, ok := props["LoadState"].(string); ok {
+		s.LoadState = node.LoadState(v)
+	}
+	if v, ok := props["ActiveState"].(string); ok {
+		s.ActiveState = node.ActiveState(v)
+	}
+	if v, ok := props["SubState"].(string); ok {
+		s.SubState = node.SubState(v)
+	}
+	return s, nil
+}
+
+// Properties returns the properties of the org.freedesktop.systemd1.Unit
+// interface of the unit.
+func (u *Unit) Properties(ctx context.Context) (map[string]interface{}, error) {
+	n, err := u.proxy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return n.GetUnitProperties(ctx, u.name, common.SYSTEMD_UNIT_INTERFACE)
+}
+
+// Watch returns a channel receiving the events of the unit, e.g.
+// monitor.UnitStateChanged, from a monitor created for the watch. The
+// monitor is closed and the channel with it when ctx is done or the
+// connection is closed.
+func (u *Unit) Watch(ctx context.Context) (<-chan monitor.Event, error) {
+	mon, err := u.m.CreateMonitor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, events, err := mon.Watch(ctx, u.node, u.name)
+	if err != nil {
+		closeMonitor(mon)
+		return nil, fmt.Errorf("failed to watch unit %s on node %s: %w", u.name, u.node, err)
+	}
+
+	out := make(chan monitor.Event, unitWatchBufferSize)
+	go func() {
+		defer close(out)
+		// the watch channel closes when ctx is done or the monitor closes
+		defer closeMonitor(mon)
+		for event := range events {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// closeMonitor closes a monitor no longer used by a watch.
+func closeMonitor(mon MonitorAPI) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = mon.Close(ctx)
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package manager
+
+import (
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// ListUnitsOption restricts the units returned by ListUnits. Options of
+// different kinds must all match, each option accepts a unit matching any
+// of its values.
+type ListUnitsOption func(*unitFilter)
+
+type unitFilter struct {
+	activeStates []node.ActiveState
+	patterns     []string
+	nodes        []string
+	order        SortOrder
+}
+
+// WithActiveState returns only the units in one of the active states, e.g.
+// node.ActiveStateFailed.
+func WithActiveState(states ...node.ActiveState) ListUnitsOption {
+	return func(f *unitFilter) {
+		f.activeStates = append(f.activeStates, states...)
+	}
+}
+
+// WithPattern returns only the units whose name matches one of the glob
+// patterns, e.g. "nginx*". See monitor.Match for the syntax.
+func WithPattern(patterns ...string) ListUnitsOption {
+	return func(f *unitFilter) {
+		f.patterns = append(f.patterns, patterns...)
+	}
+}
+
+// WithNodes returns only the units of the named nodes. Their units are
+// requested from each node instead of listing the units of all nodes.
+func WithNodes(names ...string) ListUnitsOption {
+	return func(f *unitFilter) {
+		f.nodes = append(f.nodes, names...)
+	}
+}
+
+// WithUnitOrder returns the units of each node in the given order. It
+// restricts no units. ListUnitsFunc passes the units on in the order they
+// are received and ignores it.
+func WithUnitOrder(order SortOrder) ListUnitsOption {
+	return func(f *unitFilter) {
+		f.order = order
+	}
+}
+
+func newUnitFilter(opts []ListUnitsOption) unitFilter {
+	var f unitFilter
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return f
+}
+
+func (f unitFilter) empty() bool {
+	return len(f.activeStates) == 0 && len(f.patterns) == 0 && len(f.nodes) == 0
+}
+
+func (f unitFilter) matchesNode(name string) bool {
+	return len(f.nodes) == 0 || contains(f.nodes, name)
+}
+
+func (f unitFilter) matches(u node.UnitInfo) bool {
+	if len(f.activeStates) > 0 && !contains(f.activeStates, u.ActiveState) {
+		return false
+	}
+	if len(f.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range f.patterns {
+		if monitor.Match(pattern, u.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterUnits returns the units keyed by node name which match the
+// options, as ListUnits does on the units of the controller. Nodes without
+// matching units are omitted unless no options restricting the units are
+// given. With WithUnitOrder, the units of each node are sorted in place.
+func FilterUnits(units map[string][]node.UnitInfo, opts ...ListUnitsOption) map[string][]node.UnitInfo {
+	f := newUnitFilter(opts)
+	if !f.empty() {
+		units = f.apply(units)
+	}
+	f.sort(units)
+	return units
+}
+
+func (f unitFilter) apply(units map[string][]node.UnitInfo) map[string][]node.UnitInfo {
+	filtered := make(map[string][]node.UnitInfo)
+	for name, nodeUnits := range units {
+		if !f.matchesNode(name) {
+			continue
+		}
+		for _, u := range nodeUnits {
+			if f.matches(u) {
+				filtered[name] = append(filtered[name], u)
+			}
+		}
+	}
+	return filtered
+}
+
+// sort sorts the units of each node in the order of f.
+func (f unitFilter) sort(units map[string][]node.UnitInfo) {
+	if f.order == Unsorted {
+		return
+	}
+	for _, nodeUnits := range units {
+		SortUnits(nodeUnits, f.order)
+	}
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
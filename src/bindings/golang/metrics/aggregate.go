@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package metrics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWindow is the period the percentiles of an Aggregator cover unless
+// WithWindow is given.
+const DefaultWindow = 5 * time.Minute
+
+const (
+	// windowSlots is the number of histograms a window is split into, the
+	// oldest of which is dropped as the window moves on.
+	windowSlots = 10
+	// bucketsPerDoubling is the number of histogram buckets per doubling
+	// of the duration, which bounds the error of the percentiles to about
+	// 4.5%.
+	bucketsPerDoubling = 8
+)
+
+// Percentiles are the percentiles of the job start times of a node within
+// the window of an Aggregator.
+type Percentiles struct {
+	// Count is the number of jobs within the window.
+	Count uint64
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	// Max is the longest job start time within the window.
+	Max time.Duration
+}
+
+// AggregatorOption configures NewAggregator.
+type AggregatorOption func(*Aggregator)
+
+// WithWindow sets the period the percentiles cover, DefaultWindow by
+// default. Older job start times are dropped in steps of a tenth of it.
+func WithWindow(window time.Duration) AggregatorOption {
+	return func(a *Aggregator) {
+		if window > 0 {
+			a.window = window
+		}
+	}
+}
+
+// Aggregator keeps rolling histograms of the times it took BlueChi to start
+// units, the JobMeasuredTime of StartUnitJobMetrics, per node, so that SLO
+// tooling can query their percentiles without keeping the events. It is
+// fed by Run or Observe and is safe for concurrent use.
+type Aggregator struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	nodes map[string]*[windowSlots]histogram
+}
+
+// histogram counts the job start times of one slot of the window by their
+// bucket.
+type histogram struct {
+	// epoch is the number of the slot since the Unix epoch.
+	epoch  int64
+	counts map[int]uint64
+	total  uint64
+	min    time.Duration
+	max    time.Duration
+}
+
+// NewAggregator returns an empty Aggregator.
+func NewAggregator(opts ...AggregatorOption) *Aggregator {
+	a := &Aggregator{window: DefaultWindow, nodes: make(map[string]*[windowSlots]histogram)}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Run observes the events until the channel is closed or ctx is done, e.g.
+// the channel returned by Subscribe or Manager.SubscribeMetrics.
+func (a *Aggregator) Run(ctx context.Context, events <-chan Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			a.Observe(event)
+		}
+	}
+}
+
+// Observe records the job start time of a StartUnitJobMetrics event, other
+// events are ignored.
+func (a *Aggregator) Observe(event Event) {
+	if e, ok := event.(StartUnitJobMetrics); ok {
+		a.ObserveDuration(e.Node, e.JobMeasuredTime)
+	}
+}
+
+// ObserveDuration records a job start time of the named node.
+func (a *Aggregator) ObserveDuration(node string, d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	slots, ok := a.nodes[node]
+	if !ok {
+		slots = new([windowSlots]histogram)
+		a.nodes[node] = slots
+	}
+	epoch := a.epoch(time.Now())
+	h := &slots[epoch%windowSlots]
+	if h.epoch != epoch || h.counts == nil {
+		*h = histogram{epoch: epoch, counts: make(map[int]uint64), min: d, max: d}
+	}
+	h.counts[bucket(d)]++
+	h.total++
+	h.min = min(h.min, d)
+	h.max = max(h.max, d)
+}
+
+// Percentiles returns the percentiles of the named node, false if no job
+// start time of it is within the window.
+func (a *Aggregator) Percentiles(node string) (Percentiles, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	h, ok := a.mergeLocked(node)
+	if !ok {
+		return Percentiles{}, false
+	}
+	return Percentiles{
+		Count: h.total,
+		P50:   h.quantile(0.5),
+		P95:   h.quantile(0.95),
+		P99:   h.quantile(0.99),
+		Max:   h.max,
+	}, true
+}
+
+// Quantile returns the q-quantile, e.g. 0.999, of the job start times of
+// the named node, false if none is within the window.
+func (a *Aggregator) Quantile(node string, q float64) (time.Duration, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	h, ok := a.mergeLocked(node)
+	if !ok {
+		return 0, false
+	}
+	return h.quantile(q), true
+}
+
+// Snapshot returns the percentiles of all nodes with job start times within
+// the window keyed by node name.
+func (a *Aggregator) Snapshot() map[string]Percentiles {
+	a.mu.Lock()
+	names := make([]string, 0, len(a.nodes))
+	for name := range a.nodes {
+		names = append(names, name)
+	}
+	a.mu.Unlock()
+
+	snapshot := make(map[string]Percentiles, len(names))
+	for _, name := range names {
+		if p, ok := a.Percentiles(name); ok {
+			snapshot[name] = p
+		}
+	}
+	return snapshot
+}
+
+// Reset drops all recorded job start times.
+func (a *Aggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nodes = make(map[string]*[windowSlots]histogram)
+}
+
+func (a *Aggregator) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(a.slotDuration())
+}
+
+func (a *Aggregator) slotDuration() time.Duration {
+	return max(a.window/windowSlots, 1)
+}
+
+// mergeLocked merges the histograms of the node within the window and drops
+// the node once all of them expired.
+func (a *Aggregator) mergeLocked(node string) (histogram, bool) {
+	slots, ok := a.nodes[node]
+	if !ok {
+		return histogram{}, false
+	}
+	oldest := a.epoch(time.Now()) - windowSlots + 1
+	merged := histogram{counts: make(map[int]uint64)}
+	for _, h := range slots {
+		if h.total == 0 || h.epoch < oldest {
+			continue
+		}
+		for b, n := range h.counts {
+			merged.counts[b] += n
+		}
+		if merged.total == 0 {
+			merged.min, merged.max = h.min, h.max
+		}
+		merged.total += h.total
+		merged.min = min(merged.min, h.min)
+		merged.max = max(merged.max, h.max)
+	}
+	if merged.total == 0 {
+		delete(a.nodes, node)
+		return histogram{}, false
+	}
+	return merged, true
+}
+
+// quantile estimates the q-quantile as the geometric middle of the bucket
+// holding it, bounded by the shortest and longest times observed.
+func (h histogram) quantile(q float64) time.Duration {
+	rank := uint64(math.Ceil(min(max(q, 0), 1) * float64(h.total)))
+	rank = max(rank, 1)
+	buckets := make([]int, 0, len(h.counts))
+	for b := range h.counts {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+
+	var seen uint64
+	for _, b := range buckets {
+		seen += h.counts[b]
+		if seen >= rank {
+			d := time.Duration(math.Exp2((float64(b)-0.5)/bucketsPerDoubling) * float64(time.Microsecond))
+			return min(max(d, h.min), h.max)
+		}
+	}
+	return h.max
+}
+
+// bucket returns the index of the bucket of d, whose upper bound is
+// 2^(index/bucketsPerDoubling) microseconds.
+func bucket(d time.Duration) int {
+	us := float64(d) / float64(time.Microsecond)
+	if us <= 1 {
+		return 0
+	}
+	return int(math.Ceil(math.Log2(us) * bucketsPerDoubling))
+}
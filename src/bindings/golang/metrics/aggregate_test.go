@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package metrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+)
+
+func within(got, want time.Duration) bool {
+	return got >= want*95/100 && got <= want*105/100
+}
+
+func TestAggregator(t *testing.T) {
+	a := metrics.NewAggregator()
+	events := make(chan metrics.Event, 202)
+	for i := 1; i <= 100; i++ {
+		events <- metrics.StartUnitJobMetrics{Node: "n1", Unit: "app.service", JobMeasuredTime: time.Duration(i) * time.Millisecond}
+		events <- metrics.AgentJobMetrics{Node: "n1", Unit: "app.service", Method: "StartUnit", SystemdJobTime: time.Hour}
+	}
+	events <- metrics.StartUnitJobMetrics{Node: "n2", JobMeasuredTime: 3 * time.Second}
+	close(events)
+	if err := a.Run(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	p, ok := a.Percentiles("n1")
+	if !ok {
+		t.Fatal("expected percentiles of n1")
+	}
+	if p.Count != 100 || p.Max != 100*time.Millisecond {
+		t.Errorf("expected 100 jobs of at most 100ms, got %+v", p)
+	}
+	if !within(p.P50, 50*time.Millisecond) || !within(p.P95, 95*time.Millisecond) || !within(p.P99, 99*time.Millisecond) {
+		t.Errorf("unexpected percentiles %+v", p)
+	}
+	if q, ok := a.Quantile("n1", 0.1); !ok || !within(q, 10*time.Millisecond) {
+		t.Errorf("expected a 0.1-quantile of about 10ms, got %s", q)
+	}
+
+	snapshot := a.Snapshot()
+	if len(snapshot) != 2 || snapshot["n2"].P50 != 3*time.Second || snapshot["n2"].Count != 1 {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+	if _, ok := a.Percentiles("n3"); ok {
+		t.Error("expected no percentiles of an unknown node")
+	}
+}
+
+func TestAggregatorWindow(t *testing.T) {
+	a := metrics.NewAggregator(metrics.WithWindow(100 * time.Millisecond))
+	a.ObserveDuration("n1", time.Second)
+	if p, ok := a.Percentiles("n1"); !ok || p.P99 != time.Second {
+		t.Fatalf("expected the recorded time, got %+v", p)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	a.ObserveDuration("n1", 10*time.Millisecond)
+	if p, ok := a.Percentiles("n1"); !ok || p.Count != 1 || p.Max != 10*time.Millisecond {
+		t.Errorf("expected the expired time to be dropped, got %+v", p)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, ok := a.Percentiles("n1"); ok {
+		t.Error("expected no percentiles once all times expired")
+	}
+	if len(a.Snapshot()) != 0 {
+		t.Error("expected an empty snapshot")
+	}
+}
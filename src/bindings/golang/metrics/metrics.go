@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package metrics provides Go bindings for the org.eclipse.bluechi.Metrics
+// interface, which delivers performance metrics collected by BlueChi.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// eventBufferSize is the capacity of the channel returned by Subscribe.
+const eventBufferSize = 64
+
+// Event is a metrics signal, either StartUnitJobMetrics or AgentJobMetrics.
+type Event interface {
+	// NodeName returns the name of the node the metrics were collected for.
+	NodeName() string
+}
+
+// StartUnitJobMetrics is emitted when a start operation processed by
+// BlueChi finishes.
+type StartUnitJobMetrics struct {
+	// Node is the name of the node the unit was started on.
+	Node string
+	// JobID is the id of the job linked to the metrics.
+	JobID string
+	// Unit is the name of the started unit.
+	Unit string
+	// JobMeasuredTime is the time it took BlueChi to start the unit.
+	JobMeasuredTime time.Duration
+	// UnitStartPropTime is the time it took systemd to start the unit.
+	UnitStartPropTime time.Duration
+}
+
+// AgentJobMetrics is emitted for all unit lifecycle operations processed by
+// BlueChi when these finish.
+type AgentJobMetrics struct {
+	// Node is the name of the node the operation was processed on.
+	Node string
+	// Unit is the name of the unit the operation was processed for.
+	Unit string
+	// Method is the lifecycle operation, e.g. StartUnit.
+	Method string
+	// SystemdJobTime is the time systemd took to process the operation.
+	SystemdJobTime time.Duration
+}
+
+func (e StartUnitJobMetrics) NodeName() string { return e.Node }
+func (e AgentJobMetrics) NodeName() string     { return e.Node }
+
+// Subscribe returns a channel on which the metrics signals emitted by the
+// controller reachable on conn are delivered. The subscription ends and the
+// channel is closed when ctx is done or conn is closed. On the connection of
+// a Manager with auto-reconnect, the subscription is kept across
+// reconnects.
+func Subscribe(ctx context.Context, conn common.Connection) (<-chan Event, error) {
+	match := bus.Match{
+		Rules: [][]dbus.MatchOption{matchOptions()},
+		Filter: func(sig *dbus.Signal) bool {
+			return sig.Path == common.METRICS_OBJECT_PATH
+		},
+	}
+	sub, err := bus.Subscribe(conn, match, eventBufferSize, bus.Block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to metrics: %w", err)
+	}
+
+	// metrics are not coalesced, each of them is a measurement of its own
+	events := bus.NewOutbox[Event](conn, eventBufferSize, nil)
+	bus.Go(conn, func() {
+		defer close(events.C)
+		defer sub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-conn.Context().Done():
+				return
+			case sig, ok := <-sub.Signals():
+				if !ok {
+					return
+				}
+				event, ok := decodeEvent(sig)
+				if !ok || events.Offer(event) {
+					continue
+				}
+				select {
+				case events.C <- event:
+				case <-ctx.Done():
+					return
+				case <-conn.Context().Done():
+					return
+				}
+			}
+		}
+	})
+	return events.C, nil
+}
+
+func matchOptions() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(common.METRICS_OBJECT_PATH),
+		dbus.WithMatchInterface(common.METRICS_INTERFACE),
+	}
+}
+
+func decodeEvent(sig *dbus.Signal) (Event, bool) {
+	switch sig.Name {
+	case common.SIGNAL_START_UNIT_JOB_METRICS:
+		var e StartUnitJobMetrics
+		var measured, prop uint64
+		if dbus.Store(sig.Body, &e.Node, &e.JobID, &e.Unit, &measured, &prop) != nil {
+			return nil, false
+		}
+		e.JobMeasuredTime = time.Duration(measured) * time.Microsecond
+		e.UnitStartPropTime = time.Duration(prop) * time.Microsecond
+		return e, true
+	case common.SIGNAL_AGENT_JOB_METRICS:
+		var e AgentJobMetrics
+		var systemd uint64
+		if dbus.Store(sig.Body, &e.Node, &e.Unit, &e.Method, &systemd) != nil {
+			return nil, false
+		}
+		e.SystemdJobTime = time.Duration(systemd) * time.Microsecond
+		return e, true
+	}
+	return nil, false
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package metrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+)
+
+func TestSubscribe(t *testing.T) {
+	address := testbus.Start(t)
+	controller := testbus.Connect(t, address)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := metrics.Subscribe(ctx, testbus.Connect(t, address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emit := func(path dbus.ObjectPath, name string, values ...interface{}) {
+		t.Helper()
+		if err := controller.Emit(path, name, values...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// signals of other objects and with a wrong body are skipped
+	emit(common.BC_OBJECT_PATH, common.SIGNAL_AGENT_JOB_METRICS, "node_a", "other.service", "StartUnit", uint64(1))
+	emit(common.METRICS_OBJECT_PATH, common.SIGNAL_START_UNIT_JOB_METRICS, "node_a")
+	emit(common.METRICS_OBJECT_PATH, common.SIGNAL_START_UNIT_JOB_METRICS, "node_a", "1", "nginx.service", uint64(1500), uint64(500))
+	emit(common.METRICS_OBJECT_PATH, common.SIGNAL_AGENT_JOB_METRICS, "node_b", "nginx.service", "StopUnit", uint64(2000))
+
+	want := []metrics.Event{
+		metrics.StartUnitJobMetrics{Node: "node_a", JobID: "1", Unit: "nginx.service", JobMeasuredTime: 1500 * time.Microsecond, UnitStartPropTime: 500 * time.Microsecond},
+		metrics.AgentJobMetrics{Node: "node_b", Unit: "nginx.service", Method: "StopUnit", SystemdJobTime: 2 * time.Millisecond},
+	}
+	for _, w := range want {
+		select {
+		case e := <-events:
+			if e != w {
+				t.Fatalf("got event %+v, want %+v", e, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %+v", w)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+}
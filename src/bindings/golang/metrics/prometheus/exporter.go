@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package prometheus exposes the performance metrics and node connection
+// states of a BlueChi cluster as Prometheus metrics.
+package prometheus
+
+import (
+	"context"
+	"net/http"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+const namespace = "bluechi"
+
+// Exporter collects the metrics signals and node connection events of a
+// BlueChi controller into Prometheus metrics.
+type Exporter struct {
+	registry *prom.Registry
+
+	startUnitJobTime  *prom.HistogramVec
+	unitStartPropTime *prom.HistogramVec
+	agentJobTime      *prom.HistogramVec
+	nodeOnline        *prom.GaugeVec
+}
+
+// NewExporter returns an exporter with its metrics registered in a new
+// registry, which is served by Handler.
+func NewExporter() *Exporter {
+	e := &Exporter{
+		registry: prom.NewRegistry(),
+		startUnitJobTime: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "start_unit_job_seconds",
+			Help:      "Time it took BlueChi to start a unit.",
+			Buckets:   prom.ExponentialBuckets(0.001, 2, 16),
+		}, []string{"node"}),
+		unitStartPropTime: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "unit_start_systemd_seconds",
+			Help:      "Time it took systemd to start a unit.",
+			Buckets:   prom.ExponentialBuckets(0.001, 2, 16),
+		}, []string{"node"}),
+		agentJobTime: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "agent_job_seconds",
+			Help:      "Time it took systemd to process a unit lifecycle operation.",
+			Buckets:   prom.ExponentialBuckets(0.001, 2, 16),
+		}, []string{"node", "method"}),
+		nodeOnline: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "node_online",
+			Help:      "Whether the node is connected to the controller (1) or not (0).",
+		}, []string{"node"}),
+	}
+	e.registry.MustRegister(e.startUnitJobTime, e.unitStartPropTime, e.agentJobTime, e.nodeOnline)
+	return e
+}
+
+// Registry returns the registry holding the metrics of the exporter, e.g.
+// to register additional collectors.
+func (e *Exporter) Registry() *prom.Registry {
+	return e.registry
+}
+
+// Handler returns an http.Handler serving the metrics of the exporter.
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Run enables metrics collection on the controller and records the metrics
+// signals and node connection events until ctx is done or m is closed. It
+// does not disable metrics collection when returning, since other clients
+// might rely on it.
+func (e *Exporter) Run(ctx context.Context, m manager.ManagerAPI) error {
+	nodeEvents, err := m.SubscribeNodeConnectionStateChanged(ctx)
+	if err != nil {
+		return err
+	}
+	metricEvents, err := m.SubscribeMetrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := m.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		e.setNodeStatus(n.Name, n.Status)
+	}
+
+	if err := m.EnableMetrics(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-nodeEvents:
+			if !ok {
+				return nil
+			}
+			e.setNodeStatus(event.Node, event.NewState)
+		case event, ok := <-metricEvents:
+			if !ok {
+				return nil
+			}
+			e.observe(event)
+		}
+	}
+}
+
+func (e *Exporter) setNodeStatus(name string, status node.NodeStatus) {
+	online := 0.0
+	if status.IsOnline() {
+		online = 1
+	}
+	e.nodeOnline.WithLabelValues(name).Set(online)
+}
+
+func (e *Exporter) observe(event metrics.Event) {
+	switch m := event.(type) {
+	case metrics.StartUnitJobMetrics:
+		e.startUnitJobTime.WithLabelValues(m.Node).Observe(m.JobMeasuredTime.Seconds())
+		e.unitStartPropTime.WithLabelValues(m.Node).Observe(m.UnitStartPropTime.Seconds())
+	case metrics.AgentJobMetrics:
+		e.agentJobTime.WithLabelValues(m.Node, m.Method).Observe(m.SystemdJobTime.Seconds())
+	}
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/metrics/prometheus"
+)
+
+// scrape returns the metrics served by srv in the text format.
+func scrape(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// awaitMetrics scrapes srv until it serves all samples, which the exporter
+// records asynchronously.
+func awaitMetrics(t *testing.T, srv *httptest.Server, samples ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := scrape(t, srv)
+		missing := ""
+		for _, sample := range samples {
+			if !strings.Contains(body, sample+"\n") {
+				missing = sample
+				break
+			}
+		}
+		if missing == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q, got:\n%s", missing, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExporter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := managertest.New()
+	f.AddNode("n1")
+	f.AddNode("n2")
+
+	e := prometheus.NewExporter()
+	srv := httptest.NewServer(e.Handler())
+	defer srv.Close()
+	done := make(chan error, 1)
+	go func() { done <- e.Run(ctx, f) }()
+
+	awaitMetrics(t, srv, `bluechi_node_online{node="n1"} 1`, `bluechi_node_online{node="n2"} 1`)
+	deadline := time.Now().Add(5 * time.Second)
+	for !f.MetricsEnabled() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the exporter to enable metrics")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f.SetNodeStatus("n2", managertest.NodeOffline)
+	awaitMetrics(t, srv, `bluechi_node_online{node="n1"} 1`, `bluechi_node_online{node="n2"} 0`)
+	f.SetNodeStatus("n2", managertest.NodeOnline)
+	awaitMetrics(t, srv, `bluechi_node_online{node="n2"} 1`)
+
+	f.EmitMetrics(metrics.StartUnitJobMetrics{
+		Node: "n1", JobID: "/org/eclipse/bluechi/job/1", Unit: "web.service",
+		JobMeasuredTime: 250 * time.Millisecond, UnitStartPropTime: 100 * time.Millisecond,
+	})
+	f.EmitMetrics(metrics.AgentJobMetrics{Node: "n2", Unit: "web.service", Method: "stop", SystemdJobTime: 50 * time.Millisecond})
+	awaitMetrics(t, srv,
+		`bluechi_start_unit_job_seconds_sum{node="n1"} 0.25`,
+		`bluechi_start_unit_job_seconds_count{node="n1"} 1`,
+		`bluechi_unit_start_systemd_seconds_sum{node="n1"} 0.1`,
+		`bluechi_unit_start_systemd_seconds_count{node="n1"} 1`,
+		`bluechi_agent_job_seconds_sum{method="stop",node="n2"} 0.05`,
+		`bluechi_agent_job_seconds_count{method="stop",node="n2"} 1`,
+	)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected Run to return nil once ctx is done, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Run to return")
+	}
+	if !f.MetricsEnabled() {
+		t.Error("expected metrics to stay enabled after Run returned")
+	}
+}
+
+func TestExporterRunFails(t *testing.T) {
+	f := managertest.New()
+	boom := errors.New("boom")
+	f.FailCall("EnableMetrics", boom)
+
+	if err := prometheus.NewExporter().Run(context.Background(), f); !errors.Is(err, boom) {
+		t.Fatalf("expected the error of EnableMetrics, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"maps"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// Event is a change of a unit on a node delivered by a monitor. It is one
+// of UnitNew, UnitRemoved, UnitStateChanged or UnitPropertiesChanged, or
+// PeerRemoved for monitors consumed as peer.
+type Event interface {
+	// NodeName returns the name of the node the event originated from.
+	NodeName() string
+	// UnitName returns the name of the unit the event refers to.
+	UnitName() string
+}
+
+// UnitNew is emitted when a new unit is loaded by systemd, or if BlueChi
+// learns of an already loaded unit.
+type UnitNew struct {
+	Node   string
+	Unit   string
+	Reason string
+}
+
+// UnitRemoved is emitted when a unit is unloaded by systemd, or when the
+// agent of the node disconnects.
+type UnitRemoved struct {
+	Node   string
+	Unit   string
+	Reason string
+}
+
+// UnitStateChanged is emitted when the active state or sub state of a unit
+// changes.
+type UnitStateChanged struct {
+	Node        string
+	Unit        string
+	ActiveState node.ActiveState
+	SubState    node.SubState
+	Reason      string
+}
+
+// UnitPropertiesChanged is emitted when properties of a unit change.
+type UnitPropertiesChanged struct {
+	Node       string
+	Unit       string
+	Interface  string
+	Properties map[string]dbus.Variant
+}
+
+// PeerRemoved is emitted to a peer of a monitor when it is removed from the
+// monitor, e.g. because the monitor has been closed by its owner. It refers
+// to no node and unit.
+type PeerRemoved struct {
+	Reason string
+}
+
+func (e UnitNew) NodeName() string               { return e.Node }
+func (e UnitNew) UnitName() string               { return e.Unit }
+func (e UnitRemoved) NodeName() string           { return e.Node }
+func (e UnitRemoved) UnitName() string           { return e.Unit }
+func (e UnitStateChanged) NodeName() string      { return e.Node }
+func (e UnitStateChanged) UnitName() string      { return e.Unit }
+func (e UnitPropertiesChanged) NodeName() string { return e.Node }
+func (e UnitPropertiesChanged) UnitName() string { return e.Unit }
+func (e PeerRemoved) NodeName() string           { return "" }
+func (e PeerRemoved) UnitName() string           { return "" }
+
+func decodeEvent(sig *dbus.Signal) (Event, bool) {
+	switch sig.Name {
+	case common.SIGNAL_UNIT_NEW:
+		var e UnitNew
+		if dbus.Store(sig.Body, &e.Node, &e.Unit, &e.Reason) != nil {
+			return nil, false
+		}
+		return e, true
+	case common.SIGNAL_UNIT_REMOVED:
+		var e UnitRemoved
+		if dbus.Store(sig.Body, &e.Node, &e.Unit, &e.Reason) != nil {
+			return nil, false
+		}
+		return e, true
+	case common.SIGNAL_UNIT_STATE_CHANGED:
+		var e UnitStateChanged
+		if dbus.Store(sig.Body, &e.Node, &e.Unit, &e.ActiveState, &e.SubState, &e.Reason) != nil {
+			return nil, false
+		}
+		return e, true
+	case common.SIGNAL_UNIT_PROPERTIES_CHANGED:
+		var e UnitPropertiesChanged
+		if dbus.Store(sig.Body, &e.Node, &e.Unit, &e.Interface, &e.Properties) != nil {
+			return nil, false
+		}
+		return e, true
+	case common.SIGNAL_PEER_REMOVED:
+		var e PeerRemoved
+		if dbus.Store(sig.Body, &e.Reason) != nil {
+			return nil, false
+		}
+		return e, true
+	}
+	return nil, false
+}
+
+// coalesce merges next into prev if it supersedes it, used by the overflow
+// policy manager.OverflowCoalesce. A state change of a unit supersedes the
+// previous one, properties changes of a unit are merged per interface.
+func coalesce(prev, next Event) (Event, bool) {
+	if prev.NodeName() != next.NodeName() || prev.UnitName() != next.UnitName() {
+		return nil, false
+	}
+	switch n := next.(type) {
+	case UnitStateChanged:
+		if _, ok := prev.(UnitStateChanged); ok {
+			return n, true
+		}
+	case UnitPropertiesChanged:
+		p, ok := prev.(UnitPropertiesChanged)
+		if !ok || p.Interface != n.Interface {
+			return nil, false
+		}
+		props := make(map[string]dbus.Variant, len(p.Properties)+len(n.Properties))
+		maps.Copy(props, p.Properties)
+		maps.Copy(props, n.Properties)
+		n.Properties = props
+		return n, true
+	}
+	return nil, false
+}
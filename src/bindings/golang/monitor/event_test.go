@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// stateChangedSignal is a UnitStateChanged signal as received from the
+// controller.
+var stateChangedSignal = &dbus.Signal{
+	Name: common.SIGNAL_UNIT_STATE_CHANGED,
+	Body: []interface{}{"node_a", "nginx.service", "active", "running", "real"},
+}
+
+// decodeEventAllocs is the allocation budget of decoding a UnitStateChanged
+// signal: the arguments of dbus.Store and boxing the event into an Event.
+const decodeEventAllocs = 2
+
+func BenchmarkDecodeEvent(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, ok := decodeEvent(stateChangedSignal); !ok {
+			b.Fatal("failed to decode the event")
+		}
+	}
+}
+
+// TestDecodeEventAllocs guards the allocations of decoding the events of a
+// monitor, which are decoded for each signal received.
+func TestDecodeEventAllocs(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		decodeEvent(stateChangedSignal)
+	})
+	if allocs > decodeEventAllocs {
+		t.Errorf("decoding an event allocates %v times, the budget is %d", allocs, decodeEventAllocs)
+	}
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"context"
+	"slices"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// Filter selects the events delivered by Filtered. from is the active state
+// of the unit before the event as last seen by Filtered, empty if it has
+// not seen one yet. Filters are built with ByNode, ByUnitGlob and
+// ByStateTransition and combined with And and Or.
+type Filter func(e Event, from node.ActiveState) bool
+
+// ByNode selects the events of the named nodes.
+func ByNode(names ...string) Filter {
+	return func(e Event, _ node.ActiveState) bool {
+		return slices.Contains(names, e.NodeName())
+	}
+}
+
+// ByUnitGlob selects the events of units matching any of the patterns, see
+// Match, e.g. "nginx*.service".
+func ByUnitGlob(patterns ...string) Filter {
+	return func(e Event, _ node.ActiveState) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			return Match(pattern, e.UnitName())
+		})
+	}
+}
+
+// ByStateTransition selects the UnitStateChanged events changing the active
+// state of the unit from from to to, e.g. from node.ActiveStateActive to
+// node.ActiveStateFailed. An empty state matches any, but the active state
+// has to change: events only changing the sub state are not selected, nor
+// are the first events of units whose state was not seen before unless
+// from is empty.
+func ByStateTransition(from node.ActiveState, to node.ActiveState) Filter {
+	return func(e Event, prev node.ActiveState) bool {
+		changed, ok := e.(UnitStateChanged)
+		if !ok || changed.ActiveState == prev {
+			return false
+		}
+		return (from == "" || from == prev) && (to == "" || to == changed.ActiveState)
+	}
+}
+
+// And selects the events selected by all of the filters.
+func And(filters ...Filter) Filter {
+	return func(e Event, from node.ActiveState) bool {
+		for _, f := range filters {
+			if !f(e, from) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects the events selected by any of the filters.
+func Or(filters ...Filter) Filter {
+	return func(e Event, from node.ActiveState) bool {
+		for _, f := range filters {
+			if f(e, from) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type unitKey struct {
+	node, unit string
+}
+
+// Filtered delivers the events of the channel selected by f, e.g. of
+// Monitor.Events, on the returned channel, so that consumers only wake up
+// for the events they care about. It tracks the active states of the units
+// for ByStateTransition. The returned channel is closed when events is
+// closed or ctx is done, sending waits while the consumer lags behind.
+func Filtered(ctx context.Context, events <-chan Event, f Filter) <-chan Event {
+	out := make(chan Event, eventBufferSize)
+	go func() {
+		defer close(out)
+		states := make(map[unitKey]node.ActiveState)
+		for {
+			var e Event
+			var ok bool
+			select {
+			case e, ok = <-events:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+
+			key := unitKey{e.NodeName(), e.UnitName()}
+			from := states[key]
+			switch e := e.(type) {
+			case UnitStateChanged:
+				states[key] = e.ActiveState
+			case UnitRemoved:
+				delete(states, key)
+			}
+			if !f(e, from) {
+				continue
+			}
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+func stateChanged(nodeName string, unit string, state node.ActiveState) UnitStateChanged {
+	return UnitStateChanged{Node: nodeName, Unit: unit, ActiveState: state}
+}
+
+func TestFiltered(t *testing.T) {
+	events := make(chan Event, 16)
+	for _, e := range []Event{
+		stateChanged("n1", "web.service", node.ActiveStateActive),
+		// only the sub state changes
+		stateChanged("n1", "web.service", node.ActiveStateActive),
+		stateChanged("n1", "web.service", node.ActiveStateFailed),
+		stateChanged("n2", "web.service", node.ActiveStateFailed),
+		stateChanged("n1", "db.service", node.ActiveStateFailed),
+		UnitRemoved{Node: "n1", Unit: "web.service"},
+		stateChanged("n1", "web.service", node.ActiveStateFailed),
+		UnitNew{Node: "n3", Unit: "web.service"},
+		PeerRemoved{Reason: "closed"},
+	} {
+		events <- e
+	}
+	close(events)
+
+	f := Or(
+		And(ByNode("n1", "n2"), ByUnitGlob("web*"), ByStateTransition(node.ActiveStateActive, node.ActiveStateFailed)),
+		And(ByNode("n3"), ByUnitGlob("*")),
+	)
+	var got []Event
+	for e := range Filtered(context.Background(), events, f) {
+		got = append(got, e)
+	}
+	want := []Event{
+		stateChanged("n1", "web.service", node.ActiveStateFailed),
+		UnitNew{Node: "n3", Unit: "web.service"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestByStateTransition(t *testing.T) {
+	tests := []struct {
+		from, to node.ActiveState
+		prev     node.ActiveState
+		event    Event
+		want     bool
+	}{
+		{"", node.ActiveStateFailed, "", stateChanged("n", "u", node.ActiveStateFailed), true},
+		{"", node.ActiveStateFailed, node.ActiveStateFailed, stateChanged("n", "u", node.ActiveStateFailed), false},
+		{node.ActiveStateActive, "", node.ActiveStateActive, stateChanged("n", "u", node.ActiveStateDeactivating), true},
+		{node.ActiveStateActive, "", "", stateChanged("n", "u", node.ActiveStateInactive), false},
+		{"", "", "", UnitNew{Node: "n", Unit: "u"}, false},
+	}
+	for _, tt := range tests {
+		if got := ByStateTransition(tt.from, tt.to)(tt.event, tt.prev); got != tt.want {
+			t.Errorf("ByStateTransition(%q, %q) of %v after %q = %v, expected %v", tt.from, tt.to, tt.event, tt.prev, got, tt.want)
+		}
+	}
+}
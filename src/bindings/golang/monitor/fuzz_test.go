@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+)
+
+// FuzzDecodeEvent feeds the decoding of the signals of a monitor with
+// arbitrary bodies, which must be dropped rather than panic.
+func FuzzDecodeEvent(f *testing.F) {
+	testbus.AddReply(f, "node_a", "a.service", "real")
+	testbus.AddReply(f, "node_a", "a.service", "active", "running", "real")
+	testbus.AddReply(f, "node_a", "a.service", "org.freedesktop.systemd1.Unit", map[string]dbus.Variant{
+		"ActiveState": dbus.MakeVariant("active"),
+	})
+	testbus.AddReply(f, "node_a", "a.service", "org.freedesktop.systemd1.Unit", map[string]string{"ActiveState": "active"})
+	testbus.AddReply(f, uint32(1))
+
+	names := []string{
+		common.SIGNAL_UNIT_NEW,
+		common.SIGNAL_UNIT_REMOVED,
+		common.SIGNAL_UNIT_STATE_CHANGED,
+		common.SIGNAL_UNIT_PROPERTIES_CHANGED,
+		common.SIGNAL_PEER_REMOVED,
+	}
+	f.Fuzz(func(t *testing.T, sig string, data []byte) {
+		body, ok := testbus.DecodeReply(sig, data)
+		if !ok {
+			return
+		}
+		for _, name := range names {
+			e, ok := decodeEvent(&dbus.Signal{Name: name, Body: body})
+			if ok && e == nil {
+				t.Fatalf("decoded nil event from %s", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,520 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package monitor provides Go bindings for the org.eclipse.bluechi.Monitor
+// interface, which delivers changes of systemd units on managed nodes.
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// eventBufferSize is the capacity of the channel returned by Events.
+const eventBufferSize = 64
+
+// Monitor is a proxy for a monitor object created on the BlueChi controller.
+type Monitor struct {
+	conn common.Connection
+
+	sub       *bus.Subscription
+	events    *bus.Outbox[Event]
+	overflows atomic.Uint64
+	done      chan struct{}
+	closeOnce sync.Once
+	unhook    func()
+
+	mu       sync.Mutex
+	path     dbus.ObjectPath
+	obj      dbus.BusObject
+	attached bool
+	subs     map[uint32]subscription
+	ids      map[uint32]uint32
+	peers    map[uint32]string
+	peerIDs  map[uint32]uint32
+	nextID   uint32
+	// replay retains the events once EnableReplay was called, stopped is
+	// set once the delivery ended.
+	replay  *replay
+	stopped bool
+}
+
+// subscription is a subscription of the monitor, kept to subscribe again
+// when the monitor is recreated after a reconnect and to filter the events
+// of glob patterns. The events of watched subscriptions are delivered on
+// the channel of their watch instead of Events.
+type subscription struct {
+	node  string
+	units []string
+	list  bool
+	watch *watch
+}
+
+// New returns a proxy for the monitor object at path and starts delivering
+// its signals on the Events channel. Use manager.Manager.CreateMonitor to
+// create a monitor on the controller. On the connection of a manager
+// Manager with auto-reconnect, the monitor and its subscriptions are
+// recreated on the controller after a reconnect.
+func New(conn common.Connection, path dbus.ObjectPath) (*Monitor, error) {
+	return newMonitor(conn, path, false)
+}
+
+// Attach returns a proxy for a monitor created by another client, which
+// added the connection as peer with AddPeer, and starts delivering the
+// events of the monitor on the Events channel. A PeerRemoved event is the
+// last event delivered. The monitor is not recreated after a reconnect,
+// that is up to its owner, and Close only stops the delivery.
+func Attach(conn common.Connection, path dbus.ObjectPath) (*Monitor, error) {
+	return newMonitor(conn, path, true)
+}
+
+func newMonitor(conn common.Connection, path dbus.ObjectPath, attached bool) (*Monitor, error) {
+	m := &Monitor{
+		conn:     conn,
+		path:     path,
+		obj:      bus.Object(conn, common.BC_DBUS_NAME, path),
+		attached: attached,
+		done:     make(chan struct{}),
+		subs:     make(map[uint32]subscription),
+		ids:      make(map[uint32]uint32),
+		peers:    make(map[uint32]string),
+		peerIDs:  make(map[uint32]uint32),
+	}
+
+	match := bus.Match{
+		Rules: [][]dbus.MatchOption{{
+			dbus.WithMatchPathNamespace(common.MONITOR_OBJECT_PATH_PREFIX),
+			dbus.WithMatchInterface(common.MONITOR_INTERFACE),
+		}},
+		// the path changes when the monitor is recreated
+		Filter: func(sig *dbus.Signal) bool {
+			return sig.Path == m.ObjectPath()
+		},
+	}
+	sub, err := bus.Subscribe(conn, match, eventBufferSize, bus.Block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to monitor %s: %w", path, err)
+	}
+
+	m.sub = sub
+	m.events = bus.NewOutbox(conn, eventBufferSize, coalesce, &m.overflows)
+	m.unhook = func() {}
+	if !attached {
+		m.unhook = bus.OnRestore(conn, m.restore)
+	}
+	bus.Go(conn, m.dispatch)
+	return m, nil
+}
+
+// ObjectPath returns the path of the monitor object on the controller.
+func (m *Monitor) ObjectPath() dbus.ObjectPath {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.path
+}
+
+// Introspect returns the introspection data of the monitor object on the
+// controller.
+func (m *Monitor) Introspect(ctx context.Context) (common.Introspection, error) {
+	m.mu.Lock()
+	obj := m.obj
+	m.mu.Unlock()
+	return common.Introspect(ctx, obj)
+}
+
+// Events returns the channel on which the unit events of all subscriptions
+// of the monitor are delivered, until EnableReplay is called. The channel
+// is closed by Close or when the connection is closed.
+func (m *Monitor) Events() <-chan Event {
+	return m.events.C
+}
+
+// OverflowCount returns the number of events lost on Events and the
+// channels of Watch because the consumer lagged behind, see
+// manager.WithEventOverflow. It stays zero with the default policy, which
+// waits for the consumer.
+func (m *Monitor) OverflowCount() uint64 {
+	return m.overflows.Load()
+}
+
+// Subscribe subscribes the monitor to changes of a unit on a node and
+// returns the id of the subscription. Both node and unit can be the
+// wildcard "*" to match all nodes or all units, or a glob pattern such as
+// "foo@*.service", see Match. Patterns are subscribed on the controller
+// with the wildcard and the events not matching them are dropped by the
+// monitor.
+func (m *Monitor) Subscribe(ctx context.Context, node string, unit string) (uint32, error) {
+	id, err := m.subscribe(ctx, subscription{node: node, units: []string{unit}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to subscribe to unit %s on node %s: %w", unit, node, err)
+	}
+	return id, nil
+}
+
+// SubscribeList subscribes the monitor to changes of a list of units on a
+// node and returns the id of the subscription. The node and the units can
+// be patterns as for Subscribe.
+func (m *Monitor) SubscribeList(ctx context.Context, node string, units []string) (uint32, error) {
+	id, err := m.subscribe(ctx, subscription{node: node, units: units, list: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to subscribe to units %v on node %s: %w", units, node, err)
+	}
+	return id, nil
+}
+
+// Watch subscribes the monitor to changes of a unit on a node like
+// Subscribe, but delivers the events of the subscription on the returned
+// channel instead of Events. The channel is closed by Unsubscribe with the
+// returned id, when ctx is done or when the monitor is closed. The channels
+// of all watches and Events are served by a single goroutine, so events
+// are delivered in order and a consumer not keeping up delays all others.
+func (m *Monitor) Watch(ctx context.Context, node string, unit string) (uint32, <-chan Event, error) {
+	w := newWatch(m.conn, &m.overflows)
+	id, err := m.subscribe(ctx, subscription{node: node, units: []string{unit}, watch: w})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to subscribe to unit %s on node %s: %w", unit, node, err)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := m.Unsubscribe(context.Background(), id); err != nil && !errors.Is(err, common.ErrNoSuchSubscription) {
+				bus.Logger(m.conn).Debug("failed to unsubscribe watch", "monitor", m.ObjectPath(), "error", err)
+			}
+		case <-w.done:
+		}
+	}()
+	return id, w.events.C, nil
+}
+
+// Unsubscribe cancels the subscription with the given id.
+func (m *Monitor) Unsubscribe(ctx context.Context, id uint32) error {
+	m.mu.Lock()
+	obj := m.obj
+	s := m.subs[id]
+	remote, ok := m.ids[id]
+	delete(m.subs, id)
+	delete(m.ids, id)
+	m.mu.Unlock()
+
+	if s.watch != nil {
+		s.watch.close()
+	}
+	if !ok {
+		return fmt.Errorf("failed to unsubscribe %d: %w", id, common.ErrNoSuchSubscription)
+	}
+	err := bus.Exec(ctx, obj, common.METHOD_MONITOR_UNSUBSCRIBE, remote)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe %d: %w", id, err)
+	}
+	return nil
+}
+
+// AddPeer adds the client owning the unique bus name, e.g. a sidecar, as
+// peer of the monitor and returns the id of the peer. Peers receive all
+// events of the subscriptions of the monitor and consume them with Attach.
+// With auto-reconnect, the peers are added again when the monitor is
+// recreated.
+func (m *Monitor) AddPeer(ctx context.Context, name string) (uint32, error) {
+	m.mu.Lock()
+	obj := m.obj
+	m.mu.Unlock()
+
+	remote, err := bus.Call[uint32](ctx, obj, common.METHOD_MONITOR_ADD_PEER, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add peer %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	m.peers[m.nextID] = name
+	m.peerIDs[m.nextID] = remote
+	return m.nextID, nil
+}
+
+// RemovePeer removes the peer with the given id, which is sent a
+// PeerRemoved event carrying reason.
+func (m *Monitor) RemovePeer(ctx context.Context, id uint32, reason string) error {
+	m.mu.Lock()
+	obj := m.obj
+	remote, ok := m.peerIDs[id]
+	delete(m.peers, id)
+	delete(m.peerIDs, id)
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("failed to remove peer %d: %w", id, common.ErrNoSuchPeer)
+	}
+	err := bus.Exec(ctx, obj, common.METHOD_MONITOR_REMOVE_PEER, remote, reason)
+	if err != nil {
+		return fmt.Errorf("failed to remove peer %d: %w", id, err)
+	}
+	return nil
+}
+
+// Close closes the monitor on the controller, stops the delivery of signals
+// and closes the Events channel. For an attached monitor, only the delivery
+// is stopped.
+func (m *Monitor) Close(ctx context.Context) error {
+	m.mu.Lock()
+	obj, path, attached := m.obj, m.path, m.attached
+	m.mu.Unlock()
+
+	if attached {
+		m.stop()
+		return nil
+	}
+
+	err := bus.Exec(ctx, obj, common.METHOD_MONITOR_CLOSE)
+	m.stop()
+	if err != nil {
+		return fmt.Errorf("failed to close monitor %s: %w", path, err)
+	}
+	return nil
+}
+
+// subscribe issues the subscription on the controller and returns the id
+// the monitor hands out for it, which stays valid when the monitor is
+// recreated. The subscription is known to the monitor before it is issued,
+// so that the events the controller sends right away are not dropped.
+func (m *Monitor) subscribe(ctx context.Context, s subscription) (uint32, error) {
+	m.mu.Lock()
+	obj := m.obj
+	m.nextID++
+	id := m.nextID
+	m.subs[id] = s
+	m.mu.Unlock()
+
+	remote, err := subscribeOn(ctx, obj, s)
+	if err != nil {
+		m.mu.Lock()
+		delete(m.subs, id)
+		m.mu.Unlock()
+		if s.watch != nil {
+			s.watch.close()
+		}
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.subs[id]; !ok {
+		// the monitor has been closed meanwhile
+		return 0, fmt.Errorf("monitor %s has been closed", m.path)
+	}
+	m.ids[id] = remote
+	return id, nil
+}
+
+func subscribeOn(ctx context.Context, obj dbus.BusObject, s subscription) (uint32, error) {
+	node, units, list := s.remote()
+	if list {
+		return bus.Call[uint32](ctx, obj, common.METHOD_MONITOR_SUBSCRIBE_LIST, node, units)
+	}
+	return bus.Call[uint32](ctx, obj, common.METHOD_MONITOR_SUBSCRIBE, node, units[0])
+}
+
+// restore creates the monitor again on the controller and renews all of its
+// subscriptions. The monitor is dropped by the controller when the
+// connection is lost or the controller restarts.
+func (m *Monitor) restore(ctx context.Context) {
+	log := bus.Logger(m.conn)
+	controller := bus.Object(m.conn, common.BC_DBUS_NAME, common.BC_OBJECT_PATH)
+	path, err := bus.Call[dbus.ObjectPath](ctx, controller, common.METHOD_CREATE_MONITOR)
+	if err != nil {
+		log.Error("failed to recreate monitor", "monitor", m.ObjectPath(), "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	select {
+	case <-m.done:
+		// closed in the meantime
+		m.mu.Unlock()
+		_ = bus.Exec(ctx, bus.Object(m.conn, common.BC_DBUS_NAME, path), common.METHOD_MONITOR_CLOSE)
+		return
+	default:
+	}
+	m.path = path
+	m.obj = bus.Object(m.conn, common.BC_DBUS_NAME, path)
+	obj := m.obj
+	subs := make(map[uint32]subscription, len(m.subs))
+	for id, s := range m.subs {
+		subs[id] = s
+	}
+	peers := make(map[uint32]string, len(m.peers))
+	for id, name := range m.peers {
+		peers[id] = name
+	}
+	m.mu.Unlock()
+
+	for id, s := range subs {
+		remote, err := subscribeOn(ctx, obj, s)
+		if err != nil {
+			log.Error("failed to renew subscription", "monitor", path, "node", s.node, "units", s.units, "error", err)
+			continue
+		}
+		m.mu.Lock()
+		if _, ok := m.subs[id]; ok {
+			m.ids[id] = remote
+		}
+		m.mu.Unlock()
+	}
+	for id, name := range peers {
+		remote, err := bus.Call[uint32](ctx, obj, common.METHOD_MONITOR_ADD_PEER, name)
+		if err != nil {
+			log.Error("failed to add peer again", "monitor", path, "peer", name, "error", err)
+			continue
+		}
+		m.mu.Lock()
+		if _, ok := m.peers[id]; ok {
+			m.peerIDs[id] = remote
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (m *Monitor) stop() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+		m.unhook()
+		m.sub.Close()
+	})
+}
+
+// targets returns whether event is delivered on Events and the watches it
+// is delivered to. Events of attached monitors and events not referring to
+// a unit are always delivered on Events, the subscriptions of attached
+// monitors are not known.
+func (m *Monitor) targets(event Event) (bool, []*watch) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := event.(PeerRemoved); ok || m.attached {
+		return true, nil
+	}
+	toEvents := false
+	var watches []*watch
+	for _, s := range m.subs {
+		if !s.matches(event.NodeName(), event.UnitName()) {
+			continue
+		}
+		if s.watch == nil {
+			toEvents = true
+		} else {
+			watches = append(watches, s.watch)
+		}
+	}
+	return toEvents, watches
+}
+
+// closeWatches closes the channels of all watches, once the delivery of
+// events has ended.
+func (m *Monitor) closeWatches() {
+	m.mu.Lock()
+	var watches []*watch
+	for id, s := range m.subs {
+		if s.watch != nil {
+			watches = append(watches, s.watch)
+			delete(m.subs, id)
+			delete(m.ids, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, w := range watches {
+		w.close()
+	}
+}
+
+func (m *Monitor) isAttached() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.attached
+}
+
+// deliver sends event on Events or, with replay enabled, retains it and
+// sends its record. It returns false if the monitor was closed meanwhile.
+func (m *Monitor) deliver(event Event) bool {
+	m.mu.Lock()
+	r := m.replay
+	var rec Record
+	if r != nil {
+		rec = r.add(event)
+	}
+	m.mu.Unlock()
+
+	if r != nil {
+		if !r.out.Offer(rec) {
+			select {
+			case r.out.C <- rec:
+			case <-m.done:
+				return false
+			case <-m.conn.Context().Done():
+				return false
+			}
+		}
+		return true
+	}
+	if !m.events.Offer(event) {
+		select {
+		case m.events.C <- event:
+		case <-m.done:
+			return false
+		case <-m.conn.Context().Done():
+			return false
+		}
+	}
+	return true
+}
+
+// endDelivery closes the channel of the records once the delivery ended.
+func (m *Monitor) endDelivery() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+	if m.replay != nil {
+		close(m.replay.out.C)
+	}
+}
+
+func (m *Monitor) dispatch() {
+	defer close(m.events.C)
+	defer m.endDelivery()
+	defer m.closeWatches()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.conn.Context().Done():
+			return
+		case sig, ok := <-m.sub.Signals():
+			if !ok {
+				return
+			}
+			event, ok := decodeEvent(sig)
+			if !ok {
+				continue
+			}
+			toEvents, watches := m.targets(event)
+			for _, w := range watches {
+				w.send(event, m.done, m.conn.Context().Done())
+			}
+			if toEvents && !m.deliver(event) {
+				return
+			}
+			if _, ok := event.(PeerRemoved); ok && m.isAttached() {
+				// the controller sends no further events to the peer
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"strings"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// IsPattern reports whether name is a glob pattern, i.e. contains "*"
+// matching any sequence of characters or "?" matching a single character.
+// The wildcard "*" is a pattern matching all names.
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "*?")
+}
+
+// Match reports whether name matches pattern, which is either a plain name
+// or a glob pattern as described for IsPattern, e.g. "foo@*.service". The
+// syntax is the one of the glob patterns of BlueChi.
+func Match(pattern string, name string) bool {
+	if !IsPattern(pattern) {
+		return pattern == name
+	}
+	return matchGlob(name, pattern)
+}
+
+func matchGlob(str string, glob string) bool {
+	if glob == "" {
+		return str == ""
+	}
+	if glob[0] == '*' {
+		return matchGlob(str, glob[1:]) || (str != "" && matchGlob(str[1:], glob))
+	}
+	if str == "" {
+		return false
+	}
+	return (glob[0] == '?' || glob[0] == str[0]) && matchGlob(str[1:], glob[1:])
+}
+
+// remote returns the subscription issued on the controller, which only
+// supports the wildcard. Glob patterns are subscribed with the wildcard and
+// filtered by the monitor.
+func (s subscription) remote() (node string, units []string, list bool) {
+	node = s.node
+	if IsPattern(node) {
+		node = common.SYMBOL_WILDCARD
+	}
+	for _, u := range s.units {
+		if IsPattern(u) {
+			return node, []string{common.SYMBOL_WILDCARD}, false
+		}
+	}
+	return node, s.units, s.list
+}
+
+// matches reports whether an event of unit on node belongs to the
+// subscription.
+func (s subscription) matches(node string, unit string) bool {
+	if !Match(s.node, node) {
+		return false
+	}
+	for _, u := range s.units {
+		if Match(u, unit) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"foo.service", "foo.service", true},
+		{"foo.service", "bar.service", false},
+		{"*", "foo.service", true},
+		{"*", "", true},
+		{"foo@*.service", "foo@1.service", true},
+		{"foo@*.service", "foo@.service", true},
+		{"foo@*.service", "foo@1.socket", false},
+		{"foo@?.service", "foo@1.service", true},
+		{"foo@?.service", "foo@12.service", false},
+		{"*.service", "a.b.service", true},
+		{"node-*", "node-foo", true},
+		{"node-*", "other", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionRemote(t *testing.T) {
+	tests := []struct {
+		sub   subscription
+		node  string
+		units []string
+		list  bool
+	}{
+		{subscription{node: "n1", units: []string{"a.service"}}, "n1", []string{"a.service"}, false},
+		{subscription{node: "*", units: []string{"*"}}, "*", []string{"*"}, false},
+		{subscription{node: "node-*", units: []string{"a.service"}}, "*", []string{"a.service"}, false},
+		{subscription{node: "n1", units: []string{"a.service", "b.service"}, list: true}, "n1", []string{"a.service", "b.service"}, true},
+		{subscription{node: "n1", units: []string{"a.service", "foo@*.service"}, list: true}, "n1", []string{"*"}, false},
+	}
+	for _, tt := range tests {
+		node, units, list := tt.sub.remote()
+		if node != tt.node || !reflect.DeepEqual(units, tt.units) || list != tt.list {
+			t.Errorf("remote of %+v = %s %v %v, expected %s %v %v", tt.sub, node, units, list, tt.node, tt.units, tt.list)
+		}
+	}
+}
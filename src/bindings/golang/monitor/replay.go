@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// DefaultReplaySize is the number of events retained by EnableReplay for
+// sizes below 1.
+const DefaultReplaySize = 1024
+
+// ErrReplayGap is returned by ReplaySince if events after the sequence
+// number are no longer retained, the consumer has to list the units again
+// then.
+var ErrReplayGap = errors.New("events to replay are no longer retained")
+
+// Record is an event of a monitor with replay enabled, numbered by its
+// position in the stream of the monitor starting at 1.
+type Record struct {
+	Seq   uint64
+	Event Event
+}
+
+// replay is the ring buffer of the records of a monitor.
+type replay struct {
+	out     *bus.Outbox[Record]
+	records []Record
+	// start is the index of the oldest record in records, n the number of
+	// records retained
+	start, n int
+	seq      uint64
+}
+
+func (r *replay) add(event Event) Record {
+	r.seq++
+	rec := Record{Seq: r.seq, Event: event}
+	if r.n < len(r.records) {
+		r.records[(r.start+r.n)%len(r.records)] = rec
+		r.n++
+	} else {
+		r.records[r.start] = rec
+		r.start = (r.start + 1) % len(r.records)
+	}
+	return rec
+}
+
+func (r *replay) since(seq uint64) ([]Record, error) {
+	if seq >= r.seq {
+		return nil, nil
+	}
+	oldest := r.seq - uint64(r.n) + 1
+	if seq+1 < oldest {
+		return nil, fmt.Errorf("failed to replay events since %d, the oldest retained is %d: %w", seq, oldest, ErrReplayGap)
+	}
+	skip := int(seq + 1 - oldest)
+	records := make([]Record, 0, r.n-skip)
+	for i := skip; i < r.n; i++ {
+		records = append(records, r.records[(r.start+i)%len(r.records)])
+	}
+	return records, nil
+}
+
+// coalesceRecords merges the events of two records like coalesce, the
+// merged record takes the number of the later one.
+func coalesceRecords(prev, next Record) (Record, bool) {
+	event, ok := coalesce(prev.Event, next.Event)
+	if !ok {
+		return Record{}, false
+	}
+	return Record{Seq: next.Seq, Event: event}, true
+}
+
+// EnableReplay makes the monitor retain the last size events it would
+// deliver on Events in a ring buffer, numbered from 1 on. The events are
+// delivered as records on the returned channel instead of Events from then
+// on. A consumer remembers the number of the last record it processed and
+// catches up with ReplaySince after it stopped reading for a while, e.g.
+// during a leader election or when records were dropped by the overflow
+// policy, without listing all units again. The events of Watch are not
+// retained. Calling EnableReplay again returns the same channel.
+func (m *Monitor) EnableReplay(size int) <-chan Record {
+	if size < 1 {
+		size = DefaultReplaySize
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.replay == nil {
+		m.replay = &replay{
+			out:     bus.NewOutbox(m.conn, eventBufferSize, coalesceRecords, &m.overflows),
+			records: make([]Record, size),
+		}
+		if m.stopped {
+			close(m.replay.out.C)
+		}
+	}
+	return m.replay.out.C
+}
+
+// ReplaySince returns the retained records after the one numbered seq in
+// order, none if seq is the last one. It fails with ErrReplayGap if some of
+// them are no longer retained.
+func (m *Monitor) ReplaySince(seq uint64) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.replay == nil {
+		return nil, fmt.Errorf("replay is not enabled on monitor %s", m.path)
+	}
+	return m.replay.since(seq)
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package monitor
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// watch is the channel of a subscription created by Watch. Sending and
+// closing are synchronized so that the channel can be closed by
+// Unsubscribe while the dispatcher is delivering an event.
+type watch struct {
+	events *bus.Outbox[Event]
+	done   chan struct{}
+
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+}
+
+func newWatch(conn common.Connection, overflows *atomic.Uint64) *watch {
+	return &watch{
+		events: bus.NewOutbox(conn, eventBufferSize, coalesce, overflows),
+		done:   make(chan struct{}),
+	}
+}
+
+// send delivers event unless the watch, the monitor, signaled by stop, or
+// the connection, signaled by closed, is closed meanwhile.
+func (w *watch) send(event Event, stop <-chan struct{}, closed <-chan struct{}) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed || w.events.Offer(event) {
+		return
+	}
+	select {
+	case w.events.C <- event:
+	case <-w.done:
+	case <-stop:
+	case <-closed:
+	}
+}
+
+func (w *watch) close() {
+	w.closeOnce.Do(func() {
+		// unblock a pending send before taking the write lock
+		close(w.done)
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.closed = true
+		close(w.events.C)
+	})
+}
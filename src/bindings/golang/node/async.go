@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+)
+
+// JobResult is the outcome of a job queued by an asynchronous call like
+// StartUnitAsync.
+type JobResult struct {
+	// Node is the name of the node.
+	Node string
+	// Unit is the name of the unit.
+	Unit string
+	// Path is the object path of the job, empty if it was not queued.
+	Path dbus.ObjectPath
+	// Result is the result of the job, e.g. job.ResultDone, empty if it
+	// was not waited for.
+	Result string
+	// Err is set if the job was not queued or waited for, or if its result
+	// is not job.ResultDone.
+	Err error
+}
+
+// StartUnitAsync starts the named unit on the node without blocking and
+// delivers the outcome of the job on the returned channel, which receives
+// a single JobResult and is closed then. The jobs of all asynchronous calls
+// on a connection are waited for by a shared job.Tracker, so many of them
+// can be pending without blocking a goroutine each. ctx bounds both the
+// call and the wait for the job.
+func (n *Node) StartUnitAsync(ctx context.Context, unit string, mode string) <-chan JobResult {
+	return n.unitJobAsync(ctx, common.METHOD_START_UNIT, "start", unit, mode)
+}
+
+// StopUnitAsync is StartUnitAsync stopping the unit.
+func (n *Node) StopUnitAsync(ctx context.Context, unit string, mode string) <-chan JobResult {
+	return n.unitJobAsync(ctx, common.METHOD_STOP_UNIT, "stop", unit, mode)
+}
+
+// RestartUnitAsync is StartUnitAsync restarting the unit.
+func (n *Node) RestartUnitAsync(ctx context.Context, unit string, mode string) <-chan JobResult {
+	return n.unitJobAsync(ctx, common.METHOD_RESTART_UNIT, "restart", unit, mode)
+}
+
+// ReloadUnitAsync is StartUnitAsync reloading the unit.
+func (n *Node) ReloadUnitAsync(ctx context.Context, unit string, mode string) <-chan JobResult {
+	return n.unitJobAsync(ctx, common.METHOD_RELOAD_UNIT, "reload", unit, mode)
+}
+
+// unitJobAsync issues the job-producing call on a goroutine living until
+// its reply arrives, the job is then waited for by the shared tracker.
+func (n *Node) unitJobAsync(ctx context.Context, method string, op string, unit string, mode string) <-chan JobResult {
+	w := &asyncJob{ch: make(chan JobResult, 1), res: JobResult{Node: n.name, Unit: unit}}
+
+	// track jobs before issuing the call so the JobRemoved signal isn't missed
+	tracker, err := job.SharedTracker(n.conn)
+	if err != nil {
+		w.finish("", err)
+		return w.ch
+	}
+	go func() {
+		path, err := n.unitJob(ctx, method, op, unit, mode)
+		if err != nil {
+			w.finish("", err)
+			return
+		}
+		w.res.Path = path
+		w.check = func(result string) error {
+			if result != job.ResultDone {
+				return fmt.Errorf("failed to %s unit %s on node %s: job %s finished with result %s", op, unit, n.name, path, result)
+			}
+			return nil
+		}
+		unregister := tracker.Notify(path, w.finish)
+		w.stopWith(context.AfterFunc(ctx, func() {
+			unregister()
+			w.finish("", fmt.Errorf("failed to wait for job %s: %w", path, ctx.Err()))
+		}))
+	}()
+	return w.ch
+}
+
+// asyncJob delivers the result of an asynchronous job once, whichever of
+// the tracker and the context comes first.
+type asyncJob struct {
+	ch    chan JobResult
+	res   JobResult
+	check func(result string) error
+
+	mu   sync.Mutex
+	done bool
+	stop func() bool
+}
+
+func (w *asyncJob) finish(result string, err error) {
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return
+	}
+	w.done = true
+	stop := w.stop
+	w.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+	if err == nil && w.check != nil {
+		err = w.check(result)
+	}
+	w.res.Result, w.res.Err = result, err
+	w.ch <- w.res
+	close(w.ch)
+}
+
+// stopWith sets the function releasing the context watch, which is called
+// right away if the result was delivered already.
+func (w *asyncJob) stopWith(stop func() bool) {
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		stop()
+		return
+	}
+	w.stop = stop
+	w.mu.Unlock()
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// UnitCondition decides on the properties of a unit whether a conditional
+// job like RestartUnitIf is queued for it. The properties are decoded into
+// their Go types as by GetUnitsProperties, a property missing on the unit
+// is missing in props.
+type UnitCondition func(props map[string]interface{}) bool
+
+// PropertyEquals returns the condition that the named property of the unit
+// equals value, e.g. PropertyEquals("ActiveState", "failed").
+func PropertyEquals(name string, value interface{}) UnitCondition {
+	return func(props map[string]interface{}) bool {
+		v, ok := props[name]
+		return ok && reflect.DeepEqual(v, value)
+	}
+}
+
+// ConditionOption configures a conditional job like RestartUnitIf.
+type ConditionOption func(*conditionOptions)
+
+type conditionOptions struct {
+	props  []string
+	settle time.Duration
+}
+
+// WithConditionProperties reads only the named properties for the
+// condition instead of all of the unit, which saves the call for the
+// interface of the unit type if they are all of org.freedesktop.systemd1.Unit.
+func WithConditionProperties(props ...string) ConditionOption {
+	return func(o *conditionOptions) {
+		o.props = props
+	}
+}
+
+// WithSettleTime reads the properties and checks the condition a second
+// time after d if it held the first time, and only queues the job if it
+// still holds, e.g. so that a unit restarting on its own is not restarted
+// again. The condition is checked once by default.
+func WithSettleTime(d time.Duration) ConditionOption {
+	return func(o *conditionOptions) {
+		o.settle = d
+	}
+}
+
+// StartUnitIf queues a start job for the named unit like StartUnit if cond
+// holds on the current properties of the unit, re-checked after the settle
+// time of WithSettleTime. It returns the object path of the job and whether
+// it was queued, e.g. to skip unnecessary jobs in a reconcile loop. The
+// check and the job are separate calls, so the unit may change in between.
+func (n *Node) StartUnitIf(ctx context.Context, unit string, mode string, cond UnitCondition, opts ...ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, common.METHOD_START_UNIT, "start", unit, mode, cond, opts)
+}
+
+// StopUnitIf is StartUnitIf stopping the unit.
+func (n *Node) StopUnitIf(ctx context.Context, unit string, mode string, cond UnitCondition, opts ...ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, common.METHOD_STOP_UNIT, "stop", unit, mode, cond, opts)
+}
+
+// RestartUnitIf is StartUnitIf restarting the unit.
+func (n *Node) RestartUnitIf(ctx context.Context, unit string, mode string, cond UnitCondition, opts ...ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, common.METHOD_RESTART_UNIT, "restart", unit, mode, cond, opts)
+}
+
+// ReloadUnitIf is StartUnitIf reloading the unit.
+func (n *Node) ReloadUnitIf(ctx context.Context, unit string, mode string, cond UnitCondition, opts ...ConditionOption) (dbus.ObjectPath, bool, error) {
+	return n.unitJobIf(ctx, common.METHOD_RELOAD_UNIT, "reload", unit, mode, cond, opts)
+}
+
+func (n *Node) unitJobIf(ctx context.Context, method string, op string, unit string, mode string, cond UnitCondition, opts []ConditionOption) (dbus.ObjectPath, bool, error) {
+	return QueueIf(ctx, n.name, unit, op,
+		func(ctx context.Context, props []string) (map[string]interface{}, error) {
+			return n.getSelectedProperties(ctx, unit, props)
+		}, cond,
+		func(ctx context.Context) (dbus.ObjectPath, error) {
+			return n.unitJob(ctx, method, op, unit, mode)
+		}, opts...)
+}
+
+// QueueIf reads the properties of the unit with get, checks cond on them,
+// after the settle time of the options once more, and calls queue for the
+// operation op if it held each time, as the conditional jobs like
+// RestartUnitIf do, e.g. for fakes of manager.NodeAPI. get is passed the
+// properties of WithConditionProperties, none for all of them.
+func QueueIf(
+	ctx context.Context,
+	nodeName string,
+	unit string,
+	op string,
+	get func(ctx context.Context, props []string) (map[string]interface{}, error),
+	cond UnitCondition,
+	queue func(ctx context.Context) (dbus.ObjectPath, error),
+	opts ...ConditionOption,
+) (dbus.ObjectPath, bool, error) {
+	var o conditionOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	props, err := get(ctx, o.props)
+	if err != nil {
+		return "", false, err
+	}
+	if !cond(props) {
+		return "", false, nil
+	}
+	if o.settle > 0 {
+		timer := time.NewTimer(o.settle)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return "", false, fmt.Errorf("failed to %s unit %s on node %s: %w", op, unit, nodeName, ctx.Err())
+		}
+		if props, err = get(ctx, o.props); err != nil {
+			return "", false, err
+		}
+		if !cond(props) {
+			return "", false, nil
+		}
+	}
+
+	path, err := queue(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return path, true, nil
+}
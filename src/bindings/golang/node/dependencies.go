@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// DependencyKind is a dependency property of systemd units.
+type DependencyKind string
+
+// Dependency kinds read by UnitDependencies.
+const (
+	// DependencyRequires are the units which must be started with the
+	// unit.
+	DependencyRequires DependencyKind = "Requires"
+	// DependencyWants are the units which are started with the unit if
+	// possible.
+	DependencyWants DependencyKind = "Wants"
+	// DependencyAfter are the units the unit is started after.
+	DependencyAfter DependencyKind = "After"
+	// DependencyBefore are the units the unit is started before.
+	DependencyBefore DependencyKind = "Before"
+)
+
+// UnitDependencies are the dependencies of a unit, each sorted by name.
+type UnitDependencies struct {
+	// Unit is the name of the unit.
+	Unit     string
+	Requires []string
+	Wants    []string
+	After    []string
+	Before   []string
+}
+
+// Of returns the dependencies of the given kind.
+func (d UnitDependencies) Of(kind DependencyKind) []string {
+	switch kind {
+	case DependencyRequires:
+		return d.Requires
+	case DependencyWants:
+		return d.Wants
+	case DependencyAfter:
+		return d.After
+	case DependencyBefore:
+		return d.Before
+	}
+	return nil
+}
+
+// UnitDependencies returns the Requires, Wants, After and Before properties
+// of the named unit.
+func (n *Node) UnitDependencies(ctx context.Context, unit string) (UnitDependencies, error) {
+	raw, err := bus.Call[map[string]dbus.Variant](ctx, n.obj, common.METHOD_GET_UNIT_PROPERTIES, unit, common.SYSTEMD_UNIT_INTERFACE)
+	if err != nil {
+		return UnitDependencies{}, fmt.Errorf("failed to get dependencies of unit %s on node %s: %w", unit, n.name, err)
+	}
+
+	d := UnitDependencies{Unit: unit}
+	fields := map[DependencyKind]*[]string{
+		DependencyRequires: &d.Requires,
+		DependencyWants:    &d.Wants,
+		DependencyAfter:    &d.After,
+		DependencyBefore:   &d.Before,
+	}
+	for kind, field := range fields {
+		v, ok := raw[string(kind)]
+		if !ok {
+			continue
+		}
+		units, err := variant.Strings(v)
+		if err != nil {
+			return UnitDependencies{}, fmt.Errorf("failed to get dependencies of unit %s on node %s: %s: %w", unit, n.name, kind, err)
+		}
+		sort.Strings(units)
+		*field = units
+	}
+	return d, nil
+}
+
+// DependencyGraph holds the dependencies of units keyed by unit name.
+type DependencyGraph map[string]UnitDependencies
+
+// DependencyReader reads the dependencies of units, e.g. a *Node or a
+// manager.NodeAPI.
+type DependencyReader interface {
+	UnitDependencies(ctx context.Context, unit string) (UnitDependencies, error)
+}
+
+// ReadDependencyGraph reads the dependencies of the named units and,
+// following the dependencies of the given kinds, of all units they depend
+// on. Without kinds, Requires and Wants are followed. After and Before are
+// recorded for all units read, but following them reads most units of a
+// node.
+func ReadDependencyGraph(ctx context.Context, r DependencyReader, units []string, kinds ...DependencyKind) (DependencyGraph, error) {
+	if len(kinds) == 0 {
+		kinds = []DependencyKind{DependencyRequires, DependencyWants}
+	}
+	g := make(DependencyGraph)
+	pending := slices.Clone(units)
+	for len(pending) > 0 {
+		unit := pending[0]
+		pending = pending[1:]
+		if _, ok := g[unit]; ok {
+			continue
+		}
+		d, err := r.UnitDependencies(ctx, unit)
+		if err != nil {
+			return nil, err
+		}
+		g[unit] = d
+		for _, kind := range kinds {
+			pending = append(pending, d.Of(kind)...)
+		}
+	}
+	return g, nil
+}
+
+// Closure returns the units the named unit depends on directly or
+// indirectly via dependencies of the given kinds, sorted by name. Units
+// missing in the graph are included but not followed.
+func (g DependencyGraph) Closure(unit string, kinds ...DependencyKind) []string {
+	seen := map[string]bool{unit: true}
+	var closure []string
+	pending := []string{unit}
+	for len(pending) > 0 {
+		d := g[pending[0]]
+		pending = pending[1:]
+		for _, kind := range kinds {
+			for _, dep := range d.Of(kind) {
+				if seen[dep] {
+					continue
+				}
+				seen[dep] = true
+				closure = append(closure, dep)
+				pending = append(pending, dep)
+			}
+		}
+	}
+	sort.Strings(closure)
+	return closure
+}
+
+// StartOrder sorts the named units so that each unit comes after the units
+// it is ordered after by After or their Before dependencies. Units not
+// ordered against each other keep their relative order. An error is
+// returned if the ordering has a cycle.
+func (g DependencyGraph) StartOrder(units []string) ([]string, error) {
+	wanted := make(map[string]bool, len(units))
+	for _, unit := range units {
+		wanted[unit] = true
+	}
+	// before[a] are the given units which must start before a
+	before := make(map[string]map[string]bool, len(units))
+	for _, unit := range units {
+		before[unit] = make(map[string]bool)
+	}
+	for _, unit := range units {
+		d := g[unit]
+		for _, dep := range d.After {
+			if wanted[dep] && dep != unit {
+				before[unit][dep] = true
+			}
+		}
+		for _, dep := range d.Before {
+			if wanted[dep] && dep != unit {
+				before[dep][unit] = true
+			}
+		}
+	}
+
+	order := make([]string, 0, len(units))
+	placed := make(map[string]bool, len(units))
+	for len(order) < len(before) {
+		progress := false
+		for _, unit := range units {
+			if placed[unit] || !allPlaced(before[unit], placed) {
+				continue
+			}
+			order = append(order, unit)
+			placed[unit] = true
+			progress = true
+		}
+		if !progress {
+			var cycle []string
+			for _, unit := range units {
+				if !placed[unit] && !slices.Contains(cycle, unit) {
+					cycle = append(cycle, unit)
+				}
+			}
+			return nil, fmt.Errorf("ordering cycle between units %s", strings.Join(cycle, ", "))
+		}
+	}
+	return order, nil
+}
+
+func allPlaced(units map[string]bool, placed map[string]bool) bool {
+	for unit := range units {
+		if !placed[unit] {
+			return false
+		}
+	}
+	return true
+}
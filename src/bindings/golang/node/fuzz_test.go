@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+const nodePath = dbus.ObjectPath("/org/eclipse/bluechi/node/node_a")
+
+// unit is the struct (ssssssouso) of a unit listed by a node.
+type unit struct {
+	Name, Description, LoadState, ActiveState, SubState, Followed string
+	ObjectPath                                                    dbus.ObjectPath
+	JobID                                                         uint32
+	JobType                                                       string
+	JobPath                                                       dbus.ObjectPath
+}
+
+func newUnit(name string) unit {
+	return unit{name, "desc", "loaded", "active", "running", "", "/org/freedesktop/systemd1/unit/x", 0, "", "/"}
+}
+
+// change is the struct (sss) of a change of a unit file.
+type change struct {
+	Type, FileName, Destination string
+}
+
+// FuzzListUnits feeds the decoding of the units listed by a node with
+// arbitrary replies, which must fail with an error rather than panic.
+func FuzzListUnits(f *testing.F) {
+	testbus.AddReply(f, []unit{newUnit("a.service"), newUnit("b.service")})
+	testbus.AddReply(f, []unit{})
+	testbus.AddReply(f, []change{{"a.service", "desc", "loaded"}})
+	testbus.AddReply(f, []string{"a.service"})
+	testbus.AddReply(f, "a.service", uint32(1))
+	testbus.AddReply(f)
+
+	f.Fuzz(func(t *testing.T, sig string, data []byte) {
+		body, ok := testbus.DecodeReply(sig, data)
+		if !ok {
+			return
+		}
+		ctx := context.Background()
+		n := node.New(&testbus.Reply{Body: body}, "node_a", nodePath)
+
+		units, err := n.ListUnits(ctx)
+		var count int
+		funcErr := n.ListUnitsFunc(ctx, func(node.UnitInfo) bool {
+			count++
+			return true
+		})
+		if (err == nil) != (funcErr == nil) || err == nil && count != len(units) {
+			t.Fatalf("ListUnits and ListUnitsFunc disagree: %d units, %v and %d units, %v", len(units), err, count, funcErr)
+		}
+		_, _ = n.ListUnitFiles(ctx)
+	})
+}
+
+// FuzzUnitProperties feeds the decoding of the properties of units and
+// nodes and of the unit file operations with arbitrary replies.
+func FuzzUnitProperties(f *testing.F) {
+	testbus.AddReply(f, map[string]dbus.Variant{
+		"ActiveState": dbus.MakeVariant("active"),
+		"Requires":    dbus.MakeVariant([]string{"a.service"}),
+		"Nested":      dbus.MakeVariant(dbus.MakeVariant(map[string]dbus.Variant{"x": dbus.MakeVariant(uint32(1))})),
+	})
+	testbus.AddReply(f, dbus.MakeVariant("active"))
+	testbus.AddReply(f, dbus.MakeVariant(uint64(1700000000)))
+	testbus.AddReply(f, dbus.MakeVariant(change{"a", "b", "c"}))
+	testbus.AddReply(f, true, []change{{"symlink", "/etc/x", "/usr/x"}})
+	testbus.AddReply(f, []change{{"unlink", "/etc/x", ""}})
+	testbus.AddReply(f, "[Unit]\nDescription=x\n")
+	testbus.AddReply(f, map[string]string{"ActiveState": "active"})
+
+	f.Fuzz(func(t *testing.T, sig string, data []byte) {
+		body, ok := testbus.DecodeReply(sig, data)
+		if !ok {
+			return
+		}
+		ctx := context.Background()
+		n := node.New(&testbus.Reply{Body: body}, "node_a", nodePath)
+		unit := "a.service"
+
+		_, _ = n.GetUnitProperties(ctx, unit, "org.freedesktop.systemd1.Unit")
+		_, _ = n.GetUnitProperty(ctx, unit, "org.freedesktop.systemd1.Unit", "ActiveState")
+		_, _ = n.GetUnitActiveState(ctx, unit)
+		_, _ = n.GetUnitSubState(ctx, unit)
+		_, _ = n.GetUnitCGroupPath(ctx, unit)
+		_, _ = n.GetUnitFileState(ctx, unit)
+		_, _ = n.UnitDependencies(ctx, unit)
+		_, _ = n.EnableUnitFiles(ctx, []string{unit}, false, false)
+		_, _ = n.DisableUnitFiles(ctx, []string{unit}, false)
+		_, _ = n.Status(ctx)
+		_, _ = n.PeerIP(ctx)
+		_, _ = n.LastSeenTimestamp(ctx)
+	})
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+)
+
+// DefaultLogLines is the number of log lines attached by WithLogs unless
+// given, as many as systemctl status shows.
+const DefaultLogLines = 10
+
+// LogProvider reads the logs of units. BlueChi does not relay the logs of
+// the nodes, so they are read from where they are kept, e.g. the journal of
+// the node or a log aggregation system.
+type LogProvider interface {
+	// UnitLogs returns the last lines of the logs of unit on the named
+	// node, the oldest first.
+	UnitLogs(ctx context.Context, nodeName string, unit string, lines int) ([]string, error)
+}
+
+// Journal is a LogProvider running journalctl.
+type Journal struct {
+	// Command returns the command line running journalctl for the named
+	// node, to which the arguments selecting the logs are appended, e.g.
+	// {"ssh", nodeName, "journalctl"}. If nil, journalctl reads the journal
+	// of the local host, e.g. on a single host or one collecting the
+	// journals of the nodes.
+	Command func(nodeName string) []string
+}
+
+// UnitLogs runs journalctl for the last lines of the logs of unit. The unit
+// name may only contain the characters allowed by systemd except for the
+// backslash of escaped names, e.g. \x2d, so that a remote shell passes it
+// on unchanged.
+func (j Journal) UnitLogs(ctx context.Context, nodeName string, unit string, lines int) ([]string, error) {
+	if !validUnitName(unit) {
+		return nil, fmt.Errorf("failed to read journal: invalid unit name %q: %w", unit, common.ErrInvalidArgs)
+	}
+	args := []string{"journalctl"}
+	if j.Command != nil {
+		args = slices.Clone(j.Command(nodeName))
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("failed to read journal: no command for node %s", nodeName)
+	}
+	args = append(args, "--unit", unit, "--lines", strconv.Itoa(lines), "--no-pager", "--quiet")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to read journal: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	text := strings.TrimRight(string(out), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// validUnitName reports whether unit consists of the characters systemd
+// allows in unit names, the backslash excepted.
+func validUnitName(unit string) bool {
+	if unit == "" {
+		return false
+	}
+	for _, c := range unit {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune(":-_.@", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
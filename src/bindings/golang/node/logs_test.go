@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+func TestJournal(t *testing.T) {
+	ctx := context.Background()
+	var gotNode string
+	// the script prints its arguments one per line instead of running
+	// journalctl
+	j := node.Journal{Command: func(nodeName string) []string {
+		gotNode = nodeName
+		return []string{"sh", "-c", `printf '%s\n' "$@"`, "journalctl"}
+	}}
+
+	lines, err := j.UnitLogs(ctx, "node1", "app@1.service", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--unit", "app@1.service", "--lines", "5", "--no-pager", "--quiet"}
+	if gotNode != "node1" || !slices.Equal(lines, want) {
+		t.Fatalf("expected %v for node1, got %v for %s", want, lines, gotNode)
+	}
+
+	for _, unit := range []string{"", "a;reboot.service", "dev-disk-by\\x2dlabel.device", "a b.service"} {
+		if _, err := j.UnitLogs(ctx, "node1", unit, 5); !errors.Is(err, common.ErrInvalidArgs) {
+			t.Errorf("%q: expected ErrInvalidArgs, got %v", unit, err)
+		}
+	}
+
+	j = node.Journal{Command: func(string) []string { return []string{"sh", "-c", "echo no journal >&2; exit 1", "journalctl"} }}
+	if _, err := j.UnitLogs(ctx, "node1", "app.service", 5); err == nil || !strings.Contains(err.Error(), "no journal") {
+		t.Fatalf("expected the error of journalctl, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package node provides Go bindings for the org.eclipse.bluechi.Node
+// interface, which controls the units of a single node managed by BlueChi.
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// Node is a proxy for a node object exported by the BlueChi controller.
+type Node struct {
+	name string
+	path dbus.ObjectPath
+	conn common.Connection
+	obj  dbus.BusObject
+}
+
+// New returns a proxy for the node object at path on the controller. Use
+// manager.Manager.GetNode to resolve the path of a node by its name.
+func New(conn common.Connection, name string, path dbus.ObjectPath) *Node {
+	return &Node{
+		name: name,
+		path: path,
+		conn: conn,
+		obj:  bus.NodeObject(conn, name, path),
+	}
+}
+
+// Name returns the name of the node.
+func (n *Node) Name() string {
+	return n.name
+}
+
+// ObjectPath returns the path of the node object on the controller.
+func (n *Node) ObjectPath() dbus.ObjectPath {
+	return n.path
+}
+
+// SetLogLevel changes the log level of the agent on the node at runtime,
+// e.g. to common.LOG_LEVEL_DEBUG.
+func (n *Node) SetLogLevel(ctx context.Context, level string) error {
+	err := bus.Exec(ctx, n.obj, common.METHOD_NODE_SET_LOG_LEVEL, level)
+	if err != nil {
+		return fmt.Errorf("failed to set log level of node %s to %s: %w", n.name, level, err)
+	}
+	return nil
+}
+
+// pingUnit is the unit read by Ping, the root slice which exists on every
+// node.
+const pingUnit = "-.slice"
+
+// Ping reads a property of the root slice on the node and returns the round
+// trip time, which covers the controller relaying the call to the agent and
+// the agent reading the property from systemd. Offline nodes fail with an
+// error matching common.ErrNodeOffline.
+func (n *Node) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if _, err := bus.Call[dbus.Variant](ctx, n.obj, common.METHOD_GET_UNIT_PROPERTY, pingUnit, common.SYSTEMD_UNIT_INTERFACE, "Id"); err != nil {
+		return 0, fmt.Errorf("failed to ping node %s: %w", n.name, err)
+	}
+	return time.Since(start), nil
+}
+
+// Status returns the connection status of the node with the controller,
+// either online or offline.
+func (n *Node) Status(ctx context.Context) (NodeStatus, error) {
+	status, err := n.getStringProperty(ctx, "Status")
+	return NodeStatus(status), err
+}
+
+// PeerIP returns the IP address the agent of the node connected from, empty
+// while the node is offline. Controllers not exporting the PeerIp property
+// yet return a *common.Error named common.ERROR_UNKNOWN_PROPERTY.
+func (n *Node) PeerIP(ctx context.Context) (string, error) {
+	return n.getStringProperty(ctx, "PeerIp")
+}
+
+// LastSeenTimestamp returns when the controller last received a heartbeat
+// of the agent of the node. The zero time is returned if the node has never
+// been connected.
+func (n *Node) LastSeenTimestamp(ctx context.Context) (time.Time, error) {
+	v, err := n.getProperty(ctx, "LastSeenTimestamp")
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := variant.Uint64(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode property LastSeenTimestamp of node %s: %w", n.name, err)
+	}
+	if seconds == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+// Introspect returns the introspection data of the node object on the
+// controller, e.g. to check with HasMethod whether the controller supports
+// a method of the node interface before calling it.
+func (n *Node) Introspect(ctx context.Context) (common.Introspection, error) {
+	return common.Introspect(ctx, n.obj)
+}
+
+func (n *Node) getProperty(ctx context.Context, name string) (dbus.Variant, error) {
+	v, err := bus.GetProperty(ctx, n.obj, common.NODE_INTERFACE, name)
+	if err != nil {
+		return dbus.Variant{}, fmt.Errorf("failed to get property %s of node %s: %w", name, n.name, err)
+	}
+	return v, nil
+}
+
+func (n *Node) getStringProperty(ctx context.Context, name string) (string, error) {
+	v, err := n.getProperty(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	s, err := variant.String(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode property %s of node %s: %w", name, n.name, err)
+	}
+	return s, nil
+}
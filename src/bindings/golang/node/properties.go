@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// unitTypeInterfaces maps unit name suffixes to the systemd interface
+// providing the type specific properties of the unit, e.g. ControlGroup.
+var unitTypeInterfaces = map[string]string{
+	".service": common.SYSTEMD_SERVICE_INTERFACE,
+	".socket":  common.SYSTEMD_SOCKET_INTERFACE,
+	".mount":   common.SYSTEMD_MOUNT_INTERFACE,
+	".swap":    common.SYSTEMD_SWAP_INTERFACE,
+	".slice":   common.SYSTEMD_SLICE_INTERFACE,
+	".scope":   common.SYSTEMD_SCOPE_INTERFACE,
+}
+
+// GetUnitProperties returns all properties of the named unit for the given
+// systemd interface, e.g. org.freedesktop.systemd1.Unit. The values are
+// decoded into their Go types.
+func (n *Node) GetUnitProperties(ctx context.Context, unit string, iface string) (map[string]interface{}, error) {
+	raw, err := bus.Call[map[string]dbus.Variant](ctx, n.obj, common.METHOD_GET_UNIT_PROPERTIES, unit, iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties of unit %s on node %s: %w", unit, n.name, err)
+	}
+
+	return variant.Values(raw), nil
+}
+
+// GetUnitProperty returns a single property of the named unit for the given
+// systemd interface, decoded into its Go type.
+func (n *Node) GetUnitProperty(ctx context.Context, unit string, iface string, property string) (interface{}, error) {
+	v, err := n.getUnitProperty(ctx, unit, iface, property)
+	if err != nil {
+		return nil, err
+	}
+	return v.Value(), nil
+}
+
+// DefaultPropertiesConcurrency is the number of units GetUnitsProperties
+// reads at the same time unless WithPropertiesConcurrency is given.
+const DefaultPropertiesConcurrency = 8
+
+// PropertiesOption configures GetUnitsProperties.
+type PropertiesOption func(*propertiesOptions)
+
+type propertiesOptions struct {
+	concurrency int
+}
+
+// WithPropertiesConcurrency limits the number of units read at the same
+// time. Values below 1 select DefaultPropertiesConcurrency.
+func WithPropertiesConcurrency(n int) PropertiesOption {
+	return func(o *propertiesOptions) {
+		o.concurrency = n
+	}
+}
+
+// GetUnitsProperties returns the named properties of the named units keyed
+// by unit and property name, decoded into their Go types, e.g. for a
+// dashboard showing a few properties of many units. All properties of a
+// unit are read with a single call for org.freedesktop.systemd1.Unit and,
+// if a property is not found there, one for the interface of its unit type,
+// e.g. org.freedesktop.systemd1.Service for MainPID. The units are read
+// concurrently. Without property names, all properties of both interfaces
+// are returned. Properties a unit does not have are omitted. If some units
+// cannot be read, the properties of the others are returned together with
+// their errors joined.
+func (n *Node) GetUnitsProperties(ctx context.Context, units []string, props []string, opts ...PropertiesOption) (map[string]map[string]interface{}, error) {
+	o := propertiesOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = DefaultPropertiesConcurrency
+	}
+	units = slices.Compact(slices.Sorted(slices.Values(units)))
+
+	results := make([]map[string]interface{}, len(units))
+	errs := make([]error, len(units))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(o.concurrency, len(units)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = n.getSelectedProperties(ctx, units[i], props)
+			}
+		}()
+	}
+	for i := range units {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	values := make(map[string]map[string]interface{}, len(units))
+	for i, unit := range units {
+		if errs[i] == nil {
+			values[unit] = results[i]
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// getSelectedProperties returns the named properties of a unit, all of them
+// without names, see GetUnitsProperties.
+func (n *Node) getSelectedProperties(ctx context.Context, unit string, props []string) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get properties of unit %s on node %s: %w", unit, n.name, err)
+	}
+	all, err := n.GetUnitProperties(ctx, unit, common.SYSTEMD_UNIT_INTERFACE)
+	if err != nil {
+		return nil, err
+	}
+	missing := len(props) == 0
+	for _, prop := range props {
+		if _, ok := all[prop]; !ok {
+			missing = true
+			break
+		}
+	}
+	if iface, ok := unitTypeInterface(unit); ok && missing {
+		typed, err := n.GetUnitProperties(ctx, unit, iface)
+		if err != nil {
+			return nil, err
+		}
+		for name, v := range typed {
+			if _, ok := all[name]; !ok {
+				all[name] = v
+			}
+		}
+	}
+	if len(props) == 0 {
+		return all, nil
+	}
+
+	selected := make(map[string]interface{}, len(props))
+	for _, prop := range props {
+		if v, ok := all[prop]; ok {
+			selected[prop] = v
+		}
+	}
+	return selected, nil
+}
+
+// GetUnitActiveState returns the active state of the named unit, e.g.
+// active, inactive or failed.
+func (n *Node) GetUnitActiveState(ctx context.Context, unit string) (ActiveState, error) {
+	state, err := n.getUnitStringProperty(ctx, unit, common.SYSTEMD_UNIT_INTERFACE, "ActiveState")
+	return ActiveState(state), err
+}
+
+// GetUnitSubState returns the sub state of the named unit, e.g. running or
+// dead.
+func (n *Node) GetUnitSubState(ctx context.Context, unit string) (SubState, error) {
+	state, err := n.getUnitStringProperty(ctx, unit, common.SYSTEMD_UNIT_INTERFACE, "SubState")
+	return SubState(state), err
+}
+
+// GetUnitCGroupPath returns the control group of the named unit. Only
+// services, sockets, mounts, swaps, slices and scopes have a control group.
+func (n *Node) GetUnitCGroupPath(ctx context.Context, unit string) (string, error) {
+	iface, ok := unitTypeInterface(unit)
+	if !ok {
+		return "", fmt.Errorf("failed to get control group of unit %s on node %s: unit type has no control group", unit, n.name)
+	}
+	return n.getUnitStringProperty(ctx, unit, iface, "ControlGroup")
+}
+
+// unitProperty is the wire format of a single entry passed to the
+// SetUnitProperties method.
+type unitProperty struct {
+	Name  string
+	Value dbus.Variant
+}
+
+// SetUnitProperties sets the given properties of the named unit. If runtime
+// is true the changes do not persist across reboots. Values are wrapped in a
+// dbus.Variant unless they already are one, so their Go type has to match
+// the D-Bus type of the property, e.g. uint64 for MemoryMax.
+func (n *Node) SetUnitProperties(ctx context.Context, unit string, runtime bool, props map[string]interface{}) error {
+	err := bus.Exec(ctx, n.obj, common.METHOD_SET_UNIT_PROPERTIES, unit, runtime, unitProperties(props))
+	if err != nil {
+		return fmt.Errorf("failed to set properties of unit %s on node %s: %w", unit, n.name, err)
+	}
+	return nil
+}
+
+// SetUnitCPUQuota limits the CPU time of the named unit to the given
+// percentage of a single CPU, e.g. 150 for one and a half CPUs. The
+// percentage must be positive and finite.
+func (n *Node) SetUnitCPUQuota(ctx context.Context, unit string, runtime bool, percent float64) error {
+	if !(percent > 0) || math.IsInf(percent, 1) {
+		return fmt.Errorf("failed to set CPU quota of unit %s on node %s: invalid quota %v%%", unit, n.name, percent)
+	}
+	perSec := uint64(percent / 100 * float64(time.Second/time.Microsecond))
+	return n.SetUnitProperties(ctx, unit, runtime, map[string]interface{}{"CPUQuotaPerSecUSec": perSec})
+}
+
+// SetUnitCPUWeight sets the relative CPU weight of the named unit, a value
+// between 1 and 10000.
+func (n *Node) SetUnitCPUWeight(ctx context.Context, unit string, runtime bool, weight uint64) error {
+	if weight < 1 || weight > 10000 {
+		return fmt.Errorf("failed to set CPU weight of unit %s on node %s: invalid weight %d", unit, n.name, weight)
+	}
+	return n.SetUnitProperties(ctx, unit, runtime, map[string]interface{}{"CPUWeight": weight})
+}
+
+// SetUnitMemoryMax sets the absolute memory limit of the named unit in
+// bytes.
+func (n *Node) SetUnitMemoryMax(ctx context.Context, unit string, runtime bool, bytes uint64) error {
+	return n.SetUnitProperties(ctx, unit, runtime, map[string]interface{}{"MemoryMax": bytes})
+}
+
+func (n *Node) getUnitProperty(ctx context.Context, unit string, iface string, property string) (dbus.Variant, error) {
+	v, err := bus.Call[dbus.Variant](ctx, n.obj, common.METHOD_GET_UNIT_PROPERTY, unit, iface, property)
+	if err != nil {
+		return dbus.Variant{}, fmt.Errorf("failed to get property %s of unit %s on node %s: %w", property, unit, n.name, err)
+	}
+	return v, nil
+}
+
+func (n *Node) getUnitStringProperty(ctx context.Context, unit string, iface string, property string) (string, error) {
+	v, err := n.getUnitProperty(ctx, unit, iface, property)
+	if err != nil {
+		return "", err
+	}
+	s, err := variant.String(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode property %s of unit %s on node %s: %w", property, unit, n.name, err)
+	}
+	return s, nil
+}
+
+// unitProperties returns props sorted by name in wire format. Values are
+// wrapped in a dbus.Variant unless they already are one.
+func unitProperties(props map[string]interface{}) []unitProperty {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keyvalues := make([]unitProperty, 0, len(props))
+	for _, name := range names {
+		v, ok := props[name].(dbus.Variant)
+		if !ok {
+			v = dbus.MakeVariant(props[name])
+		}
+		keyvalues = append(keyvalues, unitProperty{Name: name, Value: v})
+	}
+	return keyvalues
+}
+
+func unitTypeInterface(unit string) (string, bool) {
+	idx := strings.LastIndex(unit, ".")
+	if idx < 0 {
+		return "", false
+	}
+	iface, ok := unitTypeInterfaces[unit[idx:]]
+	return iface, ok
+}
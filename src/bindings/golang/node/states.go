@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import "fmt"
+
+// LoadState reports whether the unit file of a unit has been loaded, see
+// the LoadState property of systemd units.
+type LoadState string
+
+// Load states of a unit.
+const (
+	LoadStateStub       LoadState = "stub"
+	LoadStateLoaded     LoadState = "loaded"
+	LoadStateNotFound   LoadState = "not-found"
+	LoadStateBadSetting LoadState = "bad-setting"
+	LoadStateError      LoadState = "error"
+	LoadStateMerged     LoadState = "merged"
+	LoadStateMasked     LoadState = "masked"
+)
+
+var loadStates = []LoadState{
+	LoadStateStub, LoadStateLoaded, LoadStateNotFound, LoadStateBadSetting, LoadStateError, LoadStateMerged, LoadStateMasked,
+}
+
+// ParseLoadState returns the load state named s, failing for names
+// unknown to the bindings.
+func ParseLoadState(s string) (LoadState, error) {
+	return parse(s, loadStates, "load state")
+}
+
+func (s LoadState) String() string { return string(s) }
+
+// IsLoaded reports whether the unit file has been loaded successfully.
+func (s LoadState) IsLoaded() bool { return s == LoadStateLoaded }
+
+// ActiveState reports whether a unit is started, see the ActiveState
+// property of systemd units.
+type ActiveState string
+
+// Active states of a unit.
+const (
+	ActiveStateActive       ActiveState = "active"
+	ActiveStateReloading    ActiveState = "reloading"
+	ActiveStateInactive     ActiveState = "inactive"
+	ActiveStateFailed       ActiveState = "failed"
+	ActiveStateActivating   ActiveState = "activating"
+	ActiveStateDeactivating ActiveState = "deactivating"
+	ActiveStateMaintenance  ActiveState = "maintenance"
+	ActiveStateRefreshing   ActiveState = "refreshing"
+)
+
+var activeStates = []ActiveState{
+	ActiveStateActive, ActiveStateReloading, ActiveStateInactive, ActiveStateFailed,
+	ActiveStateActivating, ActiveStateDeactivating, ActiveStateMaintenance, ActiveStateRefreshing,
+}
+
+// ParseActiveState returns the active state named s, failing for names
+// unknown to the bindings.
+func ParseActiveState(s string) (ActiveState, error) {
+	return parse(s, activeStates, "active state")
+}
+
+func (s ActiveState) String() string { return string(s) }
+
+// IsActive reports whether the unit is started, including while it is
+// reloaded or refreshed.
+func (s ActiveState) IsActive() bool {
+	return s == ActiveStateActive || s == ActiveStateReloading || s == ActiveStateRefreshing
+}
+
+// IsFailed reports whether the unit is in the failed state.
+func (s ActiveState) IsFailed() bool { return s == ActiveStateFailed }
+
+// IsTransitioning reports whether the unit is being started or stopped.
+func (s ActiveState) IsTransitioning() bool {
+	return s == ActiveStateActivating || s == ActiveStateDeactivating
+}
+
+// SubState is the unit type specific state of a unit, see the SubState
+// property of systemd units. The set of sub states depends on the unit
+// type, so any non-empty name is valid, the constants are the most common
+// ones.
+type SubState string
+
+// Common sub states of units.
+const (
+	SubStateDead        SubState = "dead"
+	SubStateRunning     SubState = "running"
+	SubStateExited      SubState = "exited"
+	SubStateFailed      SubState = "failed"
+	SubStateStart       SubState = "start"
+	SubStateStop        SubState = "stop"
+	SubStateReload      SubState = "reload"
+	SubStateAutoRestart SubState = "auto-restart"
+	SubStateListening   SubState = "listening"
+	SubStateWaiting     SubState = "waiting"
+	SubStateMounted     SubState = "mounted"
+	SubStatePlugged     SubState = "plugged"
+	SubStateActive      SubState = "active"
+	SubStateElapsed     SubState = "elapsed"
+)
+
+// ParseSubState returns the sub state named s, failing if s is empty.
+func ParseSubState(s string) (SubState, error) {
+	if s == "" {
+		return "", fmt.Errorf("invalid sub state: empty name")
+	}
+	return SubState(s), nil
+}
+
+func (s SubState) String() string { return string(s) }
+
+// ParseNodeStatus returns the node status named s, failing for names
+// unknown to the bindings.
+func ParseNodeStatus(s string) (NodeStatus, error) {
+	return parse(s, []NodeStatus{StatusOnline, StatusOffline}, "node status")
+}
+
+func (s NodeStatus) String() string { return string(s) }
+
+// IsOnline reports whether the node is connected to the controller.
+func (s NodeStatus) IsOnline() bool { return s == StatusOnline }
+
+func parse[T ~string](s string, valid []T, kind string) (T, error) {
+	for _, v := range valid {
+		if string(v) == s {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q", kind, s)
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// statusBufferSize is the capacity of the channel returned by WatchStatus.
+const statusBufferSize = 4
+
+// NodeStatus is the connection status of a node with the controller.
+type NodeStatus string
+
+// Statuses of a node.
+const (
+	StatusOnline  NodeStatus = "online"
+	StatusOffline NodeStatus = "offline"
+)
+
+// WatchStatus returns a channel on which the current status of the node is
+// delivered first, followed by each change of it. The watch ends and the
+// channel is closed when ctx is done or the connection is closed. With
+// auto-reconnect, the status is read again after a reconnect and delivered
+// if it changed meanwhile.
+func (n *Node) WatchStatus(ctx context.Context) (<-chan NodeStatus, error) {
+	match := bus.Match{
+		Rules: [][]dbus.MatchOption{bus.PropertiesChangedRule(common.NODE_OBJECT_PATH_PREFIX, common.NODE_INTERFACE)},
+		Filter: func(sig *dbus.Signal) bool {
+			return sig.Path == n.path && sig.Name == common.SIGNAL_PROPERTIES_CHANGED
+		},
+	}
+	sub, err := bus.Subscribe(n.conn, match, statusBufferSize, bus.Block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to status of node %s: %w", n.name, err)
+	}
+
+	current, err := n.Status(ctx)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	restored := make(chan struct{}, 1)
+	unhook := bus.OnRestore(n.conn, func(context.Context) {
+		select {
+		case restored <- struct{}{}:
+		default:
+		}
+	})
+
+	// with OverflowCoalesce, a pending status is replaced by the next one
+	statuses := bus.NewOutbox(n.conn, statusBufferSize, func(_, next NodeStatus) (NodeStatus, bool) { return next, true })
+	last := NodeStatus(current)
+	statuses.C <- last
+	emit := func(status NodeStatus) bool {
+		if status == last {
+			return true
+		}
+		last = status
+		if statuses.Offer(status) {
+			return true
+		}
+		select {
+		case statuses.C <- status:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-n.conn.Context().Done():
+			return false
+		}
+	}
+	bus.Go(n.conn, func() {
+		defer close(statuses.C)
+		defer func() {
+			unhook()
+			sub.Close()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-n.conn.Context().Done():
+				return
+			case <-restored:
+				// changes were missed while disconnected
+				status, err := n.Status(ctx)
+				if err != nil {
+					bus.Logger(n.conn).Error("failed to get node status after reconnect", "node", n.name, "error", err)
+					continue
+				}
+				if !emit(NodeStatus(status)) {
+					return
+				}
+			case sig, ok := <-sub.Signals():
+				if !ok {
+					return
+				}
+				status, ok := statusFromSignal(sig)
+				if ok && !emit(status) {
+					return
+				}
+			}
+		}
+	})
+	return statuses.C, nil
+}
+
+func statusFromSignal(sig *dbus.Signal) (NodeStatus, bool) {
+	var iface string
+	var changed map[string]dbus.Variant
+	var invalidated []string
+	if dbus.Store(sig.Body, &iface, &changed, &invalidated) != nil || iface != common.NODE_INTERFACE {
+		return "", false
+	}
+	v, ok := changed["Status"]
+	if !ok {
+		return "", false
+	}
+	status, err := variant.String(v)
+	return NodeStatus(status), err == nil
+}
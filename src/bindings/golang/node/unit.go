@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+)
+
+// Modes used to queue unit lifecycle jobs. See the equivalent systemd
+// methods for details.
+const (
+	// ModeReplace replaces an already queued job for the unit.
+	ModeReplace = "replace"
+	// ModeFail fails the job if another job is already queued for the unit.
+	ModeFail = "fail"
+)
+
+// UnitInfo describes a systemd unit loaded on a node as reported by
+// ListUnits.
+type UnitInfo struct {
+	// Name is the primary unit name.
+	Name string
+	// Description is the human readable description of the unit.
+	Description string
+	// LoadState reports whether the unit file has been loaded successfully.
+	LoadState LoadState
+	// ActiveState reports whether the unit is currently started or not.
+	ActiveState ActiveState
+	// SubState is a more fine-grained, unit type specific version of the
+	// active state.
+	SubState SubState
+	// Followed is the unit being followed in its state by this unit, if
+	// there is any, otherwise the empty string.
+	Followed string
+	// ObjectPath is the systemd object path of the unit.
+	ObjectPath dbus.ObjectPath
+	// JobID is the id of the job queued for the unit, 0 otherwise.
+	JobID uint32
+	// JobType is the type of the queued job.
+	JobType string
+	// JobPath is the object path of the queued job.
+	JobPath dbus.ObjectPath
+}
+
+// MarshalJSON encodes the unit info with lower camel case keys, omitting
+// the fields describing a queued job if there is none.
+func (u UnitInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		LoadState   LoadState       `json:"loadState"`
+		ActiveState ActiveState     `json:"activeState"`
+		SubState    SubState        `json:"subState"`
+		Followed    string          `json:"followed,omitempty"`
+		ObjectPath  dbus.ObjectPath `json:"objectPath"`
+		JobID       uint32          `json:"jobID,omitempty"`
+		JobType     string          `json:"jobType,omitempty"`
+		JobPath     dbus.ObjectPath `json:"jobPath,omitempty"`
+	}{u.Name, u.Description, u.LoadState, u.ActiveState, u.SubState, u.Followed, u.ObjectPath, u.JobID, u.JobType, u.JobPath})
+}
+
+// ListUnits returns all loaded systemd units on the node.
+func (n *Node) ListUnits(ctx context.Context) ([]UnitInfo, error) {
+	raw, err := bus.Array(ctx, n.obj, common.METHOD_NODE_LISTUNITS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list units on node %s: %w", n.name, err)
+	}
+	units := make([]UnitInfo, len(raw))
+	for i, fields := range raw {
+		if units[i], err = DecodeUnitInfo(fields); err != nil {
+			return nil, fmt.Errorf("failed to list units on node %s: %w", n.name, err)
+		}
+	}
+	return units, nil
+}
+
+// ListUnitsFunc calls fn with each loaded systemd unit on the node until fn
+// returns false. Unlike ListUnits it builds no slice of all units, e.g. for
+// nodes with thousands of units of which only a few are of interest.
+func (n *Node) ListUnitsFunc(ctx context.Context, fn func(UnitInfo) bool) error {
+	err := bus.Each(ctx, n.obj, common.METHOD_NODE_LISTUNITS, func(fields []interface{}) (bool, error) {
+		u, err := DecodeUnitInfo(fields)
+		if err != nil {
+			return false, err
+		}
+		return fn(u), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list units on node %s: %w", n.name, err)
+	}
+	return nil
+}
+
+// DecodeUnitInfo decodes the fields of a unit as listed by ListUnits of
+// a node, i.e. of the D-Bus struct (ssssssouso).
+func DecodeUnitInfo(fields []interface{}) (UnitInfo, error) {
+	var u UnitInfo
+	if len(fields) != 10 {
+		return u, fmt.Errorf("failed to decode unit: expected 10 fields, got %d", len(fields))
+	}
+	var ok [10]bool
+	u.Name, ok[0] = fields[0].(string)
+	u.Description, ok[1] = fields[1].(string)
+	var loadState, activeState, subState string
+	loadState, ok[2] = fields[2].(string)
+	activeState, ok[3] = fields[3].(string)
+	subState, ok[4] = fields[4].(string)
+	u.Followed, ok[5] = fields[5].(string)
+	u.ObjectPath, ok[6] = fields[6].(dbus.ObjectPath)
+	u.JobID, ok[7] = fields[7].(uint32)
+	u.JobType, ok[8] = fields[8].(string)
+	u.JobPath, ok[9] = fields[9].(dbus.ObjectPath)
+	for i, valid := range ok {
+		if !valid {
+			return UnitInfo{}, fmt.Errorf("failed to decode unit %v: invalid field %d: %T", fields[0], i, fields[i])
+		}
+	}
+	u.LoadState, u.ActiveState, u.SubState = LoadState(loadState), ActiveState(activeState), SubState(subState)
+	return u, nil
+}
+
+// StartUnit queues a start job for the named unit on the node and returns
+// the object path of the job.
+func (n *Node) StartUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, common.METHOD_START_UNIT, "start", unit, mode)
+}
+
+// StopUnit queues a stop job for the named unit on the node and returns
+// the object path of the job.
+func (n *Node) StopUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, common.METHOD_STOP_UNIT, "stop", unit, mode)
+}
+
+// RestartUnit queues a restart job for the named unit on the node and
+// returns the object path of the job.
+func (n *Node) RestartUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, common.METHOD_RESTART_UNIT, "restart", unit, mode)
+}
+
+// ReloadUnit queues a reload job for the named unit on the node and returns
+// the object path of the job.
+func (n *Node) ReloadUnit(ctx context.Context, unit string, mode string) (dbus.ObjectPath, error) {
+	return n.unitJob(ctx, common.METHOD_RELOAD_UNIT, "reload", unit, mode)
+}
+
+// FreezeUnit suspends all processes in the control group of the named unit
+// until the unit is thawed again.
+func (n *Node) FreezeUnit(ctx context.Context, unit string) error {
+	err := bus.Exec(ctx, n.obj, common.METHOD_FREEZE_UNIT, unit)
+	if err != nil {
+		return fmt.Errorf("failed to freeze unit %s on node %s: %w", unit, n.name, err)
+	}
+	return nil
+}
+
+// ThawUnit resumes the processes in the control group of the named unit
+// previously suspended by FreezeUnit.
+func (n *Node) ThawUnit(ctx context.Context, unit string) error {
+	err := bus.Exec(ctx, n.obj, common.METHOD_THAW_UNIT, unit)
+	if err != nil {
+		return fmt.Errorf("failed to thaw unit %s on node %s: %w", unit, n.name, err)
+	}
+	return nil
+}
+
+func (n *Node) unitJob(ctx context.Context, method string, op string, unit string, mode string) (dbus.ObjectPath, error) {
+	job, err := bus.Call[dbus.ObjectPath](ctx, n.obj, method, unit, mode)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s unit %s on node %s: %w", op, unit, n.name, err)
+	}
+	return job, nil
+}
+
+// StartUnitAndWait starts the named unit on the node and blocks until the
+// job has finished. An error is returned if the job result is not "done".
+func (n *Node) StartUnitAndWait(ctx context.Context, unit string, mode string) error {
+	return n.unitJobAndWait(ctx, common.METHOD_START_UNIT, "start", unit, mode)
+}
+
+// StopUnitAndWait stops the named unit on the node and blocks until the job
+// has finished. An error is returned if the job result is not "done".
+func (n *Node) StopUnitAndWait(ctx context.Context, unit string, mode string) error {
+	return n.unitJobAndWait(ctx, common.METHOD_STOP_UNIT, "stop", unit, mode)
+}
+
+// RestartUnitAndWait restarts the named unit on the node and blocks until
+// the job has finished. An error is returned if the job result is not
+// "done".
+func (n *Node) RestartUnitAndWait(ctx context.Context, unit string, mode string) error {
+	return n.unitJobAndWait(ctx, common.METHOD_RESTART_UNIT, "restart", unit, mode)
+}
+
+// ReloadUnitAndWait reloads the named unit on the node and blocks until the
+// job has finished. An error is returned if the job result is not "done".
+func (n *Node) ReloadUnitAndWait(ctx context.Context, unit string, mode string) error {
+	return n.unitJobAndWait(ctx, common.METHOD_RELOAD_UNIT, "reload", unit, mode)
+}
+
+func (n *Node) unitJobAndWait(ctx context.Context, method string, op string, unit string, mode string) error {
+	// track jobs before issuing the call so the JobRemoved signal isn't missed
+	tracker, err := job.NewTracker(n.conn)
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	path, err := n.unitJob(ctx, method, op, unit, mode)
+	if err != nil {
+		return err
+	}
+
+	result, err := tracker.Wait(ctx, path)
+	if err != nil {
+		return err
+	}
+	if result != job.ResultDone {
+		return fmt.Errorf("failed to %s unit %s on node %s: job %s finished with result %s", op, unit, n.name, path, result)
+	}
+	return nil
+}
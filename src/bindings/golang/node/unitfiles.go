@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+// Types of changes made by EnableUnitFiles and DisableUnitFiles.
+const (
+	ChangeSymlink = "symlink"
+	ChangeUnlink  = "unlink"
+)
+
+// States of unit files as returned by GetUnitFileState. See systemctl
+// is-enabled for the full list.
+const (
+	UnitFileEnabled        = "enabled"
+	UnitFileEnabledRuntime = "enabled-runtime"
+	UnitFileDisabled       = "disabled"
+	UnitFileStatic         = "static"
+	UnitFileMasked         = "masked"
+	UnitFileIndirect       = "indirect"
+	UnitFileGenerated      = "generated"
+	UnitFileTransient      = "transient"
+)
+
+// UnitFile describes the unit file of a unit as returned by ListUnitFiles.
+type UnitFile struct {
+	// Name is the name of the unit.
+	Name string
+	// Path is the path of the unit file, empty for units without one.
+	Path string
+	// State is the enablement state of the unit file, e.g. enabled.
+	State string
+}
+
+// UnitFileChange describes a single change made while enabling or disabling
+// unit files.
+type UnitFileChange struct {
+	// Type is the type of the change, either symlink or unlink.
+	Type string
+	// FileName is the file name of the symlink.
+	FileName string
+	// Destination is the destination of the symlink.
+	Destination string
+}
+
+// EnableUnitFilesResult is the outcome of EnableUnitFiles.
+type EnableUnitFilesResult struct {
+	// CarriesInstallInfo is true if the unit files contained enablement
+	// information, i.e. an [Install] section.
+	CarriesInstallInfo bool
+	// Changes are the changes made.
+	Changes []UnitFileChange
+}
+
+// EnableUnitFiles enables the given unit files on the node by creating
+// symlinks to them in /etc or, if runtime is true, in /run. If force is
+// true, symlinks pointing to other units are replaced.
+func (n *Node) EnableUnitFiles(ctx context.Context, files []string, runtime bool, force bool) (EnableUnitFilesResult, error) {
+	var result EnableUnitFilesResult
+	err := n.obj.CallWithContext(ctx, common.METHOD_ENABLE_UNIT_FILES, 0, files, runtime, force).
+		Store(&result.CarriesInstallInfo, &result.Changes)
+	if err != nil {
+		return EnableUnitFilesResult{}, fmt.Errorf("failed to enable unit files %v on node %s: %w", files, n.name, err)
+	}
+	return result, nil
+}
+
+// DisableUnitFiles disables the given unit files on the node by removing
+// all symlinks to them in /etc or, if runtime is true, in /run.
+func (n *Node) DisableUnitFiles(ctx context.Context, files []string, runtime bool) ([]UnitFileChange, error) {
+	changes, err := bus.Call[[]UnitFileChange](ctx, n.obj, common.METHOD_DISABLE_UNIT_FILES, files, runtime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to disable unit files %v on node %s: %w", files, n.name, err)
+	}
+	return changes, nil
+}
+
+// GetUnitFileState returns the enablement state of the unit file of the
+// named unit, e.g. UnitFileEnabled or UnitFileStatic. The unit does not
+// need to be loaded.
+func (n *Node) GetUnitFileState(ctx context.Context, unit string) (string, error) {
+	return n.getUnitStringProperty(ctx, unit, common.SYSTEMD_UNIT_INTERFACE, "UnitFileState")
+}
+
+// ListUnitFiles returns the unit files of the units loaded on the node.
+// The node API does not pass the ListUnitFiles method of systemd through,
+// so unit files of units which are not loaded are missing and each unit
+// takes two calls on top of ListUnits.
+func (n *Node) ListUnitFiles(ctx context.Context) ([]UnitFile, error) {
+	units, err := n.ListUnits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]UnitFile, 0, len(units))
+	for _, u := range units {
+		path, err := n.getUnitStringProperty(ctx, u.Name, common.SYSTEMD_UNIT_INTERFACE, "FragmentPath")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list unit files on node %s: %w", n.name, err)
+		}
+		state, err := n.GetUnitFileState(ctx, u.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list unit files on node %s: %w", n.name, err)
+		}
+		files = append(files, UnitFile{Name: u.Name, Path: path, State: state})
+	}
+	return files, nil
+}
+
+// Reload reloads all unit files on the node, equivalent to a systemd
+// daemon-reload. Call it after changing unit files to pick up the changes.
+func (n *Node) Reload(ctx context.Context) error {
+	err := bus.Exec(ctx, n.obj, common.METHOD_RELOAD)
+	if err != nil {
+		return fmt.Errorf("failed to reload unit files on node %s: %w", n.name, err)
+	}
+	return nil
+}
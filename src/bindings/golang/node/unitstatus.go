@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"fmt"
+)
+
+// unitStatusProperties are the properties read by UnitStatus.
+var unitStatusProperties = []string{"LoadState", "ActiveState", "SubState", "Result", "ExecMainCode", "ExecMainStatus"}
+
+// UnitStatus is the state of a unit at a glance, as shown by systemctl
+// status, e.g. for the output of a CLI or an alert.
+type UnitStatus struct {
+	// Unit is the name of the unit.
+	Unit        string
+	LoadState   LoadState
+	ActiveState ActiveState
+	SubState    SubState
+	// Result is the result of the last run of the unit, e.g. "success" or
+	// "exit-code", empty for unit types without one, e.g. targets.
+	Result string
+	// ExecMainCode is the code of the exit of the main process of a
+	// service, e.g. 1 if it exited or 2 if it was killed, see
+	// waitid(2), zero for other units.
+	ExecMainCode int32
+	// ExecMainStatus is the exit status or the signal of the main process
+	// of a service, by ExecMainCode.
+	ExecMainStatus int32
+	// Logs are the last log lines of the unit if it failed and WithLogs was
+	// given.
+	Logs []string
+}
+
+// UnitStatusOption configures UnitStatus.
+type UnitStatusOption func(*unitStatusOptions)
+
+type unitStatusOptions struct {
+	logs  LogProvider
+	lines int
+}
+
+// WithLogs attaches the last lines of the logs of failed units read from
+// p, DefaultLogLines if lines is below 1.
+func WithLogs(p LogProvider, lines int) UnitStatusOption {
+	return func(o *unitStatusOptions) {
+		o.logs = p
+		o.lines = lines
+	}
+}
+
+// UnitStatus returns the states of the named unit and the result of its
+// last run, read with a call for org.freedesktop.systemd1.Unit and one for
+// the interface of its unit type. With WithLogs, the last log lines of a
+// failed unit are attached, if they cannot be read the status is returned
+// together with the error.
+func (n *Node) UnitStatus(ctx context.Context, unit string, opts ...UnitStatusOption) (UnitStatus, error) {
+	props, err := n.getSelectedProperties(ctx, unit, unitStatusProperties)
+	if err != nil {
+		return UnitStatus{}, fmt.Errorf("failed to get status of unit %s on node %s: %w", unit, n.name, err)
+	}
+	status := NewUnitStatus(unit, props)
+	return status, status.AttachLogs(ctx, n.name, opts...)
+}
+
+// NewUnitStatus returns the UnitStatus of the named unit with the given
+// properties decoded into their Go types, e.g. by GetUnitsProperties.
+// Properties missing or of another type are left empty.
+func NewUnitStatus(unit string, props map[string]interface{}) UnitStatus {
+	s := UnitStatus{Unit: unit}
+	loadState, _ := props["LoadState"].(string)
+	activeState, _ := props["ActiveState"].(string)
+	subState, _ := props["SubState"].(string)
+	s.LoadState, s.ActiveState, s.SubState = LoadState(loadState), ActiveState(activeState), SubState(subState)
+	s.Result, _ = props["Result"].(string)
+	s.ExecMainCode, _ = props["ExecMainCode"].(int32)
+	s.ExecMainStatus, _ = props["ExecMainStatus"].(int32)
+	return s
+}
+
+// AttachLogs attaches the logs requested by the options to s if the unit
+// failed, as UnitStatus does, e.g. for fakes of manager.NodeAPI.
+func (s *UnitStatus) AttachLogs(ctx context.Context, nodeName string, opts ...UnitStatusOption) error {
+	var o unitStatusOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.logs == nil || !s.ActiveState.IsFailed() {
+		return nil
+	}
+	if o.lines < 1 {
+		o.lines = DefaultLogLines
+	}
+	logs, err := o.logs.UnitLogs(ctx, nodeName, s.Unit, o.lines)
+	if err != nil {
+		return fmt.Errorf("failed to read logs of unit %s on node %s: %w", s.Unit, nodeName, err)
+	}
+	s.Logs = logs
+	return nil
+}
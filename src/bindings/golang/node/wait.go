@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/bus"
+)
+
+const (
+	// waitPollInterval is the interval in which WaitForUnitState reads the
+	// active state of the unit if no monitor can be used.
+	waitPollInterval = time.Second
+	// waitBufferSize is the capacity of the channel of the unit state
+	// signals received by WaitForUnitState.
+	waitBufferSize = 8
+)
+
+// UnitStateError is returned by WaitForUnitState if the unit did not reach
+// the target state before the context was done.
+type UnitStateError struct {
+	// Node is the name of the node.
+	Node string
+	// Unit is the name of the unit.
+	Unit string
+	// Target is the active state waited for.
+	Target ActiveState
+	// Last is the last active state observed, empty if none was.
+	Last ActiveState
+	// Err is the error of the context.
+	Err error
+}
+
+func (e *UnitStateError) Error() string {
+	last := string(e.Last)
+	if last == "" {
+		last = "unknown"
+	}
+	return fmt.Sprintf("unit %s on node %s did not become %s, last state %s: %v", e.Unit, e.Node, e.Target, last, e.Err)
+}
+
+func (e *UnitStateError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForUnitState blocks until the active state of the unit is target,
+// e.g. ActiveStateActive, ActiveStateInactive or ActiveStateFailed, and
+// returns right away if it is already. The changes are received via a
+// monitor created for the wait on the controller, the state is polled if
+// the controller refuses to create it. Use a context with a timeout to
+// bound the wait, a *UnitStateError with the last observed state is
+// returned when it is done.
+func (n *Node) WaitForUnitState(ctx context.Context, unit string, target ActiveState) error {
+	match := bus.Match{
+		Rules: [][]dbus.MatchOption{{
+			dbus.WithMatchPathNamespace(common.MONITOR_OBJECT_PATH_PREFIX),
+			dbus.WithMatchInterface(common.MONITOR_INTERFACE),
+			dbus.WithMatchMember("UnitStateChanged"),
+		}},
+		Filter: func(sig *dbus.Signal) bool {
+			node, name, ok := unitOfSignal(sig)
+			return ok && node == n.name && name == unit
+		},
+	}
+	sub, err := bus.Subscribe(n.conn, match, waitBufferSize, bus.DropOldest)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to state of unit %s on node %s: %w", unit, n.name, err)
+	}
+	defer sub.Close()
+
+	var signals <-chan *dbus.Signal
+	if closeMonitor, err := n.monitorUnit(ctx, unit); err != nil {
+		bus.Logger(n.conn).Debug("polling unit state, monitor unavailable", "node", n.name, "unit", unit, "error", err)
+	} else {
+		defer closeMonitor()
+		signals = sub.Signals()
+	}
+
+	// the state is read after subscribing, so that no change is missed
+	last, err := n.GetUnitActiveState(ctx, unit)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	for last != target {
+		select {
+		case <-ctx.Done():
+			return &UnitStateError{Node: n.name, Unit: unit, Target: target, Last: last, Err: ctx.Err()}
+		case sig, ok := <-signals:
+			if !ok {
+				// the connection is gone, polling reports its error
+				signals = nil
+				continue
+			}
+			var node, name, active, sub, reason string
+			if dbus.Store(sig.Body, &node, &name, &active, &sub, &reason) == nil {
+				last = ActiveState(active)
+			}
+		case <-ticker.C:
+			if signals != nil {
+				continue
+			}
+			state, err := n.GetUnitActiveState(ctx, unit)
+			switch {
+			case err == nil:
+				last = state
+			case ctx.Err() == nil:
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// monitorUnit creates a monitor subscribed to the unit on the node and
+// returns the function closing it.
+func (n *Node) monitorUnit(ctx context.Context, unit string) (func(), error) {
+	controller := bus.Object(n.conn, common.BC_DBUS_NAME, common.BC_OBJECT_PATH)
+	path, err := bus.Call[dbus.ObjectPath](ctx, controller, common.METHOD_CREATE_MONITOR)
+	if err != nil {
+		return nil, err
+	}
+	obj := bus.Object(n.conn, common.BC_DBUS_NAME, path)
+	closeMonitor := func() {
+		// the wait may have ended because ctx is done
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), waitPollInterval)
+		defer cancel()
+		_ = bus.Exec(ctx, obj, common.METHOD_MONITOR_CLOSE)
+	}
+	if _, err := bus.Call[uint32](ctx, obj, common.METHOD_MONITOR_SUBSCRIBE, n.name, unit); err != nil {
+		closeMonitor()
+		return nil, err
+	}
+	return closeMonitor, nil
+}
+
+func unitOfSignal(sig *dbus.Signal) (string, string, bool) {
+	if sig.Name != common.SIGNAL_UNIT_STATE_CHANGED || len(sig.Body) < 2 {
+		return "", "", false
+	}
+	node, ok := sig.Body[0].(string)
+	if !ok {
+		return "", "", false
+	}
+	unit, ok := sig.Body[1].(string)
+	return node, unit, ok
+}
@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package orchestration builds multi-node workflows, such as a rolling
+// restart of a unit, on top of the operations of a manager.ManagerAPI.
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+const (
+	// DefaultActiveTimeout is how long RollingRestart waits for the unit to
+	// become active on a node unless WithActiveTimeout is given.
+	DefaultActiveTimeout = time.Minute
+	// DefaultPollInterval is the interval in which the active state of the
+	// restarted unit is read unless WithPollInterval is given.
+	DefaultPollInterval = time.Second
+)
+
+// ErrAborted is returned, wrapping the errors of the failed nodes, when a
+// rolling restart stopped before all nodes were restarted.
+var ErrAborted = errors.New("rolling restart aborted")
+
+// RollingOption configures RollingRestart.
+type RollingOption func(*rollingOptions)
+
+type rollingOptions struct {
+	batchSize      int
+	maxUnavailable int
+	abortOnFailure bool
+	activeTimeout  time.Duration
+	pollInterval   time.Duration
+	mode           string
+	maxInFlight    int
+	serial         bool
+}
+
+// WithBatchSize restarts n nodes at the same time, 1 by default. Values
+// below 1 select 1.
+func WithBatchSize(n int) RollingOption {
+	return func(o *rollingOptions) {
+		o.batchSize = n
+	}
+}
+
+// WithMaxUnavailable bounds the number of nodes of the restart on which
+// the unit is not active, counting the nodes being restarted, to n. Batches
+// are shrunk to stay within it and the restart is aborted if not even a
+// single node can be restarted. It is the batch size by default.
+func WithMaxUnavailable(n int) RollingOption {
+	return func(o *rollingOptions) {
+		o.maxUnavailable = n
+	}
+}
+
+// WithAbortOnFailure sets whether the restart stops after the first batch
+// with a failed node, which is the default, or carries on with the
+// remaining nodes.
+func WithAbortOnFailure(abort bool) RollingOption {
+	return func(o *rollingOptions) {
+		o.abortOnFailure = abort
+	}
+}
+
+// WithActiveTimeout sets how long to wait for the unit to become active on
+// a node after the restart job finished.
+func WithActiveTimeout(timeout time.Duration) RollingOption {
+	return func(o *rollingOptions) {
+		o.activeTimeout = timeout
+	}
+}
+
+// WithPollInterval sets how often the active state of the unit is read
+// while waiting for it to become active.
+func WithPollInterval(interval time.Duration) RollingOption {
+	return func(o *rollingOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WithJobMode sets the mode the restart jobs are queued with,
+// node.ModeReplace by default.
+func WithJobMode(mode string) RollingOption {
+	return func(o *rollingOptions) {
+		o.mode = mode
+	}
+}
+
+// WithMaxInFlight limits the number of operations in flight across all
+// rolling and batch operations of the manager to n while a node is
+// restarted, see manager.WithMaxInFlight. Values below 1 set no limit.
+func WithMaxInFlight(n int) RollingOption {
+	return func(o *rollingOptions) {
+		o.maxInFlight = n
+	}
+}
+
+// WithPerNodeSerial restarts a node only while no other rolling or batch
+// operation of the manager works on it and keeps others off the node until
+// the unit is active again, see manager.WithPerNodeSerial.
+func WithPerNodeSerial() RollingOption {
+	return func(o *rollingOptions) {
+		o.serial = true
+	}
+}
+
+func newRollingOptions(opts []RollingOption) rollingOptions {
+	o := rollingOptions{
+		batchSize:      1,
+		abortOnFailure: true,
+		activeTimeout:  DefaultActiveTimeout,
+		pollInterval:   DefaultPollInterval,
+		mode:           node.ModeReplace,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.batchSize < 1 {
+		o.batchSize = 1
+	}
+	if o.maxUnavailable < 1 {
+		o.maxUnavailable = o.batchSize
+	}
+	if o.activeTimeout <= 0 {
+		o.activeTimeout = DefaultActiveTimeout
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = DefaultPollInterval
+	}
+	return o
+}
+
+// NodeResult is the outcome of the restart on a single node.
+type NodeResult struct {
+	// Node is the name of the node.
+	Node string
+	// Batch is the number of the batch the node was restarted in,
+	// starting at 1.
+	Batch int
+	// ActiveState is the last active state of the unit read on the node,
+	// empty if it was not read.
+	ActiveState node.ActiveState
+	// Err is the error of the restart, nil if the unit became active.
+	Err error
+}
+
+// RollingRestart restarts unit on the given nodes in batches, in the order
+// of nodes. Each batch waits for the restart jobs to finish and the unit to
+// report active on all of its nodes before the next batch starts. The
+// results of the nodes worked on are returned in the order of nodes. The
+// error is a *manager.MultiError with a *manager.NodeError for each failed
+// node, wrapped with ErrAborted if not all nodes were restarted.
+func RollingRestart(ctx context.Context, m manager.ManagerAPI, unit string, nodes []string, opts ...RollingOption) ([]NodeResult, error) {
+	o := newRollingOptions(opts)
+	results := make([]NodeResult, 0, len(nodes))
+	failed := &manager.MultiError{Op: "restart unit " + unit}
+
+	for batch, next := 1, 0; next < len(nodes); batch++ {
+		if err := ctx.Err(); err != nil {
+			return results, abort(unit, len(results), len(nodes), failed, err)
+		}
+
+		available, err := activeOn(ctx, m, unit, nodes)
+		if err != nil {
+			return results, abort(unit, len(results), len(nodes), failed, err)
+		}
+		size := batchSize(available, next, o)
+		if size < 1 {
+			err := fmt.Errorf("unit %s is not active on too many nodes, at most %d may be unavailable", unit, o.maxUnavailable)
+			return results, abort(unit, len(results), len(nodes), failed, err)
+		}
+
+		batchResults := make([]NodeResult, size)
+		var wg sync.WaitGroup
+		for i, name := range nodes[next : next+size] {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				state, err := restartOnNode(ctx, m, unit, name, o)
+				batchResults[i] = NodeResult{Node: name, Batch: batch, ActiveState: state, Err: err}
+			}()
+		}
+		wg.Wait()
+		next += size
+
+		for _, r := range batchResults {
+			if r.Err != nil {
+				failed.Add(r.Node, r.Err)
+			}
+		}
+		results = append(results, batchResults...)
+		if len(failed.Errors) > 0 && o.abortOnFailure && next < len(nodes) {
+			return results, abort(unit, len(results), len(nodes), failed, nil)
+		}
+	}
+	return results, failed.ErrorOrNil()
+}
+
+// abort returns the error of a rolling restart stopped after done of total
+// nodes because of cause or the failed nodes.
+func abort(unit string, done int, total int, failed *manager.MultiError, cause error) error {
+	errs := []error{ErrAborted}
+	if cause != nil {
+		errs = append(errs, cause)
+	}
+	if len(failed.Errors) > 0 {
+		errs = append(errs, failed)
+	}
+	return fmt.Errorf("failed to restart unit %s after %d of %d nodes: %w", unit, done, total, errors.Join(errs...))
+}
+
+// activeOn reports for each of the nodes whether unit is active on it. The
+// unit is not active on nodes which are offline.
+func activeOn(ctx context.Context, m manager.ManagerAPI, unit string, nodes []string) ([]bool, error) {
+	active := make([]bool, len(nodes))
+	for idx, name := range nodes {
+		n, err := m.GetNode(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		state, err := n.GetUnitActiveState(ctx, unit)
+		active[idx] = err == nil && state.IsActive()
+	}
+	return active, nil
+}
+
+// batchSize returns the size of the batch starting at next, the largest
+// one keeping the nodes being restarted and the other nodes on which the
+// unit is not active within the maximum of unavailable nodes.
+func batchSize(active []bool, next int, o rollingOptions) int {
+	for size := min(o.batchSize, len(active)-next); size > 0; size-- {
+		unavailable := size
+		for idx, ok := range active {
+			if !ok && (idx < next || idx >= next+size) {
+				unavailable++
+			}
+		}
+		if unavailable <= o.maxUnavailable {
+			return size
+		}
+	}
+	return 0
+}
+
+func restartOnNode(ctx context.Context, m manager.ManagerAPI, unit string, name string, o rollingOptions) (node.ActiveState, error) {
+	leave, err := m.NodeGate().Enter(ctx, name, o.maxInFlight, o.serial)
+	if err != nil {
+		return "", err
+	}
+	defer leave()
+
+	n, err := m.GetNode(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if err := n.RestartUnitAndWait(ctx, unit, o.mode); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.activeTimeout)
+	defer cancel()
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	for {
+		state, err := n.GetUnitActiveState(ctx, unit)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case state.IsActive():
+			return state, nil
+		case state.IsFailed():
+			return state, fmt.Errorf("unit %s failed on node %s after restart", unit, name)
+		}
+		select {
+		case <-ctx.Done():
+			return state, fmt.Errorf("unit %s not active on node %s after restart: %s: %w", unit, name, state, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
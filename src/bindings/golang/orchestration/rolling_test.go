@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package orchestration_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/orchestration"
+)
+
+func newFleet(names ...string) *managertest.Manager {
+	f := managertest.New()
+	for _, name := range names {
+		f.AddNode(name).AddUnit("app.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	}
+	return f
+}
+
+func batches(results []orchestration.NodeResult) map[string]int {
+	b := make(map[string]int, len(results))
+	for _, r := range results {
+		b[r.Node] = r.Batch
+	}
+	return b
+}
+
+func TestRollingRestart(t *testing.T) {
+	ctx := context.Background()
+	nodes := []string{"n1", "n2", "n3", "n4", "n5"}
+	f := newFleet(nodes...)
+
+	results, err := orchestration.RollingRestart(ctx, f, "app.service", nodes, orchestration.WithBatchSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(nodes) {
+		t.Fatalf("expected %d results, got %v", len(nodes), results)
+	}
+	want := map[string]int{"n1": 1, "n2": 1, "n3": 2, "n4": 2, "n5": 3}
+	for idx, r := range results {
+		if r.Node != nodes[idx] || r.Batch != want[r.Node] || r.Err != nil || !r.ActiveState.IsActive() {
+			t.Errorf("unexpected result %+v", r)
+		}
+	}
+}
+
+func TestRollingRestartAbort(t *testing.T) {
+	ctx := context.Background()
+	nodes := []string{"n1", "n2", "n3"}
+	f := newFleet(nodes...)
+	f.Node("n2").SetJobResult("app.service", job.ResultFailed)
+
+	results, err := orchestration.RollingRestart(ctx, f, "app.service", nodes)
+	if !errors.Is(err, orchestration.ErrAborted) {
+		t.Fatalf("expected ErrAborted, got %v", err)
+	}
+	var multi *manager.MultiError
+	if !errors.As(err, &multi) || multi.Errors["n2"] == nil || len(multi.Errors) != 1 {
+		t.Fatalf("expected the failure of n2, got %v", err)
+	}
+	if len(results) != 2 || results[1].Err == nil {
+		t.Fatalf("expected n3 not to be restarted, got %v", results)
+	}
+}
+
+func TestRollingRestartContinue(t *testing.T) {
+	ctx := context.Background()
+	nodes := []string{"n1", "n2", "n3"}
+	f := newFleet(nodes...)
+	f.Node("n1").SetJobResult("app.service", job.ResultFailed)
+
+	results, err := orchestration.RollingRestart(ctx, f, "app.service", nodes,
+		orchestration.WithAbortOnFailure(false), orchestration.WithMaxUnavailable(2))
+	if errors.Is(err, orchestration.ErrAborted) {
+		t.Fatalf("expected the restart to carry on, got %v", err)
+	}
+	var multi *manager.MultiError
+	if !errors.As(err, &multi) || multi.Errors["n1"] == nil || len(multi.Errors) != 1 {
+		t.Fatalf("expected the failure of n1, got %v", err)
+	}
+	if len(results) != 3 || results[2].Err != nil {
+		t.Fatalf("expected all nodes to be restarted, got %v", results)
+	}
+}
+
+func TestRollingRestartMaxUnavailable(t *testing.T) {
+	ctx := context.Background()
+	nodes := []string{"n1", "n2", "n3", "n4"}
+	f := newFleet(nodes...)
+	f.Node("n4").SetUnitState("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+
+	results, err := orchestration.RollingRestart(ctx, f, "app.service", nodes,
+		orchestration.WithBatchSize(2), orchestration.WithMaxUnavailable(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// n4 being down leaves room for one more node until it is restarted
+	got := batches(results)
+	want := map[string]int{"n1": 1, "n2": 2, "n3": 3, "n4": 3}
+	for name, batch := range want {
+		if got[name] != batch {
+			t.Errorf("expected %s in batch %d, got %v", name, batch, got)
+		}
+	}
+
+	f.Node("n1").SetUnitState("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.Node("n2").SetUnitState("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	_, err = orchestration.RollingRestart(ctx, f, "app.service", []string{"n1", "n2", "n3"},
+		orchestration.WithMaxUnavailable(1))
+	if !errors.Is(err, orchestration.ErrAborted) {
+		t.Fatalf("expected ErrAborted with too many nodes down, got %v", err)
+	}
+}
+
+func TestRollingRestartPerNodeSerial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	f := newFleet("n1", "n2")
+
+	// another operation holds n2
+	leave, err := f.NodeGate().Enter(ctx, "n2", 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer leave()
+
+	results, err := orchestration.RollingRestart(ctx, f, "app.service", []string{"n1", "n2"},
+		orchestration.WithBatchSize(2), orchestration.WithPerNodeSerial())
+	var multi *manager.MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 1 || !errors.Is(multi.Errors["n2"], context.DeadlineExceeded) {
+		t.Fatalf("expected n2 to wait for the other operation, got %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil {
+		t.Fatalf("expected n1 to be restarted, got %v", results)
+	}
+}
@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package reconcile compares a desired state of units on the nodes of a
+// cluster to the live state read through a manager.ManagerAPI and brings
+// the cluster in line with it.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// UnitState is the desired state of a unit on a node.
+type UnitState struct {
+	// Enabled is whether the unit file is enabled.
+	Enabled bool
+	// Active is whether the unit is started.
+	Active bool
+}
+
+// Spec is the desired state of the cluster, the states of units keyed by
+// unit name and node name. Units and nodes not in the spec are left alone.
+type Spec map[string]map[string]UnitState
+
+// Action is an operation of a Plan.
+type Action string
+
+// Actions of a Plan, in the order they are applied to a unit on a node.
+const (
+	ActionEnable  Action = "enable"
+	ActionDisable Action = "disable"
+	ActionStart   Action = "start"
+	ActionStop    Action = "stop"
+)
+
+// Step is an action on a unit on a node.
+type Step struct {
+	Node   string
+	Unit   string
+	Action Action
+}
+
+func (s Step) String() string {
+	return fmt.Sprintf("%s %s on node %s", s.Action, s.Unit, s.Node)
+}
+
+// Plan is the list of steps bringing the cluster to the desired state,
+// sorted by node and unit name. A unit is enabled or disabled before it is
+// started or stopped.
+type Plan []Step
+
+// Diff reads the state of the units of spec on their nodes and returns the
+// steps to bring them to the desired state. A unit which is not loaded is
+// taken as disabled and stopped. If the state of units cannot be read on
+// some nodes, e.g. because they are offline, the steps for the other nodes
+// are returned along with a *manager.MultiError with a *manager.NodeError
+// for each of these nodes.
+func Diff(ctx context.Context, m manager.ManagerAPI, spec Spec) (Plan, error) {
+	byNode := make(map[string][]string)
+	for unit, nodes := range spec {
+		for name := range nodes {
+			byNode[name] = append(byNode[name], unit)
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		plan   Plan
+		failed = &manager.MultiError{Op: "read unit states"}
+	)
+	for name, units := range byNode {
+		sort.Strings(units)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			steps, err := diffNode(ctx, m, spec, name, units)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed.Add(name, err)
+				return
+			}
+			plan = append(plan, steps...)
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(plan, func(i, j int) bool {
+		if plan[i].Node != plan[j].Node {
+			return plan[i].Node < plan[j].Node
+		}
+		return plan[i].Unit < plan[j].Unit
+	})
+	return plan, failed.ErrorOrNil()
+}
+
+func diffNode(ctx context.Context, m manager.ManagerAPI, spec Spec, name string, units []string) ([]Step, error) {
+	n, err := m.GetNode(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var steps []Step
+	for _, unit := range units {
+		actual, err := readState(ctx, n, unit)
+		if err != nil {
+			return nil, err
+		}
+		desired := spec[unit][name]
+		switch {
+		case desired.Enabled && !actual.Enabled:
+			steps = append(steps, Step{Node: name, Unit: unit, Action: ActionEnable})
+		case !desired.Enabled && actual.Enabled:
+			steps = append(steps, Step{Node: name, Unit: unit, Action: ActionDisable})
+		}
+		switch {
+		case desired.Active && !actual.Active:
+			steps = append(steps, Step{Node: name, Unit: unit, Action: ActionStart})
+		case !desired.Active && actual.Active:
+			steps = append(steps, Step{Node: name, Unit: unit, Action: ActionStop})
+		}
+	}
+	return steps, nil
+}
+
+// readState reads the state of unit on n, a unit which is not loaded is
+// disabled and stopped.
+func readState(ctx context.Context, n manager.NodeAPI, unit string) (UnitState, error) {
+	var state UnitState
+	fileState, err := n.GetUnitFileState(ctx, unit)
+	if err != nil && !errors.Is(err, common.ErrNoSuchUnit) {
+		return state, err
+	}
+	state.Enabled = fileState == node.UnitFileEnabled || fileState == node.UnitFileEnabledRuntime
+
+	activeState, err := n.GetUnitActiveState(ctx, unit)
+	if err != nil && !errors.Is(err, common.ErrNoSuchUnit) {
+		return state, err
+	}
+	state.Active = activeState.IsActive()
+	return state, nil
+}
+
+// ApplyOption configures Plan.Apply.
+type ApplyOption func(*applyOptions)
+
+type applyOptions struct {
+	mode    string
+	runtime bool
+}
+
+// WithJobMode sets the mode the start and stop jobs are queued with,
+// node.ModeReplace by default.
+func WithJobMode(mode string) ApplyOption {
+	return func(o *applyOptions) {
+		o.mode = mode
+	}
+}
+
+// WithRuntime enables and disables unit files only until the next reboot
+// of the nodes.
+func WithRuntime() ApplyOption {
+	return func(o *applyOptions) {
+		o.runtime = true
+	}
+}
+
+// Change is the outcome of a step applied by Plan.Apply.
+type Change struct {
+	Step
+	// FileChanges are the changes of the unit files by enabling or
+	// disabling the unit.
+	FileChanges []node.UnitFileChange
+	// Err is the error of the step, nil if it succeeded.
+	Err error
+}
+
+// Apply applies the steps of the plan, the nodes concurrently and the steps
+// of each node in order, waiting for the start and stop jobs to finish. On
+// a node the steps after a failed one are skipped. The changes of the
+// steps applied are returned in the order of the plan. The error is a
+// *manager.MultiError with a *manager.NodeError for each failed node.
+func (p Plan) Apply(ctx context.Context, m manager.ManagerAPI, opts ...ApplyOption) ([]Change, error) {
+	o := applyOptions{mode: node.ModeReplace}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	byNode := make(map[string][]int)
+	for i, s := range p {
+		byNode[s.Node] = append(byNode[s.Node], i)
+	}
+
+	changes := make([]*Change, len(p))
+	failed := &manager.MultiError{Op: "reconcile units"}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, steps := range byNode {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := m.GetNode(ctx, name)
+			if err != nil {
+				mu.Lock()
+				failed.Add(name, err)
+				mu.Unlock()
+				return
+			}
+			for _, i := range steps {
+				c := apply(ctx, n, p[i], o)
+				changes[i] = &c
+				if c.Err != nil {
+					mu.Lock()
+					failed.Add(name, c.Err)
+					mu.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	applied := make([]Change, 0, len(p))
+	for _, c := range changes {
+		if c != nil {
+			applied = append(applied, *c)
+		}
+	}
+	return applied, failed.ErrorOrNil()
+}
+
+func apply(ctx context.Context, n manager.NodeAPI, s Step, o applyOptions) Change {
+	c := Change{Step: s}
+	switch s.Action {
+	case ActionEnable:
+		var result node.EnableUnitFilesResult
+		result, c.Err = n.EnableUnitFiles(ctx, []string{s.Unit}, o.runtime, false)
+		c.FileChanges = result.Changes
+	case ActionDisable:
+		c.FileChanges, c.Err = n.DisableUnitFiles(ctx, []string{s.Unit}, o.runtime)
+	case ActionStart:
+		c.Err = n.StartUnitAndWait(ctx, s.Unit, o.mode)
+	case ActionStop:
+		c.Err = n.StopUnitAndWait(ctx, s.Unit, o.mode)
+	default:
+		c.Err = fmt.Errorf("unknown action %q", s.Action)
+	}
+	return c
+}
+
+// Reconcile computes the plan for spec with Diff and applies it. The
+// changes are returned together with the error of reading or applying on
+// any node.
+func Reconcile(ctx context.Context, m manager.ManagerAPI, spec Spec, opts ...ApplyOption) ([]Change, error) {
+	plan, diffErr := Diff(ctx, m, spec)
+	changes, applyErr := plan.Apply(ctx, m, opts...)
+	return changes, errors.Join(diffErr, applyErr)
+}
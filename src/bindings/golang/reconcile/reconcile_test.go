@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package reconcile_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/reconcile"
+)
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	n2 := f.AddNode("n2")
+	n2.AddUnit("app.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	n2.AddUnit("legacy.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	if _, err := n2.EnableUnitFiles(ctx, []string{"app.service", "legacy.service"}, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := reconcile.Spec{
+		"app.service": {
+			"n1": {Enabled: true, Active: true},
+			"n2": {Enabled: true, Active: true},
+		},
+		"legacy.service": {
+			"n2": {},
+		},
+	}
+	plan, err := reconcile.Diff(ctx, f, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := reconcile.Plan{
+		{Node: "n1", Unit: "app.service", Action: reconcile.ActionEnable},
+		{Node: "n1", Unit: "app.service", Action: reconcile.ActionStart},
+		{Node: "n2", Unit: "legacy.service", Action: reconcile.ActionDisable},
+		{Node: "n2", Unit: "legacy.service", Action: reconcile.ActionStop},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("expected plan %v, got %v", want, plan)
+	}
+
+	changes, err := plan.Apply(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != len(plan) {
+		t.Fatalf("expected %d changes, got %v", len(plan), changes)
+	}
+	for i, c := range changes {
+		if c.Step != plan[i] || c.Err != nil {
+			t.Errorf("unexpected change %+v", c)
+		}
+	}
+	if len(changes[0].FileChanges) != 1 || changes[0].FileChanges[0].Type != node.ChangeSymlink {
+		t.Errorf("expected the symlink of enabling, got %v", changes[0].FileChanges)
+	}
+	if !f.Node("n1").UnitFileEnabled("app.service") || f.Node("n2").UnitFileEnabled("legacy.service") {
+		t.Error("expected the unit files to be enabled as specified")
+	}
+	if u, _ := f.Node("n2").Unit("legacy.service"); u.ActiveState.IsActive() {
+		t.Errorf("expected legacy.service to be stopped, got %s", u.ActiveState)
+	}
+
+	plan, err = reconcile.Diff(ctx, f, spec)
+	if err != nil || len(plan) != 0 {
+		t.Fatalf("expected nothing left to do, got %v, %v", plan, err)
+	}
+}
+
+func TestReconcileFailures(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("app.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.Node("n1").AddUnit("other.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.Node("n1").SetJobResult("app.service", job.ResultFailed)
+	f.AddNode("n2")
+	f.SetNodeStatus("n2", node.StatusOffline)
+
+	spec := reconcile.Spec{
+		"app.service":   {"n1": {Active: true}, "n2": {Active: true}},
+		"other.service": {"n1": {Active: true}},
+	}
+	changes, err := reconcile.Reconcile(ctx, f, spec)
+	var multi *manager.MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("expected a MultiError, got %v", err)
+	}
+	if !errors.Is(err, common.ErrNodeOffline) {
+		t.Errorf("expected the offline node to fail, got %v", err)
+	}
+	// the failed start of app.service skips other.service on n1
+	if len(changes) != 1 || changes[0].Unit != "app.service" || changes[0].Err == nil {
+		t.Fatalf("expected only the failed start, got %+v", changes)
+	}
+}
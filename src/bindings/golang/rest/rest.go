@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package rest serves a small REST API with JSON bodies over a
+// manager.ManagerAPI, e.g. for dashboards or curl based automation:
+//
+//	GET  /nodes                              the nodes, see manager.NodeInfo
+//	GET  /nodes/{node}/units                 the units of a node, see node.UnitInfo
+//	POST /nodes/{node}/units/{unit}/{action} start, stop, restart or reload a unit
+//	GET  /events                             the unit changes as server-sent events
+//
+// Unit actions wait for their job and take the job mode in the query
+// parameter mode, replace by default. /events takes the query parameters
+// node and units, a comma separated list, and watches all nodes and units
+// by default. Errors are reported with a status code derived from the
+// sentinel errors of package common, e.g. 404 for common.ErrNoSuchNode, and
+// a body {"error": message}.
+//
+// The handler does not authenticate clients, wrap it in a handler doing so
+// unless it is only reachable from a trusted network.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// NewHandler returns an http.Handler serving the REST API for api.
+func NewHandler(api manager.ManagerAPI) http.Handler {
+	h := &handler{api: api}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /nodes", h.nodes)
+	mux.HandleFunc("GET /nodes/{node}/units", h.units)
+	mux.HandleFunc("POST /nodes/{node}/units/{unit}/{action}", h.unitAction)
+	mux.HandleFunc("GET /events", h.events)
+	return mux
+}
+
+type handler struct {
+	api manager.ManagerAPI
+}
+
+// jobResponse is the body of the response to a unit action.
+type jobResponse struct {
+	JobPath string `json:"jobPath,omitempty"`
+	Result  string `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *handler) nodes(w http.ResponseWriter, r *http.Request) {
+	nodes, err := h.api.ListNodes(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if nodes == nil {
+		nodes = []manager.NodeInfo{}
+	}
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func (h *handler) units(w http.ResponseWriter, r *http.Request) {
+	n, err := h.api.GetNode(r.Context(), r.PathValue("node"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	units, err := n.ListUnits(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if units == nil {
+		units = []node.UnitInfo{}
+	}
+	writeJSON(w, http.StatusOK, units)
+}
+
+func (h *handler) unitAction(w http.ResponseWriter, r *http.Request) {
+	var op func(manager.NodeAPI, context.Context, string, string) <-chan node.JobResult
+	switch r.PathValue("action") {
+	case "start":
+		op = manager.NodeAPI.StartUnitAsync
+	case "stop":
+		op = manager.NodeAPI.StopUnitAsync
+	case "restart":
+		op = manager.NodeAPI.RestartUnitAsync
+	case "reload":
+		op = manager.NodeAPI.ReloadUnitAsync
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{fmt.Sprintf("unknown action %q", r.PathValue("action"))})
+		return
+	}
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = node.ModeReplace
+	}
+
+	n, err := h.api.GetNode(r.Context(), r.PathValue("node"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res := <-op(n, r.Context(), r.PathValue("unit"), mode)
+	if res.Err != nil && res.Result == "" {
+		writeError(w, res.Err)
+		return
+	}
+	resp := jobResponse{JobPath: string(res.Path), Result: res.Result}
+	if res.Err != nil {
+		// the job ran but failed, e.g. as the unit could not be started
+		resp.Error = res.Err.Error()
+		writeJSON(w, http.StatusInternalServerError, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// event is the data of a server-sent event, named by the type of the
+// monitor event.
+type event struct {
+	Node        string                 `json:"node"`
+	Unit        string                 `json:"unit"`
+	Reason      string                 `json:"reason,omitempty"`
+	ActiveState node.ActiveState       `json:"activeState,omitempty"`
+	SubState    node.SubState          `json:"subState,omitempty"`
+	Interface   string                 `json:"interface,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+}
+
+func (h *handler) events(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	nodeName, units := r.URL.Query().Get("node"), []string{common.SYMBOL_WILDCARD}
+	if nodeName == "" {
+		nodeName = common.SYMBOL_WILDCARD
+	}
+	if u := r.URL.Query().Get("units"); u != "" {
+		units = strings.Split(u, ",")
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{"streaming is not supported"})
+		return
+	}
+
+	mon, err := h.api.CreateMonitor(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer mon.Close(context.Background())
+	if len(units) == 1 {
+		_, err = mon.Subscribe(ctx, nodeName, units[0])
+	} else {
+		_, err = mon.SubscribeList(ctx, nodeName, units)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := mon.Events()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			name, data, ok := encodeEvent(e)
+			if !ok {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// encodeEvent returns the name and data of the server-sent event of e,
+// false for events not concerning a unit.
+func encodeEvent(e monitor.Event) (string, []byte, bool) {
+	var name string
+	ev := event{Node: e.NodeName(), Unit: e.UnitName()}
+	switch e := e.(type) {
+	case monitor.UnitNew:
+		name, ev.Reason = "UnitNew", e.Reason
+	case monitor.UnitRemoved:
+		name, ev.Reason = "UnitRemoved", e.Reason
+	case monitor.UnitStateChanged:
+		name, ev.ActiveState, ev.SubState, ev.Reason = "UnitStateChanged", e.ActiveState, e.SubState, e.Reason
+	case monitor.UnitPropertiesChanged:
+		name, ev.Interface, ev.Properties = "UnitPropertiesChanged", e.Interface, variant.Values(e.Properties)
+	default:
+		return "", nil, false
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return "", nil, false
+	}
+	return name, data, true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err with the status code of its sentinel error.
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, common.ErrNoSuchNode), errors.Is(err, common.ErrNoSuchUnit):
+		code = http.StatusNotFound
+	case errors.Is(err, common.ErrNodeOffline), errors.Is(err, common.ErrNodeCircuitOpen),
+		errors.Is(err, common.ErrServiceUnknown), errors.Is(err, manager.ErrNotConnected),
+		errors.Is(err, manager.ErrDisconnected):
+		code = http.StatusServiceUnavailable
+	case errors.Is(err, common.ErrPermissionDenied):
+		code = http.StatusForbidden
+	case errors.Is(err, common.ErrInvalidArgs):
+		code = http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		code = http.StatusGatewayTimeout
+	}
+	writeJSON(w, code, errorResponse{err.Error()})
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package rest_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/rest"
+)
+
+func newServer(t *testing.T) (*managertest.Manager, *httptest.Server) {
+	f := managertest.New()
+	n1 := f.AddNode("node1")
+	n1.AddUnit("nginx.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	n1.AddUnit("sshd.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.AddNode("node2")
+	f.SetNodeStatus("node2", managertest.NodeOffline)
+
+	srv := httptest.NewServer(rest.NewHandler(f))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// do sends a request and decodes the JSON body of the response into v.
+func do(t *testing.T, method string, url string, v interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: failed to decode body: %v", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestNodesAndUnits(t *testing.T) {
+	_, srv := newServer(t)
+
+	var nodes []map[string]string
+	if code := do(t, http.MethodGet, srv.URL+"/nodes", &nodes); code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d", code)
+	}
+	if len(nodes) != 2 || nodes[0]["name"] != "node1" || nodes[1]["status"] != "offline" {
+		t.Errorf("unexpected nodes %v", nodes)
+	}
+
+	var units []map[string]interface{}
+	if code := do(t, http.MethodGet, srv.URL+"/nodes/node1/units", &units); code != http.StatusOK {
+		t.Fatalf("GET /nodes/node1/units returned %d", code)
+	}
+	names := map[interface{}]interface{}{}
+	for _, u := range units {
+		names[u["name"]] = u["activeState"]
+	}
+	if len(names) != 2 || names["nginx.service"] != "active" || names["sshd.service"] != "inactive" {
+		t.Errorf("unexpected units %v", units)
+	}
+
+	var body map[string]string
+	if code := do(t, http.MethodGet, srv.URL+"/nodes/node3/units", &body); code != http.StatusNotFound || body["error"] == "" {
+		t.Errorf("expected 404 with an error for an unknown node, got %d %v", code, body)
+	}
+}
+
+func TestDisconnected(t *testing.T) {
+	f, srv := newServer(t)
+	f.FailCall("ListNodes", fmt.Errorf("failed to list nodes: %w", manager.ErrDisconnected))
+
+	var body map[string]string
+	if code := do(t, http.MethodGet, srv.URL+"/nodes", &body); code != http.StatusServiceUnavailable || body["error"] == "" {
+		t.Errorf("expected 503 with an error while disconnected, got %d %v", code, body)
+	}
+}
+
+func TestUnitActions(t *testing.T) {
+	f, srv := newServer(t)
+
+	var resp map[string]string
+	if code := do(t, http.MethodPost, srv.URL+"/nodes/node1/units/sshd.service/start", &resp); code != http.StatusOK {
+		t.Fatalf("start returned %d %v", code, resp)
+	}
+	if resp["result"] != job.ResultDone || resp["jobPath"] == "" {
+		t.Errorf("unexpected response %v", resp)
+	}
+	if u, _ := f.Node("node1").Unit("sshd.service"); u.ActiveState != managertest.ActiveStateActive {
+		t.Errorf("sshd.service is %s after start", u.ActiveState)
+	}
+
+	f.Node("node1").SetJobResult("nginx.service", job.ResultFailed)
+	resp = nil
+	if code := do(t, http.MethodPost, srv.URL+"/nodes/node1/units/nginx.service/restart", &resp); code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for a failed job, got %d", code)
+	}
+	if resp["result"] != job.ResultFailed || resp["error"] == "" {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{"/nodes/node1/units/nginx.service/destroy", http.StatusNotFound},
+		{"/nodes/node3/units/nginx.service/stop", http.StatusNotFound},
+		{"/nodes/node1/units/missing.service/stop", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		var body map[string]string
+		if code := do(t, http.MethodPost, srv.URL+tt.url, &body); code != tt.want || body["error"] == "" {
+			t.Errorf("POST %s returned %d %v, want %d", tt.url, code, body, tt.want)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	f, srv := newServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?node=node1&units=sshd.service", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, ct)
+	}
+
+	// the headers are sent once the monitor has subscribed
+	f.Node("node1").SetUnitState("sshd.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+
+	var name string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if n, ok := strings.CutPrefix(line, "event: "); ok {
+			name = n
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var e map[string]string
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatal(err)
+		}
+		if name != "UnitStateChanged" || e["node"] != "node1" || e["unit"] != "sshd.service" || e["activeState"] != "active" {
+			t.Fatalf("unexpected event %s %v", name, e)
+		}
+		return
+	}
+	t.Fatalf("stream ended without events: %v", scanner.Err())
+}
@@ -0,0 +1,503 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package scheduler defers operations on the units of nodes to the
+// maintenance windows of the nodes, e.g. restarts after an update which
+// must not happen during business hours. Operations queued with Enqueue
+// are kept in a Store until a window of their node is open, Run executes
+// them then in the order they were queued:
+//
+//	w, err := scheduler.ParseWindow("0 2 * * SAT", 4*time.Hour, berlin)
+//	...
+//	s, err := scheduler.New(ctx, m, scheduler.Windows{"*": {w}},
+//		scheduler.WithStore(scheduler.NewFileStore("/var/lib/app/pending.json")))
+//	...
+//	_, err = s.Enqueue(ctx, "node1", "nginx.service", scheduler.ActionRestart)
+//	err = s.Run(ctx)
+//
+// Operations failing because their node is offline or the controller is not
+// reachable stay queued for the next window, other failures are reported
+// and the operation is dropped.
+package scheduler
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+)
+
+// DefaultPollInterval is the interval Run checks for open windows in unless
+// WithPollInterval is given.
+const DefaultPollInterval = 30 * time.Second
+
+var (
+	// ErrNoWindow is returned by Enqueue for nodes without a maintenance
+	// window.
+	ErrNoWindow = errors.New("no maintenance window")
+	// ErrUnknownOperation is returned by Cancel for operations which are
+	// not pending.
+	ErrUnknownOperation = errors.New("unknown operation")
+)
+
+// Action is what an Operation does with its unit.
+type Action string
+
+// Actions of an Operation.
+const (
+	ActionStart   Action = "start"
+	ActionStop    Action = "stop"
+	ActionRestart Action = "restart"
+	ActionReload  Action = "reload"
+	ActionEnable  Action = "enable"
+	ActionDisable Action = "disable"
+)
+
+func (a Action) valid() bool {
+	switch a {
+	case ActionStart, ActionStop, ActionRestart, ActionReload, ActionEnable, ActionDisable:
+		return true
+	}
+	return false
+}
+
+// Operation is an action on a unit of a node waiting for a maintenance
+// window of the node.
+type Operation struct {
+	// ID identifies the operation, the IDs increase in the order the
+	// operations were queued.
+	ID     uint64    `json:"id"`
+	Node   string    `json:"node"`
+	Unit   string    `json:"unit"`
+	Action Action    `json:"action"`
+	Queued time.Time `json:"queued"`
+}
+
+func (op Operation) String() string {
+	return fmt.Sprintf("%s %s on node %s", op.Action, op.Unit, op.Node)
+}
+
+// Result is the outcome of an executed operation.
+type Result struct {
+	Operation
+	// Err is the error of the operation, nil if it succeeded.
+	Err error
+}
+
+// Windows are the maintenance windows keyed by node name. The windows
+// keyed by common.SYMBOL_WILDCARD apply to the nodes without windows of
+// their own.
+type Windows map[string][]Window
+
+func (w Windows) of(name string) []Window {
+	if windows, ok := w[name]; ok {
+		return windows
+	}
+	return w[common.SYMBOL_WILDCARD]
+}
+
+// Store persists the pending operations, so that they survive restarts of
+// the program. Implementations must be safe for concurrent use.
+type Store interface {
+	// Load returns the pending operations.
+	Load(ctx context.Context) ([]Operation, error)
+	// Save replaces the pending operations.
+	Save(ctx context.Context, ops []Operation) error
+}
+
+// MemoryStore keeps the pending operations in memory. It is the store of a
+// Scheduler unless WithStore is given.
+type MemoryStore struct {
+	mu  sync.Mutex
+	ops []Operation
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Load returns a copy of the pending operations.
+func (s *MemoryStore) Load(ctx context.Context) ([]Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ops), nil
+}
+
+// Save replaces the pending operations.
+func (s *MemoryStore) Save(ctx context.Context, ops []Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = slices.Clone(ops)
+	return nil
+}
+
+// FileStore keeps the pending operations in a JSON file. The file is
+// replaced atomically on each change.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a FileStore for the file at path, which is created
+// on the first change if it does not exist.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Path returns the path of the file.
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+// Load reads the pending operations from the file.
+func (s *FileStore) Load(ctx context.Context) ([]Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending operations: %w", err)
+	}
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("failed to decode pending operations in %s: %w", s.path, err)
+	}
+	return ops, nil
+}
+
+// Save writes the pending operations to the file.
+func (s *FileStore) Save(ctx context.Context, ops []Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ops == nil {
+		ops = []Operation{}
+	}
+	data, err := json.MarshalIndent(ops, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pending operations: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write pending operations: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write pending operations: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write pending operations: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write pending operations: %w", err)
+	}
+	return nil
+}
+
+// Option configures a Scheduler.
+type Option func(*options)
+
+type options struct {
+	store        Store
+	mode         string
+	runtime      bool
+	pollInterval time.Duration
+	results      func(Result)
+}
+
+// WithStore sets the store of the pending operations, a MemoryStore by
+// default.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithJobMode sets the mode the jobs of the operations are queued with,
+// node.ModeReplace by default.
+func WithJobMode(mode string) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithRuntime enables and disables unit files only until the next reboot
+// of the nodes.
+func WithRuntime() Option {
+	return func(o *options) {
+		o.runtime = true
+	}
+}
+
+// WithPollInterval sets the interval Run checks for open windows in,
+// DefaultPollInterval by default.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.pollInterval = interval
+		}
+	}
+}
+
+// WithResultHandler passes the result of each operation executed by Run to
+// fn, e.g. to log failures. fn must not block.
+func WithResultHandler(fn func(Result)) Option {
+	return func(o *options) {
+		o.results = fn
+	}
+}
+
+// Scheduler executes the pending operations on the nodes of a
+// manager.ManagerAPI within their maintenance windows. Its methods are safe
+// for concurrent use.
+type Scheduler struct {
+	api     manager.ManagerAPI
+	windows Windows
+	o       options
+
+	// ticking serializes Tick, so that no operation is executed twice
+	ticking sync.Mutex
+	mu      sync.Mutex
+	pending []Operation
+	lastID  uint64
+}
+
+// New returns a Scheduler for the nodes of api with the given windows, with
+// the operations pending in the store.
+func New(ctx context.Context, api manager.ManagerAPI, windows Windows, opts ...Option) (*Scheduler, error) {
+	o := options{mode: node.ModeReplace, pollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryStore()
+	}
+
+	pending, err := o.store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(pending, func(a, b Operation) int { return cmp.Compare(a.ID, b.ID) })
+	s := &Scheduler{api: api, windows: windows, o: o, pending: pending}
+	if len(pending) > 0 {
+		s.lastID = pending[len(pending)-1].ID
+	}
+	return s, nil
+}
+
+// Enqueue queues action on unit of the named node for its next maintenance
+// window and persists it. It fails with ErrNoWindow if the node has none.
+func (s *Scheduler) Enqueue(ctx context.Context, nodeName string, unit string, action Action) (Operation, error) {
+	if !action.valid() {
+		return Operation{}, fmt.Errorf("failed to queue %s %s on node %s: unknown action: %w", action, unit, nodeName, common.ErrInvalidArgs)
+	}
+	if len(s.windows.of(nodeName)) == 0 {
+		return Operation{}, fmt.Errorf("failed to queue %s %s on node %s: %w", action, unit, nodeName, ErrNoWindow)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op := Operation{ID: s.lastID + 1, Node: nodeName, Unit: unit, Action: action, Queued: time.Now()}
+	pending := append(slices.Clone(s.pending), op)
+	if err := s.o.store.Save(ctx, pending); err != nil {
+		return Operation{}, fmt.Errorf("failed to queue %s: %w", op, err)
+	}
+	s.lastID, s.pending = op.ID, pending
+	return op, nil
+}
+
+// Cancel removes the pending operation with the given ID. It fails with
+// ErrUnknownOperation if the operation is not pending, e.g. as it was
+// executed already.
+func (s *Scheduler) Cancel(ctx context.Context, id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.pending, func(op Operation) bool { return op.ID == id })
+	if i < 0 {
+		return fmt.Errorf("failed to cancel operation %d: %w", id, ErrUnknownOperation)
+	}
+	pending := slices.Delete(slices.Clone(s.pending), i, i+1)
+	if err := s.o.store.Save(ctx, pending); err != nil {
+		return fmt.Errorf("failed to cancel %s: %w", s.pending[i], err)
+	}
+	s.pending = pending
+	return nil
+}
+
+// Pending returns the pending operations in the order they were queued.
+func (s *Scheduler) Pending() []Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.pending)
+}
+
+// NextWindow returns the times the next maintenance window of the named
+// node opens and closes, the current one if a window is open at t. It
+// returns false if the node has no window which ever opens.
+func (s *Scheduler) NextWindow(nodeName string, t time.Time) (time.Time, time.Time, bool) {
+	var start, end time.Time
+	for _, w := range s.windows.of(nodeName) {
+		ws, we := w.Next(t)
+		if !ws.IsZero() && (start.IsZero() || ws.Before(start)) {
+			start, end = ws, we
+		}
+	}
+	return start, end, !start.IsZero()
+}
+
+// openUntil returns the time the open window of the named node closes, the
+// latest of them if several are open at t, false if none is.
+func (s *Scheduler) openUntil(nodeName string, t time.Time) (time.Time, bool) {
+	var until time.Time
+	for _, w := range s.windows.of(nodeName) {
+		if end, ok := w.Open(t); ok && end.After(until) {
+			until = end
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// Run executes the pending operations whenever a window of their node is
+// open, checking every poll interval, until ctx is done. It returns nil
+// then, or the error of the store if the executed operations could not be
+// removed from it.
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.o.pollInterval)
+	defer ticker.Stop()
+	for {
+		results, err := s.Tick(ctx, time.Now())
+		if s.o.results != nil {
+			for _, r := range results {
+				s.o.results(r)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick executes the pending operations of the nodes with a window open at
+// now and returns their results in the order the operations were queued.
+// The nodes are handled concurrently and the operations of each node in
+// order, no operation is started after the window closed. Operations
+// failing as their node or the controller is not reachable stay pending.
+// Run calls Tick every poll interval, programs driving the scheduler by
+// their own timer or a simulated clock call it directly.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) ([]Result, error) {
+	s.ticking.Lock()
+	defer s.ticking.Unlock()
+
+	byNode := make(map[string][]Operation)
+	for _, op := range s.Pending() {
+		byNode[op.Node] = append(byNode[op.Node], op)
+	}
+
+	started := time.Now()
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []Result
+	)
+	for name, ops := range byNode {
+		until, ok := s.openUntil(name, now)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, op := range ops {
+				if !now.Add(time.Since(started)).Before(until) {
+					return
+				}
+				err := s.execute(ctx, op)
+				if deferred(ctx, err) {
+					return
+				}
+				mu.Lock()
+				results = append(results, Result{Operation: op, Err: err})
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(results) == 0 {
+		return nil, nil
+	}
+	slices.SortFunc(results, func(a, b Result) int { return cmp.Compare(a.ID, b.ID) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := slices.DeleteFunc(slices.Clone(s.pending), func(op Operation) bool {
+		_, done := slices.BinarySearchFunc(results, op.ID, func(r Result, id uint64) int { return cmp.Compare(r.ID, id) })
+		return done
+	})
+	// the results are reported even if they cannot be persisted, the
+	// operations are executed again after a restart then
+	s.pending = pending
+	if err := s.o.store.Save(context.WithoutCancel(ctx), pending); err != nil {
+		return results, fmt.Errorf("failed to remove executed operations: %w", err)
+	}
+	return results, nil
+}
+
+// deferred reports whether the operation failed with err is to be retried
+// in a later window, as its node or the controller was not reachable or the
+// Manager was shutting down.
+func deferred(ctx context.Context, err error) bool {
+	return ctx.Err() != nil ||
+		errors.Is(err, common.ErrNodeOffline) ||
+		errors.Is(err, common.ErrNodeCircuitOpen) ||
+		errors.Is(err, common.ErrServiceUnknown) ||
+		errors.Is(err, manager.ErrNotConnected) ||
+		errors.Is(err, manager.ErrDisconnected) ||
+		errors.Is(err, manager.ErrShuttingDown) ||
+		errors.Is(err, dbus.ErrClosed)
+}
+
+func (s *Scheduler) execute(ctx context.Context, op Operation) error {
+	n, err := s.api.GetNode(ctx, op.Node)
+	if err != nil {
+		return err
+	}
+	switch op.Action {
+	case ActionStart:
+		return n.StartUnitAndWait(ctx, op.Unit, s.o.mode)
+	case ActionStop:
+		return n.StopUnitAndWait(ctx, op.Unit, s.o.mode)
+	case ActionRestart:
+		return n.RestartUnitAndWait(ctx, op.Unit, s.o.mode)
+	case ActionReload:
+		return n.ReloadUnitAndWait(ctx, op.Unit, s.o.mode)
+	case ActionEnable:
+		_, err = n.EnableUnitFiles(ctx, []string{op.Unit}, s.o.runtime, false)
+		return err
+	case ActionDisable:
+		_, err = n.DisableUnitFiles(ctx, []string{op.Unit}, s.o.runtime)
+		return err
+	}
+	return fmt.Errorf("failed to %s: unknown action: %w", op, common.ErrInvalidArgs)
+}
@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/internal/testbus"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/job"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/scheduler"
+)
+
+func date(day int, hour int, minute int) time.Time {
+	return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		// 2024-01-01 is a Monday
+		{"0 2 * * SAT", date(1, 0, 0), date(6, 2, 0)},
+		{"*/15 * * * *", date(1, 10, 7), date(1, 10, 15)},
+		{"*/15 * * * *", date(1, 10, 15), date(1, 10, 30)},
+		{"0 22-23/1 * * mon-fri", date(5, 23, 30), date(8, 22, 0)},
+		// the day of month or the day of week
+		{"30 9 1,15 * 1", date(2, 0, 0), date(8, 9, 30)},
+		{"0 0 29 feb *", date(1, 0, 0), time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", date(1, 0, 0), date(7, 0, 0)},
+		{"0 0 30 2 *", date(1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := scheduler.ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v after %v, got %v", tt.spec, tt.want, tt.from, got)
+		}
+	}
+
+	for _, spec := range []string{"60 * * * *", "* * *", "5-1 * * * *", "* * * foo *", "*/0 * * * *"} {
+		if _, err := scheduler.ParseSchedule(spec); err == nil {
+			t.Errorf("%s: expected an error", spec)
+		}
+	}
+}
+
+func TestWindowLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	w, err := scheduler.ParseWindow("0 2 * * *", 2*time.Hour, tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 03:00 in Tokyo
+	if end, ok := w.Open(date(1, 18, 0)); !ok || !end.Equal(date(1, 19, 0)) {
+		t.Errorf("expected the window open until 19:00 UTC, got %v, %v", end, ok)
+	}
+	// 05:00 in Tokyo
+	if _, ok := w.Open(date(1, 20, 0)); ok {
+		t.Error("expected the window closed")
+	}
+	if start, end := w.Next(date(1, 20, 0)); !start.Equal(date(2, 17, 0)) || !end.Equal(date(2, 19, 0)) {
+		t.Errorf("unexpected next window %v - %v", start, end)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("sshd.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.AddNode("n2").AddUnit("nginx.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	f.SetNodeStatus("n2", managertest.NodeOffline)
+
+	w, err := scheduler.ParseWindow("0 2 * * *", time.Hour, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows := scheduler.Windows{"n1": {w}, "n2": {w}}
+	store := scheduler.NewFileStore(filepath.Join(t.TempDir(), "pending.json"))
+	s, err := scheduler.New(ctx, f, windows, scheduler.WithStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Enqueue(ctx, "n1", "sshd.service", scheduler.ActionStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Enqueue(ctx, "n2", "nginx.service", scheduler.ActionRestart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Enqueue(ctx, "n3", "nginx.service", scheduler.ActionRestart); !errors.Is(err, scheduler.ErrNoWindow) {
+		t.Fatalf("expected ErrNoWindow for a node without window, got %v", err)
+	}
+
+	// nothing is executed outside the windows
+	if results, err := s.Tick(ctx, date(1, 12, 0)); err != nil || len(results) != 0 {
+		t.Fatalf("expected no results outside the window, got %v, %v", results, err)
+	}
+
+	// the pending operations survive a restart
+	s, err = scheduler.New(ctx, f, windows, scheduler.WithStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := s.Pending(); len(pending) != 2 || pending[0].Unit != "sshd.service" {
+		t.Fatalf("unexpected pending operations %v", pending)
+	}
+
+	// the operation of the offline node waits for the next window
+	results, err := s.Tick(ctx, date(2, 2, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Node != "n1" || results[0].Err != nil {
+		t.Fatalf("unexpected results %v", results)
+	}
+	if u, _ := f.Node("n1").Unit("sshd.service"); u.ActiveState != managertest.ActiveStateActive {
+		t.Errorf("sshd.service is %s", u.ActiveState)
+	}
+	if pending := s.Pending(); len(pending) != 1 || pending[0].Node != "n2" {
+		t.Fatalf("unexpected pending operations %v", pending)
+	}
+
+	f.SetNodeStatus("n2", managertest.NodeOnline)
+	f.Node("n2").SetJobResult("nginx.service", job.ResultFailed)
+	results, err = s.Tick(ctx, date(3, 2, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected the failed restart, got %v", results)
+	}
+	if pending, _ := store.Load(ctx); len(pending) != 0 {
+		t.Fatalf("expected no pending operations, got %v", pending)
+	}
+	if err := s.Cancel(ctx, results[0].ID); !errors.Is(err, scheduler.ErrUnknownOperation) {
+		t.Fatalf("expected ErrUnknownOperation, got %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("sshd.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+
+	always, err := scheduler.ParseWindow("* * * * *", time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(chan scheduler.Result, 1)
+	s, err := scheduler.New(ctx, f, scheduler.Windows{"*": {always}},
+		scheduler.WithPollInterval(10*time.Millisecond),
+		scheduler.WithResultHandler(func(r scheduler.Result) { results <- r }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	op, err := s.Enqueue(ctx, "n1", "sshd.service", scheduler.ActionStop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-results:
+		if r.ID != op.ID || r.Err != nil {
+			t.Fatalf("unexpected result %v", r)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the operation")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTickDeferred(t *testing.T) {
+	ctx := context.Background()
+	always, err := scheduler.ParseWindow("* * * * *", time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fail := range []error{manager.ErrDisconnected, manager.ErrShuttingDown, dbus.ErrClosed, common.ErrServiceUnknown} {
+		f := managertest.New()
+		f.AddNode("n1").AddUnit("sshd.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+		f.FailCall("GetNode", fmt.Errorf("failed to get node n1: %w", fail))
+		s, err := scheduler.New(ctx, f, scheduler.Windows{"*": {always}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Enqueue(ctx, "n1", "sshd.service", scheduler.ActionStart); err != nil {
+			t.Fatal(err)
+		}
+		if results, err := s.Tick(ctx, date(1, 12, 0)); err != nil || len(results) != 0 {
+			t.Fatalf("%v: expected no results, got %v, %v", fail, results, err)
+		}
+		if pending := s.Pending(); len(pending) != 1 {
+			t.Fatalf("%v: expected the operation to stay pending, got %v", fail, pending)
+		}
+	}
+}
+
+func TestTickDisconnected(t *testing.T) {
+	ctx := context.Background()
+	address := testbus.Start(t)
+	controller := testbus.Connect(t, address)
+	testbus.RequestName(t, controller, common.BC_DBUS_INTERFACE)
+	backoff := manager.Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+	m, err := manager.NewManager(manager.WithBusAddress(address), manager.WithAutoReconnect(backoff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	always, err := scheduler.ParseWindow("* * * * *", time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := scheduler.New(ctx, m.API(), scheduler.Windows{"*": {always}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Enqueue(ctx, "n1", "sshd.service", scheduler.ActionRestart); err != nil {
+		t.Fatal(err)
+	}
+
+	// the controller leaves the bus while the restart is pending
+	states := m.ConnectionEvents()
+	if _, err := controller.ReleaseName(common.BC_DBUS_INTERFACE); err != nil {
+		t.Fatal(err)
+	}
+	for state := range states {
+		if state == manager.Reconnecting {
+			break
+		}
+	}
+	if results, err := s.Tick(ctx, time.Now()); err != nil || len(results) != 0 {
+		t.Fatalf("expected no results while disconnected, got %v, %v", results, err)
+	}
+	if pending := s.Pending(); len(pending) != 1 || pending[0].Unit != "sshd.service" {
+		t.Fatalf("expected the restart to stay pending, got %v", pending)
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is a recurring maintenance window of a node. It opens at the
+// times of its schedule by the wall clock of its location and stays open
+// for its duration.
+type Window struct {
+	// Schedule is when the window opens.
+	Schedule *Schedule
+	// Duration is how long the window stays open.
+	Duration time.Duration
+	// Location is the time zone of the node the schedule is evaluated in,
+	// time.Local if nil.
+	Location *time.Location
+}
+
+// ParseWindow returns a Window opening at the times of the cron expression
+// spec in loc and staying open for d, e.g.
+//
+//	ParseWindow("0 2 * * SAT", 4*time.Hour, time.UTC)
+//
+// for the four hours after 02:00 UTC each Saturday.
+func ParseWindow(spec string, d time.Duration, loc *time.Location) (Window, error) {
+	if d <= 0 {
+		return Window{}, fmt.Errorf("failed to parse window %q: invalid duration %v", spec, d)
+	}
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		return Window{}, err
+	}
+	return Window{Schedule: s, Duration: d, Location: loc}, nil
+}
+
+func (w Window) location() *time.Location {
+	if w.Location == nil {
+		return time.Local
+	}
+	return w.Location
+}
+
+// Open reports whether the window is open at t and returns the time it
+// closes then.
+func (w Window) Open(t time.Time) (time.Time, bool) {
+	// an opening closing after t is after t minus the duration, the
+	// window is open if the first of them is not after t
+	start := w.Schedule.Next(t.In(w.location()).Add(-w.Duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	return start.Add(w.Duration), true
+}
+
+// Next returns the times the window opens and closes next, the current
+// opening if it is open at t. Both are zero if the schedule never matches.
+func (w Window) Next(t time.Time) (time.Time, time.Time) {
+	if end, ok := w.Open(t); ok {
+		return end.Add(-w.Duration), end
+	}
+	start := w.Schedule.Next(t.In(w.location()))
+	if start.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+	return start, start.Add(w.Duration)
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package sdnotify reports the health of the connection of a Manager to
+// systemd with the sd_notify protocol, so that services built on the
+// bindings signal their readiness and are restarted by the watchdog of
+// systemd once the connection to the controller is lost for good.
+package sdnotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+)
+
+// ErrConnectionLost is returned by Run when the connection to the
+// controller is lost for good.
+var ErrConnectionLost = errors.New("connection to the BlueChi controller lost")
+
+// Notify sends state to the service manager, e.g. "READY=1", over the
+// socket named by $NOTIFY_SOCKET. It reports false without an error if the
+// process was not started by systemd with a notify socket.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// a leading @ names an abstract socket, which net handles as well
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket %s: %w", socket, err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to notify service manager: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout systemd set for the process
+// with WatchdogSec=, zero if the watchdog is not enabled for it. Keep-alive
+// notifications are due within the timeout.
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		// the watchdog is meant for another process, e.g. the parent
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(usec, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC %q", usec)
+	}
+	return time.Duration(n) * time.Microsecond, nil
+}
+
+// Option configures Run.
+type Option func(*options)
+
+type options struct {
+	maxOutage time.Duration
+}
+
+// WithMaxOutage makes Run consider the connection lost for good once it has
+// not been connected for d, e.g. while auto-reconnect keeps trying to reach
+// a controller which is gone. By default it is only considered lost when
+// the Manager gives up reconnecting.
+func WithMaxOutage(d time.Duration) Option {
+	return func(o *options) {
+		o.maxOutage = d
+	}
+}
+
+// Run reports the connection state of m to systemd until ctx is done:
+// READY=1 once it is connected the first time, the state as STATUS= on each
+// change, and WATCHDOG=1 keep-alive notifications at half the watchdog
+// timeout while the connection is up or expected to come back. Once the
+// connection is lost for good, it sends WATCHDOG=trigger, so that systemd
+// restarts the service according to its Restart= setting, and returns
+// ErrConnectionLost. When ctx is done, it sends STOPPING=1 and returns nil,
+// so ctx must be canceled before m is closed on a regular shutdown. Without
+// a notify socket, Run only watches the connection.
+func Run(ctx context.Context, m manager.ManagerAPI, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	interval, err := WatchdogInterval()
+	if err != nil {
+		return err
+	}
+	var keepAlive <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	events := m.ConnectionEvents()
+	ready := false
+	// outage fires once the connection has been down for too long
+	var outage <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			_, err := Notify("STOPPING=1")
+			return err
+		case <-keepAlive:
+			if _, err := Notify("WATCHDOG=1"); err != nil {
+				return err
+			}
+		case <-outage:
+			return lost(interval, fmt.Sprintf("not connected for %s", o.maxOutage))
+		case state, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					_, err := Notify("STOPPING=1")
+					return err
+				}
+				return lost(interval, "gave up reconnecting")
+			}
+			status := "STATUS=" + state.String()
+			if state == manager.Connected {
+				outage = nil
+				if !ready {
+					status = "READY=1\n" + status
+					ready = true
+				}
+			} else if outage == nil && o.maxOutage > 0 {
+				outage = time.After(o.maxOutage)
+			}
+			if _, err := Notify(status); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// lost tells systemd that the connection is lost for good for reason and
+// returns ErrConnectionLost.
+func lost(interval time.Duration, reason string) error {
+	state := "STATUS=" + ErrConnectionLost.Error() + ": " + reason
+	if interval > 0 {
+		state += "\nWATCHDOG=trigger"
+	}
+	if _, err := Notify(state); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrConnectionLost, reason)
+}
@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package sdnotify_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/sdnotify"
+)
+
+// listen serves a notify socket for the test and returns the channel of
+// the notifications received on it.
+func listen(t *testing.T) <-chan string {
+	// the path of a unix socket is limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "sdnotify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	states := make(chan string, 64)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			states <- string(buf[:n])
+		}
+	}()
+	return states
+}
+
+// await returns the first notification containing want.
+func await(t *testing.T, states <-chan string, want string) string {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state := <-states:
+			if strings.Contains(state, want) {
+				return state
+			}
+		case <-timeout:
+			t.Fatalf("no notification %q", want)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := sdnotify.Notify("READY=1"); sent || err != nil {
+		t.Fatalf("expected nothing to be sent without a notify socket, got %v (%v)", sent, err)
+	}
+
+	states := listen(t)
+	if sent, err := sdnotify.Notify("READY=1"); !sent || err != nil {
+		t.Fatalf("expected the notification to be sent, got %v (%v)", sent, err)
+	}
+	await(t, states, "READY=1")
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "2000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if d, err := sdnotify.WatchdogInterval(); err != nil || d != 2*time.Second {
+		t.Fatalf("expected 2s, got %s (%v)", d, err)
+	}
+	t.Setenv("WATCHDOG_PID", "1")
+	if d, err := sdnotify.WatchdogInterval(); err != nil || d != 0 {
+		t.Fatalf("expected the watchdog of another process to be ignored, got %s (%v)", d, err)
+	}
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "soon")
+	if _, err := sdnotify.WatchdogInterval(); err == nil {
+		t.Fatal("expected an invalid timeout to fail")
+	}
+}
+
+func TestRun(t *testing.T) {
+	states := listen(t)
+	t.Setenv("WATCHDOG_USEC", "100000")
+	t.Setenv("WATCHDOG_PID", "")
+	f := managertest.New()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sdnotify.Run(context.Background(), f, sdnotify.WithMaxOutage(100*time.Millisecond))
+	}()
+	if state := await(t, states, "READY=1"); !strings.Contains(state, "STATUS=connected") {
+		t.Fatalf("unexpected notification %q", state)
+	}
+	await(t, states, "WATCHDOG=1")
+
+	// a short outage is tolerated
+	f.SetState(manager.Reconnecting)
+	await(t, states, "STATUS=reconnecting")
+	f.SetState(manager.Connected)
+	await(t, states, "STATUS=connected")
+	time.Sleep(150 * time.Millisecond)
+
+	f.SetState(manager.Reconnecting)
+	await(t, states, "WATCHDOG=trigger")
+	if err := <-done; !errors.Is(err, sdnotify.ErrConnectionLost) {
+		t.Fatalf("expected %v, got %v", sdnotify.ErrConnectionLost, err)
+	}
+}
+
+func TestRunStopping(t *testing.T) {
+	states := listen(t)
+	t.Setenv("WATCHDOG_USEC", "")
+	f := managertest.New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sdnotify.Run(ctx, f) }()
+	await(t, states, "READY=1")
+	cancel()
+	await(t, states, "STOPPING=1")
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// closing the Manager first means it gave up on the connection
+	go func() { done <- sdnotify.Run(context.Background(), f) }()
+	await(t, states, "STATUS=connected")
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, sdnotify.ErrConnectionLost) {
+		t.Fatalf("expected %v, got %v", sdnotify.ErrConnectionLost, err)
+	}
+}
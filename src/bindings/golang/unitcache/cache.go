@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package unitcache keeps the last known state of the units of all nodes in
+// memory, e.g. for user interfaces which cannot afford a D-Bus round trip
+// per render. The cache is populated by ListUnits and kept fresh by the
+// events of a monitor.
+package unitcache
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/common"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/monitor"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/node"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+// Cache holds the units of all online nodes. Its methods are safe for
+// concurrent use by multiple goroutines.
+type Cache struct {
+	api    manager.ManagerAPI
+	mon    manager.MonitorAPI
+	nodes  <-chan manager.NodeConnectionStateChanged
+	states <-chan manager.ConnState
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error
+
+	mu    sync.RWMutex
+	units map[string]map[string]node.UnitInfo
+	err   error
+}
+
+// New creates a monitor for all units on m, lists the units of all nodes
+// and returns the cache holding them. The cache is kept up to date until
+// ctx is done or Close is called. The units of a node are listed again when
+// it comes online, and those of all nodes after a reconnect of m.
+func New(ctx context.Context, m manager.ManagerAPI) (*Cache, error) {
+	mon, err := m.CreateMonitor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// subscribe before listing so that no change is missed, the events
+	// queue up until the listing has been applied
+	if _, err := mon.Subscribe(ctx, common.SYMBOL_WILDCARD, common.SYMBOL_WILDCARD); err != nil {
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	nodes, err := m.SubscribeNodeConnectionStateChanged(watchCtx)
+	if err != nil {
+		cancel()
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+	units, err := m.ListUnits(ctx)
+	if err != nil {
+		cancel()
+		_ = mon.Close(ctx)
+		return nil, err
+	}
+
+	c := &Cache{
+		api:    m,
+		mon:    mon,
+		nodes:  nodes,
+		states: m.ConnectionEvents(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+		units:  make(map[string]map[string]node.UnitInfo),
+	}
+	c.replace(units, nil)
+	go c.run(watchCtx)
+	return c, nil
+}
+
+// Snapshot returns a copy of the cached units keyed by node name, each
+// sorted by unit name. Use All to iterate over the nodes in a stable order.
+func (c *Cache) Snapshot() map[string][]node.UnitInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshot := make(map[string][]node.UnitInfo, len(c.units))
+	for name, units := range c.units {
+		list := make([]node.UnitInfo, 0, len(units))
+		for _, u := range units {
+			list = append(list, u)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		snapshot[name] = list
+	}
+	return snapshot
+}
+
+// All returns an iterator over a Snapshot taken when iterating, yielding
+// the name of the node and the unit ordered by node name and then unit
+// name, so that e.g. diffs of the output of two runs only show the changed
+// units.
+func (c *Cache) All() iter.Seq2[string, node.UnitInfo] {
+	return func(yield func(string, node.UnitInfo) bool) {
+		snapshot := c.Snapshot()
+		for _, name := range slices.Sorted(maps.Keys(snapshot)) {
+			for _, u := range snapshot[name] {
+				if !yield(name, u) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Unit returns the cached state of a unit on a node.
+func (c *Cache) Unit(nodeName string, unit string) (node.UnitInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	u, ok := c.units[nodeName][unit]
+	return u, ok
+}
+
+// Err returns the error of the last attempt to list units again, nil if it
+// succeeded. The cache keeps the previous units of the concerned nodes
+// meanwhile, which may be stale.
+func (c *Cache) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.err
+}
+
+// Close stops updating the cache and closes its monitor. The cached units
+// stay available.
+func (c *Cache) Close() error {
+	c.cancel()
+	<-c.done
+	return c.closeErr
+}
+
+func (c *Cache) run(ctx context.Context) {
+	defer close(c.done)
+	defer func() {
+		c.cancel()
+		if err := c.mon.Close(context.Background()); err != nil {
+			c.closeErr = fmt.Errorf("failed to close unit cache: %w", err)
+		}
+	}()
+
+	events, nodes, states := c.mon.Events(), c.nodes, c.states
+	connected := true
+	for events != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			c.apply(e)
+		case e, ok := <-nodes:
+			if !ok {
+				nodes = nil
+				continue
+			}
+			if e.NewState.IsOnline() {
+				c.refresh(ctx, e.Node)
+			} else {
+				c.drop(e.Node)
+			}
+		case state, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			// changes were missed while disconnected
+			if state == manager.Connected && !connected {
+				units, err := c.api.ListUnits(ctx)
+				c.replace(units, err)
+			}
+			connected = state == manager.Connected
+		}
+	}
+}
+
+// replace replaces all units by the listed ones unless listing failed.
+func (c *Cache) replace(units map[string][]node.UnitInfo, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.err = err
+	if err != nil {
+		return
+	}
+	c.units = make(map[string]map[string]node.UnitInfo, len(units))
+	for name, list := range units {
+		c.setLocked(name, list)
+	}
+}
+
+// refresh lists the units of a single node again.
+func (c *Cache) refresh(ctx context.Context, nodeName string) {
+	units, err := c.api.ListUnits(ctx, manager.WithNodes(nodeName))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+	if err == nil {
+		c.setLocked(nodeName, units[nodeName])
+	}
+}
+
+func (c *Cache) setLocked(nodeName string, list []node.UnitInfo) {
+	units := make(map[string]node.UnitInfo, len(list))
+	for _, u := range list {
+		units[u.Name] = u
+	}
+	c.units[nodeName] = units
+}
+
+func (c *Cache) drop(nodeName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.units, nodeName)
+}
+
+func (c *Cache) apply(event monitor.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nodeName, unit := event.NodeName(), event.UnitName()
+	units, ok := c.units[nodeName]
+	if _, removed := event.(monitor.UnitRemoved); removed {
+		delete(units, unit)
+		return
+	}
+	if !ok {
+		units = make(map[string]node.UnitInfo)
+		c.units[nodeName] = units
+	}
+	u, ok := units[unit]
+	if !ok {
+		u = node.UnitInfo{Name: unit, LoadState: node.LoadStateLoaded}
+	}
+
+	switch e := event.(type) {
+	case monitor.UnitStateChanged:
+		u.ActiveState, u.SubState = e.ActiveState, e.SubState
+	case monitor.UnitPropertiesChanged:
+		if e.Interface != common.SYSTEMD_UNIT_INTERFACE {
+			return
+		}
+		updateProperty(&u.ActiveState, e.Properties, "ActiveState")
+		updateProperty(&u.SubState, e.Properties, "SubState")
+		updateProperty(&u.LoadState, e.Properties, "LoadState")
+		updateProperty(&u.Description, e.Properties, "Description")
+	case monitor.UnitNew:
+	default:
+		return
+	}
+	units[unit] = u
+}
+
+func updateProperty[T ~string](field *T, props map[string]dbus.Variant, name string) {
+	v, ok := props[name]
+	if !ok {
+		return
+	}
+	if s, err := variant.String(v); err == nil {
+		*field = T(s)
+	}
+}
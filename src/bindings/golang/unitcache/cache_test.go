@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package unitcache_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/manager/managertest"
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/unitcache"
+)
+
+// eventually fails the test unless cond becomes true within a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("a.service", managertest.ActiveStateInactive, managertest.SubStateDead)
+	f.AddNode("n2").AddUnit("b.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+
+	c, err := unitcache.New(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := c.Snapshot()
+	if len(snapshot) != 2 || len(snapshot["n1"]) != 1 || snapshot["n1"][0].ActiveState != managertest.ActiveStateInactive {
+		t.Fatalf("unexpected snapshot %v", snapshot)
+	}
+	var listed []string
+	for name, u := range c.All() {
+		listed = append(listed, name+"/"+u.Name)
+	}
+	if want := []string{"n1/a.service", "n2/b.service"}; !slices.Equal(listed, want) {
+		t.Fatalf("expected units %v, got %v", want, listed)
+	}
+
+	f.Node("n1").SetUnitState("a.service", managertest.ActiveStateFailed, managertest.SubStateFailed)
+	eventually(t, "a.service to fail", func() bool {
+		u, ok := c.Unit("n1", "a.service")
+		return ok && u.ActiveState == managertest.ActiveStateFailed
+	})
+
+	f.Node("n1").AddUnit("c.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+	eventually(t, "c.service to be added", func() bool {
+		_, ok := c.Unit("n1", "c.service")
+		return ok
+	})
+	f.Node("n1").RemoveUnit("a.service")
+	eventually(t, "a.service to be removed", func() bool {
+		_, ok := c.Unit("n1", "a.service")
+		return !ok
+	})
+
+	f.SetNodeStatus("n2", managertest.NodeOffline)
+	eventually(t, "n2 to be dropped", func() bool {
+		_, ok := c.Snapshot()["n2"]
+		return !ok
+	})
+	f.SetNodeStatus("n2", managertest.NodeOnline)
+	eventually(t, "n2 to be listed again", func() bool {
+		_, ok := c.Unit("n2", "b.service")
+		return ok
+	})
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Unit("n1", "c.service"); !ok {
+		t.Fatal("expected the units to stay available after Close")
+	}
+}
+
+func TestCacheReconnect(t *testing.T) {
+	ctx := context.Background()
+	f := managertest.New()
+	f.AddNode("n1").AddUnit("a.service", managertest.ActiveStateActive, managertest.SubStateRunning)
+
+	c, err := unitcache.New(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the units of all nodes are listed again after a reconnect
+	injected := errors.New("injected")
+	f.FailCall("ListUnits", injected)
+	f.SetState(manager.Disconnected)
+	f.SetState(manager.Connected)
+	eventually(t, "listing to fail", func() bool { return errors.Is(c.Err(), injected) })
+
+	f.FailCall("ListUnits", nil)
+	f.SetState(manager.Disconnected)
+	f.SetState(manager.Connected)
+	eventually(t, "listing to succeed", func() bool { return c.Err() == nil })
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+// Package variant converts dbus.Variant values, as returned for properties
+// of BlueChi and systemd objects, to Go types.
+package variant
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrUnexpectedType is returned, wrapped, if the value of a variant does not
+// have the requested type.
+var ErrUnexpectedType = errors.New("unexpected variant type")
+
+func typeError(v dbus.Variant, want string) error {
+	return fmt.Errorf("%w: cannot convert %s to %s", ErrUnexpectedType, v.Signature(), want)
+}
+
+// String returns the value of a variant of D-Bus type s, o or g.
+func String(v dbus.Variant) (string, error) {
+	switch value := v.Value().(type) {
+	case string:
+		return value, nil
+	case dbus.ObjectPath:
+		return string(value), nil
+	case dbus.Signature:
+		return value.String(), nil
+	}
+	return "", typeError(v, "string")
+}
+
+// Bool returns the value of a variant of D-Bus type b.
+func Bool(v dbus.Variant) (bool, error) {
+	if value, ok := v.Value().(bool); ok {
+		return value, nil
+	}
+	return false, typeError(v, "bool")
+}
+
+// Uint64 returns the value of a variant of any unsigned D-Bus integer type,
+// i.e. y, q, u or t.
+func Uint64(v dbus.Variant) (uint64, error) {
+	switch value := v.Value().(type) {
+	case byte:
+		return uint64(value), nil
+	case uint16:
+		return uint64(value), nil
+	case uint32:
+		return uint64(value), nil
+	case uint64:
+		return value, nil
+	}
+	return 0, typeError(v, "uint64")
+}
+
+// Int64 returns the value of a variant of any signed D-Bus integer type,
+// i.e. n, i or x, or of an unsigned type whose values fit, i.e. y, q or u.
+func Int64(v dbus.Variant) (int64, error) {
+	switch value := v.Value().(type) {
+	case int16:
+		return int64(value), nil
+	case int32:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case byte:
+		return int64(value), nil
+	case uint16:
+		return int64(value), nil
+	case uint32:
+		return int64(value), nil
+	}
+	return 0, typeError(v, "int64")
+}
+
+// Milliseconds returns the value of a variant of any D-Bus integer type as a
+// number of milliseconds, e.g. of the heartbeat settings of BlueChi.
+func Milliseconds(v dbus.Variant) (time.Duration, error) {
+	const limit = math.MaxInt64 / int64(time.Millisecond)
+	ms, err := Int64(v)
+	if err != nil {
+		var u uint64
+		if u, err = Uint64(v); err != nil || u > uint64(limit) {
+			return 0, typeError(v, "milliseconds")
+		}
+		ms = int64(u)
+	}
+	if ms > limit || ms < -limit {
+		return 0, typeError(v, "milliseconds")
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Float64 returns the value of a variant of D-Bus type d.
+func Float64(v dbus.Variant) (float64, error) {
+	if value, ok := v.Value().(float64); ok {
+		return value, nil
+	}
+	return 0, typeError(v, "float64")
+}
+
+// Strings returns the value of a variant of D-Bus type as or ao.
+func Strings(v dbus.Variant) ([]string, error) {
+	switch value := v.Value().(type) {
+	case []string:
+		return value, nil
+	case []dbus.ObjectPath:
+		strs := make([]string, 0, len(value))
+		for _, path := range value {
+			strs = append(strs, string(path))
+		}
+		return strs, nil
+	}
+	return nil, typeError(v, "[]string")
+}
+
+// Map returns the value of a variant of D-Bus type a{sv}, e.g. a set of
+// properties.
+func Map(v dbus.Variant) (map[string]dbus.Variant, error) {
+	if value, ok := v.Value().(map[string]dbus.Variant); ok {
+		return value, nil
+	}
+	return nil, typeError(v, "map[string]dbus.Variant")
+}
+
+// StringMap returns the value of a variant of D-Bus type a{ss}.
+func StringMap(v dbus.Variant) (map[string]string, error) {
+	if value, ok := v.Value().(map[string]string); ok {
+		return value, nil
+	}
+	return nil, typeError(v, "map[string]string")
+}
+
+// Values unwraps all variants of a set of properties into their Go values.
+func Values(props map[string]dbus.Variant) map[string]interface{} {
+	values := make(map[string]interface{}, len(props))
+	for name, v := range props {
+		values[name] = v.Value()
+	}
+	return values
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: LGPL-2.1-or-later
+
+package variant_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/eclipse-bluechi/bluechi/src/bindings/golang/variant"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"active", "active"},
+		{dbus.ObjectPath("/org/eclipse/bluechi"), "/org/eclipse/bluechi"},
+		{dbus.Signature{}, ""},
+	}
+	for _, tt := range tests {
+		got, err := variant.String(dbus.MakeVariant(tt.in))
+		if err != nil || got != tt.want {
+			t.Errorf("variant.String(%#v) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := variant.String(dbus.MakeVariant(uint32(1))); !errors.Is(err, variant.ErrUnexpectedType) {
+		t.Errorf("expected ErrUnexpectedType, got %v", err)
+	}
+}
+
+func TestIntegers(t *testing.T) {
+	unsigned := []interface{}{byte(7), uint16(7), uint32(7), uint64(7)}
+	for _, in := range unsigned {
+		got, err := variant.Uint64(dbus.MakeVariant(in))
+		if err != nil || got != 7 {
+			t.Errorf("variant.Uint64(%T) = %d, %v", in, got, err)
+		}
+	}
+	if _, err := variant.Uint64(dbus.MakeVariant(int32(-1))); !errors.Is(err, variant.ErrUnexpectedType) {
+		t.Errorf("expected ErrUnexpectedType for a signed value, got %v", err)
+	}
+
+	signed := []interface{}{int16(-7), int32(-7), int64(-7)}
+	for _, in := range signed {
+		got, err := variant.Int64(dbus.MakeVariant(in))
+		if err != nil || got != -7 {
+			t.Errorf("variant.Int64(%T) = %d, %v", in, got, err)
+		}
+	}
+	if got, err := variant.Int64(dbus.MakeVariant(uint32(7))); err != nil || got != 7 {
+		t.Errorf("variant.Int64(uint32) = %d, %v", got, err)
+	}
+	if _, err := variant.Int64(dbus.MakeVariant(uint64(7))); !errors.Is(err, variant.ErrUnexpectedType) {
+		t.Errorf("expected ErrUnexpectedType for uint64, got %v", err)
+	}
+
+	for _, in := range []interface{}{int32(2000), int64(2000), uint64(2000)} {
+		if got, err := variant.Milliseconds(dbus.MakeVariant(in)); err != nil || got != 2*time.Second {
+			t.Errorf("variant.Milliseconds(%T) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := variant.Milliseconds(dbus.MakeVariant(uint64(math.MaxUint64))); !errors.Is(err, variant.ErrUnexpectedType) {
+		t.Errorf("expected ErrUnexpectedType for an overflowing value, got %v", err)
+	}
+}
+
+func TestBoolAndFloat(t *testing.T) {
+	if got, err := variant.Bool(dbus.MakeVariant(true)); err != nil || !got {
+		t.Errorf("variant.Bool(true) = %v, %v", got, err)
+	}
+	if got, err := variant.Float64(dbus.MakeVariant(1.5)); err != nil || got != 1.5 {
+		t.Errorf("variant.Float64(1.5) = %v, %v", got, err)
+	}
+	if _, err := variant.Bool(dbus.MakeVariant("true")); !errors.Is(err, variant.ErrUnexpectedType) {
+		t.Errorf("expected ErrUnexpectedType, got %v", err)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	got, err := variant.Strings(dbus.MakeVariant([]string{"a.service", "b.service"}))
+	if err != nil || !reflect.DeepEqual(got, []string{"a.service", "b.service"}) {
+		t.Errorf("variant.Strings(as) = %v, %v", got, err)
+	}
+	got, err = variant.Strings(dbus.MakeVariant([]dbus.ObjectPath{"/a", "/b"}))
+	if err != nil || !reflect.DeepEqual(got, []string{"/a", "/b"}) {
+		t.Errorf("variant.Strings(ao) = %v, %v", got, err)
+	}
+}
+
+func TestMaps(t *testing.T) {
+	props := map[string]dbus.Variant{"Name": dbus.MakeVariant("node_a"), "Id": dbus.MakeVariant(uint32(3))}
+	got, err := variant.Map(dbus.MakeVariant(props))
+	if err != nil || len(got) != 2 {
+		t.Fatalf("variant.Map(a{sv}) = %v, %v", got, err)
+	}
+	if values := variant.Values(got); values["Name"] != "node_a" || values["Id"] != uint32(3) {
+		t.Errorf("Values = %v", values)
+	}
+
+	env, err := variant.StringMap(dbus.MakeVariant(map[string]string{"A": "1"}))
+	if err != nil || env["A"] != "1" {
+		t.Errorf("variant.StringMap(a{ss}) = %v, %v", env, err)
+	}
+}